@@ -17,7 +17,7 @@ import (
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: gendocs <markdown|man|completions>")
+		fmt.Println("Usage: gendocs <markdown|man|completions|packaging [version]>")
 		os.Exit(1)
 	}
 
@@ -30,6 +30,12 @@ func main() {
 		generateMan(cmd)
 	case "completions":
 		generateCompletions(cmd)
+	case "packaging":
+		version := cli.Version
+		if len(os.Args) > 2 {
+			version = os.Args[2]
+		}
+		generatePackaging(cmd, version)
 	default:
 		fmt.Printf("Unknown format: %s\n", os.Args[1])
 		os.Exit(1)
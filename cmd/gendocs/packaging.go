@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	manDir         = "out/man"
+	completionsDir = "out/completions"
+	packagingDir   = "out/packaging"
+	homepage       = "https://github.com/bolasblack/alcatraz"
+)
+
+// completionAsset describes where a generated completion script is installed.
+type completionAsset struct {
+	File     string // file name in out/completions
+	BrewFunc string // Homebrew install helper
+	BrewName string // installed name under Homebrew
+	NixDir   string // directory under $out/share
+	NixName  string // installed name under Nix
+}
+
+// completionAssets mirrors what generateCompletions writes.
+var completionAssets = []completionAsset{
+	{File: "alca.bash", BrewFunc: "bash_completion", BrewName: "alca", NixDir: "bash-completion/completions", NixName: "alca"},
+	{File: "alca.zsh", BrewFunc: "zsh_completion", BrewName: "_alca", NixDir: "zsh/site-functions", NixName: "_alca"},
+	{File: "alca.fish", BrewFunc: "fish_completion", BrewName: "alca.fish", NixDir: "fish/vendor_completions.d", NixName: "alca.fish"},
+}
+
+// brewPlatform is one release archive referenced by the Homebrew formula.
+type brewPlatform struct {
+	OS, Arch string
+	Guard    string // Ruby condition selecting the CPU
+}
+
+var brewPlatforms = []brewPlatform{
+	{"darwin", "amd64", "Hardware::CPU.intel?"},
+	{"darwin", "arm64", "Hardware::CPU.arm?"},
+	{"linux", "amd64", "Hardware::CPU.intel? && Hardware::CPU.is_64_bit?"},
+	{"linux", "arm64", "Hardware::CPU.arm? && Hardware::CPU.is_64_bit?"},
+}
+
+// packagingData is the input of the packaging templates.
+type packagingData struct {
+	Version     string
+	Description string
+	Homepage    string
+	ManPages    []string
+	Completions []completionAsset
+	Platforms   []brewPlatform
+}
+
+// Placeholder checksums are left for the release job to fill in, since the
+// archives do not exist when the formula is generated.
+var brewTemplate = template.Must(template.New("brew").Funcs(template.FuncMap{
+	"upper": strings.ToUpper,
+	"list":  func(items ...string) []string { return items },
+}).Parse(`# typed: false
+# frozen_string_literal: true
+
+# This file was generated by 'gendocs packaging'. DO NOT EDIT.
+# Replace the @SHA256_*@ placeholders with the release archive checksums.
+class Alca < Formula
+  desc "{{.Description}}"
+  homepage "{{.Homepage}}"
+  version "{{.Version}}"
+  license "MIT"
+{{range $os := list "darwin" "linux"}}
+  on_{{if eq $os "darwin"}}macos{{else}}linux{{end}} do
+{{- range $.Platforms}}{{if eq .OS $os}}
+    if {{.Guard}}
+      url "{{$.Homepage}}/releases/download/v{{$.Version}}/alcatraz_{{$.Version}}_{{.OS}}_{{.Arch}}.tar.gz"
+      sha256 "@SHA256_{{upper .OS}}_{{upper .Arch}}@"
+
+      define_method(:install) do
+        bin.install "alca"
+{{- range $.ManPages}}
+        man1.install "man/man1/{{.}}"
+{{- end}}
+{{- range $.Completions}}
+        {{.BrewFunc}}.install "completions/{{.File}}" => "{{.BrewName}}"
+{{- end}}
+      end
+    end
+{{- end}}{{end}}
+  end
+{{end}}
+  test do
+    system "#{bin}/alca", "--version"
+  end
+end
+`))
+
+var nixTemplate = template.Must(template.New("nix").Parse(`# This file was generated by 'gendocs packaging'. DO NOT EDIT.
+#
+# Usage from flake.nix:
+#   packaging = import ./out/packaging/alca.nix;
+#   installPhase = ''... ${packaging.installDocs} ...'';
+#   apps.default = packaging.app alca;
+{
+  version = "{{.Version}}";
+
+  # Installs the man pages and shell completions generated in out/.
+  installDocs = ''
+    mkdir -p $out/share/man/man1
+{{- range .ManPages}}
+    cp out/man/{{.}} $out/share/man/man1/{{.}}
+{{- end}}
+{{- range .Completions}}
+    mkdir -p $out/share/{{.NixDir}}
+    cp out/completions/{{.File}} $out/share/{{.NixDir}}/{{.NixName}}
+{{- end}}
+  '';
+
+  app = alca: {
+    type = "app";
+    program = "${alca}/bin/alca";
+    meta = {
+      description = "{{.Description}}";
+      homepage = "{{.Homepage}}";
+    };
+  };
+}
+`))
+
+// generatePackaging writes a Homebrew formula template and a Nix app
+// definition listing the man pages and completions found in out/, so install
+// metadata always matches the generated files. Run after the man and
+// completions modes.
+func generatePackaging(cmd *cobra.Command, version string) {
+	manPages, err := listManPages()
+	if err != nil {
+		log.Fatalf("Failed to list man pages: %v", err)
+	}
+	if len(manPages) == 0 {
+		log.Fatalf("No man pages in %s/, run 'gendocs man' first", manDir)
+	}
+	for _, c := range completionAssets {
+		if _, err := os.Stat(filepath.Join(completionsDir, c.File)); err != nil {
+			log.Fatalf("Missing %s/%s, run 'gendocs completions' first", completionsDir, c.File)
+		}
+	}
+
+	data := packagingData{
+		Version:     strings.TrimPrefix(version, "v"),
+		Description: packageDescription(cmd),
+		Homepage:    homepage,
+		ManPages:    manPages,
+		Completions: completionAssets,
+		Platforms:   brewPlatforms,
+	}
+
+	if err := os.MkdirAll(packagingDir, 0755); err != nil {
+		log.Fatalf("Failed to create directory: %v", err)
+	}
+	writeTemplate(filepath.Join(packagingDir, "alca.rb"), brewTemplate, data)
+	writeTemplate(filepath.Join(packagingDir, "alca.nix"), nixTemplate, data)
+
+	fmt.Printf("Generated packaging metadata in %s/\n", packagingDir)
+}
+
+// listManPages returns the sorted man page file names in out/man.
+func listManPages() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(manDir, "*.1"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(paths))
+	for _, p := range paths {
+		names = append(names, filepath.Base(p))
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// packageDescription derives the package description from the root
+// command, dropping the "Alcatraz - " product prefix.
+func packageDescription(cmd *cobra.Command) string {
+	_, desc, ok := strings.Cut(cmd.Short, " - ")
+	if !ok {
+		desc = cmd.Short
+	}
+	return desc
+}
+
+func writeTemplate(path string, tmpl *template.Template, data packagingData) {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", path, err)
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		_ = f.Close()
+		log.Fatalf("Failed to generate %s: %v", path, err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("Failed to close %s: %v", path, err)
+	}
+}
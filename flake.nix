@@ -102,7 +102,7 @@
           src = ./.;
 
           # First build with empty hash to get the correct one
-          vendorHash = "sha256-BqcdENlkvx6l0IBlHi7EZhDnTj9om0sHJbgvtPMViDk=";
+          vendorHash = "sha256-SSGl6Ed1Av6OcsX4oNbLvXvQbOUkSod38e3GAcAaYPk=";
 
           # Disable default build, use Makefile instead
           buildPhase = ''
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt CONTAINER",
+	Short: "Manage an existing container with alca",
+	Long: `Take over a container started outside alca, e.g. by a docker run script,
+as the project container of the current directory.
+
+alca reads the container's image, working directory, environment, bind
+mounts, published ports and resource limits, writes them to ` + sandbox.ConfigFilename + `,
+and records the container in .alca/state.json. From then on 'alca up',
+'enter', 'run', 'status' and 'down' manage it like any project container.
+
+The container is not recreated, so nothing inside it is lost. It keeps the
+options it was started with until a config change or 'alca rebuild'
+recreates it; only then do alca's creation-time defaults apply (capability
+drops, and the project labels 'alca list' and 'alca cleanup' look for).
+
+The bind mount of the current directory, if any, becomes the workdir; other
+bind mounts become mounts. Named volumes and tmpfs mounts are not carried
+over. Environment values are copied as literals, secrets included: review
+` + sandbox.ConfigFilename + ` (and 'alca config lint') before committing it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAdopt,
+}
+
+// adoptSkippedEnvs are image and runtime defaults not worth copying into
+// the generated config; alca's own defaults (config.DefaultEnvs) are
+// skipped too.
+var adoptSkippedEnvs = []string{"PATH", "HOSTNAME", "HOME", "container"}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	out := os.Stdout
+
+	cwd, err := getCwd()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIDeps()
+	tfs, env, runtimeEnv := deps.Tfs, deps.Env, deps.RuntimeEnv
+
+	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, &config.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to select runtime: %w", err)
+	}
+
+	if err := adoptContainer(ctx, env, runtimeEnv, rt, cwd, args[0], out, time.Now()); err != nil {
+		return err
+	}
+	return sandbox.CommitWithSudo(ctx, env, tfs, out, "")
+}
+
+// adoptContainer writes the config and state that make containerName the
+// project container of cwd.
+func adoptContainer(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cwd, containerName string, out io.Writer, now time.Time) error {
+	configPath := filepath.Join(cwd, sandbox.ConfigFilename)
+	if _, err := env.Fs.Stat(configPath); err == nil {
+		return fmt.Errorf("%s already exists: adopt into a directory without an alca project", configPath)
+	}
+	if st, err := state.Load(env, cwd); err != nil || st != nil {
+		return fmt.Errorf("%s already exists: adopt into a directory without an alca project", state.StateFilePath(cwd))
+	}
+
+	spec, err := rt.ContainerSpec(ctx, runtimeEnv, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", containerName, err)
+	}
+	if id := spec.Labels[state.LabelProjectID]; id != "" {
+		return fmt.Errorf("container %s is already managed by alca (project %s, %s)",
+			spec.Name, id, sandbox.DisplayOrDefault(spec.Labels[state.LabelProjectPath]))
+	}
+
+	cfg, notes := adoptedConfig(spec, cwd)
+	content, err := cfg.EncodeTOML()
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("# Adopted from container %s on %s; review before committing.\n", spec.Name, now.Format(time.DateOnly))
+	if err := afero.WriteFile(env.Fs, configPath, []byte(header+content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
+	// Record the config as loaded (defaults applied), so the first up
+	// sees no drift and leaves the container alone
+	loaded, _, err := sandbox.LoadConfigFromCwd(ctx, env, cwd)
+	if err != nil {
+		return fmt.Errorf("generated %s does not load: %w", sandbox.ConfigFilename, err)
+	}
+	st := &state.State{
+		SchemaVersion: state.CurrentSchemaVersion,
+		ProjectID:     uuid.New().String(),
+		ContainerName: spec.Name,
+		CreatedAt:     now,
+		Runtime:       rt.Name(),
+	}
+	st.UpdateConfig(loaded)
+	if err := state.Save(env, cwd, st); err != nil {
+		return err
+	}
+
+	util.ProgressDone(out, "Adopted container %s into %s\n", spec.Name, cwd)
+	for _, note := range notes {
+		_, _ = fmt.Fprintf(out, "Note: %s\n", note)
+	}
+	if !spec.Running {
+		_, _ = fmt.Fprintln(out, "The container is stopped; 'alca up' starts it.")
+	}
+	return nil
+}
+
+// adoptedConfig infers a config from spec, with notes on what did not
+// carry over.
+func adoptedConfig(spec runtime.ContainerSpec, cwd string) (config.Config, []string) {
+	var notes []string
+	cfg := config.Config{
+		Image:     spec.Image,
+		Resources: config.Resources{Memory: spec.Memory, CPUs: spec.CPUs},
+		Network:   config.Network{Ports: spec.Ports},
+	}
+
+	workdirMounted := false
+	for _, m := range spec.Binds {
+		if filepath.Clean(m.Source) == filepath.Clean(cwd) && !workdirMounted {
+			cfg.Workdir = m.Target
+			workdirMounted = true
+			if m.Readonly {
+				notes = append(notes, fmt.Sprintf("%s is mounted read-only; alca mounts the workdir read-write when the container is recreated", cwd))
+			}
+			continue
+		}
+		cfg.Mounts = append(cfg.Mounts, m)
+	}
+	if !workdirMounted {
+		cfg.Workdir = spec.WorkingDir
+		notes = append(notes, fmt.Sprintf("%s is not mounted in the container; it is mounted at workdir %s once the container is recreated",
+			cwd, sandbox.DisplayOrDefault(cfg.Workdir)))
+	}
+
+	defaults := config.DefaultEnvs()
+	for _, kv := range spec.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		if _, ok := defaults[key]; ok || key == "" || slices.Contains(adoptSkippedEnvs, key) {
+			continue
+		}
+		ev := config.EnvValue{Value: value}
+		if err := ev.Validate(); err != nil {
+			notes = append(notes, fmt.Sprintf("env %s skipped: its value contains ${ and would be read as a host variable reference", key))
+			continue
+		}
+		if cfg.Envs == nil {
+			cfg.Envs = map[string]config.EnvValue{}
+		}
+		cfg.Envs[key] = ev
+	}
+	return cfg, notes
+}
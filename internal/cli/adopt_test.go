@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+func TestAdopt_ThenUpKeepsContainer(t *testing.T) {
+	fs := afero.NewOsFs()
+	fake, dir := setupFakeProject(t, "")
+	if err := fs.Remove(filepath.Join(dir, sandbox.ConfigFilename)); err != nil {
+		t.Fatal(err)
+	}
+	fake.AddContainer(runtime.FakeContainer{
+		Name:  "legacy",
+		Image: "node:20",
+		State: runtime.StateRunning,
+		Env:   []string{"PATH=/usr/bin", "NODE_ENV=development", "TEMPLATE=${NAME}x"},
+		Binds: []config.MountConfig{{Source: dir, Target: "/app"}, {Source: "/data", Target: "/data", Readonly: true}},
+		Ports: map[int]int{3000: 3000},
+	})
+	id := fake.Container("legacy").ID
+
+	adoptCmd.SetContext(t.Context())
+	if err := runAdopt(adoptCmd, []string{"legacy"}); err != nil {
+		t.Fatalf("adopt: %v", err)
+	}
+
+	content, err := afero.ReadFile(fs, filepath.Join(dir, sandbox.ConfigFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"image = 'node:20'", "workdir = '/app'", "NODE_ENV", "'/data:/data:ro'"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("generated config lacks %q:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"PATH", "TEMPLATE"} {
+		if strings.Contains(string(content), unwanted) {
+			t.Errorf("generated config should skip %s:\n%s", unwanted, content)
+		}
+	}
+
+	st := loadFakeState(t, dir)
+	if st.ContainerName != "legacy" || st.ProjectID == "" {
+		t.Errorf("state = %+v", st)
+	}
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if slices.Contains(fake.Calls, "Down legacy") {
+		t.Errorf("up should not recreate the adopted container, calls: %v", fake.Calls)
+	}
+	if c := fake.Container("legacy"); c == nil || c.ID != id {
+		t.Errorf("adopted container replaced: %+v", c)
+	}
+
+	if err := runAdopt(adoptCmd, []string{"legacy"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("second adopt = %v, want an already exists error", err)
+	}
+}
+
+func TestAdopt_RefusesManagedContainer(t *testing.T) {
+	fs := afero.NewOsFs()
+	fake, dir := setupFakeProject(t, "")
+	if err := fs.Remove(filepath.Join(dir, sandbox.ConfigFilename)); err != nil {
+		t.Fatal(err)
+	}
+	fake.AddContainer(runtime.FakeContainer{
+		Name:   "alca-other",
+		Image:  "alpine",
+		Labels: map[string]string{state.LabelProjectID: "abc", state.LabelProjectPath: "/elsewhere"},
+	})
+
+	adoptCmd.SetContext(t.Context())
+	err := runAdopt(adoptCmd, []string{"alca-other"})
+	if err == nil || !strings.Contains(err.Error(), "already managed") {
+		t.Fatalf("adopt = %v, want an already managed error", err)
+	}
+	if _, err := fs.Stat(filepath.Join(dir, sandbox.ConfigFilename)); !os.IsNotExist(err) {
+		t.Errorf("no config should be written, stat = %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Suggest workdir_exclude entries for large generated directories",
+	Long: `Scan the workdir for large generated directories (node_modules, target,
+.venv, build output, tool caches) and suggest workdir_exclude entries for
+them. Excluded directories are not synced by Mutagen, which makes the first
+sync and every rescan much faster; the container keeps its own copy, so
+reinstall dependencies inside it (e.g. npm install).
+
+Directories already excluded are skipped. Names that are also common source
+directories (build, dist, target, venv) are only suggested next to a
+project file that generates them, such as Cargo.toml for target.
+
+With --write, the suggestions are appended to workdir_exclude in .alca.toml.
+The file is re-encoded, so comments are not preserved.`,
+	Args: cobra.NoArgs,
+	RunE: runAnalyze,
+}
+
+func init() {
+	analyzeCmd.Flags().Bool("write", false, "Append the suggestions to workdir_exclude in "+sandbox.ConfigFilename)
+	analyzeCmd.Flags().Int64("min-size", 1, "Only suggest directories of at least this many megabytes")
+}
+
+// generatedDir is a directory name that build tools and package managers
+// generate.
+type generatedDir struct {
+	name string
+	// markers are files next to the directory that show it is generated.
+	// Empty means the name alone does.
+	markers []string
+}
+
+// generatedDirs lists the directories alca analyze looks for.
+var generatedDirs = []generatedDir{
+	{name: "node_modules"},
+	{name: "bower_components"},
+	{name: ".next"},
+	{name: ".nuxt"},
+	{name: ".svelte-kit"},
+	{name: ".turbo"},
+	{name: ".parcel-cache"},
+	{name: ".venv"},
+	{name: "__pycache__"},
+	{name: ".pytest_cache"},
+	{name: ".mypy_cache"},
+	{name: ".ruff_cache"},
+	{name: ".tox"},
+	{name: ".gradle"},
+	{name: ".terraform"},
+	{name: ".dart_tool"},
+	{name: ".zig-cache"},
+	{name: ".direnv"},
+	{name: "target", markers: []string{"Cargo.toml", "pom.xml", "build.sbt"}},
+	{name: "build", markers: []string{"build.gradle", "build.gradle.kts", "CMakeLists.txt", "package.json", "setup.py", "pubspec.yaml"}},
+	{name: "dist", markers: []string{"package.json", "setup.py", "pyproject.toml"}},
+	{name: "venv", markers: []string{"requirements.txt", "pyproject.toml", "setup.py"}},
+	{name: ".build", markers: []string{"Package.swift"}},
+	{name: "Pods", markers: []string{"Podfile"}},
+}
+
+// excludeSuggestion is a suggested workdir_exclude pattern with the size of
+// what it matches.
+type excludeSuggestion struct {
+	Pattern string
+	Size    int64
+	Files   int
+}
+
+// runAnalyze scans the workdir and prints or writes exclude suggestions.
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	write, _ := cmd.Flags().GetBool("write")
+	minSize, _ := cmd.Flags().GetInt64("min-size")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	env := newHostEnv()
+	return analyzeProject(cmd.Context(), env, cwd, write, minSize<<20, os.Stdout)
+}
+
+// analyzeProject suggests workdir_exclude entries for the project in cwd,
+// appending them to the config when write is set.
+func analyzeProject(ctx context.Context, env *util.Env, cwd string, write bool, minSize int64, out io.Writer) error {
+	cfg, configPath, err := sandbox.LoadConfigFromCwd(ctx, env, cwd)
+	if err != nil {
+		return err
+	}
+
+	// Mounts[0] is the workdir mount, with exclude_from patterns resolved
+	workdir := cfg.Mounts[0]
+	root := workdir.Source
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(cwd, root)
+	}
+
+	suggestions, err := scanExcludeSuggestions(env.Fs, root, workdir.Exclude, minSize)
+	if err != nil {
+		return err
+	}
+	if len(suggestions) == 0 {
+		_, _ = fmt.Fprintln(out, "No large generated directories found outside workdir_exclude.")
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "%10s  %8s  %s\n", "SIZE", "FILES", "PATTERN")
+	patterns := make([]any, len(suggestions))
+	for i, s := range suggestions {
+		_, _ = fmt.Fprintf(out, "%10s  %8d  %s\n", formatSize(s.Size), s.Files, s.Pattern)
+		patterns[i] = s.Pattern
+	}
+	_, _ = fmt.Fprintln(out)
+
+	if !write {
+		quoted := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			quoted[i] = fmt.Sprintf("%q", s.Pattern)
+		}
+		_, _ = fmt.Fprintf(out, "Add to workdir_exclude in %s, or run 'alca analyze --write':\n  workdir_exclude = [%s]\n", sandbox.ConfigFilename, strings.Join(quoted, ", "))
+		return nil
+	}
+
+	override := config.Override{Path: []string{"workdir_exclude"}, Value: patterns, Append: true}
+	if err := config.SaveOverrides(env.Fs, configPath, []config.Override{override}); err != nil {
+		return fmt.Errorf("failed to update %s: %w", sandbox.ConfigFilename, err)
+	}
+	_, _ = fmt.Fprintf(out, "Added %d pattern(s) to workdir_exclude in %s. Run 'alca up' to apply them.\n", len(suggestions), sandbox.ConfigFilename)
+
+	// An included file setting workdir_exclude replaces the list written here
+	if updated, _, err := sandbox.LoadConfigFromCwd(ctx, env, cwd); err == nil && !slices.Contains(updated.WorkdirExclude, suggestions[0].Pattern) {
+		_, _ = fmt.Fprintf(out, "Warning: an included file sets workdir_exclude and overrides %s; move the patterns there\n", sandbox.ConfigFilename)
+	}
+	return nil
+}
+
+// scanExcludeSuggestions walks root for generatedDirs not matched by
+// existing and at least minSize bytes. Directories found at any depth by
+// name alone are suggested as one **/<name>/ pattern; marker-checked ones as
+// their anchored path. Largest first.
+func scanExcludeSuggestions(fsys afero.Fs, root string, existing []string, minSize int64) ([]excludeSuggestion, error) {
+	byPattern := make(map[string]*excludeSuggestion)
+	err := afero.Walk(fsys, root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || path == root {
+			return nil
+		}
+		name := info.Name()
+		if name == ".git" || name == ".alca" {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+
+		var pattern string
+		for _, g := range generatedDirs {
+			if g.name != name || !hasMarker(fsys, filepath.Dir(path), g.markers) {
+				continue
+			}
+			if len(g.markers) == 0 {
+				pattern = "**/" + name + "/"
+			} else {
+				pattern = "/" + rel + "/"
+			}
+			break
+		}
+		if pattern == "" {
+			return nil
+		}
+		if !sandbox.IsExcluded(existing, name, rel) {
+			s := byPattern[pattern]
+			if s == nil {
+				s = &excludeSuggestion{Pattern: pattern}
+				byPattern[pattern] = s
+			}
+			size, files := dirUsage(fsys, path)
+			s.Size += size
+			s.Files += files
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+
+	var suggestions []excludeSuggestion
+	for _, s := range byPattern {
+		if s.Size >= minSize {
+			suggestions = append(suggestions, *s)
+		}
+	}
+	slices.SortFunc(suggestions, func(a, b excludeSuggestion) int {
+		if c := cmp.Compare(b.Size, a.Size); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Pattern, b.Pattern)
+	})
+	return suggestions, nil
+}
+
+// hasMarker reports whether dir contains one of markers, or markers is empty.
+func hasMarker(fsys afero.Fs, dir string, markers []string) bool {
+	if len(markers) == 0 {
+		return true
+	}
+	for _, m := range markers {
+		if _, err := fsys.Stat(filepath.Join(dir, m)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dirUsage returns the total size and number of files under dir. Symlinks
+// are counted but not followed.
+func dirUsage(fsys afero.Fs, dir string) (int64, int) {
+	var size int64
+	var files int
+	_ = afero.Walk(fsys, dir, func(_ string, info fs.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+			files++
+		}
+		return nil
+	})
+	return size, files
+}
+
+// formatSize formats a byte count with a binary unit, e.g. 1.5 GB.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func writeAnalyzeFiles(t *testing.T, fs afero.Fs, files map[string]int) {
+	t.Helper()
+	for name, size := range files {
+		if err := afero.WriteFile(fs, name, bytes.Repeat([]byte("x"), size), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestScanExcludeSuggestions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeAnalyzeFiles(t, fs, map[string]int{
+		"/p/node_modules/a/index.js":        300,
+		"/p/web/node_modules/b/index.js":    100,
+		"/p/rust/Cargo.toml":                1,
+		"/p/rust/target/debug/app":          500,
+		"/p/src/build/generator.go":         50, // build without a marker is source
+		"/p/.git/objects/pack":              900,
+		"/p/.venv/lib/site.py":              10,
+		"/p/cache/__pycache__/mod.pyc":      10,
+		"/p/node_modules/.bin/node_modules": 1, // not descended into
+	})
+
+	got, err := scanExcludeSuggestions(fs, "/p", []string{"**/.venv"}, 20)
+	if err != nil {
+		t.Fatalf("scanExcludeSuggestions() error: %v", err)
+	}
+	want := []excludeSuggestion{
+		{Pattern: "/rust/target/", Size: 500, Files: 1},
+		{Pattern: "**/node_modules/", Size: 401, Files: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("scanExcludeSuggestions() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("suggestion %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAnalyzeProject_Write(t *testing.T) {
+	env := &util.Env{Fs: afero.NewMemMapFs()}
+	cwd := "/project"
+	writeAnalyzeFiles(t, env.Fs, map[string]int{"/project/node_modules/a.js": 10})
+	if err := afero.WriteFile(env.Fs, filepath.Join(cwd, sandbox.ConfigFilename), []byte("image = \"alpine\"\nworkdir_exclude = [\".env\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := analyzeProject(context.Background(), env, cwd, false, 0, &out); err != nil {
+		t.Fatalf("analyzeProject() error: %v", err)
+	}
+	if !strings.Contains(out.String(), `workdir_exclude = ["**/node_modules/"]`) {
+		t.Errorf("missing suggestion in output: %q", out.String())
+	}
+
+	out.Reset()
+	if err := analyzeProject(context.Background(), env, cwd, true, 0, &out); err != nil {
+		t.Fatalf("analyzeProject(write) error: %v", err)
+	}
+	cfg, _, err := sandbox.LoadConfigFromCwd(context.Background(), env, cwd)
+	if err != nil {
+		t.Fatalf("loadConfigFromCwd() error: %v", err)
+	}
+	if strings.Join(cfg.WorkdirExclude, ",") != ".env,**/node_modules/" {
+		t.Errorf("WorkdirExclude = %q", cfg.WorkdirExclude)
+	}
+
+	out.Reset()
+	if err := analyzeProject(context.Background(), env, cwd, false, 0, &out); err != nil {
+		t.Fatalf("analyzeProject() error: %v", err)
+	}
+	if !strings.Contains(out.String(), "No large generated directories") {
+		t.Errorf("excluded directory suggested again: %q", out.String())
+	}
+}
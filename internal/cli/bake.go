@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var bakeCmd = &cobra.Command{
+	Use:   "bake",
+	Short: "Snapshot the set-up container into a project image",
+	Long: `Commit the running container to a project-specific image, so containers
+recreated later (alca down/up, rebuild) start from it instead of the
+configured image. With the nix template, this keeps the 'nix develop'
+closure built by commands.up, so recreating the container does not
+download it again and works without network access.
+
+Only the container filesystem is baked: mounts, including the workdir,
+are not, and the configured envs are scrubbed from the image.
+
+The bake is tied to the configured image, commands.up, and the workdir's
+flake.nix and flake.lock. When any of them changes, 'alca up' discards
+the baked image and creates the container from the configured image;
+run 'alca bake' again once it is set up.`,
+	Args: cobra.NoArgs,
+	RunE: runBake,
+}
+
+func init() {
+	bakeCmd.Flags().Bool("force", false, "Bake again even if the baked image is up to date")
+}
+
+// runBake commits the running container and records the image in state.
+func runBake(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	force, _ := cmd.Flags().GetBool("force")
+	out := cmd.OutOrStdout()
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIDeps()
+	env, tfs, runtimeEnv := deps.Env, deps.Tfs, deps.RuntimeEnv
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State != runtime.StateRunning {
+		return errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	hash, err := sandbox.BakeHash(env.Fs, cwd, cfg)
+	if err != nil {
+		return err
+	}
+	if st.Bake != nil && st.Bake.Hash == hash && !force {
+		util.ProgressDone(out, "Baked image is up to date: %s\n", st.Bake.Image)
+		return nil
+	}
+
+	image := bakeImageName(st.ProjectID, hash)
+	util.ProgressStep(out, "Baking %s into %s...\n", status.Name, image)
+	if err := rt.BakeImage(ctx, runtimeEnv, cfg, status.Name, image); err != nil {
+		return err
+	}
+
+	previous := st.Bake
+	st.Bake = &state.BakeInfo{Image: image, Base: cfg.Image, Hash: hash, BakedAt: time.Now()}
+	if err := sandbox.SaveBakeState(ctx, env, tfs, cwd, st, out); err != nil {
+		return err
+	}
+	if previous != nil && previous.Image != image {
+		if err := rt.RemoveImage(ctx, runtimeEnv, previous.Image); err != nil {
+			util.ProgressStep(out, "Warning: failed to remove previous baked image: %v\n", err)
+		}
+	}
+
+	util.ProgressDone(out, "Baked %s; new containers start from it\n", image)
+	return nil
+}
+
+// bakeImageName returns the image reference for a project's baked image.
+func bakeImageName(projectID, hash string) string {
+	return fmt.Sprintf("alca-bake/%s:%s", projectID, hash[:12])
+}
@@ -0,0 +1,13 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBakeImageName(t *testing.T) {
+	got := bakeImageName("7f3c", strings.Repeat("ab", 32))
+	if got != "alca-bake/7f3c:abababababab" {
+		t.Errorf("bakeImageName() = %q", got)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/state"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
@@ -40,6 +41,7 @@ func init() {
 func runCleanup(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	cleanupAll, _ := cmd.Flags().GetBool("all")
+	prompt := promptModeFromCmd(cmd)
 
 	cwd, err := findProjectDir()
 	if err != nil {
@@ -51,7 +53,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	env, runtimeEnv := deps.Env, deps.RuntimeEnv
 
 	// Load config (optional) and select runtime
-	_, rt, err := loadConfigAndRuntimeOptional(ctx, env, runtimeEnv, cwd)
+	_, rt, err := sandbox.LoadConfigAndRuntimeOptional(ctx, env, runtimeEnv, cwd)
 	if err != nil {
 		return err
 	}
@@ -76,9 +78,11 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 
 	var toDelete []runtime.ContainerInfo
 
-	if cleanupAll {
-		// --all flag: skip interaction
+	if cleanupAll || prompt.AssumeYes {
+		// --all or --yes: skip interaction
 		toDelete = orphansToContainerInfos(orphans)
+	} else if prompt.NonInteractive {
+		return fmt.Errorf("found %d orphan container(s), pass --all or --yes to remove them: %w", len(orphans), sandbox.ErrConfirmationRequired)
 	} else {
 		toDelete = selectOrphansInteractively(orphans)
 	}
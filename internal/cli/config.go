@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Output formats for the config lint command.
+const (
+	lintFormatText = "text"
+	lintFormatJSON = "json"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and edit the project configuration",
+}
+
+var configLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Warn about risky or redundant settings",
+	Long: `Check the resolved .alca.toml (with extends and includes merged) for
+settings that weaken the sandbox or have no effect.
+
+Rules:
+  mount-root           a mount exposes the host root filesystem (error)
+  mount-home           $HOME is mounted or is the project directory (warning)
+  lan-access-all       network.lan-access contains "*" (warning)
+  dangerous-cap        caps.add contains ALL, SYS_ADMIN, SYS_PTRACE or SYS_MODULE (warning)
+  nested-socket        nested_containers = "socket" mounts the host runtime socket (error)
+  nested-dind          nested_containers = "dind" runs a privileged sidecar (warning)
+  plaintext-secret     an env value looks like a credential (warning)
+  duplicate-mount      two mounts share a target (warning)
+  mount-option-ignored a mount's propagation, consistency or sync options do not apply on this host (info)
+  unreachable-include  an extends/includes glob matches no file (info)
+
+Exits non-zero when any error-severity finding is reported. 'alca up -v'
+prints the same findings before starting the container.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigLint,
+}
+
+var configRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Print the merged configuration as TOML",
+	Long: `Print the configuration 'alca up' uses, with extends, includes and parent
+project configs merged, as TOML. Values of sensitive envs are masked.
+
+--golden prints a rendering to commit next to .alca.toml, so a change to the
+effective config (including one coming from an extended or included file)
+shows up in code review. It only changes when the config does:
+  - per-user files (.alca.local.toml, .alca.*.local.toml) are left out
+  - the project and home directories are written as ${PROJECT_DIR} and ${HOME}
+  - sensitive env values written in the config are masked
+  - fields are in a fixed order and tables sorted by key
+
+Conditional includes are still evaluated for the current machine, so render
+on the platform CI runs on if the config uses them.
+
+--check FILE renders the golden output and compares it with FILE instead of
+printing it, printing a diff and exiting non-zero when they differ:
+
+  alca config render --golden > .alca.golden.toml
+  alca config render --check .alca.golden.toml`,
+	Args: cobra.NoArgs,
+	RunE: runConfigRender,
+}
+
+func init() {
+	configLintCmd.Flags().StringP("format", "o", lintFormatText, "Output format (text, json)")
+	configCmd.AddCommand(configLintCmd)
+
+	configRenderCmd.Flags().Bool("golden", false, "Print a machine-independent rendering for a golden file")
+	configRenderCmd.Flags().String("check", "", "Compare the golden rendering with `FILE` and fail when they differ")
+	configCmd.AddCommand(configRenderCmd)
+}
+
+// runConfigLint prints lint findings for the current project's config.
+func runConfigLint(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIReadDeps()
+	cfg, configPath, err := sandbox.LoadConfigFromCwd(cmd.Context(), deps.Env, cwd)
+	if err != nil {
+		return err
+	}
+
+	findings := sandbox.LintConfig(deps.Env, configPath, cfg)
+	if err := writeLintFindings(os.Stdout, findings, format); err != nil {
+		return err
+	}
+
+	errCount := 0
+	for _, f := range findings {
+		if f.Severity == config.LintError {
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		return fmt.Errorf("%d lint error(s) found", errCount)
+	}
+	return nil
+}
+
+// writeLintFindings writes findings in the given format.
+func writeLintFindings(w io.Writer, findings []config.LintFinding, format string) error {
+	switch format {
+	case lintFormatText:
+		if len(findings) == 0 {
+			_, _ = fmt.Fprintln(w, "No issues found")
+			return nil
+		}
+		for _, f := range findings {
+			_, _ = fmt.Fprintln(w, config.FormatLintFinding(f))
+		}
+	case lintFormatJSON:
+		if findings == nil {
+			findings = []config.LintFinding{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	default:
+		return fmt.Errorf("unknown format %q, valid options: %s, %s", format, lintFormatText, lintFormatJSON)
+	}
+	return nil
+}
+
+// runConfigRender prints the merged config, or checks it against a golden file.
+func runConfigRender(cmd *cobra.Command, args []string) error {
+	golden, _ := cmd.Flags().GetBool("golden")
+	check, _ := cmd.Flags().GetString("check")
+	out := cmd.OutOrStdout()
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+	deps := newCLIReadDeps()
+
+	if !golden && check == "" {
+		cfg, _, err := sandbox.LoadConfigFromCwd(cmd.Context(), deps.Env, cwd)
+		if err != nil {
+			return err
+		}
+		text, err := cfg.EncodeTOML()
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(out, text)
+		return err
+	}
+
+	text, err := renderGoldenConfig(cmd.Context(), deps.Env, cwd)
+	if err != nil {
+		return err
+	}
+	if check == "" {
+		_, err = io.WriteString(out, text)
+		return err
+	}
+	return checkGoldenConfig(out, deps.Env.Fs, check, text)
+}
+
+// renderGoldenConfig loads the project config without per-user files and
+// renders it for a golden file.
+func renderGoldenConfig(ctx context.Context, env *util.Env, cwd string) (string, error) {
+	goldenEnv := &util.Env{Fs: config.WithoutLocalConfigFiles(env.Fs), Cmd: env.Cmd}
+	cfg, _, err := sandbox.LoadConfigFromCwd(ctx, goldenEnv, cwd)
+	if err != nil {
+		return "", err
+	}
+	home, _ := os.UserHomeDir()
+	return config.RenderGolden(cfg, cwd, home)
+}
+
+// checkGoldenConfig compares rendered with the golden file at path,
+// writing a diff to w and returning an error when they differ.
+func checkGoldenConfig(w io.Writer, fs afero.Fs, path, rendered string) error {
+	want, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return fmt.Errorf("failed to read golden file: %w", err)
+	}
+	diff := util.UnifiedDiff(path, "current config", string(want), rendered, 3)
+	if diff == "" {
+		util.ProgressDone(w, "%s is up to date\n", path)
+		return nil
+	}
+	_, _ = io.WriteString(w, diff)
+	return fmt.Errorf("%s is out of date, regenerate it with 'alca config render --golden > %s'", path, path)
+}
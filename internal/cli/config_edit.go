@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var configGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print the value a config file sets for a key",
+	Long: `Print the value ` + sandbox.ConfigFilename + ` (or ` + sandbox.LocalConfigFilename + ` with --local) sets for a
+dotted key such as resources.memory. Strings are printed as is, other values
+as TOML. Only the file itself is read: values from extends and includes are
+not merged in. Exits non-zero when the file does not set the key.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Set a key in a config file, keeping its comments",
+	Long: `Set a dotted key such as resources.memory in ` + sandbox.ConfigFilename + ` (or
+` + sandbox.LocalConfigFilename + ` with --local, which is created if needed).
+
+VALUE is read as TOML (4, true, ["a", "b"]) and otherwise as a plain string,
+as with 'alca up --set'. Only the edited value changes: comments and
+formatting elsewhere are kept. An existing value is replaced where it is; a
+new key is added under its table's header, or under a new header at the end
+of the file. --append adds VALUE (or each item of an array VALUE) to an
+array.
+
+The key must be a config field, and the project config must still load
+afterwards; otherwise the file is left unchanged.
+
+  alca config set resources.memory 8g
+  alca config set --append network.lan-access 192.168.1.9:443
+  alca config set --local runtime podman`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset KEY",
+	Short: "Remove a key from a config file, keeping its comments",
+	Long: `Remove the line setting a dotted key from ` + sandbox.ConfigFilename + ` (or ` + sandbox.LocalConfigFilename + `
+with --local). Tables are not removed whole: unset their keys one by one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigUnset,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{configGetCmd, configSetCmd, configUnsetCmd} {
+		cmd.Flags().Bool("local", false, "Use "+sandbox.LocalConfigFilename+" instead of "+sandbox.ConfigFilename)
+		configCmd.AddCommand(cmd)
+	}
+	configSetCmd.Flags().Bool("append", false, "Append VALUE to an array instead of replacing it")
+}
+
+// runConfigGet prints a key's value from the config file.
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	path, err := config.ParseKey(args[0])
+	if err != nil {
+		return err
+	}
+	_, file, err := configEditPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	data, err := afero.ReadFile(newCLIReadDeps().Env.Fs, file)
+	if err != nil {
+		return err
+	}
+	v, err := config.GetKey(data, path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(file), err)
+	}
+	return writeConfigValue(os.Stdout, v)
+}
+
+// writeConfigValue prints a value for scripts: strings bare, the rest as TOML.
+func writeConfigValue(w io.Writer, v any) error {
+	if s, ok := v.(string); ok {
+		_, _ = fmt.Fprintln(w, s)
+		return nil
+	}
+	text, err := config.FormatValue(v)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(w, text)
+	return nil
+}
+
+// runConfigSet sets a key in the config file.
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	appendValue, _ := cmd.Flags().GetBool("append")
+	path, err := config.ParseKey(args[0])
+	if err != nil {
+		return err
+	}
+	o := config.Override{Path: path, Value: config.ParseValue(args[1]), Append: appendValue}
+	return editConfigFile(cmd, func(data []byte) ([]byte, error) {
+		return config.SetKey(data, o)
+	}, "Set "+strings.Join(path, "."))
+}
+
+// runConfigUnset removes a key from the config file.
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	path, err := config.ParseKey(args[0])
+	if err != nil {
+		return err
+	}
+	return editConfigFile(cmd, func(data []byte) ([]byte, error) {
+		return config.UnsetKey(data, path)
+	}, "Removed "+strings.Join(path, "."))
+}
+
+// configEditPath returns the project directory and the config file the
+// get/set/unset commands work on.
+func configEditPath(cmd *cobra.Command) (string, string, error) {
+	local, _ := cmd.Flags().GetBool("local")
+	cwd, err := findProjectDir()
+	if err != nil {
+		return "", "", err
+	}
+	if local {
+		return cwd, filepath.Join(cwd, sandbox.LocalConfigFilename), nil
+	}
+	return cwd, filepath.Join(cwd, sandbox.ConfigFilename), nil
+}
+
+// editConfigFile applies edit to the config file and writes the result,
+// unless it breaks a project config that loaded before.
+func editConfigFile(cmd *cobra.Command, edit func([]byte) ([]byte, error), done string) error {
+	ctx := cmd.Context()
+	cwd, file, err := configEditPath(cmd)
+	if err != nil {
+		return err
+	}
+	deps := newCLIDeps()
+	env, tfs := deps.Env, deps.Tfs
+	name := filepath.Base(file)
+
+	if config.IsEncryptedConfigPath(file) {
+		return fmt.Errorf("%s: encrypted configs must be edited by hand", file)
+	}
+	data, err := afero.ReadFile(env.Fs, file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	edited, err := edit(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	// Try the edit on a scratch layer first. A config that already fails
+	// to load is not held against the edit, which may be the fix.
+	if _, _, err := sandbox.LoadConfigFromCwd(ctx, env, cwd); err == nil {
+		scratch := &util.Env{Fs: afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(env.Fs), afero.NewMemMapFs()), Cmd: env.Cmd}
+		if err := afero.WriteFile(scratch.Fs, file, edited, 0o644); err != nil {
+			return err
+		}
+		if _, _, err := sandbox.LoadConfigFromCwd(ctx, scratch, cwd); err != nil {
+			return fmt.Errorf("%s left unchanged: %w", name, err)
+		}
+	}
+
+	if err := afero.WriteFile(env.Fs, file, edited, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := sandbox.CommitWithSudo(ctx, env, tfs, os.Stdout, ""); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	util.ProgressDone(os.Stdout, "%s in %s\n", done, name)
+
+	if name == sandbox.LocalConfigFilename && !config.IncludesFile(env, filepath.Join(cwd, sandbox.ConfigFilename), file, config.StrictExpandEnv, sandbox.ConfigLoadOptions(ctx, env)...) {
+		util.ProgressStep(os.Stdout, "Warning: %s does not include %s; add includes = [\"./%s\"] to use it\n", sandbox.ConfigFilename, sandbox.LocalConfigFilename, sandbox.LocalConfigFilename)
+	}
+	return nil
+}
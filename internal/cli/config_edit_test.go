@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+func TestConfigSetUnset_PreservesComments(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFakeConfig(t, dir, "# sandbox for CI\nimage = \"alpine:3\" # pinned\n\n[resources]\nmemory = \"2g\"\n")
+
+	configSetCmd.SetContext(t.Context())
+	if err := runConfigSet(configSetCmd, []string{"resources.cpus", "4"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := runConfigSet(configSetCmd, []string{"userns", "sideways"}); err == nil {
+		t.Error("set of an invalid userns should fail")
+	}
+	configUnsetCmd.SetContext(t.Context())
+	if err := runConfigUnset(configUnsetCmd, []string{"resources.memory"}); err != nil {
+		t.Fatalf("unset: %v", err)
+	}
+
+	got, err := afero.ReadFile(afero.NewOsFs(), filepath.Join(dir, sandbox.ConfigFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# sandbox for CI\nimage = \"alpine:3\" # pinned\n\n[resources]\ncpus = 4\n"
+	if string(got) != want {
+		t.Errorf("config =\n%s\nwant\n%s", got, want)
+	}
+
+	var out bytes.Buffer
+	if err := writeConfigValue(&out, "alpine:3"); err != nil || out.String() != "alpine:3\n" {
+		t.Errorf("writeConfigValue(string) = %q, %v", out.String(), err)
+	}
+	out.Reset()
+	if err := writeConfigValue(&out, []any{"a", "b"}); err != nil || out.String() != "['a', 'b']\n" {
+		t.Errorf("writeConfigValue(array) = %q, %v", out.String(), err)
+	}
+}
+
+func TestConfigSet_LocalCreatesFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFakeConfig(t, dir, "image = \"alpine:3\"\nincludes = [\"./.alca.local.toml\"]\n")
+	if err := afero.WriteFile(afero.NewOsFs(), filepath.Join(dir, sandbox.LocalConfigFilename), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := configSetCmd.Flags().Set("local", "true"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = configSetCmd.Flags().Set("local", "false") })
+	configSetCmd.SetContext(t.Context())
+	if err := runConfigSet(configSetCmd, []string{"network.lan-access", `["10.0.0.1:22"]`}); err != nil {
+		t.Fatalf("set --local: %v", err)
+	}
+
+	got, err := afero.ReadFile(afero.NewOsFs(), filepath.Join(dir, sandbox.LocalConfigFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "[network]\nlan-access = ['10.0.0.1:22']") {
+		t.Errorf("local config = %q", got)
+	}
+}
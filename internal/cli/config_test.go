@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/config/configtest"
+)
+
+func TestWriteLintFindings(t *testing.T) {
+	findings := []config.LintFinding{
+		{Rule: "mount-root", Severity: config.LintError, Field: "mounts[/host]", Message: "mounts the host root filesystem"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeLintFindings(&buf, findings, lintFormatText); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, "ERROR   mounts[/host]: mounts the host root filesystem (mount-root)") {
+		t.Errorf("text output = %q", got)
+	}
+
+	buf.Reset()
+	if err := writeLintFindings(&buf, nil, lintFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []config.LintFinding
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded == nil {
+		t.Errorf("expected empty JSON array, got %q (err %v)", buf.String(), err)
+	}
+
+	if err := writeLintFindings(&buf, findings, "yaml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestRenderGoldenConfig(t *testing.T) {
+	env := configtest.Fixture{
+		".alca.toml":       "image = \"alpine\"\nincludes = [\"./.alca.*.toml\"]\nmounts = [\"/project/cache:/cache\"]\n\n[envs]\nTOKEN = { value = \"hunter2\", sensitive = true }\n",
+		".alca.dev.toml":   "[resources]\ncpus = 2\n",
+		".alca.local.toml": "[resources]\nmemory = \"64g\"\n",
+	}.Env(t)
+
+	got, err := renderGoldenConfig(context.Background(), env, configtest.ProjectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configtest.AssertGolden(t, afero.NewOsFs(), "testdata/config_render.golden", got)
+}
+
+func TestCheckGoldenConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/project/.alca.golden.toml", []byte("image = 'alpine'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := checkGoldenConfig(&buf, fs, "/project/.alca.golden.toml", "image = 'alpine'\n"); err != nil {
+		t.Fatalf("matching golden file: %v", err)
+	}
+	if !strings.Contains(buf.String(), "up to date") {
+		t.Errorf("output = %q", buf.String())
+	}
+
+	buf.Reset()
+	err := checkGoldenConfig(&buf, fs, "/project/.alca.golden.toml", "image = 'debian'\n")
+	if err == nil || !strings.Contains(err.Error(), "alca config render --golden") {
+		t.Fatalf("err = %v, want an out-of-date error", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "-image = 'alpine'") || !strings.Contains(got, "+image = 'debian'") {
+		t.Errorf("diff = %q", got)
+	}
+
+	if err := checkGoldenConfig(&buf, fs, "/project/missing.toml", ""); err == nil {
+		t.Error("missing golden file should fail")
+	}
+}
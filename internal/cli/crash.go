@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// issuesURL is where crash reports are filed.
+	issuesURL = "https://github.com/bolasblack/alcatraz/issues/new"
+	// crashLogTail is how much of each log file a crash bundle keeps.
+	crashLogTail = 256 << 10
+	// crashProbeTimeout bounds each version query made for a crash bundle.
+	crashProbeTimeout = 5 * time.Second
+)
+
+// crashInfo is what is known about a crash when the bundle is written.
+type crashInfo struct {
+	Panic string
+	Stack []byte
+	Args  []string
+	Time  time.Time
+}
+
+// reportCrash writes a crash bundle for a panic and tells the user how to
+// file it. It must not panic itself: a failure to write the bundle is
+// reported and the stack is printed instead.
+func reportCrash(w io.Writer, info crashInfo) {
+	_, _ = fmt.Fprintf(w, "alca crashed: %s\n", util.Redact(info.Panic))
+
+	path, err := func() (path string, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic while writing the crash report: %v", r)
+			}
+		}()
+		host := newHostEnv()
+		return writeCrashBundle(host.Fs, host.Cmd, crashBundleDir(host.Fs), info)
+	}()
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Could not save a crash report: %v\n\n%s\n", err, util.Redact(string(info.Stack)))
+		_, _ = fmt.Fprintf(w, "Please open an issue at %s with the output above and the command you ran.\n", issuesURL)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\nA crash report was saved to %s.\n"+
+		"Please open an issue at %s, describe what you ran, and attach the file.\n"+
+		"Env values are removed from it, but check the logs and config inside before sharing.\n", path, issuesURL)
+}
+
+// crashBundleDir is the project's .alca directory, or ~/.alcatraz outside
+// a project.
+func crashBundleDir(fs afero.Fs) string {
+	if cwd, err := getCwd(); err == nil {
+		dir := sandbox.FindProjectDirFrom(fs, cwd)
+		if _, err := fs.Stat(filepath.Join(dir, sandbox.ConfigFilename)); err == nil {
+			return state.StateDirPath(dir)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, util.AlcatrazDir)
+	}
+	return os.TempDir()
+}
+
+// writeCrashBundle writes crash-<timestamp>.zip to dir with the panic and
+// stack, versions, the redacted config and state, and the tails of the
+// project's logs. Parts that cannot be read are left out. Returns the
+// bundle's path.
+func writeCrashBundle(fs afero.Fs, cmdRunner util.CommandRunner, dir string, info crashInfo) (string, error) {
+	if err := fs.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, "crash-"+info.Time.UTC().Format("20060102T150405Z")+".zip")
+	f, err := fs.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create crash report: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	zw := zip.NewWriter(f)
+	add := func(name string, data []byte) {
+		if w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.Time}); err == nil {
+			_, _ = w.Write([]byte(util.Redact(string(data))))
+		}
+	}
+
+	add("panic.txt", []byte(fmt.Sprintf("%s\n\n%s", info.Panic, info.Stack)))
+	add("versions.txt", crashVersions(cmdRunner, info))
+
+	projectDir := filepath.Dir(dir)
+	if filepath.Base(dir) == state.StateDir {
+		env := &util.Env{Fs: afero.NewReadOnlyFs(fs), Cmd: cmdRunner}
+		ctx, cancel := context.WithTimeout(context.Background(), crashProbeTimeout)
+		cfg, _, err := sandbox.LoadConfigFromCwd(ctx, env, projectDir)
+		cancel()
+		if err == nil {
+			if data, err := json.MarshalIndent(redactConfig(cfg).ToRaw(), "", "  "); err == nil {
+				add("config.json", data)
+			}
+		}
+		if st, err := state.Load(env, projectDir); err == nil && st != nil {
+			redacted := *st
+			redacted.Config = redactConfig(st.Config)
+			if data, err := json.MarshalIndent(&redacted, "", "  "); err == nil {
+				add("state.json", data)
+			}
+		}
+		for _, name := range []string{"up-progress.json", "usage.json"} {
+			if data, err := afero.ReadFile(fs, filepath.Join(dir, name)); err == nil {
+				add(name, data)
+			}
+		}
+		logs, _ := afero.Glob(fs, filepath.Join(dir, "logs", "*"))
+		for _, logPath := range logs {
+			if data, err := readTail(fs, logPath, crashLogTail); err == nil {
+				add("logs/"+filepath.Base(logPath), data)
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	return path, nil
+}
+
+// crashVersions describes alca, the host and the tools it drives.
+func crashVersions(cmdRunner util.CommandRunner, info crashInfo) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "alca %s (commit %s, built %s)\n", Version, sandbox.DisplayOrDefault(Commit), sandbox.DisplayOrDefault(Date))
+	fmt.Fprintf(&b, "go %s %s/%s\n", goruntime.Version(), goruntime.GOOS, goruntime.GOARCH)
+	fmt.Fprintf(&b, "args: %s\n", strings.Join(info.Args, " "))
+	for _, probe := range [][]string{{"docker", "version"}, {"podman", "version"}, {"mutagen", "version"}} {
+		ctx, cancel := context.WithTimeout(context.Background(), crashProbeTimeout)
+		output, err := cmdRunner.RunQuiet(ctx, probe[0], probe[1:]...)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(&b, "\n$ %s: %v\n", strings.Join(probe, " "), err)
+			continue
+		}
+		fmt.Fprintf(&b, "\n$ %s\n%s", strings.Join(probe, " "), output)
+	}
+	return []byte(b.String())
+}
+
+// redactConfig returns a copy of cfg with env values replaced, since they
+// commonly hold tokens. Returns nil for nil.
+func redactConfig(cfg *config.Config) *config.Config {
+	if cfg == nil {
+		return nil
+	}
+	redacted := *cfg
+	redacted.Envs = make(map[string]config.EnvValue, len(cfg.Envs))
+	for k, v := range cfg.Envs {
+		v.Value = util.RedactedValue
+		redacted.Envs[k] = v
+	}
+	return &redacted
+}
+
+// readTail returns up to the last n bytes of a file.
+func readTail(fs afero.Fs, path string, n int64) ([]byte, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return nil, fmt.Errorf("not a file: %s", path)
+	}
+	if info.Size() > n {
+		if _, err := f.Seek(info.Size()-n, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(f)
+}
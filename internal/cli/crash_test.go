@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"archive/zip"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestWriteCrashBundle(t *testing.T) {
+	fs := afero.NewOsFs()
+	dir := t.TempDir()
+	writeFakeConfig(t, dir, "image = \"alpine\"\n\n[envs]\nTOKEN = \"s3cret\"\n")
+	stateDir := state.StateDirPath(dir)
+	if err := fs.MkdirAll(filepath.Join(stateDir, "logs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, filepath.Join(stateDir, "logs", "container.log"), []byte("hello from the container\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker version", []byte("Server: Docker Engine 27.0.0\n"))
+	info := crashInfo{
+		Panic: "runtime error: index out of range",
+		Stack: []byte("goroutine 1 [running]:\nmain.main()\n"),
+		Args:  []string{"alca", "up"},
+		Time:  time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+	}
+	path, err := writeCrashBundle(fs, mock, stateDir, info)
+	if err != nil {
+		t.Fatalf("writeCrashBundle: %v", err)
+	}
+	if want := filepath.Join(stateDir, "crash-20261018T120000Z.zip"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = zr.Close() }()
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for name, want := range map[string]string{
+		"panic.txt":          "index out of range",
+		"versions.txt":       "Docker Engine 27.0.0",
+		"config.json":        util.RedactedValue,
+		"logs/container.log": "hello from the container",
+	} {
+		if !strings.Contains(files[name], want) {
+			t.Errorf("%s = %q, want it to contain %q", name, files[name], want)
+		}
+	}
+	for name, content := range files {
+		if strings.Contains(content, "s3cret") {
+			t.Errorf("%s leaks an env value", name)
+		}
+	}
+}
+
+func TestReportCrash_PrintsInstructions(t *testing.T) {
+	dir := t.TempDir()
+	writeFakeConfig(t, dir, "image = \"alpine\"\n")
+	t.Chdir(dir)
+
+	var out strings.Builder
+	reportCrash(&out, crashInfo{Panic: "boom", Time: time.Now()})
+	if !strings.Contains(out.String(), "alca crashed: boom") || !strings.Contains(out.String(), issuesURL) {
+		t.Errorf("output = %q", out.String())
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, state.StateDir, "crash-*.zip")); len(matches) != 1 {
+		t.Errorf("crash bundles = %v, want one in %s", matches, state.StateDir)
+	}
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/credstore"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Manage secrets kept in the system keychain",
+	Long: `Store, read and remove secrets kept in the system keychain instead of
+plaintext files. alca itself reads only the age identity for encrypted
+configs from it; other keys are kept for your own scripts.
+
+Secrets go to the macOS Keychain, or to the Secret Service (GNOME Keyring,
+KWallet) on Linux desktops. Elsewhere they go to ~/.alcatraz/` + credstore.CredentialsFile + `,
+encrypted with the passphrase in ` + credstore.EnvPassphrase + `. Set
+` + credstore.EnvBackend + ` to keychain, secret-service or file to choose.
+
+Key alca reads:
+  ` + credstore.KeyAgeIdentity + `  age identities that decrypt *.age configs, used when
+                ` + config.EnvAgeIdentity + ` and ` + config.EnvAgeIdentityFile + ` are unset
+
+  alca credentials set ` + credstore.KeyAgeIdentity + ` < ~/.config/age/key.txt`,
+}
+
+var credentialsSetCmd = &cobra.Command{
+	Use:   "set KEY",
+	Short: "Store a secret read from stdin",
+	Long: `Store the secret read from stdin under KEY, replacing any previous value.
+At a terminal the input is not echoed; otherwise all of stdin is read and a
+trailing newline dropped.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCredentialsSet,
+}
+
+var credentialsGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print a stored secret",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredentialsGet,
+}
+
+var credentialsRmCmd = &cobra.Command{
+	Use:   "rm KEY",
+	Short: "Remove a stored secret",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredentialsRm,
+}
+
+func init() {
+	credentialsCmd.AddCommand(credentialsSetCmd)
+	credentialsCmd.AddCommand(credentialsGetCmd)
+	credentialsCmd.AddCommand(credentialsRmCmd)
+}
+
+// newCredentialStore opens the credential store on the host. The store
+// keeps its file outside any project, so no transaction is needed.
+func newCredentialStore() (credstore.Store, error) {
+	return credstore.New(newHostEnv())
+}
+
+func runCredentialsSet(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	if err := credstore.ValidateKey(key); err != nil {
+		return err
+	}
+	store, err := newCredentialStore()
+	if err != nil {
+		return err
+	}
+
+	value, err := readSecret(os.Stdin, fmt.Sprintf("Value for %s: ", key))
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("refusing to store an empty value for %s", key)
+	}
+	if err := store.Set(cmd.Context(), key, value); err != nil {
+		return err
+	}
+	util.ProgressDone(os.Stdout, "Stored %s in the %s credential store\n", key, store.Name())
+	return nil
+}
+
+func runCredentialsGet(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	if err := credstore.ValidateKey(key); err != nil {
+		return err
+	}
+	store, err := newCredentialStore()
+	if err != nil {
+		return err
+	}
+	value, err := store.Get(cmd.Context(), key)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(os.Stdout, value)
+	return nil
+}
+
+func runCredentialsRm(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	if err := credstore.ValidateKey(key); err != nil {
+		return err
+	}
+	store, err := newCredentialStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(cmd.Context(), key); err != nil {
+		return err
+	}
+	util.ProgressDone(os.Stdout, "Removed %s from the %s credential store\n", key, store.Name())
+	return nil
+}
+
+// readSecret reads a secret without echo from a terminal, else all of r
+// with one trailing newline dropped.
+func readSecret(r *os.File, prompt string) (string, error) {
+	if term.IsTerminal(int(r.Fd())) {
+		_, _ = fmt.Fprint(os.Stderr, prompt)
+		value, err := term.ReadPassword(int(r.Fd()))
+		_, _ = fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the value: %w", err)
+		}
+		return string(value), nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the value from stdin: %w", err)
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
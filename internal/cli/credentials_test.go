@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadSecret_Pipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.WriteString("line one\nline two\r\n")
+	_ = w.Close()
+	defer func() { _ = r.Close() }()
+
+	got, err := readSecret(r, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "line one\nline two" {
+		t.Errorf("readSecret = %q", got)
+	}
+}
@@ -0,0 +1,366 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/cron"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// cronLogMaxSize is the size at which a task log is rotated to .log.1.
+	cronLogMaxSize = 1 << 20
+)
+
+var cronCmd = &cobra.Command{
+	Use:   "cron",
+	Short: "Show and run the project's scheduled tasks",
+	Long: `Show and run the tasks in the [cron] section of .alca.toml:
+
+  [cron]
+  "0 * * * *" = "make snapshot"
+  "CRON_TZ=Europe/Berlin 30 9 * * 1-5" = "./scripts/standup-report"
+
+Each key is a cron schedule (minute hour day-of-month month day-of-week, or
+@hourly, @daily, ...) and each value a command run with sh -c in the
+container, as the enter user in the workdir.
+
+Schedules follow the host's time zone, not the container's, unless they
+start with CRON_TZ=<zone>. Across daylight saving changes each task runs
+once: tasks in the skipped hour run when it ends, and the repeated hour
+does not run them again.
+
+'alca up' starts a scheduler for the project in the background; 'alca down'
+stops it, and it stops by itself once the container is gone. Tasks do not
+run while the container is stopped, and a task still running when it is
+due again is skipped. Each task logs to .alca/logs/cron/.`,
+}
+
+var cronListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled tasks and their next run",
+	Args:  cobra.NoArgs,
+	RunE:  runCronList,
+}
+
+var cronRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the scheduler in the foreground",
+	Long: `Run the project's scheduler in the foreground until the container is
+removed or [cron] is emptied. 'alca up' starts it in the background, so this
+is only needed to watch it or to run it under a service manager.`,
+	Args: cobra.NoArgs,
+	RunE: runCronRun,
+}
+
+func init() {
+	cronCmd.AddCommand(cronListCmd)
+	cronCmd.AddCommand(cronRunCmd)
+}
+
+func runCronList(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+	env := util.NewReadonlyOsEnv()
+	cfg, _, err := sandbox.LoadConfigFromCwd(cmd.Context(), env, cwd)
+	if err != nil {
+		return err
+	}
+	entries, err := cfg.CronEntries(time.Local)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		_, _ = fmt.Fprintln(out, "No [cron] tasks configured.")
+		return nil
+	}
+	writeCronList(out, cwd, entries, time.Now())
+
+	if pid, ok := sandbox.CronSchedulerPID(env.Fs, cwd); ok {
+		_, _ = fmt.Fprintf(out, "\nScheduler running (pid %d).\n", pid)
+	} else {
+		_, _ = fmt.Fprintln(out, "\nScheduler not running; 'alca up' starts it.")
+	}
+	return nil
+}
+
+// writeCronList prints each task with its next run and log file.
+func writeCronList(out io.Writer, projectDir string, entries []config.CronEntry, now time.Time) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SCHEDULE\tNEXT RUN\tCOMMAND\tLOG")
+	for _, e := range entries {
+		next := "never"
+		if t := e.Schedule.Next(now); !t.IsZero() {
+			next = t.Format("2006-01-02 15:04 MST")
+		}
+		logPath, _ := filepath.Rel(projectDir, cronLogPath(projectDir, e))
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Spec, next, e.Command, logPath)
+	}
+	_ = w.Flush()
+}
+
+func runCronRun(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Started by alca up, the scheduler outlives the terminal it came from
+	signal.Ignore(syscall.SIGHUP)
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+	env := newHostEnv()
+	deps := sandbox.NewDeps(env)
+	cfg, _, err := sandbox.LoadConfigFromCwd(ctx, env, cwd)
+	if err != nil {
+		return err
+	}
+	rt, err := runtime.SelectRuntime(ctx, deps.RuntimeEnv, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to select runtime: %w", err)
+	}
+
+	if pid, ok := sandbox.CronSchedulerPID(env.Fs, cwd); ok && pid != os.Getpid() {
+		return fmt.Errorf("a scheduler is already running for this project (pid %d)", pid)
+	}
+	pidPath := filepath.Join(state.StateDirPath(cwd), sandbox.CronPidFile)
+	if err := env.Fs.MkdirAll(filepath.Dir(pidPath), 0o755); err != nil {
+		return err
+	}
+	if err := afero.WriteFile(env.Fs, pidPath, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pidPath, err)
+	}
+	defer func() {
+		if pid, ok := readCronPID(env.Fs, cwd); ok && pid == os.Getpid() {
+			_ = env.Fs.Remove(pidPath)
+		}
+	}()
+
+	s := newCronScheduler(env, deps.RuntimeEnv, rt, cwd, time.Local, time.Now(), cmd.OutOrStdout())
+	util.ProgressStep(cmd.OutOrStdout(), "Cron scheduler started for %s\n", cwd)
+	for {
+		now := time.Now()
+		select {
+		case <-ctx.Done():
+			s.wait()
+			return nil
+		case <-time.After(now.Truncate(time.Minute).Add(time.Minute).Sub(now)):
+		}
+		if !s.tick(ctx, time.Now()) {
+			s.wait()
+			util.ProgressStep(cmd.OutOrStdout(), "Cron scheduler stopped\n")
+			return nil
+		}
+	}
+}
+
+// cronScheduler runs a project's [cron] tasks. It rereads the config and
+// state on every tick, so edits apply without restarting it.
+type cronScheduler struct {
+	env        *util.Env
+	runtimeEnv *runtime.RuntimeEnv
+	rt         runtime.Runtime
+	projectDir string
+	local      *time.Location
+	out        io.Writer
+
+	// lastTick is when the previous tick ran; lastWall is the latest wall
+	// minute seen per time zone, which never moves back.
+	lastTick time.Time
+	lastWall map[string]time.Time
+
+	mu      gosync.Mutex
+	running map[string]bool
+	wg      gosync.WaitGroup
+}
+
+func newCronScheduler(env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, projectDir string, local *time.Location, start time.Time, out io.Writer) *cronScheduler {
+	return &cronScheduler{
+		env:        env,
+		runtimeEnv: runtimeEnv,
+		rt:         rt,
+		projectDir: projectDir,
+		local:      local,
+		out:        out,
+		lastTick:   start,
+		lastWall:   make(map[string]time.Time),
+		running:    make(map[string]bool),
+	}
+}
+
+// tick starts the tasks due since the previous tick. Returns false when
+// the scheduler should stop: the project has no state or container any
+// more, or no tasks.
+func (s *cronScheduler) tick(ctx context.Context, now time.Time) bool {
+	prev := s.lastTick
+	s.lastTick = now
+
+	st, err := state.Load(s.env, s.projectDir)
+	if err != nil {
+		util.ProgressStep(s.out, "Warning: %v\n", err)
+		return true
+	}
+	if st == nil {
+		return false
+	}
+	cfg, _, err := sandbox.LoadConfigFromCwd(ctx, s.env, s.projectDir)
+	if err != nil {
+		util.ProgressStep(s.out, "Warning: %v\n", err)
+		return true
+	}
+	entries, err := cfg.CronEntries(s.local)
+	if err != nil {
+		util.ProgressStep(s.out, "Warning: %v\n", err)
+		return true
+	}
+	if len(entries) == 0 {
+		return false
+	}
+	status, err := s.rt.Status(ctx, s.runtimeEnv, s.projectDir, st)
+	if err != nil {
+		util.ProgressStep(s.out, "Warning: %v\n", err)
+		return true
+	}
+	if status.State == runtime.StateNotFound {
+		return false
+	}
+
+	// Advance every zone's clock even while the container is stopped, so
+	// tasks missed meanwhile do not all run on resume
+	due := make([]config.CronEntry, 0, len(entries))
+	walls := make(map[string]time.Time)
+	for _, e := range entries {
+		zone := e.Schedule.Location.String()
+		last, ok := s.lastWall[zone]
+		if !ok {
+			last = cron.Wall(prev, e.Schedule.Location)
+		}
+		wall := cron.Wall(now, e.Schedule.Location)
+		if e.Schedule.Due(last, wall) {
+			due = append(due, e)
+		}
+		if wall.After(last) {
+			walls[zone] = wall
+		} else {
+			walls[zone] = last
+		}
+	}
+	for zone, wall := range walls {
+		s.lastWall[zone] = wall
+	}
+
+	if status.State != runtime.StateRunning {
+		return true
+	}
+	for _, e := range due {
+		s.start(ctx, cfg, status.Name, e, now)
+	}
+	return true
+}
+
+// start runs a task in the background unless its previous run is still going.
+func (s *cronScheduler) start(ctx context.Context, cfg *config.Config, containerName string, e config.CronEntry, now time.Time) {
+	key := e.Spec + "\x00" + e.Command
+	logPath := cronLogPath(s.projectDir, e)
+
+	s.mu.Lock()
+	busy := s.running[key]
+	if !busy {
+		s.running[key] = true
+	}
+	s.mu.Unlock()
+	if busy {
+		_ = appendCronLog(s.env.Fs, logPath, func(w io.Writer) {
+			_, _ = fmt.Fprintf(w, "=== %s skipped: the previous run is still going\n", now.Format(time.RFC3339))
+		})
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, key)
+			s.mu.Unlock()
+		}()
+		err := appendCronLog(s.env.Fs, logPath, func(w io.Writer) {
+			_, _ = fmt.Fprintf(w, "=== %s %s: %s\n", now.Format(time.RFC3339), e.Spec, e.Command)
+			started := time.Now()
+			err := s.rt.RunTask(ctx, s.runtimeEnv, cfg, containerName, e.Command, w)
+			took := time.Since(started).Round(time.Second)
+			if err != nil {
+				_, _ = fmt.Fprintf(w, "=== failed after %s: %v\n", took, err)
+				return
+			}
+			_, _ = fmt.Fprintf(w, "=== done in %s\n", took)
+		})
+		if err != nil {
+			util.ProgressStep(s.out, "Warning: cron task %q: %v\n", e.Command, err)
+		}
+	}()
+}
+
+// wait blocks until running tasks finish.
+func (s *cronScheduler) wait() {
+	s.wg.Wait()
+}
+
+var cronLogNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// cronLogPath names a task's log after its command, with a hash of the
+// schedule and command so similar tasks do not share a file.
+func cronLogPath(projectDir string, e config.CronEntry) string {
+	slug := strings.Trim(cronLogNameUnsafe.ReplaceAllString(e.Command, "-"), "-")
+	if len(slug) > 32 {
+		slug = strings.TrimRight(slug[:32], "-")
+	}
+	sum := sha256.Sum256([]byte(e.Spec + "\x00" + e.Command))
+	return filepath.Join(sandbox.CronLogDir(projectDir), slug+"-"+hex.EncodeToString(sum[:4])+".log")
+}
+
+// appendCronLog opens a task log for appending, rotating it to .log.1 once
+// it exceeds cronLogMaxSize, and passes it to write.
+func appendCronLog(fs afero.Fs, path string, write func(io.Writer)) error {
+	if err := fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if info, err := fs.Stat(path); err == nil && info.Size() > cronLogMaxSize {
+		_ = fs.Rename(path, path+".1")
+	}
+	f, err := fs.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	write(f)
+	return f.Close()
+}
+
+// readCronPID returns the PID recorded in the project's cron.pid.
+func readCronPID(fs afero.Fs, projectDir string) (int, bool) {
+	return sandbox.ReadPIDFile(fs, filepath.Join(state.StateDirPath(projectDir), sandbox.CronPidFile))
+}
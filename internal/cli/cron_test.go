@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/cron"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const fakeCronConfig = fakeProjectConfig + `
+[cron]
+"*/5 * * * *" = "make snapshot"
+`
+
+func TestFakeRuntime_Cron(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeCronConfig)
+	mock := fakeCommandRunner(t)
+	mock.ExpectMatch("* cron run", nil, nil)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	spawned := startedCalls(mock, "cron", "run")
+	if len(spawned) != 1 || spawned[0].Dir != dir {
+		t.Fatalf("scheduler started as %+v, want once in %s", spawned, dir)
+	}
+	if got, want := spawned[0].LogFile, filepath.Join(sandbox.CronLogDir(dir), "scheduler.log"); got != want {
+		t.Errorf("scheduler log = %s, want %s", got, want)
+	}
+	st := loadFakeState(t, dir)
+
+	env := &util.Env{Fs: afero.NewOsFs()}
+	start := time.Date(2026, 3, 2, 10, 3, 30, 0, time.UTC)
+	var out bytes.Buffer
+	s := newCronScheduler(env, nil, fake, dir, time.UTC, start, &out)
+
+	// 10:04 is not due, 10:05 is
+	if !s.tick(context.Background(), start.Add(30*time.Second)) {
+		t.Fatal("scheduler stopped early")
+	}
+	if !s.tick(context.Background(), start.Add(90*time.Second)) {
+		t.Fatal("scheduler stopped early")
+	}
+	s.wait()
+	execs := fake.Container(st.ContainerName).Execs
+	last := execs[len(execs)-1]
+	if strings.Join(last, " ") != "sh -c make snapshot" {
+		t.Fatalf("last exec = %v, want the cron task", last)
+	}
+	taskRuns := 0
+	for _, e := range execs {
+		if strings.Join(e, " ") == "sh -c make snapshot" {
+			taskRuns++
+		}
+	}
+	if taskRuns != 1 {
+		t.Errorf("task ran %d times, want 1", taskRuns)
+	}
+
+	entries, _ := (&config.Config{Cron: map[string]string{"*/5 * * * *": "make snapshot"}}).CronEntries(time.UTC)
+	log, err := afero.ReadFile(afero.NewOsFs(), cronLogPath(dir, entries[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "*/5 * * * *: make snapshot") || !strings.Contains(string(log), "=== done") {
+		t.Errorf("task log:\n%s", log)
+	}
+
+	// The scheduler stops once the container is gone
+	if err := runFakeCommand(t, downCmd, runDown); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if s.tick(context.Background(), start.Add(150*time.Second)) {
+		t.Error("scheduler kept running after down")
+	}
+}
+
+func TestFakeRuntime_CronNotConfigured(t *testing.T) {
+	setupFakeProject(t, fakeProjectConfig)
+	mock := fakeCommandRunner(t)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if spawned := startedCalls(mock, "cron", "run"); len(spawned) != 0 {
+		t.Errorf("scheduler started without [cron] tasks: %+v", spawned)
+	}
+}
+
+func TestCronLogPath(t *testing.T) {
+	schedule, _ := cron.Parse("@hourly", time.UTC)
+	a := cronLogPath("/p", config.CronEntry{Spec: "@hourly", Schedule: schedule, Command: "make snapshot && ./upload.sh --all"})
+	b := cronLogPath("/p", config.CronEntry{Spec: "@daily", Schedule: schedule, Command: "make snapshot && ./upload.sh --all"})
+	if !strings.HasPrefix(a, "/p/.alca/logs/cron/make-snapshot-upload-sh-all-") || !strings.HasSuffix(a, ".log") {
+		t.Errorf("cronLogPath = %s", a)
+	}
+	if a == b {
+		t.Error("different schedules share a log file")
+	}
+}
+
+func TestAppendCronLog_Rotates(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/p/.alca/logs/cron/task.log"
+	if err := afero.WriteFile(fs, path, bytes.Repeat([]byte("x"), cronLogMaxSize+1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendCronLog(fs, path, func(w io.Writer) { _, _ = w.Write([]byte("new run\n")) }); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := afero.ReadFile(fs, path)
+	if string(data) != "new run\n" {
+		t.Errorf("log = %q, want only the new run", data)
+	}
+	if info, err := fs.Stat(path + ".1"); err != nil || info.Size() != cronLogMaxSize+1 {
+		t.Errorf("rotated log: %v, %v", info, err)
+	}
+}
@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+const (
+	diffFormatText = "text"
+	diffFormatJSON = "json"
+	diffExportTar  = "tar"
+)
+
+// diffLetters are the one-letter kinds printed by the text format, as
+// docker diff prints them.
+var diffLetters = map[runtime.ChangeKind]string{
+	runtime.ChangeAdded:   "A",
+	runtime.ChangeChanged: "C",
+	runtime.ChangeDeleted: "D",
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [path...]",
+	Short: "Show files changed in the container outside mounts",
+	Long: `List the files added (A), changed (C) and deleted (D) in the container's
+writable layer since it was created: what commands run in the sandbox
+(or an agent) modified outside the project directory and other mounts,
+such as installed packages, files under /tmp or edits to /etc. Paths
+given as arguments limit the list to those paths and what is below them.
+
+The container may be stopped. Changes are lost when the container is
+recreated ('alca up' after a config change, 'alca rebuild' or 'alca down').
+
+With --export tar, the added and changed files are written as a tar
+archive to --output, or to stdout when it is not a terminal. Deleted files
+are listed but cannot be archived.`,
+	Example: `  alca diff
+  alca diff /etc /usr/local --kind added,changed
+  alca diff --export tar --output changes.tar`,
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringSlice("kind", nil, "Only list these kinds of change (added, changed, deleted)")
+	diffCmd.Flags().StringP("format", "o", diffFormatText, "Output format (text, json)")
+	diffCmd.Flags().String("export", "", "Write the added and changed files as an archive (tar)")
+	diffCmd.Flags().String("output", "", "With --export, write the archive to this file instead of stdout")
+}
+
+// runDiff lists or exports the changes in the project container.
+func runDiff(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	format, _ := cmd.Flags().GetString("format")
+	kinds, _ := cmd.Flags().GetStringSlice("kind")
+	export, _ := cmd.Flags().GetString("export")
+	output, _ := cmd.Flags().GetString("output")
+
+	if format != diffFormatText && format != diffFormatJSON {
+		return fmt.Errorf("unknown format %q, valid options: %s, %s", format, diffFormatText, diffFormatJSON)
+	}
+	for _, k := range kinds {
+		if _, ok := diffLetters[runtime.ChangeKind(k)]; !ok {
+			return fmt.Errorf("unknown kind %q, valid options: %s, %s, %s", k, runtime.ChangeAdded, runtime.ChangeChanged, runtime.ChangeDeleted)
+		}
+	}
+	if export != "" && export != diffExportTar {
+		return fmt.Errorf("unknown export format %q, valid options: %s", export, diffExportTar)
+	}
+	if output != "" && export == "" {
+		return errors.New("--output requires --export")
+	}
+	if export != "" && output == "" && term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("refusing to write an archive to a terminal, use --output or redirect stdout")
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	_, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State == runtime.StateNotFound {
+		return errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	changes, err := rt.Diff(ctx, runtimeEnv, status.Name)
+	if err != nil {
+		return err
+	}
+	changes = filterChanges(changes, kinds, args)
+
+	if export == "" {
+		return writeChanges(os.Stdout, changes, format)
+	}
+
+	// The archive is written through the real filesystem, since the
+	// command otherwise only reads.
+	hostFs := host.Fs
+	w := io.Writer(os.Stdout)
+	if output != "" {
+		f, err := hostFs.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	n, err := exportChanges(ctx, hostFs, runtimeEnv, rt, status.Name, changes, w)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Exported %d path(s)\n", n)
+	return nil
+}
+
+// filterChanges keeps the changes of the given kinds under any of the
+// given container paths. Empty kinds or paths keep everything.
+func filterChanges(changes []runtime.FileChange, kinds, paths []string) []runtime.FileChange {
+	var kept []runtime.FileChange
+	for _, c := range changes {
+		if len(kinds) > 0 && !slices.Contains(kinds, string(c.Kind)) {
+			continue
+		}
+		if len(paths) > 0 && !slices.ContainsFunc(paths, func(p string) bool { return isUnderPath(c.Path, p) }) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// isUnderPath reports whether p is dir or inside it.
+func isUnderPath(p, dir string) bool {
+	dir = path.Clean("/" + dir)
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// writeChanges prints changes as "A /path" lines or a JSON array.
+func writeChanges(w io.Writer, changes []runtime.FileChange, format string) error {
+	if format == diffFormatJSON {
+		if changes == nil {
+			changes = []runtime.FileChange{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "No changes in the container's writable layer")
+		return nil
+	}
+	for _, c := range changes {
+		_, _ = fmt.Fprintf(w, "%s %s\n", diffLetters[c.Kind], c.Path)
+	}
+	return nil
+}
+
+// exportChanges copies the added and changed paths out of the container
+// into a temporary directory and writes them to w as a tar archive, with
+// names relative to the container's root. Directories listed only
+// because something inside them changed are not copied whole. Paths gone
+// since the diff was taken are skipped. Returns the number of paths
+// copied.
+func exportChanges(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, containerName string, changes []runtime.FileChange, w io.Writer) (int, error) {
+	tmpDir, err := afero.TempDir(fs, "", "alca-diff-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = fs.RemoveAll(tmpDir) }()
+
+	copied := 0
+	for _, p := range exportLeaves(changes) {
+		dst := filepath.Join(tmpDir, filepath.FromSlash(p))
+		if err := fs.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return copied, fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		err := rt.CopyFromContainer(ctx, runtimeEnv, containerName, p, dst)
+		if errors.Is(err, runtime.ErrPathNotFound) {
+			continue
+		}
+		if err != nil {
+			return copied, fmt.Errorf("failed to copy %s: %w", p, err)
+		}
+		copied++
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeTarTree(fs, tw, tmpDir); err != nil {
+		return copied, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return copied, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return copied, nil
+}
+
+// exportLeaves returns the added and changed paths that contain no other
+// listed change, so each file is copied once.
+func exportLeaves(changes []runtime.FileChange) []string {
+	var paths []string
+	for _, c := range changes {
+		if c.Kind != runtime.ChangeDeleted {
+			paths = append(paths, c.Path)
+		}
+	}
+	var leaves []string
+	for _, p := range paths {
+		if !slices.ContainsFunc(paths, func(other string) bool { return strings.HasPrefix(other, p+"/") }) {
+			leaves = append(leaves, p)
+		}
+	}
+	return leaves
+}
+
+// writeTarTree adds the entries under root to tw, named relative to root.
+func writeTarTree(fs afero.Fs, tw *tar.Writer, root string) error {
+	return afero.Walk(fs, root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			reader, ok := fs.(afero.LinkReader)
+			if !ok {
+				return nil
+			}
+			if link, err = reader.ReadlinkIfPossible(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := fs.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
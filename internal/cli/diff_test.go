@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var testChanges = []runtime.FileChange{
+	{Kind: runtime.ChangeChanged, Path: "/etc"},
+	{Kind: runtime.ChangeAdded, Path: "/etc/motd"},
+	{Kind: runtime.ChangeDeleted, Path: "/usr/share/doc"},
+	{Kind: runtime.ChangeAdded, Path: "/etcetera"},
+}
+
+func TestFilterChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		kinds []string
+		paths []string
+		want  []string
+	}{
+		{"everything", nil, nil, []string{"/etc", "/etc/motd", "/usr/share/doc", "/etcetera"}},
+		{"kind", []string{"added"}, nil, []string{"/etc/motd", "/etcetera"}},
+		{"path is a prefix of directories only", nil, []string{"/etc"}, []string{"/etc", "/etc/motd"}},
+		{"path without leading slash", nil, []string{"usr/"}, []string{"/usr/share/doc"}},
+		{"root", nil, []string{"/"}, []string{"/etc", "/etc/motd", "/usr/share/doc", "/etcetera"}},
+		{"kind and path", []string{"changed", "deleted"}, []string{"/etc", "/usr"}, []string{"/etc", "/usr/share/doc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range filterChanges(testChanges, tt.kinds, tt.paths) {
+				got = append(got, c.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteChanges(t *testing.T) {
+	var text bytes.Buffer
+	if err := writeChanges(&text, testChanges[:3], diffFormatText); err != nil {
+		t.Fatal(err)
+	}
+	if want := "C /etc\nA /etc/motd\nD /usr/share/doc\n"; text.String() != want {
+		t.Errorf("text = %q, want %q", text.String(), want)
+	}
+
+	var empty bytes.Buffer
+	if err := writeChanges(&empty, nil, diffFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(empty.String()) != "[]" {
+		t.Errorf("json for no changes = %q, want []", empty.String())
+	}
+}
+
+func TestExportLeaves(t *testing.T) {
+	got := exportLeaves(testChanges)
+	want := []string{"/etc/motd", "/etcetera"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exportLeaves() = %v, want %v", got, want)
+	}
+}
+
+func TestFakeRuntime_DiffExport(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	c := fake.Container(st.ContainerName)
+	c.Changes = testChanges
+	c.Files = map[string]string{"/etc/motd": "hello\n"}
+	fake.AddContainer(*c)
+
+	if err := runFakeCommand(t, diffCmd, runDiff); err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+
+	var archive bytes.Buffer
+	fs := afero.NewOsFs()
+	runtimeEnv := &runtime.RuntimeEnv{Cmd: util.NewMockCommandRunner(), Fs: fs}
+	n, err := exportChanges(context.Background(), fs, runtimeEnv, fake, st.ContainerName, testChanges, &archive)
+	if err != nil {
+		t.Fatalf("exportChanges: %v", err)
+	}
+	// /etcetera is listed but gone from the container
+	if n != 1 {
+		t.Errorf("exported %d path(s), want 1", n)
+	}
+
+	files := map[string]string{}
+	tr := tar.NewReader(&archive)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[hdr.Name] = string(data)
+	}
+	want := map[string]string{"etc/": "", "etc/motd": "hello\n"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("archive = %v, want %v", files, want)
+	}
+}
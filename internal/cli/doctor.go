@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+// Doctor check results.
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+	doctorSkip = "skip"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose common environment problems",
+	Long: `Check the runtime and the project's container for common problems.
+
+Checks:
+  runtime    a container runtime is available, with its capabilities
+  container  the project's container is running
+  clock      the container clock is within clock.drift_threshold of the host
+             (VM-backed runtimes only; the VM clock can fall behind after
+             the host sleeps, breaking TLS and build caches)
+  mirror     registry_mirror answers the registry API (when configured)
+
+Use --fix to correct what can be corrected automatically.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().Bool("fix", false, "Correct problems that can be fixed automatically")
+}
+
+// doctorCheck is the result of one doctor check.
+type doctorCheck struct {
+	Name   string
+	Result string
+	Detail string
+}
+
+// runDoctor runs every check and fails if any check failed.
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	fix, _ := cmd.Flags().GetBool("fix")
+	out := cmd.OutOrStdout()
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIReadDeps()
+	cfg, _, err := sandbox.LoadConfigFromCwd(ctx, deps.Env, cwd)
+	if err != nil {
+		return err
+	}
+
+	checks := doctorChecks(ctx, deps, cfg, cwd, fix)
+	checks = append(checks, checkRegistryMirror(ctx, mirrorClient, cfg.RegistryMirror))
+	failed := 0
+	for _, c := range checks {
+		_, _ = fmt.Fprintf(out, "%-4s  %-9s  %s\n", c.Result, c.Name, c.Detail)
+		if c.Result == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// doctorChecks runs the checks in order, skipping those whose prerequisites failed.
+func doctorChecks(ctx context.Context, deps sandbox.ReadDeps, cfg *config.Config, cwd string, fix bool) []doctorCheck {
+	runtimeEnv := deps.RuntimeEnv
+	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, cfg)
+	if err != nil {
+		return []doctorCheck{
+			{"runtime", doctorFail, err.Error()},
+			{"container", doctorSkip, "no runtime"},
+			{"clock", doctorSkip, "no runtime"},
+		}
+	}
+	detail := rt.Name()
+	if caps, err := rt.Capabilities(ctx, runtimeEnv); err == nil {
+		detail += " (" + caps.String() + ")"
+	}
+	checks := []doctorCheck{{"runtime", doctorOK, detail}}
+
+	st, err := sandbox.LoadRequiredState(deps.Env, cwd)
+	if err != nil {
+		return append(checks,
+			doctorCheck{"container", doctorWarn, "not initialized (run 'alca up')"},
+			doctorCheck{"clock", doctorSkip, "no container"})
+	}
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return append(checks,
+			doctorCheck{"container", doctorFail, err.Error()},
+			doctorCheck{"clock", doctorSkip, "no container"})
+	}
+	if status.State != runtime.StateRunning {
+		return append(checks,
+			doctorCheck{"container", doctorWarn, string(status.State)},
+			doctorCheck{"clock", doctorSkip, "container not running"})
+	}
+	checks = append(checks, doctorCheck{"container", doctorOK, status.Name + " running"})
+
+	platform := runtime.DetectPlatform(ctx, runtimeEnv)
+	return append(checks, checkClock(ctx, runtimeEnv, rt, cfg, platform, status.Name, fix))
+}
+
+// checkClock compares the container and host clocks. With fix, drift beyond
+// the threshold is corrected and the clock measured again.
+func checkClock(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, platform runtime.RuntimePlatform, containerName string, fix bool) doctorCheck {
+	if !runtime.IsDarwin(platform) {
+		return doctorCheck{"clock", doctorSkip, "container shares the host clock"}
+	}
+	drift, err := rt.ClockDrift(ctx, runtimeEnv, containerName)
+	if err != nil {
+		return doctorCheck{"clock", doctorFail, err.Error()}
+	}
+	threshold := cfg.Clock.Threshold()
+	if threshold == 0 || drift.Abs() <= threshold {
+		return doctorCheck{"clock", doctorOK, fmt.Sprintf("drift %s", drift)}
+	}
+	if !fix {
+		return doctorCheck{"clock", doctorFail, fmt.Sprintf("drift %s exceeds %s (run 'alca doctor --fix')", drift, threshold)}
+	}
+	if err := rt.SyncClock(ctx, runtimeEnv, cfg); err != nil {
+		return doctorCheck{"clock", doctorFail, err.Error()}
+	}
+	after, err := rt.ClockDrift(ctx, runtimeEnv, containerName)
+	if err != nil {
+		return doctorCheck{"clock", doctorFail, err.Error()}
+	}
+	if after.Abs() > threshold {
+		return doctorCheck{"clock", doctorFail, fmt.Sprintf("drift %s after correction still exceeds %s", after, threshold)}
+	}
+	return doctorCheck{"clock", doctorOK, fmt.Sprintf("corrected drift of %s", drift)}
+}
+
+// mirrorClient bounds the registry mirror probe.
+var mirrorClient = &http.Client{Timeout: 5 * time.Second}
+
+// checkRegistryMirror verifies the registry mirror answers GET /v2/, the
+// registry API version check. 401 counts as reachable: the mirror may
+// require auth for the API while still serving pulls.
+func checkRegistryMirror(ctx context.Context, client *http.Client, mirror string) doctorCheck {
+	if mirror == "" {
+		return doctorCheck{"mirror", doctorSkip, "registry_mirror not set"}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(mirror, "/")+"/v2/", nil)
+	if err != nil {
+		return doctorCheck{"mirror", doctorFail, err.Error()}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return doctorCheck{"mirror", doctorFail, fmt.Sprintf("%s unreachable: %v", mirror, err)}
+	}
+	_ = resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusUnauthorized:
+		return doctorCheck{"mirror", doctorOK, mirror}
+	default:
+		return doctorCheck{"mirror", doctorFail, fmt.Sprintf("%s is not a registry (GET /v2/: HTTP %d)", mirror, resp.StatusCode)}
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+// clockRuntime reports a sequence of clock drifts and records SyncClock calls.
+type clockRuntime struct {
+	runtime.StubRuntime
+	drifts []time.Duration
+	synced int
+}
+
+func (c *clockRuntime) ClockDrift(_ context.Context, _ *runtime.RuntimeEnv, _ string) (time.Duration, error) {
+	d := c.drifts[0]
+	if len(c.drifts) > 1 {
+		c.drifts = c.drifts[1:]
+	}
+	return d, nil
+}
+
+func (c *clockRuntime) SyncClock(_ context.Context, _ *runtime.RuntimeEnv, _ *config.Config) error {
+	c.synced++
+	return nil
+}
+
+func TestCheckClock(t *testing.T) {
+	tests := []struct {
+		name       string
+		platform   runtime.RuntimePlatform
+		threshold  string
+		drifts     []time.Duration
+		fix        bool
+		wantResult string
+		wantSynced int
+	}{
+		{"linux skipped", runtime.PlatformLinux, "", []time.Duration{time.Hour}, true, doctorSkip, 0},
+		{"within threshold", runtime.PlatformMacOrbStack, "", []time.Duration{-3 * time.Second}, true, doctorOK, 0},
+		{"drift reported", runtime.PlatformMacDockerDesktop, "", []time.Duration{-42 * time.Second}, false, doctorFail, 0},
+		{"drift fixed", runtime.PlatformMacDockerDesktop, "", []time.Duration{-42 * time.Second, 0}, true, doctorOK, 1},
+		{"fix ineffective", runtime.PlatformMacDockerDesktop, "", []time.Duration{-42 * time.Second}, true, doctorFail, 1},
+		{"custom threshold", runtime.PlatformMacDockerDesktop, "1m", []time.Duration{-42 * time.Second}, false, doctorOK, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &clockRuntime{drifts: tt.drifts}
+			cfg := &config.Config{Clock: config.Clock{DriftThreshold: tt.threshold}}
+
+			got := checkClock(context.Background(), nil, rt, cfg, tt.platform, "alca-test", tt.fix)
+			if got.Result != tt.wantResult {
+				t.Errorf("result = %q (%s), want %q", got.Result, got.Detail, tt.wantResult)
+			}
+			if rt.synced != tt.wantSynced {
+				t.Errorf("SyncClock called %d times, want %d", rt.synced, tt.wantSynced)
+			}
+		})
+	}
+}
+
+func TestCheckRegistryMirror(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantResult string
+	}{
+		{"open registry", http.StatusOK, doctorOK},
+		{"auth required", http.StatusUnauthorized, doctorOK},
+		{"not a registry", http.StatusNotFound, doctorFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v2/" {
+					t.Errorf("path = %q, want /v2/", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			got := checkRegistryMirror(context.Background(), srv.Client(), srv.URL+"/")
+			if got.Result != tt.wantResult {
+				t.Errorf("result = %q (%s), want %q", got.Result, got.Detail, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestCheckRegistryMirror_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	if got := checkRegistryMirror(context.Background(), srv.Client(), url); got.Result != doctorFail {
+		t.Errorf("result = %q (%s), want fail", got.Result, got.Detail)
+	}
+	if got := checkRegistryMirror(context.Background(), srv.Client(), ""); got.Result != doctorSkip {
+		t.Errorf("unset mirror: result = %q, want skip", got.Result)
+	}
+}
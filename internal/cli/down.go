@@ -1,20 +1,11 @@
 package cli
 
 import (
-	"context"
-	"fmt"
-	"io"
 	"os"
 
-	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
-	"github.com/bolasblack/alcatraz/internal/network"
-	"github.com/bolasblack/alcatraz/internal/runtime"
-	"github.com/bolasblack/alcatraz/internal/state"
-	"github.com/bolasblack/alcatraz/internal/sync"
-	"github.com/bolasblack/alcatraz/internal/transact"
-	"github.com/bolasblack/alcatraz/internal/util"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 )
 
 func init() {
@@ -22,18 +13,16 @@ func init() {
 }
 
 var downCmd = &cobra.Command{
-	Use:   "down",
-	Short: "Stop the sandbox environment",
-	Long:  `Stop the running Alcatraz sandbox environment.`,
-	RunE:  runDown,
+	Use:         "down",
+	Short:       "Stop the sandbox environment",
+	Long:        `Stop the running Alcatraz sandbox environment.`,
+	RunE:        runDown,
+	Annotations: destructive,
 }
 
 // runDown stops and removes the container.
 // See AGD-009 for CLI workflow design.
 func runDown(cmd *cobra.Command, args []string) error {
-	ctx := cmd.Context()
-	var out io.Writer = os.Stdout
-
 	force, err := cmd.Flags().GetBool("force")
 	if err != nil {
 		return err
@@ -43,159 +32,5 @@ func runDown(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-
-	// Create shared dependencies once
-	deps := newCLIDeps()
-	tfs, env, runtimeEnv := deps.Tfs, deps.Env, deps.RuntimeEnv
-
-	// Load config (optional) and select runtime
-	cfg, rt, err := loadConfigAndRuntimeOptional(ctx, env, runtimeEnv, cwd)
-	if err != nil {
-		return err
-	}
-
-	util.ProgressStep(out, "Using runtime: %s\n", rt.Name())
-
-	// Load state (optional - missing state is not an error for down)
-	st, err := loadStateOptional(env, cwd)
-	if err != nil {
-		return err
-	}
-
-	if st == nil {
-		util.ProgressStep(out, "No state file found. Container may not exist.\n")
-		return nil
-	}
-
-	// Check for sync conflicts before destroying container (AGD-031).
-	// Once the container is gone, conflicts become unresolvable.
-	syncFs := afero.NewOsFs()
-	syncEnv := sync.NewSyncEnv(syncFs, deps.CmdRunner, runtime.NewMutagenSyncClient(runtimeEnv))
-	if err := guardSyncConflicts(ctx, syncFs, syncEnv, cwd, st.ProjectID, force, os.Stderr); err != nil {
-		return err
-	}
-
-	// Execute pre_down hook on host (runs before any teardown)
-	if cfg.Hooks.PreDown != "" {
-		util.ProgressStep(out, "Running pre_down hook...\n")
-		if err := runHook(ctx, deps.CmdRunner, cfg.Hooks.PreDown, cwd); err != nil {
-			util.ProgressStep(out, "Warning: pre_down hook failed: %v\n", err)
-		}
-	}
-
-	platform := runtime.DetectPlatform(ctx, runtimeEnv)
-
-	// Create shared network env once for all network operations (AGD-029)
-	networkEnv := network.NewNetworkEnv(tfs, deps.CmdRunner, cwd, st.ProjectID, platform)
-	fw, _ := network.New(ctx, networkEnv)
-
-	// Cleanup firewall rules before stopping container (need container ID)
-	// See AGD-027 for design decisions
-	// Files removed via tfs, committed to real disk before nft cleanup commands run.
-	if err := cleanupFirewall(ctx, fw, env, tfs, runtimeEnv, rt, st, out); err != nil {
-		util.ProgressStep(out, "Warning: firewall cleanup: %v\n", err)
-	}
-
-	// Stop container
-	util.ProgressStep(out, "Stopping container...\n")
-	if err := rt.Down(ctx, runtimeEnv, cwd, st); err != nil {
-		return fmt.Errorf("failed to stop container: %w", err)
-	}
-
-	// Network cleanup
-	nh := network.NewNetworkHelperForProject(cfg.Network, platform)
-	if nh != nil {
-		if err := nh.Teardown(networkEnv, cwd); err != nil {
-			util.ProgressStep(out, "Warning: failed to cleanup network: %v\n", err)
-		}
-
-		if err := commitIfNeeded(ctx, env, tfs, out, "Removing firewall rules"); err != nil {
-			util.ProgressStep(out, "Warning: failed to commit: %v\n", err)
-		}
-	}
-
-	util.ProgressDone(out, "Container stopped\n")
-	return nil
-}
-
-// guardSyncConflicts checks for unresolved sync conflicts and blocks if found.
-// When force is true, prints a warning and allows the operation to proceed.
-// Best-effort: detection errors are logged to stderr to avoid blocking 'down'.
-func guardSyncConflicts(ctx context.Context, fs afero.Fs, syncEnv *sync.SyncEnv, projectRoot string, projectID string, force bool, w io.Writer) error {
-	if force {
-		_, _ = fmt.Fprintln(w, "Warning: --force used, skipping sync conflict check. Conflicts may become unresolvable after container is destroyed.")
-		return nil
-	}
-
-	conflicts := checkSyncConflictsBeforeDown(ctx, fs, syncEnv, projectRoot, projectID)
-	if len(conflicts) > 0 {
-		sync.RenderBanner(conflicts, w)
-		return fmt.Errorf("resolve sync conflicts before stopping the container, or use --force to skip this check: %w", errSyncConflicts)
-	}
-	return nil
-}
-
-// checkSyncConflictsBeforeDown reads cached conflicts (fast path) or polls
-// mutagen synchronously (slow path) when no cache exists.
-// Best-effort: detection errors are logged to stderr to avoid blocking 'down'.
-func checkSyncConflictsBeforeDown(ctx context.Context, fs afero.Fs, syncEnv *sync.SyncEnv, projectRoot string, projectID string) []sync.ConflictInfo {
-	// Fast path: read from cache
-	cache, err := sync.ReadCache(fs, projectRoot)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to read sync conflict cache: %v\n", err)
-	}
-	if cache != nil {
-		return cache.Conflicts
-	}
-
-	// Slow path: cache missing, do synchronous poll
-	cache, err = sync.SyncUpdateCache(ctx, syncEnv, projectID, projectRoot)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to check sync conflicts: %v\n", err)
-	}
-	if cache != nil {
-		return cache.Conflicts
-	}
-	return nil
-}
-
-// cleanupFirewall removes firewall rules for the container.
-// See AGD-027 for design decisions.
-func cleanupFirewall(ctx context.Context, fw network.Firewall, env *util.Env, tfs *transact.TransactFs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, out io.Writer) error {
-	if fw == nil {
-		return nil
-	}
-
-	// Clean up stale rule files before container-specific cleanup
-	if staleCount, err := fw.CleanupStaleFiles(ctx); err != nil {
-		util.ProgressStep(out, "Warning: stale rule cleanup: %v\n", err)
-	} else if staleCount > 0 {
-		util.ProgressStep(out, "Cleaned up %d stale firewall rule file(s)\n", staleCount)
-	}
-
-	// Get container status to find the container ID
-	status, err := rt.Status(ctx, runtimeEnv, "", st)
-	if err != nil || status.State == runtime.StateNotFound {
-		return nil
-	}
-
-	// Cleanup firewall rules (removes files via tfs)
-	action, err := fw.Cleanup(status.ID)
-	if err != nil {
-		return fmt.Errorf("cleanup firewall rules: %w", err)
-	}
-
-	// Commit tfs to remove files from real disk
-	if err := commitIfNeeded(ctx, env, tfs, out, "Removing firewall rules"); err != nil {
-		return fmt.Errorf("commit firewall cleanup: %w", err)
-	}
-
-	// Run post-commit action (nft delete table or reload)
-	if action != nil && action.Run != nil {
-		if err := action.Run(ctx, nil); err != nil {
-			return fmt.Errorf("execute firewall cleanup: %w", err)
-		}
-	}
-
-	return nil
+	return sandbox.Down(cmd.Context(), newHostEnv(), cwd, sandbox.DownOptions{Force: force, Out: os.Stdout})
 }
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var duCmd = &cobra.Command{
+	Use:   "du",
+	Short: "Show the disk space the sandbox uses",
+	Long: `Show the disk space the project's sandbox takes: its image (and baked
+image), the container's writable layer, named volumes (the dind daemon's
+storage), Mutagen's sync state on the host, and the .alca directory.
+
+With --all, every alca project with a container is listed, with a total in
+which images shared between projects are counted once.
+
+Image sizes include layers shared with other images, so removing an image
+may free less. To reclaim space: 'alca down' removes a project's container
+(volumes are kept), 'alca cleanup' removes the containers of deleted
+projects, and 'docker image prune' (or 'podman image prune') removes
+images no container uses.`,
+	Args: cobra.NoArgs,
+	RunE: runDu,
+}
+
+func init() {
+	duCmd.Flags().Bool("all", false, "Report every alca project instead of the current one")
+}
+
+// duEntry is one line of the disk usage report.
+type duEntry struct {
+	what  string
+	name  string
+	bytes int64
+	// image is set for images, which projects may share.
+	image bool
+}
+
+// projectDiskUsage is the disk usage of one project.
+type projectDiskUsage struct {
+	dir     string
+	entries []duEntry
+}
+
+// total sums the project's entries.
+func (u projectDiskUsage) total() int64 {
+	var n int64
+	for _, e := range u.entries {
+		n += e.bytes
+	}
+	return n
+}
+
+// sum sums the entries matching what.
+func (u projectDiskUsage) sum(what string) int64 {
+	var n int64
+	for _, e := range u.entries {
+		if e.what == what {
+			n += e.bytes
+		}
+	}
+	return n
+}
+
+const (
+	duImage      = "image"
+	duBakedImage = "baked image"
+	duContainer  = "container layer"
+	duVolume     = "volume"
+	duSync       = "sync state"
+	duStateDir   = state.StateDir
+)
+
+func runDu(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	all, _ := cmd.Flags().GetBool("all")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+	deps := newCLIReadDeps()
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+
+	cfg, rt, err := sandbox.LoadConfigAndRuntimeOptional(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	syncUsage := newMutagenUsage()
+
+	if all {
+		return runDuAll(ctx, env, runtimeEnv, rt, syncUsage, os.Stdout)
+	}
+
+	st, err := sandbox.LoadStateOptional(env, cwd)
+	if err != nil {
+		return err
+	}
+	usage := measureProjectDiskUsage(ctx, env, runtimeEnv, rt, syncUsage, cwd, cfg, st, os.Stderr)
+	printProjectDiskUsage(os.Stdout, usage)
+	return nil
+}
+
+// runDuAll reports every project with an alca container.
+func runDuAll(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, syncUsage mutagenUsage, out io.Writer) error {
+	containers, err := rt.ListContainers(ctx, runtimeEnv)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	if len(containers) == 0 {
+		_, _ = fmt.Fprintln(out, "No Alcatraz containers found.")
+		return nil
+	}
+
+	var usages []projectDiskUsage
+	for _, c := range containers {
+		st, _ := state.Load(env, c.ProjectPath)
+		if c.ProjectPath == "" || st == nil || st.ProjectID != c.ProjectID {
+			// Orphan: measure what the runtime still holds
+			st = &state.State{ProjectID: c.ProjectID, ContainerName: c.Name}
+		}
+		cfg := &config.Config{Image: c.Image}
+		if loaded, _, err := sandbox.LoadConfigFromCwd(ctx, env, c.ProjectPath); err == nil {
+			cfg = loaded
+		}
+		usages = append(usages, measureProjectDiskUsage(ctx, env, runtimeEnv, rt, syncUsage, c.ProjectPath, cfg, st, os.Stderr))
+	}
+	printAllDiskUsage(env.Fs, out, usages)
+	return nil
+}
+
+// measureProjectDiskUsage measures a project. Parts that cannot be measured
+// are reported to warn and left out. cfg and st may be nil.
+func measureProjectDiskUsage(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, syncUsage mutagenUsage, dir string, cfg *config.Config, st *state.State, warn io.Writer) projectDiskUsage {
+	usage := projectDiskUsage{dir: dir}
+
+	if st != nil {
+		var images []string
+		if cfg != nil && cfg.Image != "" {
+			images = append(images, cfg.Image)
+		}
+		if st.Bake != nil {
+			images = append(images, st.Bake.Image)
+		}
+		items, err := rt.DiskUsage(ctx, runtimeEnv, st.ContainerName, images, []string{runtime.DindVolumeName(st.ProjectID)})
+		if err != nil {
+			_, _ = fmt.Fprintf(warn, "Warning: %s: %v\n", sandbox.DisplayOrDefault(dir), err)
+		}
+		for _, item := range items {
+			e := duEntry{name: item.Name, bytes: item.Bytes}
+			switch {
+			case item.Kind == runtime.DiskUsageImage && st.Bake != nil && item.Name == st.Bake.Image:
+				e.what, e.image = duBakedImage, true
+			case item.Kind == runtime.DiskUsageImage:
+				e.what, e.image = duImage, true
+			case item.Kind == runtime.DiskUsageContainer:
+				e.what = duContainer
+			default:
+				e.what = duVolume
+			}
+			usage.entries = append(usage.entries, e)
+		}
+
+		if n, sessions := syncUsage.size(ctx, runtimeEnv, env.Fs, st.ProjectID); sessions > 0 {
+			usage.entries = append(usage.entries, duEntry{what: duSync, name: fmt.Sprintf("%d Mutagen session(s)", sessions), bytes: n})
+		}
+	}
+
+	if dir != "" {
+		stateDir := state.StateDirPath(dir)
+		if n, err := dirSize(env.Fs, stateDir); err == nil {
+			usage.entries = append(usage.entries, duEntry{what: duStateDir, name: stateDir, bytes: n})
+		}
+	}
+	return usage
+}
+
+// printProjectDiskUsage prints one project's entries and total.
+func printProjectDiskUsage(out io.Writer, usage projectDiskUsage) {
+	_, _ = fmt.Fprintf(out, "Disk usage of %s:\n", usage.dir)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, e := range usage.entries {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\n", e.what, e.name, util.FormatSize(e.bytes))
+	}
+	_, _ = fmt.Fprintf(w, "  total\t\t%s\n", util.FormatSize(usage.total()))
+	_ = w.Flush()
+	_, _ = fmt.Fprintln(out, "Image sizes include layers shared with other images.")
+}
+
+// printAllDiskUsage prints a row per project and a total that counts each
+// image once. Projects whose directory is gone from fs are marked deleted.
+func printAllDiskUsage(fs afero.Fs, out io.Writer, usages []projectDiskUsage) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PROJECT\tIMAGES\tCONTAINER\tVOLUMES\tSYNC\t.ALCA\tTOTAL")
+
+	var total int64
+	seen := map[string]bool{}
+	for _, u := range usages {
+		dir := u.dir
+		if dir == "" {
+			dir = "(unknown)"
+		} else if _, err := fs.Stat(dir); err != nil {
+			dir += " (deleted)"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", dir,
+			util.FormatSize(u.sum(duImage)+u.sum(duBakedImage)), util.FormatSize(u.sum(duContainer)),
+			util.FormatSize(u.sum(duVolume)), util.FormatSize(u.sum(duSync)),
+			util.FormatSize(u.sum(duStateDir)), util.FormatSize(u.total()))
+
+		for _, e := range u.entries {
+			if e.image {
+				if seen[e.name] {
+					continue
+				}
+				seen[e.name] = true
+			}
+			total += e.bytes
+		}
+	}
+	_ = w.Flush()
+	_, _ = fmt.Fprintf(out, "\nTotal: %s (images shared between projects counted once)\n", util.FormatSize(total))
+}
+
+// mutagenUsage measures Mutagen's host-side state (caches, staging and
+// archives) of sync sessions. The container side lives in the container's
+// writable layer.
+type mutagenUsage struct {
+	dataDir string
+}
+
+// newMutagenUsage locates Mutagen's data directory.
+func newMutagenUsage() mutagenUsage {
+	dataDir := os.Getenv("MUTAGEN_DATA_DIRECTORY")
+	if dataDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dataDir = filepath.Join(home, ".mutagen")
+		}
+	}
+	return mutagenUsage{dataDir: dataDir}
+}
+
+// size returns the bytes Mutagen stores for a project's sessions, found by
+// their identifiers in file names, and the number of sessions.
+func (m mutagenUsage) size(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, fs afero.Fs, projectID string) (int64, int) {
+	ids := runtime.ProjectSyncIdentifiers(ctx, runtimeEnv, projectID)
+	if len(ids) == 0 || m.dataDir == "" {
+		return 0, len(ids)
+	}
+	var n int64
+	_ = afero.Walk(fs, m.dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		for _, id := range ids {
+			if strings.Contains(path, id) {
+				n += info.Size()
+				break
+			}
+		}
+		return nil
+	})
+	return n, len(ids)
+}
+
+// dirSize sums the sizes of the files under dir.
+func dirSize(fs afero.Fs, dir string) (int64, error) {
+	var n int64
+	err := afero.Walk(fs, dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			n += info.Size()
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestFakeRuntime_DiskUsage(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	c := fake.Container(st.ContainerName)
+	c.DiskSize = 3 << 20
+	fake.AddContainer(*c)
+
+	osEnv := &util.Env{Fs: afero.NewOsFs()}
+	cfg, _, err := sandbox.LoadConfigFromCwd(context.Background(), osEnv, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warn strings.Builder
+	usage := measureProjectDiskUsage(context.Background(), osEnv, &runtime.RuntimeEnv{Cmd: util.NewMockCommandRunner()}, fake, mutagenUsage{}, dir, cfg, st, &warn)
+	if warn.Len() > 0 {
+		t.Errorf("unexpected warnings: %s", warn.String())
+	}
+	if got := usage.sum(duImage); got != runtime.FakeImageSize {
+		t.Errorf("image = %d, want %d", got, runtime.FakeImageSize)
+	}
+	if got := usage.sum(duContainer); got != 3<<20 {
+		t.Errorf("container layer = %d, want %d", got, 3<<20)
+	}
+	if usage.sum(duStateDir) == 0 {
+		t.Error(".alca not measured")
+	}
+
+	// A second project on the same image counts it once in the total
+	var out strings.Builder
+	printAllDiskUsage(afero.NewOsFs(), &out, []projectDiskUsage{usage, usage})
+	want := util.FormatSize(2*usage.total() - runtime.FakeImageSize)
+	if !strings.Contains(out.String(), "Total: "+want+" ") {
+		t.Errorf("output missing total %s:\n%s", want, out.String())
+	}
+	if strings.Contains(out.String(), "(deleted)") {
+		t.Errorf("existing project marked deleted:\n%s", out.String())
+	}
+
+	out.Reset()
+	printAllDiskUsage(afero.NewMemMapFs(), &out, []projectDiskUsage{usage})
+	if !strings.Contains(out.String(), dir+" (deleted)") {
+		t.Errorf("missing project not marked deleted:\n%s", out.String())
+	}
+}
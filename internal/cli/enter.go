@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+var enterCmd = &cobra.Command{
+	Use:   "enter",
+	Short: "Open a shell inside the sandbox",
+	Long: `Open an interactive shell inside the Alcatraz sandbox.
+Equivalent to 'alca run <shell>', including commands.enter and history.
+
+With --readonly, the shell runs in a separate inspection container instead:
+every mount of the sandbox is attached read-only, the session runs as nobody
+with no capabilities, and networking is disabled. Nothing done in the session
+can modify the sandbox, so reviewers can look around an agent's workspace
+without perturbing it. commands.enter is not applied and nothing is recorded
+in history. Changes the agent made outside its mounts are not visible.
+A workdir synced by Mutagen cannot be attached, so --readonly needs a
+bind-mounted workdir.
+
+Like git, alca finds the project from any subdirectory: the nearest
+.alca.toml above the current directory is used, and the shell starts in
+the matching container directory (for example /workspace/src when entered
+from src/). Directories no mount covers start in the workdir.
+
+The shell runs as the user set by --user, commands.enter.user or user in
+.alca.toml, in that order; otherwise as the image's default user.
+
+With enter_tmux = true in .alca.toml, every enter attaches to one shared
+tmux session in the container (zellij if tmux is not installed), created on
+first enter, so several host terminals share the same session state.
+
+The session marks itself as sandboxed: the terminal title names the project
+and container, and ALCA_SANDBOX=1 and ALCA_PROJECT_NAME are set. [ui] in
+.alca.toml changes the title and can prefix the shell prompt.
+
+In a workspace, --member enters a member project's container instead.
+
+If the container is stopped or gone (e.g. the runtime daemon restarted),
+stale sync sessions are removed and 'alca up' is offered; --auto-up runs it
+without asking.
+
+If .alca.toml changed since the container was created (so a new env or
+mount is not there yet), a one-line warning names the changed settings.
+--auto-reconcile runs 'alca up' first instead, which applies network and
+resource limit changes in place and rebuilds the container, after
+confirmation (--yes skips it), only for changes that need one.`,
+	Args: cobra.NoArgs,
+	RunE: runEnter,
+}
+
+func init() {
+	enterCmd.Flags().String("shell", "sh", "Shell to start inside the sandbox")
+	enterCmd.Flags().Bool("readonly", false, "Inspect the sandbox read-only, as nobody, without network")
+	enterCmd.Flags().StringP("user", "u", "", "Enter as this user (name or uid[:gid]), overriding the config")
+	enterCmd.Flags().String("member", "", "Enter this workspace member's container")
+	enterCmd.Flags().Bool("auto-up", false, "Run 'alca up' first if the container is stopped or gone")
+	enterCmd.Flags().Bool("auto-reconcile", false, "Run 'alca up' first if the config changed since the container was created")
+	enterCmd.MarkFlagsMutuallyExclusive("readonly", "user")
+}
+
+// runEnter opens a shell in the sandbox, or a read-only inspection session.
+func runEnter(cmd *cobra.Command, args []string) error {
+	shell, _ := cmd.Flags().GetString("shell")
+	readonly, _ := cmd.Flags().GetBool("readonly")
+	user, _ := cmd.Flags().GetString("user")
+	member, _ := cmd.Flags().GetString("member")
+
+	projectDir, err := memberProjectDir(member)
+	if err != nil {
+		return err
+	}
+	if !readonly {
+		opts := execOptionsFromCmd(cmd, user)
+		opts.Recovery.CheckDrift = true
+		return passExitCode(sandbox.Enter(cmd.Context(), newHostEnv(), projectDir, shell, opts))
+	}
+	return passExitCode(runReadonlyEnter(cmd.Context(), projectDir, shell))
+}
+
+// runReadonlyEnter starts an inspection session against the running container.
+// A shell that exits non-zero returns its *exec.ExitError.
+func runReadonlyEnter(ctx context.Context, cwd string, shell string) error {
+	deps := newCLIReadDeps()
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	if err := sandbox.CheckProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
+		return err
+	}
+
+	execCfg := *cfg
+	if hostDir, err := getCwd(); err == nil {
+		execCfg.Workdir = sandbox.ExecWorkdir(cfg, cwd, hostDir)
+	}
+	err = rt.ExecReadonly(ctx, runtimeEnv, &execCfg, cwd, st, []string{shell})
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return err
+	}
+	if errors.Is(err, runtime.ErrNotRunning) {
+		return errors.New(sandbox.ErrMsgNotRunning)
+	}
+	return fmt.Errorf("failed to start inspection session: %w", err)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+func TestFakeRuntime_EnterTmux(t *testing.T) {
+	fake, dir := setupFakeProject(t, "enter_tmux = true\n"+fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := runFakeCommand(t, enterCmd, runEnter); err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+
+	c := fake.Container(loadFakeState(t, dir).ContainerName)
+	last := c.Execs[len(c.Execs)-1]
+	if len(last) != 3 || last[0] != "sh" || !strings.Contains(last[2], "tmux new-session -A -s alca 'sh'") {
+		t.Errorf("enter exec = %q, want the shared tmux session", last)
+	}
+}
+
+func TestFakeRuntime_EnterAutoReconcile(t *testing.T) {
+	_, dir := setupFakeProject(t, fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	writeFakeConfig(t, dir, fakeProjectConfig+`
+[envs]
+FOO = "bar"
+`)
+
+	var out strings.Builder
+	recovery := &sandbox.Recovery{
+		CheckDrift:    true,
+		AutoReconcile: true,
+		Up: func(context.Context, string) error {
+			return runFakeCommand(t, upCmd, runUp, "quiet", "force")
+		},
+		Out: &out,
+	}
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{Recovery: recovery}); err != nil {
+		t.Fatalf("enter --auto-reconcile: %v", err)
+	}
+	if !strings.Contains(out.String(), "(envs)") {
+		t.Errorf("output does not name the drift: %q", out.String())
+	}
+	if st := loadFakeState(t, dir); st.Config.Envs["FOO"].Value != "bar" {
+		t.Errorf("container not recreated with the new envs: %+v", st.Config.Envs)
+	}
+}
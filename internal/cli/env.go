@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print the resolved container environment",
+	Long: `Print the environment variables the container receives, after merging
+defaults with the configured envs and expanding ${VAR} references from
+the current host environment.
+
+Variables that expand to an empty value are omitted, since they are not
+passed to the container. Use --enter to show only the variables that are
+re-applied on every 'alca run' (override_on_enter). Values of envs marked
+sensitive are masked unless --show-sensitive is given.
+
+Formats:
+  dotenv  KEY="value" lines (default)
+  json    a JSON object
+  export  'export KEY=value' lines for eval in a host shell`,
+	Args: cobra.NoArgs,
+	RunE: runEnv,
+}
+
+// containerEnvProfile, written in the container by 'alca env sync', makes
+// login shells source sandbox.ContainerEnvFile.
+const containerEnvProfile = "/etc/profile.d/alca-env.sh"
+
+var envSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Keep an env file in the container up to date with the host",
+	Long: `Write the override_on_enter variables, expanded from the current host
+environment, to ` + sandbox.ContainerEnvFile + ` in the container, and from now on
+rewrite it on every 'alca enter' and 'alca run'.
+
+Each enter already sets these variables for its own session, but shells and
+background processes started by earlier sessions keep the values they were
+started with. They can pick up the latest ones by sourcing the file:
+
+  . ` + sandbox.ContainerEnvFile + `
+
+Login shells source it through ` + containerEnvProfile + `. The file is
+replaced atomically, so concurrent enters from several terminals never leave
+it half-written; the last one wins. It is readable by every user in the
+container.
+
+--off stops the refresh; the file keeps its last values.`,
+	Args: cobra.NoArgs,
+	RunE: runEnvSync,
+}
+
+func init() {
+	envSyncCmd.Flags().Bool("off", false, "Stop refreshing the env file on enter")
+	envCmd.AddCommand(envSyncCmd)
+
+	envCmd.Flags().StringP("format", "o", sandbox.EnvFormatDotenv, "Output format (dotenv, json, export)")
+	envCmd.Flags().Bool("enter", false, "Show only override_on_enter variables")
+	envCmd.Flags().Bool("show-sensitive", false, "Print values of envs marked sensitive instead of masking them")
+}
+
+// runEnv prints the resolved container environment.
+func runEnv(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	enterOnly, _ := cmd.Flags().GetBool("enter")
+	showSensitive, _ := cmd.Flags().GetBool("show-sensitive")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIReadDeps()
+	cfg, _, err := sandbox.LoadConfigFromCwd(cmd.Context(), deps.Env, cwd)
+	if err != nil {
+		return err
+	}
+
+	envs := cfg.ResolvedEnvs(os.Getenv, enterOnly)
+	if !showSensitive {
+		maskSensitiveEnvs(envs, cfg.MergedEnvs())
+	}
+	return sandbox.WriteEnvs(os.Stdout, envs, format)
+}
+
+// maskSensitiveEnvs replaces the values of envs marked sensitive. Masking by
+// key rather than by value also covers quoting that would hide the value
+// from the output filter.
+func maskSensitiveEnvs(resolved map[string]string, envs map[string]config.EnvValue) {
+	for k := range resolved {
+		if envs[k].Sensitive {
+			resolved[k] = util.RedactedValue
+		}
+	}
+}
+
+// runEnvSync writes the container env file and turns its refresh on or off.
+func runEnvSync(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	off, _ := cmd.Flags().GetBool("off")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	if err := syncContainerEnv(ctx, runtimeEnv, rt, cfg, cwd, st, !off); err != nil {
+		return err
+	}
+	// Commands such as run load state read-only, so the state is written
+	// through the real filesystem, as recordEvent does.
+	if err := state.Save(host, cwd, st); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	if off {
+		util.ProgressDone(os.Stdout, "%s is no longer refreshed on enter\n", sandbox.ContainerEnvFile)
+		return nil
+	}
+	util.ProgressDone(os.Stdout, "Wrote %s; every enter now refreshes it\n", sandbox.ContainerEnvFile)
+	fmt.Printf("Long-running shells can load the latest values with: . %s\n", sandbox.ContainerEnvFile)
+	return nil
+}
+
+// syncContainerEnv sets st.EnvSync and, when enabling, installs the profile
+// hook and writes the env file in the running container.
+func syncContainerEnv(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, cwd string, st *state.State, enable bool) error {
+	st.EnvSync = enable
+	if !enable {
+		return nil
+	}
+
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State != runtime.StateRunning {
+		return errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	profile := fmt.Sprintf("if [ -r %[1]s ]; then . %[1]s; fi\n", sandbox.ContainerEnvFile)
+	if err := rt.WriteFile(ctx, runtimeEnv, status.Name, containerEnvProfile, profile); err != nil {
+		return err
+	}
+	return sandbox.WriteContainerEnv(ctx, runtimeEnv, rt, cfg, status.Name)
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/sandboxinfo"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestMaskSensitiveEnvs(t *testing.T) {
+	resolved := map[string]string{"TOKEN": "tok-123456", "PLAIN": "visible"}
+	maskSensitiveEnvs(resolved, map[string]config.EnvValue{
+		"TOKEN": {Value: "${TOKEN}", Sensitive: true},
+		"PLAIN": {Value: "visible"},
+	})
+	if resolved["TOKEN"] != util.RedactedValue || resolved["PLAIN"] != "visible" {
+		t.Errorf("masked envs = %v", resolved)
+	}
+}
+
+func TestFakeRuntime_EnvSync(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+
+	// Not opted in: enter leaves the container's files alone
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for path := range fake.Container(st.ContainerName).Files {
+		if path != sandboxinfo.ContainerPath {
+			t.Fatalf("%s written before env sync", path)
+		}
+	}
+
+	t.Setenv("TERM", "xterm-one")
+	if err := runFakeCommand(t, envSyncCmd, runEnvSync); err != nil {
+		t.Fatalf("env sync: %v", err)
+	}
+	c := fake.Container(st.ContainerName)
+	if !strings.Contains(c.Files[sandbox.ContainerEnvFile], "export TERM='xterm-one'\n") {
+		t.Errorf("env file = %q", c.Files[sandbox.ContainerEnvFile])
+	}
+	if !strings.Contains(c.Files[containerEnvProfile], sandbox.ContainerEnvFile) {
+		t.Errorf("profile hook = %q", c.Files[containerEnvProfile])
+	}
+	if !loadFakeState(t, dir).EnvSync {
+		t.Error("env sync not recorded in state")
+	}
+
+	// Every later session refreshes the file with the host's current values
+	t.Setenv("TERM", "xterm-two")
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := fake.Container(st.ContainerName).Files[sandbox.ContainerEnvFile]; !strings.Contains(got, "export TERM='xterm-two'\n") {
+		t.Errorf("env file after enter = %q", got)
+	}
+
+	if err := runFakeCommand(t, envSyncCmd, runEnvSync, "off"); err != nil {
+		t.Fatalf("env sync --off: %v", err)
+	}
+	t.Setenv("TERM", "xterm-three")
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := fake.Container(st.ContainerName).Files[sandbox.ContainerEnvFile]; strings.Contains(got, "xterm-three") {
+		t.Errorf("env file refreshed after --off: %q", got)
+	}
+}
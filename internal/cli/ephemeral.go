@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/network"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// runEphemeral starts a throwaway container named alca-<purpose>-<id> from the
+// project config, runs the command returned by buildCommand in it, and removes
+// the container with its Mutagen syncs and firewall rules. state.json is never
+// read or written. SIGINT, SIGTERM and SIGHUP cancel the bring-up but never
+// skip teardown. A non-zero exit status of the command becomes alca's own.
+func runEphemeral(cmd *cobra.Command, purpose string, buildCommand func(cfg *config.Config) ([]string, error)) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+	// Teardown must finish even after a signal cancelled ctx.
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	prompt := promptModeFromCmd(cmd)
+
+	var out io.Writer = os.Stdout
+	if quiet {
+		out = nil
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewDeps(host)
+	tfs, env, runtimeEnv := deps.Tfs, deps.Env, deps.RuntimeEnv
+
+	cfg, _, err := sandbox.LoadConfigFromCwd(ctx, env, cwd)
+	if err != nil {
+		return err
+	}
+	execCmd, err := buildCommand(cfg)
+	if err != nil {
+		return err
+	}
+
+	rt, err := runtime.SelectRuntimeWithOutput(ctx, runtimeEnv, cfg, out)
+	if err != nil {
+		return fmt.Errorf("failed to select runtime: %w", err)
+	}
+	if err := runtime.ValidateMutagenAvailable(ctx, runtimeEnv, cfg); err != nil {
+		return err
+	}
+
+	platform := runtime.DetectPlatform(ctx, runtimeEnv)
+	st := state.NewEphemeral(rt.Name(), purpose)
+	networkEnv := network.NewNetworkEnv(tfs, deps.CmdRunner, cwd, st.ProjectID, platform)
+	fw, fwType := network.New(ctx, networkEnv)
+
+	teardown := func() {
+		if err := sandbox.CleanupFirewall(cleanupCtx, fw, env, tfs, runtimeEnv, rt, st, out); err != nil {
+			util.ProgressStep(out, "Warning: firewall cleanup: %v\n", err)
+		}
+		util.ProgressStep(out, "Removing %s container %s...\n", purpose, st.ContainerName)
+		if err := rt.Down(cleanupCtx, runtimeEnv, cwd, st); err != nil {
+			util.ProgressStep(out, "Warning: failed to remove %s: %v\n", st.ContainerName, err)
+		}
+	}
+
+	util.ProgressStep(out, "Starting %s container %s...\n", purpose, st.ContainerName)
+	if err := rt.Up(ctx, runtimeEnv, cfg, cwd, st, out); err != nil {
+		teardown()
+		return fmt.Errorf("failed to start %s container: %w", purpose, err)
+	}
+
+	nh := network.NewNetworkHelperForProject(cfg.Network, platform)
+	if _, err := sandbox.SetupFirewall(ctx, host.Fs, fw, fwType, networkEnv, env, tfs, runtimeEnv, cfg.Network, rt, st, nh, out, prompt); err != nil {
+		if !errors.Is(err, sandbox.ErrSkipFirewall) {
+			// Never run with less isolation than configured
+			teardown()
+			return err
+		}
+	}
+
+	sandbox.ApplyShaping(ctx, networkEnv, runtimeEnv, rt, st, cfg.Network.Shaping, out)
+
+	if ctx.Err() != nil {
+		teardown()
+		return fmt.Errorf("interrupted: %w", ctx.Err())
+	}
+
+	execErr := rt.Exec(ctx, runtimeEnv, cfg, cwd, st, execCmd)
+	teardown()
+
+	if execErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(execErr, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return fmt.Errorf("failed to run command: %w", execErr)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// errorOutput is how a failed command reports its error with --format json.
+type errorOutput struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code        util.ErrorCode `json:"code,omitempty"`
+	Message     string         `json:"message"`
+	Remediation string         `json:"remediation,omitempty"`
+	DocsURL     string         `json:"docs_url,omitempty"`
+}
+
+// newErrorDetail describes err, with the code and hint of the first
+// util.HintedError in its chain.
+func newErrorDetail(err error) errorDetail {
+	d := errorDetail{Message: util.Redact(err.Error())}
+	if h, ok := util.AsHintedError(err); ok {
+		d.Code = h.Code
+		d.Remediation = h.Remediation
+		d.DocsURL = h.DocsURL()
+	}
+	return d
+}
+
+// writeError reports a failed command: as a JSON object when the command
+// was asked for JSON output (--format json), otherwise as the message
+// followed by the remediation hint and docs link, if any.
+func writeError(w io.Writer, cmd *cobra.Command, err error) {
+	d := newErrorDetail(err)
+	if wantsJSON(cmd) {
+		data, _ := json.Marshal(errorOutput{Error: d})
+		_, _ = fmt.Fprintln(w, string(data))
+		return
+	}
+
+	_, _ = fmt.Fprintln(w, d.Message)
+	if d.Remediation != "" {
+		_, _ = fmt.Fprintf(w, "\n%s\nSee %s\n", d.Remediation, d.DocsURL)
+	}
+}
+
+// wantsJSON reports whether cmd has a --format (-o) flag set to json.
+func wantsJSON(cmd *cobra.Command) bool {
+	if cmd == nil {
+		return false
+	}
+	f := cmd.Flags().Lookup("format")
+	return f != nil && f.Value.String() == "json"
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestWriteError(t *testing.T) {
+	hinted := fmt.Errorf("failed to select runtime: %w",
+		util.NewHintedError(util.CodeRuntimeUnavailable, "no container runtime available", "Start Docker.", nil))
+
+	textCmd := &cobra.Command{Use: "up"}
+	jsonCmd := &cobra.Command{Use: "status"}
+	jsonCmd.Flags().StringP("format", "o", "text", "")
+	if err := jsonCmd.Flags().Set("format", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("text with hint", func(t *testing.T) {
+		var out strings.Builder
+		writeError(&out, textCmd, hinted)
+		want := "failed to select runtime: no container runtime available\n\nStart Docker.\nSee " +
+			util.ErrorDocsURL + "#runtime-unavailable\n"
+		if out.String() != want {
+			t.Errorf("output = %q, want %q", out.String(), want)
+		}
+	})
+
+	t.Run("text without hint", func(t *testing.T) {
+		var out strings.Builder
+		writeError(&out, textCmd, errors.New("boom"))
+		if out.String() != "boom\n" {
+			t.Errorf("output = %q, want %q", out.String(), "boom\n")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out strings.Builder
+		writeError(&out, jsonCmd, hinted)
+		var got errorOutput
+		if err := json.Unmarshal([]byte(out.String()), &got); err != nil {
+			t.Fatalf("output %q is not JSON: %v", out.String(), err)
+		}
+		want := errorDetail{
+			Code:        util.CodeRuntimeUnavailable,
+			Message:     "failed to select runtime: no container runtime available",
+			Remediation: "Start Docker.",
+			DocsURL:     util.ErrorDocsURL + "#runtime-unavailable",
+		}
+		if got.Error != want {
+			t.Errorf("error = %+v, want %+v", got.Error, want)
+		}
+	})
+}
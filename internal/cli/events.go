@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+// statusEventCount is how many recent events alca status shows.
+const statusEventCount = 5
+
+// printRecentEvents prints the latest container events for alca status.
+func printRecentEvents(w io.Writer, events []state.Event) {
+	if len(events) == 0 {
+		return
+	}
+	if len(events) > statusEventCount {
+		events = events[len(events)-statusEventCount:]
+	}
+	_, _ = fmt.Fprintln(w, "Recent events:")
+	for _, e := range events {
+		_, _ = fmt.Fprintf(w, "  %s  %-7s  %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.Kind, e.Message)
+	}
+	_, _ = fmt.Fprintln(w, "")
+}
+
+// eventsPollInterval is how often alca events --follow checks for new
+// events. Tests shorten it.
+var eventsPollInterval = 500 * time.Millisecond
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show sandbox lifecycle events",
+	Long: `List the project's lifecycle events, oldest first: containers created,
+started, stopped, paused, resumed and removed, processes in the container
+crashing or killed, Mutagen sync sessions created and conflicted, firewall
+rules applied and removed, and configuration drift detected by 'alca up'.
+
+Events are appended to .alca/events.jsonl, one JSON object per line with
+time, type, project_id, container, message and details; the file is never
+rewritten, so automation can tail it. --follow keeps printing events as
+they are recorded; --json prints them as stored.`,
+	Example: `  alca events
+  alca events --follow --json
+  alca events --type container.created --type container.removed`,
+	Args: cobra.NoArgs,
+	RunE: runEvents,
+}
+
+func init() {
+	eventsCmd.Flags().BoolP("follow", "f", false, "Keep printing new events until interrupted")
+	eventsCmd.Flags().Bool("json", false, "Print events as JSON lines")
+	eventsCmd.Flags().IntP("limit", "n", 0, "Start with only the last n events (0 shows all)")
+	eventsCmd.Flags().StringArray("type", nil, "Show only events of this type (repeatable)")
+}
+
+// runEvents prints the recorded events, then with --follow new ones.
+func runEvents(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	follow, _ := cmd.Flags().GetBool("follow")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	limit, _ := cmd.Flags().GetInt("limit")
+	types, _ := cmd.Flags().GetStringArray("type")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+	p := eventPrinter{w: cmd.OutOrStdout(), json: asJSON, types: types}
+	return printEvents(ctx, newCLIReadDeps().Env.Fs, cwd, p, limit, follow)
+}
+
+// eventPrinter writes lifecycle events of the selected types.
+type eventPrinter struct {
+	w     io.Writer
+	json  bool
+	types []string
+}
+
+func (p eventPrinter) print(events []lifecycle.Event) error {
+	for _, e := range events {
+		if len(p.types) > 0 && !slices.Contains(p.types, e.Type) {
+			continue
+		}
+		if p.json {
+			line, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(p.w, "%s\n", line); err != nil {
+				return err
+			}
+			continue
+		}
+		line := fmt.Sprintf("%s  %-22s", e.Time.Local().Format("2006-01-02 15:04:05"), e.Type)
+		if e.Container != "" {
+			line += "  " + e.Container
+		}
+		if e.Message != "" {
+			line += "  " + e.Message
+		}
+		if _, err := fmt.Fprintln(p.w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printEvents prints the project's events, the last limit of them if limit
+// is positive, and with follow polls for new ones until ctx is done.
+func printEvents(ctx context.Context, fs afero.Fs, cwd string, p eventPrinter, limit int, follow bool) error {
+	events, offset, err := lifecycle.ReadFrom(fs, cwd, 0)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	if err := p.print(events); err != nil || !follow {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(eventsPollInterval):
+		}
+		events, offset, err = lifecycle.ReadFrom(fs, cwd, offset)
+		if err != nil {
+			return err
+		}
+		if err := p.print(events); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
+)
+
+func TestFakeRuntime_LifecycleEvents(t *testing.T) {
+	_, dir := setupFakeProject(t, fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	// A second up leaves the running container alone
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	if err := runFakeCommand(t, downCmd, runDown); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+
+	events, err := lifecycle.Load(afero.NewOsFs(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+		if e.ProjectID != st.ProjectID || e.Container != st.ContainerName || e.Time.IsZero() {
+			t.Errorf("event = %+v", e)
+		}
+	}
+	want := []string{lifecycle.ContainerCreated, lifecycle.ContainerStarted, lifecycle.ContainerRemoved}
+	if strings.Join(types, " ") != strings.Join(want, " ") {
+		t.Errorf("event types = %v, want %v", types, want)
+	}
+}
+
+func TestPrintEvents(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	for _, e := range []lifecycle.Event{
+		{Time: at, Type: lifecycle.ContainerCreated, Container: "alca-a"},
+		{Time: at, Type: lifecycle.DriftDetected, Container: "alca-a", Message: "image"},
+		{Time: at, Type: lifecycle.ContainerRemoved, Container: "alca-a"},
+	} {
+		if err := lifecycle.Append(fs, "/p", e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := printEvents(context.Background(), fs, "/p", eventPrinter{w: &buf}, 2, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "2026-01-02 03:04:05  config.drift_detected   alca-a  image\n" +
+		"2026-01-02 03:04:05  container.removed       alca-a\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	p := eventPrinter{w: &buf, json: true, types: []string{lifecycle.ContainerCreated}}
+	if err := printEvents(context.Background(), fs, "/p", p, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(buf.String()); strings.Count(got, "\n") != 0 || !strings.Contains(got, `"type":"container.created"`) {
+		t.Errorf("json output = %q", got)
+	}
+}
+
+func TestPrintEvents_Follow(t *testing.T) {
+	orig := eventsPollInterval
+	eventsPollInterval = time.Millisecond
+	t.Cleanup(func() { eventsPollInterval = orig })
+
+	fs := afero.NewMemMapFs()
+	var mu gosync.Mutex
+	var buf bytes.Buffer
+	w := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return buf.Write(p)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- printEvents(ctx, fs, "/p", eventPrinter{w: w, json: true}, 0, true)
+	}()
+
+	if err := lifecycle.Append(fs, "/p", lifecycle.Event{Type: lifecycle.ContainerStarted}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := buf.String()
+		mu.Unlock()
+		if strings.Contains(got, lifecycle.ContainerStarted) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("followed output = %q", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("printEvents = %v", err)
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/state"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
@@ -37,16 +38,16 @@ This is an experimental feature and its behavior may change in future versions.`
 	RunE: runReload,
 }
 
-var syncCmd = &cobra.Command{
+var experimentalSyncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Sync conflict management",
 }
 
 func init() {
 	experimentalCmd.AddCommand(reloadCmd)
-	experimentalCmd.AddCommand(syncCmd)
-	syncCmd.AddCommand(syncCheckCmd)
-	syncCmd.AddCommand(syncResolveCmd)
+	experimentalCmd.AddCommand(experimentalSyncCmd)
+	experimentalSyncCmd.AddCommand(syncCheckCmd)
+	experimentalSyncCmd.AddCommand(syncResolveCmd)
 }
 
 // runReload re-applies the configuration to the running container.
@@ -66,7 +67,7 @@ func runReload(cmd *cobra.Command, args []string) error {
 	tfs, env, runtimeEnv := deps.Tfs, deps.Env, deps.RuntimeEnv
 
 	// Load configuration and runtime
-	cfg, rt, err := loadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
 	if err != nil {
 		return err
 	}
@@ -74,13 +75,13 @@ func runReload(cmd *cobra.Command, args []string) error {
 	util.ProgressStep(os.Stdout, "Using runtime: %s\n", rt.Name())
 
 	// Load state (required)
-	st, err := loadRequiredState(env, cwd)
+	st, err := sandbox.LoadRequiredState(env, cwd)
 	if err != nil {
 		return err
 	}
 
 	// Check if project directory has moved since container was created
-	if err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
+	if err := sandbox.CheckProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
 		return err
 	}
 
@@ -99,7 +100,7 @@ func runReload(cmd *cobra.Command, args []string) error {
 	// Reload the container
 	if err := rt.Reload(ctx, runtimeEnv, cfg, cwd, st); err != nil {
 		if errors.Is(err, runtime.ErrNotRunning) {
-			return errors.New(ErrMsgNotRunning)
+			return errors.New(sandbox.ErrMsgNotRunning)
 		}
 		return fmt.Errorf("failed to reload container: %w", err)
 	}
@@ -111,7 +112,7 @@ func runReload(cmd *cobra.Command, args []string) error {
 	}
 
 	// Commit file operations (project dir, normally no sudo needed)
-	if err := commitWithSudo(ctx, env, tfs, os.Stdout, ""); err != nil {
+	if err := sandbox.CommitWithSudo(ctx, env, tfs, os.Stdout, ""); err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
 
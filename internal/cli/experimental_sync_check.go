@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/sync"
 )
 
@@ -41,18 +42,18 @@ func runSyncCheck(cmd *cobra.Command, args []string) error {
 	deps := newCLIReadDeps()
 	env, runtimeEnv := deps.Env, deps.RuntimeEnv
 
-	cfg, rt, err := loadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
 	if err != nil {
 		return err
 	}
 
-	st, err := loadRequiredState(env, cwd)
+	st, err := sandbox.LoadRequiredState(env, cwd)
 	if err != nil {
 		return err
 	}
 
 	// Check if project directory has moved since container was created
-	if err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
+	if err := sandbox.CheckProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
 		return err
 	}
 
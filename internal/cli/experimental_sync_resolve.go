@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/sync"
 )
 
@@ -33,18 +34,18 @@ func runSyncResolve(cmd *cobra.Command, args []string) error {
 	deps := newCLIReadDeps()
 	env, runtimeEnv := deps.Env, deps.RuntimeEnv
 
-	cfg, rt, err := loadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
 	if err != nil {
 		return err
 	}
 
-	st, err := loadRequiredState(env, cwd)
+	st, err := sandbox.LoadRequiredState(env, cwd)
 	if err != nil {
 		return err
 	}
 
 	// Check if project directory has moved since container was created
-	if err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
+	if err := sandbox.CheckProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
 		return err
 	}
 
@@ -54,7 +55,7 @@ func runSyncResolve(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get container status: %w", err)
 	}
 	if status.State != runtime.StateRunning {
-		return errors.New(ErrMsgNotRunning)
+		return errors.New(sandbox.ErrMsgNotRunning)
 	}
 
 	// SyncEnv needs a writable fs for conflict resolution (file deletion).
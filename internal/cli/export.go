@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Files of an export bundle.
+const (
+	exportManifestFile = "manifest.json"
+	exportConfigFile   = "alca.toml"
+	exportSyncFile     = "sync.json"
+	exportImageFile    = "image.tar"
+)
+
+// Sources of the exported image, as recorded in the manifest.
+const (
+	exportSourceContainer = "container"
+	exportSourceBake      = "bake"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the sandbox as an image bundle",
+	Long: `Write the sandbox to a .tar.gz bundle that can be archived or handed to
+someone else to reproduce it:
+
+  image.tar      the container's filesystem committed to an image (an OCI
+                 archive with Podman; Docker's archive has an OCI layout
+                 since Docker 25)
+  alca.toml      the resolved config (extends and includes merged) with
+                 image set to the exported image
+  sync.json      the project's Mutagen sync sessions, with their endpoints,
+                 ignores and state, as 'mutagen sync list' reports them
+  manifest.json  the alca version, project, container and images involved
+
+As with 'alca bake', the configured envs are scrubbed from the image, and
+mounts (including the workdir) are not part of it. alca.toml is written as
+configured, so review it for literal secrets before sharing the bundle.
+
+With --baked, the image baked by 'alca bake' is exported instead of the
+current container, which then does not need to be running.
+
+To reproduce the sandbox from a bundle:
+
+  tar -xzf bundle.tar.gz && docker load -i image.tar
+  cp alca.toml /path/to/checkout/.alca.toml && cd /path/to/checkout && alca up`,
+	Example: `  alca export
+  alca export --output sandbox.tar.gz
+  alca export --baked --output - | ssh host 'cat > sandbox.tar.gz'`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringP("output", "o", "", "Bundle file, or - for stdout (default <container>-<time>.tar.gz)")
+	exportCmd.Flags().Bool("baked", false, "Export the image baked by 'alca bake' instead of the container")
+}
+
+// exportManifest describes an export bundle.
+type exportManifest struct {
+	AlcaVersion string    `json:"alca_version"`
+	ExportedAt  time.Time `json:"exported_at"`
+	ProjectID   string    `json:"project_id"`
+	Container   string    `json:"container"`
+	// Source is how the image was made: committed from the container, or
+	// the baked image.
+	Source    string `json:"source"`
+	Image     string `json:"image"`
+	BaseImage string `json:"base_image"`
+	Runtime   string `json:"runtime"`
+}
+
+// runExport writes the export bundle of the project container.
+func runExport(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	output, _ := cmd.Flags().GetString("output")
+	baked, _ := cmd.Flags().GetBool("baked")
+	progress := os.Stderr
+
+	if output == "-" && term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("refusing to write a bundle to a terminal, use --output <file> or redirect stdout")
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	manifest := exportManifest{
+		AlcaVersion: Version,
+		ExportedAt:  now.UTC(),
+		ProjectID:   st.ProjectID,
+		Container:   st.ContainerName,
+		BaseImage:   cfg.Image,
+		Runtime:     rt.Name(),
+	}
+
+	if baked {
+		if st.Bake == nil {
+			return errors.New("no baked image, run 'alca bake' first")
+		}
+		manifest.Source, manifest.Image = exportSourceBake, st.Bake.Image
+	} else {
+		status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+		if err != nil {
+			return fmt.Errorf("failed to get container status: %w", err)
+		}
+		if status.State != runtime.StateRunning {
+			return errors.New(sandbox.ErrMsgNotRunning)
+		}
+		manifest.Source, manifest.Container = exportSourceContainer, status.Name
+		manifest.Image = exportImageName(st.ProjectID, now)
+
+		util.ProgressStep(progress, "Committing %s to %s...\n", status.Name, manifest.Image)
+		if err := rt.BakeImage(ctx, runtimeEnv, cfg, status.Name, manifest.Image); err != nil {
+			return err
+		}
+		defer func() {
+			if err := rt.RemoveImage(ctx, runtimeEnv, manifest.Image); err != nil {
+				util.ProgressStep(progress, "Warning: failed to remove %s: %v\n", manifest.Image, err)
+			}
+		}()
+	}
+
+	// The bundle is written through the real filesystem, since the command
+	// otherwise only reads.
+	hostFs := host.Fs
+	w := io.Writer(os.Stdout)
+	if output != "-" {
+		if output == "" {
+			output = fmt.Sprintf("%s-%s.tar.gz", st.ContainerName, now.Format("20060102-150405"))
+		}
+		f, err := hostFs.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	sessions := exportSyncSessions(ctx, runtimeEnv, st, progress)
+	util.ProgressStep(progress, "Saving %s...\n", manifest.Image)
+	if err := writeExportBundle(ctx, hostFs, runtimeEnv, rt, cfg, manifest, sessions, w); err != nil {
+		if output != "-" {
+			_ = hostFs.Remove(output)
+		}
+		return err
+	}
+	if output != "-" {
+		util.ProgressDone(progress, "Exported the sandbox to %s\n", output)
+	}
+	return nil
+}
+
+// exportImageName returns the temporary image reference the container is
+// committed to for an export.
+func exportImageName(projectID string, now time.Time) string {
+	return fmt.Sprintf("alca-export/%s:%s", projectID, now.UTC().Format("20060102150405"))
+}
+
+// exportSyncSessions returns the project's Mutagen sync sessions as
+// 'mutagen sync list' reports them. Without Mutagen there are none;
+// sessions that cannot be listed are reported to warn and left out.
+func exportSyncSessions(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, st *state.State, warn io.Writer) []json.RawMessage {
+	sessions := []json.RawMessage{}
+	names, err := runtime.ListMutagenSyncs(ctx, runtimeEnv, util.MutagenSessionPrefix(st.ProjectID))
+	if err != nil {
+		return sessions
+	}
+	for _, name := range names {
+		output, err := runtime.ListSessionJSON(ctx, runtimeEnv, name)
+		if err != nil || !json.Valid(output) {
+			util.ProgressStep(warn, "Warning: sync session %s not exported: %v\n", name, err)
+			continue
+		}
+		sessions = append(sessions, json.RawMessage(output))
+	}
+	return sessions
+}
+
+// writeExportBundle writes the bundle files into a temporary directory
+// and then to w as a gzipped tar archive.
+func writeExportBundle(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, manifest exportManifest, sessions []json.RawMessage, w io.Writer) error {
+	tmpDir, err := afero.TempDir(fs, "", "alca-export-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = fs.RemoveAll(tmpDir) }()
+
+	if err := rt.SaveImage(ctx, runtimeEnv, manifest.Image, filepath.Join(tmpDir, exportImageFile)); err != nil {
+		return err
+	}
+
+	exported := *cfg
+	exported.Image = manifest.Image
+	configTOML, err := exported.EncodeTOML()
+	if err != nil {
+		return err
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	syncJSON, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		exportManifestFile: append(manifestJSON, '\n'),
+		exportConfigFile:   []byte(configTOML),
+		exportSyncFile:     append(syncJSON, '\n'),
+	}
+	for name, data := range files {
+		if err := afero.WriteFile(fs, filepath.Join(tmpDir, name), data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeTarTree(fs, tw, tmpDir); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestExportImageName(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.FixedZone("X", 3600))
+	if got, want := exportImageName("p1", now), "alca-export/p1:20260304040607"; got != want {
+		t.Errorf("exportImageName() = %q, want %q", got, want)
+	}
+}
+
+// readBundle returns the files of a gzipped tar bundle by name.
+func readBundle(t *testing.T, path string) map[string]string {
+	t.Helper()
+	f, err := afero.NewOsFs().Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[hdr.Name] = string(data)
+	}
+}
+
+func TestFakeRuntime_Export(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	output := filepath.Join(dir, "bundle.tar.gz")
+	if err := exportCmd.Flags().Set("output", output); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = exportCmd.Flags().Set("output", "") })
+
+	if err := runFakeCommand(t, exportCmd, runExport); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	files := readBundle(t, output)
+	var manifest exportManifest
+	if err := json.Unmarshal([]byte(files[exportManifestFile]), &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if manifest.Source != exportSourceContainer || manifest.BaseImage != "alpine:3" || !strings.HasPrefix(manifest.Image, "alca-export/") {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+	if got := files[exportImageFile]; got != "fake image "+manifest.Image+"\n" {
+		t.Errorf("image.tar = %q", got)
+	}
+	if !strings.Contains(files[exportConfigFile], "image = '"+manifest.Image+"'") {
+		t.Errorf("alca.toml does not use the exported image:\n%s", files[exportConfigFile])
+	}
+	if strings.TrimSpace(files[exportSyncFile]) != "[]" {
+		t.Errorf("sync.json = %q, want []", files[exportSyncFile])
+	}
+	if fake.HasImage(manifest.Image) {
+		t.Error("committed image not removed after export")
+	}
+}
+
+func TestFakeRuntime_ExportBakedRequiresBake(t *testing.T) {
+	setupFakeProject(t, fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := exportCmd.Flags().Set("output", output); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = exportCmd.Flags().Set("output", "") })
+
+	err := runFakeCommand(t, exportCmd, runExport, "baked")
+	if err == nil || !strings.Contains(err.Error(), "alca bake") {
+		t.Fatalf("export --baked without a bake: got %v", err)
+	}
+	if _, err := afero.NewOsFs().Stat(output); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("bundle written without an image: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// fakeProjectConfig allows all LAN traffic so no firewall work is needed.
+const fakeProjectConfig = `image = "alpine:3"
+
+[commands]
+up = "apk add git"
+
+[network]
+lan-access = ["*"]
+`
+
+// setupFakeProject creates a project with the given .alca.toml in a temp
+// dir, makes it the working directory, and routes the CLI to the fake
+// runtime and a mock command runner that fails every host command.
+func setupFakeProject(t *testing.T, configContent string) (*runtime.Fake, string) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFakeConfig(t, dir, configContent)
+	t.Chdir(dir)
+
+	t.Setenv(runtime.EnvFakeRuntime, "1")
+	fake := runtime.ResetSharedFake()
+
+	mock := util.NewMockCommandRunner()
+	mock.LogTranscriptOnFailure(t)
+	orig := newCommandRunner
+	newCommandRunner = func() util.CommandRunner { return mock }
+	t.Cleanup(func() { newCommandRunner = orig })
+
+	return fake, dir
+}
+
+// fakeCommandRunner returns the mock host command runner installed by
+// setupFakeProject.
+func fakeCommandRunner(t *testing.T) *util.MockCommandRunner {
+	t.Helper()
+	mock, ok := newCommandRunner().(*util.MockCommandRunner)
+	if !ok {
+		t.Fatal("no mock command runner; call setupFakeProject first")
+	}
+	return mock
+}
+
+// startedCalls returns the commands started in the background whose
+// arguments are args.
+func startedCalls(mock *util.MockCommandRunner, args ...string) []util.CommandCall {
+	var calls []util.CommandCall
+	for _, c := range mock.Calls {
+		if c.LogFile != "" && slices.Equal(c.Args, args) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func writeFakeConfig(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := afero.WriteFile(afero.NewOsFs(), filepath.Join(dir, sandbox.ConfigFilename), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+// runFakeCommand runs a CLI command in-process with flags set, restoring
+// the flags afterwards since commands are package globals.
+func runFakeCommand(t *testing.T, cmd *cobra.Command, run func(*cobra.Command, []string) error, flags ...string) error {
+	t.Helper()
+	for _, name := range flags {
+		if err := cmd.Flags().Set(name, "true"); err != nil {
+			t.Fatalf("set --%s: %v", name, err)
+		}
+	}
+	defer func() {
+		for _, name := range flags {
+			f := cmd.Flags().Lookup(name)
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	}()
+	cmd.SetContext(context.Background())
+	return run(cmd, nil)
+}
+
+func loadFakeState(t *testing.T, dir string) *state.State {
+	t.Helper()
+	st, err := state.Load(&util.Env{Fs: afero.NewOsFs()}, dir)
+	if err != nil || st == nil {
+		t.Fatalf("state.Load() = %v, %v", st, err)
+	}
+	return st
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// forwardInterval is how often the host directories are scanned.
+	forwardInterval = time.Second
+	// forwardBatch caps the paths touched by one exec.
+	forwardBatch = 200
+)
+
+// forwardSkipDirs are directories not scanned: large, and ignored by file
+// watchers anyway.
+var forwardSkipDirs = []string{".git", "node_modules"}
+
+var forwardEventsCmd = &cobra.Command{
+	Use:   "forward-events",
+	Short: "Forward host file changes to watchers in the container",
+	Long: `Watch the project's bind-mounted directories on the host and touch each file
+created or modified there inside the container, so file watchers (webpack,
+vite, air, nodemon, ...) running in the container see edits made on the
+host.
+
+On macOS the container runs in a VM, and inotify events of host edits do
+not always cross the VM's file sharing. With forward_file_events = true in
+.alca.toml, 'alca up' starts the forwarder in the background on macOS; 'alca
+down' stops it, and it stops by itself once the project's state is gone.
+It logs to .alca/logs/` + sandbox.ForwardLog + `.
+
+Touching sets a file's times to their current values, so the file is not
+changed but watchers get an attribute-change event. Deleted files are not
+forwarded, and .git and node_modules directories are not scanned. Mounts
+synced with Mutagen need no forwarding: the sync writes files inside the
+container, which watchers see.`,
+	Args: cobra.NoArgs,
+	RunE: runForwardEvents,
+}
+
+// runForwardEvents runs the project's forwarder in the foreground.
+func runForwardEvents(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Started by alca up, the forwarder outlives the terminal it came from
+	signal.Ignore(syscall.SIGHUP)
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+	env := newHostEnv()
+	deps := sandbox.NewDeps(env)
+	cfg, _, err := sandbox.LoadConfigFromCwd(ctx, env, cwd)
+	if err != nil {
+		return err
+	}
+	rt, err := runtime.SelectRuntime(ctx, deps.RuntimeEnv, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to select runtime: %w", err)
+	}
+	roots := sandbox.ForwardRoots(cfg, cwd, runtime.DetectPlatform(ctx, deps.RuntimeEnv))
+	if len(roots) == 0 {
+		return errors.New("no bind-mounted directories to watch; Mutagen-synced mounts need no forwarding")
+	}
+
+	pidPath := sandbox.ForwardPidPath(cwd)
+	if pid, ok := sandbox.RunningPID(env.Fs, pidPath); ok && pid != os.Getpid() {
+		return fmt.Errorf("a forwarder is already running for this project (pid %d)", pid)
+	}
+	if err := env.Fs.MkdirAll(filepath.Dir(pidPath), 0o755); err != nil {
+		return err
+	}
+	if err := afero.WriteFile(env.Fs, pidPath, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pidPath, err)
+	}
+	defer func() {
+		if pid, ok := sandbox.ReadPIDFile(env.Fs, pidPath); ok && pid == os.Getpid() {
+			_ = env.Fs.Remove(pidPath)
+		}
+	}()
+
+	f := newFileEventForwarder(env, deps.RuntimeEnv, rt, cwd, roots, cmd.OutOrStdout())
+	util.ProgressStep(cmd.OutOrStdout(), "Forwarding file changes for %s\n", cwd)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(forwardInterval):
+		}
+		if !f.tick(ctx) {
+			util.ProgressStep(cmd.OutOrStdout(), "File event forwarder stopped\n")
+			return nil
+		}
+	}
+}
+
+// fileStamp is what a scan compares to find modified files.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileEventForwarder touches, in the container, the files created or
+// modified under its roots on the host since the previous scan.
+type fileEventForwarder struct {
+	env        *util.Env
+	runtimeEnv *runtime.RuntimeEnv
+	rt         runtime.Runtime
+	projectDir string
+	roots      []sandbox.ForwardRoot
+	out        io.Writer
+
+	// seen is nil until the first scan, which only records the files.
+	seen map[string]fileStamp
+}
+
+func newFileEventForwarder(env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, projectDir string, roots []sandbox.ForwardRoot, out io.Writer) *fileEventForwarder {
+	return &fileEventForwarder{
+		env:        env,
+		runtimeEnv: runtimeEnv,
+		rt:         rt,
+		projectDir: projectDir,
+		roots:      roots,
+		out:        out,
+	}
+}
+
+// tick forwards the changes since the previous tick. Returns false when
+// the forwarder should stop: the project has no state or container any
+// more.
+func (f *fileEventForwarder) tick(ctx context.Context) bool {
+	st, err := state.Load(f.env, f.projectDir)
+	if err != nil {
+		util.ProgressStep(f.out, "Warning: %v\n", err)
+		return true
+	}
+	if st == nil {
+		return false
+	}
+
+	changed := f.scan()
+	if len(changed) == 0 {
+		return true
+	}
+	status, err := f.rt.Status(ctx, f.runtimeEnv, f.projectDir, st)
+	if err != nil {
+		util.ProgressStep(f.out, "Warning: %v\n", err)
+		return true
+	}
+	if status.State == runtime.StateNotFound {
+		return false
+	}
+	if status.State != runtime.StateRunning {
+		return true
+	}
+	for batch := range slices.Chunk(changed, forwardBatch) {
+		if err := f.rt.TouchFiles(ctx, f.runtimeEnv, status.Name, batch); err != nil {
+			util.ProgressStep(f.out, "Warning: %v\n", err)
+		}
+	}
+	return true
+}
+
+// scan walks the roots and returns the container paths of the files
+// created or modified since the previous scan.
+func (f *fileEventForwarder) scan() []string {
+	first := f.seen == nil
+	seen := make(map[string]fileStamp, len(f.seen))
+	var changed []string
+	for _, root := range f.roots {
+		_ = afero.Walk(f.env.Fs, root.Source, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				// Unreadable or vanished entries are skipped
+				return nil
+			}
+			if info.IsDir() {
+				if p != root.Source && slices.Contains(forwardSkipDirs, info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+			seen[p] = stamp
+			if old, ok := f.seen[p]; first || (ok && old.modTime.Equal(stamp.modTime) && old.size == stamp.size) {
+				return nil
+			}
+			rel, err := filepath.Rel(root.Source, p)
+			if err != nil {
+				return nil
+			}
+			changed = append(changed, path.Join(root.Target, filepath.ToSlash(rel)))
+			return nil
+		})
+	}
+	f.seen = seen
+	return changed
+}
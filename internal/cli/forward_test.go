@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestFileEventForwarder_Scan(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	write := func(p string) {
+		t.Helper()
+		if err := afero.WriteFile(fs, p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("/p/main.go")
+	write("/p/web/app.js")
+	write("/p/node_modules/dep/index.js")
+	write("/p/.git/HEAD")
+
+	f := newFileEventForwarder(&util.Env{Fs: fs}, nil, nil, "/p", []sandbox.ForwardRoot{{Source: "/p", Target: "/workspace"}}, nil)
+	if changed := f.scan(); len(changed) != 0 {
+		t.Fatalf("first scan = %v, want nothing", changed)
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := fs.Chtimes("/p/web/app.js", later, later); err != nil {
+		t.Fatal(err)
+	}
+	write("/p/new.go")
+	write("/p/node_modules/dep/other.js")
+	write("/p/.git/index")
+
+	changed := f.scan()
+	slices.Sort(changed)
+	want := []string{"/workspace/new.go", "/workspace/web/app.js"}
+	if !slices.Equal(changed, want) {
+		t.Errorf("scan = %v, want %v", changed, want)
+	}
+	if changed := f.scan(); len(changed) != 0 {
+		t.Errorf("unchanged scan = %v, want nothing", changed)
+	}
+}
+
+func TestFakeRuntime_ForwardFileEvents(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+
+	env := &util.Env{Fs: afero.NewOsFs()}
+	var out bytes.Buffer
+	f := newFileEventForwarder(env, nil, fake, dir, []sandbox.ForwardRoot{{Source: dir, Target: "/workspace"}}, &out)
+	if !f.tick(context.Background()) {
+		t.Fatal("forwarder stopped early")
+	}
+	if err := afero.WriteFile(env.Fs, filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !f.tick(context.Background()) {
+		t.Fatal("forwarder stopped early")
+	}
+	execs := fake.Container(st.ContainerName).Execs
+	if last := strings.Join(execs[len(execs)-1], " "); last != "touch /workspace/main.go" {
+		t.Errorf("last exec = %q, want the touched file", last)
+	}
+
+	// The forwarder stops once the project is down
+	if err := runFakeCommand(t, downCmd, runDown); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if err := afero.WriteFile(env.Fs, filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if f.tick(context.Background()) {
+		t.Error("forwarder kept running after down")
+	}
+}
@@ -1,157 +1,17 @@
 package cli
 
 import (
-	"bufio"
-	"context"
-	"errors"
 	"fmt"
-	"io"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/spf13/afero"
-	"golang.org/x/term"
 
-	"github.com/bolasblack/alcatraz/internal/config"
-	"github.com/bolasblack/alcatraz/internal/runtime"
-	"github.com/bolasblack/alcatraz/internal/state"
-	"github.com/bolasblack/alcatraz/internal/transact"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
 
-// Common error messages for CLI commands.
-const (
-	ErrMsgConfigNotFound = "configuration not found: run 'alca init' first"
-	ErrMsgStateNotFound  = "no state file found: run 'alca up' first"
-	ErrMsgNotRunning     = "container is not running: run 'alca up' first"
-)
-
-// loadConfigFromCwd loads configuration from the current working directory.
-// Returns the config and config path, or an error with user-friendly message.
-func loadConfigFromCwd(env *util.Env, cwd string) (*config.Config, string, error) {
-	configPath := filepath.Join(cwd, ConfigFilename)
-	cfg, err := config.LoadConfig(env, configPath, config.StrictExpandEnv)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, configPath, errors.New(ErrMsgConfigNotFound)
-		}
-		return nil, configPath, fmt.Errorf("failed to load config: %w", err)
-	}
-	return &cfg, configPath, nil
-}
-
-// loadConfigOptional loads configuration, returning zero config if not found.
-// Use this for commands that can work without a config file.
-func loadConfigOptional(env *util.Env, cwd string) (*config.Config, string) {
-	configPath := filepath.Join(cwd, ConfigFilename)
-	cfg, _ := config.LoadConfig(env, configPath, config.StrictExpandEnv)
-	return &cfg, configPath
-}
-
-// loadConfigAndRuntime loads config and selects the appropriate runtime.
-// This is the most common pattern for commands that need both.
-func loadConfigAndRuntime(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, cwd string) (*config.Config, runtime.Runtime, error) {
-	cfg, _, err := loadConfigFromCwd(env, cwd)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, cfg)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to select runtime: %w", err)
-	}
-
-	return cfg, rt, nil
-}
-
-// loadConfigAndRuntimeOptional loads config (optional) and selects runtime.
-// Use for commands like 'list' and 'cleanup' that work without config.
-func loadConfigAndRuntimeOptional(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, cwd string) (*config.Config, runtime.Runtime, error) {
-	cfg, _ := loadConfigOptional(env, cwd)
-
-	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, cfg)
-	if err != nil {
-		return cfg, nil, fmt.Errorf("failed to select runtime: %w", err)
-	}
-
-	return cfg, rt, nil
-}
-
-// loadRequiredState loads state file and returns error if not found.
-// Use for commands that require an existing container state.
-func loadRequiredState(env *util.Env, cwd string) (*state.State, error) {
-	st, err := state.Load(env, cwd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load state: %w", err)
-	}
-	if st == nil {
-		return nil, errors.New(ErrMsgStateNotFound)
-	}
-	return st, nil
-}
-
-// loadStateOptional loads state file, returning nil without error if not found.
-// Use for commands where missing state is acceptable (e.g., down).
-func loadStateOptional(env *util.Env, cwd string) (*state.State, error) {
-	st, err := state.Load(env, cwd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load state: %w", err)
-	}
-	return st, nil
-}
-
-// displayConfigDrift prints configuration drift information to the writer.
-// Returns true if there was any drift to display.
-func displayConfigDrift(w io.Writer, drift *state.DriftChanges, runtimeChanged bool, oldRuntime, newRuntime string) bool {
-	if drift == nil && !runtimeChanged {
-		return false
-	}
-
-	_, _ = fmt.Fprintln(w, "Configuration has changed since last container creation:")
-
-	if runtimeChanged {
-		_, _ = fmt.Fprintf(w, "  Runtime: %s → %s\n", oldRuntime, newRuntime)
-	}
-
-	if drift != nil {
-		if drift.Image != nil {
-			_, _ = fmt.Fprintf(w, "  Image: %s → %s\n", drift.Image[0], drift.Image[1])
-		}
-		if drift.Mounts {
-			_, _ = fmt.Fprintf(w, "  Mounts: changed\n")
-		}
-		if drift.Workdir != nil {
-			_, _ = fmt.Fprintf(w, "  Workdir: %s → %s\n", drift.Workdir[0], drift.Workdir[1])
-		}
-		if drift.WorkdirExclude {
-			_, _ = fmt.Fprintf(w, "  Workdir exclude: changed\n")
-		}
-		if drift.CommandUp != nil {
-			_, _ = fmt.Fprintf(w, "  Commands.up: changed\n")
-		}
-		if drift.Memory != nil {
-			_, _ = fmt.Fprintf(w, "  Resources.memory: %s → %s\n", drift.Memory[0], drift.Memory[1])
-		}
-		if drift.CPUs != nil {
-			_, _ = fmt.Fprintf(w, "  Resources.cpus: %d → %d\n", drift.CPUs[0], drift.CPUs[1])
-		}
-		if drift.Envs {
-			_, _ = fmt.Fprintf(w, "  Envs: changed\n")
-		}
-		if drift.Ports {
-			_, _ = fmt.Fprintf(w, "  Ports: changed\n")
-		}
-		if drift.HooksPostUp != nil {
-			_, _ = fmt.Fprintf(w, "  Hooks.post_up: changed\n")
-		}
-		if drift.HooksPreDown != nil {
-			_, _ = fmt.Fprintf(w, "  Hooks.pre_down: changed\n")
-		}
-	}
-
-	return true
-}
+// ErrMsgNoTestCommand is the error of alca test without commands.test.
+const ErrMsgNoTestCommand = "no test command configured: set commands.test in .alca.toml"
 
 // getCwd returns the current working directory or an error.
 func getCwd() (string, error) {
@@ -170,186 +30,24 @@ func findProjectDir() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return findProjectDirFrom(afero.NewOsFs(), cwd), nil
-}
-
-// findProjectDirFrom walks up from startDir looking for ConfigFilename.
-// Returns startDir if not found.
-func findProjectDirFrom(fs afero.Fs, startDir string) string {
-	dir := startDir
-	for {
-		info, err := fs.Stat(filepath.Join(dir, ConfigFilename))
-		if err == nil && !info.IsDir() {
-			return dir
-		}
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			// Reached filesystem root — return original startDir
-			return startDir
-		}
-		dir = parent
-	}
-}
-
-// promptConfirm prompts the user for confirmation.
-// Returns false immediately when stdin is not a terminal (CI, scripts, piped input)
-// so that non-interactive invocations never block waiting for input.
-func promptConfirm(prompt string) bool {
-	if !term.IsTerminal(int(os.Stdin.Fd())) {
-		return false
-	}
-	fmt.Printf("%s [y/N] ", prompt)
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return false
-	}
-	response = strings.ToLower(strings.TrimSpace(response))
-	return response == "y" || response == "yes"
-}
-
-// commitWithSudo commits TransactFs changes, intelligently grouping operations
-// by sudo requirement and executing each group with the appropriate method.
-// If any operation requires sudo, the msg is printed first to explain why.
-func commitWithSudo(ctx context.Context, env *util.Env, tfs *transact.TransactFs, out io.Writer, msg string) error {
-	_, err := tfs.Commit(func(commitCtx transact.CommitContext) (*transact.CommitOpsResult, error) {
-		if len(commitCtx.Ops) == 0 {
-			return nil, nil
-		}
-
-		// Check if any op needs sudo and output explanation
-		if out != nil {
-			for _, op := range commitCtx.Ops {
-				if op.NeedSudo {
-					if msg != "" {
-						util.ProgressStep(out, "%s (sudo required)...\n", msg)
-						break
-					}
-
-					// Not expected to happen, so we need to print the path
-					util.ProgressStep(out, "Writing to %s (sudo required)...\n", op.Path)
-				}
-			}
-		}
-
-		// Group operations by NeedSudo while preserving order
-		groups := transact.GroupOpsBySudo(commitCtx.Ops)
-
-		// Execute each group with the appropriate method
-		if err := transact.ExecuteGroupedOps(commitCtx.BaseFs, groups, func(script string) error {
-			return env.Cmd.SudoRunScriptQuiet(ctx, script)
-		}); err != nil {
-			return nil, err
-		}
-
-		return nil, nil
-	})
-	return err
-}
-
-// commitIfNeeded checks if there are pending changes and commits them with sudo support.
-// The msg is only printed if sudo is actually required.
-func commitIfNeeded(ctx context.Context, env *util.Env, tfs *transact.TransactFs, out io.Writer, msg string) error {
-	if !tfs.NeedsCommit() {
-		return nil
-	}
-	return commitWithSudo(ctx, env, tfs, out, msg)
-}
-
-// cliDeps holds shared CLI dependencies for commands that perform writes.
-type cliDeps struct {
-	Tfs        *transact.TransactFs
-	CmdRunner  util.CommandRunner
-	Env        *util.Env
-	RuntimeEnv *runtime.RuntimeEnv
+	return sandbox.FindProjectDirFrom(afero.NewOsFs(), cwd), nil
 }
 
 // newCLIDeps creates the shared transactional dependencies used by most CLI commands.
-func newCLIDeps() cliDeps {
-	tfs := transact.New()
-	cmdRunner := util.NewCommandRunner()
-	return cliDeps{
-		Tfs:        tfs,
-		CmdRunner:  cmdRunner,
-		Env:        &util.Env{Fs: tfs, Cmd: cmdRunner},
-		RuntimeEnv: runtime.NewRuntimeEnv(cmdRunner),
-	}
-}
-
-// cliReadDeps holds shared CLI dependencies for read-only commands.
-type cliReadDeps struct {
-	CmdRunner  util.CommandRunner
-	Env        *util.Env
-	RuntimeEnv *runtime.RuntimeEnv
-}
-
-// newCLIReadDeps creates shared dependencies for read-only CLI commands.
-func newCLIReadDeps() cliReadDeps {
-	cmdRunner := util.NewCommandRunner()
-	return cliReadDeps{
-		CmdRunner:  cmdRunner,
-		Env:        &util.Env{Fs: afero.NewReadOnlyFs(afero.NewOsFs()), Cmd: cmdRunner},
-		RuntimeEnv: runtime.NewRuntimeEnv(cmdRunner),
-	}
+func newCLIDeps() sandbox.Deps {
+	return sandbox.NewDeps(newHostEnv())
 }
 
-// checkProjectPathConsistency detects when a project directory has been moved
-// since the container was created. Returns an error with instructions if the
-// container's recorded path differs from the current working directory.
-func checkProjectPathConsistency(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, cwd string, cfg *config.Config) error {
-	if st == nil {
-		return nil
-	}
-
-	containers, err := rt.ListContainers(ctx, runtimeEnv)
-	if err != nil {
-		return fmt.Errorf("failed to list containers: %w", err)
-	}
-
-	// Find the container matching this project
-	var found *runtime.ContainerInfo
-	for i := range containers {
-		if containers[i].ProjectID == st.ProjectID {
-			found = &containers[i]
-			break
-		}
-	}
-
-	// No container found — nothing to check
-	if found == nil {
-		return nil
-	}
-
-	// Paths match — all good
-	if found.ProjectPath == cwd {
-		return nil
-	}
-
-	msg := fmt.Sprintf("project directory has moved from %s to %s\n\n"+
-		"The container was created in a different directory. Run 'alca down' first, then 'alca up' to recreate.",
-		found.ProjectPath, cwd)
-
-	// Check if mutagen/sync might be active
-	if cfg != nil && cfg.HasMutagenSync() {
-		msg += "\n\nNote: the old directory may have been recreated by file sync (mutagen). Check and clean up if needed."
-	}
-
-	return fmt.Errorf("%s: %w", msg, errProjectPathMismatch)
+// newHostEnv returns the real filesystem and the command runner.
+func newHostEnv() *util.Env {
+	return &util.Env{Fs: afero.NewOsFs(), Cmd: newCommandRunner()}
 }
 
-// runHook executes a host-side lifecycle hook command via "sh -c".
-// The command runs in the project directory with inherited stdout/stderr.
-// Returns nil if hook is empty (no-op).
-func runHook(ctx context.Context, cmdRunner util.CommandRunner, hook string, cwd string) error {
-	if hook == "" {
-		return nil
-	}
-	return cmdRunner.RunInDir(ctx, cwd, "sh", "-c", hook)
-}
+// newCommandRunner creates the CommandRunner for CLI dependencies.
+// Tests replace it to run commands against a mock.
+var newCommandRunner = func() util.CommandRunner { return util.NewCommandRunner() }
 
-// progressFunc returns a progress callback that writes to the given writer.
-func progressFunc(w io.Writer) func(format string, args ...any) {
-	return func(format string, args ...any) {
-		util.ProgressStep(w, format, args...)
-	}
+// newCLIReadDeps creates shared dependencies for read-only CLI commands.
+func newCLIReadDeps() sandbox.ReadDeps {
+	return sandbox.NewReadDeps(newHostEnv())
 }
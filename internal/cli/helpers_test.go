@@ -1,242 +1,11 @@
 package cli
 
 import (
-	"bytes"
-	"context"
-	"errors"
-	"os"
-	"path/filepath"
-	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
-
-	"github.com/bolasblack/alcatraz/internal/config"
-	"github.com/bolasblack/alcatraz/internal/runtime"
-	"github.com/bolasblack/alcatraz/internal/state"
-	"github.com/bolasblack/alcatraz/internal/util"
 )
 
-func TestDisplayConfigDrift(t *testing.T) {
-	tests := []struct {
-		name           string
-		drift          *state.DriftChanges
-		runtimeChanged bool
-		oldRuntime     string
-		newRuntime     string
-		wantOutput     bool
-		wantContains   []string
-	}{
-		{
-			name:           "no drift",
-			drift:          nil,
-			runtimeChanged: false,
-			wantOutput:     false,
-		},
-		{
-			name:           "runtime changed only",
-			drift:          nil,
-			runtimeChanged: true,
-			oldRuntime:     "Docker",
-			newRuntime:     "Podman",
-			wantOutput:     true,
-			wantContains:   []string{"Runtime: Docker → Podman"},
-		},
-		{
-			name: "image changed",
-			drift: &state.DriftChanges{
-				Image: &[2]string{"ubuntu:20.04", "ubuntu:22.04"},
-			},
-			wantOutput:   true,
-			wantContains: []string{"Image: ubuntu:20.04 → ubuntu:22.04"},
-		},
-		{
-			name: "workdir changed",
-			drift: &state.DriftChanges{
-				Workdir: &[2]string{"/app", "/workspace"},
-			},
-			wantOutput:   true,
-			wantContains: []string{"Workdir: /app → /workspace"},
-		},
-		{
-			name: "mounts changed",
-			drift: &state.DriftChanges{
-				Mounts: true,
-			},
-			wantOutput:   true,
-			wantContains: []string{"Mounts: changed"},
-		},
-		{
-			name: "command up changed",
-			drift: &state.DriftChanges{
-				CommandUp: &[2]string{"sleep infinity", "tail -f /dev/null"},
-			},
-			wantOutput:   true,
-			wantContains: []string{"Commands.up: changed"},
-		},
-		{
-			name: "memory changed",
-			drift: &state.DriftChanges{
-				Memory: &[2]string{"1g", "2g"},
-			},
-			wantOutput:   true,
-			wantContains: []string{"Resources.memory: 1g → 2g"},
-		},
-		{
-			name: "cpus changed",
-			drift: &state.DriftChanges{
-				CPUs: &[2]int{2, 4},
-			},
-			wantOutput:   true,
-			wantContains: []string{"Resources.cpus: 2 → 4"},
-		},
-		{
-			name: "envs changed",
-			drift: &state.DriftChanges{
-				Envs: true,
-			},
-			wantOutput:   true,
-			wantContains: []string{"Envs: changed"},
-		},
-		{
-			name: "multiple changes",
-			drift: &state.DriftChanges{
-				Image:  &[2]string{"old", "new"},
-				Mounts: true,
-			},
-			runtimeChanged: true,
-			oldRuntime:     "Docker",
-			newRuntime:     "Podman",
-			wantOutput:     true,
-			wantContains: []string{
-				"Configuration has changed",
-				"Runtime: Docker → Podman",
-				"Image: old → new",
-				"Mounts: changed",
-			},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			var buf bytes.Buffer
-			result := displayConfigDrift(&buf, tt.drift, tt.runtimeChanged, tt.oldRuntime, tt.newRuntime)
-
-			if result != tt.wantOutput {
-				t.Errorf("displayConfigDrift() returned %v, want %v", result, tt.wantOutput)
-			}
-
-			output := buf.String()
-			if tt.wantOutput && output == "" {
-				t.Error("expected output but got empty string")
-			}
-			if !tt.wantOutput && output != "" {
-				t.Errorf("expected no output but got: %s", output)
-			}
-
-			for _, want := range tt.wantContains {
-				if !bytes.Contains(buf.Bytes(), []byte(want)) {
-					t.Errorf("output missing expected string %q\nGot: %s", want, output)
-				}
-			}
-		})
-	}
-}
-
-func TestFindProjectDirFrom(t *testing.T) {
-	t.Run("finds config in current dir", func(t *testing.T) {
-		fs := afero.NewMemMapFs()
-		_ = afero.WriteFile(fs, "/home/user/project/.alca.toml", []byte(""), 0644)
-
-		got := findProjectDirFrom(fs, "/home/user/project")
-		if got != "/home/user/project" {
-			t.Errorf("got %q, want /home/user/project", got)
-		}
-	})
-
-	t.Run("finds config in parent dir", func(t *testing.T) {
-		fs := afero.NewMemMapFs()
-		_ = afero.WriteFile(fs, "/home/user/project/.alca.toml", []byte(""), 0644)
-		_ = fs.MkdirAll("/home/user/project/src/pkg", 0755)
-
-		got := findProjectDirFrom(fs, "/home/user/project/src/pkg")
-		if got != "/home/user/project" {
-			t.Errorf("got %q, want /home/user/project", got)
-		}
-	})
-
-	t.Run("returns startDir when not found", func(t *testing.T) {
-		fs := afero.NewMemMapFs()
-
-		got := findProjectDirFrom(fs, "/home/user/noproject/deep/dir")
-		if got != "/home/user/noproject/deep/dir" {
-			t.Errorf("got %q, want /home/user/noproject/deep/dir", got)
-		}
-	})
-
-	t.Run("finds nearest config (not grandparent)", func(t *testing.T) {
-		fs := afero.NewMemMapFs()
-		_ = afero.WriteFile(fs, "/home/user/.alca.toml", []byte(""), 0644)
-		_ = afero.WriteFile(fs, "/home/user/project/.alca.toml", []byte(""), 0644)
-		_ = fs.MkdirAll("/home/user/project/src", 0755)
-
-		got := findProjectDirFrom(fs, "/home/user/project/src")
-		if got != "/home/user/project" {
-			t.Errorf("got %q, want /home/user/project (nearest), not /home/user", got)
-		}
-	})
-
-	t.Run("skips directory named .alca.toml", func(t *testing.T) {
-		fs := afero.NewMemMapFs()
-		// .alca.toml exists as a directory in /project — should be skipped
-		_ = fs.MkdirAll("/home/user/project/.alca.toml", 0755)
-		// Real config file in parent
-		_ = afero.WriteFile(fs, "/home/user/.alca.toml", []byte(""), 0644)
-		_ = fs.MkdirAll("/home/user/project/src", 0755)
-
-		got := findProjectDirFrom(fs, "/home/user/project/src")
-		if got != "/home/user" {
-			t.Errorf("got %q, want /home/user (should skip directory)", got)
-		}
-	})
-
-	t.Run("skips directory named .alca.toml with no fallback", func(t *testing.T) {
-		fs := afero.NewMemMapFs()
-		// Only a directory exists, no real file anywhere
-		_ = fs.MkdirAll("/home/user/project/.alca.toml", 0755)
-		_ = fs.MkdirAll("/home/user/project/src", 0755)
-
-		got := findProjectDirFrom(fs, "/home/user/project/src")
-		if got != "/home/user/project/src" {
-			t.Errorf("got %q, want /home/user/project/src (startDir fallback)", got)
-		}
-	})
-
-	t.Run("symlink to config file is accepted", func(t *testing.T) {
-		// MemMapFs doesn't support symlinks — use OsFs with temp dir
-		tmpDir := t.TempDir()
-		fs := afero.NewOsFs()
-
-		projectDir := filepath.Join(tmpDir, "project")
-		realDir := filepath.Join(tmpDir, "shared")
-		_ = fs.MkdirAll(projectDir, 0755)
-		_ = fs.MkdirAll(realDir, 0755)
-
-		// Create real config file and symlink to it
-		realConfig := filepath.Join(realDir, ".alca.toml")
-		_ = afero.WriteFile(fs, realConfig, []byte("image = \"test\""), 0644)
-		_ = os.Symlink(realConfig, filepath.Join(projectDir, ".alca.toml")) //nolint:fslint // afero has no Symlink; real filesystem needed
-
-		subDir := filepath.Join(projectDir, "src", "pkg")
-		_ = fs.MkdirAll(subDir, 0755)
-
-		got := findProjectDirFrom(fs, subDir)
-		if got != projectDir {
-			t.Errorf("got %q, want %q (symlink should be treated as file)", got, projectDir)
-		}
-	})
-}
-
 func TestNewCLIDeps(t *testing.T) {
 	deps := newCLIDeps()
 
@@ -264,281 +33,3 @@ func TestNewCLIReadDeps(t *testing.T) {
 		t.Error("write through Env.Fs should fail (read-only)")
 	}
 }
-
-func TestProgressFunc(t *testing.T) {
-	t.Run("writes formatted output to writer", func(t *testing.T) {
-		var buf bytes.Buffer
-		pf := progressFunc(&buf)
-
-		pf("Installing %s version %d...\n", "package", 2)
-
-		output := buf.String()
-		if !strings.Contains(output, "Installing package version 2...") {
-			t.Errorf("expected formatted progress output, got: %q", output)
-		}
-	})
-
-	t.Run("includes step prefix from ProgressStep", func(t *testing.T) {
-		var buf bytes.Buffer
-		pf := progressFunc(&buf)
-
-		pf("hello")
-
-		output := buf.String()
-		// ProgressStep prepends "→ " prefix
-		if !strings.HasPrefix(output, "→ ") {
-			t.Errorf("expected output to start with '→ ' prefix, got: %q", output)
-		}
-	})
-
-	t.Run("nil writer does not panic", func(t *testing.T) {
-		pf := progressFunc(nil)
-		// Should not panic
-		pf("test %s", "message")
-	})
-
-	t.Run("multiple calls append to writer", func(t *testing.T) {
-		var buf bytes.Buffer
-		pf := progressFunc(&buf)
-
-		pf("first\n")
-		pf("second\n")
-
-		output := buf.String()
-		if !strings.Contains(output, "first") || !strings.Contains(output, "second") {
-			t.Errorf("expected both messages in output, got: %q", output)
-		}
-	})
-}
-
-// pathCheckMockRuntime implements runtime.Runtime for testing checkProjectPathConsistency.
-type pathCheckMockRuntime struct {
-	runtime.StubRuntime
-	containers []runtime.ContainerInfo
-	listErr    error
-}
-
-var _ runtime.Runtime = (*pathCheckMockRuntime)(nil)
-
-func (m *pathCheckMockRuntime) ListContainers(_ context.Context, _ *runtime.RuntimeEnv) ([]runtime.ContainerInfo, error) {
-	return m.containers, m.listErr
-}
-
-func TestRunHook_EmptyIsNoop(t *testing.T) {
-	cmd := util.NewMockCommandRunner()
-	err := runHook(context.Background(), cmd, "", "/tmp")
-	if err != nil {
-		t.Errorf("expected nil error for empty hook, got: %v", err)
-	}
-	if len(cmd.Calls) != 0 {
-		t.Errorf("expected no calls for empty hook, got %d", len(cmd.Calls))
-	}
-}
-
-func TestRunHook_ExecutesViaSh(t *testing.T) {
-	cmd := util.NewMockCommandRunner()
-	cmd.ExpectSuccess("sh -c echo hello", nil)
-	defer cmd.AssertAllExpectationsMet(t)
-
-	err := runHook(context.Background(), cmd, "echo hello", "/my/project")
-	if err != nil {
-		t.Errorf("expected nil error, got: %v", err)
-	}
-	// Verify working directory was passed to RunInDir
-	if len(cmd.Calls) != 1 {
-		t.Fatalf("expected 1 call, got %d", len(cmd.Calls))
-	}
-	if cmd.Calls[0].Dir != "/my/project" {
-		t.Errorf("expected Dir=%q, got %q", "/my/project", cmd.Calls[0].Dir)
-	}
-}
-
-func TestRunHook_ReturnsError(t *testing.T) {
-	cmdErr := errors.New("exit status 1")
-	cmd := util.NewMockCommandRunner()
-	cmd.ExpectFailure("sh -c exit 1", cmdErr)
-
-	err := runHook(context.Background(), cmd, "exit 1", "/tmp")
-	if !errors.Is(err, cmdErr) {
-		t.Fatalf("expected command error to propagate, got: %v", err)
-	}
-}
-
-func TestDisplayConfigDrift_HooksPostUp(t *testing.T) {
-	var buf bytes.Buffer
-	drift := &state.DriftChanges{
-		HooksPostUp: &[2]string{"echo old", "echo new"},
-	}
-	result := displayConfigDrift(&buf, drift, false, "", "")
-	if !result {
-		t.Error("expected output")
-	}
-	if !strings.Contains(buf.String(), "Hooks.post_up: changed") {
-		t.Errorf("expected Hooks.post_up in output, got: %s", buf.String())
-	}
-}
-
-func TestDisplayConfigDrift_HooksPreDown(t *testing.T) {
-	var buf bytes.Buffer
-	drift := &state.DriftChanges{
-		HooksPreDown: &[2]string{"pkill old", "pkill new"},
-	}
-	result := displayConfigDrift(&buf, drift, false, "", "")
-	if !result {
-		t.Error("expected output")
-	}
-	if !strings.Contains(buf.String(), "Hooks.pre_down: changed") {
-		t.Errorf("expected Hooks.pre_down in output, got: %s", buf.String())
-	}
-}
-
-func TestCheckProjectPathConsistency(t *testing.T) {
-	ctx := context.Background()
-	runtimeEnv := &runtime.RuntimeEnv{}
-	projectID := "test-project-id"
-
-	t.Run("paths match returns no error", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{
-			containers: []runtime.ContainerInfo{
-				{ProjectID: projectID, ProjectPath: "/home/user/myproject"},
-			},
-		}
-		st := &state.State{ProjectID: projectID}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, "/home/user/myproject", nil)
-		if err != nil {
-			t.Errorf("expected no error, got: %v", err)
-		}
-	})
-
-	t.Run("path mismatch returns error", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{
-			containers: []runtime.ContainerInfo{
-				{ProjectID: projectID, ProjectPath: "/home/user/old-path"},
-			},
-		}
-		st := &state.State{ProjectID: projectID}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, "/home/user/new-path", nil)
-		if !errors.Is(err, errProjectPathMismatch) {
-			t.Fatalf("expected errProjectPathMismatch, got: %v", err)
-		}
-		if !strings.Contains(err.Error(), "/home/user/old-path") {
-			t.Errorf("error should mention old path, got: %v", err)
-		}
-		if !strings.Contains(err.Error(), "/home/user/new-path") {
-			t.Errorf("error should mention new path, got: %v", err)
-		}
-		if !strings.Contains(err.Error(), "alca down") {
-			t.Errorf("error should mention 'alca down', got: %v", err)
-		}
-	})
-
-	t.Run("no container found returns no error", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{
-			containers: []runtime.ContainerInfo{
-				{ProjectID: "other-project", ProjectPath: "/somewhere/else"},
-			},
-		}
-		st := &state.State{ProjectID: projectID}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, "/home/user/myproject", nil)
-		if err != nil {
-			t.Errorf("expected no error, got: %v", err)
-		}
-	})
-
-	t.Run("nil state returns no error", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, nil, "/home/user/myproject", nil)
-		if err != nil {
-			t.Errorf("expected no error, got: %v", err)
-		}
-	})
-
-	t.Run("mismatch with mutagen config includes sync warning", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{
-			containers: []runtime.ContainerInfo{
-				{ProjectID: projectID, ProjectPath: "/home/user/old-path"},
-			},
-		}
-		st := &state.State{ProjectID: projectID}
-		cfg := &config.Config{
-			WorkdirExclude: []string{"node_modules"},
-		}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, "/home/user/new-path", cfg)
-		if !errors.Is(err, errProjectPathMismatch) {
-			t.Fatalf("expected errProjectPathMismatch, got: %v", err)
-		}
-		if !strings.Contains(err.Error(), "mutagen") {
-			t.Errorf("error should mention mutagen, got: %v", err)
-		}
-	})
-
-	t.Run("mismatch with mount excludes includes sync warning", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{
-			containers: []runtime.ContainerInfo{
-				{ProjectID: projectID, ProjectPath: "/home/user/old-path"},
-			},
-		}
-		st := &state.State{ProjectID: projectID}
-		cfg := &config.Config{
-			Mounts: []config.MountConfig{
-				{Source: ".", Target: "/app", Exclude: []string{".git"}},
-			},
-		}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, "/home/user/new-path", cfg)
-		if !errors.Is(err, errProjectPathMismatch) {
-			t.Fatalf("expected errProjectPathMismatch, got: %v", err)
-		}
-		if !strings.Contains(err.Error(), "mutagen") {
-			t.Errorf("error should mention mutagen, got: %v", err)
-		}
-	})
-
-	t.Run("mismatch without mutagen does not include sync warning", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{
-			containers: []runtime.ContainerInfo{
-				{ProjectID: projectID, ProjectPath: "/home/user/old-path"},
-			},
-		}
-		st := &state.State{ProjectID: projectID}
-		cfg := &config.Config{}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, "/home/user/new-path", cfg)
-		if !errors.Is(err, errProjectPathMismatch) {
-			t.Fatalf("expected errProjectPathMismatch, got: %v", err)
-		}
-		if strings.Contains(err.Error(), "mutagen") {
-			t.Errorf("error should NOT mention mutagen, got: %v", err)
-		}
-	})
-
-	t.Run("list containers error is propagated", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{listErr: errors.New("connection refused")}
-		st := &state.State{ProjectID: projectID}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, "/any", nil)
-		if err == nil {
-			t.Fatal("expected error, got nil")
-		}
-		if !strings.Contains(err.Error(), "connection refused") {
-			t.Errorf("error should contain original cause, got: %v", err)
-		}
-	})
-
-	t.Run("empty container list returns no error", func(t *testing.T) {
-		rt := &pathCheckMockRuntime{
-			containers: []runtime.ContainerInfo{},
-		}
-		st := &state.State{ProjectID: projectID}
-
-		err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, "/home/user/myproject", nil)
-		if err != nil {
-			t.Errorf("expected no error, got: %v", err)
-		}
-	})
-}
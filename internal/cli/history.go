@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/history"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show commands previously run in the sandbox",
+	Long: `List commands executed with 'alca run', oldest first, with their start
+time and exit code. History is stored in .alca/history.jsonl.
+
+Use 'alca history replay <n>' to run entry n again.`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+var historyReplayCmd = &cobra.Command{
+	Use:   "replay <n>",
+	Short: "Re-run a command from history",
+	Long: `Re-run history entry n (as numbered by 'alca history') inside the sandbox.
+The replayed command is recorded as a new history entry.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistoryReplay,
+}
+
+func init() {
+	historyCmd.Flags().IntP("limit", "n", 0, "Show only the last n entries (0 shows all)")
+	historyCmd.AddCommand(historyReplayCmd)
+}
+
+// runHistory lists recorded commands.
+func runHistory(cmd *cobra.Command, args []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	entries, err := history.Load(newCLIReadDeps().Env.Fs, cwd)
+	if err != nil {
+		return err
+	}
+
+	writeHistory(os.Stdout, entries, limit)
+	return nil
+}
+
+// writeHistory prints entries numbered from 1, keeping numbering stable when limited.
+func writeHistory(w io.Writer, entries []history.Entry, limit int) {
+	if len(entries) == 0 {
+		_, _ = fmt.Fprintln(w, "No commands recorded yet.")
+		return
+	}
+
+	start := 0
+	if limit > 0 && limit < len(entries) {
+		start = len(entries) - limit
+	}
+
+	width := len(strconv.Itoa(len(entries)))
+	for i := start; i < len(entries); i++ {
+		e := entries[i]
+		quoted := make([]string, len(e.Command))
+		for j, arg := range e.Command {
+			quoted[j] = shellQuoteIfNeeded(arg)
+		}
+		_, _ = fmt.Fprintf(w, "%*d  %s  exit %-3d  %s\n", width, i+1,
+			e.Time.Local().Format("2006-01-02 15:04:05"), e.ExitCode, strings.Join(quoted, " "))
+	}
+}
+
+// runHistoryReplay re-runs a history entry.
+func runHistoryReplay(cmd *cobra.Command, args []string) error {
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return fmt.Errorf("invalid history entry %q: must be a positive number", args[0])
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	entries, err := history.Load(newCLIReadDeps().Env.Fs, cwd)
+	if err != nil {
+		return err
+	}
+	if n > len(entries) {
+		return fmt.Errorf("history entry %d not found (%d recorded)", n, len(entries))
+	}
+
+	command := entries[n-1].Command
+	_, _ = fmt.Fprintf(os.Stderr, "→ Replaying: %s\n", strings.Join(command, " "))
+	return passExitCode(sandbox.Exec(cmd.Context(), newHostEnv(), cwd, command, execOptionsFromCmd(cmd, "")))
+}
+
+// shellQuoteIfNeeded quotes an argument only if it contains characters the
+// shell would interpret, keeping history output readable.
+func shellQuoteIfNeeded(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`&|;<>()*?[]{}~#!") {
+		return s
+	}
+	return sandbox.ShellQuote(s)
+}
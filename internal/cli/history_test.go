@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/history"
+)
+
+func TestWriteHistory(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	entries := []history.Entry{
+		{Time: ts, Command: []string{"ls"}, ExitCode: 0},
+		{Time: ts, Command: []string{"git", "commit", "-m", "fix bug"}, ExitCode: 1},
+		{Time: ts, Command: []string{"make"}, ExitCode: 0},
+	}
+
+	var buf bytes.Buffer
+	writeHistory(&buf, entries, 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "2  2026-01-02 03:04:05  exit 1  ") ||
+		!strings.HasSuffix(lines[0], "git commit -m 'fix bug'") {
+		t.Errorf("unexpected line: %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "3  ") {
+		t.Errorf("expected stable numbering, got %q", lines[1])
+	}
+}
+
+func TestWriteHistory_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	writeHistory(&buf, nil, 0)
+	if !strings.Contains(buf.String(), "No commands recorded") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// idleCheckInterval throttles the background check started by alca
+	// invocations.
+	idleCheckInterval = 10 * time.Minute
+	// idleCheckMarker is the file in ~/.alcatraz whose mtime records the
+	// last background check.
+	idleCheckMarker = "idle-check"
+)
+
+var stopIdleCmd = &cobra.Command{
+	Use:   "stop-idle",
+	Short: "Stop containers unused for longer than their idle_timeout",
+	Long: `Stop every running alca container whose project sets idle_timeout and that
+no alca up, enter or run has used for that long. Open enter and run
+sessions count as use; shells reached another way (ssh, docker exec) do not.
+
+Stopped containers keep their files: the next 'alca enter' or 'alca run'
+starts them again without asking, and 'alca up' does too.
+
+Every alca command starts this check in the background, at most every
+` + idleCheckInterval.String() + `. To check on a schedule instead, run 'alca stop-idle' from a
+launchd or systemd timer and set ` + sandbox.EnvNoIdleCheck + `=1.
+
+Only containers of the runtime alca detects by default are checked.`,
+	Args: cobra.NoArgs,
+	RunE: runStopIdle,
+}
+
+func init() {
+	stopIdleCmd.Flags().Bool("dry-run", false, "List idle containers without stopping them")
+}
+
+func runStopIdle(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	// Usage files are small and rewritten as a whole; they need no
+	// transaction or sudo
+	env := newHostEnv()
+	runtimeEnv := sandbox.NewReadDeps(env).RuntimeEnv
+	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, &config.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to select runtime: %w", err)
+	}
+	return stopIdleContainers(ctx, env, runtimeEnv, rt, time.Now(), dryRun, os.Stdout)
+}
+
+// stopIdleContainers stops the running containers idle past their project's
+// idle_timeout and marks them in the usage file for enter and run to
+// restart. Projects whose config, state or usage cannot be read are skipped.
+func stopIdleContainers(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, now time.Time, dryRun bool, out io.Writer) error {
+	containers, err := rt.ListContainers(ctx, runtimeEnv)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range containers {
+		if c.State != runtime.StateRunning || c.ProjectPath == "" {
+			continue
+		}
+		st, err := state.Load(env, c.ProjectPath)
+		if err != nil || st == nil || st.ProjectID != c.ProjectID || st.Runtime != rt.Name() {
+			continue
+		}
+		cfg, _, err := sandbox.LoadConfigFromCwd(ctx, env, c.ProjectPath)
+		if err != nil {
+			continue
+		}
+		timeout := cfg.IdleTimeoutDuration()
+		usage, err := state.LoadUsage(env, c.ProjectPath)
+		if err != nil || timeout == 0 || usage.IdleFor(now) < timeout {
+			continue
+		}
+
+		idle := usage.IdleFor(now).Truncate(time.Minute)
+		if dryRun {
+			_, _ = fmt.Fprintf(out, "%s (%s): idle for %s\n", c.Name, c.ProjectPath, idle)
+			continue
+		}
+		if err := rt.Stop(ctx, runtimeEnv, c.ProjectPath, st); err != nil {
+			util.ProgressStep(out, "Warning: failed to stop idle container %s: %v\n", c.Name, err)
+			continue
+		}
+		sandbox.EmitEvent(env.Fs, c.ProjectPath, st, lifecycle.Event{
+			Type:      lifecycle.ContainerStopped,
+			Container: c.Name,
+			Message:   fmt.Sprintf("stopped after being idle for %s", idle),
+		})
+		usage.IdleStoppedAt = now
+		if err := state.SaveUsage(env, c.ProjectPath, usage); err != nil {
+			util.ProgressStep(out, "Warning: %v\n", err)
+		}
+		util.ProgressDone(out, "Stopped %s (%s), idle for %s\n", c.Name, c.ProjectPath, idle)
+	}
+	return nil
+}
+
+// startIdleCheck runs 'alca stop-idle' in the background, at most once per
+// idleCheckInterval across all alca invocations. Best-effort: failures are
+// ignored, and the invocation never waits for the check.
+func startIdleCheck(cmd *cobra.Command) {
+	if os.Getenv(sandbox.EnvNoIdleCheck) != "" {
+		return
+	}
+	// The helper runs as root, and stop-idle is the check itself
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == networkHelperCmd || c == stopIdleCmd {
+			return
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return
+	}
+	spawnIdleCheck(newHostEnv(), filepath.Join(home, util.AlcatrazDir, idleCheckMarker), self, time.Now())
+}
+
+// spawnIdleCheck starts 'self stop-idle' in the background if a check is
+// due at now. The check does not start checks of its own.
+func spawnIdleCheck(env *util.Env, marker, self string, now time.Time) {
+	if !claimIdleCheck(env.Fs, marker, now) {
+		return
+	}
+	_ = env.Cmd.Start(util.StartOptions{Env: []string{sandbox.EnvNoIdleCheck + "=1"}}, self, "stop-idle")
+}
+
+// claimIdleCheck reports whether a check is due at now, and if so records
+// it in the marker file so concurrent invocations do not check again.
+func claimIdleCheck(fs afero.Fs, marker string, now time.Time) bool {
+	if info, err := fs.Stat(marker); err == nil && now.Sub(info.ModTime()) < idleCheckInterval {
+		return false
+	}
+	if err := fs.MkdirAll(filepath.Dir(marker), 0o755); err != nil {
+		return false
+	}
+	if err := afero.WriteFile(fs, marker, nil, 0o644); err != nil {
+		return false
+	}
+	return fs.Chtimes(marker, now, now) == nil
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestClaimIdleCheck_Throttles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	marker := "/home/u/.alcatraz/idle-check"
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if !claimIdleCheck(fs, marker, now) {
+		t.Fatal("first check should be due")
+	}
+	if claimIdleCheck(fs, marker, now.Add(idleCheckInterval/2)) {
+		t.Error("check within the interval should be skipped")
+	}
+	if !claimIdleCheck(fs, marker, now.Add(idleCheckInterval)) {
+		t.Error("check after the interval should be due")
+	}
+}
+
+func TestSpawnIdleCheck_StartsStopIdleOncePerInterval(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("/usr/local/bin/alca stop-idle", nil)
+	env := &util.Env{Fs: afero.NewMemMapFs(), Cmd: mockCmd}
+	marker := "/home/u/.alcatraz/idle-check"
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	spawnIdleCheck(env, marker, "/usr/local/bin/alca", now)
+	spawnIdleCheck(env, marker, "/usr/local/bin/alca", now.Add(idleCheckInterval/2))
+
+	if n := mockCmd.CallCount("/usr/local/bin/alca stop-idle"); n != 1 {
+		t.Fatalf("stop-idle started %d times, want 1", n)
+	}
+	if got := mockCmd.Calls[0].Env; len(got) != 1 || got[0] != sandbox.EnvNoIdleCheck+"=1" {
+		t.Errorf("Env = %v, want %s=1 so the check does not start another", got, sandbox.EnvNoIdleCheck)
+	}
+}
+
+func TestFakeRuntime_StopIdleAndRestartOnRun(t *testing.T) {
+	fake, dir := setupFakeProject(t, `idle_timeout = "1h"
+`+fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	osEnv := &util.Env{Fs: afero.NewOsFs()}
+	usage, err := state.LoadUsage(osEnv, dir)
+	if err != nil || usage == nil {
+		t.Fatalf("up recorded no use: %v, %v", usage, err)
+	}
+
+	// Not idle yet
+	rt := runtime.SharedFake()
+	var out strings.Builder
+	if err := stopIdleContainers(context.Background(), osEnv, nil, rt, usage.LastUsedAt.Add(30*time.Minute), false, &out); err != nil {
+		t.Fatalf("stopIdleContainers: %v", err)
+	}
+	if c := fake.Container(st.ContainerName); c.State != runtime.StateRunning {
+		t.Fatalf("container stopped before idle_timeout: %+v", c)
+	}
+
+	if err := stopIdleContainers(context.Background(), osEnv, nil, rt, usage.LastUsedAt.Add(2*time.Hour), false, &out); err != nil {
+		t.Fatalf("stopIdleContainers: %v", err)
+	}
+	if c := fake.Container(st.ContainerName); c.State != runtime.StateStopped {
+		t.Fatalf("idle container not stopped: %+v", c)
+	}
+	if !sandbox.WasIdleStopped(newHostEnv(), dir) {
+		t.Error("idle stop not recorded")
+	}
+
+	// run starts it again without asking, even non-interactively
+	recovery := &sandbox.Recovery{
+		Prompt: sandbox.PromptMode{NonInteractive: true},
+		Up: func(context.Context, string) error {
+			return runFakeCommand(t, upCmd, runUp, "quiet")
+		},
+		Out: &out,
+	}
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{Recovery: recovery}); err != nil {
+		t.Fatalf("run after idle stop: %v", err)
+	}
+	if c := fake.Container(st.ContainerName); c.State != runtime.StateRunning {
+		t.Errorf("container after run = %+v, want running", c)
+	}
+	if sandbox.WasIdleStopped(newHostEnv(), dir) {
+		t.Error("idle stop still recorded after use")
+	}
+}
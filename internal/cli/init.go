@@ -15,13 +15,14 @@ import (
 
 	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/preset"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
 	"github.com/bolasblack/alcatraz/internal/transact"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
 
-// ConfigFilename is the standard configuration file name.
-// See AGD-009 for configuration format design.
-const ConfigFilename = ".alca.toml"
+// EnvrcFilename is the direnv file written by alca init --direnv.
+const EnvrcFilename = ".envrc"
 
 var initCmd = &cobra.Command{
 	Use:   "init [git+<url>]",
@@ -32,6 +33,12 @@ When called with a git+<url> argument, downloads preset configuration files from
 Use --template/-t to select a template non-interactively (e.g., --template alpine).
 Use --update to refresh previously downloaded preset files to their latest versions.
 
+Use --direnv to also write an .envrc that loads 'alca shellenv' whenever
+direnv enters the project; with --auto-up it first runs
+'alca up --quiet --idempotent', so the sandbox is started on cd. When
+.alca.toml already exists, only the .envrc is written. Run 'direnv allow'
+afterwards.
+
 The --template and --update flags are mutually exclusive.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runInit,
@@ -40,6 +47,8 @@ The --template and --update flags are mutually exclusive.`,
 func init() {
 	initCmd.Flags().Bool("update", false, "Update all preset files to latest versions")
 	initCmd.Flags().StringP("template", "t", "", "Template to use (alpine, debian-mise, debian-slim, nix)")
+	initCmd.Flags().Bool("direnv", false, "Also write an "+EnvrcFilename+" that loads 'alca shellenv'")
+	initCmd.Flags().Bool("auto-up", false, "With --direnv, start the container when entering the directory")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
@@ -50,11 +59,24 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	update, _ := cmd.Flags().GetBool("update")
 	templateFlag, _ := cmd.Flags().GetString("template")
+	direnv, _ := cmd.Flags().GetBool("direnv")
+	autoUp, _ := cmd.Flags().GetBool("auto-up")
 
 	// Mutual exclusion: --template and --update cannot be combined
 	if update && templateFlag != "" {
 		return fmt.Errorf("cannot use --template with --update")
 	}
+	if autoUp && !direnv {
+		return fmt.Errorf("--auto-up requires --direnv")
+	}
+
+	// direnv flow: the project is already initialized
+	if direnv && len(args) == 0 && !update {
+		fs := newCLIReadDeps().Env.Fs
+		if _, err := fs.Stat(filepath.Join(cwd, sandbox.ConfigFilename)); err == nil {
+			return runInitDirenv(cmd.Context(), cwd, autoUp)
+		}
+	}
 
 	// Update flow
 	if update {
@@ -67,7 +89,13 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Template flow
-	return runInitTemplate(cmd.Context(), cwd, templateFlag)
+	if err := runInitTemplate(cmd.Context(), cwd, templateFlag); err != nil {
+		return err
+	}
+	if direnv {
+		return runInitDirenv(cmd.Context(), cwd, autoUp)
+	}
+	return nil
 }
 
 func runInitTemplate(ctx context.Context, cwd string, templateFlag string) error {
@@ -75,7 +103,7 @@ func runInitTemplate(ctx context.Context, cwd string, templateFlag string) error
 	tfs := transact.New()
 	env := util.NewEnv(tfs)
 
-	configPath := filepath.Join(cwd, ConfigFilename)
+	configPath := filepath.Join(cwd, sandbox.ConfigFilename)
 
 	// Check if config already exists
 	if _, err := env.Fs.Stat(configPath); err == nil {
@@ -116,7 +144,7 @@ func runInitTemplate(ctx context.Context, cwd string, templateFlag string) error
 	}
 
 	// Commit the changes (project dir, normally no sudo needed)
-	if err := commitWithSudo(ctx, env, tfs, os.Stdout, ""); err != nil {
+	if err := sandbox.CommitWithSudo(ctx, env, tfs, os.Stdout, ""); err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
 
@@ -127,7 +155,7 @@ func runInitTemplate(ctx context.Context, cwd string, templateFlag string) error
 
 // presetEnvAndCacheDir creates the common dependencies for preset operations.
 func presetEnvAndCacheDir() (*preset.PresetEnv, string, error) {
-	cmdRunner := util.NewCommandRunner()
+	cmdRunner := newCommandRunner()
 	fs := afero.NewOsFs()
 	env := preset.NewPresetEnv(fs, cmdRunner)
 
@@ -188,3 +216,48 @@ func runInitUpdate(ctx context.Context, cwd string) error {
 
 	return preset.RunUpdateFlow(ctx, env, cacheDir, cwd, os.Stdout)
 }
+
+// runInitDirenv handles `alca init --direnv` — writes the project's .envrc.
+func runInitDirenv(ctx context.Context, cwd string, autoUp bool) error {
+	tfs := transact.New()
+	env := util.NewEnv(tfs)
+
+	envrcPath := filepath.Join(cwd, EnvrcFilename)
+	if err := writeEnvrc(env.Fs, envrcPath, autoUp); err != nil {
+		return err
+	}
+	if err := sandbox.CommitWithSudo(ctx, env, tfs, os.Stdout, ""); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+
+	util.ProgressDone(os.Stdout, "Created %s\n", envrcPath)
+	fmt.Println("Run 'direnv allow' to enable it.")
+	return nil
+}
+
+// writeEnvrc writes an .envrc that loads alca shellenv, refusing to replace
+// an existing one since it usually holds the user's own setup.
+func writeEnvrc(fs afero.Fs, path string, autoUp bool) error {
+	if _, err := fs.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists: add 'eval \"$(alca shellenv)\"' to it instead", path)
+	}
+	if err := afero.WriteFile(fs, path, []byte(envrcContent(autoUp)), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// envrcContent returns the .envrc body. shellenv needs the state file, so
+// without auto-up it is only loaded once the container has been created.
+func envrcContent(autoUp bool) string {
+	var b strings.Builder
+	b.WriteString("# Generated by alca init --direnv\n")
+	b.WriteString("watch_file " + sandbox.ConfigFilename + "\n")
+	if autoUp {
+		b.WriteString("alca up --quiet --idempotent\n")
+	}
+	b.WriteString("if [ -f " + filepath.Join(state.StateDir, state.StateFilename) + " ]; then\n")
+	b.WriteString("  eval \"$(alca shellenv)\"\n")
+	b.WriteString("fi\n")
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestEnvrcContent(t *testing.T) {
+	plain := envrcContent(false)
+	if strings.Contains(plain, "alca up") {
+		t.Errorf("envrc without auto-up runs alca up:\n%s", plain)
+	}
+	if !strings.Contains(plain, "watch_file .alca.toml\n") ||
+		!strings.Contains(plain, "if [ -f .alca/state.json ]; then\n  eval \"$(alca shellenv)\"\nfi\n") {
+		t.Errorf("unexpected envrc:\n%s", plain)
+	}
+
+	autoUp := envrcContent(true)
+	up := strings.Index(autoUp, "alca up --quiet --idempotent\n")
+	if up < 0 || up > strings.Index(autoUp, "alca shellenv") {
+		t.Errorf("envrc with auto-up must run alca up before shellenv:\n%s", autoUp)
+	}
+}
+
+func TestWriteEnvrc(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := writeEnvrc(fs, "/p/.envrc", true); err != nil {
+		t.Fatalf("writeEnvrc() error: %v", err)
+	}
+	data, err := afero.ReadFile(fs, "/p/.envrc")
+	if err != nil || string(data) != envrcContent(true) {
+		t.Errorf(".envrc = %q, %v", data, err)
+	}
+
+	// An existing .envrc is left alone
+	if err := writeEnvrc(fs, "/p/.envrc", false); err == nil {
+		t.Error("expected error for existing .envrc")
+	}
+	if data, _ := afero.ReadFile(fs, "/p/.envrc"); string(data) != envrcContent(true) {
+		t.Error("existing .envrc was overwritten")
+	}
+}
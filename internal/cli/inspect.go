@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Show merged config, state and container details as one JSON document",
+	Long: `Print one JSON document joining everything alca knows about the project:
+
+  project_dir  the project root
+  config       the merged configuration (extends/includes applied)
+  caps         the effective capability set (presets and defaults resolved)
+  state        the .alca/state.json record (null before the first 'alca up')
+  container    the runtime's inspect output (null when no container exists)
+
+Use --format to render a Go template against the document instead. Keys are
+the same as in the JSON output, and the json function prints a value as JSON:
+
+  alca inspect --format '{{.container.State.Status}}'
+  alca inspect --format '{{json .config.network}}'`,
+	Args: cobra.NoArgs,
+	RunE: runInspect,
+}
+
+func init() {
+	inspectCmd.Flags().StringP("format", "f", "", "Render a Go template instead of JSON")
+}
+
+// inspectDocument is the combined view printed by alca inspect.
+type inspectDocument struct {
+	ProjectDir string           `json:"project_dir"`
+	Config     config.RawConfig `json:"config"`
+	Caps       config.Caps      `json:"caps"`
+	State      *state.State     `json:"state"`
+	Container  json.RawMessage  `json:"container"`
+}
+
+// runInspect prints the combined project view.
+func runInspect(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	format, _ := cmd.Flags().GetString("format")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIReadDeps()
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, deps.Env, deps.RuntimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+
+	st, err := sandbox.LoadStateOptional(deps.Env, cwd)
+	if err != nil {
+		return err
+	}
+
+	container, err := inspectProjectContainer(ctx, deps.RuntimeEnv, rt, cwd, st)
+	if err != nil {
+		return err
+	}
+
+	doc := inspectDocument{
+		ProjectDir: cwd,
+		Config:     cfg.ToRaw(),
+		Caps:       cfg.Caps,
+		State:      st,
+		Container:  container,
+	}
+	return writeInspect(util.NewRedactingWriter(os.Stdout), doc, format)
+}
+
+// inspectProjectContainer returns the inspect output of the project's
+// container, or nil if there is no state or no container.
+func inspectProjectContainer(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cwd string, st *state.State) (json.RawMessage, error) {
+	if st == nil {
+		return nil, nil
+	}
+
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State == runtime.StateNotFound {
+		return nil, nil
+	}
+
+	return rt.InspectContainer(ctx, runtimeEnv, status.Name)
+}
+
+// writeInspect prints doc as indented JSON, or rendered through the Go
+// template format, which sees the document with its JSON keys.
+func writeInspect(w io.Writer, doc inspectDocument, format string) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode inspect output: %w", err)
+	}
+
+	if format == "" {
+		_, err := fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	var generic map[string]any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("failed to encode inspect output: %w", err)
+	}
+
+	tmpl, err := template.New("format").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid --format template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, generic); err != nil {
+		return fmt.Errorf("failed to render --format template: %w", err)
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
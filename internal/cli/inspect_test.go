@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+func testInspectDocument() inspectDocument {
+	return inspectDocument{
+		ProjectDir: "/p",
+		Config:     config.RawConfig{Image: "alpine", Workdir: "/workspace"},
+		Caps:       config.Caps{Drop: []string{"ALL"}, Add: []string{"CHOWN"}},
+		State:      &state.State{ProjectID: "abc", ContainerName: "alca-abc"},
+		Container:  json.RawMessage(`{"Id": "123", "State": {"Status": "running"}}`),
+	}
+}
+
+func TestWriteInspect_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeInspect(&buf, testInspectDocument(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	for _, key := range []string{"project_dir", "config", "caps", "state", "container"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("output missing %q: %s", key, buf.String())
+		}
+	}
+}
+
+func TestWriteInspect_NullSections(t *testing.T) {
+	doc := testInspectDocument()
+	doc.State = nil
+	doc.Container = nil
+
+	var buf bytes.Buffer
+	if err := writeInspect(&buf, doc, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"state": null`) || !strings.Contains(buf.String(), `"container": null`) {
+		t.Errorf("missing sections should be null:\n%s", buf.String())
+	}
+}
+
+func TestWriteInspect_Format(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"nested container field", "{{.container.State.Status}}", "running\n"},
+		{"config uses toml names", "{{.config.image}} {{.state.container_name}}", "alpine alca-abc\n"},
+		{"json function", "{{json .container.State}}", `{"Status":"running"}` + "\n"},
+		{"effective caps", "{{json .caps}}", `{"add":["CHOWN"],"drop":["ALL"]}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeInspect(&buf, testInspectDocument(), tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeInspect() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteInspect_InvalidFormat(t *testing.T) {
+	err := writeInspect(&bytes.Buffer{}, testInspectDocument(), "{{.config")
+	if err == nil || !strings.Contains(err.Error(), "invalid --format") {
+		t.Errorf("writeInspect() error = %v, want invalid --format", err)
+	}
+}
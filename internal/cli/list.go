@@ -3,20 +3,28 @@ package cli
 import (
 	"fmt"
 	"os"
+	"sort"
 	"text/tabwriter"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
+	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
 
 var listCmd = &cobra.Command{
-	Use:   "list",
-	Short: "List all Alcatraz containers",
-	Long:  `List all containers managed by Alcatraz across all projects.`,
-	RunE:  runList,
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List all Alcatraz containers",
+	Long: `List all containers managed by Alcatraz across all projects.
+
+Subprojects of a monorepo (projects whose .alca.toml inherits the config
+of a project above them) are listed together after the monorepo root,
+which the MONOREPO column names.`,
+	RunE: runList,
 }
 
 // runList displays all alca-managed containers.
@@ -28,13 +36,13 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create shared dependencies once
-	cmdRunner := util.NewCommandRunner()
+	cmdRunner := newCommandRunner()
 	env := &util.Env{Fs: afero.NewReadOnlyFs(afero.NewOsFs()), Cmd: cmdRunner}
 	runtimeEnv := runtime.NewRuntimeEnv(cmdRunner)
 
 	// Load config (optional) and select runtime
 	// Log warning if config has issues but continue
-	cfg, rt, err := loadConfigAndRuntimeOptional(ctx, env, runtimeEnv, cwd)
+	cfg, rt, err := sandbox.LoadConfigAndRuntimeOptional(ctx, env, runtimeEnv, cwd)
 	if err != nil {
 		return err
 	}
@@ -50,11 +58,13 @@ func runList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	roots := groupByMonorepo(env.Fs, containers)
+
 	// Print table
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "NAME\tSTATUS\tPROJECT ID\tPROJECT PATH\tCREATED")
+	_, _ = fmt.Fprintln(w, "NAME\tSTATUS\tPROJECT ID\tPROJECT PATH\tMONOREPO\tCREATED")
 
-	for _, c := range containers {
+	for i, c := range containers {
 		status := string(c.State)
 		projectPath := c.ProjectPath
 		if projectPath == "" {
@@ -69,10 +79,68 @@ func runList(cmd *cobra.Command, args []string) error {
 			createdAt = createdAt[:19]
 		}
 
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			c.Name, status, projectID, projectPath, createdAt)
+		monorepo := roots[i]
+		if monorepo == "" {
+			monorepo = "-"
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.Name, status, projectID, projectPath, monorepo, createdAt)
 	}
 
 	_ = w.Flush()
 	return nil
 }
+
+// groupByMonorepo sorts containers so each monorepo's root project and
+// subprojects are adjacent, root first, and returns the monorepo root of
+// each (empty for projects that are not subprojects). Groups keep the
+// position of their first container.
+func groupByMonorepo(fs afero.Fs, containers []runtime.ContainerInfo) []string {
+	roots := make([]string, len(containers))
+	groups := make([]string, len(containers))
+	for i, c := range containers {
+		if c.ProjectPath != "" {
+			roots[i] = config.MonorepoRoot(fs, c.ProjectPath)
+		}
+		groups[i] = c.ProjectPath
+		if roots[i] != "" {
+			groups[i] = roots[i]
+		}
+	}
+
+	position := make([]int, len(containers))
+	first := map[string]int{}
+	for i, group := range groups {
+		position[i] = i
+		if group == "" {
+			continue
+		}
+		if p, ok := first[group]; ok {
+			position[i] = p
+		} else {
+			first[group] = i
+		}
+	}
+
+	order := make([]int, len(containers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := order[a], order[b]
+		if position[ia] != position[ib] {
+			return position[ia] < position[ib]
+		}
+		// The root project's path sorts before its subprojects'
+		return containers[ia].ProjectPath < containers[ib].ProjectPath
+	})
+
+	sorted := make([]runtime.ContainerInfo, len(containers))
+	sortedRoots := make([]string, len(containers))
+	for i, idx := range order {
+		sorted[i], sortedRoots[i] = containers[idx], roots[idx]
+	}
+	copy(containers, sorted)
+	return sortedRoots
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+func TestGroupByMonorepo(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for path, content := range map[string]string{
+		"/repo/.alca.toml":     "image = \"a\"\n",
+		"/repo/api/.alca.toml": "inherit = true\n",
+		"/repo/web/.alca.toml": "inherit = true\n",
+		"/solo/.alca.toml":     "image = \"a\"\n",
+	} {
+		if err := afero.WriteFile(fs, path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	containers := []runtime.ContainerInfo{
+		{Name: "web", ProjectPath: "/repo/web"},
+		{Name: "solo", ProjectPath: "/solo"},
+		{Name: "orphan"},
+		{Name: "root", ProjectPath: "/repo"},
+		{Name: "api", ProjectPath: "/repo/api"},
+	}
+
+	roots := groupByMonorepo(fs, containers)
+
+	var names []string
+	for _, c := range containers {
+		names = append(names, c.Name)
+	}
+	if want := []string{"root", "api", "web", "solo", "orphan"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("order = %v, want %v", names, want)
+	}
+	if want := []string{"", "/repo", "/repo", "", ""}; !reflect.DeepEqual(roots, want) {
+		t.Errorf("roots = %q, want %q", roots, want)
+	}
+}
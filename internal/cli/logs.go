@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// defaultLogsOutput is the suggested --output path, under the state
+// directory that alca init already keeps out of version control.
+const defaultLogsOutput = ".alca/logs/container.log"
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show container logs, optionally saving them to rotating files",
+	Long: `Print the container's logs (the output of its main process).
+
+With --output, the logs are also appended to a file, which is rotated when it
+reaches --rotate (container.log becomes container.log.1, and so on, keeping
+--keep rotated files). Relative paths are relative to the project directory;
+` + defaultLogsOutput + ` is the conventional location. With --follow this lets
+agents and CI collect sandbox logs without keeping a terminal attached:
+
+  alca logs --follow --quiet --output ` + defaultLogsOutput + ` --rotate 10MB &`,
+	Args: cobra.NoArgs,
+	RunE: runLogs,
+}
+
+func init() {
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep streaming new output until the container stops")
+	logsCmd.Flags().String("since", "", "Only show logs since a duration ago (e.g. 10m) or a timestamp")
+	logsCmd.Flags().String("tail", "all", "Number of lines to show from the end of the logs")
+	logsCmd.Flags().BoolP("timestamps", "t", false, "Prefix each line with its timestamp")
+	logsCmd.Flags().StringP("output", "o", "", "Also append the logs to this file (e.g. "+defaultLogsOutput+")")
+	logsCmd.Flags().String("rotate", "10MB", "Rotate the --output file at this size (0 disables rotation)")
+	logsCmd.Flags().Int("keep", 5, "Number of rotated --output files to keep")
+	logsCmd.Flags().BoolP("quiet", "q", false, "With --output, do not print the logs to stdout")
+}
+
+// logsOutput is where alca logs writes besides stdout.
+type logsOutput struct {
+	path    string
+	maxSize int64
+	keep    int
+	quiet   bool
+}
+
+// runLogs streams the project container's logs.
+func runLogs(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	var opts runtime.LogsOptions
+	opts.Follow, _ = cmd.Flags().GetBool("follow")
+	opts.Since, _ = cmd.Flags().GetString("since")
+	opts.Tail, _ = cmd.Flags().GetString("tail")
+	opts.Timestamps, _ = cmd.Flags().GetBool("timestamps")
+
+	var output logsOutput
+	output.path, _ = cmd.Flags().GetString("output")
+	output.keep, _ = cmd.Flags().GetInt("keep")
+	output.quiet, _ = cmd.Flags().GetBool("quiet")
+	rotate, _ := cmd.Flags().GetString("rotate")
+	maxSize, err := util.ParseSize(rotate)
+	if err != nil {
+		return fmt.Errorf("--rotate: %w", err)
+	}
+	output.maxSize = maxSize
+	if output.quiet && output.path == "" {
+		return errors.New("--quiet requires --output")
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	_, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	return streamLogs(ctx, host.Fs, runtimeEnv, rt, cwd, st, opts, output, os.Stdout)
+}
+
+// streamLogs writes the container's logs to stdout and, if set, to the
+// rotating output file on hostFs, since the command otherwise only reads.
+func streamLogs(ctx context.Context, hostFs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cwd string, st *state.State, opts runtime.LogsOptions, output logsOutput, stdout io.Writer) error {
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State == runtime.StateNotFound {
+		return errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	w := stdout
+	if output.path != "" {
+		path := output.path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		file, err := util.NewRotatingWriter(hostFs, path, output.maxSize, output.keep)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		if output.quiet {
+			w = file
+		} else {
+			w = io.MultiWriter(stdout, file)
+		}
+	}
+
+	err = rt.Logs(ctx, runtimeEnv, status.Name, opts, w)
+	if err != nil && ctx.Err() != nil {
+		// Interrupted while following: not an error
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLogs_OutputRotates(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	c := fake.Container(st.ContainerName)
+	c.Logs = strings.Repeat("0123456789\n", 3)
+	fake.AddContainer(*c)
+
+	for name, value := range map[string]string{"output": defaultLogsOutput, "rotate": "40", "keep": "1"} {
+		if err := logsCmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("set --%s: %v", name, err)
+		}
+		t.Cleanup(func() {
+			f := logsCmd.Flags().Lookup(name)
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+	}
+
+	// Three runs of 33 bytes: each write past 40 bytes rotates, keeping one
+	for range 3 {
+		if err := runFakeCommand(t, logsCmd, runLogs, "quiet"); err != nil {
+			t.Fatalf("logs: %v", err)
+		}
+	}
+
+	fs := afero.NewOsFs()
+	path := filepath.Join(dir, defaultLogsOutput)
+	for _, name := range []string{path, path + ".1"} {
+		data, err := afero.ReadFile(fs, name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(data) != c.Logs {
+			t.Errorf("%s = %q, want %q", name, data, c.Logs)
+		}
+	}
+	if _, err := fs.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Errorf("%s.2 should not be kept: %v", path, err)
+	}
+}
+
+func TestLogs_NotRunning(t *testing.T) {
+	setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, logsCmd, runLogs); err == nil {
+		t.Fatal("logs without a container should fail")
+	}
+}
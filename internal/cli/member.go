@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// run and enter can target another member project of the enclosing
+// workspace, each with its own container. A member is named by its directory
+// relative to the workspace root ("services/db"), or by the last path element
+// ("db") when that is unambiguous.
+
+// memberProjectDir returns the project directory that run/enter target:
+// the current project when member is empty, otherwise the named member.
+func memberProjectDir(member string) (string, error) {
+	if member == "" {
+		return findProjectDir()
+	}
+	root, members, err := loadWorkspace()
+	if err != nil {
+		return "", fmt.Errorf("--member needs a workspace: %w", err)
+	}
+	return findWorkspaceMember(root, members, member)
+}
+
+// findWorkspaceMember returns the member directory named by member.
+func findWorkspaceMember(root string, members []string, member string) (string, error) {
+	var names, byBase []string
+	for _, dir := range members {
+		name := workspaceMemberName(root, dir)
+		if name == filepath.Clean(member) {
+			return dir, nil
+		}
+		if filepath.Base(dir) == member {
+			byBase = append(byBase, dir)
+		}
+		names = append(names, name)
+	}
+
+	switch len(byBase) {
+	case 1:
+		return byBase[0], nil
+	case 0:
+		return "", fmt.Errorf("unknown workspace member %q, available: %s", member, strings.Join(names, ", "))
+	default:
+		var matches []string
+		for _, dir := range byBase {
+			matches = append(matches, workspaceMemberName(root, dir))
+		}
+		return "", fmt.Errorf("workspace member %q is ambiguous, use one of: %s", member, strings.Join(matches, ", "))
+	}
+}
+
+// workspaceMemberName returns a member's directory relative to the workspace root.
+func workspaceMemberName(root, dir string) string {
+	name, err := filepath.Rel(root, dir)
+	if err != nil {
+		return dir
+	}
+	return name
+}
+
+// runInAllMembers runs args in every workspace member, one at a time and
+// in member order so that e.g. migrations finish before seeding starts.
+// Returns the aggregated exit code: 0 if every member succeeded, else the
+// highest exit code (1 for members whose command could not be started).
+func runInAllMembers(ctx context.Context, args []string, user string, out io.Writer) (int, error) {
+	root, members, err := loadWorkspace()
+	if err != nil {
+		return 0, fmt.Errorf("--all needs a workspace: %w", err)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate alca executable: %w", err)
+	}
+
+	results := runWorkspaceMembers(ctx, members, memberRunArgs(args, user), 1, execWorkspaceMember(newCommandRunner(), self))
+	_ = writeWorkspaceResults(out, root, results)
+	return aggregateExitCode(results), nil
+}
+
+// memberRunArgs builds the 'alca run' arguments executed in each member.
+func memberRunArgs(args []string, user string) []string {
+	runArgs := []string{"run"}
+	if user != "" {
+		runArgs = append(runArgs, "--user", user)
+	}
+	return append(append(runArgs, "--"), args...)
+}
+
+// aggregateExitCode returns the highest exit code among failed results,
+// or 0 if all succeeded.
+func aggregateExitCode(results []workspaceResult) int {
+	code := 0
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		c := 1
+		var exitErr *exec.ExitError
+		if errors.As(r.Err, &exitErr) && exitErr.ExitCode() > 0 {
+			c = exitErr.ExitCode()
+		}
+		code = max(code, c)
+	}
+	return code
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestFindWorkspaceMember(t *testing.T) {
+	fs := newWorkspaceFs(t)
+	root, members, err := loadWorkspaceFrom(fs, "/ws/api")
+	if err != nil {
+		t.Fatalf("loadWorkspaceFrom() error: %v", err)
+	}
+
+	tests := []struct {
+		member  string
+		want    string
+		wantErr string
+	}{
+		{"api", "/ws/api", ""},
+		{"services/auth", "/ws/services/auth", ""},
+		{"billing", "/ws/services/billing", ""},
+		{"db", "", "unknown workspace member"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.member, func(t *testing.T) {
+			got, err := findWorkspaceMember(root, members, tt.member)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindWorkspaceMember_Ambiguous(t *testing.T) {
+	members := []string{"/ws/api/db", "/ws/worker/db"}
+	_, err := findWorkspaceMember("/ws", members, "db")
+	if err == nil || !strings.Contains(err.Error(), "api/db, worker/db") {
+		t.Errorf("error = %v, want ambiguity listing both members", err)
+	}
+	if got, err := findWorkspaceMember("/ws", members, "worker/db"); err != nil || got != "/ws/worker/db" {
+		t.Errorf("full path lookup = %q, %v", got, err)
+	}
+}
+
+func TestMemberRunArgs(t *testing.T) {
+	if got := strings.Join(memberRunArgs([]string{"make", "migrate"}, ""), " "); got != "run -- make migrate" {
+		t.Errorf("got %q", got)
+	}
+	if got := strings.Join(memberRunArgs([]string{"id"}, "root"), " "); got != "run --user root -- id" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestAggregateExitCode(t *testing.T) {
+	exitErr := func(code int) error {
+		_, err := util.NewCommandRunner().RunQuiet(context.Background(), "sh", "-c", "exit "+strconv.Itoa(code))
+		var e *exec.ExitError
+		if !errors.As(err, &e) {
+			t.Fatalf("expected exit error, got %v", err)
+		}
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		results []workspaceResult
+		want    int
+	}{
+		{"all succeeded", []workspaceResult{{}, {}}, 0},
+		{"highest exit code", []workspaceResult{{Err: exitErr(2)}, {}, {Err: exitErr(5)}}, 5},
+		{"start failure counts as 1", []workspaceResult{{Err: errors.New("no such file")}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregateExitCode(tt.results); got != tt.want {
+				t.Errorf("aggregateExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade .alca.toml and state.json to the current schema version",
+	Long: `Report the schema migrations that apply to this project's .alca.toml and
+.alca/state.json. Older files are already migrated in memory whenever alca
+loads them; use --write to persist the upgrade.
+
+Rewriting .alca.toml re-encodes it, so comments are not preserved.
+Files from a newer alca than this binary are rejected; upgrade alca instead.`,
+	Args:        cobra.NoArgs,
+	RunE:        runMigrate,
+	Annotations: destructive,
+}
+
+func init() {
+	migrateCmd.Flags().Bool("write", false, "Persist migrated files")
+}
+
+// runMigrate reports or applies schema migrations for the current project.
+func runMigrate(cmd *cobra.Command, args []string) error {
+	write, _ := cmd.Flags().GetBool("write")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	env := newHostEnv()
+	return migrateProject(cmd.Context(), env, cwd, write, os.Stdout)
+}
+
+// migrateProject migrates the project's config and state files, reporting
+// each applied step to out.
+func migrateProject(ctx context.Context, env *util.Env, cwd string, write bool, out io.Writer) error {
+	configPath := filepath.Join(cwd, sandbox.ConfigFilename)
+	configSteps, err := config.MigrateConfigFile(env.Fs, configPath, write, sandbox.ConfigLoadOptions(ctx, env)...)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New(sandbox.ErrMsgConfigNotFound)
+		}
+		return err
+	}
+
+	stateSteps, err := state.Migrate(env, cwd, write)
+	if err != nil {
+		return err
+	}
+
+	reportMigrations(out, sandbox.ConfigFilename, configSteps)
+	reportMigrations(out, state.StateFilePath("."), stateSteps)
+
+	switch {
+	case len(configSteps)+len(stateSteps) == 0:
+		_, _ = fmt.Fprintln(out, "Already at the current schema version.")
+	case !write:
+		_, _ = fmt.Fprintln(out, "Run 'alca migrate --write' to persist these migrations.")
+	}
+	return nil
+}
+
+// reportMigrations prints one line per applied migration step for name.
+func reportMigrations(out io.Writer, name string, steps []config.Migration) {
+	for _, m := range steps {
+		_, _ = fmt.Fprintf(out, "%s: schema %d -> %d: %s\n", name, m.From, m.From+1, m.Description)
+	}
+}
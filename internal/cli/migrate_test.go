@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestMigrateProject(t *testing.T) {
+	t.Run("up to date", func(t *testing.T) {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		_ = afero.WriteFile(env.Fs, "/p/.alca.toml", []byte("image = \"alpine\"\n"), 0644)
+
+		var out bytes.Buffer
+		if err := migrateProject(context.Background(), env, "/p", true, &out); err != nil {
+			t.Fatalf("migrateProject() error = %v", err)
+		}
+		if !strings.Contains(out.String(), "Already at the current schema version.") {
+			t.Errorf("unexpected output: %q", out.String())
+		}
+	})
+
+	t.Run("missing config", func(t *testing.T) {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		err := migrateProject(context.Background(), env, "/p", false, &bytes.Buffer{})
+		if err == nil || err.Error() != sandbox.ErrMsgConfigNotFound {
+			t.Errorf("migrateProject() error = %v, want %q", err, sandbox.ErrMsgConfigNotFound)
+		}
+	})
+
+	t.Run("newer state", func(t *testing.T) {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		_ = afero.WriteFile(env.Fs, "/p/.alca.toml", []byte("image = \"alpine\"\n"), 0644)
+		_ = afero.WriteFile(env.Fs, "/p/.alca/state.json", []byte(`{"schema_version": 7}`), 0644)
+
+		err := migrateProject(context.Background(), env, "/p", false, &bytes.Buffer{})
+		if !errors.Is(err, config.ErrSchemaTooNew) {
+			t.Errorf("migrateProject() error = %v, want ErrSchemaTooNew", err)
+		}
+	})
+}
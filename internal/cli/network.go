@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/network"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var networkCmd = &cobra.Command{
+	Use:   "network",
+	Short: "Inspect container network activity",
+}
+
+var networkAllowCmd = &cobra.Command{
+	Use:   "allow <target>",
+	Short: "Temporarily allow the container to reach a LAN address",
+	Long: `Grant the container temporary access to a LAN address, using the
+lan-access rule syntax (e.g. 192.168.1.50:5432 or udp://10.0.0.2:53).
+
+The grant is recorded with its expiry time in .alca/state.json and written to
+the container's firewall rules immediately. Expiry is enforced by the kernel
+clock, so access ends on time even if alca is not run again; expired grants
+are removed from state on the next 'alca up' or 'alca network allow'.
+
+Permanent or per-project temporary rules belong in .alca.toml instead:
+  lan-access = ["192.168.1.50:5432 ttl=2h"]`,
+	Example: `  alca network allow 192.168.1.50:5432 --ttl 2h`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runNetworkAllow,
+}
+
+var networkLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Follow outbound connections made by the container",
+	Long: `Follow the outbound connections logged for this project's container.
+
+Requires network.log_connections = true in .alca.toml (applied on the next
+'alca up'). Every new connection is logged by an nftables rule to the kernel
+log, which is read from /dev/kmsg — with sudo on Linux, or through the network
+helper container on macOS (over 'podman machine ssh' on a Podman machine).
+Earlier entries still in the kernel buffer are printed first, then new
+connections as they happen. Press Ctrl+C to stop.`,
+	Args: cobra.NoArgs,
+	RunE: runNetworkLog,
+}
+
+func init() {
+	networkLogCmd.Flags().Bool("all", false, "Show connections from every Alcatraz container, not just this project's")
+	networkCmd.AddCommand(networkLogCmd)
+
+	networkAllowCmd.Flags().Duration("ttl", 0, "How long the grant lasts (e.g. 30m, 2h)")
+	_ = networkAllowCmd.MarkFlagRequired("ttl")
+	networkCmd.AddCommand(networkAllowCmd)
+}
+
+func runNetworkAllow(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	out := os.Stdout
+
+	ttl, _ := cmd.Flags().GetDuration("ttl")
+	if ttl <= 0 {
+		return fmt.Errorf("--ttl must be positive")
+	}
+	rule, err := network.ParseLANAccessRule(args[0])
+	if err != nil {
+		return err
+	}
+	if rule.AllLAN || rule.TTL > 0 {
+		return fmt.Errorf("target must be a single address rule without options, e.g. 192.168.1.50:5432")
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewDeps(host)
+	tfs, env, runtimeEnv := deps.Tfs, deps.Env, deps.RuntimeEnv
+
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+	if st.Config == nil {
+		return fmt.Errorf("container has not been started, run 'alca up' first")
+	}
+	if slices.Contains(cfg.Network.LANAccess, network.LanAccessWildcard) {
+		return fmt.Errorf("lan-access = [\"*\"] already allows all LAN access")
+	}
+
+	expires := time.Now().Add(ttl)
+	st.SetGrant(rule.Key(), expires)
+
+	platform := runtime.DetectPlatform(ctx, runtimeEnv)
+	networkEnv := network.NewNetworkEnv(tfs, deps.CmdRunner, cwd, st.ProjectID, platform)
+	fw, fwType := network.New(ctx, networkEnv)
+	nh := network.NewNetworkHelperForSystem(platform)
+
+	expandedNet, err := sandbox.SetupFirewall(ctx, host.Fs, fw, fwType, networkEnv, env, tfs, runtimeEnv, cfg.Network, rt, st, nh, out, promptModeFromCmd(cmd))
+	if err != nil {
+		return err
+	}
+	if err := sandbox.SaveNetworkState(ctx, env, tfs, cwd, expandedNet, st, out); err != nil {
+		return err
+	}
+
+	util.ProgressDone(out, "Allowed %s until %s\n", rule.Key(), expires.Format(time.DateTime))
+	return nil
+}
+
+func runNetworkLog(cmd *cobra.Command, _ []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	all, _ := cmd.Flags().GetBool("all")
+	deps := newCLIReadDeps()
+
+	prefix := ""
+	if !all {
+		cwd, err := findProjectDir()
+		if err != nil {
+			return err
+		}
+		cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, deps.Env, deps.RuntimeEnv, cwd)
+		if err != nil {
+			return err
+		}
+		st, err := sandbox.LoadRequiredState(deps.Env, cwd)
+		if err != nil {
+			return err
+		}
+		if !cfg.Network.LogConnections {
+			util.ProgressStep(os.Stderr, "Warning: network.log_connections is not enabled; set it in .alca.toml and run 'alca up'\n")
+		}
+		status, err := rt.Status(ctx, deps.RuntimeEnv, cwd, st)
+		if err != nil || status.State == runtime.StateNotFound {
+			return fmt.Errorf("container not found, run 'alca up' first")
+		}
+		prefix = network.ConnectionLogPrefix(status.ID)
+	}
+
+	platform := runtime.DetectPlatform(ctx, deps.RuntimeEnv)
+	args, err := network.ConnectionLogCommand(platform)
+	if err != nil {
+		return err
+	}
+
+	// The log is streamed until interrupted, so nothing keeps a copy of it
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := deps.Env.Cmd.RunTo(ctx, pw, args[0], args[1:]...)
+		_ = pw.Close()
+		done <- err
+	}()
+	printConnectionLog(pr, os.Stdout, os.Stderr, prefix)
+	_ = pr.Close()
+
+	if err := <-done; err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read kernel log: %w", err)
+	}
+	return nil
+}
+
+// printConnectionLog pretty-prints the connection entries in a kernel log
+// stream to w, skipping unrelated kernel messages. Lines that are not
+// kernel messages, such as errors from sudo, go to errW. Empty prefix
+// matches every container.
+func printConnectionLog(r io.Reader, w, errW io.Writer, prefix string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if entry, ok := network.ParseConnectionLogLine(line, prefix); ok {
+			_, _ = fmt.Fprintln(w, entry)
+		} else if !isKmsgLine(line) {
+			_, _ = fmt.Fprintln(errW, line)
+		}
+	}
+}
+
+// isKmsgLine reports whether line is a /dev/kmsg record
+// ("<prio>,<seq>,<usec>,<flags>;<message>") or one of its indented
+// continuation lines.
+func isKmsgLine(line string) bool {
+	if strings.HasPrefix(line, " ") {
+		return true
+	}
+	header, _, ok := strings.Cut(line, ";")
+	return ok && strings.Count(header, ",") >= 3
+}
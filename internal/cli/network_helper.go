@@ -2,13 +2,17 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bolasblack/alcatraz/internal/network"
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
 
@@ -18,7 +22,8 @@ var networkHelperCmd = &cobra.Command{
 	Long: `Manage the network helper for container LAN access.
 
 On macOS: Installs a helper container that runs nftables inside the
-container runtime VM for network isolation.
+container runtime VM for network isolation. On a Podman machine, installs
+a systemd unit in the machine instead.
 
 On Linux: Configures nftables to include alcatraz rule files from
 /etc/nftables.d/alcatraz/ for persistent firewall rules.`,
@@ -31,7 +36,9 @@ var networkHelperInstallCmd = &cobra.Command{
 
 On macOS:
 1. Create ~/.alcatraz/files/alcatraz_nft/ directory
-2. Start the alcatraz-network-helper container
+2. Start the alcatraz-network-helper container (on a Podman machine:
+   install and start the alcatraz-nft.service unit, which needs a
+   rootful machine)
 
 On Linux:
 1. Create /etc/nftables.d/alcatraz/ directory
@@ -48,7 +55,8 @@ var networkHelperUninstallCmd = &cobra.Command{
 	Long: `Uninstall the network helper and clean up all rules.
 
 On macOS:
-1. Stop and remove the alcatraz-network-helper container
+1. Stop and remove the alcatraz-network-helper container (on a Podman
+   machine: disable and remove the alcatraz-nft.service unit)
 
 On Linux:
 1. Remove all rule files from /etc/nftables.d/alcatraz/
@@ -63,21 +71,45 @@ Requires sudo privileges on Linux.`,
 var networkHelperStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show network helper status",
-	Long:  `Display the current status of the network helper, including LaunchDaemon state and active rules.`,
-	RunE:  runNetworkHelperStatus,
+	Long: `Display the current status of the network helper, including LaunchDaemon state and active rules.
+
+Rule files are read directly where they are readable. On Linux they are
+owned by root; if they cannot be read and sudo works without a password,
+they are read through sudo. Otherwise the status says so: rerun with --sudo
+to enter the password once up front.`,
+	RunE: runNetworkHelperStatus,
+}
+
+var networkHelperReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reload the firewall rules of all projects",
+	Long: `Reload the firewall rules of every project from their rule files.
+
+On Linux: all files in /etc/nftables.d/alcatraz/ are checked together with
+'nft -c', then loaded as one transaction. The reload holds the same lock as
+'alca up', so concurrent projects cannot interleave their loads. If loading
+fails, the alcatraz tables loaded before are restored.
+
+On macOS: signals the helper container to reload its rule directory (on a
+Podman machine: restarts the alcatraz-nft.service unit).
+
+Requires sudo privileges on Linux.`,
+	Args: cobra.NoArgs,
+	RunE: runNetworkHelperReload,
 }
 
 func init() {
-	networkHelperInstallCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
-	networkHelperUninstallCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
 	networkHelperCmd.AddCommand(networkHelperInstallCmd)
 	networkHelperCmd.AddCommand(networkHelperUninstallCmd)
 	networkHelperCmd.AddCommand(networkHelperStatusCmd)
+	networkHelperCmd.AddCommand(networkHelperReloadCmd)
+
+	networkHelperStatusCmd.Flags().Bool("sudo", false, "Prompt for the sudo password up front to read root-only rule files")
 }
 
 // networkHelperSetup holds the shared dependencies for network-helper subcommands.
 type networkHelperSetup struct {
-	deps       cliDeps
+	deps       sandbox.Deps
 	platform   runtime.RuntimePlatform
 	nh         network.NetworkHelper
 	networkEnv *network.NetworkEnv
@@ -121,18 +153,21 @@ func runNetworkHelperInstall(cmd *cobra.Command, args []string) error {
 
 	// Confirmation prompt
 	fmt.Println("This will install the network helper to manage firewall rules.")
-	yes, _ := cmd.Flags().GetBool("yes")
-	if !yes && !promptConfirm("Continue?") {
+	ok, err := promptModeFromCmd(cmd).Confirm("Continue?")
+	if err != nil {
+		return err
+	}
+	if !ok {
 		return nil
 	}
 
-	progress := progressFunc(os.Stdout)
+	progress := sandbox.ProgressFunc(os.Stdout)
 	action, err := nh.InstallHelper(networkEnv, progress)
 	if err != nil {
 		return err
 	}
 
-	if err := commitIfNeeded(ctx, setup.deps.Env, setup.deps.Tfs, os.Stdout, "Writing system files"); err != nil {
+	if err := sandbox.CommitIfNeeded(ctx, setup.deps.Env, setup.deps.Tfs, os.Stdout, "Writing system files"); err != nil {
 		return err
 	}
 
@@ -166,18 +201,21 @@ func runNetworkHelperUninstall(cmd *cobra.Command, args []string) error {
 
 	// Confirmation prompt
 	fmt.Println("This will remove the network helper and all rules.")
-	yes, _ := cmd.Flags().GetBool("yes")
-	if !yes && !promptConfirm("Continue?") {
+	ok, err := promptModeFromCmd(cmd).Confirm("Continue?")
+	if err != nil {
+		return err
+	}
+	if !ok {
 		return nil
 	}
 
-	progress := progressFunc(os.Stdout)
+	progress := sandbox.ProgressFunc(os.Stdout)
 	action, err := nh.UninstallHelper(networkEnv, progress)
 	if err != nil {
 		return err
 	}
 
-	if err := commitIfNeeded(ctx, setup.deps.Env, setup.deps.Tfs, os.Stdout, "Removing system files"); err != nil {
+	if err := sandbox.CommitIfNeeded(ctx, setup.deps.Env, setup.deps.Tfs, os.Stdout, "Removing system files"); err != nil {
 		return err
 	}
 
@@ -194,6 +232,7 @@ func runNetworkHelperUninstall(cmd *cobra.Command, args []string) error {
 // runNetworkHelperStatus shows the current status.
 func runNetworkHelperStatus(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
+	useSudo, _ := cmd.Flags().GetBool("sudo")
 	deps := newCLIReadDeps()
 	platform := runtime.DetectPlatform(ctx, deps.RuntimeEnv)
 	nh := network.NewNetworkHelperForSystem(platform)
@@ -202,6 +241,13 @@ func runNetworkHelperStatus(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Prompt once before any output, so later sudo calls reuse the credentials
+	if useSudo {
+		if err := deps.CmdRunner.SudoRun(ctx, "-v"); err != nil {
+			return fmt.Errorf("sudo authentication failed: %w", err)
+		}
+	}
+
 	networkEnv := network.NewNetworkEnv(deps.Env.Fs, deps.Env.Cmd, "", "", platform)
 
 	status := nh.HelperStatus(ctx, networkEnv)
@@ -218,41 +264,94 @@ func runNetworkHelperStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Detailed status from the implementation
-	detailed := nh.DetailedStatus(networkEnv)
-	printHelperSummary(status, detailed)
-	printRuleFiles(detailed)
+	detailed := readDetailedStatus(ctx, deps.CmdRunner, nh.DetailedStatus(networkEnv), useSudo)
+	printHelperSummary(os.Stdout, status, detailed)
+	printRuleFiles(os.Stdout, detailed)
 
 	return nil
 }
 
-func printRuleFiles(status network.DetailedStatusInfo) {
-	fmt.Println("")
-	fmt.Println("Rule files:")
-	if len(status.RuleFiles) == 0 {
-		fmt.Println("  (none)")
-	} else {
-		for _, f := range status.RuleFiles {
-			fmt.Printf("  - %s\n", f.Name)
-		}
+// runNetworkHelperReload reloads every project's rule files.
+func runNetworkHelperReload(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	deps := newCLIReadDeps()
+	platform := runtime.DetectPlatform(ctx, deps.RuntimeEnv)
+	networkEnv := network.NewNetworkEnv(deps.Env.Fs, deps.Env.Cmd, "", "", platform)
+
+	fw, _ := network.New(ctx, networkEnv)
+	if fw == nil {
+		fmt.Println("No firewall available on this platform/runtime.")
+		return nil
+	}
+	if err := fw.ReloadRules(ctx); err != nil {
+		return err
+	}
+
+	util.ProgressDone(os.Stdout, "Firewall rules reloaded.\n")
+	return nil
+}
+
+// errSudoNeedsPassword means rule files are root-only and sudo would prompt.
+var errSudoNeedsPassword = errors.New("rule files are only readable by root and sudo needs a password")
+
+// readDetailedStatus retries rule files that could not be read for lack of
+// permission through sudo, if sudo will not prompt: either the caller has
+// already authenticated (useSudo) or sudo needs no password. Otherwise
+// ReadErr becomes errSudoNeedsPassword, so status can report it.
+func readDetailedStatus(ctx context.Context, cmdRunner util.CommandRunner, detailed network.DetailedStatusInfo, useSudo bool) network.DetailedStatusInfo {
+	if !errors.Is(detailed.ReadErr, fs.ErrPermission) || detailed.RuleDir == "" {
+		return detailed
+	}
+	if !useSudo && !sudoWithoutPassword(ctx, cmdRunner) {
+		detailed.ReadErr = errSudoNeedsPassword
+		return detailed
+	}
+	files, err := network.ReadRuleFilesSudo(ctx, cmdRunner, detailed.RuleDir)
+	detailed.RuleFiles, detailed.ReadErr = files, err
+	return detailed
+}
+
+// sudoWithoutPassword reports whether sudo runs without prompting, because
+// of NOPASSWD or cached credentials.
+func sudoWithoutPassword(ctx context.Context, cmdRunner util.CommandRunner) bool {
+	_, err := cmdRunner.RunQuiet(ctx, "sudo", "-n", "true")
+	return err == nil
+}
+
+func printRuleFiles(w io.Writer, status network.DetailedStatusInfo) {
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "Rule files:")
+	for _, f := range status.RuleFiles {
+		_, _ = fmt.Fprintf(w, "  - %s\n", f.Name)
+	}
+	switch {
+	case errors.Is(status.ReadErr, errSudoNeedsPassword):
+		_, _ = fmt.Fprintf(w, "  (unknown: %s; rerun with 'alca network-helper status --sudo')\n", status.ReadErr)
+	case status.ReadErr != nil:
+		_, _ = fmt.Fprintf(w, "  (unreadable: %s)\n", status.ReadErr)
+	case len(status.RuleFiles) == 0:
+		_, _ = fmt.Fprintln(w, "  (none)")
 	}
 }
 
-func printHelperSummary(helperStatus network.HelperStatus, detailedStatus network.DetailedStatusInfo) {
-	fmt.Println("")
-	fmt.Println("Helper Summary:")
+func printHelperSummary(w io.Writer, helperStatus network.HelperStatus, detailedStatus network.DetailedStatusInfo) {
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "Helper Summary:")
 
 	// (1) Installation status
 	if helperStatus.Installed {
-		fmt.Println("  Installed: Yes")
+		_, _ = fmt.Fprintln(w, "  Installed: Yes")
 	} else {
-		fmt.Println("  Installed: No")
+		_, _ = fmt.Fprintln(w, "  Installed: No")
 	}
 
-	// (2) Rules applied status
-	rulesApplied := len(detailedStatus.RuleFiles) > 0
-	if rulesApplied {
-		fmt.Printf("  Rules applied: Yes (%d rule files)\n", len(detailedStatus.RuleFiles))
-	} else {
-		fmt.Println("  Rules applied: No")
+	// (2) Rules applied status; unknown when the rule files could not be read
+	switch {
+	case len(detailedStatus.RuleFiles) > 0:
+		_, _ = fmt.Fprintf(w, "  Rules applied: Yes (%d rule files)\n", len(detailedStatus.RuleFiles))
+	case detailedStatus.ReadErr != nil:
+		_, _ = fmt.Fprintln(w, "  Rules applied: Unknown")
+	default:
+		_, _ = fmt.Fprintln(w, "  Rules applied: No")
 	}
 }
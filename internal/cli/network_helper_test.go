@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/network"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestReadDetailedStatus_SudoNeedsPassword(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectFailure("sudo -n true", errors.New("a password is required"))
+	denied := network.DetailedStatusInfo{RuleDir: "/rules", ReadErr: fs.ErrPermission}
+
+	got := readDetailedStatus(context.Background(), cmd, denied, false)
+	if !errors.Is(got.ReadErr, errSudoNeedsPassword) {
+		t.Fatalf("ReadErr = %v, want errSudoNeedsPassword", got.ReadErr)
+	}
+
+	var out bytes.Buffer
+	printHelperSummary(&out, network.HelperStatus{Installed: true}, got)
+	printRuleFiles(&out, got)
+	if s := out.String(); !strings.Contains(s, "Rules applied: Unknown") || !strings.Contains(s, "--sudo") || strings.Contains(s, "(none)") {
+		t.Errorf("status output = %q, want the sudo hint instead of (none)", s)
+	}
+}
+
+func TestReadDetailedStatus_ReadsThroughSudo(t *testing.T) {
+	denied := network.DetailedStatusInfo{RuleDir: "/rules", ReadErr: fs.ErrPermission}
+	for _, useSudo := range []bool{false, true} {
+		cmd := util.NewMockCommandRunner()
+		cmd.ExpectSuccess("sudo -n true", nil)
+		cmd.ExpectSuccess("sudo find /rules -maxdepth 1 -type f -name *.nft", []byte("/rules/a.nft\n"))
+		cmd.ExpectSuccess("sudo cat /rules/a.nft", []byte("table a"))
+
+		got := readDetailedStatus(context.Background(), cmd, denied, useSudo)
+		if got.ReadErr != nil || len(got.RuleFiles) != 1 || got.RuleFiles[0].Name != "a.nft" {
+			t.Errorf("useSudo=%v: readDetailedStatus() = %+v", useSudo, got)
+		}
+	}
+}
+
+func TestReadDetailedStatus_ReadableSkipsSudo(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	info := network.DetailedStatusInfo{RuleDir: "/rules", RuleFiles: []network.RuleFileInfo{{Name: "a.nft"}}}
+	got := readDetailedStatus(context.Background(), cmd, info, false)
+	if len(got.RuleFiles) != 1 || got.ReadErr != nil {
+		t.Errorf("readDetailedStatus() = %+v", got)
+	}
+	if calls := cmd.Calls; len(calls) != 0 {
+		t.Errorf("readable rule files should not run sudo, ran %v", calls)
+	}
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPrintConnectionLog(t *testing.T) {
+	kmsg := strings.Join([]string{
+		"6,1,1000000,-;alca[alca-aaa] IN=docker0 OUT=eth0 SRC=172.17.0.2 DST=1.1.1.1 PROTO=UDP SPT=40000 DPT=53",
+		"6,2,2000000,-;eth0: link becomes ready",
+		" SUBSYSTEM=net",
+		"sudo: a password is required",
+		"6,3,3000000,-;alca[alca-bbb] IN=docker0 OUT=eth0 SRC=172.17.0.3 DST=8.8.8.8 PROTO=TCP SPT=40001 DPT=443",
+	}, "\n")
+
+	var buf, errBuf bytes.Buffer
+	printConnectionLog(strings.NewReader(kmsg), &buf, &errBuf, "alca[alca-aaa] ")
+	want := "[    1.000000] alca-aaa UDP  172.17.0.2:40000 → 1.1.1.1:53\n"
+	if buf.String() != want {
+		t.Errorf("printConnectionLog() = %q, want %q", buf.String(), want)
+	}
+	if errBuf.String() != "sudo: a password is required\n" {
+		t.Errorf("printConnectionLog() errors = %q, want the sudo error only", errBuf.String())
+	}
+
+	buf.Reset()
+	printConnectionLog(strings.NewReader(kmsg), &buf, io.Discard, "")
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("printConnectionLog() without prefix printed %d entries, want 2:\n%s", lines, buf.String())
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Freeze the sandbox container",
+	Long: `Freeze every process in the sandbox container (docker/podman pause) and
+pause its file sync sessions. The container keeps its memory, so nothing is
+lost: 'alca resume' continues the processes where they stopped. While
+paused the container uses no CPU, and 'alca enter' and 'alca run' refuse to
+start.`,
+	Args: cobra.NoArgs,
+	RunE: runPause,
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Continue a paused sandbox container",
+	Long: `Thaw a container frozen by 'alca pause' and resume its file sync sessions.
+'alca up' resumes a paused container too.`,
+	Args: cobra.NoArgs,
+	RunE: runResume,
+}
+
+func runPause(cmd *cobra.Command, args []string) error {
+	return runSetContainerPaused(cmd, pauseProject)
+}
+
+func runResume(cmd *cobra.Command, args []string) error {
+	return runSetContainerPaused(cmd, sandbox.ResumeProject)
+}
+
+// runSetContainerPaused loads the project in the current directory and
+// pauses or resumes its container with action.
+func runSetContainerPaused(cmd *cobra.Command, action func(context.Context, afero.Fs, *runtime.RuntimeEnv, runtime.Runtime, *state.State, string, io.Writer) error) error {
+	ctx := cmd.Context()
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewReadDeps(host)
+	_, rt, err := sandbox.LoadConfigAndRuntime(ctx, deps.Env, deps.RuntimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(deps.Env, cwd)
+	if err != nil {
+		return err
+	}
+	return action(ctx, host.Fs, deps.RuntimeEnv, rt, st, cwd, os.Stdout)
+}
+
+// pauseProject freezes the running container of the project. An already
+// paused container is reported, not rejected.
+func pauseProject(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, cwd string, out io.Writer) error {
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	switch status.State {
+	case runtime.StatePaused:
+		util.ProgressStep(out, "Container %s is already paused\n", status.Name)
+		return nil
+	case runtime.StateRunning:
+	default:
+		return errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	if err := rt.Pause(ctx, runtimeEnv, cwd, st); err != nil {
+		return fmt.Errorf("failed to pause container: %w", err)
+	}
+	sandbox.EmitEvent(fs, cwd, st, lifecycle.Event{Type: lifecycle.ContainerPaused, Container: status.Name})
+	util.ProgressDone(out, "Paused container %s; 'alca resume' continues it\n", status.Name)
+	return nil
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+func TestFakeRuntime_PauseResume(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+
+	if err := runFakeCommand(t, pauseCmd, runPause); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if c := fake.Container(st.ContainerName); c.State != runtime.StatePaused {
+		t.Fatalf("container state after pause = %q", c.State)
+	}
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{}); err == nil || err.Error() != sandbox.ErrMsgPaused {
+		t.Errorf("run while paused: err = %v, want %q", err, sandbox.ErrMsgPaused)
+	}
+	// Pausing twice is not an error
+	if err := runFakeCommand(t, pauseCmd, runPause); err != nil {
+		t.Errorf("second pause: %v", err)
+	}
+
+	if err := runFakeCommand(t, resumeCmd, runResume); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{}); err != nil {
+		t.Errorf("run after resume: %v", err)
+	}
+
+	// up thaws a paused container instead of recreating it
+	before := fake.Container(st.ContainerName)
+	if err := runFakeCommand(t, pauseCmd, runPause); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up while paused: %v", err)
+	}
+	if c := fake.Container(st.ContainerName); c.State != runtime.StateRunning || c.ID != before.ID {
+		t.Errorf("container after up = %+v, want the same container running", c)
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// discoverPlugins finds alca-<name> executables on the given PATH.
+// Earlier PATH entries win, matching shell lookup.
+func discoverPlugins(fs afero.Fs, pathEnv string) map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			continue
+		}
+		entries, err := afero.ReadDir(fs, dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, sandbox.PluginPrefix) || entry.IsDir() || entry.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			pluginName := strings.TrimPrefix(name, sandbox.PluginPrefix)
+			if pluginName == "" {
+				continue
+			}
+			if _, exists := plugins[pluginName]; !exists {
+				plugins[pluginName] = filepath.Join(dir, name)
+			}
+		}
+	}
+	return plugins
+}
+
+// registerPlugins adds a subcommand for each plugin that does not shadow a built-in command.
+func registerPlugins(root *cobra.Command, plugins map[string]string) {
+	builtin := make(map[string]bool)
+	for _, c := range root.Commands() {
+		builtin[c.Name()] = true
+		for _, alias := range c.Aliases {
+			builtin[alias] = true
+		}
+	}
+
+	for name, path := range plugins {
+		if builtin[name] {
+			continue
+		}
+		root.AddCommand(&cobra.Command{
+			Use:                name,
+			Short:              fmt.Sprintf("Plugin (%s)", path),
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runPluginCommand(cmd.Context(), path, args)
+			},
+		})
+	}
+}
+
+// runPluginCommand runs a plugin as a subcommand, passing through its exit code.
+func runPluginCommand(ctx context.Context, path string, args []string) error {
+	pc := loadPluginContext(ctx)
+	err := sandbox.ExecPlugin(ctx, newCommandRunner(), path, args, "", pc.Environ())
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
+}
+
+// loadPluginContext gathers best-effort project context for a plugin.
+// Plugins may run outside a project, so missing config or state is not an error.
+func loadPluginContext(ctx context.Context) sandbox.PluginContext {
+	cwd, err := findProjectDir()
+	if err != nil {
+		return sandbox.PluginContext{}
+	}
+	env := util.NewReadonlyOsEnv()
+
+	pc := sandbox.PluginContext{ProjectDir: cwd}
+	if cfg, _, err := sandbox.LoadConfigFromCwd(ctx, env, cwd); err == nil {
+		pc.Config = cfg
+	}
+	if st, err := state.Load(env, cwd); err == nil {
+		pc.State = st
+	}
+	return pc
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func TestDiscoverPlugins(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/usr/local/bin/alca-notify", []byte("#!/bin/sh"), 0755)
+	_ = afero.WriteFile(fs, "/usr/bin/alca-notify", []byte("#!/bin/sh"), 0755)
+	_ = afero.WriteFile(fs, "/usr/bin/alca-tunnel", []byte("#!/bin/sh"), 0755)
+	_ = afero.WriteFile(fs, "/usr/bin/alca-readme", []byte("not executable"), 0644)
+	_ = afero.WriteFile(fs, "/usr/bin/alca-", []byte("#!/bin/sh"), 0755)
+	_ = afero.WriteFile(fs, "/usr/bin/other", []byte("#!/bin/sh"), 0755)
+	_ = fs.MkdirAll("/usr/bin/alca-dir", 0755)
+
+	got := discoverPlugins(fs, "/usr/local/bin:/missing:/usr/bin")
+
+	want := map[string]string{
+		"notify": "/usr/local/bin/alca-notify",
+		"tunnel": "/usr/bin/alca-tunnel",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for name, path := range want {
+		if got[name] != path {
+			t.Errorf("plugin %q = %q, want %q", name, got[name], path)
+		}
+	}
+}
+
+func TestRegisterPlugins_SkipsBuiltins(t *testing.T) {
+	root := &cobra.Command{Use: "alca"}
+	root.AddCommand(&cobra.Command{Use: "up"})
+	root.AddCommand(&cobra.Command{Use: "list", Aliases: []string{"ls"}})
+
+	registerPlugins(root, map[string]string{
+		"up":     "/bin/alca-up",
+		"ls":     "/bin/alca-ls",
+		"notify": "/bin/alca-notify",
+	})
+
+	var names []string
+	for _, c := range root.Commands() {
+		names = append(names, c.Name())
+	}
+	if strings.Join(names, ",") != "list,notify,up" {
+		t.Errorf("commands = %v, want [list notify up]", names)
+	}
+}
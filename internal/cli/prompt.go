@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+// Global flag names for confirmation handling.
+const (
+	flagYes            = "yes"
+	flagNonInteractive = "non-interactive"
+)
+
+// promptModeFromCmd resolves the prompt mode from the global --yes and
+// --non-interactive flags and the ALCA_ASSUME_YES environment variable.
+func promptModeFromCmd(cmd *cobra.Command) sandbox.PromptMode {
+	yes, _ := cmd.Flags().GetBool(flagYes)
+	nonInteractive, _ := cmd.Flags().GetBool(flagNonInteractive)
+	envYes, _ := strconv.ParseBool(os.Getenv(sandbox.EnvAssumeYes))
+	return sandbox.PromptMode{
+		AssumeYes:      yes || envYes,
+		NonInteractive: nonInteractive,
+	}
+}
+
+// addPromptFlags registers the global confirmation flags on the root command.
+func addPromptFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolP(flagYes, "y", false, "Answer yes to all confirmation prompts (or set "+sandbox.EnvAssumeYes+"=1)")
+	cmd.PersistentFlags().Bool(flagNonInteractive, false, "Never prompt; fail when confirmation is required")
+}
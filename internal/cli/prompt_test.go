@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+// newPromptTestCmd returns a command with the global prompt flags registered,
+// parsed with the given arguments.
+func newPromptTestCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	cmd := &cobra.Command{Use: "test"}
+	addPromptFlags(cmd)
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("ParseFlags: %v", err)
+	}
+	return cmd
+}
+
+func TestPromptModeFromCmd(t *testing.T) {
+	tests := []struct {
+		name               string
+		args               []string
+		env                string
+		wantAssumeYes      bool
+		wantNonInteractive bool
+	}{
+		{name: "defaults", args: nil},
+		{name: "--yes", args: []string{"--yes"}, wantAssumeYes: true},
+		{name: "-y", args: []string{"-y"}, wantAssumeYes: true},
+		{name: "--non-interactive", args: []string{"--non-interactive"}, wantNonInteractive: true},
+		{name: "env true", env: "1", wantAssumeYes: true},
+		{name: "env false", env: "false"},
+		{name: "env invalid", env: "maybe"},
+		{name: "both", args: []string{"--yes", "--non-interactive"}, wantAssumeYes: true, wantNonInteractive: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(sandbox.EnvAssumeYes, tt.env)
+			mode := promptModeFromCmd(newPromptTestCmd(t, tt.args...))
+			if mode.AssumeYes != tt.wantAssumeYes {
+				t.Errorf("assumeYes = %v, want %v", mode.AssumeYes, tt.wantAssumeYes)
+			}
+			if mode.NonInteractive != tt.wantNonInteractive {
+				t.Errorf("nonInteractive = %v, want %v", mode.NonInteractive, tt.wantNonInteractive)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Pre-pull the container image",
+	Long: `Pull the configured container image ahead of time, so that 'alca up'
+does not block on the download.
+
+A pull cut off by a network failure is retried; layers that finished
+downloading are kept, so running 'alca pull' again after a failure resumes
+instead of starting over.
+
+Pulling does not affect a running container. To switch to a newer image,
+recreate the container with 'alca down && alca up'.`,
+	Args: cobra.NoArgs,
+	RunE: runPull,
+}
+
+func init() {
+	pullCmd.Flags().BoolP("quiet", "q", false, "Suppress progress output")
+}
+
+// runPull pulls the configured image with the selected runtime.
+func runPull(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	quiet, _ := cmd.Flags().GetBool("quiet")
+
+	var out io.Writer = os.Stdout
+	if quiet {
+		out = nil
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIReadDeps()
+	cfg, _, err := sandbox.LoadConfigFromCwd(ctx, deps.Env, cwd)
+	if err != nil {
+		return err
+	}
+
+	rt, err := runtime.SelectRuntimeWithOutput(ctx, deps.RuntimeEnv, cfg, out)
+	if err != nil {
+		return fmt.Errorf("failed to select runtime: %w", err)
+	}
+
+	util.ProgressStep(out, "Pulling %s with %s...\n", cfg.Image, rt.Name())
+	if err := sandbox.PullImage(ctx, deps.RuntimeEnv, rt, cfg, out); err != nil {
+		return err
+	}
+
+	util.ProgressDone(out, "Image up to date: %s\n", cfg.Image)
+	sandbox.WarnArchMismatch(ctx, deps.RuntimeEnv, rt, cfg, out)
+	return nil
+}
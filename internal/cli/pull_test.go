@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+func TestFakeRuntime_UpPullPolicy(t *testing.T) {
+	fake, _ := setupFakeProject(t, fakeProjectConfig)
+	ctx := context.Background()
+	cwd, _ := findProjectDir()
+
+	if err := sandbox.Up(ctx, newHostEnv(), cwd, sandbox.UpOptions{PullPolicy: "sometimes"}); err == nil || !strings.Contains(err.Error(), "invalid pull policy") {
+		t.Fatalf("Up(sometimes) = %v, want an invalid pull policy error", err)
+	}
+
+	err := sandbox.Up(ctx, newHostEnv(), cwd, sandbox.UpOptions{PullPolicy: sandbox.PullNever})
+	if err == nil || !strings.Contains(err.Error(), "not present locally") {
+		t.Fatalf("Up(never) without the image = %v, want a not present error", err)
+	}
+
+	if err := sandbox.Up(ctx, newHostEnv(), cwd, sandbox.UpOptions{PullPolicy: sandbox.PullAlways}); err != nil {
+		t.Fatalf("Up(always): %v", err)
+	}
+	if !slices.Contains(fake.Calls, "PullImage alpine:3") {
+		t.Errorf("calls = %v, want PullImage alpine:3", fake.Calls)
+	}
+
+	// The image is local now
+	if err := sandbox.Down(ctx, newHostEnv(), cwd, sandbox.DownOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sandbox.Up(ctx, newHostEnv(), cwd, sandbox.UpOptions{PullPolicy: sandbox.PullNever}); err != nil {
+		t.Errorf("Up(never) with the image present: %v", err)
+	}
+}
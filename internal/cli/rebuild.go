@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/sync"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var rebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Recreate the sandbox container, preserving data",
+	Long: `Tear down and recreate the container with the current configuration.
+
+Named volumes are kept. Paths listed in [rebuild] preserve are copied out
+of the old container into temporary volumes on the runtime host, held by a
+stopped container named alca-rebuild-<project>-<time>, and restored into the
+new container once it is up:
+
+  [rebuild]
+  preserve = ["/root/.cache", "/root/.local/share"]
+
+If the new container fails to start or a restore fails, the temporary
+container and volumes are kept and their names are printed so nothing is
+lost.`,
+	Args:        cobra.NoArgs,
+	RunE:        runRebuild,
+	Annotations: destructive,
+}
+
+func init() {
+	rebuildCmd.Flags().BoolP("quiet", "q", false, "Suppress progress output")
+	rebuildCmd.Flags().BoolP("force", "f", false, "Skip sync conflict check and proceed anyway")
+}
+
+// runRebuild stages preserved paths, removes the container and recreates it
+// through the regular up flow, then restores the preserved paths.
+func runRebuild(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	force, _ := cmd.Flags().GetBool("force")
+
+	var out io.Writer = os.Stdout
+	if quiet {
+		out = nil
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+
+	// The container is removed before up runs, so up never asks to rebuild;
+	// --force only skips the sync conflict check below.
+	prompt := promptModeFromCmd(cmd)
+	upOpts := sandbox.UpOptions{AssumeYes: prompt.AssumeYes, Interactive: !prompt.NonInteractive, Out: out}
+	up := func() error {
+		ctx, stop := interruptContext(ctx)
+		defer stop()
+		return sandbox.Up(ctx, host, cwd, upOpts)
+	}
+
+	cfg, _, err := sandbox.LoadConfigFromCwd(ctx, env, cwd)
+	if err != nil {
+		return err
+	}
+
+	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to select runtime: %w", err)
+	}
+
+	st, err := sandbox.LoadStateOptional(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	// The old container lives in the runtime recorded in state, which may
+	// differ from the newly selected one (see rebuildContainerIfNeeded).
+	oldRt := rt
+	status := runtime.ContainerStatus{State: runtime.StateNotFound}
+	if st != nil {
+		if r := runtime.ByName(st.Runtime); r != nil {
+			oldRt = r
+		}
+		status, _ = oldRt.Status(ctx, runtimeEnv, cwd, st)
+	}
+	if status.State == runtime.StateNotFound {
+		util.ProgressStep(out, "No existing container, creating a new one.\n")
+		return up()
+	}
+
+	// Staged paths stay in the old runtime's volumes
+	if len(cfg.Rebuild.Preserve) > 0 && oldRt.Name() != rt.Name() {
+		return fmt.Errorf("cannot preserve [rebuild] paths from %s to %s; run 'alca down' and 'alca up' instead, or remove [rebuild] preserve", oldRt.Name(), rt.Name())
+	}
+
+	// Check for sync conflicts before destroying container (AGD-031).
+	syncEnv := sync.NewSyncEnv(host.Fs, deps.CmdRunner, runtime.NewMutagenSyncClient(runtimeEnv))
+	if err := sandbox.GuardSyncConflicts(ctx, host.Fs, syncEnv, cwd, st.ProjectID, force, os.Stderr); err != nil {
+		return err
+	}
+
+	// Preserved paths are staged in volumes on the runtime host, so they
+	// survive container removal without a round trip through the host disk.
+	var stage string
+	var preserved []string
+	if len(cfg.Rebuild.Preserve) > 0 {
+		stage = rebuildStageName(st.ProjectID, time.Now())
+		util.ProgressStep(out, "Saving preserved paths...\n")
+		preserved, err = oldRt.StagePaths(ctx, runtimeEnv, status.Name, stage, cfg.Rebuild.Preserve)
+		if err != nil {
+			_ = oldRt.RemoveStage(ctx, runtimeEnv, stage)
+			return fmt.Errorf("failed to save preserved paths: %w", err)
+		}
+		for _, p := range cfg.Rebuild.Preserve {
+			if !slices.Contains(preserved, p) {
+				util.ProgressStep(out, "Skipping %s (not present in container)\n", p)
+			}
+		}
+	}
+
+	util.ProgressStep(out, "Removing existing container for rebuild...\n")
+	if err := oldRt.Down(ctx, runtimeEnv, cwd, st); err != nil {
+		removeStage(ctx, runtimeEnv, oldRt, stage)
+		return fmt.Errorf("failed to remove container for rebuild: %w", err)
+	}
+
+	if err := up(); err != nil {
+		reportKeptStage(rt, stage)
+		return err
+	}
+
+	if len(preserved) == 0 {
+		removeStage(ctx, runtimeEnv, rt, stage)
+		return nil
+	}
+
+	// Container identity is unchanged (state persists), look it up again for the new instance.
+	status, err = rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil || status.State != runtime.StateRunning {
+		reportKeptStage(rt, stage)
+		return fmt.Errorf("new container is not running, cannot restore preserved paths: %w", runtime.ErrNotRunning)
+	}
+
+	util.ProgressStep(out, "Restoring preserved paths...\n")
+	if err := rt.RestoreStagedPaths(ctx, runtimeEnv, stage, status.Name, preserved); err != nil {
+		reportKeptStage(rt, stage)
+		return err
+	}
+	removeStage(ctx, runtimeEnv, rt, stage)
+
+	util.ProgressDone(out, "Rebuild complete, restored %d path(s)\n", len(preserved))
+	return nil
+}
+
+// rebuildStageName names the stage of one rebuild; the time keeps a stage
+// left by a failed rebuild from blocking the next one.
+func rebuildStageName(projectID string, now time.Time) string {
+	return fmt.Sprintf("alca-rebuild-%s-%d", projectID, now.Unix())
+}
+
+// removeStage removes stage if there is one, warning on failure.
+func removeStage(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, stage string) {
+	if stage == "" {
+		return
+	}
+	if err := rt.RemoveStage(ctx, runtimeEnv, stage); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// reportKeptStage tells the user where preserved data was left after a failed rebuild.
+func reportKeptStage(rt runtime.Runtime, stage string) {
+	if stage == "" {
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Preserved data kept in %s container %s and its volumes (label alca.stage=%s)\n", rt.Name(), stage, stage)
+}
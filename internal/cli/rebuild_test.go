@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+const rebuildPreserveConfig = fakeProjectConfig + `
+[rebuild]
+preserve = ["/root/.cache", "/root/.missing"]
+`
+
+// upWithCache brings the fake project up and writes a file under the
+// preserved /root/.cache.
+func upWithCache(t *testing.T, fake *runtime.Fake, dir string) string {
+	t.Helper()
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	name := loadFakeState(t, dir).ContainerName
+	if err := fake.WriteFile(context.Background(), nil, name, "/root/.cache/go/build", "cached"); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// stagedAs returns the stage name of the first StagePaths call.
+func stagedAs(t *testing.T, fake *runtime.Fake) string {
+	t.Helper()
+	for _, call := range fake.Calls {
+		if fields := strings.Fields(call); fields[0] == "StagePaths" {
+			return fields[2]
+		}
+	}
+	t.Fatalf("no StagePaths call in %q", fake.Calls)
+	return ""
+}
+
+func TestRebuild_RestoresPreservedPaths(t *testing.T) {
+	fake, dir := setupFakeProject(t, rebuildPreserveConfig)
+	name := upWithCache(t, fake, dir)
+	oldID := fake.Container(name).ID
+
+	if err := runFakeCommand(t, rebuildCmd, runRebuild, "quiet"); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+
+	c := fake.Container(name)
+	if c == nil || c.ID == oldID || c.State != runtime.StateRunning {
+		t.Fatalf("container after rebuild = %+v, want a new running one", c)
+	}
+	if got := c.Files["/root/.cache/go/build"]; got != "cached" {
+		t.Errorf("restored file = %q, want %q", got, "cached")
+	}
+	stage := stagedAs(t, fake)
+	if !strings.HasPrefix(stage, "alca-rebuild-") || fake.Stage(stage) != nil {
+		t.Errorf("stage %q left behind: %v", stage, fake.Stage(stage))
+	}
+}
+
+func TestRebuild_KeepsStageWhenUpFails(t *testing.T) {
+	fake, dir := setupFakeProject(t, rebuildPreserveConfig)
+	upWithCache(t, fake, dir)
+
+	fake.FailNext("Up", errors.New("image pull failed"))
+	if err := runFakeCommand(t, rebuildCmd, runRebuild, "quiet"); err == nil {
+		t.Fatal("rebuild succeeded despite the failing up")
+	}
+	if got := fake.Stage(stagedAs(t, fake))["/root/.cache/go/build"]; got != "cached" {
+		t.Errorf("staged file = %q, want the data kept for recovery", got)
+	}
+}
+
+func TestRebuildStageName(t *testing.T) {
+	first := rebuildStageName("abc", time.Unix(100, 0))
+	if first != "alca-rebuild-abc-100" {
+		t.Errorf("rebuildStageName() = %q", first)
+	}
+	if first == rebuildStageName("abc", time.Unix(101, 0)) {
+		t.Error("stage names of two rebuilds collide")
+	}
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+// newContainerRecovery reads --auto-up, --auto-reconcile and the prompt
+// flags from cmd. The up flow runs with cmd's flags, so up-only flags take
+// their defaults.
+func newContainerRecovery(cmd *cobra.Command) *sandbox.Recovery {
+	autoUp, _ := cmd.Flags().GetBool("auto-up")
+	autoReconcile, _ := cmd.Flags().GetBool("auto-reconcile")
+	return &sandbox.Recovery{
+		AutoUp:        autoUp,
+		AutoReconcile: autoReconcile,
+		Prompt:        promptModeFromCmd(cmd),
+		Up: func(_ context.Context, cwd string) error {
+			return upProject(cmd, cwd)
+		},
+		Out: cmd.ErrOrStderr(),
+	}
+}
@@ -1,11 +1,21 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"runtime/debug"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
 )
 
 var (
@@ -22,18 +32,99 @@ var rootCmd = &cobra.Command{
 
 Wraps AI code agents in containers with file and network isolation,
 so you can run agents without guardrails and keep your system safe.`,
-	Version:       Version,
-	SilenceUsage:  true,
-	SilenceErrors: true,
+	Version:           Version,
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	PersistentPreRunE: applyGlobalFlags,
 }
 
+const (
+	flagTimeout          = "timeout"
+	flagInsecureIncludes = "insecure-includes"
+)
+
+// timeoutCtx and cancelTimeout hold the --timeout context of the running
+// command, so Execute can report an expired deadline and release it.
+var (
+	timeoutCtx                       = context.Background()
+	cancelTimeout context.CancelFunc = func() {}
+)
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	defer func() {
+		if r := recover(); r != nil {
+			reportCrash(os.Stderr, crashInfo{Panic: fmt.Sprint(r), Stack: debug.Stack(), Args: os.Args, Time: time.Now()})
+			os.Exit(2)
+		}
+	}()
+	registerPlugins(rootCmd, discoverPlugins(newHostEnv().Fs, os.Getenv("PATH")))
+	// Mask sensitive values (see util.RegisterSecret) in command output
+	rootCmd.SetOut(util.NewRedactingWriter(os.Stdout))
+	rootCmd.SetErr(util.NewRedactingWriter(os.Stderr))
+	cmd, err := rootCmd.ExecuteC()
+	cancelTimeout()
+	if err != nil {
+		writeError(os.Stderr, cmd, timeoutExceeded(err))
 		os.Exit(1)
 	}
 }
 
+// applyGlobalFlags applies the persistent flags every command shares.
+func applyGlobalFlags(cmd *cobra.Command, args []string) error {
+	applyInsecureIncludes(cmd, os.Stderr)
+	startIdleCheck(cmd)
+	if err := checkVersionSkew(cmd); err != nil {
+		return err
+	}
+	return applyTimeout(cmd, args)
+}
+
+// applyInsecureIncludes turns off signature checks on shared configs (see
+// config.TrustedConfigKeysFile) for the configs cmd loads, when the flag
+// or config.EnvInsecureIncludes asks to, with a warning naming which.
+func applyInsecureIncludes(cmd *cobra.Command, w io.Writer) {
+	source := ""
+	// Plugin commands do not parse flags, so only the variable applies
+	if insecure, err := cmd.Flags().GetBool(flagInsecureIncludes); err == nil && insecure {
+		source = "--" + flagInsecureIncludes
+	} else if os.Getenv(config.EnvInsecureIncludes) == "1" {
+		source = config.EnvInsecureIncludes + "=1"
+	}
+	if source == "" {
+		return
+	}
+	cmd.SetContext(sandbox.WithInsecureIncludes(cmd.Context()))
+	_, _ = fmt.Fprintf(w, "Warning: %s: shared configs are loaded without verifying their signatures\n", source)
+}
+
+// applyTimeout bounds the command's context by --timeout, so runtime calls
+// (pulls, up commands, daemon queries) are killed once it expires.
+// Interactive sessions started by enter and run are not bounded: they are
+// attached to the terminal and ended by the user.
+func applyTimeout(cmd *cobra.Command, _ []string) error {
+	d, err := cmd.Flags().GetDuration(flagTimeout)
+	if err != nil {
+		// Plugin commands do not parse flags
+		return nil
+	}
+	if d < 0 {
+		return fmt.Errorf("--timeout %s: must not be negative", d)
+	}
+	timeoutCtx, cancelTimeout = util.WithTimeout(cmd.Context(), d)
+	cmd.SetContext(timeoutCtx)
+	return nil
+}
+
+// timeoutExceeded annotates err when the --timeout deadline expired, since
+// the underlying failure is usually just a killed subprocess.
+func timeoutExceeded(err error) error {
+	if !errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	d, _ := rootCmd.PersistentFlags().GetDuration(flagTimeout)
+	return fmt.Errorf("%w (--timeout %s exceeded)", err, d)
+}
+
 // GetRootCmd returns the root command for documentation generation.
 func GetRootCmd() *cobra.Command {
 	return rootCmd
@@ -44,15 +135,56 @@ func init() {
 		log.SetOutput(os.Stderr)
 	}
 
+	addPromptFlags(rootCmd)
+	rootCmd.PersistentFlags().Duration(flagTimeout, 0, "Abort the command after this long, e.g. 10m (0 = no limit; interactive sessions are not limited)")
+	rootCmd.PersistentFlags().Bool(flagInsecureIncludes, false, "Load shared configs (extends/includes outside the project) even if unsigned or not signed by a trusted key")
+	rootCmd.PersistentFlags().Bool(sandbox.FlagAllowVersionSkew, false, "Run destructive commands on a project last used with a much newer alca")
+
+	state.CLIVersion = Version
 	rootCmd.SetVersionTemplate(fmt.Sprintf("alca version %s\ncommit: %s\ndate: %s\n", Version, Commit, Date))
 
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(adoptCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(inspectCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(analyzeCmd)
 	rootCmd.AddCommand(upCmd)
 	rootCmd.AddCommand(downCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(stopIdleCmd)
+	rootCmd.AddCommand(cronCmd)
+	rootCmd.AddCommand(forwardEventsCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(rebuildCmd)
+	rootCmd.AddCommand(pullCmd)
+	rootCmd.AddCommand(bakeCmd)
+	rootCmd.AddCommand(sbomCmd)
+	rootCmd.AddCommand(exportCmd)
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(enterCmd)
+	rootCmd.AddCommand(sshConfigCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(sandboxCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(shellenvCmd)
+	rootCmd.AddCommand(credentialsCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(eventsCmd)
+	rootCmd.AddCommand(whoamiCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(stateCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(duCmd)
 	rootCmd.AddCommand(cleanupCmd)
+	rootCmd.AddCommand(workspaceCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(selfUpdateCmd)
 	rootCmd.AddCommand(experimentalCmd)
+	rootCmd.AddCommand(networkCmd)
 	rootCmd.AddCommand(networkHelperCmd)
 }
@@ -1,18 +1,42 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 )
 
 func TestRootCommandHasSubcommands(t *testing.T) {
 	expectedCommands := []string{
 		"init",
 		"status",
+		"inspect",
+		"config",
 		"up",
 		"down",
+		"rebuild",
+		"pull",
+		"bake",
 		"run",
+		"enter",
+		"test",
+		"sandbox",
+		"env",
+		"history",
+		"migrate",
+		"state",
 		"list",
 		"cleanup",
+		"workspace",
+		"sync",
+		"doctor",
+		"network",
 		"network-helper",
 		"experimental",
 	}
@@ -57,8 +81,69 @@ func TestRootCommandInfo(t *testing.T) {
 	}
 }
 
-func TestConfigFilenameConstant(t *testing.T) {
-	if ConfigFilename != ".alca.toml" {
-		t.Errorf("expected ConfigFilename to be '.alca.toml', got %q", ConfigFilename)
+func TestApplyTimeout(t *testing.T) {
+	defer func() { timeoutCtx, cancelTimeout = context.Background(), func() {} }()
+
+	newCmd := func(value string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().Duration(flagTimeout, 0, "")
+		_ = cmd.Flags().Set(flagTimeout, value)
+		cmd.SetContext(context.Background())
+		return cmd
+	}
+
+	cmd := newCmd("0")
+	if err := applyTimeout(cmd, nil); err != nil {
+		t.Fatalf("applyTimeout(0) error: %v", err)
+	}
+	if _, ok := cmd.Context().Deadline(); ok {
+		t.Error("--timeout 0 should not set a deadline")
+	}
+
+	cmd = newCmd("10m")
+	if err := applyTimeout(cmd, nil); err != nil {
+		t.Fatalf("applyTimeout(10m) error: %v", err)
+	}
+	if _, ok := cmd.Context().Deadline(); !ok {
+		t.Error("--timeout 10m should set a deadline")
+	}
+	cancelTimeout()
+
+	if err := applyTimeout(newCmd("-1s"), nil); err == nil {
+		t.Error("negative --timeout should be rejected")
+	}
+}
+
+func TestApplyInsecureIncludes(t *testing.T) {
+	newCmd := func(flag bool) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool(flagInsecureIncludes, flag, "")
+		cmd.SetContext(context.Background())
+		return cmd
+	}
+	tests := []struct {
+		name     string
+		flag     bool
+		env      string
+		wantWarn string
+	}{
+		{"off", false, "", ""},
+		{"flag", true, "", "Warning: --insecure-includes: "},
+		{"env", false, "1", "Warning: " + config.EnvInsecureIncludes + "=1: "},
+		{"env not 1", false, "yes", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.EnvInsecureIncludes, tt.env)
+			cmd := newCmd(tt.flag)
+			var out bytes.Buffer
+			applyInsecureIncludes(cmd, &out)
+			if !strings.HasPrefix(out.String(), tt.wantWarn) || (tt.wantWarn == "") != (out.Len() == 0) {
+				t.Errorf("warning = %q, want prefix %q", out.String(), tt.wantWarn)
+			}
+			if got := sandbox.InsecureIncludes(cmd.Context()); got != (tt.wantWarn != "") {
+				t.Errorf("insecure = %v, want %v", got, tt.wantWarn != "")
+			}
+		})
 	}
 }
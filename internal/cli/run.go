@@ -2,123 +2,93 @@ package cli
 
 import (
 	"errors"
-	"fmt"
 	"os"
 	"os/exec"
-	"strings"
 
-	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
-	"github.com/bolasblack/alcatraz/internal/runtime"
-	"github.com/bolasblack/alcatraz/internal/sync"
-	"github.com/bolasblack/alcatraz/internal/util"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 )
 
 var runCmd = &cobra.Command{
 	Use:   "run [command]",
 	Short: "Run a command inside the sandbox",
-	Long:  `Execute a command inside the Alcatraz sandbox environment.`,
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runRun,
+	Long: `Execute a command inside the Alcatraz sandbox environment.
+
+Like git, alca finds the project from any subdirectory: the nearest
+.alca.toml above the current directory is used, and the command runs in the
+matching container directory, so 'alca run make' in src/ runs make in
+<workdir>/src. Directories no mount covers run in the workdir.
+
+The command runs as the user set by --user, commands.enter.user or user in
+.alca.toml, in that order; otherwise as the image's default user.
+
+In a workspace (.alca-workspace.toml), --member runs the command in a member
+project's container instead, named by its path relative to the workspace root
+or its last path element. --all runs it in every member, one after another;
+the exit code is the highest exit code of any member.
+
+If the container is stopped or gone (e.g. the runtime daemon restarted),
+stale sync sessions are removed and 'alca up' is offered; --auto-up runs it
+without asking.`,
+	Example: `  alca run ls -la
+  alca run --user root apk add curl
+  alca run --member db -- psql -c 'select 1'
+  alca run --all -- make migrate`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRun,
 }
 
 func init() {
 	// Stop flag parsing after the first positional argument
 	// This allows: alca run ls -la (without needing --)
 	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().StringP("user", "u", "", "Run as this user (name or uid[:gid]), overriding the config")
+	runCmd.Flags().String("member", "", "Run in this workspace member's container")
+	runCmd.Flags().Bool("all", false, "Run in every workspace member's container")
+	runCmd.Flags().Bool("auto-up", false, "Run 'alca up' first if the container is stopped or gone")
+	runCmd.MarkFlagsMutuallyExclusive("member", "all")
 }
 
 // runRun executes a command inside the container.
 // See AGD-009 for CLI workflow design.
 func runRun(cmd *cobra.Command, args []string) error {
-	ctx := cmd.Context()
-	cwd, err := findProjectDir()
-	if err != nil {
-		return err
-	}
-
-	// Create shared dependencies once
-	cmdRunner := util.NewCommandRunner()
-	env := &util.Env{Fs: afero.NewReadOnlyFs(afero.NewOsFs()), Cmd: cmdRunner}
-	runtimeEnv := runtime.NewRuntimeEnv(cmdRunner)
-
-	// Load configuration and runtime
-	cfg, rt, err := loadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
-	if err != nil {
-		return err
-	}
-
-	// Load state (required)
-	st, err := loadRequiredState(env, cwd)
-	if err != nil {
-		return err
-	}
-
-	// Check if project directory has moved since container was created
-	if err := checkProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
-		return err
-	}
-
-	// Check if container is running
-	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
-	if err != nil {
-		return fmt.Errorf("failed to get container status: %w", err)
-	}
-
-	if status.State != runtime.StateRunning {
-		return errors.New(ErrMsgNotRunning)
-	}
-
-	// SWR: show stale cache banner immediately, refresh periodically in background.
-	syncFs := afero.NewOsFs()
-	syncEnv := sync.NewSyncEnv(syncFs, cmdRunner, runtime.NewMutagenSyncClient(runtimeEnv))
-	if cache, err := sync.ReadCache(syncFs, cwd); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to read sync conflict cache: %v\n", err)
-	} else if cache != nil && len(cache.Conflicts) > 0 {
-		sync.RenderBanner(cache.Conflicts, os.Stderr)
-	}
-	stopRefresh := sync.StartPeriodicRefresh(ctx, syncEnv, st.ProjectID, cwd)
-
-	// Build command with optional enter prefix
-	// If commands.enter is set, use it as command wrapper/prefix
-	var execCmd []string
-	if cfg.Commands.Enter.Command != "" {
-		// Enter may contain shell syntax (&&, |, etc.), so wrap with sh -c
-		// Quote each arg to preserve spaces and special characters
-		quotedArgs := make([]string, len(args))
-		for i, arg := range args {
-			quotedArgs[i] = shellQuote(arg)
+	user, _ := cmd.Flags().GetString("user")
+	member, _ := cmd.Flags().GetString("member")
+	all, _ := cmd.Flags().GetBool("all")
+
+	if all {
+		code, err := runInAllMembers(cmd.Context(), args, user, os.Stdout)
+		if err != nil {
+			return err
 		}
-		fullCmd := cfg.Commands.Enter.Command + " " + strings.Join(quotedArgs, " ")
-		execCmd = []string{"sh", "-c", fullCmd}
-	} else {
-		// Run command directly
-		execCmd = args
-	}
-
-	err = rt.Exec(ctx, runtimeEnv, cfg, cwd, st, execCmd)
-
-	// Show exit banner if conflicts exist
-	if conflicts := stopRefresh(); len(conflicts) > 0 {
-		sync.RenderBanner(conflicts, os.Stderr)
+		if code != 0 {
+			os.Exit(code)
+		}
+		return nil
 	}
 
+	projectDir, err := memberProjectDir(member)
 	if err != nil {
-		// Pass through exit codes instead of reporting as error
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			os.Exit(exitErr.ExitCode())
-		}
-		return fmt.Errorf("failed to execute command: %w", err)
+		return err
 	}
+	return passExitCode(sandbox.Exec(cmd.Context(), newHostEnv(), projectDir, args, execOptionsFromCmd(cmd, user)))
+}
 
-	return nil
+// execOptionsFromCmd runs as user from the current directory, recovering a
+// container that is not running as cmd's flags say.
+func execOptionsFromCmd(cmd *cobra.Command, user string) sandbox.ExecOptions {
+	dir, _ := getCwd()
+	return sandbox.ExecOptions{User: user, Dir: dir, Recovery: newContainerRecovery(cmd)}
 }
 
-// shellQuote quotes a string for safe use in shell commands.
-// It wraps the string in single quotes and escapes internal single quotes.
-func shellQuote(s string) string {
-	// Replace ' with '\'' (end quote, escaped quote, start quote)
-	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+// passExitCode exits with the exit code of a command that ran and failed
+// in the container, so alca exits as the command did. Other errors are
+// returned.
+func passExitCode(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
 }
@@ -1,56 +1,41 @@
 package cli
 
-import "testing"
-
-func TestShellQuote(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "simple string",
-			input:    "hello",
-			expected: "'hello'",
-		},
-		{
-			name:     "string with spaces",
-			input:    "hello world",
-			expected: "'hello world'",
-		},
-		{
-			name:     "string with single quote",
-			input:    "it's",
-			expected: "'it'\\''s'",
-		},
-		{
-			name:     "string with multiple single quotes",
-			input:    "it's a 'test'",
-			expected: "'it'\\''s a '\\''test'\\'''",
-		},
-		{
-			name:     "empty string",
-			input:    "",
-			expected: "''",
-		},
-		{
-			name:     "string with special chars",
-			input:    "hello$world",
-			expected: "'hello$world'",
-		},
-		{
-			name:     "string with double quotes",
-			input:    `say "hello"`,
-			expected: `'say "hello"'`,
-		},
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+func TestFakeRuntime_RunAutoUpAfterContainerVanished(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
 	}
+	st := loadFakeState(t, dir)
+	before := fake.Container(st.ContainerName)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := shellQuote(tt.input)
-			if result != tt.expected {
-				t.Errorf("shellQuote(%q) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
+	// A daemon restart takes the container with it; state.json still points at it
+	if err := fake.RemoveContainer(context.Background(), nil, st.ContainerName); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if err := runFakeCommand(t, runCmd, func(cmd *cobra.Command, _ []string) error {
+		return sandbox.Exec(cmd.Context(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{Recovery: newContainerRecovery(cmd)})
+	}, "auto-up"); err != nil {
+		t.Fatalf("run --auto-up: %v", err)
+	}
+
+	after := fake.Container(st.ContainerName)
+	if after == nil || after.State != runtime.StateRunning || after.ID == before.ID {
+		t.Fatalf("container after run --auto-up = %+v, want a new running container", after)
+	}
+	if last := after.Execs[len(after.Execs)-1]; !slices.Equal(last, []string{"true"}) {
+		t.Errorf("last exec = %q, want [true]", last)
 	}
 }
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+)
+
+var sandboxCmd = &cobra.Command{
+	Use:   "sandbox -- <command>",
+	Short: "Run a command in a one-shot throwaway container",
+	Long: `Create a throwaway container from the project configuration, run the
+command in it, and remove the container afterwards — even when alca is
+interrupted with Ctrl-C, SIGTERM or SIGHUP. The container gets a random
+name (alca-sandbox-<id>) and state.json is left untouched, so the
+long-lived container from 'alca up' is unaffected.
+
+Useful for letting an agent attempt a risky operation in isolation.
+Changes inside the container are discarded; changes to bind-mounted
+project files are not.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSandbox,
+}
+
+func init() {
+	sandboxCmd.Flags().BoolP("quiet", "q", false, "Suppress progress output")
+	// Stop flag parsing after the first positional argument, as in 'alca run'
+	sandboxCmd.Flags().SetInterspersed(false)
+}
+
+// runSandbox runs args in an ephemeral container.
+func runSandbox(cmd *cobra.Command, args []string) error {
+	return runEphemeral(cmd, "sandbox", func(cfg *config.Config) ([]string, error) {
+		return sandbox.WrapWithEnter(cfg, args), nil
+	})
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/sbom"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+// sbomFilename is the default SBOM output file inside the .alca directory.
+const sbomFilename = "sbom.json"
+
+var sbomCmd = &cobra.Command{
+	Use:   "sbom",
+	Short: "Write a software bill of materials for the sandbox",
+	Long: `Write a CycloneDX 1.5 or SPDX 2.3 JSON document listing the OS packages in
+the running container, for compliance pipelines.
+
+Packages are read from the apk, dpkg and rpm databases. Each is marked as
+coming from the image or from the up command: the configured image is
+listed in a throwaway container without network and compared with the
+container, so packages installed or upgraded since creation (normally by
+commands.up) are attributed to the up command. Packages installed by other
+means (language package managers, nix, binaries copied in) are not listed.
+
+The document is written to .alca/sbom.json unless --output is given;
+--output - prints it.`,
+	Example: `  alca sbom
+  alca sbom --format spdx --output sbom.spdx.json`,
+	Args: cobra.NoArgs,
+	RunE: runSBOM,
+}
+
+func init() {
+	sbomCmd.Flags().String("format", sbom.FormatCycloneDX, "Document format (cyclonedx, spdx)")
+	sbomCmd.Flags().StringP("output", "o", "", "Output file, or - for stdout (default .alca/"+sbomFilename+")")
+}
+
+// runSBOM writes the SBOM of the project container.
+func runSBOM(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	if format != sbom.FormatCycloneDX && format != sbom.FormatSPDX {
+		return fmt.Errorf("unknown format %q, valid options: %s, %s", format, sbom.FormatCycloneDX, sbom.FormatSPDX)
+	}
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	doc, err := buildSBOM(ctx, runtimeEnv, rt, cfg, cwd, st, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	if output == "-" {
+		return sbom.Encode(os.Stdout, doc, format)
+	}
+	if output == "" {
+		output = filepath.Join(state.StateDirPath(cwd), sbomFilename)
+	}
+	if err := writeSBOM(host.Fs, output, doc, format); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s (%d packages, %d from the up command)\n", output, len(doc.Components), countUpLayer(doc.Components))
+	return nil
+}
+
+// buildSBOM lists the packages of the configured image and of the running
+// container and diffs them. Warnings go to warnOut.
+func buildSBOM(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, cwd string, st *state.State, warnOut io.Writer) (sbom.Document, error) {
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return sbom.Document{}, fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State != runtime.StateRunning {
+		return sbom.Document{}, errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	containerPkgs, err := rt.ContainerPackages(ctx, runtimeEnv, status.Name)
+	if err != nil {
+		return sbom.Document{}, err
+	}
+	imagePkgs, err := rt.ImagePackages(ctx, runtimeEnv, cfg.Image, cfg.Platform)
+	if err != nil {
+		return sbom.Document{}, err
+	}
+	if len(containerPkgs.Packages) == 0 {
+		_, _ = fmt.Fprintln(warnOut, "Warning: no apk, dpkg or rpm package database found in the container; the SBOM lists no packages")
+	}
+
+	osID, osVersion := containerPkgs.OSID, containerPkgs.OSVersion
+	if osID == "" {
+		osID, osVersion = imagePkgs.OSID, imagePkgs.OSVersion
+	}
+	return sbom.Document{
+		Image:       cfg.Image,
+		Serial:      uuid.NewString(),
+		Created:     time.Now(),
+		ToolVersion: Version,
+		OSID:        osID,
+		OSVersion:   osVersion,
+		Components:  sbom.Diff(imagePkgs, containerPkgs),
+	}, nil
+}
+
+// writeSBOM encodes doc to path, creating its directory.
+func writeSBOM(fs afero.Fs, path string, doc sbom.Document, format string) error {
+	if err := fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	f, err := fs.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := sbom.Encode(f, doc, format); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// countUpLayer counts the components installed by the up command.
+func countUpLayer(components []sbom.Component) int {
+	n := 0
+	for _, c := range components {
+		if c.Layer == sbom.LayerUp {
+			n++
+		}
+	}
+	return n
+}
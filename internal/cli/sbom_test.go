@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/sbom"
+)
+
+func TestFakeRuntime_SBOM(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+
+	musl := runtime.Package{Manager: "apk", Name: "musl", Version: "1.2.5-r8", Arch: "x86_64"}
+	git := runtime.Package{Manager: "apk", Name: "git", Version: "2.47.2-r0", Arch: "x86_64"}
+	fake.SetImagePackages("alpine:3", runtime.PackageList{OSID: "alpine", OSVersion: "3.21.0", Packages: []runtime.Package{musl}})
+	c := fake.Container(st.ContainerName)
+	c.Packages = runtime.PackageList{OSID: "alpine", OSVersion: "3.21.0", Packages: []runtime.Package{git, musl}}
+	fake.AddContainer(*c)
+
+	if err := runFakeCommand(t, sbomCmd, runSBOM); err != nil {
+		t.Fatalf("sbom: %v", err)
+	}
+
+	data, err := afero.ReadFile(afero.NewOsFs(), filepath.Join(dir, ".alca", sbomFilename))
+	if err != nil {
+		t.Fatalf("read sbom: %v", err)
+	}
+	var bom struct {
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			Name       string `json:"name"`
+			PURL       string `json:"purl"`
+			Properties []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"properties"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("sbom is not valid JSON: %v\n%s", err, data)
+	}
+	if bom.BOMFormat != "CycloneDX" || len(bom.Components) != 2 {
+		t.Fatalf("sbom = %s", data)
+	}
+	layers := map[string]string{}
+	for _, comp := range bom.Components {
+		layers[comp.Name] = comp.Properties[0].Value
+	}
+	if layers["git"] != sbom.LayerUp || layers["musl"] != sbom.LayerImage {
+		t.Errorf("layers = %v", layers)
+	}
+}
+
+func TestFakeRuntime_SBOMNotRunning(t *testing.T) {
+	_, _ = setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := runFakeCommand(t, downCmd, runDown); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if err := runFakeCommand(t, sbomCmd, runSBOM); err == nil || err.Error() != sandbox.ErrMsgNotRunning {
+		t.Errorf("sbom after down: err = %v, want %q", err, sandbox.ErrMsgNotRunning)
+	}
+}
@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/minisign"
+	"github.com/bolasblack/alcatraz/internal/selfupdate"
+)
+
+// ReleaseKey is the minisign public key release checksums are signed with,
+// set at build time via ldflags. Builds without it cannot verify signatures.
+var ReleaseKey = ""
+
+// errNoReleaseKey is returned when the signature cannot be verified because
+// the build has no release key, and --insecure was not given.
+var errNoReleaseKey = errors.New("this alca build has no release key to verify the signature with; rerun with --insecure to install without verifying it")
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update alca to the latest release",
+	Long: `Download the latest alca release from GitHub and replace the running
+binary with it.
+
+The archive is checked against the release's checksums.txt, whose minisign
+signature is verified with the key built into alca. The new binary is
+written next to the old one and renamed over it, so an interrupted update
+leaves the old binary in place.
+
+--channel beta also considers prereleases. --from-file installs an archive
+downloaded by hand (for hosts without GitHub access); checksums.txt and
+checksums.txt.minisig from the same release must be next to it.
+
+Builds without a release key (e.g. built from source) refuse to update
+unless --insecure is given, which skips the signature check; the archive is
+still checked against checksums.txt.
+
+alca installed with Homebrew or Nix must be updated with them instead.`,
+	Args: cobra.NoArgs,
+	RunE: runSelfUpdate,
+}
+
+func init() {
+	selfUpdateCmd.Flags().String("channel", selfupdate.ChannelStable, "Release channel: stable or beta")
+	selfUpdateCmd.Flags().String("from-file", "", "Install a downloaded release archive instead of fetching one")
+	selfUpdateCmd.Flags().Bool("check", false, "Only report whether an update is available")
+	selfUpdateCmd.Flags().Bool("force", false, "Install even if the release is the running version")
+	selfUpdateCmd.Flags().Bool("insecure", false, "Install without verifying the release signature")
+}
+
+// selfUpdateTimeout bounds the whole download.
+const selfUpdateTimeout = 5 * time.Minute
+
+// selfUpdateOptions is what a self-update needs, separated from the
+// command so tests can point it at a fake release server and binary.
+type selfUpdateOptions struct {
+	fs       afero.Fs
+	client   *selfupdate.Client
+	exePath  string
+	current  string
+	goos     string
+	goarch   string
+	keys     []minisign.Key
+	channel  string
+	fromFile string
+	check    bool
+	force    bool
+	insecure bool
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	channel, _ := cmd.Flags().GetString("channel")
+	fromFile, _ := cmd.Flags().GetString("from-file")
+	check, _ := cmd.Flags().GetBool("check")
+	force, _ := cmd.Flags().GetBool("force")
+	insecure, _ := cmd.Flags().GetBool("insecure")
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the alca binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	var keys []minisign.Key
+	if ReleaseKey != "" {
+		if keys, err = minisign.ParseKeys(ReleaseKey); err != nil {
+			return fmt.Errorf("built-in release key is invalid: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), selfUpdateTimeout)
+	defer cancel()
+	return selfUpdate(ctx, out, selfUpdateOptions{
+		fs:       newHostEnv().Fs,
+		client:   &selfupdate.Client{HTTP: &http.Client{Timeout: selfUpdateTimeout}, APIURL: selfupdate.DefaultAPIURL},
+		exePath:  exe,
+		current:  Version,
+		goos:     goruntime.GOOS,
+		goarch:   goruntime.GOARCH,
+		keys:     keys,
+		channel:  channel,
+		fromFile: fromFile,
+		check:    check,
+		force:    force,
+		insecure: insecure,
+	})
+}
+
+func selfUpdate(ctx context.Context, out io.Writer, opts selfUpdateOptions) error {
+	if opts.channel != selfupdate.ChannelStable && opts.channel != selfupdate.ChannelBeta {
+		return fmt.Errorf("invalid --channel %q: must be %s or %s", opts.channel, selfupdate.ChannelStable, selfupdate.ChannelBeta)
+	}
+	if opts.check && opts.fromFile != "" {
+		return fmt.Errorf("--check cannot be used with --from-file")
+	}
+	if manager := selfupdate.ManagedBy(opts.exePath); manager != "" && !opts.check {
+		return fmt.Errorf("%s was installed by %s; update it with %s instead", opts.exePath, manager, manager)
+	}
+
+	var archiveName string
+	var archive, checksums, sig []byte
+	if opts.fromFile != "" {
+		var err error
+		archiveName = filepath.Base(opts.fromFile)
+		if archive, checksums, sig, err = readLocalRelease(opts.fs, opts.fromFile); err != nil {
+			return err
+		}
+	} else {
+		release, err := opts.client.Latest(ctx, opts.channel)
+		if err != nil {
+			return fmt.Errorf("failed to check for updates: %w", err)
+		}
+		if release.Version() == strings.TrimPrefix(opts.current, "v") && !opts.force {
+			_, _ = fmt.Fprintf(out, "alca %s is up to date.\n", opts.current)
+			return nil
+		}
+		if opts.check {
+			_, _ = fmt.Fprintf(out, "alca %s is available (running %s). Run 'alca self-update' to install it.\n", release.Tag, opts.current)
+			return nil
+		}
+		archiveName = selfupdate.ArchiveName(release.Version(), opts.goos, opts.goarch)
+		if archive, checksums, sig, err = downloadRelease(ctx, opts.client, release, archiveName); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "Downloaded %s.\n", archiveName)
+	}
+
+	if err := verifyRelease(out, opts.keys, opts.insecure, archiveName, archive, checksums, sig); err != nil {
+		return err
+	}
+	binary, err := selfupdate.ExtractBinary(bytes.NewReader(archive))
+	if err != nil {
+		return fmt.Errorf("%s: %w", archiveName, err)
+	}
+	if err := selfupdate.Install(opts.fs, opts.exePath, binary); err != nil {
+		return fmt.Errorf("failed to install the update: %w", err)
+	}
+	_, _ = fmt.Fprintf(out, "Updated %s from %s.\n", opts.exePath, archiveName)
+	return nil
+}
+
+// verifyRelease checks the archive against checksums.txt and checksums.txt
+// against its signature. Without built-in keys the signature cannot be
+// checked, which fails unless insecure is set.
+func verifyRelease(out io.Writer, keys []minisign.Key, insecure bool, archiveName string, archive, checksums, sig []byte) error {
+	switch {
+	case insecure:
+		_, _ = fmt.Fprintln(out, "Warning: --insecure given; the release signature was not verified.")
+	case len(keys) == 0:
+		return errNoReleaseKey
+	default:
+		if err := selfupdate.VerifySignature(keys, checksums, sig); err != nil {
+			return err
+		}
+	}
+	return selfupdate.VerifyChecksum(checksums, archiveName, archive)
+}
+
+// downloadRelease downloads the archive, checksums.txt and, if the release
+// has one, its signature.
+func downloadRelease(ctx context.Context, client *selfupdate.Client, release selfupdate.Release, archiveName string) (archive, checksums, sig []byte, err error) {
+	fetch := func(name string) ([]byte, error) {
+		asset, ok := release.Asset(name)
+		if !ok {
+			return nil, fmt.Errorf("release %s has no %s", release.Tag, name)
+		}
+		data, err := client.Download(ctx, asset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", name, err)
+		}
+		return data, nil
+	}
+	if archive, err = fetch(archiveName); err != nil {
+		return nil, nil, nil, err
+	}
+	if checksums, err = fetch(selfupdate.ChecksumsName); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, ok := release.Asset(selfupdate.SignatureName); ok {
+		if sig, err = fetch(selfupdate.SignatureName); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return archive, checksums, sig, nil
+}
+
+// readLocalRelease reads an archive and the checksums.txt and signature
+// next to it. A missing signature is returned as nil.
+func readLocalRelease(fs afero.Fs, path string) (archive, checksums, sig []byte, err error) {
+	if archive, err = afero.ReadFile(fs, path); err != nil {
+		return nil, nil, nil, err
+	}
+	dir := filepath.Dir(path)
+	if checksums, err = afero.ReadFile(fs, filepath.Join(dir, selfupdate.ChecksumsName)); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s must be next to the archive: %w", selfupdate.ChecksumsName, err)
+	}
+	sig, err = afero.ReadFile(fs, filepath.Join(dir, selfupdate.SignatureName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil, err
+	}
+	return archive, checksums, sig, nil
+}
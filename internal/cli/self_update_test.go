@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/selfupdate"
+)
+
+// releaseArchive returns a release .tar.gz holding an alca binary.
+func releaseArchive(t *testing.T, binary string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "alca", Mode: 0o755, Size: int64(len(binary)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = tw.Write([]byte(binary))
+	_ = tw.Close()
+	_ = gz.Close()
+	return buf.Bytes()
+}
+
+// setupSelfUpdateTest serves a v0.4.0 release with archive and a
+// checksums.txt for it, and returns options updating a fake binary.
+func setupSelfUpdateTest(t *testing.T, archive []byte, checksummed []byte) selfUpdateOptions {
+	t.Helper()
+	name := selfupdate.ArchiveName("0.4.0", "linux", "amd64")
+	sum := sha256.Sum256(checksummed)
+	checksums := hex.EncodeToString(sum[:]) + "  " + name + "\n"
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `[{"tag_name": "v0.4.0", "assets": [
+			{"name": %q, "browser_download_url": "%s/a"},
+			{"name": "checksums.txt", "browser_download_url": "%s/checksums"}
+		]}]`, name, srv.URL, srv.URL)
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write(archive) })
+	mux.HandleFunc("/checksums", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(checksums)) })
+
+	fs := afero.NewMemMapFs()
+	exe := "/usr/local/bin/alca"
+	if err := afero.WriteFile(fs, exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return selfUpdateOptions{
+		fs:      fs,
+		client:  &selfupdate.Client{HTTP: srv.Client(), APIURL: srv.URL + "/releases"},
+		exePath: exe,
+		current: "0.3.0",
+		goos:    "linux",
+		goarch:  "amd64",
+		channel: selfupdate.ChannelStable,
+		// The test releases are unsigned and there is no release key
+		insecure: true,
+	}
+}
+
+func readExe(t *testing.T, opts selfUpdateOptions) string {
+	t.Helper()
+	data, err := afero.ReadFile(opts.fs, opts.exePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSelfUpdate_InstallsLatestRelease(t *testing.T) {
+	archive := releaseArchive(t, "new binary")
+	opts := setupSelfUpdateTest(t, archive, archive)
+
+	var out bytes.Buffer
+	if err := selfUpdate(context.Background(), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := readExe(t, opts); got != "new binary" {
+		t.Errorf("binary = %q, want new binary", got)
+	}
+	if !strings.Contains(out.String(), "signature was not verified") {
+		t.Errorf("expected unverified warning with --insecure, got:\n%s", out.String())
+	}
+}
+
+func TestSelfUpdate_RefusesWithoutReleaseKey(t *testing.T) {
+	archive := releaseArchive(t, "new binary")
+	opts := setupSelfUpdateTest(t, archive, archive)
+	opts.insecure = false
+
+	err := selfUpdate(context.Background(), &bytes.Buffer{}, opts)
+	if !errors.Is(err, errNoReleaseKey) {
+		t.Fatalf("expected errNoReleaseKey, got %v", err)
+	}
+	if got := readExe(t, opts); got != "old binary" {
+		t.Errorf("binary = %q, want it unchanged", got)
+	}
+}
+
+func TestSelfUpdate_ChecksumMismatchKeepsBinary(t *testing.T) {
+	opts := setupSelfUpdateTest(t, releaseArchive(t, "evil binary"), []byte("something else"))
+
+	err := selfUpdate(context.Background(), &bytes.Buffer{}, opts)
+	if !errors.Is(err, selfupdate.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if got := readExe(t, opts); got != "old binary" {
+		t.Errorf("binary = %q, want it unchanged", got)
+	}
+}
+
+func TestSelfUpdate_UpToDateAndCheck(t *testing.T) {
+	archive := releaseArchive(t, "new binary")
+	opts := setupSelfUpdateTest(t, archive, archive)
+
+	opts.current = "v0.4.0"
+	var out bytes.Buffer
+	if err := selfUpdate(context.Background(), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "up to date") {
+		t.Errorf("expected up to date, got:\n%s", out.String())
+	}
+
+	opts.current = "0.3.0"
+	opts.check = true
+	out.Reset()
+	if err := selfUpdate(context.Background(), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "v0.4.0 is available") {
+		t.Errorf("expected available release, got:\n%s", out.String())
+	}
+	if got := readExe(t, opts); got != "old binary" {
+		t.Errorf("--check replaced the binary: %q", got)
+	}
+}
+
+func TestSelfUpdate_FromFile(t *testing.T) {
+	opts := setupSelfUpdateTest(t, nil, nil)
+	opts.client = nil // must not touch the network
+
+	dir := "/downloads"
+	archive := releaseArchive(t, "offline binary")
+	name := selfupdate.ArchiveName("0.4.0", "linux", "amd64")
+	sum := sha256.Sum256(archive)
+	if err := afero.WriteFile(opts.fs, filepath.Join(dir, name), archive, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(opts.fs, filepath.Join(dir, selfupdate.ChecksumsName), []byte(hex.EncodeToString(sum[:])+"  "+name+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.fromFile = filepath.Join(dir, name)
+	if err := selfUpdate(context.Background(), &bytes.Buffer{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := readExe(t, opts); got != "offline binary" {
+		t.Errorf("binary = %q, want offline binary", got)
+	}
+}
+
+func TestSelfUpdate_RejectsInvalidUse(t *testing.T) {
+	opts := setupSelfUpdateTest(t, nil, nil)
+
+	opts.channel = "nightly"
+	if err := selfUpdate(context.Background(), &bytes.Buffer{}, opts); err == nil {
+		t.Error("expected error for unknown channel")
+	}
+
+	opts.channel = selfupdate.ChannelStable
+	opts.exePath = "/opt/homebrew/Cellar/alca/0.3.0/bin/alca"
+	err := selfUpdate(context.Background(), &bytes.Buffer{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "Homebrew") {
+		t.Errorf("expected Homebrew error, got %v", err)
+	}
+}
@@ -0,0 +1,588 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// serveSocketFile in ~/.alcatraz is the default socket of alca serve.
+	serveSocketFile = "serve.sock"
+	// serveTokenSuffix names the token file next to the socket.
+	serveTokenSuffix = ".token"
+)
+
+// JSON-RPC 2.0 error codes used by alca serve.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcOperationError = -32000
+	rpcUnauthorized   = -32001
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve project operations to other programs on a unix socket",
+	Long: `Run a long-lived service that GUIs and agent orchestrators use to manage
+many sandboxes without starting alca for every operation.
+
+The service listens on a unix socket (~/.alcatraz/` + serveSocketFile + ` by default, --socket to
+change) that only the current user can open, and speaks JSON-RPC 2.0, one
+JSON value per line. A connection must first call "auth" with the token
+alca serve writes next to the socket (<socket>` + serveTokenSuffix + `, readable by the user
+only, new on every start):
+
+  {"jsonrpc":"2.0","id":1,"method":"auth","params":{"token":"..."}}
+
+Every other method takes the absolute project directory as "project":
+
+  up      {"project", "force", "resume", "set": [...], "yes", "pull_policy"}
+  down    {"project", "force"}
+  status  {"project"}
+  exec    {"project", "command": [...], "user"}  -> {"exit_code"}
+  logs    {"project", "follow", "tail", "since", "timestamps"}
+
+Nothing prompts: a change that needs confirmation fails unless "yes" is
+true. While up, down, exec and logs run, their output arrives as "output"
+notifications carrying the request id:
+
+  {"jsonrpc":"2.0","method":"output","params":{"id":1,"data":"..."}}
+
+Requests on one connection run concurrently; up and down on the same
+project wait for each other. Closing the connection cancels its requests.`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().String("socket", "", "Listen on this unix socket instead of ~/.alcatraz/"+serveSocketFile)
+}
+
+// runServe listens on the socket until interrupted.
+func runServe(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	socketPath, _ := cmd.Flags().GetString("socket")
+	if socketPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		socketPath = filepath.Join(home, util.AlcatrazDir, serveSocketFile)
+	}
+
+	env := newHostEnv()
+	ln, token, err := listenServe(env.Fs, socketPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = ln.Close()
+		// The token lives exactly as long as the socket
+		_ = env.Fs.Remove(socketPath + serveTokenSuffix)
+	}()
+
+	util.ProgressStep(cmd.OutOrStdout(), "Serving on %s\n", socketPath)
+	newServeServer(env, token).serve(ctx, ln)
+	return nil
+}
+
+// listenServe creates the socket and a fresh token file on fs, both
+// readable by the current user only. fs must be the host filesystem the
+// socket is created on. A socket left behind by a dead server is replaced;
+// one a live server answers on is an error.
+func listenServe(fs afero.Fs, socketPath string) (net.Listener, string, error) {
+	if err := fs.MkdirAll(filepath.Dir(socketPath), 0o700); err != nil {
+		return nil, "", err
+	}
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		_ = conn.Close()
+		return nil, "", fmt.Errorf("alca serve is already running on %s", socketPath)
+	}
+	_ = os.Remove(socketPath) //nolint:fslint // stale socket of a dead server
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(buf)
+	if err := afero.WriteFile(fs, socketPath+serveTokenSuffix, []byte(token+"\n"), 0o600); err != nil {
+		return nil, "", err
+	}
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.Chmod(socketPath, 0o600); err != nil { //nolint:fslint // the socket must be a real file
+		_ = ln.Close()
+		return nil, "", err
+	}
+	return ln, token, nil
+}
+
+// rpcRequest is a JSON-RPC 2.0 request; a missing id makes it a
+// notification, which gets no response.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// rpcNotification is a message from the server without a response.
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// serveOutput carries output of the request id.
+type serveOutput struct {
+	ID   json.RawMessage `json:"id"`
+	Data string          `json:"data"`
+}
+
+// serveServer handles the connections of alca serve.
+type serveServer struct {
+	host  *util.Env
+	token string
+
+	mu    gosync.Mutex
+	locks map[string]*gosync.Mutex
+}
+
+func newServeServer(host *util.Env, token string) *serveServer {
+	return &serveServer{host: host, token: token, locks: map[string]*gosync.Mutex{}}
+}
+
+// serve accepts connections until ctx is done, then waits for them to end.
+func (s *serveServer) serve(ctx context.Context, ln net.Listener) {
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+	var wg gosync.WaitGroup
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handleConn(ctx, conn)
+		}()
+	}
+	wg.Wait()
+}
+
+// lockProject serializes the operations that change projectDir.
+func (s *serveServer) lockProject(projectDir string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[projectDir]
+	if !ok {
+		l = &gosync.Mutex{}
+		s.locks[projectDir] = l
+	}
+	s.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// serveConn is one client connection.
+type serveConn struct {
+	srv *serveServer
+
+	mu  gosync.Mutex
+	enc *json.Encoder
+}
+
+// send writes one message; messages of concurrent requests are not
+// interleaved.
+func (c *serveConn) send(v any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.enc.Encode(v)
+}
+
+func (c *serveConn) reply(id json.RawMessage, result any, err error) {
+	if id == nil {
+		return
+	}
+	resp := rpcResponse{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		var re *rpcError
+		if !errors.As(err, &re) {
+			re = &rpcError{Code: rpcOperationError, Message: err.Error()}
+		}
+		resp.Error = re
+	} else {
+		resp.Result = result
+	}
+	c.send(resp)
+}
+
+// outputWriter sends what is written as output notifications of id.
+type outputWriter struct {
+	c  *serveConn
+	id json.RawMessage
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.c.send(rpcNotification{JSONRPC: "2.0", Method: "output", Params: serveOutput{ID: w.id, Data: string(p)}})
+	return len(p), nil
+}
+
+// handleConn reads requests until the client disconnects, answering auth
+// inline and running the others concurrently. Closing the connection
+// cancels the requests in flight.
+func (s *serveServer) handleConn(ctx context.Context, conn net.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg gosync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+		_ = conn.Close()
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	c := &serveConn{srv: s, enc: json.NewEncoder(conn)}
+	dec := json.NewDecoder(conn)
+	authed := false
+	for {
+		var req rpcRequest
+		if err := dec.Decode(&req); err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				c.reply(json.RawMessage("null"), nil, &rpcError{Code: rpcParseError, Message: err.Error()})
+			}
+			return
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			c.reply(orNull(req.ID), nil, &rpcError{Code: rpcInvalidRequest, Message: "not a JSON-RPC 2.0 request"})
+			continue
+		}
+		if req.Method == "auth" {
+			var p struct {
+				Token string `json:"token"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			if subtle.ConstantTimeCompare([]byte(p.Token), []byte(s.token)) != 1 {
+				c.reply(orNull(req.ID), nil, &rpcError{Code: rpcUnauthorized, Message: "invalid token"})
+				return
+			}
+			authed = true
+			c.reply(req.ID, struct{}{}, nil)
+			continue
+		}
+		if !authed {
+			c.reply(orNull(req.ID), nil, &rpcError{Code: rpcUnauthorized, Message: "call auth with the token first"})
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.handle(ctx, req.Method, req.Params, outputWriter{c: c, id: orNull(req.ID)})
+			c.reply(req.ID, result, err)
+		}()
+	}
+}
+
+// orNull returns id, or a JSON null for requests without one.
+func orNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// serveProjectParams is the project every method but auth works on.
+type serveProjectParams struct {
+	Project string `json:"project"`
+}
+
+// projectDir resolves the project directory like alca run from a
+// subdirectory would.
+func (p serveProjectParams) projectDir(fs afero.Fs) (string, error) {
+	if !filepath.IsAbs(p.Project) {
+		return "", &rpcError{Code: rpcInvalidParams, Message: "project must be an absolute path"}
+	}
+	dir, ok := sandbox.FindProjectDir(fs, filepath.Clean(p.Project))
+	if !ok {
+		return "", &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("%s: %s", p.Project, sandbox.ErrMsgConfigNotFound)}
+	}
+	return dir, nil
+}
+
+type serveUpParams struct {
+	serveProjectParams
+	Force      bool               `json:"force"`
+	Resume     bool               `json:"resume"`
+	Set        []string           `json:"set"`
+	Yes        bool               `json:"yes"`
+	PullPolicy sandbox.PullPolicy `json:"pull_policy"`
+}
+
+type serveDownParams struct {
+	serveProjectParams
+	Force bool `json:"force"`
+}
+
+type serveExecParams struct {
+	serveProjectParams
+	Command []string `json:"command"`
+	User    string   `json:"user"`
+}
+
+type serveLogsParams struct {
+	serveProjectParams
+	Follow     bool   `json:"follow"`
+	Tail       string `json:"tail"`
+	Since      string `json:"since"`
+	Timestamps bool   `json:"timestamps"`
+}
+
+// serveStatus is the result of status.
+type serveStatus struct {
+	Initialized bool            `json:"initialized"`
+	Runtime     string          `json:"runtime,omitempty"`
+	ProjectID   string          `json:"project_id,omitempty"`
+	Container   *serveContainer `json:"container,omitempty"`
+}
+
+type serveContainer struct {
+	State     runtime.ContainerState `json:"state"`
+	Name      string                 `json:"name,omitempty"`
+	ID        string                 `json:"id,omitempty"`
+	Image     string                 `json:"image,omitempty"`
+	StartedAt string                 `json:"started_at,omitempty"`
+}
+
+// serveExecResult is the result of exec.
+type serveExecResult struct {
+	ExitCode int `json:"exit_code"`
+}
+
+// handle runs one method, sending its output to out.
+func (s *serveServer) handle(ctx context.Context, method string, raw json.RawMessage, out io.Writer) (any, error) {
+	decode := func(v any) error {
+		if len(raw) == 0 {
+			raw = json.RawMessage("{}")
+		}
+		if err := json.Unmarshal(raw, v); err != nil {
+			return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch method {
+	case "up":
+		var p serveUpParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		dir, err := p.projectDir(s.host.Fs)
+		if err != nil {
+			return nil, err
+		}
+		defer s.lockProject(dir)()
+		if err := sandbox.CheckVersionSkew(s.host.Fs, dir, out); err != nil {
+			return nil, err
+		}
+		return struct{}{}, sandbox.Up(ctx, s.host, dir, sandbox.UpOptions{Force: p.Force, Resume: p.Resume, Set: p.Set, AssumeYes: p.Yes, PullPolicy: p.PullPolicy, Out: out})
+
+	case "down":
+		var p serveDownParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		dir, err := p.projectDir(s.host.Fs)
+		if err != nil {
+			return nil, err
+		}
+		defer s.lockProject(dir)()
+		if err := sandbox.CheckVersionSkew(s.host.Fs, dir, out); err != nil {
+			return nil, err
+		}
+		return struct{}{}, sandbox.Down(ctx, s.host, dir, sandbox.DownOptions{Force: p.Force, Out: out})
+
+	case "status":
+		var p serveProjectParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		dir, err := p.projectDir(s.host.Fs)
+		if err != nil {
+			return nil, err
+		}
+		return serveProjectStatus(ctx, s.host, dir)
+
+	case "exec":
+		var p serveExecParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		if len(p.Command) == 0 {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "command is empty"}
+		}
+		dir, err := p.projectDir(s.host.Fs)
+		if err != nil {
+			return nil, err
+		}
+		code, err := execStreaming(ctx, s.host, dir, p.Command, p.User, out)
+		if err != nil {
+			return nil, err
+		}
+		return serveExecResult{ExitCode: code}, nil
+
+	case "logs":
+		var p serveLogsParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		dir, err := p.projectDir(s.host.Fs)
+		if err != nil {
+			return nil, err
+		}
+		opts := runtime.LogsOptions{Follow: p.Follow, Tail: p.Tail, Since: p.Since, Timestamps: p.Timestamps}
+		return struct{}{}, serveLogs(ctx, s.host, dir, opts, out)
+
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "unknown method " + method}
+	}
+}
+
+// serveProjectStatus is Status as the status result.
+func serveProjectStatus(ctx context.Context, host *util.Env, dir string) (*serveStatus, error) {
+	ps, err := sandbox.Status(ctx, host, dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range []error{ps.RuntimeErr, ps.StateErr, ps.ContainerErr} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := &serveStatus{Initialized: ps.Initialized, Runtime: ps.Runtime}
+	if ps.State == nil {
+		return s, nil
+	}
+	s.ProjectID = ps.State.ProjectID
+	c := ps.Container
+	s.Container = &serveContainer{State: c.State, Name: c.Name, ID: c.ID, Image: c.Image, StartedAt: c.StartedAt}
+	return s, nil
+}
+
+// execStreaming runs command in the project's running container without a
+// terminal, writing its stdout and stderr to out, and records it in the
+// project history. Returns the command's exit code.
+func execStreaming(ctx context.Context, host *util.Env, dir string, command []string, user string, out io.Writer) (int, error) {
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, dir)
+	if err != nil {
+		return 0, err
+	}
+	st, err := sandbox.LoadRequiredState(env, dir)
+	if err != nil {
+		return 0, err
+	}
+	if err := sandbox.CheckProjectPathConsistency(ctx, runtimeEnv, rt, st, dir, cfg); err != nil {
+		return 0, err
+	}
+	if user != "" {
+		cfg.Commands.Enter.User = user
+	}
+
+	status, err := rt.Status(ctx, runtimeEnv, dir, st)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get container status: %w", err)
+	}
+	switch status.State {
+	case runtime.StateRunning:
+	case runtime.StatePaused:
+		return 0, errors.New(sandbox.ErrMsgPaused)
+	default:
+		return 0, errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	quoted := make([]string, 0, len(command))
+	for _, arg := range sandbox.WrapWithEnter(cfg, command) {
+		quoted = append(quoted, sandbox.ShellQuote(arg))
+	}
+
+	startedAt := time.Now()
+	endUse := sandbox.TrackUse(host, dir)
+	err = rt.RunTask(ctx, runtimeEnv, sandbox.SessionConfig(cfg, dir, ""), status.Name, strings.Join(quoted, " "), out)
+	endUse()
+	sandbox.RecordHistory(host.Fs, dir, startedAt, command, err)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute command: %w", err)
+	}
+	return 0, nil
+}
+
+// serveLogs writes the project's container logs to out.
+func serveLogs(ctx context.Context, host *util.Env, dir string, opts runtime.LogsOptions, out io.Writer) error {
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	_, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, dir)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, dir)
+	if err != nil {
+		return err
+	}
+	return streamLogs(ctx, host.Fs, runtimeEnv, rt, dir, st, opts, logsOutput{}, out)
+}
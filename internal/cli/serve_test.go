@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// serveClient is a test client of alca serve.
+type serveClient struct {
+	t      *testing.T
+	conn   net.Conn
+	enc    *json.Encoder
+	dec    *json.Decoder
+	nextID int
+}
+
+// startServe serves on a fresh socket until the test ends and returns a
+// connected client and the token.
+func startServe(t *testing.T) (*serveClient, string) {
+	t.Helper()
+	fs := afero.NewOsFs()
+	// Unix socket paths are limited to ~100 bytes, too few for t.TempDir
+	dir, err := afero.TempDir(fs, "", "alca-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = fs.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, serveSocketFile)
+
+	ln, token, err := listenServe(fs, socketPath)
+	if err != nil {
+		t.Fatalf("listenServe: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		newServeServer(newHostEnv(), token).serve(ctx, ln)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	data, err := afero.ReadFile(fs, socketPath+serveTokenSuffix)
+	if err != nil || strings.TrimSpace(string(data)) != token {
+		t.Fatalf("token file = %q, %v", data, err)
+	}
+	for _, p := range []string{socketPath, socketPath + serveTokenSuffix} {
+		if info, err := fs.Stat(p); err != nil || info.Mode().Perm() != 0o600 {
+			t.Fatalf("%s mode = %v, %v", p, info.Mode().Perm(), err)
+		}
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &serveClient{t: t, conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}, token
+}
+
+// call sends a request and returns its response and the output sent for it.
+func (c *serveClient) call(method string, params any) (rpcResponse, string) {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(strings.TrimSpace(string(mustJSON(c.t, c.nextID))))
+	if err := c.enc.Encode(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		c.t.Fatal(err)
+	}
+	var output strings.Builder
+	for {
+		var msg struct {
+			rpcResponse
+			Method string      `json:"method"`
+			Params serveOutput `json:"params"`
+		}
+		if err := c.dec.Decode(&msg); err != nil {
+			c.t.Fatalf("%s: read: %v", method, err)
+		}
+		if msg.Method == "output" {
+			output.WriteString(msg.Params.Data)
+			continue
+		}
+		if string(msg.ID) != string(id) {
+			c.t.Fatalf("%s: response id %s, want %s", method, msg.ID, id)
+		}
+		return msg.rpcResponse, output.String()
+	}
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestServe_RequiresAuth(t *testing.T) {
+	_, dir := setupFakeProject(t, fakeProjectConfig)
+	c, _ := startServe(t)
+
+	resp, _ := c.call("status", map[string]any{"project": dir})
+	if resp.Error == nil || resp.Error.Code != rpcUnauthorized {
+		t.Fatalf("status before auth = %+v", resp)
+	}
+
+	resp, _ = c.call("auth", map[string]any{"token": "wrong"})
+	if resp.Error == nil || resp.Error.Code != rpcUnauthorized {
+		t.Fatalf("auth with wrong token = %+v", resp)
+	}
+	var msg json.RawMessage
+	if err := c.dec.Decode(&msg); err == nil {
+		t.Errorf("connection still open after a wrong token, got %s", msg)
+	}
+}
+
+func TestServe_UpStatusExecDown(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+	c, token := startServe(t)
+
+	if resp, _ := c.call("auth", map[string]any{"token": token}); resp.Error != nil {
+		t.Fatalf("auth: %v", resp.Error)
+	}
+
+	resp, out := c.call("up", map[string]any{"project": dir, "yes": true})
+	if resp.Error != nil {
+		t.Fatalf("up: %v", resp.Error)
+	}
+	if !strings.Contains(out, "Environment ready") {
+		t.Errorf("up output = %q", out)
+	}
+
+	// A subdirectory resolves to its project, as with the CLI
+	sub := filepath.Join(dir, "src")
+	if err := afero.NewOsFs().Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	resp, _ = c.call("status", map[string]any{"project": sub})
+	if resp.Error != nil {
+		t.Fatalf("status: %v", resp.Error)
+	}
+	var status serveStatus
+	if err := json.Unmarshal(mustJSON(t, resp.Result), &status); err != nil {
+		t.Fatal(err)
+	}
+	st := loadFakeState(t, dir)
+	if status.ProjectID != st.ProjectID || status.Container == nil || status.Container.State != "running" {
+		t.Fatalf("status = %+v", status)
+	}
+
+	resp, _ = c.call("exec", map[string]any{"project": dir, "command": []string{"echo", "a b"}})
+	if resp.Error != nil {
+		t.Fatalf("exec: %v", resp.Error)
+	}
+	if got := string(mustJSON(t, resp.Result)); got != `{"exit_code":0}` {
+		t.Errorf("exec result = %s", got)
+	}
+	execs := fake.Container(st.ContainerName).Execs
+	if last := strings.Join(execs[len(execs)-1], " "); last != "sh -c 'echo' 'a b'" {
+		t.Errorf("last exec = %q", last)
+	}
+
+	if resp, _ := c.call("down", map[string]any{"project": dir}); resp.Error != nil {
+		t.Fatalf("down: %v", resp.Error)
+	}
+	if fake.Container(st.ContainerName) != nil {
+		t.Error("container still exists after down")
+	}
+}
+
+func TestServe_RejectsBadRequests(t *testing.T) {
+	setupFakeProject(t, fakeProjectConfig)
+	c, token := startServe(t)
+	if resp, _ := c.call("auth", map[string]any{"token": token}); resp.Error != nil {
+		t.Fatalf("auth: %v", resp.Error)
+	}
+
+	tests := []struct {
+		method string
+		params any
+		code   int
+	}{
+		{"reboot", map[string]any{}, rpcMethodNotFound},
+		{"status", map[string]any{"project": "relative/dir"}, rpcInvalidParams},
+		{"status", map[string]any{"project": t.TempDir()}, rpcInvalidParams},
+		{"exec", map[string]any{"project": "/", "command": []string{}}, rpcInvalidParams},
+	}
+	for _, tt := range tests {
+		resp, _ := c.call(tt.method, tt.params)
+		if resp.Error == nil || resp.Error.Code != tt.code {
+			t.Errorf("%s %v = %+v, want code %d", tt.method, tt.params, resp, tt.code)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+// Shells supported by the shellenv command.
+const (
+	shellenvShellPOSIX = "sh"
+	shellenvShellFish  = "fish"
+)
+
+var shellenvCmd = &cobra.Command{
+	Use:   "shellenv",
+	Short: "Print shell code exporting the project's variables and helpers",
+	Long: `Print shell code for the host shell that exports the project's
+variables and defines functions wrapping alca enter and alca run, so they
+work from any directory.
+
+Exported variables:
+  ALCA_PROJECT_DIR     the project directory
+  ALCA_PROJECT_ID      the project ID from .alca/state.json
+  ALCA_CONTAINER_NAME  the container name, e.g. for docker exec
+  DOCKER_HOST          the Podman API socket, when the project runs on
+                       Podman on Linux
+  DOCKER_CONTEXT       runtime_context, when set (with CONTAINER_CONNECTION)
+
+Functions: alca_enter and alca_run run the commands in the project
+directory with their arguments passed through.
+
+  eval "$(alca shellenv)"                # bash, zsh; also in .envrc
+  alca shellenv --shell fish | source    # fish`,
+	Args: cobra.NoArgs,
+	RunE: runShellenv,
+}
+
+func init() {
+	shellenvCmd.Flags().String("shell", shellenvShellPOSIX, "Shell syntax to print (sh for bash/zsh/sh, fish)")
+}
+
+// shellenvVar is one exported variable, kept in output order.
+type shellenvVar struct {
+	Name  string
+	Value string
+}
+
+// runShellenv prints the shell code for the current project.
+func runShellenv(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	shell, _ := cmd.Flags().GetString("shell")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIReadDeps()
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	return writeShellenv(os.Stdout, shell, cwd, shellenvVars(ctx, runtimeEnv, rt, cfg, cwd, st))
+}
+
+// shellenvVars returns the variables to export for the project.
+func shellenvVars(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, cwd string, st *state.State) []shellenvVar {
+	vars := []shellenvVar{
+		{"ALCA_PROJECT_DIR", cwd},
+		{"ALCA_PROJECT_ID", st.ProjectID},
+		{"ALCA_CONTAINER_NAME", st.ContainerName},
+	}
+	if host := runtime.DockerHost(ctx, runtimeEnv, rt); host != "" {
+		vars = append(vars, shellenvVar{runtime.EnvDockerHost, host})
+	}
+	if cfg.RuntimeContext != "" {
+		vars = append(vars,
+			shellenvVar{runtime.EnvDockerContext, cfg.RuntimeContext},
+			shellenvVar{runtime.EnvPodmanConnection, cfg.RuntimeContext})
+	}
+	return vars
+}
+
+// writeShellenv writes the exports and wrapper functions in shell syntax.
+func writeShellenv(w io.Writer, shell, projectDir string, vars []shellenvVar) error {
+	switch shell {
+	case shellenvShellPOSIX:
+		for _, v := range vars {
+			_, _ = fmt.Fprintf(w, "export %s=%s\n", v.Name, sandbox.ShellQuote(v.Value))
+		}
+		for _, sub := range []string{"enter", "run"} {
+			_, _ = fmt.Fprintf(w, "alca_%s() { (cd %s && command alca %s \"$@\"); }\n", sub, sandbox.ShellQuote(projectDir), sub)
+		}
+	case shellenvShellFish:
+		for _, v := range vars {
+			_, _ = fmt.Fprintf(w, "set -gx %s %s\n", v.Name, fishQuote(v.Value))
+		}
+		for _, sub := range []string{"enter", "run"} {
+			_, _ = fmt.Fprintf(w, "function alca_%s; set -l d $PWD; cd %s; or return; command alca %s $argv; set -l s $status; cd $d; return $s; end\n", sub, fishQuote(projectDir), sub)
+		}
+	default:
+		return fmt.Errorf("unknown shell %q, valid options: %s", shell,
+			strings.Join([]string{shellenvShellPOSIX, shellenvShellFish}, ", "))
+	}
+	return nil
+}
+
+// fishQuote quotes s for fish, where only \ and ' are special inside
+// single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
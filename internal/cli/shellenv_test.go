@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestWriteShellenv(t *testing.T) {
+	vars := []shellenvVar{
+		{"ALCA_PROJECT_DIR", "/home/me/it's"},
+		{"ALCA_CONTAINER_NAME", "alca-1234"},
+	}
+
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{
+			shell: shellenvShellPOSIX,
+			want: "export ALCA_PROJECT_DIR='/home/me/it'\\''s'\n" +
+				"export ALCA_CONTAINER_NAME='alca-1234'\n" +
+				"alca_enter() { (cd '/home/me/it'\\''s' && command alca enter \"$@\"); }\n" +
+				"alca_run() { (cd '/home/me/it'\\''s' && command alca run \"$@\"); }\n",
+		},
+		{
+			shell: shellenvShellFish,
+			want: "set -gx ALCA_PROJECT_DIR '/home/me/it\\'s'\n" +
+				"set -gx ALCA_CONTAINER_NAME 'alca-1234'\n" +
+				"function alca_enter; set -l d $PWD; cd '/home/me/it\\'s'; or return; command alca enter $argv; set -l s $status; cd $d; return $s; end\n" +
+				"function alca_run; set -l d $PWD; cd '/home/me/it\\'s'; or return; command alca run $argv; set -l s $status; cd $d; return $s; end\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeShellenv(&buf, tt.shell, "/home/me/it's", vars); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteShellenv_UnknownShell(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeShellenv(&buf, "powershell", "/p", nil); err == nil {
+		t.Error("expected error for unknown shell")
+	}
+}
+
+func TestShellenvVars(t *testing.T) {
+	st := &state.State{ProjectID: "1234-abcd", ContainerName: "alca-1234"}
+	runtimeEnv := runtime.NewRuntimeEnv(util.NewMockCommandRunner())
+
+	var names []string
+	for _, v := range shellenvVars(context.Background(), runtimeEnv, runtime.NewDocker(), &config.Config{RuntimeContext: "builder"}, "/p", st) {
+		names = append(names, v.Name+"="+v.Value)
+	}
+	want := "ALCA_PROJECT_DIR=/p ALCA_PROJECT_ID=1234-abcd ALCA_CONTAINER_NAME=alca-1234 DOCKER_CONTEXT=builder CONTAINER_CONNECTION=builder"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("vars = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Print an SSH client config entry for the container",
+	Long: `Print a Host entry for ~/.ssh/config that reaches the container's SSH
+server, for tools that only speak SSH (some IDEs, rsync, ansible).
+
+Requires ssh.enabled = true in .alca.toml. alca up then publishes the server
+on 127.0.0.1 and authorizes a per-project key kept in .alca/ssh/. Sessions
+log in as the enter user (user or commands.enter.user, else root) and get a
+login environment: envs from .alca.toml are not set.
+
+  alca ssh-config >> ~/.ssh/config
+  ssh alca-myproject`,
+	Args: cobra.NoArgs,
+	RunE: runSSHConfig,
+}
+
+func init() {
+	sshConfigCmd.Flags().String("host", "", "Host alias to write (default alca-<project directory name>)")
+}
+
+// runSSHConfig prints the SSH client config for the project container.
+func runSSHConfig(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	host, _ := cmd.Flags().GetString("host")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIReadDeps()
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	cfg, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	return writeSSHConfig(ctx, runtimeEnv, rt, cfg, cwd, st, host, os.Stdout)
+}
+
+// writeSSHConfig writes the Host entry for the running container to out.
+func writeSSHConfig(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, cwd string, st *state.State, host string, out io.Writer) error {
+	if !cfg.SSH.Enabled {
+		return fmt.Errorf("SSH is not enabled: set ssh.enabled = true in %s and run 'alca up'", sandbox.ConfigFilename)
+	}
+
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State != runtime.StateRunning {
+		return errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	port, err := rt.PublishedPort(ctx, runtimeEnv, status.Name, runtime.SSHContainerPort)
+	if errors.Is(err, runtime.ErrPortNotPublished) {
+		return errors.New("the container was created before ssh was enabled: run 'alca up' to recreate it")
+	}
+	if err != nil {
+		return err
+	}
+
+	if host == "" {
+		host = "alca-" + filepath.Base(cwd)
+	}
+	// The host key changes whenever the container is recreated, so it is
+	// not pinned; the server only listens on the host's loopback.
+	_, _ = fmt.Fprintf(out, `Host %s
+  HostName 127.0.0.1
+  Port %d
+  User %s
+  IdentityFile "%s"
+  IdentitiesOnly yes
+  StrictHostKeyChecking no
+  UserKnownHostsFile /dev/null
+  LogLevel ERROR
+`, host, port, runtime.SSHUser(cfg), runtime.SSHKeyPath(cwd))
+	return nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+func TestWriteSSHConfig(t *testing.T) {
+	fake, dir := setupFakeProject(t, "image = \"alpine:3\"\nuser = \"dev\"\n\n[ssh]\nenabled = true\nport = 2200\n")
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	cfg := &config.Config{User: "dev", SSH: config.SSH{Enabled: true, Port: 2200}}
+
+	var out bytes.Buffer
+	if err := writeSSHConfig(context.Background(), runtime.NewRuntimeEnv(nil), fake, cfg, dir, st, "sandbox", &out); err != nil {
+		t.Fatalf("writeSSHConfig() error: %v", err)
+	}
+	for _, want := range []string{"Host sandbox\n", "Port 2200\n", "User dev\n", `IdentityFile "` + runtime.SSHKeyPath(dir) + `"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("ssh config missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteSSHConfig_Errors(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	env := runtime.NewRuntimeEnv(nil)
+
+	err := writeSSHConfig(context.Background(), env, fake, &config.Config{}, dir, st, "", &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "ssh.enabled") {
+		t.Errorf("disabled ssh error = %v", err)
+	}
+
+	// Enabled after the container was created: it must be recreated
+	err = writeSSHConfig(context.Background(), env, fake, &config.Config{SSH: config.SSH{Enabled: true}}, dir, st, "", &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "alca up") {
+		t.Errorf("unpublished port error = %v", err)
+	}
+}
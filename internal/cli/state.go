@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Inspect and maintain the project state file",
+}
+
+var stateRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Recover a corrupted or missing .alca/state.json",
+	Long: `Recover .alca/state.json when it is corrupted or missing.
+
+The newest valid backup (state.json.bak, .bak.1, ...) is restored first.
+Without one, the state is rebuilt from the labels of this project's container
+(project ID, path and version). A state rebuilt from labels has no record of
+the config the container was created with, so drift detection resumes after
+the next rebuild.`,
+	Args: cobra.NoArgs,
+	RunE: runStateRepair,
+}
+
+func init() {
+	stateCmd.AddCommand(stateRepairCmd)
+}
+
+// runStateRepair restores or reconstructs the state file.
+func runStateRepair(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	out := os.Stdout
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIDeps()
+	tfs, env, runtimeEnv := deps.Tfs, deps.Env, deps.RuntimeEnv
+
+	listContainers := func() (string, []runtime.ContainerInfo, error) {
+		_, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+		if err != nil {
+			return "", nil, err
+		}
+		containers, err := rt.ListContainers(ctx, runtimeEnv)
+		return rt.Name(), containers, err
+	}
+
+	repaired, err := repairState(env, cwd, listContainers, out, time.Now())
+	if err != nil || !repaired {
+		return err
+	}
+	return sandbox.CommitWithSudo(ctx, env, tfs, out, "")
+}
+
+// repairState restores the newest valid backup or rebuilds state from the
+// labels of the project's container. listContainers is only called when no
+// backup is usable. Returns false if the state file was already healthy.
+func repairState(env *util.Env, cwd string, listContainers func() (string, []runtime.ContainerInfo, error), out io.Writer, now time.Time) (bool, error) {
+	current, err := state.Load(env, cwd)
+	if err == nil && current != nil {
+		_, _ = fmt.Fprintln(out, "State file is healthy; nothing to repair.")
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, state.ErrStateCorrupted) {
+		return false, err
+	}
+
+	for n := range state.BackupCount {
+		backup, err := state.LoadBackup(env, cwd, n)
+		if err != nil || backup == nil {
+			continue
+		}
+		if err := state.Save(env, cwd, backup); err != nil {
+			return false, err
+		}
+		_, _ = fmt.Fprintf(out, "Restored state from %s\n", state.BackupFilePath(cwd, n))
+		return true, nil
+	}
+
+	runtimeName, containers, err := listContainers()
+	if err != nil {
+		return false, fmt.Errorf("no usable backup, and listing containers failed: %w", err)
+	}
+
+	var matches []runtime.ContainerInfo
+	for _, c := range containers {
+		if c.ProjectPath == cwd {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return false, fmt.Errorf("no usable backup and no container labelled with %s; remove %s and run 'alca up' to start fresh",
+			cwd, state.StateFilePath(cwd))
+	case 1:
+	default:
+		names := make([]string, len(matches))
+		for i, c := range matches {
+			names[i] = c.Name
+		}
+		return false, fmt.Errorf("several containers are labelled with %s (%s); remove the stale ones with 'alca cleanup' first",
+			cwd, strings.Join(names, ", "))
+	}
+
+	c := matches[0]
+	if c.Version != "" && c.Version != state.CurrentVersion {
+		return false, fmt.Errorf("container %s was created by alca state version %s, this alca uses %s", c.Name, c.Version, state.CurrentVersion)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, c.CreatedAt)
+	if err != nil {
+		createdAt = now
+	}
+	st := &state.State{
+		ProjectID:     c.ProjectID,
+		ContainerName: c.Name,
+		CreatedAt:     createdAt,
+		Runtime:       runtimeName,
+	}
+	if err := state.Save(env, cwd, st); err != nil {
+		return false, err
+	}
+	_, _ = fmt.Fprintf(out, "Rebuilt state from the labels of container %s\n", c.Name)
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestRepairState(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	noContainers := func() (string, []runtime.ContainerInfo, error) {
+		t.Error("containers should not be listed")
+		return "", nil, nil
+	}
+	corrupt := func(t *testing.T, env *util.Env) {
+		t.Helper()
+		if err := afero.WriteFile(env.Fs, state.StateFilePath("/p"), []byte("{trunc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("healthy state is left alone", func(t *testing.T) {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		_ = state.Save(env, "/p", &state.State{ProjectID: "abc"})
+
+		var out bytes.Buffer
+		repaired, err := repairState(env, "/p", noContainers, &out, now)
+		if err != nil || repaired {
+			t.Errorf("repairState() = %v, %v; want false, nil", repaired, err)
+		}
+	})
+
+	t.Run("restores newest backup", func(t *testing.T) {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		_ = state.Save(env, "/p", &state.State{ProjectID: "abc"})
+		_ = state.Save(env, "/p", &state.State{ProjectID: "abc", ContainerName: "alca-abc"})
+		corrupt(t, env)
+
+		var out bytes.Buffer
+		repaired, err := repairState(env, "/p", noContainers, &out, now)
+		if err != nil || !repaired {
+			t.Fatalf("repairState() = %v, %v; want true, nil", repaired, err)
+		}
+		st, err := state.Load(env, "/p")
+		if err != nil || st.ProjectID != "abc" {
+			t.Errorf("Load() after repair = %+v, %v", st, err)
+		}
+	})
+
+	t.Run("rebuilds from container labels", func(t *testing.T) {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		corrupt(t, env)
+		list := func() (string, []runtime.ContainerInfo, error) {
+			return "Docker", []runtime.ContainerInfo{
+				{Name: "alca-other", ProjectID: "zzz", ProjectPath: "/elsewhere"},
+				{Name: "alca-abc", ProjectID: "abc", ProjectPath: "/p", Version: state.CurrentVersion, CreatedAt: "2025-06-01T10:00:00Z"},
+			}, nil
+		}
+
+		var out bytes.Buffer
+		repaired, err := repairState(env, "/p", list, &out, now)
+		if err != nil || !repaired {
+			t.Fatalf("repairState() = %v, %v; want true, nil", repaired, err)
+		}
+		st, err := state.Load(env, "/p")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.ProjectID != "abc" || st.ContainerName != "alca-abc" || st.Runtime != "Docker" {
+			t.Errorf("rebuilt state = %+v", st)
+		}
+		if !st.CreatedAt.Equal(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("CreatedAt = %v, want container creation time", st.CreatedAt)
+		}
+	})
+
+	t.Run("missing state without container", func(t *testing.T) {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		list := func() (string, []runtime.ContainerInfo, error) { return "Docker", nil, nil }
+
+		_, err := repairState(env, "/p", list, &bytes.Buffer{}, now)
+		if err == nil || !strings.Contains(err.Error(), "start fresh") {
+			t.Errorf("repairState() error = %v, want start fresh hint", err)
+		}
+	})
+
+	t.Run("listing failure", func(t *testing.T) {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		boom := errors.New("daemon down")
+		list := func() (string, []runtime.ContainerInfo, error) { return "", nil, boom }
+
+		_, err := repairState(env, "/p", list, &bytes.Buffer{}, now)
+		if !errors.Is(err, boom) {
+			t.Errorf("repairState() error = %v, want %v", err, boom)
+		}
+	})
+}
@@ -3,13 +3,12 @@ package cli
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
-	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
 	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/state"
 	"github.com/bolasblack/alcatraz/internal/sync"
 )
@@ -17,8 +16,11 @@ import (
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show current Alcatraz status",
-	Long:  `Display the current status of Alcatraz sandbox configuration and running processes.`,
-	RunE:  runStatus,
+	Long: `Display the current status of Alcatraz sandbox configuration and running processes.
+
+Recent container events (out-of-memory kills, crashes and restarts) are
+shown below the status.`,
+	RunE: runStatus,
 }
 
 // runStatus displays container status.
@@ -30,14 +32,13 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Create shared dependencies once
-	deps := newCLIReadDeps()
-	env, runtimeEnv := deps.Env, deps.RuntimeEnv
-
-	configPath := filepath.Join(cwd, ConfigFilename)
+	host := newHostEnv()
+	ps, err := sandbox.Status(ctx, host, cwd, os.Stderr)
+	if err != nil {
+		return err
+	}
 
-	// Check if config exists
-	if _, err := env.Fs.Stat(configPath); os.IsNotExist(err) {
+	if !ps.Initialized {
 		fmt.Println("Status: Not initialized")
 		fmt.Println("")
 		fmt.Println("Run 'alca init' to create a configuration file.")
@@ -45,67 +46,62 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Println("Status: Initialized")
-	fmt.Printf("Config: %s\n", configPath)
+	fmt.Printf("Config: %s\n", ps.ConfigPath)
 	fmt.Println("")
 
-	// Load config
-	cfg, err := config.LoadConfig(env, configPath, config.StrictExpandEnv)
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
-	// Select runtime
-	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, &cfg)
-	if err != nil {
+	if ps.RuntimeErr != nil {
 		fmt.Println("Runtime: None available")
 		fmt.Println("")
-		fmt.Printf("Error: %v\n", err)
+		fmt.Printf("Error: %v\n", ps.RuntimeErr)
 		return nil
 	}
 
-	fmt.Printf("Runtime: %s\n", rt.Name())
+	fmt.Printf("Runtime: %s\n", ps.Runtime)
 	fmt.Println("")
 
-	// Load state (optional for status)
-	st, err := state.Load(env, cwd)
-	if err != nil {
-		fmt.Printf("State: Error loading state: %v\n", err)
+	if ps.StateErr != nil {
+		fmt.Printf("State: Error loading state: %v\n", ps.StateErr)
 		return nil
 	}
 
-	if st == nil {
+	if ps.State == nil {
 		fmt.Println("State: Not created")
 		fmt.Println("")
 		fmt.Println("Run 'alca up' to create the container.")
 		return nil
 	}
 
-	fmt.Printf("Project ID: %s\n", st.ProjectID)
+	fmt.Printf("Project ID: %s\n", ps.State.ProjectID)
 	fmt.Println("")
 
-	// Get container status
-	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
-	if err != nil {
+	if ps.ContainerErr != nil {
 		fmt.Println("Container: Error getting status")
 		return nil
 	}
 
-	printContainerStatus(status, st, &cfg, rt)
+	printContainerStatus(ps.Container, ps.State, ps.Config, ps.Runtime)
+	printRecentEvents(os.Stdout, ps.State.Events)
 
 	// Show sync conflict banner if container is running (AGD-031).
-	if status.State == runtime.StateRunning {
-		syncEnv := sync.NewSyncEnv(afero.NewOsFs(), deps.CmdRunner, runtime.NewMutagenSyncClient(runtimeEnv))
-		showSyncBanner(ctx, syncEnv, st.ProjectID, cwd, os.Stderr)
+	if ps.Container.State == runtime.StateRunning {
+		deps := sandbox.NewReadDeps(host)
+		printSyncPaused(ctx, deps.RuntimeEnv, ps.State.ProjectID, os.Stdout)
+		syncEnv := sync.NewSyncEnv(host.Fs, deps.CmdRunner, runtime.NewMutagenSyncClient(deps.RuntimeEnv))
+		sandbox.ShowSyncBanner(ctx, syncEnv, ps.State.ProjectID, cwd, os.Stderr)
 	}
 
 	return nil
 }
 
 // printContainerStatus prints container status with drift detection.
-func printContainerStatus(status runtime.ContainerStatus, st *state.State, cfg *config.Config, rt runtime.Runtime) {
+func printContainerStatus(status runtime.ContainerStatus, st *state.State, cfg *config.Config, rtName string) {
 	switch status.State {
 	case runtime.StateRunning:
-		printRunningContainerStatus(status, st, cfg, rt)
+		printRunningContainerStatus(status, st, cfg, rtName)
+	case runtime.StatePaused:
+		fmt.Printf("Container: Paused (%s)\n", status.Name)
+		fmt.Println("")
+		fmt.Println("Run 'alca resume' to continue it.")
 	case runtime.StateStopped:
 		fmt.Println("Container: Stopped")
 		fmt.Println("")
@@ -120,7 +116,7 @@ func printContainerStatus(status runtime.ContainerStatus, st *state.State, cfg *
 }
 
 // printRunningContainerStatus prints status for a running container.
-func printRunningContainerStatus(status runtime.ContainerStatus, st *state.State, cfg *config.Config, rt runtime.Runtime) {
+func printRunningContainerStatus(status runtime.ContainerStatus, st *state.State, cfg *config.Config, rtName string) {
 	fmt.Println("Container: Running")
 	fmt.Printf("  ID:    %s\n", status.ID)
 	fmt.Printf("  Name:  %s\n", status.Name)
@@ -128,16 +124,43 @@ func printRunningContainerStatus(status runtime.ContainerStatus, st *state.State
 	if status.StartedAt != "" {
 		fmt.Printf("  Started: %s\n", status.StartedAt)
 	}
+	// The level the container was created with; drift below shows changes.
+	applied := cfg
+	if st.Config != nil {
+		applied = st.Config
+	}
+	fmt.Printf("  Network: %s\n", describeIsolation(applied.Network))
+	if mode := applied.NestedContainers.OrNone(); mode != config.NestedContainersNone {
+		fmt.Printf("  Nested containers: %s\n", mode)
+	}
 	fmt.Println("")
 
 	// Check for configuration drift
-	runtimeChanged := st.Runtime != rt.Name()
+	runtimeChanged := st.Runtime != rtName
 	drift := st.DetectConfigDrift(cfg)
-	if displayConfigDrift(os.Stdout, drift, runtimeChanged, st.Runtime, rt.Name()) {
+	if sandbox.DisplayConfigDrift(os.Stdout, drift, runtimeChanged, st.Runtime, rtName) {
 		fmt.Println("")
-		fmt.Println("Run 'alca up -f' to rebuild with new configuration.")
+		if runtimeChanged || drift.RequiresRecreate() {
+			fmt.Println("Run 'alca up -f' to rebuild with new configuration.")
+		} else {
+			fmt.Println("Run 'alca up' to apply the changes in place.")
+		}
 		fmt.Println("")
 	}
 
 	fmt.Println("Run 'alca run <command>' to execute commands.")
 }
+
+// describeIsolation words the effective network isolation level for status.
+func describeIsolation(n config.Network) string {
+	switch level := n.IsolationLevel(); level {
+	case config.IsolationNone:
+		return "none (no network)"
+	case config.IsolationDefault:
+		return "default (private ranges blocked)"
+	case config.IsolationStrict:
+		return "strict (egress limited to lan-access and proxy)"
+	default:
+		return level + " (lan-access and proxy as configured)"
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Control the project's file sync sessions",
+	Long: `Control the Mutagen file sync sessions of the current project.
+
+Pause sync before heavy host operations (checking out a large branch,
+regenerating build outputs) so half-finished changes are not propagated
+into the container, then resume it to reconcile both sides in one pass.
+'alca status' shows when sync is paused.`,
+}
+
+var syncPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause file sync for the current project",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSyncSetPaused(cmd, true)
+	},
+}
+
+var syncResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume paused file sync for the current project",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSyncSetPaused(cmd, false)
+	},
+}
+
+func init() {
+	syncCmd.AddCommand(syncPauseCmd)
+	syncCmd.AddCommand(syncResumeCmd)
+}
+
+func runSyncSetPaused(cmd *cobra.Command, pause bool) error {
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	deps := newCLIReadDeps()
+	st, err := sandbox.LoadRequiredState(deps.Env, cwd)
+	if err != nil {
+		return err
+	}
+
+	return setProjectSyncsPaused(cmd.Context(), deps.RuntimeEnv, st.ProjectID, pause, os.Stdout)
+}
+
+// setProjectSyncsPaused pauses or resumes every sync session of a project.
+// Projects without sessions (no mount excludes) are reported, not rejected.
+func setProjectSyncsPaused(ctx context.Context, env *runtime.RuntimeEnv, projectID string, pause bool, out io.Writer) error {
+	action, verb := runtime.ResumeProjectSyncs, "resume"
+	if pause {
+		action, verb = runtime.PauseProjectSyncs, "pause"
+	}
+
+	sessions, err := action(ctx, env, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to %s file sync: %w", verb, err)
+	}
+	if len(sessions) == 0 {
+		util.ProgressStep(out, "No file sync sessions for this project\n")
+		return nil
+	}
+
+	util.ProgressDone(out, "File sync %sd (%d session(s))\n", verb, len(sessions))
+	return nil
+}
@@ -30,17 +30,12 @@ func (e *dockerContainerExecutor) ExecInContainer(ctx context.Context, container
 	return nil
 }
 
-// showSyncBanner detects conflicts synchronously and shows the banner on w.
-// Uses synchronous update because the process exits immediately after status —
-// an async goroutine would be killed before it finishes writing the cache.
-// Best-effort: errors are logged to w but do not block the command.
-func showSyncBanner(ctx context.Context, syncEnv *sync.SyncEnv, projectID string, projectRoot string, w io.Writer) {
-	cache, err := sync.SyncUpdateCache(ctx, syncEnv, projectID, projectRoot)
-	if err != nil {
-		_, _ = fmt.Fprintf(w, "Warning: failed to check sync conflicts: %v\n", err)
+// printSyncPaused notes on w when the project's file sync is paused.
+// Best-effort: a missing or failing mutagen prints nothing.
+func printSyncPaused(ctx context.Context, env *runtime.RuntimeEnv, projectID string, w io.Writer) {
+	paused, err := runtime.PausedProjectSyncs(ctx, env, projectID)
+	if err != nil || len(paused) == 0 {
 		return
 	}
-	if cache != nil && len(cache.Conflicts) > 0 {
-		sync.RenderBanner(cache.Conflicts, w)
-	}
+	_, _ = fmt.Fprintf(w, "Sync: paused (%d session(s)), run 'alca sync resume' to resume\n", len(paused))
 }
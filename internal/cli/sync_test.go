@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const listSyncsKey = `mutagen sync list --template={{range .}}{{.Name}}{{"\n"}}{{end}}`
+
+func TestSetProjectSyncsPaused(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(listSyncsKey, []byte("alca-p1-0\nalca-p1-1\n"))
+	cmd.ExpectSuccess("mutagen sync pause alca-p1-0", nil)
+	cmd.ExpectSuccess("mutagen sync pause alca-p1-1", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	var out bytes.Buffer
+	if err := setProjectSyncsPaused(context.Background(), runtime.NewRuntimeEnv(cmd), "p1", true, &out); err != nil {
+		t.Fatalf("setProjectSyncsPaused() unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "File sync paused (2 session(s))") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+func TestSetProjectSyncsPaused_NoSessions(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(listSyncsKey, []byte(""))
+
+	var out bytes.Buffer
+	if err := setProjectSyncsPaused(context.Background(), runtime.NewRuntimeEnv(cmd), "p1", false, &out); err != nil {
+		t.Fatalf("setProjectSyncsPaused() unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "No file sync sessions") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+func TestPrintSyncPaused(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(`mutagen sync list --template={{range .}}{{.Name}} {{.Paused}}{{"\n"}}{{end}}`, []byte("alca-p1-0 true\n"))
+
+	var out bytes.Buffer
+	printSyncPaused(context.Background(), runtime.NewRuntimeEnv(cmd), "p1", &out)
+	if !strings.Contains(out.String(), "Sync: paused") {
+		t.Errorf("expected paused indicator, got %q", out.String())
+	}
+
+	// Mutagen unavailable: nothing printed
+	out.Reset()
+	printSyncPaused(context.Background(), runtime.NewRuntimeEnv(util.NewMockCommandRunner()), "p1", &out)
+	if out.Len() != 0 {
+		t.Errorf("expected no output when mutagen fails, got %q", out.String())
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Run the project's test command in a fresh throwaway container",
+	Long: `Start a fresh container from the current configuration, run commands.test
+in it, then remove the container together with its Mutagen syncs and
+firewall rules. Output is streamed and the command's exit code is returned.
+
+The test container has its own name (alca-test-<id>) and project ID, so the
+long-lived container managed by 'alca up' is never touched. commands.up and
+commands.enter apply as usual. If alca is killed before teardown, the
+leftover container is reported by 'alca cleanup'.`,
+	Args: cobra.NoArgs,
+	RunE: runTest,
+}
+
+func init() {
+	testCmd.Flags().BoolP("quiet", "q", false, "Suppress progress output")
+}
+
+// runTest runs commands.test in an ephemeral container.
+func runTest(cmd *cobra.Command, args []string) error {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	var out io.Writer = os.Stdout
+	if quiet {
+		out = nil
+	}
+
+	err := runEphemeral(cmd, "test", func(cfg *config.Config) ([]string, error) {
+		if cfg.Commands.Test.Command == "" {
+			return nil, errors.New(ErrMsgNoTestCommand)
+		}
+		util.ProgressStep(out, "Test command: %s\n", cfg.Commands.Test.Command)
+		return sandbox.WrapWithEnter(cfg, []string{"sh", "-c", cfg.Commands.Test.Command}), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	util.ProgressDone(out, "Tests passed\n")
+	return nil
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptContext returns a context cancelled by the first SIGINT or SIGTERM,
+// giving rollbacks a chance to run. A second signal exits immediately, which
+// also gets the user out of a prompt that is waiting for input.
+func interruptContext(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		interrupted := false
+		for sig := range sigs {
+			if interrupted {
+				os.Exit(130)
+			}
+			interrupted = true
+			_, _ = fmt.Fprintf(os.Stderr, "\nReceived %v, cleaning up (repeat to exit immediately)...\n", sig)
+			cancel()
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(sigs)
+		cancel()
+	}
+}
@@ -3,423 +3,330 @@ package cli
 import (
 	"context"
 	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
 
-	"github.com/bolasblack/alcatraz/internal/config"
-	"github.com/bolasblack/alcatraz/internal/network"
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
 	"github.com/bolasblack/alcatraz/internal/state"
-	"github.com/bolasblack/alcatraz/internal/transact"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
 
-// spyRuntime tracks GetHostIP calls for testing caching behavior.
-type spyRuntime struct {
-	runtime.StubRuntime
-	getHostIPResult string
-	getHostIPErr    error
-	getHostIPCalls  int
-}
+func TestFakeRuntime_UpRunDown(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
 
-var _ runtime.Runtime = (*spyRuntime)(nil)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	if st.Runtime != runtime.FakeRuntimeName {
+		t.Errorf("state runtime = %q", st.Runtime)
+	}
+	c := fake.Container(st.ContainerName)
+	if c == nil || c.State != runtime.StateRunning || c.Image != "alpine:3" {
+		t.Fatalf("container after up = %+v", c)
+	}
+	if c.Labels[state.LabelProjectID] != st.ProjectID {
+		t.Errorf("labels = %v", c.Labels)
+	}
 
-func (s *spyRuntime) GetHostIP(_ context.Context, _ *runtime.RuntimeEnv) (string, error) {
-	s.getHostIPCalls++
-	return s.getHostIPResult, s.getHostIPErr
-}
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"go", "test"}, sandbox.ExecOptions{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	c = fake.Container(st.ContainerName)
+	want := [][]string{{"sh", "-c", "apk add git"}, {"go", "test"}}
+	if !slices.EqualFunc(c.Execs, want, slices.Equal) {
+		t.Errorf("execs = %q, want %q", c.Execs, want)
+	}
+
+	// A second up leaves the running container alone
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if got := fake.Container(st.ContainerName); got.ID != c.ID {
+		t.Errorf("second up recreated the container: %s -> %s", c.ID, got.ID)
+	}
 
-// stubNetworkHelper implements network.NetworkHelper for testing.
-// Returns Installed=true by default so ensureNetworkHelper passes.
-type stubNetworkHelper struct {
-	installed bool
+	if err := runFakeCommand(t, downCmd, runDown); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if fake.Container(st.ContainerName) != nil {
+		t.Error("container still exists after down")
+	}
+	if err := sandbox.Exec(context.Background(), newHostEnv(), dir, []string{"true"}, sandbox.ExecOptions{}); err == nil || err.Error() != sandbox.ErrMsgNotRunning {
+		t.Errorf("run after down: err = %v, want %q", err, sandbox.ErrMsgNotRunning)
+	}
 }
 
-var _ network.NetworkHelper = (*stubNetworkHelper)(nil)
+func TestFakeRuntime_DriftRecreatesContainer(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{"force", []string{"quiet", "force"}},
+		// --idempotent skips only a healthy, unchanged container
+		{"force idempotent", []string{"quiet", "force", "idempotent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, dir := setupFakeProject(t, fakeProjectConfig)
 
-func (s *stubNetworkHelper) HelperStatus(_ context.Context, _ *network.NetworkEnv) network.HelperStatus {
-	return network.HelperStatus{Installed: s.installed}
-}
-func (s *stubNetworkHelper) DetailedStatus(_ *network.NetworkEnv) network.DetailedStatusInfo {
-	return network.DetailedStatusInfo{}
-}
-func (s *stubNetworkHelper) Setup(_ *network.NetworkEnv, _ string, _ network.ProgressFunc) (*network.PostCommitAction, error) {
-	return &network.PostCommitAction{}, nil
-}
-func (s *stubNetworkHelper) Teardown(_ *network.NetworkEnv, _ string) error { return nil }
-func (s *stubNetworkHelper) InstallHelper(_ *network.NetworkEnv, _ network.ProgressFunc) (*network.PostCommitAction, error) {
-	return &network.PostCommitAction{}, nil
-}
-func (s *stubNetworkHelper) UninstallHelper(_ *network.NetworkEnv, _ network.ProgressFunc) (*network.PostCommitAction, error) {
-	return &network.PostCommitAction{}, nil
-}
+			if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+				t.Fatalf("up: %v", err)
+			}
+			st := loadFakeState(t, dir)
+			before := fake.Container(st.ContainerName)
 
-// driftRuntime controls Status return for drift detection tests.
-type driftRuntime struct {
-	runtime.StubRuntime
-	statusState runtime.ContainerState
-}
+			writeFakeConfig(t, dir, `image = "alpine:4"
+
+[network]
+lan-access = ["*"]
+`)
+			if err := runFakeCommand(t, upCmd, runUp, tt.flags...); err != nil {
+				t.Fatalf("up after drift: %v", err)
+			}
 
-func (d *driftRuntime) Status(_ context.Context, _ *runtime.RuntimeEnv, _ string, _ *state.State) (runtime.ContainerStatus, error) {
-	return runtime.ContainerStatus{State: d.statusState}, nil
+			after := fake.Container(st.ContainerName)
+			if after == nil || after.Image != "alpine:4" || after.ID == before.ID {
+				t.Fatalf("container after drift = %+v, want a new alpine:4 container", after)
+			}
+			if st := loadFakeState(t, dir); st.Config == nil || st.Config.Image != "alpine:4" {
+				t.Errorf("state config not updated: %+v", st.Config)
+			}
+			if !slices.Contains(fake.Calls, "Down "+st.ContainerName) {
+				t.Errorf("expected the old container to be removed, calls: %v", fake.Calls)
+			}
+		})
+	}
 }
 
-func (d *driftRuntime) Name() string { return "Docker" }
+func TestFakeRuntime_ResourceChangeUpdatesInPlace(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
 
-func TestHandleConfigDrift_NoContainer_NoDrift(t *testing.T) {
-	rt := &driftRuntime{statusState: runtime.StateNotFound}
-	st := &state.State{
-		Runtime: "Docker",
-		Config: &config.Config{
-			Image:    "alpine:3.20",
-			Commands: config.Commands{Up: config.CommandValue{Command: "old"}},
-		},
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
 	}
-	cfg := &config.Config{
-		Image:    "alpine:3.21",
-		Commands: config.Commands{Up: config.CommandValue{Command: "new"}},
+	st := loadFakeState(t, dir)
+	before := fake.Container(st.ContainerName)
+
+	writeFakeConfig(t, dir, fakeProjectConfig+`
+[resources]
+memory = "4g"
+cpus = 2
+`)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up after resource change: %v", err)
 	}
 
-	rebuild, err := handleConfigDrift(context.Background(), cfg, st, rt, nil, "/tmp", nil, false)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	after := fake.Container(st.ContainerName)
+	if after == nil || after.ID != before.ID {
+		t.Fatalf("container after resource change = %+v, want the same container", after)
 	}
-	if rebuild {
-		t.Error("expected no rebuild when container doesn't exist")
+	if after.Resources.Memory != "4g" || after.Resources.CPUs != 2 {
+		t.Errorf("container resources = %+v, want 4g and 2 cpus", after.Resources)
 	}
-}
-
-func TestHandleConfigDrift_RunningContainer_DetectsDrift(t *testing.T) {
-	rt := &driftRuntime{statusState: runtime.StateRunning}
-	st := &state.State{
-		Runtime: "Docker",
-		Config: &config.Config{
-			Image: "alpine:3.20",
-		},
+	if slices.Contains(fake.Calls, "Down "+st.ContainerName) {
+		t.Errorf("container was removed, calls: %v", fake.Calls)
 	}
-	cfg := &config.Config{
-		Image: "alpine:3.21",
+	st = loadFakeState(t, dir)
+	if st.Config.Resources.Memory != "4g" || st.Config.Resources.CPUs != 2 {
+		t.Errorf("state resources = %+v, want the applied limits", st.Config.Resources)
 	}
 
-	// force=true so we don't hit promptConfirm
-	rebuild, err := handleConfigDrift(context.Background(), cfg, st, rt, nil, "/tmp", nil, true)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	// The recorded limits match the config, so the next up has nothing to apply
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("second up: %v", err)
 	}
-	if !rebuild {
-		t.Error("expected rebuild when container exists and config changed")
+	updates := 0
+	for _, c := range fake.Calls {
+		if c == "UpdateResources "+st.ContainerName {
+			updates++
+		}
+	}
+	if updates != 1 {
+		t.Errorf("UpdateResources called %d times, want 1; calls: %v", updates, fake.Calls)
 	}
 }
 
-func TestHandleConfigDrift_RunningContainer_NoDriftWhenUnchanged(t *testing.T) {
-	rt := &driftRuntime{statusState: runtime.StateRunning}
-	cfg := &config.Config{Image: "alpine:3.21"}
-	st := &state.State{
-		Runtime: "Docker",
-		Config:  cfg,
+func TestFakeRuntime_ContainerName(t *testing.T) {
+	fake, dir := setupFakeProject(t, `container_name = "myproj-dev"
+`+fakeProjectConfig)
+
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	if st.ContainerName != "myproj-dev" {
+		t.Fatalf("state container name = %q, want myproj-dev", st.ContainerName)
+	}
+	if c := fake.Container("myproj-dev"); c == nil || c.Labels[state.LabelProjectID] != st.ProjectID {
+		t.Fatalf("container myproj-dev = %+v, want labeled with project %s", c, st.ProjectID)
 	}
 
-	rebuild, err := handleConfigDrift(context.Background(), cfg, st, rt, nil, "/tmp", nil, false)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	// Another project's container holds the new name
+	fake.AddContainer(runtime.FakeContainer{Name: "taken", Labels: map[string]string{
+		state.LabelProjectID:   "other-project",
+		state.LabelProjectPath: "/other",
+	}})
+	writeFakeConfig(t, dir, `container_name = "taken"
+`+fakeProjectConfig)
+	if err := runFakeCommand(t, downCmd, runDown); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	err := runFakeCommand(t, upCmd, runUp, "quiet")
+	if !errors.Is(err, sandbox.ErrContainerNameTaken) {
+		t.Fatalf("up with a taken name = %v, want errContainerNameTaken", err)
 	}
-	if rebuild {
-		t.Error("expected no rebuild when config unchanged")
+	if got := loadFakeState(t, dir).ContainerName; got != "myproj-dev" {
+		t.Errorf("state container name after refused up = %q, want myproj-dev", got)
 	}
 }
 
-func TestContainerMissing_UpdatesState(t *testing.T) {
-	fs := afero.NewMemMapFs()
-	env := &util.Env{Fs: fs}
-	cwd := "/project"
+func TestFakeRuntime_UpAfterProjectMoved(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
 
-	// Simulate state.json with old config (left by previous alca up)
-	oldCfg := &config.Config{Image: "alpine:3.20", Commands: config.Commands{Up: config.CommandValue{Command: "old"}}}
-	st := &state.State{ProjectID: "test-id", ContainerName: "alca-test", Runtime: "Docker", Config: oldCfg}
-	if err := state.Save(env, cwd, st); err != nil {
-		t.Fatalf("save: %v", err)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
 	}
+	st := loadFakeState(t, dir)
+	before := fake.Container(st.ContainerName)
 
-	// New config
-	newCfg := &config.Config{Image: "alpine:3.21", Commands: config.Commands{Up: config.CommandValue{Command: "new"}}}
-
-	// containerMissing returns true → state should be updated
-	rt := &driftRuntime{statusState: runtime.StateNotFound}
-	if !containerMissing(context.Background(), rt, nil, cwd, st) {
-		t.Fatal("expected containerMissing=true")
+	moved := filepath.Join(t.TempDir(), "renamed")
+	if err := afero.NewOsFs().Rename(dir, moved); err != nil {
+		t.Fatal(err)
 	}
+	t.Chdir(moved)
 
-	// Simulate the save path from runUp
-	st.UpdateConfig(newCfg)
-	if err := state.Save(env, cwd, st); err != nil {
-		t.Fatalf("save: %v", err)
+	// The project's commands refuse to run against the old mount
+	err := sandbox.CheckProjectPathConsistency(context.Background(), &runtime.RuntimeEnv{}, fake, st, moved, nil)
+	if !errors.Is(err, sandbox.ErrProjectPathMismatch) {
+		t.Fatalf("checkProjectPathConsistency() = %v, want errProjectPathMismatch", err)
 	}
 
-	// Verify state.json has new config
-	loaded, _ := state.Load(env, cwd)
-	if loaded.Config.Image != "alpine:3.21" {
-		t.Errorf("state not updated: image=%q, want alpine:3.21", loaded.Config.Image)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet", "force", "idempotent"); err != nil {
+		t.Fatalf("up after move: %v", err)
 	}
-	if loaded.Config.Commands.Up.Command != "new" {
-		t.Errorf("state not updated: up=%q, want 'new'", loaded.Config.Commands.Up.Command)
+	after := fake.Container(st.ContainerName)
+	if after == nil || after.ID == before.ID {
+		t.Fatalf("container after move = %+v, want a new container", after)
+	}
+	if after.Labels[state.LabelProjectPath] != moved || after.Labels[state.LabelProjectID] != st.ProjectID {
+		t.Errorf("labels after move = %v, want path %s and project %s", after.Labels, moved, st.ProjectID)
+	}
+	if err := sandbox.CheckProjectPathConsistency(context.Background(), &runtime.RuntimeEnv{}, fake, st, moved, nil); err != nil {
+		t.Errorf("checkProjectPathConsistency() after up = %v", err)
 	}
 }
 
-func TestExpandAlcaTokensInRules(t *testing.T) {
-	ctx := context.Background()
-	runtimeEnv := &runtime.RuntimeEnv{}
-
-	t.Run("caches GetHostIP across multiple rules", func(t *testing.T) {
-		spy := &spyRuntime{getHostIPResult: "172.17.0.1"}
-		rules := []string{
-			"${alca:HOST_IP}:8080",
-			"${alca:HOST_IP}:9090",
-			"${alca:HOST_IP}:3000",
-		}
-
-		got, err := expandAlcaTokensInRules(ctx, runtimeEnv, spy, rules)
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
-
-		want := []string{"172.17.0.1:8080", "172.17.0.1:9090", "172.17.0.1:3000"}
-		if len(got) != len(want) {
-			t.Fatalf("got %d rules, want %d", len(got), len(want))
-		}
-		for i := range want {
-			if got[i] != want[i] {
-				t.Errorf("rule %d: got %q, want %q", i, got[i], want[i])
-			}
-		}
-
-		if spy.getHostIPCalls != 1 {
-			t.Errorf("GetHostIP called %d times, want 1 (caching)", spy.getHostIPCalls)
-		}
-	})
-
-	t.Run("error from GetHostIP propagates", func(t *testing.T) {
-		resolveErr := errors.New("network unavailable")
-		spy := &spyRuntime{getHostIPErr: resolveErr}
-		rules := []string{"${alca:HOST_IP}:8080"}
-
-		_, err := expandAlcaTokensInRules(ctx, runtimeEnv, spy, rules)
-		if !errors.Is(err, resolveErr) {
-			t.Fatalf("expected resolver error, got: %v", err)
-		}
-	})
-
-	t.Run("rules without tokens pass through unchanged", func(t *testing.T) {
-		spy := &spyRuntime{getHostIPResult: "172.17.0.1"}
-		rules := []string{"192.168.1.1:8080", "10.0.0.1:9090"}
-
-		got, err := expandAlcaTokensInRules(ctx, runtimeEnv, spy, rules)
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
-
-		if len(got) != len(rules) {
-			t.Fatalf("got %d rules, want %d", len(got), len(rules))
-		}
-		for i := range rules {
-			if got[i] != rules[i] {
-				t.Errorf("rule %d: got %q, want %q", i, got[i], rules[i])
-			}
-		}
-
-		if spy.getHostIPCalls != 0 {
-			t.Errorf("GetHostIP called %d times, want 0 (no tokens)", spy.getHostIPCalls)
-		}
-	})
-}
-
-func TestSetupFirewall_ReturnsExpandedNetwork(t *testing.T) {
-	ctx := context.Background()
-	cmd := util.NewMockCommandRunner()
-	fs := afero.NewMemMapFs()
-	tfs := transact.New(transact.WithActualFs(fs))
-	env := &util.Env{Fs: tfs, Cmd: cmd}
-	runtimeEnv := runtime.NewRuntimeEnv(cmd)
-	networkEnv := network.NewNetworkEnv(tfs, cmd, "/tmp/test", "test-id", runtime.PlatformLinux)
+func TestFakeRuntime_UpIdempotent(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
 
-	spy := &spyRuntime{getHostIPResult: "172.17.0.1"}
-	st := &state.State{ProjectID: "test-id", ContainerName: "alca-test"}
-	nh := &stubNetworkHelper{installed: true}
-
-	// Config with raw alca tokens — setupFirewall must expand them
-	netCfg := config.Network{
-		LANAccess: []string{"${alca:HOST_IP}:8080", "${alca:HOST_IP}:9090"},
+	// Nothing to skip yet: the first run creates the container
+	if err := runFakeCommand(t, upCmd, runUp, "quiet", "idempotent"); err != nil {
+		t.Fatalf("up: %v", err)
 	}
-
-	// fw=nil, fwType=TypeNone → succeeds after expansion without applying rules
-	expandedNet, err := setupFirewall(ctx, nil, network.TypeNone, networkEnv, env, tfs, runtimeEnv, netCfg, spy, st, nh, nil)
-	if err != nil {
-		t.Fatalf("setupFirewall returned error: %v", err)
+	st := loadFakeState(t, dir)
+	if c := fake.Container(st.ContainerName); c == nil || c.State != runtime.StateRunning {
+		t.Fatalf("container after up = %+v", c)
 	}
 
-	// Returned network must contain resolved IPs, not raw tokens
-	want := []string{"172.17.0.1:8080", "172.17.0.1:9090"}
-	if len(expandedNet.LANAccess) != len(want) {
-		t.Fatalf("expanded LANAccess: got %d rules, want %d", len(expandedNet.LANAccess), len(want))
+	calls := len(fake.Calls)
+	if err := runFakeCommand(t, upCmd, runUp, "idempotent"); err != nil {
+		t.Fatalf("idempotent up: %v", err)
 	}
-	for i, w := range want {
-		if expandedNet.LANAccess[i] != w {
-			t.Errorf("expanded LANAccess[%d] = %q, want %q", i, expandedNet.LANAccess[i], w)
-		}
+	if slices.Contains(fake.Calls[calls:], "Up "+st.ContainerName) {
+		t.Errorf("idempotent up on a healthy container called Up: %v", fake.Calls[calls:])
 	}
 }
 
-func TestSetupFirewall_ErrSkipFirewall_DoesNotSaveState(t *testing.T) {
-	ctx := context.Background()
-	cmd := util.NewMockCommandRunner()
-	fs := afero.NewMemMapFs()
-	tfs := transact.New(transact.WithActualFs(fs))
-	env := &util.Env{Fs: tfs, Cmd: cmd}
-	runtimeEnv := runtime.NewRuntimeEnv(cmd)
-	networkEnv := network.NewNetworkEnv(tfs, cmd, "/tmp/test", "test-id", runtime.PlatformLinux)
-	cwd := "/tmp/test-project"
+func TestFakeRuntime_UpFailure(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+	errCreate := errors.New("no space left on device")
+	fake.FailNext("Up", errCreate)
 
-	spy := &spyRuntime{getHostIPResult: "172.17.0.1"}
-	st := &state.State{
-		ProjectID:     "test-id",
-		ContainerName: "alca-test",
-		Config: &config.Config{
-			Network: config.Network{
-				LANAccess: []string{"old-rule"},
-			},
-		},
+	err := runFakeCommand(t, upCmd, runUp, "quiet")
+	if !errors.Is(err, errCreate) {
+		t.Fatalf("up error = %v, want %v", err, errCreate)
+	}
+	if st := loadFakeState(t, dir); fake.Container(st.ContainerName) != nil {
+		t.Error("failed up left a container behind")
+	}
+	// Only an interrupted up is left for --resume
+	if p, err := state.LoadUpProgress(&util.Env{Fs: afero.NewOsFs()}, dir); err != nil || p != nil {
+		t.Errorf("progress after failed up = %+v, %v; want none", p, err)
 	}
 
-	// Save initial state to disk
-	initEnv := &util.Env{Fs: fs}
-	if err := state.Save(initEnv, cwd, st); err != nil {
-		t.Fatalf("failed to save initial state: %v", err)
+	// The failure was consumed; retrying succeeds
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("retry up: %v", err)
 	}
+}
 
-	// nh with Installed=false triggers ensureNetworkHelper → errSkipFirewall
-	// (since we can't call promptConfirm in tests, we use nh=nil which returns
-	// a regular error, not errSkipFirewall. Instead, directly verify the wiring:
-	// when setupFirewall returns an error, the returned Network is zero-value.)
-	nh := &stubNetworkHelper{installed: false}
+func TestFakeRuntime_UpSetSave(t *testing.T) {
+	fake, dir := setupFakeProject(t, `includes = ["./.alca.*.toml"]
+`+fakeProjectConfig)
 
-	netCfg := config.Network{
-		LANAccess: []string{"${alca:HOST_IP}:8080"},
+	setFlag := upCmd.Flags().Lookup("set")
+	if err := setFlag.Value.Set("image=alpine:4"); err != nil {
+		t.Fatalf("set --set: %v", err)
 	}
+	t.Cleanup(func() {
+		_ = setFlag.Value.(pflag.SliceValue).Replace(nil)
+		setFlag.Changed = false
+	})
 
-	_, fwErr := setupFirewall(ctx, nil, network.TypeNone, networkEnv, env, tfs, runtimeEnv, netCfg, spy, st, nh, nil)
-
-	// setupFirewall should return an error (helper not installed)
-	if fwErr == nil {
-		t.Fatal("expected error from setupFirewall when helper not installed")
+	if err := runFakeCommand(t, upCmd, runUp, "quiet", "set-save"); err != nil {
+		t.Fatalf("up --set: %v", err)
+	}
+	st := loadFakeState(t, dir)
+	if c := fake.Container(st.ContainerName); c == nil || c.Image != "alpine:4" {
+		t.Fatalf("container after up --set = %+v, want alpine:4", c)
+	}
+	data, err := afero.ReadFile(afero.NewOsFs(), filepath.Join(dir, sandbox.LocalConfigFilename))
+	if err != nil || !strings.Contains(string(data), "alpine:4") {
+		t.Fatalf("%s = %q, %v", sandbox.LocalConfigFilename, data, err)
 	}
 
-	// State on disk should be unchanged — saveNetworkState was never called
-	loaded, err := state.Load(initEnv, cwd)
-	if err != nil {
-		t.Fatalf("failed to load state: %v", err)
+	// The saved override applies without --set, so there is no drift
+	_ = setFlag.Value.(pflag.SliceValue).Replace(nil)
+	before := fake.Container(st.ContainerName)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("second up: %v", err)
 	}
-	if len(loaded.Config.Network.LANAccess) != 1 || loaded.Config.Network.LANAccess[0] != "old-rule" {
-		t.Errorf("state was modified despite setupFirewall error: got %v", loaded.Config.Network.LANAccess)
+	if after := fake.Container(st.ContainerName); after.ID != before.ID {
+		t.Errorf("second up recreated the container: %s -> %s", before.ID, after.ID)
 	}
 }
 
-func TestSaveNetworkState(t *testing.T) {
-	cwd := "/tmp/test-project"
-
-	t.Run("persists expanded values and preserves other config fields", func(t *testing.T) {
-		actualFs := afero.NewMemMapFs()
-		tfs := transact.New(transact.WithActualFs(actualFs))
-		cmd := util.NewMockCommandRunner()
-		env := &util.Env{Fs: tfs, Cmd: cmd}
-
-		// Create initial state with raw tokens in network config
-		st := &state.State{
-			ProjectID:     "test-project-id",
-			ContainerName: "alca-test",
-			Runtime:       "docker",
-			Config: &config.Config{
-				Image: "ubuntu:22.04",
-				Network: config.Network{
-					LANAccess: []string{"${alca:HOST_IP}:8080"},
-				},
-			},
-		}
-
-		// Save initial state directly to actual filesystem
-		initEnv := &util.Env{Fs: actualFs}
-		if err := state.Save(initEnv, cwd, st); err != nil {
-			t.Fatalf("failed to save initial state: %v", err)
-		}
-
-		// Simulate what runUp does: pass expanded (resolved) values from setupFirewall
-		expandedNet := config.Network{
-			LANAccess: []string{"172.17.0.1:8080", "10.0.0.5:9090"},
-		}
-
-		err := saveNetworkState(context.Background(), env, tfs, cwd, expandedNet, st, nil)
-		if err != nil {
-			t.Fatalf("saveNetworkState returned error: %v", err)
-		}
-
-		// Read state back from actual filesystem to verify persistence
-		readEnv := &util.Env{Fs: actualFs}
-		loaded, err := state.Load(readEnv, cwd)
-		if err != nil {
-			t.Fatalf("failed to load state: %v", err)
-		}
-		if loaded == nil {
-			t.Fatal("loaded state is nil")
-		}
-
-		// Network should contain expanded IPs, not raw tokens
-		if len(loaded.Config.Network.LANAccess) != 2 {
-			t.Fatalf("expected 2 LANAccess rules, got %d", len(loaded.Config.Network.LANAccess))
-		}
-		if loaded.Config.Network.LANAccess[0] != "172.17.0.1:8080" {
-			t.Errorf("LANAccess[0] = %q, want %q", loaded.Config.Network.LANAccess[0], "172.17.0.1:8080")
-		}
-		if loaded.Config.Network.LANAccess[1] != "10.0.0.5:9090" {
-			t.Errorf("LANAccess[1] = %q, want %q", loaded.Config.Network.LANAccess[1], "10.0.0.5:9090")
-		}
-
-		// Other config fields should be preserved
-		if loaded.Config.Image != "ubuntu:22.04" {
-			t.Errorf("Image = %q, want %q (should be preserved)", loaded.Config.Image, "ubuntu:22.04")
-		}
-		if loaded.ProjectID != "test-project-id" {
-			t.Errorf("ProjectID = %q, want %q", loaded.ProjectID, "test-project-id")
-		}
-	})
-
-	t.Run("updates in-memory state with expanded values", func(t *testing.T) {
-		actualFs := afero.NewMemMapFs()
-		tfs := transact.New(transact.WithActualFs(actualFs))
-		cmd := util.NewMockCommandRunner()
-		env := &util.Env{Fs: tfs, Cmd: cmd}
-
-		st := &state.State{
-			ProjectID:     "test-id",
-			ContainerName: "alca-test",
-			Runtime:       "docker",
-			Config: &config.Config{
-				Network: config.Network{
-					LANAccess: []string{"${alca:HOST_IP}:3000"},
-				},
-			},
-		}
-
-		expandedNet := config.Network{
-			LANAccess: []string{"172.17.0.1:3000"},
-		}
+func TestFakeRuntime_Peers(t *testing.T) {
+	fake, backend := setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up backend: %v", err)
+	}
 
-		err := saveNetworkState(context.Background(), env, tfs, cwd, expandedNet, st, nil)
-		if err != nil {
-			t.Fatalf("saveNetworkState returned error: %v", err)
-		}
+	frontend := filepath.Join(filepath.Dir(backend), "frontend")
+	if err := afero.NewOsFs().Mkdir(frontend, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFakeConfig(t, frontend, fakeProjectConfig+"peers = [\"../"+filepath.Base(backend)+"\"]\n")
+	t.Chdir(frontend)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up frontend: %v", err)
+	}
 
-		// In-memory state should have expanded values
-		if len(st.Config.Network.LANAccess) != 1 || st.Config.Network.LANAccess[0] != "172.17.0.1:3000" {
-			t.Errorf("in-memory state not updated with expanded values: got %v", st.Config.Network.LANAccess)
+	netName := runtime.PeerNetworkName(frontend, backend)
+	for _, dir := range []string{backend, frontend} {
+		c := fake.Container(loadFakeState(t, dir).ContainerName)
+		if !slices.Contains(c.Networks, netName) {
+			t.Errorf("container of %s networks = %v, want %s", filepath.Base(dir), c.Networks, netName)
 		}
-	})
+	}
+	if !slices.Contains(fake.Calls, "ConnectPeerNetwork "+loadFakeState(t, frontend).ContainerName+" "+netName+" frontend") {
+		t.Errorf("frontend should be reachable as \"frontend\", calls: %v", fake.Calls)
+	}
 }
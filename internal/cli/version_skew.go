@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+// annotationDestructive marks commands that remove containers or rewrite
+// state, which a much older alca must not run on a project a newer one
+// manages.
+const annotationDestructive = "alca.destructive"
+
+// destructive is the Annotations value for commands that remove
+// containers or rewrite state.
+var destructive = map[string]string{annotationDestructive: "true"}
+
+// checkVersionSkew warns when the project in the working directory was
+// last saved by a newer alca, and refuses destructive commands when that
+// alca is beyond state.SkewWindow unless --allow-version-skew is given.
+// Projects without state, and dev builds, are not checked.
+func checkVersionSkew(cmd *cobra.Command) error {
+	allow, err := cmd.Flags().GetBool(sandbox.FlagAllowVersionSkew)
+	if err != nil {
+		// Plugin commands do not parse flags
+		return nil
+	}
+	cwd, err := getCwd()
+	if err != nil {
+		return nil
+	}
+	env := newCLIReadDeps().Env
+	st, err := state.Load(env, sandbox.FindProjectDirFrom(env.Fs, cwd))
+	if err != nil || st == nil {
+		return nil
+	}
+	return sandbox.VersionSkewCheck(cmd.ErrOrStderr(), st, Version, cmd.Annotations[annotationDestructive] != "", allow)
+}
@@ -0,0 +1,17 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestDestructiveCommandsAreAnnotated(t *testing.T) {
+	for _, cmd := range []string{"up", "down", "rebuild", "migrate"} {
+		c, _, err := rootCmd.Find([]string{cmd})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Annotations[annotationDestructive] == "" {
+			t.Errorf("%s is not annotated as destructive", cmd)
+		}
+	}
+}
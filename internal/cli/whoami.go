@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/sandboxinfo"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show what the sandbox allows, as processes inside it see it",
+	Long: `Show the description of the sandbox that 'alca up' writes into the
+container at ` + sandboxinfo.ContainerPath + `: the project ID and name,
+the container, the network policy (isolation level, lan-access rules,
+host access, proxy, ports, peers) and whether a firewall enforces it, the
+mounts and whether they are writable, the resource limits and the
+capabilities.
+
+Processes in the container read the same file, found through
+$` + sandbox.EnvSandboxInfo + ` in enter and run sessions, to learn what they are
+allowed to do, for example an agent deciding not to try network access
+that the firewall blocks. Host paths are never included.
+
+The file is rewritten on every 'alca up'.`,
+	Args: cobra.NoArgs,
+	RunE: runWhoami,
+}
+
+func init() {
+	whoamiCmd.Flags().Bool("json", false, "Print the description as JSON, as stored in the container")
+}
+
+// runWhoami prints the sandbox description from the running container.
+func runWhoami(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	cwd, err := findProjectDir()
+	if err != nil {
+		return err
+	}
+
+	host := newHostEnv()
+	deps := sandbox.NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	_, rt, err := sandbox.LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+	st, err := sandbox.LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State != runtime.StateRunning {
+		return errors.New(sandbox.ErrMsgNotRunning)
+	}
+
+	data, err := readSandboxInfo(ctx, host.Fs, runtimeEnv, rt, status.Name)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	info, err := sandboxinfo.Parse(data)
+	if err != nil {
+		return err
+	}
+	printSandboxInfo(os.Stdout, info)
+	return nil
+}
+
+// readSandboxInfo copies the sandbox description out of the container.
+func readSandboxInfo(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, containerName string) ([]byte, error) {
+	tmpDir, err := afero.TempDir(fs, "", "alca-whoami-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = fs.RemoveAll(tmpDir) }()
+
+	dst := filepath.Join(tmpDir, filepath.Base(sandboxinfo.ContainerPath))
+	err = rt.CopyFromContainer(ctx, runtimeEnv, containerName, sandboxinfo.ContainerPath, dst)
+	if errors.Is(err, runtime.ErrPathNotFound) {
+		return nil, fmt.Errorf("the container has no %s yet; run 'alca up' to write it", sandboxinfo.ContainerPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", sandboxinfo.ContainerPath, err)
+	}
+	return afero.ReadFile(fs, dst)
+}
+
+// printSandboxInfo writes the human-readable form of info.
+func printSandboxInfo(w io.Writer, info sandboxinfo.Info) {
+	orNone := func(values []string) string {
+		if len(values) == 0 {
+			return "none"
+		}
+		return strings.Join(values, ", ")
+	}
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	_, _ = fmt.Fprintf(w, "Project:     %s (%s)\n", info.ProjectName, info.ProjectID)
+	_, _ = fmt.Fprintf(w, "Container:   %s (%s)\n", info.Container, info.Runtime)
+	_, _ = fmt.Fprintf(w, "Workdir:     %s\n", info.Workdir)
+	if info.User != "" {
+		_, _ = fmt.Fprintf(w, "User:        %s\n", info.User)
+	}
+
+	n := info.Network
+	firewall := n.Firewall
+	if firewall == "" {
+		firewall = "none (rules not enforced)"
+	}
+	_, _ = fmt.Fprintf(w, "Network:     isolation %s, firewall %s\n", n.Isolation, firewall)
+	_, _ = fmt.Fprintf(w, "  LAN access:  %s\n", orNone(n.LANAccess))
+	_, _ = fmt.Fprintf(w, "  Host access: %s\n", yesNo(n.AllowHostAccess))
+	if n.Proxy != "" {
+		_, _ = fmt.Fprintf(w, "  Proxy:       %s\n", n.Proxy)
+	}
+	if len(n.Ports) > 0 {
+		_, _ = fmt.Fprintf(w, "  Ports:       %s\n", orNone(n.Ports))
+	}
+	if len(n.Peers) > 0 {
+		_, _ = fmt.Fprintf(w, "  Peers:       %s\n", orNone(n.Peers))
+	}
+
+	_, _ = fmt.Fprintln(w, "Mounts:")
+	for _, m := range info.Mounts {
+		mode := "read-write"
+		if m.Readonly {
+			mode = "read-only"
+		}
+		_, _ = fmt.Fprintf(w, "  %s (%s)\n", m.Target, mode)
+	}
+
+	memory, cpus := info.Resources.Memory, "unlimited"
+	if memory == "" {
+		memory = "unlimited"
+	}
+	if info.Resources.CPUs > 0 {
+		cpus = fmt.Sprintf("%d", info.Resources.CPUs)
+	}
+	if info.Resources.CpusetCPUs != "" {
+		cpus += " (pinned to " + info.Resources.CpusetCPUs + ")"
+	}
+	_, _ = fmt.Fprintf(w, "Resources:   memory %s, cpus %s\n", memory, cpus)
+	_, _ = fmt.Fprintf(w, "Caps:        drop %s; add %s\n", orNone(info.Caps.Drop), orNone(info.Caps.Add))
+}
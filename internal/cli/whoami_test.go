@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandboxinfo"
+)
+
+func TestFakeRuntime_SandboxInfo(t *testing.T) {
+	fake, dir := setupFakeProject(t, fakeProjectConfig)
+	if err := runFakeCommand(t, upCmd, runUp, "quiet"); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	st := loadFakeState(t, dir)
+
+	fs := afero.NewOsFs()
+	data, err := readSandboxInfo(context.Background(), fs, &runtime.RuntimeEnv{Fs: fs}, fake, st.ContainerName)
+	if err != nil {
+		t.Fatalf("readSandboxInfo: %v", err)
+	}
+	info, err := sandboxinfo.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ProjectID != st.ProjectID || info.Container != st.ContainerName || info.Runtime != "Fake" {
+		t.Errorf("info = %+v", info)
+	}
+	if len(info.Network.LANAccess) != 1 || info.Network.LANAccess[0] != "*" {
+		t.Errorf("lan access = %v", info.Network.LANAccess)
+	}
+	if strings.Contains(string(data), dir) {
+		t.Errorf("sandbox info exposes the host path %s:\n%s", dir, data)
+	}
+
+	var out bytes.Buffer
+	printSandboxInfo(&out, info)
+	for _, want := range []string{"Project:     " + info.ProjectName, "LAN access:  *", "Mounts:\n  /workspace (read-write)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("whoami output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestReadSandboxInfo_NotWritten(t *testing.T) {
+	fake := runtime.NewFake()
+	fs := afero.NewOsFs()
+	_, err := readSandboxInfo(context.Background(), fs, &runtime.RuntimeEnv{Fs: fs}, fake, "alca-old")
+	if err == nil || !strings.Contains(err.Error(), "run 'alca up'") {
+		t.Errorf("readSandboxInfo() = %v, want a hint to run alca up", err)
+	}
+}
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	gosync "sync"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// WorkspaceFilename is the workspace file that lists member project directories.
+const WorkspaceFilename = ".alca-workspace.toml"
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage several projects from one workspace root",
+	Long: `Run alca commands across the member projects listed in .alca-workspace.toml.
+
+The workspace file is found by walking up from the current directory:
+
+  # .alca-workspace.toml
+  members = ["api", "web", "services/*"]
+
+Members are directories relative to the workspace file; glob patterns are
+supported and only match directories containing .alca.toml. Commands run
+in parallel and their output is shown per member once all have finished.`,
+}
+
+var workspaceUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Start all workspace projects",
+	Long: `Run 'alca up' in every workspace member.
+
+Members never prompt: configuration drift fails the member unless --yes
+is given, in which case containers are rebuilt without confirmation.`,
+	Args: cobra.NoArgs,
+	RunE: runWorkspaceSubcommand("up"),
+}
+
+var workspaceDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Stop all workspace projects",
+	Args:  cobra.NoArgs,
+	RunE:  runWorkspaceSubcommand("down"),
+}
+
+var workspaceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of all workspace projects",
+	Args:  cobra.NoArgs,
+	RunE:  runWorkspaceSubcommand("status"),
+}
+
+func init() {
+	workspaceCmd.PersistentFlags().IntP("jobs", "j", 0, "Maximum members to run in parallel (0 runs all at once)")
+	workspaceCmd.AddCommand(workspaceUpCmd)
+	workspaceCmd.AddCommand(workspaceDownCmd)
+	workspaceCmd.AddCommand(workspaceStatusCmd)
+}
+
+// workspaceMemberRunner runs alca with args in a member directory and returns its combined output.
+type workspaceMemberRunner func(ctx context.Context, dir string, args []string) ([]byte, error)
+
+// workspaceResult is the outcome of running a command in one member.
+type workspaceResult struct {
+	Dir    string
+	Output []byte
+	Err    error
+}
+
+// runWorkspaceSubcommand returns a RunE that runs 'alca <sub>' in every member.
+func runWorkspaceSubcommand(sub string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		jobs, _ := cmd.Flags().GetInt("jobs")
+
+		root, members, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		self, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate alca executable: %w", err)
+		}
+
+		memberArgs := workspaceMemberArgs(sub, promptModeFromCmd(cmd))
+		results := runWorkspaceMembers(cmd.Context(), members, memberArgs, jobs, execWorkspaceMember(newCommandRunner(), self))
+		return writeWorkspaceResults(os.Stdout, root, results)
+	}
+}
+
+// loadWorkspace finds the workspace enclosing the current directory and
+// resolves its members.
+func loadWorkspace() (root string, members []string, err error) {
+	cwd, err := getCwd()
+	if err != nil {
+		return "", nil, err
+	}
+	return loadWorkspaceFrom(newCLIReadDeps().Env.Fs, cwd)
+}
+
+// loadWorkspaceFrom finds the workspace enclosing startDir and resolves its members.
+func loadWorkspaceFrom(fs afero.Fs, startDir string) (root string, members []string, err error) {
+	root, ok := findWorkspaceDirFrom(fs, startDir)
+	if !ok {
+		return "", nil, fmt.Errorf("no %s found in %s or any parent directory", WorkspaceFilename, startDir)
+	}
+
+	ws, err := config.LoadWorkspace(fs, filepath.Join(root, WorkspaceFilename))
+	if err != nil {
+		return "", nil, err
+	}
+	members, err = resolveWorkspaceMembers(fs, root, ws.Members)
+	if err != nil {
+		return "", nil, err
+	}
+	return root, members, nil
+}
+
+// workspaceMemberArgs builds the alca arguments for a member.
+// Members run without a terminal, so prompts are turned into --yes or failures.
+func workspaceMemberArgs(sub string, prompt sandbox.PromptMode) []string {
+	args := []string{sub}
+	if prompt.AssumeYes {
+		args = append(args, "--"+flagYes)
+	} else {
+		args = append(args, "--"+flagNonInteractive)
+	}
+	return args
+}
+
+// findWorkspaceDirFrom walks up from startDir looking for WorkspaceFilename.
+func findWorkspaceDirFrom(fs afero.Fs, startDir string) (string, bool) {
+	dir := startDir
+	for {
+		info, err := fs.Stat(filepath.Join(dir, WorkspaceFilename))
+		if err == nil && !info.IsDir() {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// resolveWorkspaceMembers expands member patterns into project directories.
+// A literal member without .alca.toml is an error; glob matches without
+// .alca.toml are skipped, so "services/*" can include unrelated directories.
+func resolveWorkspaceMembers(fs afero.Fs, root string, patterns []string) ([]string, error) {
+	var members []string
+	add := func(dir string) {
+		if !slices.Contains(members, dir) {
+			members = append(members, dir)
+		}
+	}
+	isProject := func(dir string) bool {
+		info, err := fs.Stat(filepath.Join(dir, sandbox.ConfigFilename))
+		return err == nil && !info.IsDir()
+	}
+
+	for _, pattern := range patterns {
+		abs := pattern
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(root, pattern)
+		}
+
+		if !strings.ContainsAny(pattern, "*?[") {
+			if !isProject(abs) {
+				return nil, fmt.Errorf("workspace member %q has no %s", pattern, sandbox.ConfigFilename)
+			}
+			add(abs)
+			continue
+		}
+
+		matches, err := afero.Glob(fs, abs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace member pattern %q: %w", pattern, err)
+		}
+		for _, m := range matches {
+			if isProject(m) {
+				add(m)
+			}
+		}
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("workspace members match no project with %s", sandbox.ConfigFilename)
+	}
+	return members, nil
+}
+
+// runWorkspaceMembers runs the command in all members, at most jobs at a time.
+// Results are returned in member order.
+func runWorkspaceMembers(ctx context.Context, members []string, args []string, jobs int, run workspaceMemberRunner) []workspaceResult {
+	if jobs <= 0 || jobs > len(members) {
+		jobs = len(members)
+	}
+
+	results := make([]workspaceResult, len(members))
+	sem := make(chan struct{}, jobs)
+	var wg gosync.WaitGroup
+	for i, dir := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			output, err := run(ctx, dir, args)
+			results[i] = workspaceResult{Dir: dir, Output: output, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// writeWorkspaceResults prints each member's output under a header, followed by a summary.
+// Returns an error if any member failed.
+func writeWorkspaceResults(w io.Writer, root string, results []workspaceResult) error {
+	failed := 0
+	for _, r := range results {
+		name := workspaceMemberName(root, r.Dir)
+		status := "ok"
+		if r.Err != nil {
+			status = "failed: " + r.Err.Error()
+			failed++
+		}
+		_, _ = fmt.Fprintf(w, "==> %s (%s)\n", name, status)
+
+		for _, line := range strings.Split(strings.TrimRight(string(r.Output), "\n"), "\n") {
+			if line != "" {
+				_, _ = fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%d succeeded, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d workspace members failed", failed, len(results))
+	}
+	return nil
+}
+
+// execWorkspaceMember runs the alca binary in a member directory with cmd.
+func execWorkspaceMember(cmd util.CommandRunner, self string) workspaceMemberRunner {
+	return func(ctx context.Context, dir string, args []string) ([]byte, error) {
+		var buf bytes.Buffer
+		err := cmd.RunInDir(ctx, &buf, dir, self, args...)
+		return buf.Bytes(), err
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func newWorkspaceFs(t *testing.T) afero.Fs {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/ws/"+WorkspaceFilename, []byte(`members = ["api", "services/*"]`), 0644)
+	_ = afero.WriteFile(fs, "/ws/api/"+sandbox.ConfigFilename, []byte(`image = "alpine"`), 0644)
+	_ = afero.WriteFile(fs, "/ws/services/auth/"+sandbox.ConfigFilename, []byte(`image = "alpine"`), 0644)
+	_ = afero.WriteFile(fs, "/ws/services/billing/"+sandbox.ConfigFilename, []byte(`image = "alpine"`), 0644)
+	_ = fs.MkdirAll("/ws/services/docs", 0755)
+	return fs
+}
+
+func TestFindWorkspaceDirFrom(t *testing.T) {
+	fs := newWorkspaceFs(t)
+
+	root, ok := findWorkspaceDirFrom(fs, "/ws/services/auth")
+	if !ok || root != "/ws" {
+		t.Errorf("findWorkspaceDirFrom() = %q, %v; want /ws, true", root, ok)
+	}
+
+	if _, ok := findWorkspaceDirFrom(fs, "/elsewhere"); ok {
+		t.Error("expected no workspace outside /ws")
+	}
+}
+
+func TestResolveWorkspaceMembers(t *testing.T) {
+	fs := newWorkspaceFs(t)
+
+	members, err := resolveWorkspaceMembers(fs, "/ws", []string{"api", "services/*", "api"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "/ws/api,/ws/services/auth,/ws/services/billing"
+	if got := strings.Join(members, ","); got != want {
+		t.Errorf("members = %s, want %s", got, want)
+	}
+}
+
+func TestResolveWorkspaceMembers_LiteralWithoutConfig(t *testing.T) {
+	fs := newWorkspaceFs(t)
+
+	if _, err := resolveWorkspaceMembers(fs, "/ws", []string{"services/docs"}); err == nil {
+		t.Error("expected error for literal member without config")
+	}
+}
+
+func TestWorkspaceMemberArgs(t *testing.T) {
+	if got := strings.Join(workspaceMemberArgs("up", sandbox.PromptMode{}), " "); got != "up --non-interactive" {
+		t.Errorf("got %q", got)
+	}
+	if got := strings.Join(workspaceMemberArgs("up", sandbox.PromptMode{AssumeYes: true}), " "); got != "up --yes" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRunWorkspaceMembers(t *testing.T) {
+	members := []string{"/ws/api", "/ws/web", "/ws/db"}
+	run := func(_ context.Context, dir string, args []string) ([]byte, error) {
+		if dir == "/ws/web" {
+			return []byte("boom\n"), errors.New("exit status 1")
+		}
+		return []byte(strings.Join(args, " ") + " in " + dir + "\n"), nil
+	}
+
+	results := runWorkspaceMembers(context.Background(), members, []string{"status"}, 2, run)
+
+	var buf bytes.Buffer
+	err := writeWorkspaceResults(&buf, "/ws", results)
+	if err == nil {
+		t.Fatal("expected error when a member fails")
+	}
+
+	want := `==> api (ok)
+    status in /ws/api
+==> web (failed: exit status 1)
+    boom
+==> db (ok)
+    status in /ws/db
+
+2 succeeded, 1 failed
+`
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestExecWorkspaceMember(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("/bin/alca status", []byte("running\n"))
+
+	output, err := execWorkspaceMember(mock, "/bin/alca")(context.Background(), "/ws/api", []string{"status"})
+	if err != nil || string(output) != "running\n" {
+		t.Fatalf("member = %q, %v", output, err)
+	}
+	if dir := mock.Calls[0].Dir; dir != "/ws/api" {
+		t.Errorf("ran in %q, want /ws/api", dir)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// kernelCaps lists the Linux capability names (without the CAP_ prefix)
+// known to the kernel, see capabilities(7).
+var kernelCaps = []string{
+	"AUDIT_CONTROL", "AUDIT_READ", "AUDIT_WRITE", "BLOCK_SUSPEND", "BPF",
+	"CHECKPOINT_RESTORE", "CHOWN", "DAC_OVERRIDE", "DAC_READ_SEARCH", "FOWNER",
+	"FSETID", "IPC_LOCK", "IPC_OWNER", "KILL", "LEASE", "LINUX_IMMUTABLE",
+	"MAC_ADMIN", "MAC_OVERRIDE", "MKNOD", "NET_ADMIN", "NET_BIND_SERVICE",
+	"NET_BROADCAST", "NET_RAW", "PERFMON", "SETFCAP", "SETGID", "SETPCAP",
+	"SETUID", "SYS_ADMIN", "SYS_BOOT", "SYS_CHROOT", "SYS_MODULE", "SYS_NICE",
+	"SYS_PACCT", "SYS_PTRACE", "SYS_RAWIO", "SYS_RESOURCE", "SYS_TIME",
+	"SYS_TTY_CONFIG", "SYSLOG", "WAKE_ALARM",
+}
+
+// CapsPresets are the named capability sets accepted as caps = "<name>".
+// Every preset drops ALL first, so the add list is the complete set.
+var CapsPresets = map[string]Caps{
+	// No capabilities at all; suits tools that never change file ownership.
+	"minimal": {Drop: []string{"ALL"}},
+	// Same as omitting caps.
+	"default": {Drop: []string{"ALL"}, Add: DefaultCaps},
+	// Package managers and image builders that set file modes and chroot.
+	"build": {Drop: []string{"ALL"}, Add: append(slices.Clone(DefaultCaps), "FSETID", "SETFCAP", "SYS_CHROOT", "MKNOD")},
+	// Network debugging: raw sockets, routing changes, low ports.
+	"network-admin": {Drop: []string{"ALL"}, Add: append(slices.Clone(DefaultCaps), "NET_ADMIN", "NET_RAW", "NET_BIND_SERVICE")},
+}
+
+// CapsPresetNames returns the preset names in sorted order.
+func CapsPresetNames() []string {
+	return slices.Sorted(maps.Keys(CapsPresets))
+}
+
+// capsPreset returns a copy of the named preset.
+func capsPreset(name string) (Caps, error) {
+	p, ok := CapsPresets[name]
+	if !ok {
+		msg := fmt.Sprintf("caps: unknown preset %q (available: %s)", name, strings.Join(CapsPresetNames(), ", "))
+		if s := closestName(name, CapsPresetNames()); s != "" {
+			msg += fmt.Sprintf(", did you mean %q?", s)
+		}
+		return Caps{}, fmt.Errorf("%s: %w", msg, ErrUnknownCapsPreset)
+	}
+	return Caps{Drop: slices.Clone(p.Drop), Add: slices.Clone(p.Add)}, nil
+}
+
+// ValidateCaps rejects capability names the kernel does not know. Names are
+// matched case-insensitively with an optional CAP_ prefix, as Docker does;
+// "ALL" is accepted in both lists.
+func ValidateCaps(c Caps) error {
+	for _, list := range []struct {
+		field string
+		names []string
+	}{{"caps.drop", c.Drop}, {"caps.add", c.Add}} {
+		for _, name := range list.names {
+			norm := normalizeCapName(name)
+			if norm == "ALL" || slices.Contains(kernelCaps, norm) {
+				continue
+			}
+			msg := fmt.Sprintf("%s %q", list.field, name)
+			if s := closestName(norm, kernelCaps); s != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", s)
+			}
+			return fmt.Errorf("%s: %w", msg, ErrUnknownCapability)
+		}
+	}
+	return nil
+}
+
+// normalizeCapName upper-cases name and strips the CAP_ prefix.
+func normalizeCapName(name string) string {
+	return strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "CAP_")
+}
+
+// closestName returns the candidate nearest to name by edit distance, or ""
+// when nothing is close enough to be a plausible typo.
+func closestName(name string, candidates []string) string {
+	best, bestDist := "", len(name)/2+1
+	for _, c := range candidates {
+		if d := editDistance(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestValidateCaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		caps     Caps
+		wantErr  bool
+		wantHint string
+	}{
+		{"empty", Caps{}, false, ""},
+		{"defaults", Caps{Drop: []string{"ALL"}, Add: DefaultCaps}, false, ""},
+		{"lower case", Caps{Add: []string{"net_admin"}}, false, ""},
+		{"CAP_ prefix", Caps{Add: []string{"CAP_SYS_PTRACE"}}, false, ""},
+		{"unknown add with typo", Caps{Add: []string{"NET_ADMN"}}, true, `"NET_ADMIN"`},
+		{"unknown drop with typo", Caps{Drop: []string{"MKNODE"}}, true, `"MKNOD"`},
+		{"unknown without suggestion", Caps{Add: []string{"TELEPORT"}}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCaps(tt.caps)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("ValidateCaps(%+v) unexpected error: %v", tt.caps, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnknownCapability) {
+				t.Fatalf("ValidateCaps(%+v) error = %v, want ErrUnknownCapability", tt.caps, err)
+			}
+			if tt.wantHint != "" && !strings.Contains(err.Error(), "did you mean "+tt.wantHint) {
+				t.Errorf("error %q should suggest %s", err, tt.wantHint)
+			}
+			if tt.wantHint == "" && strings.Contains(err.Error(), "did you mean") {
+				t.Errorf("error %q should not suggest anything", err)
+			}
+		})
+	}
+}
+
+func TestCapsPresets_Valid(t *testing.T) {
+	for name, preset := range CapsPresets {
+		if err := ValidateCaps(preset); err != nil {
+			t.Errorf("preset %q: %v", name, err)
+		}
+		if !slices.Equal(preset.Drop, []string{"ALL"}) {
+			t.Errorf("preset %q: Drop = %v, want [ALL]", name, preset.Drop)
+		}
+	}
+}
+
+func TestLoadConfig_CapsPreset(t *testing.T) {
+	content := `
+image = "alpine"
+caps = "network-admin"
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	want := CapsPresets["network-admin"]
+	if !CapsEqual(cfg.Caps, want) {
+		t.Errorf("Caps = %+v, want %+v", cfg.Caps, want)
+	}
+}
+
+func TestLoadConfig_CapsMinimalPresetKeepsEmptyAdd(t *testing.T) {
+	content := `
+image = "alpine"
+caps = "minimal"
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(cfg.Caps.Add) != 0 {
+		t.Errorf("Caps.Add = %v, want none (defaults must not be applied)", cfg.Caps.Add)
+	}
+}
+
+func TestLoadConfig_UnknownCapsPreset(t *testing.T) {
+	content := `
+image = "alpine"
+caps = "biuld"
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	_, err := LoadConfig(env, path, noExpandEnv)
+	if !errors.Is(err, ErrUnknownCapsPreset) {
+		t.Fatalf("LoadConfig() error = %v, want ErrUnknownCapsPreset", err)
+	}
+	if !strings.Contains(err.Error(), `did you mean "build"`) {
+		t.Errorf("error %q should suggest \"build\"", err)
+	}
+}
+
+func TestLoadConfig_UnknownCapability(t *testing.T) {
+	content := `
+image = "alpine"
+caps = ["SYS_PTRACEE"]
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if _, err := LoadConfig(env, path, noExpandEnv); !errors.Is(err, ErrUnknownCapability) {
+		t.Errorf("LoadConfig() error = %v, want ErrUnknownCapability", err)
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Bounds of resources.cgroup.cpu_weight, those of the cgroup v2 cpu.weight file.
+const (
+	MinCPUWeight = 1
+	MaxCPUWeight = 10000
+)
+
+// Cgroup tunes the container's cgroup v2 controllers, so many sandboxes on
+// one workstation share the CPUs and disks predictably. Settings apply on
+// cgroup v2 hosts only and are ignored with a warning elsewhere.
+type Cgroup struct {
+	Parent     string    `toml:"parent,omitempty" json:"parent,omitempty" jsonschema:"description=Systemd slice the container's cgroup is created in (e.g. alca.slice) so limits set on the slice apply to all sandboxes in it"`
+	CPUWeight  int       `toml:"cpu_weight,omitempty" json:"cpu_weight,omitempty" jsonschema:"description=Relative CPU weight (1-10000; the kernel default is 100) used when CPUs are contended,minimum=1,maximum=10000"`
+	CpusetCPUs string    `toml:"cpuset_cpus,omitempty" json:"cpuset_cpus,omitempty" jsonschema:"description=CPUs the container is pinned to as a cpuset list (e.g. 0-3)"`
+	CpusetMems string    `toml:"cpuset_mems,omitempty" json:"cpuset_mems,omitempty" jsonschema:"description=NUMA memory nodes the container may allocate from (e.g. 0 or 0-1)"`
+	IOLatency  IOLatency `toml:"io_latency,omitempty" json:"io_latency,omitempty" jsonschema:"description=io.latency target protecting the container's disk latency (Podman only)"`
+}
+
+// IOLatency is an io.latency target: when the device's average latency for
+// the container exceeds Target, sibling cgroups are throttled.
+type IOLatency struct {
+	Device string `toml:"device,omitempty" json:"device,omitempty" jsonschema:"description=Block device as major:minor (see lsblk) e.g. 259:0"`
+	Target string `toml:"target,omitempty" json:"target,omitempty" jsonschema:"description=Latency target as a duration (e.g. 10ms)"`
+}
+
+// IsZero reports whether no cgroup setting is configured.
+func (c Cgroup) IsZero() bool {
+	return c == Cgroup{}
+}
+
+// CPUShares returns the --cpu-shares value the runtime converts to
+// CPUWeight, or 0 when no weight is set. Docker, Podman and runc map shares
+// [2, 262144] linearly onto cpu.weight [1, 10000]; rounding up here makes
+// the conversion land on CPUWeight exactly.
+func (c Cgroup) CPUShares() int64 {
+	if c.CPUWeight <= 0 {
+		return 0
+	}
+	w := int64(c.CPUWeight - 1)
+	return 2 + (w*262142+9998)/9999
+}
+
+// IOLatencyValue returns the io.latency file value, e.g. "259:0 target=10000"
+// (microseconds), or "" when no target is set.
+func (c Cgroup) IOLatencyValue() string {
+	if c.IOLatency.Device == "" {
+		return ""
+	}
+	d, _ := time.ParseDuration(c.IOLatency.Target)
+	return c.IOLatency.Device + " target=" + strconv.FormatInt(d.Microseconds(), 10)
+}
+
+var (
+	// cgroupSlicePattern matches systemd slice unit names; a dash nests the
+	// slice in its prefix (alca-work.slice is inside alca.slice).
+	cgroupSlicePattern = regexp.MustCompile(`^[A-Za-z0-9_:.]+(-[A-Za-z0-9_:.]+)*\.slice$`)
+	// cpusetPattern matches a cpuset list such as "0-3,8,10-11".
+	cpusetPattern = regexp.MustCompile(`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`)
+	// blockDevicePattern matches a major:minor device number.
+	blockDevicePattern = regexp.MustCompile(`^[0-9]+:[0-9]+$`)
+)
+
+// validateCgroup checks resources.cgroup against what the kernel accepts.
+func validateCgroup(c Cgroup) error {
+	if c.Parent != "" && !cgroupSlicePattern.MatchString(c.Parent) {
+		return fmt.Errorf("resources.cgroup.parent %q: must be a systemd slice name such as alca.slice: %w", c.Parent, ErrInvalidCgroup)
+	}
+	if c.CPUWeight != 0 && (c.CPUWeight < MinCPUWeight || c.CPUWeight > MaxCPUWeight) {
+		return fmt.Errorf("resources.cgroup.cpu_weight %d: must be %d-%d: %w", c.CPUWeight, MinCPUWeight, MaxCPUWeight, ErrInvalidCgroup)
+	}
+	for _, f := range []struct{ name, value string }{
+		{"resources.cgroup.cpuset_cpus", c.CpusetCPUs},
+		{"resources.cgroup.cpuset_mems", c.CpusetMems},
+	} {
+		if f.value != "" && !cpusetPattern.MatchString(f.value) {
+			return fmt.Errorf("%s %q: must be a list of numbers and ranges such as 0-3,8: %w", f.name, f.value, ErrInvalidCgroup)
+		}
+	}
+	l := c.IOLatency
+	if (l.Device == "") != (l.Target == "") {
+		return fmt.Errorf("resources.cgroup.io_latency: device and target must be set together: %w", ErrInvalidCgroup)
+	}
+	if l.Device != "" && !blockDevicePattern.MatchString(l.Device) {
+		return fmt.Errorf("resources.cgroup.io_latency.device %q: must be major:minor such as 259:0: %w", l.Device, ErrInvalidCgroup)
+	}
+	if l.Target != "" {
+		if d, err := time.ParseDuration(l.Target); err != nil || d < time.Microsecond {
+			return fmt.Errorf("resources.cgroup.io_latency.target %q: must be a positive duration such as 10ms: %w", l.Target, ErrInvalidCgroup)
+		}
+	}
+	return nil
+}
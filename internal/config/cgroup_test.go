@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_Cgroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		cgroup  string
+		want    Cgroup
+		wantErr bool
+	}{
+		{"unset", "", Cgroup{}, false},
+		{
+			"all set",
+			"parent = \"alca-work.slice\"\ncpu_weight = 50\ncpuset_cpus = \"0-3,8\"\ncpuset_mems = \"0\"\nio_latency = { device = \"259:0\", target = \"10ms\" }\n",
+			Cgroup{Parent: "alca-work.slice", CPUWeight: 50, CpusetCPUs: "0-3,8", CpusetMems: "0", IOLatency: IOLatency{Device: "259:0", Target: "10ms"}},
+			false,
+		},
+		{"parent not a slice", "parent = \"/sys/fs/cgroup/alca\"\n", Cgroup{}, true},
+		{"cpu weight out of range", "cpu_weight = 20000\n", Cgroup{}, true},
+		{"cpuset with spaces", "cpuset_cpus = \"0, 1\"\n", Cgroup{}, true},
+		{"io latency without target", "io_latency = { device = \"259:0\" }\n", Cgroup{}, true},
+		{"io latency device by path", "io_latency = { device = \"/dev/nvme0n1\", target = \"10ms\" }\n", Cgroup{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			content := "image = \"alpine\"\n"
+			if tt.cgroup != "" {
+				content += "[resources.cgroup]\n" + tt.cgroup
+			}
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cfg, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCgroup) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidCgroup", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Resources.Cgroup != tt.want {
+				t.Errorf("Resources.Cgroup = %+v, want %+v", cfg.Resources.Cgroup, tt.want)
+			}
+		})
+	}
+}
+
+func TestCgroup_CPUSharesRoundTrips(t *testing.T) {
+	// Inverse of the runtime's shares-to-weight conversion
+	toWeight := func(shares int64) int64 { return 1 + ((shares-2)*9999)/262142 }
+	for weight := MinCPUWeight; weight <= MaxCPUWeight; weight++ {
+		if got := toWeight(Cgroup{CPUWeight: weight}.CPUShares()); got != int64(weight) {
+			t.Fatalf("cpu_weight %d converts back to %d", weight, got)
+		}
+	}
+	if got := (Cgroup{}).CPUShares(); got != 0 {
+		t.Errorf("CPUShares() without a weight = %d, want 0", got)
+	}
+}
+
+func TestMergeCgroup(t *testing.T) {
+	base := Cgroup{Parent: "alca.slice", CPUWeight: 100, IOLatency: IOLatency{Device: "8:0", Target: "5ms"}}
+	overlay := Cgroup{CPUWeight: 20, CpusetCPUs: "0-1"}
+	want := Cgroup{Parent: "alca.slice", CPUWeight: 20, CpusetCPUs: "0-1", IOLatency: IOLatency{Device: "8:0", Target: "5ms"}}
+	if got := mergeCgroup(base, overlay); got != want {
+		t.Errorf("mergeCgroup() = %+v, want %+v", got, want)
+	}
+}
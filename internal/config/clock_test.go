@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_ClockDriftThreshold(t *testing.T) {
+	tests := []struct {
+		threshold string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{"", DefaultClockDriftThreshold, false},
+		{"30s", 30 * time.Second, false},
+		{"0", 0, false},
+		{"5", 0, true},
+		{"-1s", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.threshold, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			content := "image = \"alpine\"\n"
+			if tt.threshold != "" {
+				content += "[clock]\ndrift_threshold = \"" + tt.threshold + "\"\n"
+			}
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cfg, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidClockDrift) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidClockDrift", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if got := cfg.Clock.Threshold(); got != tt.want {
+				t.Errorf("Threshold() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,8 +6,12 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/invopop/jsonschema"
 
@@ -20,6 +24,9 @@ import (
 type CommandValue struct {
 	Command string `json:"command,omitempty"`
 	Append  bool   `json:"append,omitempty"`
+	// User runs the command as this user (name or uid[:gid]).
+	// Only supported for commands.enter.
+	User string `json:"user,omitempty"`
 }
 
 // UnmarshalJSON supports both string format (backward compat with old state files)
@@ -46,6 +53,7 @@ func (cv *CommandValue) UnmarshalJSON(data []byte) error {
 type Commands struct {
 	Up    CommandValue `json:"up,omitempty"`
 	Enter CommandValue `json:"enter,omitempty"`
+	Test  CommandValue `json:"test,omitempty"`
 }
 
 // Hooks defines host-side lifecycle hooks that run on the host machine.
@@ -55,6 +63,74 @@ type Hooks struct {
 	PreDown string `toml:"pre_down,omitempty" json:"pre_down,omitempty" jsonschema:"description=Command to run on the host before the container stops"`
 }
 
+// platformPattern matches an image platform such as linux/arm64 or linux/arm/v7.
+var platformPattern = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+
+// pluginNamePattern matches valid plugin names (the <name> in alca-<name>).
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Rebuild configures `alca rebuild`.
+type Rebuild struct {
+	Preserve []string `toml:"preserve,omitempty" json:"preserve,omitempty" jsonschema:"description=Absolute in-container paths copied out before the container is recreated and restored afterwards (e.g. /root/.cache)"`
+}
+
+// SSH configures an SSH server in the container, for tools that only speak
+// SSH (some IDEs, rsync, ansible). See alca ssh-config.
+type SSH struct {
+	Enabled bool `toml:"enabled,omitempty" json:"enabled,omitempty" jsonschema:"description=Run an SSH server in the container (sshd or dropbear from the image; dropbear is installed if neither is present) reachable from the host with a per-project key. Print the client config with alca ssh-config."`
+	Port    int  `toml:"port,omitempty" json:"port,omitempty" jsonschema:"description=Host port on 127.0.0.1 forwarded to the container's SSH server. Unset picks a free port when the container is created."`
+}
+
+// DefaultClockDriftThreshold is the clock drift tolerated when
+// clock.drift_threshold is unset.
+const DefaultClockDriftThreshold = 5 * time.Second
+
+// Clock configures the container clock drift check on VM-backed runtimes.
+type Clock struct {
+	DriftThreshold string `toml:"drift_threshold,omitempty" json:"drift_threshold,omitempty" jsonschema:"description=Maximum drift between host and container clocks (Go duration such as 5s) before alca up/enter/run correct the VM clock. 0 disables the check. Defaults to 5s."`
+}
+
+// Threshold returns the parsed drift threshold, DefaultClockDriftThreshold
+// if unset, or 0 if the check is disabled. Assumes LoadConfig validated it.
+func (c Clock) Threshold() time.Duration {
+	if c.DriftThreshold == "" {
+		return DefaultClockDriftThreshold
+	}
+	d, _ := time.ParseDuration(c.DriftThreshold)
+	return d
+}
+
+// Timeouts bounds long-running host-side operations. Unset fields mean no limit.
+type Timeouts struct {
+	Pull      string `toml:"pull,omitempty" json:"pull,omitempty" jsonschema:"description=Time limit for pulling the image (alca pull, and container creation in alca up, which pulls a missing image). Go duration such as 10m."`
+	UpCommand string `toml:"up_command,omitempty" json:"up_command,omitempty" jsonschema:"description=Time limit for commands.up during alca up. Go duration such as 30m."`
+}
+
+// PullTimeout returns the parsed pull timeout, or 0 for no limit.
+// Assumes LoadConfig validated it.
+func (t Timeouts) PullTimeout() time.Duration {
+	d, _ := time.ParseDuration(t.Pull)
+	return d
+}
+
+// UpCommandTimeout returns the parsed commands.up timeout, or 0 for no limit.
+// Assumes LoadConfig validated it.
+func (t Timeouts) UpCommandTimeout() time.Duration {
+	d, _ := time.ParseDuration(t.UpCommand)
+	return d
+}
+
+// MinIdleTimeout is the shortest idle_timeout: enter and run sessions
+// refresh their last-use time every few minutes while they are open.
+const MinIdleTimeout = 10 * time.Minute
+
+// IdleTimeoutDuration returns the parsed idle_timeout, or 0 when idle
+// containers are left running. Assumes LoadConfig validated it.
+func (c *Config) IdleTimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(c.IdleTimeout)
+	return d
+}
+
 // RawCommandValue is the raw type for command values in TOML.
 // Supports string format ("cmd") or struct format ({command = "cmd", append = true}).
 type RawCommandValue = any
@@ -64,12 +140,14 @@ type RawCommandValue = any
 type RawCommands struct {
 	Up    RawCommandValue `toml:"up,omitempty" json:"up,omitempty"`
 	Enter RawCommandValue `toml:"enter,omitempty" json:"enter,omitempty"`
+	Test  RawCommandValue `toml:"test,omitempty" json:"test,omitempty"`
 }
 
 // Resources defines container resource limits.
 type Resources struct {
 	Memory string `toml:"memory,omitempty" json:"memory,omitempty" jsonschema:"description=Memory limit (e.g. 4g or 512m)"`
 	CPUs   int    `toml:"cpus,omitempty" json:"cpus,omitempty" jsonschema:"description=Number of CPUs to allocate"`
+	Cgroup Cgroup `toml:"cgroup,omitempty" json:"cgroup,omitempty" jsonschema:"description=cgroup v2 tuning: slice and CPU weight and cpuset pinning and io latency"`
 }
 
 // RuntimeType defines the container runtime selection mode.
@@ -86,15 +164,61 @@ const (
 	RuntimeDocker RuntimeType = "docker"
 )
 
+// ImageCheckMode defines how often `alca up` checks the registry for a newer image digest.
+type ImageCheckMode string
+
+const (
+	// ImageCheckNever disables the upstream image check (default).
+	ImageCheckNever ImageCheckMode = "never"
+	// ImageCheckDaily checks at most once every 24 hours per project.
+	ImageCheckDaily ImageCheckMode = "daily"
+	// ImageCheckAlways checks on every `alca up`.
+	ImageCheckAlways ImageCheckMode = "always"
+)
+
+// UsernsMode selects the container's user namespace (the --userns run flag).
+// Empty keeps the runtime's default.
+type UsernsMode string
+
+const (
+	// UsernsKeepID maps the host user to the same uid/gid in the container,
+	// so files in bind mounts keep their host ownership (Podman).
+	UsernsKeepID UsernsMode = "keep-id"
+	// UsernsAuto gives the container its own unused range of host IDs, so
+	// container root maps to an unprivileged host user (Podman).
+	UsernsAuto UsernsMode = "auto"
+	// UsernsHost shares the host's user namespace: container uids are host
+	// uids. Opts out of Docker's daemon-wide userns-remap.
+	UsernsHost UsernsMode = "host"
+)
+
+// NestedContainersMode defines how the sandbox can run containers of its own.
+type NestedContainersMode string
+
+const (
+	// NestedContainersNone gives the sandbox no container daemon (default).
+	NestedContainersNone NestedContainersMode = "none"
+	// NestedContainersDind runs a Docker daemon in a privileged sidecar that
+	// shares the sandbox's network namespace, so the sandbox firewall also
+	// covers the daemon and the containers it starts.
+	NestedContainersDind NestedContainersMode = "dind"
+	// NestedContainersSocket mounts the host runtime's socket. Containers
+	// started through it are siblings on the host, outside the sandbox and
+	// its firewall.
+	NestedContainersSocket NestedContainersMode = "socket"
+)
+
 // DefaultWorkdir is the default working directory inside the container.
 const DefaultWorkdir = "/workspace"
 
 // EnvValue represents an environment variable configuration.
-// Can be unmarshaled from either a string or an object with value and override_on_enter fields.
+// Can be unmarshaled from either a string or an object with value, override_on_enter
+// and sensitive fields.
 // See AGD-017 for environment variable configuration design.
 type EnvValue struct {
 	Value           string `toml:"value" json:"value" jsonschema:"description=The value or ${VAR} reference"`
 	OverrideOnEnter bool   `toml:"override_on_enter,omitempty" json:"override_on_enter,omitempty" jsonschema:"description=Also set at docker exec time"`
+	Sensitive       bool   `toml:"sensitive,omitempty" json:"sensitive,omitempty" jsonschema:"description=Mask the expanded value in alca output, debug echo and run history"`
 }
 
 // envVarPattern matches simple ${VAR} syntax.
@@ -148,6 +272,7 @@ func envValueSchema() *jsonschema.Schema {
 	props := jsonschema.NewProperties()
 	props.Set("value", &jsonschema.Schema{Type: "string", Description: "The value or ${VAR} reference"})
 	props.Set("override_on_enter", &jsonschema.Schema{Type: "boolean", Description: "Also set at docker exec time"})
+	props.Set("sensitive", &jsonschema.Schema{Type: "boolean", Description: "Mask the expanded value in alca output, debug echo and run history"})
 
 	return &jsonschema.Schema{
 		OneOf: []*jsonschema.Schema{
@@ -158,7 +283,7 @@ func envValueSchema() *jsonschema.Schema {
 				AdditionalProperties: jsonschema.FalseSchema,
 			},
 		},
-		Description: "Environment variable value (string or object with override_on_enter)",
+		Description: "Environment variable value (string or object with override_on_enter and sensitive)",
 	}
 }
 
@@ -184,17 +309,44 @@ func DefaultEnvs() map[string]EnvValue {
 // See AGD-030 for LAN access design decisions.
 // See AGD-037 for transparent proxy design decisions.
 type Network struct {
-	LANAccess []string     `toml:"lan-access,omitempty" json:"lan-access,omitempty" jsonschema:"description=LAN access configuration (currently only '*' is supported)"`
-	Ports     []PortConfig `toml:"ports,omitempty" json:"ports,omitempty" jsonschema:"description=Port mappings (Docker -p flags)"`
-	Proxy     string       `toml:"proxy,omitempty" json:"proxy,omitempty" jsonschema:"description=Transparent proxy address (host:port). All container TCP/UDP traffic is redirected via nftables DNAT. Supports ${alca:HOST_IP} token."`
+	LANAccess       []string                 `toml:"lan-access,omitempty" json:"lan-access,omitempty" jsonschema:"description=LAN access configuration (currently only '*' is supported)"`
+	Ports           []PortConfig             `toml:"ports,omitempty" json:"ports,omitempty" jsonschema:"description=Port mappings (Docker -p flags)"`
+	Proxy           string                   `toml:"proxy,omitempty" json:"proxy,omitempty" jsonschema:"description=Transparent proxy address (host:port). All container TCP/UDP traffic is redirected via nftables DNAT. Supports ${alca:HOST_IP} token."`
+	Shaping         Shaping                  `toml:"shaping,omitempty" json:"shaping,omitempty" jsonschema:"description=Bandwidth and latency limits for container traffic (Linux only)"`
+	LogConnections  bool                     `toml:"log_connections,omitempty" json:"log_connections,omitempty" jsonschema:"description=Log every new outbound connection from the container to the kernel log. View with alca network log."`
+	AllowHostAccess bool                     `toml:"allow_host_access,omitempty" json:"allow_host_access,omitempty" jsonschema:"description=Allow the container to reach services on the host machine at host.alca.internal"`
+	Isolation       string                   `toml:"isolation,omitempty" json:"isolation,omitempty" jsonschema:"enum=none,enum=default,enum=strict,enum=custom,description=Isolation level: none (no network), default (private ranges blocked), strict (egress limited to lan-access and proxy) or custom (lan-access and proxy as configured). Derived from lan-access/proxy when unset."`
+	Peers           []string                 `toml:"peers,omitempty" json:"peers,omitempty" jsonschema:"description=Other alca project directories whose containers share a network with this one and are reachable by their directory name. Relative paths are resolved against the project directory."`
+	Preset          string                   `toml:"preset,omitempty" json:"preset,omitempty" jsonschema:"description=Named network preset whose policy is applied under this section's settings. Defined in network.presets of any config layer or in ~/.alcatraz/network-presets.toml."`
+	Presets         map[string]NetworkPreset `toml:"presets,omitempty" json:"presets,omitempty" jsonschema:"description=Network preset definitions by name, selectable with network.preset"`
 }
 
 // RawNetwork is the raw TOML representation of Network.
 // Uses RawPortSlice to support polymorphic port decoding (string or object).
 type RawNetwork struct {
-	LANAccess []string     `toml:"lan-access,omitempty" json:"lan-access,omitempty" jsonschema:"description=LAN access configuration (currently only '*' is supported)"`
-	Ports     RawPortSlice `toml:"ports,omitempty" json:"ports,omitempty"`
-	Proxy     string       `toml:"proxy,omitempty" json:"proxy,omitempty" jsonschema:"description=Transparent proxy address (host:port). All container TCP/UDP traffic is redirected via nftables DNAT. Supports ${alca:HOST_IP} token."`
+	LANAccess       []string                 `toml:"lan-access,omitempty" json:"lan-access,omitempty" jsonschema:"description=LAN access configuration (currently only '*' is supported)"`
+	Ports           RawPortSlice             `toml:"ports,omitempty" json:"ports,omitempty"`
+	Proxy           string                   `toml:"proxy,omitempty" json:"proxy,omitempty" jsonschema:"description=Transparent proxy address (host:port). All container TCP/UDP traffic is redirected via nftables DNAT. Supports ${alca:HOST_IP} token."`
+	Shaping         Shaping                  `toml:"shaping,omitempty" json:"shaping,omitempty" jsonschema:"description=Bandwidth and latency limits for container traffic (Linux only)"`
+	LogConnections  bool                     `toml:"log_connections,omitempty" json:"log_connections,omitempty" jsonschema:"description=Log every new outbound connection from the container to the kernel log. View with alca network log."`
+	AllowHostAccess bool                     `toml:"allow_host_access,omitempty" json:"allow_host_access,omitempty" jsonschema:"description=Allow the container to reach services on the host machine at host.alca.internal"`
+	Isolation       string                   `toml:"isolation,omitempty" json:"isolation,omitempty" jsonschema:"enum=none,enum=default,enum=strict,enum=custom,description=Isolation level: none (no network), default (private ranges blocked), strict (egress limited to lan-access and proxy) or custom (lan-access and proxy as configured). Derived from lan-access/proxy when unset."`
+	Peers           []string                 `toml:"peers,omitempty" json:"peers,omitempty" jsonschema:"description=Other alca project directories whose containers share a network with this one and are reachable by their directory name. Relative paths are resolved against the project directory."`
+	Preset          string                   `toml:"preset,omitempty" json:"preset,omitempty" jsonschema:"description=Named network preset whose policy is applied under this section's settings. Defined in network.presets of any config layer or in ~/.alcatraz/network-presets.toml."`
+	Presets         map[string]NetworkPreset `toml:"presets,omitempty" json:"presets,omitempty" jsonschema:"description=Network preset definitions by name, selectable with network.preset"`
+}
+
+// Shaping limits container egress bandwidth and adds latency via tc/netem.
+// Applied to the container's network interface on Linux; ignored with a
+// warning where the container runs inside a VM (macOS).
+type Shaping struct {
+	Rate  string `toml:"rate,omitempty" json:"rate,omitempty" jsonschema:"description=Bandwidth limit in tc rate syntax (e.g. 10mbit or 512kbit)"`
+	Delay string `toml:"delay,omitempty" json:"delay,omitempty" jsonschema:"description=Added latency per packet (e.g. 50ms)"`
+}
+
+// IsZero reports whether no shaping is configured.
+func (s Shaping) IsZero() bool {
+	return s.Rate == "" && s.Delay == ""
 }
 
 // Caps represents container capability configuration (resolved form).
@@ -253,17 +405,39 @@ func StringSlicesEqual(a, b []string) bool {
 // Config represents the Alcatraz container configuration (after processing).
 // This is the final merged config used internally by the program.
 type Config struct {
-	Image          string
-	Workdir        string
-	WorkdirExclude []string
-	Runtime        RuntimeType
-	Commands       Commands
-	Mounts         []MountConfig
-	Resources      Resources
-	Envs           map[string]EnvValue
-	Network        Network
-	Caps           Caps
-	Hooks          Hooks
+	Image              string
+	ImageCheck         ImageCheckMode
+	Platform           string
+	RegistryMirror     string
+	Workdir            string
+	WorkdirSource      string
+	WorkdirExclude     []string
+	WorkdirExcludeFrom []string
+	WorkdirSync        MountSync
+	Runtime            RuntimeType
+	RuntimeContext     string
+	User               string
+	EnterTmux          bool
+	NestedContainers   NestedContainersMode
+	Userns             UsernsMode
+	Commands           Commands
+	Mounts             []MountConfig
+	Resources          Resources
+	Envs               map[string]EnvValue
+	Network            Network
+	SSH                SSH
+	Caps               Caps
+	Hooks              Hooks
+	Rebuild            Rebuild
+	Clock              Clock
+	Timeouts           Timeouts
+	IdleTimeout        string
+	ContainerName      string
+	NameTemplate       string
+	Plugins            []string
+	Cron               map[string]string
+	ForwardFileEvents  bool
+	UI                 UI
 }
 
 // HasMutagenSync returns true if the config has any sync excludes configured,
@@ -280,6 +454,31 @@ func (c *Config) HasMutagenSync() bool {
 	return false
 }
 
+// OrNone returns the mode, defaulting to none if empty.
+func (m NestedContainersMode) OrNone() NestedContainersMode {
+	if m == "" {
+		return NestedContainersNone
+	}
+	return m
+}
+
+// ExecUser returns the user that enter and run sessions execute as:
+// commands.enter.user, then user. Empty means the image's default user.
+func (c *Config) ExecUser() string {
+	if c.Commands.Enter.User != "" {
+		return c.Commands.Enter.User
+	}
+	return c.User
+}
+
+// NormalizeImageCheck returns the image check mode, defaulting to never if empty.
+func (c *Config) NormalizeImageCheck() ImageCheckMode {
+	if c.ImageCheck == "" {
+		return ImageCheckNever
+	}
+	return c.ImageCheck
+}
+
 // NormalizeRuntime returns the runtime type, defaulting to auto if empty.
 func (c *Config) NormalizeRuntime() RuntimeType {
 	if c.Runtime == "" {
@@ -299,6 +498,38 @@ func (c *Config) MergedEnvs() map[string]EnvValue {
 	return merged
 }
 
+// ResolvedEnvs returns the merged environment variables expanded via getenv.
+// Variables that expand to an empty string are omitted, matching what the
+// container actually receives. If enterOnly is true, only variables with
+// override_on_enter are returned (those re-applied at exec time).
+func (c *Config) ResolvedEnvs(getenv func(string) string, enterOnly bool) map[string]string {
+	resolved := make(map[string]string)
+	for key, ev := range c.MergedEnvs() {
+		if enterOnly && !ev.OverrideOnEnter {
+			continue
+		}
+		if expanded := ev.Expand(getenv); expanded != "" {
+			resolved[key] = expanded
+		}
+	}
+	return resolved
+}
+
+// SensitiveValues returns the expanded values of envs marked sensitive,
+// for registration with util.RegisterSecret.
+func (c *Config) SensitiveValues(getenv func(string) string) []string {
+	var values []string
+	for _, ev := range c.MergedEnvs() {
+		if !ev.Sensitive {
+			continue
+		}
+		if expanded := ev.Expand(getenv); expanded != "" {
+			values = append(values, expanded)
+		}
+	}
+	return values
+}
+
 // ValidateEnvs validates all environment variable configurations.
 func (c *Config) ValidateEnvs() error {
 	for key, env := range c.MergedEnvs() {
@@ -313,6 +544,7 @@ func (c *Config) ValidateEnvs() error {
 // Supports two modes:
 //   - Array mode (additive): caps = ["DAC_OVERRIDE", "SETUID"]
 //   - Object mode (full control): [caps] drop = [...] add = [...]
+//   - Preset mode: caps = "build" (see CapsPresets)
 //
 // See AGD-026 for design rationale.
 type RawCaps = any
@@ -331,8 +563,18 @@ func capsJSONSchema() *jsonschema.Schema {
 		Description: "Capabilities to add (e.g., [\"CHOWN\", \"FOWNER\"])",
 	})
 
+	presets := make([]any, 0, len(CapsPresets))
+	for _, name := range CapsPresetNames() {
+		presets = append(presets, name)
+	}
+
 	return &jsonschema.Schema{
 		OneOf: []*jsonschema.Schema{
+			{
+				Type:        "string",
+				Enum:        presets,
+				Description: "Preset mode: a named capability set (minimal, default, build, network-admin)",
+			},
 			{
 				Type:        "array",
 				Items:       &jsonschema.Schema{Type: "string"},
@@ -345,15 +587,19 @@ func capsJSONSchema() *jsonschema.Schema {
 				Description:          "Full control mode: explicit drop and add lists (no implicit defaults)",
 			},
 		},
-		Description: "Container capability configuration. Array = additive mode, Object = full control mode.",
+		Description: "Container capability configuration. String = preset, Array = additive mode, Object = full control mode.",
 	}
 }
 
 // commandValueJSONSchema returns the JSON schema for a command value field.
-func commandValueJSONSchema() *jsonschema.Schema {
+// withUser allows the user key, which only commands.enter supports.
+func commandValueJSONSchema(withUser bool) *jsonschema.Schema {
 	cmdProps := jsonschema.NewProperties()
 	cmdProps.Set("command", &jsonschema.Schema{Type: "string", Description: "The command string"})
 	cmdProps.Set("append", &jsonschema.Schema{Type: "boolean", Description: "Append to base command during merge (default: false)"})
+	if withUser {
+		cmdProps.Set("user", &jsonschema.Schema{Type: "string", Description: "User to run as (name or uid[:gid]); overrides the top-level user"})
+	}
 
 	return &jsonschema.Schema{
 		OneOf: []*jsonschema.Schema{
@@ -372,8 +618,9 @@ func commandValueJSONSchema() *jsonschema.Schema {
 // commandsJSONSchema returns the JSON schema for the commands section.
 func commandsJSONSchema() *jsonschema.Schema {
 	props := jsonschema.NewProperties()
-	props.Set("up", commandValueJSONSchema())
-	props.Set("enter", commandValueJSONSchema())
+	props.Set("up", commandValueJSONSchema(false))
+	props.Set("enter", commandValueJSONSchema(true))
+	props.Set("test", commandValueJSONSchema(false))
 
 	return &jsonschema.Schema{
 		Type:                 "object",
@@ -401,19 +648,75 @@ func (RawConfig) JSONSchemaExtend(schema *jsonschema.Schema) {
 // to their validated, strongly-typed counterparts (Config, []MountConfig, EnvValue, Caps)
 // during parsing in rawToConfig(). See also: RawMountSlice, RawEnvValueMap, RawCaps.
 type RawConfig struct {
-	Extends        []string       `toml:"extends,omitempty" json:"extends,omitempty" jsonschema:"description=Config files to extend (declaring file overrides extended files). Paths support ${VAR} environment variable expansion and glob patterns."`
-	Includes       []string       `toml:"includes,omitempty" json:"includes,omitempty" jsonschema:"description=Config files to include (included files override declaring file). Paths support ${VAR} environment variable expansion and glob patterns."`
-	Image          string         `toml:"image" json:"image" jsonschema:"description=Container image to use"`
-	Workdir        string         `toml:"workdir,omitempty" json:"workdir,omitempty" jsonschema:"description=Working directory inside container"`
-	WorkdirExclude []string       `toml:"workdir_exclude,omitempty" json:"workdir_exclude,omitempty" jsonschema:"description=Patterns to exclude from workdir mount (requires Mutagen)"`
-	Runtime        RuntimeType    `toml:"runtime,omitempty" json:"runtime,omitempty" jsonschema:"enum=auto,enum=docker,description=Container runtime selection"`
-	Commands       RawCommands    `toml:"commands,omitempty" json:"commands,omitempty" jsonschema:"description=Lifecycle commands"`
-	Mounts         RawMountSlice  `toml:"mounts,omitempty" json:"mounts,omitempty"`
-	Resources      Resources      `toml:"resources,omitempty" json:"resources,omitempty" jsonschema:"description=Container resource limits"`
-	Envs           RawEnvValueMap `toml:"envs,omitempty" json:"envs,omitempty"`
-	Network        RawNetwork     `toml:"network,omitempty" json:"network,omitempty" jsonschema:"description=Network configuration"`
-	Caps           RawCaps        `toml:"caps,omitempty" json:"caps,omitempty"`
-	Hooks          Hooks          `toml:"hooks,omitempty" json:"hooks,omitempty" jsonschema:"description=Host-side lifecycle hooks (run on host machine)"`
+	SchemaVersion      int                  `toml:"schema_version,omitempty" json:"schema_version,omitempty" jsonschema:"description=Schema version of this file. Older versions are migrated on load; see alca migrate."`
+	Inherit            bool                 `toml:"inherit,omitempty" json:"inherit,omitempty" jsonschema:"description=Extend the nearest .alca.toml in a parent directory (for a subproject of a monorepo). This file and its own extends win over the inherited config."`
+	Extends            []string             `toml:"extends,omitempty" json:"extends,omitempty" jsonschema:"description=Config files to extend (declaring file overrides extended files). Paths support ${VAR} environment variable expansion and glob patterns."`
+	Includes           RawIncludeSlice      `toml:"includes,omitempty" json:"includes,omitempty"`
+	Image              string               `toml:"image" json:"image" jsonschema:"description=Container image to use"`
+	ImageCheck         ImageCheckMode       `toml:"image_check,omitempty" json:"image_check,omitempty" jsonschema:"enum=never,enum=daily,enum=always,description=How often alca up checks the registry for a newer digest of the image tag"`
+	Platform           string               `toml:"platform,omitempty" json:"platform,omitempty" jsonschema:"description=Image platform to pull and run (os/arch[/variant], e.g. linux/arm64). Defaults to the runtime host's platform."`
+	RegistryMirror     string               `toml:"registry_mirror,omitempty" json:"registry_mirror,omitempty" jsonschema:"description=Pull-through cache for Docker Hub images (e.g. http://localhost:5000). Podman pulls through it directly; Docker must list it in the daemon's registry-mirrors."`
+	Workdir            string               `toml:"workdir,omitempty" json:"workdir,omitempty" jsonschema:"description=Working directory inside container"`
+	WorkdirSource      string               `toml:"workdir_source,omitempty" json:"workdir_source,omitempty" jsonschema:"description=Host directory mounted at workdir instead of the project directory (relative to the project directory). Supports ${VAR} environment variable expansion."`
+	WorkdirExclude     []string             `toml:"workdir_exclude,omitempty" json:"workdir_exclude,omitempty" jsonschema:"description=Patterns to exclude from workdir mount (requires Mutagen)"`
+	WorkdirExcludeFrom []string             `toml:"workdir_exclude_from,omitempty" json:"workdir_exclude_from,omitempty" jsonschema:"description=Ignore files (e.g. .gitignore or .alcaignore) whose patterns are added to workdir_exclude. Relative to the workdir source and re-read on every alca up."`
+	WorkdirSync        MountSync            `toml:"workdir_sync,omitempty" json:"workdir_sync,omitempty" jsonschema:"description=Mutagen session limits and symlink and permissions handling for the workdir mount (when synced)"`
+	Runtime            RuntimeType          `toml:"runtime,omitempty" json:"runtime,omitempty" jsonschema:"enum=auto,enum=docker,description=Container runtime selection"`
+	RuntimeContext     string               `toml:"runtime_context,omitempty" json:"runtime_context,omitempty" jsonschema:"description=Named Docker context or Podman system connection to run the container on (e.g. a remote ssh:// host)"`
+	User               string               `toml:"user,omitempty" json:"user,omitempty" jsonschema:"description=User that alca enter and alca run execute as (name or uid[:gid]). Defaults to the image's user."`
+	EnterTmux          bool                 `toml:"enter_tmux,omitempty" json:"enter_tmux,omitempty" jsonschema:"description=Attach every alca enter to one shared tmux (or zellij) session in the container, created on first enter"`
+	NestedContainers   NestedContainersMode `toml:"nested_containers,omitempty" json:"nested_containers,omitempty" jsonschema:"enum=none,enum=dind,enum=socket,description=Let the sandbox run containers: dind starts a privileged Docker daemon sidecar sharing the container's network, socket mounts the host runtime's socket (full control of the host daemon). Defaults to none."`
+	Userns             UsernsMode           `toml:"userns,omitempty" json:"userns,omitempty" jsonschema:"enum=keep-id,enum=auto,enum=host,description=User namespace of the container: keep-id maps the host user to the same uid in the container (Podman), auto maps container root to an unused unprivileged host range (Podman), host shares the host namespace and opts out of Docker's userns-remap. Defaults to the runtime's setting."`
+	Commands           RawCommands          `toml:"commands,omitempty" json:"commands,omitempty" jsonschema:"description=Lifecycle commands"`
+	Mounts             RawMountSlice        `toml:"mounts,omitempty" json:"mounts,omitempty"`
+	Resources          Resources            `toml:"resources,omitempty" json:"resources,omitempty" jsonschema:"description=Container resource limits"`
+	Envs               RawEnvValueMap       `toml:"envs,omitempty" json:"envs,omitempty"`
+	Network            RawNetwork           `toml:"network,omitempty" json:"network,omitempty" jsonschema:"description=Network configuration"`
+	SSH                SSH                  `toml:"ssh,omitempty" json:"ssh,omitempty" jsonschema:"description=SSH server in the container for tools that only speak SSH"`
+	Caps               RawCaps              `toml:"caps,omitempty" json:"caps,omitempty"`
+	Hooks              Hooks                `toml:"hooks,omitempty" json:"hooks,omitempty" jsonschema:"description=Host-side lifecycle hooks (run on host machine)"`
+	Rebuild            Rebuild              `toml:"rebuild,omitempty" json:"rebuild,omitempty" jsonschema:"description=Settings for alca rebuild"`
+	Clock              Clock                `toml:"clock,omitempty" json:"clock,omitempty" jsonschema:"description=Container clock drift check for VM-backed runtimes (macOS)"`
+	Timeouts           Timeouts             `toml:"timeouts,omitempty" json:"timeouts,omitempty" jsonschema:"description=Time limits for long-running operations"`
+	IdleTimeout        string               `toml:"idle_timeout,omitempty" json:"idle_timeout,omitempty" jsonschema:"description=Stop the container after no alca up or enter or run has used it for this long (at least 10m). Go duration such as 2h. The next alca enter or run starts it again."`
+	ContainerName      string               `toml:"container_name,omitempty" json:"container_name,omitempty" jsonschema:"description=Name of the project's container instead of the generated alca-<id>. Applied when the container is next created."`
+	NameTemplate       string               `toml:"name_template,omitempty" json:"name_template,omitempty" jsonschema:"description=Template for the container name with {project.name} and {project.id} and {branch} placeholders (e.g. alca-{project.name}-{branch}). Applied when the container is next created."`
+	Plugins            []string             `toml:"plugins,omitempty" json:"plugins,omitempty" jsonschema:"description=Plugins (alca-<name> executables on PATH) notified on lifecycle events"`
+	Cron               map[string]string    `toml:"cron,omitempty" json:"cron,omitempty" jsonschema:"description=Scheduled tasks: a cron schedule (optionally prefixed with CRON_TZ=<zone>) mapped to a shell command run in the container. Schedules use the host time zone."`
+	ForwardFileEvents  bool                 `toml:"forward_file_events,omitempty" json:"forward_file_events,omitempty" jsonschema:"description=Watch bind-mounted host directories and touch changed files in the container so file watchers see edits made on the host (macOS)"`
+	UI                 UI                   `toml:"ui,omitempty" json:"ui,omitempty" jsonschema:"description=Terminal title and shell prompt of alca enter"`
+}
+
+// LoadOption configures how config files are loaded.
+type LoadOption func(*loadOptions)
+
+// loadOptions holds the LoadOptions of one load.
+type loadOptions struct {
+	storedAgeIdentity func() (string, error)
+	insecureIncludes  bool
+}
+
+// newLoadOptions applies opts.
+func newLoadOptions(opts []LoadOption) loadOptions {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithStoredAgeIdentity makes encrypted configs fall back to the age
+// identities lookup returns when neither EnvAgeIdentity nor
+// EnvAgeIdentityFile is set. lookup returns "" when nothing is stored; it
+// is only called for an encrypted config.
+func WithStoredAgeIdentity(lookup func() (string, error)) LoadOption {
+	return func(o *loadOptions) { o.storedAgeIdentity = lookup }
+}
+
+// WithInsecureIncludes loads shared configs without verifying their
+// signatures (--insecure-includes).
+func WithInsecureIncludes() LoadOption {
+	return func(o *loadOptions) { o.insecureIncludes = true }
 }
 
 // LoadConfig reads and parses a configuration file from the given path.
@@ -421,11 +724,28 @@ type RawConfig struct {
 // Applies defaults for missing fields: runtime defaults to "auto", workdir to "/workspace".
 // Normalizes workdir into Mounts[0] with any excludes.
 // expandEnv expands ${VAR} references in include/extend paths (use os.ExpandEnv for production).
-func LoadConfig(env *util.Env, path string, expandEnv func(string) (string, error)) (Config, error) {
-	cfg, err := LoadWithIncludes(env, path, expandEnv)
+func LoadConfig(env *util.Env, path string, expandEnv func(string) (string, error), opts ...LoadOption) (Config, error) {
+	return LoadConfigWithOverrides(env, path, expandEnv, nil, opts...)
+}
+
+// LoadConfigWithOverrides is LoadConfig with overrides (alca up --set) layered
+// on the merged config as if from a last include, before validation.
+func LoadConfigWithOverrides(env *util.Env, path string, expandEnv func(string) (string, error), overrides []Override, opts ...LoadOption) (Config, error) {
+	cfg, err := LoadWithIncludes(env, path, expandEnv, opts...)
 	if err != nil {
 		return Config{}, err
 	}
+	if len(overrides) > 0 {
+		if cfg, err = applyOverrides(cfg, overrides, expandEnv); err != nil {
+			return Config{}, fmt.Errorf("--set: %w", err)
+		}
+	}
+	if err := resolveNetworkPreset(env.Fs, &cfg); err != nil {
+		return Config{}, err
+	}
+	if err := normalizeNetworkIsolation(&cfg.Network); err != nil {
+		return Config{}, err
+	}
 
 	// Validate required fields
 	if cfg.Image == "" {
@@ -447,6 +767,106 @@ func LoadConfig(env *util.Env, path string, expandEnv func(string) (string, erro
 		}
 	}
 
+	if err := ValidateMountSync(cfg.WorkdirSync); err != nil {
+		return Config{}, fmt.Errorf("workdir_sync: %w", err)
+	}
+
+	// Validate image check mode
+	switch cfg.ImageCheck {
+	case "", ImageCheckNever, ImageCheckDaily, ImageCheckAlways:
+	default:
+		return Config{}, fmt.Errorf("image_check %q: must be never, daily, or always: %w", cfg.ImageCheck, ErrInvalidImageCheck)
+	}
+
+	// Validate nested containers mode
+	switch cfg.Userns {
+	case "", UsernsKeepID, UsernsAuto, UsernsHost:
+	default:
+		return Config{}, fmt.Errorf("userns %q: must be keep-id, auto, or host: %w", cfg.Userns, ErrInvalidUserns)
+	}
+
+	switch cfg.NestedContainers {
+	case "", NestedContainersNone, NestedContainersDind, NestedContainersSocket:
+	default:
+		return Config{}, fmt.Errorf("nested_containers %q: must be none, dind, or socket: %w", cfg.NestedContainers, ErrInvalidNestedContainers)
+	}
+
+	// Validate platform (os/arch[/variant])
+	if cfg.Platform != "" && !platformPattern.MatchString(cfg.Platform) {
+		return Config{}, fmt.Errorf("platform %q: must be os/arch[/variant], e.g. linux/arm64: %w", cfg.Platform, ErrInvalidPlatform)
+	}
+
+	// Validate registry mirror (http(s)://host[:port])
+	if m := cfg.RegistryMirror; m != "" {
+		if u, err := url.Parse(m); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+			return Config{}, fmt.Errorf("registry_mirror %q: must be http(s)://host[:port], e.g. http://localhost:5000: %w", m, ErrInvalidRegistryMirror)
+		}
+	}
+
+	// Validate clock drift threshold
+	if t := cfg.Clock.DriftThreshold; t != "" {
+		if d, err := time.ParseDuration(t); err != nil || d < 0 {
+			return Config{}, fmt.Errorf("clock.drift_threshold %q: must be a non-negative duration such as 5s: %w", t, ErrInvalidClockDrift)
+		}
+	}
+
+	// Validate timeouts
+	for _, t := range []struct{ name, value string }{
+		{"timeouts.pull", cfg.Timeouts.Pull},
+		{"timeouts.up_command", cfg.Timeouts.UpCommand},
+	} {
+		if t.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(t.value); err != nil || d <= 0 {
+			return Config{}, fmt.Errorf("%s %q: must be a positive duration such as 10m: %w", t.name, t.value, ErrInvalidTimeout)
+		}
+	}
+
+	// Validate idle timeout
+	if t := cfg.IdleTimeout; t != "" {
+		if d, err := time.ParseDuration(t); err != nil || d < MinIdleTimeout {
+			return Config{}, fmt.Errorf("idle_timeout %q: must be a duration of at least %s, such as 2h: %w", t, MinIdleTimeout, ErrInvalidTimeout)
+		}
+	}
+
+	if err := validateUI(cfg.UI); err != nil {
+		return Config{}, err
+	}
+
+	if err := validateCgroup(cfg.Resources.Cgroup); err != nil {
+		return Config{}, err
+	}
+
+	// Validate container naming
+	if err := validateContainerNaming(cfg.ContainerName, cfg.NameTemplate); err != nil {
+		return Config{}, err
+	}
+
+	// Validate SSH port
+	if p := cfg.SSH.Port; p < 0 || p > 65535 {
+		return Config{}, fmt.Errorf("ssh.port %d: must be 1-65535: %w", p, ErrInvalidPort)
+	}
+
+	// Validate rebuild preserve paths
+	for _, p := range cfg.Rebuild.Preserve {
+		if !strings.HasPrefix(p, "/") {
+			return Config{}, fmt.Errorf("rebuild.preserve %q: must be an absolute container path: %w", p, ErrInvalidPreservePath)
+		}
+	}
+
+	// Validate cron schedules
+	if err := validateCron(cfg.Cron); err != nil {
+		return Config{}, err
+	}
+
+	// Validate plugin names
+	for _, name := range cfg.Plugins {
+		if !pluginNamePattern.MatchString(name) {
+			return Config{}, fmt.Errorf("plugins %q: must be lowercase letters, digits and dashes: %w", name, ErrInvalidPluginName)
+		}
+	}
+
 	// Validate alca tokens in lan-access rules (AGD-036)
 	for _, rule := range cfg.Network.LANAccess {
 		if err := ValidateAlcaTokens(rule); err != nil {
@@ -454,6 +874,12 @@ func LoadConfig(env *util.Env, path string, expandEnv func(string) (string, erro
 		}
 	}
 
+	for _, peer := range cfg.Network.Peers {
+		if strings.TrimSpace(peer) == "" {
+			return Config{}, fmt.Errorf("network.peers: empty path: %w", ErrInvalidPeer)
+		}
+	}
+
 	// Validate port mappings
 	if err := ValidatePorts(cfg.Network.Ports); err != nil {
 		return Config{}, fmt.Errorf("network: %w", err)
@@ -466,6 +892,14 @@ func LoadConfig(env *util.Env, path string, expandEnv func(string) (string, erro
 		}
 	}
 
+	if err := ValidateShaping(cfg.Network.Shaping); err != nil {
+		return Config{}, fmt.Errorf("network.shaping: %w", err)
+	}
+
+	if err := ValidateCaps(cfg.Caps); err != nil {
+		return Config{}, err
+	}
+
 	// Apply default caps if not specified (AGD-026)
 	// Empty Caps means no caps field was in config - apply secure defaults
 	if len(cfg.Caps.Drop) == 0 && len(cfg.Caps.Add) == 0 {
@@ -476,12 +910,26 @@ func LoadConfig(env *util.Env, path string, expandEnv func(string) (string, erro
 	}
 
 	// Normalize: insert workdir as Mounts[0]
+	workdirSource := "."
+	if cfg.WorkdirSource != "" {
+		workdirSource = cfg.WorkdirSource
+	}
 	workdirMount := MountConfig{
-		Source:  ".",
-		Target:  cfg.Workdir,
-		Exclude: cfg.WorkdirExclude,
+		Source:      workdirSource,
+		Target:      cfg.Workdir,
+		Exclude:     cfg.WorkdirExclude,
+		ExcludeFrom: cfg.WorkdirExcludeFrom,
+		Sync:        cfg.WorkdirSync,
 	}
 	cfg.Mounts = append([]MountConfig{workdirMount}, cfg.Mounts...)
 
+	// Read exclude_from ignore files into the mounts' excludes
+	if err := resolveExcludeFrom(env.Fs, filepath.Dir(path), cfg.Mounts); err != nil {
+		return Config{}, err
+	}
+
+	// Mask sensitive env values in everything alca prints from here on
+	util.RegisterSecret(cfg.SensitiveValues(os.Getenv)...)
+
 	return cfg, nil
 }
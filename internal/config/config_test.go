@@ -26,6 +26,7 @@ mounts = ["/host:/container", "/data:/data"]
 [commands]
 up = "apt update"
 enter = "bash"
+test = "make test"
 `
 	env, memFs := newTestEnv(t)
 	path := "/test/.alca.toml"
@@ -50,6 +51,9 @@ enter = "bash"
 	if cfg.Commands.Enter.Command != "bash" {
 		t.Errorf("expected commands.enter 'bash', got %q", cfg.Commands.Enter.Command)
 	}
+	if cfg.Commands.Test.Command != "make test" {
+		t.Errorf("expected commands.test 'make test', got %q", cfg.Commands.Test.Command)
+	}
 	// Mounts[0] is the workdir mount (normalized), user mounts follow
 	if len(cfg.Mounts) != 3 {
 		t.Errorf("expected 3 mounts (workdir + 2 user), got %d", len(cfg.Mounts))
@@ -270,6 +274,80 @@ func TestConfigMergedEnvs(t *testing.T) {
 	})
 }
 
+func TestConfigResolvedEnvs(t *testing.T) {
+	cfg := Config{
+		Envs: map[string]EnvValue{
+			"STATIC":  {Value: "value"},
+			"FROMENV": {Value: "${HOST_VAR}", OverrideOnEnter: true},
+			"UNSET":   {Value: "${MISSING_VAR}"},
+		},
+	}
+	getenv := func(key string) string {
+		if key == "HOST_VAR" {
+			return "host-value"
+		}
+		return ""
+	}
+
+	t.Run("all envs expanded, empty omitted", func(t *testing.T) {
+		resolved := cfg.ResolvedEnvs(getenv, false)
+		if resolved["STATIC"] != "value" {
+			t.Errorf("STATIC = %q, want %q", resolved["STATIC"], "value")
+		}
+		if resolved["FROMENV"] != "host-value" {
+			t.Errorf("FROMENV = %q, want %q", resolved["FROMENV"], "host-value")
+		}
+		if _, ok := resolved["UNSET"]; ok {
+			t.Error("expected UNSET to be omitted")
+		}
+		// Default envs expand to empty with this getenv and are omitted
+		if _, ok := resolved["TERM"]; ok {
+			t.Error("expected empty default TERM to be omitted")
+		}
+	})
+
+	t.Run("enter only", func(t *testing.T) {
+		resolved := cfg.ResolvedEnvs(getenv, true)
+		if len(resolved) != 1 || resolved["FROMENV"] != "host-value" {
+			t.Errorf("expected only FROMENV, got %v", resolved)
+		}
+	})
+}
+
+func TestLoadConfig_SensitiveEnvIsRedacted(t *testing.T) {
+	util.ResetSecrets()
+	t.Cleanup(util.ResetSecrets)
+	t.Setenv("ALCA_TEST_TOKEN", "tok-1234567890")
+	content := `
+image = "ubuntu:latest"
+
+[envs]
+PLAIN = "visible-value"
+
+[envs.TOKEN]
+value = "${ALCA_TEST_TOKEN}"
+sensitive = true
+`
+	env, memFs := newTestEnv(t)
+	if err := afero.WriteFile(memFs, "/test/.alca.toml", []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(env, "/test/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !cfg.Envs["TOKEN"].Sensitive {
+		t.Error("expected TOKEN to be sensitive")
+	}
+	if got := util.Redact("-e TOKEN=tok-1234567890 -e PLAIN=visible-value"); got != "-e TOKEN="+util.RedactedValue+" -e PLAIN=visible-value" {
+		t.Errorf("Redact() = %q, want only the sensitive value masked", got)
+	}
+	if raw, ok := cfg.ToRaw().Envs["TOKEN"].(EnvValue); !ok || !raw.Sensitive {
+		t.Errorf("ToRaw() TOKEN = %#v, want the object form keeping sensitive", cfg.ToRaw().Envs["TOKEN"])
+	}
+}
+
 func TestConfigValidateEnvs(t *testing.T) {
 	t.Run("valid envs", func(t *testing.T) {
 		cfg := Config{
@@ -584,3 +662,147 @@ func TestHooksEqual(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfig_ImageCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    ImageCheckMode
+		wantErr bool
+	}{
+		{"default", `image = "ubuntu:latest"`, ImageCheckNever, false},
+		{"daily", "image = \"ubuntu:latest\"\nimage_check = \"daily\"", ImageCheckDaily, false},
+		{"always", "image = \"ubuntu:latest\"\nimage_check = \"always\"", ImageCheckAlways, false},
+		{"invalid", "image = \"ubuntu:latest\"\nimage_check = \"weekly\"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			_ = afero.WriteFile(memFs, "/project/.alca.toml", []byte(tt.content), 0644)
+
+			cfg, err := LoadConfig(env, "/project/.alca.toml", noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidImageCheck) {
+					t.Fatalf("expected ErrInvalidImageCheck, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error: %v", err)
+			}
+			if got := cfg.NormalizeImageCheck(); got != tt.want {
+				t.Errorf("NormalizeImageCheck() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_NestedContainers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    NestedContainersMode
+		wantErr bool
+	}{
+		{"default", `image = "ubuntu:latest"`, NestedContainersNone, false},
+		{"dind", "image = \"ubuntu:latest\"\nnested_containers = \"dind\"", NestedContainersDind, false},
+		{"socket", "image = \"ubuntu:latest\"\nnested_containers = \"socket\"", NestedContainersSocket, false},
+		{"invalid", "image = \"ubuntu:latest\"\nnested_containers = \"sysbox\"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			_ = afero.WriteFile(memFs, "/project/.alca.toml", []byte(tt.content), 0644)
+
+			cfg, err := LoadConfig(env, "/project/.alca.toml", noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidNestedContainers) {
+					t.Fatalf("expected ErrInvalidNestedContainers, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error: %v", err)
+			}
+			if got := cfg.NestedContainers.OrNone(); got != tt.want {
+				t.Errorf("NestedContainers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Userns(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    UsernsMode
+		wantErr bool
+	}{
+		{"default", `image = "ubuntu:latest"`, "", false},
+		{"keep-id", "image = \"ubuntu:latest\"\nuserns = \"keep-id\"", UsernsKeepID, false},
+		{"auto", "image = \"ubuntu:latest\"\nuserns = \"auto\"", UsernsAuto, false},
+		{"host", "image = \"ubuntu:latest\"\nuserns = \"host\"", UsernsHost, false},
+		{"invalid", "image = \"ubuntu:latest\"\nuserns = \"private\"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			_ = afero.WriteFile(memFs, "/project/.alca.toml", []byte(tt.content), 0644)
+
+			cfg, err := LoadConfig(env, "/project/.alca.toml", noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidUserns) {
+					t.Fatalf("expected ErrInvalidUserns, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error: %v", err)
+			}
+			if cfg.Userns != tt.want {
+				t.Errorf("Userns = %q, want %q", cfg.Userns, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RebuildPreserve(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	content := "image = \"ubuntu:latest\"\n\n[rebuild]\npreserve = [\"/root/.cache\"]\n"
+	_ = afero.WriteFile(memFs, "/project/.alca.toml", []byte(content), 0644)
+
+	cfg, err := LoadConfig(env, "/project/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if len(cfg.Rebuild.Preserve) != 1 || cfg.Rebuild.Preserve[0] != "/root/.cache" {
+		t.Errorf("Rebuild.Preserve = %v, want [/root/.cache]", cfg.Rebuild.Preserve)
+	}
+
+	content = "image = \"ubuntu:latest\"\n\n[rebuild]\npreserve = [\"~/.cache\"]\n"
+	_ = afero.WriteFile(memFs, "/project/.alca.toml", []byte(content), 0644)
+	if _, err := LoadConfig(env, "/project/.alca.toml", noExpandEnv); !errors.Is(err, ErrInvalidPreservePath) {
+		t.Errorf("expected ErrInvalidPreservePath, got %v", err)
+	}
+}
+
+func TestLoadConfig_Plugins(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	_ = afero.WriteFile(memFs, "/project/.alca.toml", []byte("image = \"ubuntu:latest\"\nplugins = [\"notify\", \"port-forward\"]\n"), 0644)
+
+	cfg, err := LoadConfig(env, "/project/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if len(cfg.Plugins) != 2 || cfg.Plugins[1] != "port-forward" {
+		t.Errorf("Plugins = %v, want [notify port-forward]", cfg.Plugins)
+	}
+
+	_ = afero.WriteFile(memFs, "/project/.alca.toml", []byte("image = \"ubuntu:latest\"\nplugins = [\"../evil\"]\n"), 0644)
+	if _, err := LoadConfig(env, "/project/.alca.toml", noExpandEnv); !errors.Is(err, ErrInvalidPluginName) {
+		t.Errorf("expected ErrInvalidPluginName, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Package configtest builds config fixtures for tests and compares
+// rendered configs against golden files. It is for tests only: importing it
+// registers the -update flag.
+package configtest
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ProjectDir is the directory Fixture writes relative paths under.
+const ProjectDir = "/project"
+
+// update is set by 'go test -update' to rewrite golden files.
+var update = flag.Bool("update", false, "rewrite golden files instead of comparing against them")
+
+// Fixture is a set of config files keyed by path, relative to ProjectDir
+// unless absolute, such as
+//
+//	configtest.Fixture{
+//		".alca.toml":       `image = "alpine"` + "\n" + `includes = [".alca.*.toml"]`,
+//		".alca.dev.toml":   `[resources]` + "\n" + `memory = "4g"`,
+//		"/home/me/x.toml":  `...`,
+//	}
+type Fixture map[string]string
+
+// ConfigPath is the path of the project config a fixture is loaded from.
+var ConfigPath = filepath.Join(ProjectDir, ".alca.toml")
+
+// Write writes the fixture's files to fsys, in path order.
+func (f Fixture) Write(t testing.TB, fsys afero.Fs) {
+	t.Helper()
+	paths := make([]string, 0, len(f))
+	for p := range f {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		path := p
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(ProjectDir, path)
+		}
+		if err := fsys.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+		if err := afero.WriteFile(fsys, path, []byte(f[p]), 0o644); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+	}
+}
+
+// Env writes the fixture to an in-memory filesystem and returns an Env
+// on it with a mock command runner.
+func (f Fixture) Env(t testing.TB) *util.Env {
+	t.Helper()
+	env := util.NewTestEnv()
+	f.Write(t, env.Fs)
+	return env
+}
+
+// Load writes the fixture to an in-memory filesystem and loads the project
+// config from it, with ${VAR} references left unexpanded.
+func (f Fixture) Load(t testing.TB) *config.Config {
+	t.Helper()
+	cfg, err := config.LoadConfig(f.Env(t), ConfigPath, noExpandEnv)
+	if err != nil {
+		t.Fatalf("configtest: loading fixture: %v", err)
+	}
+	return &cfg
+}
+
+func noExpandEnv(s string) (string, error) { return s, nil }
+
+// AssertGolden compares got with the golden file at path on fsys (usually
+// afero.NewOsFs() and a testdata path). With -update the file is written
+// instead. On a mismatch the test fails with a unified diff.
+func AssertGolden(t testing.TB, fsys afero.Fs, path string, got string) {
+	t.Helper()
+	if *update {
+		if err := fsys.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+		if err := afero.WriteFile(fsys, path, []byte(got), 0o644); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+		return
+	}
+
+	want, err := afero.ReadFile(fsys, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist; run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("configtest: %v", err)
+	}
+	if diff := util.UnifiedDiff(path, "got", string(want), got, 3); diff != "" {
+		t.Errorf("output differs from golden file (run with -update to accept):\n%s", diff)
+	}
+}
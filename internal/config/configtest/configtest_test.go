@@ -0,0 +1,42 @@
+package configtest
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestFixtureLoad(t *testing.T) {
+	cfg := Fixture{
+		".alca.toml":      "image = \"alpine\"\nincludes = [\"./.alca.*.toml\"]\n",
+		".alca.dev.toml":  "[envs]\nTOKEN = \"${TOKEN}\"\n",
+		"/elsewhere.toml": "image = \"ignored\"\n",
+	}.Load(t)
+
+	if cfg.Image != "alpine" {
+		t.Errorf("Image = %q, want alpine", cfg.Image)
+	}
+	if got := cfg.Envs["TOKEN"].Value; got != "${TOKEN}" {
+		t.Errorf("TOKEN = %q, want the reference left unexpanded", got)
+	}
+}
+
+func TestFixtureWrite_RelativeToProjectDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	Fixture{".alca.toml": "a", "/abs/x.toml": "b"}.Write(t, fs)
+
+	for path, want := range map[string]string{ConfigPath: "a", "/abs/x.toml": "b"} {
+		got, err := afero.ReadFile(fs, path)
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", path, got, err, want)
+		}
+	}
+}
+
+func TestAssertGolden_Matches(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/testdata/x.golden", []byte("image = 'alpine'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	AssertGolden(t, fs, "/testdata/x.golden", "image = 'alpine'\n")
+}
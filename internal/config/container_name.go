@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholders of name_template.
+const (
+	NamePlaceholderProjectName = "{project.name}"
+	NamePlaceholderProjectID   = "{project.id}"
+	NamePlaceholderBranch      = "{branch}"
+)
+
+// containerNamePattern is what Docker and Podman accept as a container name.
+var containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// namePlaceholderPattern matches any {...} in a template, known or not.
+var namePlaceholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ContainerNameVars are the values substituted into name_template.
+type ContainerNameVars struct {
+	ProjectName string // base name of the project directory
+	ProjectID   string // short project ID, as in the generated name
+	Branch      string // current git branch, empty outside a repository
+}
+
+// ContainerNameFor returns the configured container name, rendering
+// name_template with vars, or "" when neither container_name nor
+// name_template is set. Characters a container name cannot hold are replaced
+// with '-', so a branch such as feature/x yields feature-x.
+func (c *Config) ContainerNameFor(vars ContainerNameVars) string {
+	if c.ContainerName != "" {
+		return c.ContainerName
+	}
+	if c.NameTemplate == "" {
+		return ""
+	}
+	name := strings.NewReplacer(
+		NamePlaceholderProjectName, vars.ProjectName,
+		NamePlaceholderProjectID, vars.ProjectID,
+		NamePlaceholderBranch, vars.Branch,
+	).Replace(c.NameTemplate)
+	return sanitizeContainerName(name)
+}
+
+// sanitizeContainerName replaces invalid characters with '-', collapses
+// repeated dashes (left by an empty placeholder) and trims separators from
+// both ends.
+func sanitizeContainerName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.':
+			b.WriteRune(r)
+			dash = false
+		case !dash:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.Trim(b.String(), "-_.")
+}
+
+// validateContainerNaming checks container_name and name_template.
+func validateContainerNaming(name, template string) error {
+	if name != "" && template != "" {
+		return fmt.Errorf("container_name and name_template cannot both be set: %w", ErrInvalidContainerName)
+	}
+	if name != "" && !containerNamePattern.MatchString(name) {
+		return fmt.Errorf("container_name %q: must start with a letter or digit and contain only letters, digits, '_', '.' and '-': %w", name, ErrInvalidContainerName)
+	}
+	if template == "" {
+		return nil
+	}
+	for _, p := range namePlaceholderPattern.FindAllString(template, -1) {
+		switch p {
+		case NamePlaceholderProjectName, NamePlaceholderProjectID, NamePlaceholderBranch:
+		default:
+			return fmt.Errorf("name_template %q: unknown placeholder %s (use %s, %s or %s): %w", template, p,
+				NamePlaceholderProjectName, NamePlaceholderProjectID, NamePlaceholderBranch, ErrInvalidContainerName)
+		}
+	}
+	// The literal text alone must yield a name
+	if sanitizeContainerName(namePlaceholderPattern.ReplaceAllString(template, "x")) == "" {
+		return fmt.Errorf("name_template %q: produces an empty name: %w", template, ErrInvalidContainerName)
+	}
+	return nil
+}
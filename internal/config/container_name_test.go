@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_ContainerNaming(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"unset", "", false},
+		{"name", "container_name = \"myproj-dev\"\n", false},
+		{"template", "name_template = \"alca-{project.name}-{branch}\"\n", false},
+		{"both", "container_name = \"a\"\nname_template = \"b-{branch}\"\n", true},
+		{"invalid name", "container_name = \"my proj\"\n", true},
+		{"leading dash", "container_name = \"-dev\"\n", true},
+		{"unknown placeholder", "name_template = \"alca-{user}\"\n", true},
+		{"empty literal", "name_template = \"--\"\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			if err := afero.WriteFile(memFs, path, []byte("image = \"alpine\"\n"+tt.content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidContainerName) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidContainerName", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestContainerNameFor(t *testing.T) {
+	vars := ContainerNameVars{ProjectName: "My App", ProjectID: "0123456789ab", Branch: "feature/login"}
+	tests := []struct {
+		name string
+		cfg  Config
+		vars ContainerNameVars
+		want string
+	}{
+		{"unset", Config{}, vars, ""},
+		{"name", Config{ContainerName: "myproj-dev"}, vars, "myproj-dev"},
+		{"template", Config{NameTemplate: "alca-{project.name}-{branch}"}, vars, "alca-My-App-feature-login"},
+		{"project id", Config{NameTemplate: "dev.{project.id}"}, vars, "dev.0123456789ab"},
+		{"no branch", Config{NameTemplate: "alca-{project.name}-{branch}"}, ContainerNameVars{ProjectName: "app"}, "alca-app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ContainerNameFor(tt.vars); got != tt.want {
+				t.Errorf("ContainerNameFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
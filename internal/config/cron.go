@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/cron"
+)
+
+// CronEntry is one [cron] task.
+type CronEntry struct {
+	// Spec is the schedule as written in the config.
+	Spec     string
+	Schedule *cron.Schedule
+	// Command is run with sh -c in the container.
+	Command string
+}
+
+// CronEntries returns the parsed [cron] tasks in schedule order. Schedules
+// without CRON_TZ= use local, normally time.Local (the host's zone).
+func (c *Config) CronEntries(local *time.Location) ([]CronEntry, error) {
+	entries := make([]CronEntry, 0, len(c.Cron))
+	for spec, command := range c.Cron {
+		schedule, err := cron.Parse(spec, local)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w: %w", spec, ErrInvalidCron, err)
+		}
+		entries = append(entries, CronEntry{Spec: spec, Schedule: schedule, Command: command})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Spec < entries[j].Spec })
+	return entries, nil
+}
+
+func validateCron(tasks map[string]string) error {
+	for spec, command := range tasks {
+		if _, err := cron.Parse(spec, time.UTC); err != nil {
+			return fmt.Errorf("cron %q: %w: %w", spec, ErrInvalidCron, err)
+		}
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("cron %q: empty command: %w", spec, ErrInvalidCron)
+		}
+	}
+	return nil
+}
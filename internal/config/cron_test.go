@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCronEntries(t *testing.T) {
+	cfg := &Config{Cron: map[string]string{
+		"@hourly":   "make snapshot",
+		"0 9 * * 1": "./report",
+	}}
+	entries, err := cfg.CronEntries(time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Spec != "0 9 * * 1" || entries[1].Command != "make snapshot" {
+		t.Errorf("entries = %+v", entries)
+	}
+	if entries[0].Schedule.Location != time.UTC {
+		t.Errorf("Location = %s, want the given local zone", entries[0].Schedule.Location)
+	}
+}
+
+func TestValidateCron(t *testing.T) {
+	if err := validateCron(map[string]string{"0 * * * *": "date"}); err != nil {
+		t.Errorf("valid entry: %v", err)
+	}
+	for _, tasks := range []map[string]string{
+		{"0 * * *": "date"},
+		{"0 * * * *": "  "},
+	} {
+		if err := validateCron(tasks); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("%v: expected ErrInvalidCron, got %v", tasks, err)
+		}
+	}
+}
+
+func TestMergeConfigs_Cron(t *testing.T) {
+	base := Config{Cron: map[string]string{"@hourly": "make snapshot", "@daily": "make prune"}}
+	overlay := Config{Cron: map[string]string{"@daily": "make gc", "@weekly": "make report"}}
+	result := mergeConfigs(base, overlay)
+	want := map[string]string{"@hourly": "make snapshot", "@daily": "make gc", "@weekly": "make report"}
+	if len(result.Cron) != len(want) {
+		t.Fatalf("Cron = %v, want %v", result.Cron, want)
+	}
+	for k, v := range want {
+		if result.Cron[k] != v {
+			t.Errorf("Cron[%q] = %q, want %q", k, result.Cron[k], v)
+		}
+	}
+	if base.Cron["@daily"] != "make prune" {
+		t.Error("merge modified the base config")
+	}
+}
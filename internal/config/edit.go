@@ -0,0 +1,421 @@
+// edit.go implements alca config get/set/unset: reading and rewriting single
+// keys of a config file in place, so the comments and formatting around
+// them survive.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// docEntry is a key = value expression of a TOML document, located by byte
+// offsets.
+type docEntry struct {
+	path       []string // full key, table prefix included
+	start      int      // start of the line the key is on
+	valueStart int
+	valueEnd   int
+	lineEnd    int // past the newline ending the value's line
+}
+
+// docSection is a table header and the expressions under it. The first
+// section is the root table, which has no header.
+type docSection struct {
+	path  []string
+	array bool // [[array.of.tables]]
+	start int  // start of the header line
+	end   int  // past the last line of the section's expressions (or header)
+}
+
+// docLayout is where the tables and keys of a TOML document are.
+type docLayout struct {
+	sections []docSection
+	entries  []docEntry
+}
+
+// bareKeyPattern matches keys that need no quoting.
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// parseLayout locates the tables and keys of data.
+func parseLayout(data []byte) (*docLayout, error) {
+	layout := &docLayout{sections: []docSection{{}}}
+	var p unstable.Parser
+	p.Reset(data)
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			keys, first, last := keyNodes(expr.Key())
+			end := lineEnd(data, last)
+			layout.sections = append(layout.sections, docSection{
+				path:  keys,
+				array: expr.Kind == unstable.ArrayTable,
+				start: lineStart(data, first),
+				end:   end,
+			})
+		case unstable.KeyValue:
+			keys, first, last := keyNodes(expr.Key())
+			section := &layout.sections[len(layout.sections)-1]
+			valueStart := skipAssign(data, last)
+			valueEnd := scanValueEnd(data, valueStart)
+			e := docEntry{
+				path:       append(slices.Clone(section.path), keys...),
+				start:      lineStart(data, first),
+				valueStart: valueStart,
+				valueEnd:   valueEnd,
+				lineEnd:    lineEnd(data, valueEnd),
+			}
+			layout.entries = append(layout.entries, e)
+			section.end = e.lineEnd
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+// keyNodes returns the segments of a key and the offsets of its first byte
+// and past its last.
+func keyNodes(it unstable.Iterator) (keys []string, start, end int) {
+	start = -1
+	for it.Next() {
+		n := it.Node()
+		keys = append(keys, string(n.Data))
+		if start < 0 {
+			start = int(n.Raw.Offset)
+		}
+		end = int(n.Raw.Offset + n.Raw.Length)
+	}
+	return keys, start, end
+}
+
+func lineStart(data []byte, i int) int {
+	return bytes.LastIndexByte(data[:i], '\n') + 1
+}
+
+// lineEnd returns the offset past the newline ending the line i is on.
+func lineEnd(data []byte, i int) int {
+	if n := bytes.IndexByte(data[i:], '\n'); n >= 0 {
+		return i + n + 1
+	}
+	return len(data)
+}
+
+// skipAssign returns the offset of the value after a key ending at i.
+func skipAssign(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '=') {
+		i++
+	}
+	return i
+}
+
+// scanValueEnd returns the offset past the value starting at i, in a
+// document known to parse.
+func scanValueEnd(data []byte, i int) int {
+	rest := data[i:]
+	switch {
+	case bytes.HasPrefix(rest, []byte(`"""`)):
+		return scanStringEnd(data, i+3, `"""`, true)
+	case bytes.HasPrefix(rest, []byte(`'''`)):
+		return scanStringEnd(data, i+3, `'''`, false)
+	case rest[0] == '"':
+		return scanStringEnd(data, i+1, `"`, true)
+	case rest[0] == '\'':
+		return scanStringEnd(data, i+1, `'`, false)
+	case rest[0] == '[' || rest[0] == '{':
+		depth := 0
+		for i < len(data) {
+			switch data[i] {
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			case '"', '\'':
+				i = scanValueEnd(data, i)
+				continue
+			case '#':
+				i = lineEnd(data, i)
+				continue
+			}
+			i++
+		}
+		return i
+	default:
+		for i < len(data) && !strings.ContainsRune(" \t\r\n#,]}", rune(data[i])) {
+			i++
+		}
+		return i
+	}
+}
+
+func scanStringEnd(data []byte, i int, delim string, escapes bool) int {
+	for i < len(data) {
+		if escapes && data[i] == '\\' {
+			i += 2
+			continue
+		}
+		if bytes.HasPrefix(data[i:], []byte(delim)) {
+			i += len(delim)
+			// A multiline string may end in up to two more quotes: """a"""""
+			for n := 0; len(delim) == 3 && n < 2 && i < len(data) && data[i] == delim[0]; n++ {
+				i++
+			}
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+func (l *docLayout) entry(path []string) *docEntry {
+	for i := range l.entries {
+		if slices.Equal(l.entries[i].path, path) {
+			return &l.entries[i]
+		}
+	}
+	return nil
+}
+
+// hasPrefix reports whether prefix is a leading part of path.
+func hasPrefix(path, prefix []string) bool {
+	return len(prefix) <= len(path) && slices.Equal(path[:len(prefix)], prefix)
+}
+
+// GetKey returns the value a config file sets for a dotted key, decoded as
+// TOML (string, int64, bool, []any or map[string]any).
+func GetKey(data []byte, path []string) (any, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var v any = doc
+	for _, seg := range path {
+		table, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", strings.Join(path, "."), ErrKeyNotFound)
+		}
+		if v, ok = table[seg]; !ok {
+			return nil, fmt.Errorf("%s: %w", strings.Join(path, "."), ErrKeyNotFound)
+		}
+	}
+	return v, nil
+}
+
+// SetKey returns data with o applied: an existing value is replaced (or
+// appended to) in place, and a new key goes under its table's header, or
+// under a new header at the end. Everything else in data is kept as is,
+// except the comments inside a multi-line array that is appended to.
+func SetKey(data []byte, o Override) ([]byte, error) {
+	key := strings.Join(o.Path, ".")
+	if _, err := decodeOverrides([]Override{o}); err != nil {
+		return nil, err
+	}
+	layout, err := parseLayout(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	if e := layout.entry(o.Path); e != nil {
+		text, err := replacementValue(data[e.valueStart:e.valueEnd], o)
+		if err != nil {
+			return nil, err
+		}
+		out = splice(data, e.valueStart, e.valueEnd, text)
+	} else {
+		if out, err = insertKey(data, layout, o); err != nil {
+			return nil, err
+		}
+	}
+
+	// The layout rules above cover hand-written configs; refuse anything
+	// they get wrong rather than write a broken file
+	var doc map[string]any
+	if err := toml.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("%s: cannot place the key in this file, edit it by hand (%v): %w", key, err, ErrInvalidConfigEdit)
+	}
+	return out, nil
+}
+
+// replacementValue renders the new value for an existing one.
+func replacementValue(existing []byte, o Override) (string, error) {
+	if !o.Append {
+		return FormatValue(o.Value)
+	}
+	old := ParseValue(string(existing))
+	items, ok := old.([]any)
+	if !ok {
+		return "", fmt.Errorf("%s is not an array: %w", strings.Join(o.Path, "."), ErrInvalidConfigEdit)
+	}
+	added := appendedItems(o.Value)
+	if bytes.IndexByte(existing, '\n') >= 0 {
+		// Re-encoding a multi-line array drops its comments; acceptable for
+		// the rare hand-formatted list
+		return FormatValue(append(items, added...))
+	}
+	text := make([]string, 0, len(added))
+	for _, item := range added {
+		s, err := FormatValue(item)
+		if err != nil {
+			return "", err
+		}
+		text = append(text, s)
+	}
+	body := strings.TrimRight(strings.TrimSpace(string(existing[1:len(existing)-1])), ",")
+	if body != "" {
+		body += ", "
+	}
+	return "[" + body + strings.Join(text, ", ") + "]", nil
+}
+
+func appendedItems(v any) []any {
+	if items, ok := v.([]any); ok {
+		return items
+	}
+	return []any{v}
+}
+
+// insertKey adds a key the document does not set yet.
+func insertKey(data []byte, layout *docLayout, o Override) ([]byte, error) {
+	key := strings.Join(o.Path, ".")
+	for _, e := range layout.entries {
+		if hasPrefix(o.Path, e.path) {
+			return nil, fmt.Errorf("%s: %s is not a table: %w", key, strings.Join(e.path, "."), ErrInvalidConfigEdit)
+		}
+		if hasPrefix(e.path, o.Path) {
+			return nil, fmt.Errorf("%s is a table: set its keys one by one: %w", key, ErrInvalidConfigEdit)
+		}
+	}
+	for _, s := range layout.sections[1:] {
+		if hasPrefix(s.path, o.Path) {
+			return nil, fmt.Errorf("%s is a table: set its keys one by one: %w", key, ErrInvalidConfigEdit)
+		}
+		if s.array && hasPrefix(o.Path, s.path) {
+			return nil, fmt.Errorf("%s is inside an array of tables ([[%s]]): edit the file by hand: %w", key, strings.Join(s.path, "."), ErrInvalidConfigEdit)
+		}
+	}
+
+	value := o.Value
+	if o.Append {
+		value = appendedItems(o.Value)
+	}
+	text, err := FormatValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// The deepest table header the key falls under takes it; the root
+	// table always qualifies
+	parent := o.Path[:len(o.Path)-1]
+	best := 0
+	for i, s := range layout.sections {
+		if !s.array && hasPrefix(parent, s.path) && len(s.path) > len(layout.sections[best].path) {
+			best = i
+		}
+	}
+	section := layout.sections[best]
+	// Root keys already dotted into the table (resources.cpus = 2) rule
+	// out a header for it
+	dotted := slices.ContainsFunc(layout.entries, func(e docEntry) bool { return hasPrefix(e.path, o.Path[:1]) })
+	if best == 0 && len(parent) > 0 && !dotted {
+		// No [parent] header yet: add one at the end
+		var b strings.Builder
+		b.Write(data)
+		if len(data) > 0 {
+			if data[len(data)-1] != '\n' {
+				b.WriteByte('\n')
+			}
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "[%s]\n%s = %s\n", formatKey(parent), formatKey(o.Path[len(parent):]), text)
+		return []byte(b.String()), nil
+	}
+
+	pos := section.end
+	if best == 0 && !hasRootEntries(layout) {
+		if len(layout.sections) > 1 {
+			// Root keys must precede the first table header
+			pos = 0
+		} else {
+			// A file of comments only (or nothing): add at the end
+			pos = len(data)
+		}
+	}
+	line := formatKey(o.Path[len(section.path):]) + " = " + text + "\n"
+	if pos > 0 && data[pos-1] != '\n' {
+		line = "\n" + line
+	}
+	return splice(data, pos, pos, line), nil
+}
+
+func hasRootEntries(layout *docLayout) bool {
+	return layout.sections[0].end > 0
+}
+
+// UnsetKey returns data without the line setting the dotted key.
+func UnsetKey(data []byte, path []string) ([]byte, error) {
+	layout, err := parseLayout(data)
+	if err != nil {
+		return nil, err
+	}
+	if e := layout.entry(path); e != nil {
+		return splice(data, e.start, e.lineEnd, ""), nil
+	}
+	key := strings.Join(path, ".")
+	for _, s := range layout.sections[1:] {
+		if hasPrefix(s.path, path) {
+			return nil, fmt.Errorf("%s is a table: unset its keys one by one: %w", key, ErrInvalidConfigEdit)
+		}
+	}
+	for _, e := range layout.entries {
+		if hasPrefix(e.path, path) {
+			return nil, fmt.Errorf("%s is a table: unset its keys one by one: %w", key, ErrInvalidConfigEdit)
+		}
+		if hasPrefix(path, e.path) {
+			return nil, fmt.Errorf("%s: %s is not a table, and inline values are edited whole: %w", key, strings.Join(e.path, "."), ErrInvalidConfigEdit)
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", key, ErrKeyNotFound)
+}
+
+// FormatValue renders a value as it is written in a config file: strings
+// quoted, arrays and tables inline.
+func FormatValue(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.SetTablesInline(true)
+	if err := enc.Encode(map[string]any{"v": v}); err != nil {
+		return "", fmt.Errorf("encode %v: %w", v, err)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(buf.String()), "v = "), nil
+}
+
+// formatKey renders key segments as a dotted TOML key, quoting where needed.
+func formatKey(path []string) string {
+	segs := make([]string, len(path))
+	for i, seg := range path {
+		if bareKeyPattern.MatchString(seg) {
+			segs[i] = seg
+		} else {
+			segs[i], _ = FormatValue(seg)
+		}
+	}
+	return strings.Join(segs, ".")
+}
+
+func splice(data []byte, start, end int, text string) []byte {
+	out := make([]byte, 0, len(data)-(end-start)+len(text))
+	out = append(out, data[:start]...)
+	out = append(out, text...)
+	return append(out, data[end:]...)
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+const editTestConfig = `# Project sandbox
+image = "alpine:3" # pinned for CI
+workdir = "/workspace"
+
+[resources]
+# Keep it small
+memory = "2g"
+
+[network]
+lan-access = ["10.0.0.1:22"]
+ports = [
+  3000, # web
+]
+`
+
+func mustSetKey(t *testing.T, data, override string) string {
+	t.Helper()
+	o, err := ParseOverride(override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := SetKey([]byte(data), o)
+	if err != nil {
+		t.Fatalf("SetKey(%s): %v", override, err)
+	}
+	return string(out)
+}
+
+func TestSetKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		override string
+		want     string
+	}{
+		{
+			name:     "replace keeps the trailing comment",
+			data:     editTestConfig,
+			override: "image=node:20",
+			want:     "# Project sandbox\nimage = 'node:20' # pinned for CI\nworkdir = \"/workspace\"\n\n[resources]\n# Keep it small\nmemory = \"2g\"\n\n[network]\nlan-access = [\"10.0.0.1:22\"]\nports = [\n  3000, # web\n]\n",
+		},
+		{
+			name:     "new key goes under its table",
+			data:     "image = \"alpine\"\n\n[resources]\nmemory = \"2g\"\n\n[network]\nlan-access = []\n",
+			override: "resources.cpus=4",
+			want:     "image = \"alpine\"\n\n[resources]\nmemory = \"2g\"\ncpus = 4\n\n[network]\nlan-access = []\n",
+		},
+		{
+			name:     "new table is added at the end",
+			data:     "image = \"alpine\"\n",
+			override: "resources.memory=8g",
+			want:     "image = \"alpine\"\n\n[resources]\nmemory = '8g'\n",
+		},
+		{
+			name:     "root key goes after the root keys",
+			data:     "image = \"alpine\"\n\n[resources]\nmemory = \"2g\"\n",
+			override: "workdir=/src",
+			want:     "image = \"alpine\"\nworkdir = '/src'\n\n[resources]\nmemory = \"2g\"\n",
+		},
+		{
+			name:     "root key before the first header",
+			data:     "[resources]\nmemory = \"2g\"\n",
+			override: "image=alpine",
+			want:     "image = 'alpine'\n[resources]\nmemory = \"2g\"\n",
+		},
+		{
+			name:     "dotted root keys stay dotted",
+			data:     "image = \"alpine\"\nresources.memory = \"2g\"\n",
+			override: "resources.cpus=2",
+			want:     "image = \"alpine\"\nresources.memory = \"2g\"\nresources.cpus = 2\n",
+		},
+		{
+			name:     "nested key under its parent header",
+			data:     "[network]\nlan-access = []\n",
+			override: "network.shaping.rate=10mbit",
+			want:     "[network]\nlan-access = []\nshaping.rate = '10mbit'\n",
+		},
+		{
+			name:     "append to a single-line array",
+			data:     "[network]\nlan-access = [\"10.0.0.1:22\"] # ssh\n",
+			override: "network.lan-access+=192.168.1.9:443",
+			want:     "[network]\nlan-access = [\"10.0.0.1:22\", '192.168.1.9:443'] # ssh\n",
+		},
+		{
+			name:     "append to a missing array creates it",
+			data:     "",
+			override: "network.lan-access+=*",
+			want:     "[network]\nlan-access = ['*']\n",
+		},
+		{
+			name:     "comments-only file",
+			data:     "# local overrides\n",
+			override: "image=alpine",
+			want:     "# local overrides\nimage = 'alpine'\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustSetKey(t, tt.data, tt.override); got != tt.want {
+				t.Errorf("SetKey() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetKey_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		override string
+		wantErr  error
+	}{
+		{"unknown key", editTestConfig, "resources.memroy=8g", ErrInvalidOverride},
+		{"wrong type", editTestConfig, "resources.cpus=many", ErrInvalidOverride},
+		{"replace a table", editTestConfig, "resources=8g", ErrInvalidOverride},
+		{"append to a scalar", editTestConfig, "image+=x", ErrInvalidOverride},
+		{"inside an array of tables", "[[network.ports]]\nport = 3000\n", "network.ports.port=80", ErrInvalidOverride},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := ParseOverride(tt.override)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := SetKey([]byte(tt.data), o); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetKey(%s) = %v, want %v", tt.override, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetKey(t *testing.T) {
+	v, err := GetKey([]byte(editTestConfig), []string{"resources", "memory"})
+	if err != nil || v != "2g" {
+		t.Errorf("GetKey(resources.memory) = %v, %v", v, err)
+	}
+	if _, err := GetKey([]byte(editTestConfig), []string{"resources", "cpus"}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("GetKey(resources.cpus) = %v, want ErrKeyNotFound", err)
+	}
+	if _, err := GetKey([]byte(editTestConfig), []string{"image", "tag"}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("GetKey(image.tag) = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestUnsetKey(t *testing.T) {
+	out, err := UnsetKey([]byte(editTestConfig), []string{"network", "ports"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Project sandbox\nimage = \"alpine:3\" # pinned for CI\nworkdir = \"/workspace\"\n\n[resources]\n# Keep it small\nmemory = \"2g\"\n\n[network]\nlan-access = [\"10.0.0.1:22\"]\n"
+	if string(out) != want {
+		t.Errorf("UnsetKey() =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, err := UnsetKey([]byte(editTestConfig), []string{"resources", "cpus"}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("UnsetKey(missing) = %v, want ErrKeyNotFound", err)
+	}
+	if _, err := UnsetKey([]byte(editTestConfig), []string{"resources"}); !errors.Is(err, ErrInvalidConfigEdit) {
+		t.Errorf("UnsetKey(table) = %v, want ErrInvalidConfigEdit", err)
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/spf13/afero"
+)
+
+// EncryptedConfigSuffix marks a config file as age-encrypted.
+// Files with this suffix are decrypted in memory at load time and never
+// written to disk in plaintext.
+const EncryptedConfigSuffix = ".age"
+
+// Environment variables that provide the age identity used to decrypt
+// encrypted config files. EnvAgeIdentity takes precedence over EnvAgeIdentityFile.
+const (
+	// EnvAgeIdentity holds one or more age identities (AGE-SECRET-KEY-1...).
+	EnvAgeIdentity = "ALCA_AGE_IDENTITY"
+	// EnvAgeIdentityFile points to an age identity file (as written by age-keygen).
+	EnvAgeIdentityFile = "ALCA_AGE_IDENTITY_FILE"
+)
+
+// IsEncryptedConfigPath returns true if the path refers to an encrypted config file.
+func IsEncryptedConfigPath(path string) bool {
+	return strings.HasSuffix(path, EncryptedConfigSuffix)
+}
+
+// decryptConfig decrypts an age-encrypted config file content in memory.
+// The identity is read from EnvAgeIdentity or from the file at EnvAgeIdentityFile,
+// else looked up as opts say.
+func decryptConfig(fs afero.Fs, path string, data []byte, opts loadOptions) ([]byte, error) {
+	identities, err := loadAgeIdentities(fs, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s: %w", path, err)
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s: %w: %w", path, ErrDecryptFailed, err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s: %w: %w", path, ErrDecryptFailed, err)
+	}
+	return plaintext, nil
+}
+
+// loadAgeIdentities parses age identities from the environment, else from
+// the credential store lookup in opts.
+func loadAgeIdentities(fs afero.Fs, opts loadOptions) ([]age.Identity, error) {
+	if raw := os.Getenv(EnvAgeIdentity); raw != "" {
+		identities, err := age.ParseIdentities(strings.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvAgeIdentity, err)
+		}
+		return identities, nil
+	}
+
+	if path := os.Getenv(EnvAgeIdentityFile); path != "" {
+		f, err := fs.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", EnvAgeIdentityFile, err)
+		}
+		defer func() { _ = f.Close() }()
+		identities, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity file %s: %w", path, err)
+		}
+		return identities, nil
+	}
+
+	if opts.storedAgeIdentity != nil {
+		raw, err := opts.storedAgeIdentity()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the stored age identity: %w", err)
+		}
+		if raw != "" {
+			identities, err := age.ParseIdentities(strings.NewReader(raw))
+			if err != nil {
+				return nil, fmt.Errorf("invalid stored age identity: %w", err)
+			}
+			return identities, nil
+		}
+	}
+
+	return nil, fmt.Errorf("set %s or %s, or store one with 'alca credentials set age-identity': %w", EnvAgeIdentity, EnvAgeIdentityFile, ErrNoDecryptionIdentity)
+}
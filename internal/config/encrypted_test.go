@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// writeEncrypted encrypts content to the recipient and writes it to path.
+func writeEncrypted(t *testing.T, fs afero.Fs, path string, recipient age.Recipient, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		t.Fatalf("age.Encrypt: %v", err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := afero.WriteFile(fs, path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// setupEncryptedInclude writes a main config including an encrypted secrets file.
+func setupEncryptedInclude(t *testing.T) (*util.Env, string, *age.X25519Identity) {
+	t.Helper()
+	env, memFs := newTestEnv(t)
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity: %v", err)
+	}
+
+	writeEncrypted(t, memFs, "/test/.alca.secrets.toml.age", identity.Recipient(), `
+[envs]
+API_TOKEN = "s3cret"
+`)
+	mainPath := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, mainPath, []byte(`
+image = "alpine:latest"
+includes = [".alca.secrets.toml.age"]
+`), 0644); err != nil {
+		t.Fatalf("failed to write main file: %v", err)
+	}
+	return env, mainPath, identity
+}
+
+func TestLoadWithIncludes_EncryptedInclude_IdentityFromEnv(t *testing.T) {
+	env, mainPath, identity := setupEncryptedInclude(t)
+	t.Setenv(EnvAgeIdentity, identity.String())
+	t.Setenv(EnvAgeIdentityFile, "")
+
+	cfg, err := LoadWithIncludes(env, mainPath, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadWithIncludes failed: %v", err)
+	}
+	if got := cfg.Envs["API_TOKEN"].Value; got != "s3cret" {
+		t.Errorf("API_TOKEN = %q, want %q", got, "s3cret")
+	}
+}
+
+func TestLoadWithIncludes_EncryptedInclude_IdentityFromFile(t *testing.T) {
+	env, mainPath, identity := setupEncryptedInclude(t)
+	keyPath := "/home/user/.config/age/key.txt"
+	if err := afero.WriteFile(env.Fs, keyPath, []byte("# test key\n"+identity.String()+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+	t.Setenv(EnvAgeIdentity, "")
+	t.Setenv(EnvAgeIdentityFile, keyPath)
+
+	cfg, err := LoadWithIncludes(env, mainPath, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadWithIncludes failed: %v", err)
+	}
+	if got := cfg.Envs["API_TOKEN"].Value; got != "s3cret" {
+		t.Errorf("API_TOKEN = %q, want %q", got, "s3cret")
+	}
+}
+
+func TestLoadWithIncludes_EncryptedInclude_IdentityFromStore(t *testing.T) {
+	env, mainPath, identity := setupEncryptedInclude(t)
+	t.Setenv(EnvAgeIdentity, "")
+	t.Setenv(EnvAgeIdentityFile, "")
+	stored := WithStoredAgeIdentity(func() (string, error) { return identity.String(), nil })
+
+	cfg, err := LoadWithIncludes(env, mainPath, noExpandEnv, stored)
+	if err != nil {
+		t.Fatalf("LoadWithIncludes failed: %v", err)
+	}
+	if got := cfg.Envs["API_TOKEN"].Value; got != "s3cret" {
+		t.Errorf("API_TOKEN = %q, want %q", got, "s3cret")
+	}
+
+	// Nothing stored falls through to the usual error
+	nothing := WithStoredAgeIdentity(func() (string, error) { return "", nil })
+	if _, err := LoadWithIncludes(env, mainPath, noExpandEnv, nothing); !errors.Is(err, ErrNoDecryptionIdentity) {
+		t.Fatalf("expected ErrNoDecryptionIdentity, got %v", err)
+	}
+}
+
+func TestLoadWithIncludes_EncryptedInclude_NoIdentity(t *testing.T) {
+	env, mainPath, _ := setupEncryptedInclude(t)
+	t.Setenv(EnvAgeIdentity, "")
+	t.Setenv(EnvAgeIdentityFile, "")
+
+	_, err := LoadWithIncludes(env, mainPath, noExpandEnv)
+	if !errors.Is(err, ErrNoDecryptionIdentity) {
+		t.Fatalf("expected ErrNoDecryptionIdentity, got %v", err)
+	}
+}
+
+func TestLoadWithIncludes_EncryptedInclude_WrongIdentity(t *testing.T) {
+	env, mainPath, _ := setupEncryptedInclude(t)
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity: %v", err)
+	}
+	t.Setenv(EnvAgeIdentity, other.String())
+	t.Setenv(EnvAgeIdentityFile, "")
+
+	_, err = LoadWithIncludes(env, mainPath, noExpandEnv)
+	if !errors.Is(err, ErrDecryptFailed) {
+		t.Fatalf("expected ErrDecryptFailed, got %v", err)
+	}
+}
@@ -4,22 +4,52 @@ import "errors"
 
 // Sentinel errors for the config package.
 var (
-	ErrCircularReference   = errors.New("circular reference")
-	ErrUndefinedEnvVar     = errors.New("undefined environment variable")
-	ErrInvalidEnvSyntax    = errors.New("invalid env syntax")
-	ErrWorkdirConflict     = errors.New("workdir conflict")
-	ErrInvalidMountFormat  = errors.New("invalid mount format")
-	ErrInvalidMountOption  = errors.New("invalid mount option")
-	ErrMountSourceEmpty    = errors.New("mount source empty")
-	ErrMountTargetEmpty    = errors.New("mount target empty")
-	ErrInvalidType         = errors.New("invalid type")
-	ErrUnknownAlcaToken    = errors.New("unknown alca token")
-	ErrInvalidAlcaToken    = errors.New("invalid alca token")
-	ErrInvalidPort         = errors.New("invalid port")
-	ErrInvalidProtocol     = errors.New("invalid protocol")
-	ErrInvalidHostIP       = errors.New("invalid host IP")
-	ErrInvalidPortFormat   = errors.New("invalid port format")
-	ErrInvalidProxyFormat  = errors.New("invalid proxy format")
-	ErrProxyHostNotIP      = errors.New("proxy host must be an IP address")
-	ErrProxyPortOutOfRange = errors.New("proxy port must be 1-65535")
+	ErrCircularReference       = errors.New("circular reference")
+	ErrUndefinedEnvVar         = errors.New("undefined environment variable")
+	ErrInvalidEnvSyntax        = errors.New("invalid env syntax")
+	ErrWorkdirConflict         = errors.New("workdir conflict")
+	ErrInvalidMountFormat      = errors.New("invalid mount format")
+	ErrInvalidMountOption      = errors.New("invalid mount option")
+	ErrMountSourceEmpty        = errors.New("mount source empty")
+	ErrMountTargetEmpty        = errors.New("mount target empty")
+	ErrInvalidType             = errors.New("invalid type")
+	ErrUnknownAlcaToken        = errors.New("unknown alca token")
+	ErrInvalidAlcaToken        = errors.New("invalid alca token")
+	ErrInvalidPort             = errors.New("invalid port")
+	ErrInvalidProtocol         = errors.New("invalid protocol")
+	ErrInvalidHostIP           = errors.New("invalid host IP")
+	ErrInvalidPortFormat       = errors.New("invalid port format")
+	ErrInvalidProxyFormat      = errors.New("invalid proxy format")
+	ErrProxyHostNotIP          = errors.New("proxy host must be an IP address")
+	ErrProxyPortOutOfRange     = errors.New("proxy port must be 1-65535")
+	ErrNoDecryptionIdentity    = errors.New("no decryption identity")
+	ErrDecryptFailed           = errors.New("decrypt failed")
+	ErrInvalidImageCheck       = errors.New("invalid image check mode")
+	ErrInvalidPreservePath     = errors.New("invalid preserve path")
+	ErrInvalidPluginName       = errors.New("invalid plugin name")
+	ErrNoWorkspaceMembers      = errors.New("no workspace members")
+	ErrSchemaTooNew            = errors.New("schema version newer than supported")
+	ErrInvalidSchemaVersion    = errors.New("invalid schema version")
+	ErrInvalidShaping          = errors.New("invalid network shaping")
+	ErrUnknownCapability       = errors.New("unknown capability")
+	ErrUnknownCapsPreset       = errors.New("unknown caps preset")
+	ErrInvalidPlatform         = errors.New("invalid platform")
+	ErrInvalidRegistryMirror   = errors.New("invalid registry mirror")
+	ErrInvalidOverride         = errors.New("invalid config override")
+	ErrUnknownNetworkPreset    = errors.New("unknown network preset")
+	ErrInvalidIsolation        = errors.New("invalid network isolation")
+	ErrInvalidPeer             = errors.New("invalid network peer")
+	ErrInvalidClockDrift       = errors.New("invalid clock drift threshold")
+	ErrInvalidTimeout          = errors.New("invalid timeout")
+	ErrInvalidInclude          = errors.New("invalid include")
+	ErrInvalidNestedContainers = errors.New("invalid nested containers mode")
+	ErrUnsignedConfig          = errors.New("shared config is not signed")
+	ErrInvalidConfigSignature  = errors.New("invalid config signature")
+	ErrInvalidUserns           = errors.New("invalid userns mode")
+	ErrKeyNotFound             = errors.New("config key not found")
+	ErrInvalidConfigEdit       = errors.New("invalid config edit")
+	ErrInvalidContainerName    = errors.New("invalid container name")
+	ErrInvalidCron             = errors.New("invalid cron entry")
+	ErrInvalidUI               = errors.New("invalid ui setting")
+	ErrInvalidCgroup           = errors.New("invalid cgroup setting")
 )
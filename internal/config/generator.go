@@ -71,8 +71,9 @@ func GenerateConfig(fs afero.Fs, configPath string, tc TemplateConfig) error {
 // generateConfigContent returns the TOML content string for a TemplateConfig.
 func generateConfigContent(tc TemplateConfig) (string, error) {
 	raw := configToRaw(tc.Config)
+	raw.SchemaVersion = CurrentSchemaVersion
 	raw.Extends = tc.Extends
-	raw.Includes = tc.Includes
+	raw.Includes = includesFromPaths(tc.Includes)
 
 	var buf bytes.Buffer
 	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
@@ -259,22 +260,61 @@ func insertUpComment(content, comment string) string {
 	return strings.Join(result, "\n")
 }
 
+// ToRaw converts the config to its raw form, whose JSON encoding uses the
+// same field names as .alca.toml.
+func (c *Config) ToRaw() RawConfig {
+	return configToRaw(*c)
+}
+
+// EncodeTOML serializes the config as .alca.toml content. The output is
+// deterministic (struct field order, sorted map keys), so two encodings can be
+// diffed line by line.
+func (c *Config) EncodeTOML() (string, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(configToRaw(*c)); err != nil {
+		return "", fmt.Errorf("encode config: %w", err)
+	}
+	return buf.String(), nil
+}
+
 // configToRaw converts Config to RawConfig for TOML serialization.
 func configToRaw(c Config) RawConfig {
 	// Mirror type ensures all Config fields are explicitly handled (AGD-015).
 	// Adding a new field to Config will cause a compile error here.
 	type configFields struct {
-		Image          string
-		Workdir        string
-		WorkdirExclude []string
-		Runtime        RuntimeType
-		Commands       Commands
-		Mounts         []MountConfig
-		Resources      Resources
-		Envs           map[string]EnvValue
-		Network        Network
-		Caps           Caps
-		Hooks          Hooks
+		Image              string
+		ImageCheck         ImageCheckMode
+		Platform           string
+		RegistryMirror     string
+		Workdir            string
+		WorkdirSource      string
+		WorkdirExclude     []string
+		WorkdirExcludeFrom []string
+		WorkdirSync        MountSync
+		Runtime            RuntimeType
+		RuntimeContext     string
+		User               string
+		EnterTmux          bool
+		NestedContainers   NestedContainersMode
+		Userns             UsernsMode
+		Commands           Commands
+		Mounts             []MountConfig
+		Resources          Resources
+		Envs               map[string]EnvValue
+		Network            Network
+		SSH                SSH
+		Caps               Caps
+		Hooks              Hooks
+		Rebuild            Rebuild
+		Clock              Clock
+		Timeouts           Timeouts
+		IdleTimeout        string
+		ContainerName      string
+		NameTemplate       string
+		Plugins            []string
+		Cron               map[string]string
+		ForwardFileEvents  bool
+		UI                 UI
 	}
 	_ = configFields(c)
 
@@ -282,34 +322,59 @@ func configToRaw(c Config) RawConfig {
 	if c.Commands.Up.Command != "" {
 		commands.Up = commandValueToRaw(c.Commands.Up)
 	}
-	if c.Commands.Enter.Command != "" {
+	if c.Commands.Enter.Command != "" || c.Commands.Enter.User != "" {
 		commands.Enter = commandValueToRaw(c.Commands.Enter)
 	}
+	if c.Commands.Test.Command != "" {
+		commands.Test = commandValueToRaw(c.Commands.Test)
+	}
 
 	return RawConfig{
-		Image:          c.Image,
-		Workdir:        c.Workdir,
-		WorkdirExclude: c.WorkdirExclude,
-		Runtime:        c.Runtime,
-		Commands:       commands,
-		Mounts:         mountsToRaw(c.Mounts),
-		Resources:      c.Resources,
-		Envs:           envsToRaw(c.Envs),
-		Network:        networkToRaw(c.Network),
-		Caps:           capsToRaw(c.Caps),
-		Hooks:          c.Hooks,
+		Image:              c.Image,
+		ImageCheck:         c.ImageCheck,
+		Platform:           c.Platform,
+		RegistryMirror:     c.RegistryMirror,
+		Workdir:            c.Workdir,
+		WorkdirSource:      c.WorkdirSource,
+		WorkdirExclude:     c.WorkdirExclude,
+		WorkdirExcludeFrom: c.WorkdirExcludeFrom,
+		WorkdirSync:        c.WorkdirSync,
+		Runtime:            c.Runtime,
+		RuntimeContext:     c.RuntimeContext,
+		User:               c.User,
+		EnterTmux:          c.EnterTmux,
+		NestedContainers:   c.NestedContainers,
+		Userns:             c.Userns,
+		Commands:           commands,
+		Mounts:             mountsToRaw(c.Mounts),
+		Resources:          c.Resources,
+		Envs:               envsToRaw(c.Envs),
+		Network:            networkToRaw(c.Network),
+		SSH:                c.SSH,
+		Caps:               capsToRaw(c.Caps),
+		Hooks:              c.Hooks,
+		Rebuild:            c.Rebuild,
+		Clock:              c.Clock,
+		Timeouts:           c.Timeouts,
+		IdleTimeout:        c.IdleTimeout,
+		ContainerName:      c.ContainerName,
+		NameTemplate:       c.NameTemplate,
+		Plugins:            c.Plugins,
+		Cron:               c.Cron,
+		ForwardFileEvents:  c.ForwardFileEvents,
+		UI:                 c.UI,
 	}
 }
 
 // envsToRaw converts EnvValue map to raw format for TOML serialization.
-// Simple values use string format; values with OverrideOnEnter use full struct.
+// Simple values use string format; values with OverrideOnEnter or Sensitive use full struct.
 func envsToRaw(envs map[string]EnvValue) RawEnvValueMap {
 	if len(envs) == 0 {
 		return nil
 	}
 	raw := make(RawEnvValueMap, len(envs))
 	for k, v := range envs {
-		if !v.OverrideOnEnter {
+		if !v.OverrideOnEnter && !v.Sensitive {
 			raw[k] = v.Value
 		} else {
 			raw[k] = v
@@ -340,9 +405,16 @@ func mountsToRaw(mounts []MountConfig) RawMountSlice {
 func networkToRaw(n Network) RawNetwork {
 	// Mirror type ensures all Network fields are explicitly handled (AGD-015).
 	type networkFields struct {
-		LANAccess []string
-		Ports     []PortConfig
-		Proxy     string
+		LANAccess       []string
+		Ports           []PortConfig
+		Proxy           string
+		Shaping         Shaping
+		LogConnections  bool
+		AllowHostAccess bool
+		Isolation       string
+		Peers           []string
+		Preset          string
+		Presets         map[string]NetworkPreset
 	}
 	_ = networkFields(n)
 
@@ -354,9 +426,16 @@ func networkToRaw(n Network) RawNetwork {
 		}
 	}
 	return RawNetwork{
-		LANAccess: n.LANAccess,
-		Ports:     rawPorts,
-		Proxy:     n.Proxy,
+		LANAccess:       n.LANAccess,
+		Ports:           rawPorts,
+		Proxy:           n.Proxy,
+		Shaping:         n.Shaping,
+		LogConnections:  n.LogConnections,
+		AllowHostAccess: n.AllowHostAccess,
+		Isolation:       n.Isolation,
+		Peers:           n.Peers,
+		Preset:          n.Preset,
+		Presets:         n.Presets,
 	}
 }
 
@@ -401,13 +480,21 @@ func capsToRaw(caps Caps) RawCaps {
 }
 
 // commandValueToRaw converts CommandValue to raw format for TOML serialization.
-// Uses simple string format when append is false, object format when append is true.
+// Uses simple string format when only a command is set, object format when
+// append or user is set.
 func commandValueToRaw(cv CommandValue) RawCommandValue {
-	if cv.Append {
-		return map[string]any{
-			"command": cv.Command,
-			"append":  true,
+	if cv.Append || cv.User != "" {
+		m := map[string]any{}
+		if cv.Command != "" {
+			m["command"] = cv.Command
 		}
+		if cv.Append {
+			m["append"] = true
+		}
+		if cv.User != "" {
+			m["user"] = cv.User
+		}
+		return m
 	}
 	return cv.Command
 }
@@ -483,10 +570,14 @@ func appendGitignoreEntries(fs afero.Fs, dir string, entries []string) error {
 func mountConfigToMap(m MountConfig) map[string]any {
 	// Mirror type ensures all MountConfig fields are explicitly handled (AGD-015).
 	type fields struct {
-		Source   string
-		Target   string
-		Readonly bool
-		Exclude  []string
+		Source      string
+		Target      string
+		Readonly    bool
+		Exclude     []string
+		ExcludeFrom []string
+		Propagation string
+		Consistency string
+		Sync        MountSync
 	}
 	_ = fields(m)
 
@@ -500,5 +591,52 @@ func mountConfigToMap(m MountConfig) map[string]any {
 	if len(m.Exclude) > 0 {
 		result["exclude"] = m.Exclude
 	}
+	if len(m.ExcludeFrom) > 0 {
+		result["exclude_from"] = m.ExcludeFrom
+	}
+	if m.Propagation != "" {
+		result["propagation"] = m.Propagation
+	}
+	if m.Consistency != "" {
+		result["consistency"] = m.Consistency
+	}
+	if !m.Sync.IsZero() {
+		result["sync"] = mountSyncToMap(m.Sync)
+	}
+	return result
+}
+
+// mountSyncToMap converts MountSync to map for TOML serialization.
+func mountSyncToMap(s MountSync) map[string]any {
+	// Mirror type ensures all MountSync fields are explicitly handled (AGD-015).
+	type fields struct {
+		MaxEntryCount        int
+		MaxStagingFileSize   string
+		SymlinkMode          string
+		PermissionsMode      string
+		DefaultFileMode      string
+		DefaultDirectoryMode string
+	}
+	_ = fields(s)
+
+	result := map[string]any{}
+	if s.MaxEntryCount > 0 {
+		result["max_entry_count"] = s.MaxEntryCount
+	}
+	if s.MaxStagingFileSize != "" {
+		result["max_staging_file_size"] = s.MaxStagingFileSize
+	}
+	if s.SymlinkMode != "" {
+		result["symlink_mode"] = s.SymlinkMode
+	}
+	if s.PermissionsMode != "" {
+		result["permissions_mode"] = s.PermissionsMode
+	}
+	if s.DefaultFileMode != "" {
+		result["default_file_mode"] = s.DefaultFileMode
+	}
+	if s.DefaultDirectoryMode != "" {
+		result["default_directory_mode"] = s.DefaultDirectoryMode
+	}
 	return result
 }
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Placeholders RenderGolden writes for machine-specific paths.
+const (
+	GoldenHomePlaceholder       = "${HOME}"
+	GoldenProjectDirPlaceholder = "${PROJECT_DIR}"
+)
+
+// goldenHeader starts every golden rendering.
+const goldenHeader = `# Effective alca configuration, generated by 'alca config render --golden'.
+# Do not edit: regenerate it after changing .alca.toml or a file it extends
+# or includes, and review the diff.
+
+`
+
+// IsLocalConfigFile reports whether name is a per-user config file
+// (.alca.local.toml or .alca.<name>.local.toml), which is kept out of
+// version control.
+func IsLocalConfigFile(name string) bool {
+	name = filepath.Base(name)
+	return strings.HasPrefix(name, ".alca.") && strings.HasSuffix(name, ".local.toml")
+}
+
+// WithoutLocalConfigFiles returns fsys with per-user config files hidden,
+// so a config loaded through it depends only on files in version control.
+// Includes matching only local files then match nothing.
+func WithoutLocalConfigFiles(fsys afero.Fs) afero.Fs {
+	return withoutLocalFs{fsys}
+}
+
+// withoutLocalFs hides the files IsLocalConfigFile matches: opening or
+// stating them fails as if they did not exist, and directory listings
+// leave them out.
+type withoutLocalFs struct {
+	afero.Fs
+}
+
+func (f withoutLocalFs) Name() string {
+	return "WithoutLocalConfigFiles(" + f.Fs.Name() + ")"
+}
+
+func (f withoutLocalFs) Open(name string) (afero.File, error) {
+	if IsLocalConfigFile(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	file, err := f.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return withoutLocalFile{file}, nil
+}
+
+func (f withoutLocalFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if IsLocalConfigFile(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	file, err := f.Fs.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return withoutLocalFile{file}, nil
+}
+
+func (f withoutLocalFs) Stat(name string) (os.FileInfo, error) {
+	if IsLocalConfigFile(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.Fs.Stat(name)
+}
+
+// withoutLocalFile is a directory handle whose listings skip local config files.
+type withoutLocalFile struct {
+	afero.File
+}
+
+func (f withoutLocalFile) Readdir(count int) ([]os.FileInfo, error) {
+	infos, err := f.File.Readdir(count)
+	return slices.DeleteFunc(infos, func(fi os.FileInfo) bool { return IsLocalConfigFile(fi.Name()) }), err
+}
+
+func (f withoutLocalFile) Readdirnames(n int) ([]string, error) {
+	names, err := f.File.Readdirnames(n)
+	return slices.DeleteFunc(names, IsLocalConfigFile), err
+}
+
+// RenderGolden serializes cfg for a golden file committed next to the
+// config: EncodeTOML under a header, with what differs between machines
+// and users replaced so the rendering only changes when the config does:
+//   - sensitive env values written in the config are masked; ${VAR}
+//     references are kept, as they name the variable, not its value
+//   - projectDir and home are written as GoldenProjectDirPlaceholder and
+//     GoldenHomePlaceholder (the project directory first, as it is usually
+//     under home)
+//
+// Load cfg through WithoutLocalConfigFiles to leave per-user files out.
+func RenderGolden(cfg *Config, projectDir, home string) (string, error) {
+	masked := *cfg
+	if len(cfg.Envs) > 0 {
+		masked.Envs = make(map[string]EnvValue, len(cfg.Envs))
+		for name, v := range cfg.Envs {
+			if v.Sensitive && !strings.Contains(v.Value, "${") {
+				v.Value = util.RedactedValue
+			}
+			masked.Envs[name] = v
+		}
+	}
+
+	text, err := masked.EncodeTOML()
+	if err != nil {
+		return "", err
+	}
+
+	// Longest path first, so a project under home keeps its own placeholder
+	replacements := []struct{ path, placeholder string }{
+		{projectDir, GoldenProjectDirPlaceholder},
+		{home, GoldenHomePlaceholder},
+	}
+	sort.SliceStable(replacements, func(i, j int) bool {
+		return len(replacements[i].path) > len(replacements[j].path)
+	})
+	for _, r := range replacements {
+		if r.path == "" || r.path == "/" {
+			continue
+		}
+		text = replacePath(text, r.path, r.placeholder)
+	}
+
+	return goldenHeader + text, nil
+}
+
+// replacePath replaces path in text where it is a whole path or a
+// leading part of one, so /home/al does not match in /home/alice.
+func replacePath(text, path, placeholder string) string {
+	re := regexp.MustCompile(regexp.QuoteMeta(path) + `([^A-Za-z0-9._-]|$)`)
+	return re.ReplaceAllStringFunc(text, func(m string) string {
+		return placeholder + m[len(path):]
+	})
+}
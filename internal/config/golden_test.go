@@ -0,0 +1,112 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestIsLocalConfigFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{".alca.local.toml", true},
+		{"/p/.alca.dev.local.toml", true},
+		{".alca.toml", false},
+		{".alca.dev.toml", false},
+		{"local.toml", false},
+		{"/p/.alca.local.toml.bak", false},
+	}
+	for _, tt := range tests {
+		if got := IsLocalConfigFile(tt.name); got != tt.want {
+			t.Errorf("IsLocalConfigFile(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithoutLocalConfigFiles_SkipsLocalIncludes(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	files := map[string]string{
+		"/p/.alca.toml":          "image = \"alpine\"\nincludes = [\"./.alca.*.toml\"]\n",
+		"/p/.alca.team.toml":     "[resources]\ncpus = 2\n",
+		"/p/.alca.local.toml":    "[resources]\nmemory = \"8g\"\n",
+		"/p/.alca.me.local.toml": "image = \"mine\"\n",
+	}
+	for path, content := range files {
+		if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	full, err := LoadConfig(env, "/p/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full.Resources.Memory != "8g" || full.Image != "mine" {
+		t.Fatalf("local files should load normally, got memory %q image %q", full.Resources.Memory, full.Image)
+	}
+
+	shared, err := LoadConfig(&util.Env{Fs: WithoutLocalConfigFiles(memFs)}, "/p/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig without local files: %v", err)
+	}
+	if shared.Image != "alpine" || shared.Resources.Memory != "" {
+		t.Errorf("local files should be left out, got image %q memory %q", shared.Image, shared.Resources.Memory)
+	}
+	if shared.Resources.CPUs != 2 {
+		t.Errorf("shared includes should still load, got cpus %v", shared.Resources.CPUs)
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	cfg := Config{
+		Image:   "alpine",
+		Workdir: DefaultWorkdir,
+		Mounts: []MountConfig{
+			{Source: "/home/al/.ssh", Target: "/root/.ssh"},
+			{Source: "/home/al/src/proj/cache", Target: "/cache"},
+			{Source: "/home/alice/data", Target: "/data"},
+		},
+		Envs: map[string]EnvValue{
+			"TOKEN": {Value: "supersecretvalue", Sensitive: true},
+			"REF":   {Value: "${GITHUB_TOKEN}", Sensitive: true},
+			"PLAIN": {Value: "visible"},
+		},
+	}
+
+	got, err := RenderGolden(&cfg, "/home/al/src/proj", "/home/al")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(got, "# Effective alca configuration") {
+		t.Errorf("missing header:\n%s", got)
+	}
+	for _, want := range []string{
+		"'${HOME}/.ssh:/root/.ssh'",
+		"'${PROJECT_DIR}/cache:/cache'",
+		"'/home/alice/data:/data'",
+		"value = '" + util.RedactedValue + "'",
+		"value = '${GITHUB_TOKEN}'",
+		"PLAIN = 'visible'",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendering should contain %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "supersecretvalue") {
+		t.Errorf("sensitive value leaked:\n%s", got)
+	}
+	if cfg.Envs["TOKEN"].Value != "supersecretvalue" {
+		t.Error("RenderGolden must not modify the config")
+	}
+
+	again, _ := RenderGolden(&cfg, "/home/al/src/proj", "/home/al")
+	if again != got {
+		t.Error("rendering should be deterministic")
+	}
+}
@@ -0,0 +1,98 @@
+// ignore_file.go implements exclude_from: mount excludes read from
+// .gitignore-style ignore files.
+// See AGD-025 for mount exclude implementation with Mutagen.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// ParseIgnoreFile converts the content of a .gitignore-style file to Mutagen
+// ignore patterns. Mutagen's pattern syntax follows Git's (negation with !,
+// anchoring with a leading /, directory-only patterns with a trailing /, **),
+// so patterns are kept as written. Only what Mutagen has no syntax for
+// changes:
+//   - blank lines and # comments are dropped
+//   - unescaped trailing spaces are trimmed, and \# and "\ " are unescaped
+//   - patterns for a literal leading ! (\!) cannot be expressed and are dropped
+func ParseIgnoreFile(content string) []string {
+	var patterns []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = trimIgnoreTrailingSpaces(line)
+		switch {
+		case strings.HasPrefix(line, `\!`):
+			continue
+		case strings.HasPrefix(line, `\#`):
+			line = line[1:]
+		}
+		line = strings.ReplaceAll(line, `\ `, " ")
+		if line == "" || line == "/" || line == "!" {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// trimIgnoreTrailingSpaces trims trailing spaces unless escaped with a
+// backslash, as Git does.
+func trimIgnoreTrailingSpaces(line string) string {
+	end := len(line)
+	for end > 0 && (line[end-1] == ' ' || line[end-1] == '\t') {
+		if end > 1 && line[end-2] == '\\' {
+			break
+		}
+		end--
+	}
+	return line[:end]
+}
+
+// resolveExcludeFrom reads each mount's ExcludeFrom files and appends their
+// patterns to its Exclude, skipping patterns already listed. Files are
+// relative to the mount source, which is relative to projectDir. Missing
+// files are skipped, so a list can name optional files such as .alcaignore.
+// Runs on every load, so alca up picks up edits to the files.
+func resolveExcludeFrom(fsys afero.Fs, projectDir string, mounts []MountConfig) error {
+	for i := range mounts {
+		m := &mounts[i]
+		if len(m.ExcludeFrom) == 0 {
+			continue
+		}
+		source := m.Source
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(projectDir, source)
+		}
+		exclude := slices.Clone(m.Exclude)
+		for _, name := range m.ExcludeFrom {
+			path := name
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(source, name)
+			}
+			data, err := afero.ReadFile(fsys, path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("mount %s: exclude_from %q: %w", m.Target, name, err)
+			}
+			for _, p := range ParseIgnoreFile(string(data)) {
+				if !slices.Contains(exclude, p) {
+					exclude = append(exclude, p)
+				}
+			}
+		}
+		m.Exclude = exclude
+	}
+	return nil
+}
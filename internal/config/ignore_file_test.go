@@ -0,0 +1,96 @@
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestParseIgnoreFile(t *testing.T) {
+	content := "# build output\n" +
+		"node_modules/\n" +
+		"\n" +
+		"/dist\n" +
+		"*.log   \n" +
+		"!keep.log\n" +
+		"**/.env\n" +
+		"\\#notes\n" +
+		"\\!literal\n" +
+		"trailing\\ \r\n" +
+		"/\n"
+
+	got := ParseIgnoreFile(content)
+	want := []string{"node_modules/", "/dist", "*.log", "!keep.log", "**/.env", "#notes", "trailing "}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseIgnoreFile() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadConfig_ExcludeFrom(t *testing.T) {
+	content := `
+image = "alpine"
+workdir_exclude = [".env"]
+workdir_exclude_from = [".gitignore", ".alcaignore"]
+
+[[mounts]]
+source = "/data"
+target = "/data"
+exclude_from = ["ignore"]
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/project/.alca.toml"
+	files := map[string]string{
+		path:                       content,
+		"/test/project/.gitignore": "node_modules/\n.env\n# comment\n",
+		"/data/ignore":             "cache/\n",
+	}
+	for name, data := range files {
+		if err := afero.WriteFile(memFs, name, []byte(data), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	// .alcaignore is missing and skipped; .env is not repeated
+	if want := []string{".env", "node_modules/"}; !slices.Equal(cfg.Mounts[0].Exclude, want) {
+		t.Errorf("workdir excludes = %q, want %q", cfg.Mounts[0].Exclude, want)
+	}
+	if !slices.Equal(cfg.WorkdirExclude, []string{".env"}) {
+		t.Errorf("WorkdirExclude = %q, should stay as written", cfg.WorkdirExclude)
+	}
+	if want := []string{"cache/"}; !slices.Equal(cfg.Mounts[1].Exclude, want) {
+		t.Errorf("mount excludes = %q, want %q", cfg.Mounts[1].Exclude, want)
+	}
+	if !cfg.HasMutagenSync() {
+		t.Error("patterns from ignore files should require Mutagen")
+	}
+
+	// Edits to the ignore file are picked up on the next load
+	if err := afero.WriteFile(memFs, "/test/project/.alcaignore", []byte("tmp/\n"), 0644); err != nil {
+		t.Fatalf("failed to write .alcaignore: %v", err)
+	}
+	reloaded, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if want := []string{".env", "node_modules/", "tmp/"}; !slices.Equal(reloaded.Mounts[0].Exclude, want) {
+		t.Errorf("reloaded workdir excludes = %q, want %q", reloaded.Mounts[0].Exclude, want)
+	}
+	if MountsEqual(cfg.Mounts, reloaded.Mounts) {
+		t.Error("changed ignore file should change the mounts")
+	}
+}
+
+func TestMountConfig_ExcludeFromNeedsObjectFormat(t *testing.T) {
+	m := MountConfig{Source: "/a", Target: "/b", ExcludeFrom: []string{".gitignore"}}
+	if m.CanBeSimpleString() {
+		t.Error("mount with exclude_from cannot be a simple string")
+	}
+	if got := mountConfigToMap(m)["exclude_from"]; !slices.Equal(got.([]string), m.ExcludeFrom) {
+		t.Errorf("mountConfigToMap exclude_from = %v", got)
+	}
+}
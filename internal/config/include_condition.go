@@ -0,0 +1,379 @@
+// include_condition.go implements conditional includes: includes entries
+// of the form { path = "...", when = "<expression>" }.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	goruntime "runtime"
+	"slices"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// IncludeRef is one includes entry. When is empty for unconditional
+// includes.
+type IncludeRef struct {
+	Path string
+	When string
+}
+
+// RawIncludeSlice is the includes list as written: each entry is a path
+// string or a { path, when } table.
+type RawIncludeSlice []any
+
+// JSONSchema implements jsonschema.JSONSchemer to generate correct schema.
+func (RawIncludeSlice) JSONSchema() *jsonschema.Schema {
+	props := jsonschema.NewProperties()
+	props.Set("path", &jsonschema.Schema{Type: "string", Description: "Config file path or glob (required)"})
+	props.Set("when", &jsonschema.Schema{
+		Type:        "string",
+		Description: "Condition on platform, arch, hostname or user, e.g. platform == 'darwin' or hostname =~ 'corp-*'. The file is only included when it holds.",
+	})
+
+	return &jsonschema.Schema{
+		Type: "array",
+		Items: &jsonschema.Schema{
+			OneOf: []*jsonschema.Schema{
+				{Type: "string", Description: "Config file path or glob"},
+				{
+					Type:                 "object",
+					Properties:           props,
+					Required:             []string{"path"},
+					AdditionalProperties: jsonschema.FalseSchema,
+					Description:          "Conditional include",
+				},
+			},
+		},
+		Description: "Config files to include (included files override declaring file). Paths support ${VAR} environment variable expansion and glob patterns. Files ending in .age are decrypted with the age identity from ALCA_AGE_IDENTITY or ALCA_AGE_IDENTITY_FILE.",
+	}
+}
+
+// Refs parses the entries. Conditions are checked for syntax but not
+// evaluated.
+func (s RawIncludeSlice) Refs() ([]IncludeRef, error) {
+	refs := make([]IncludeRef, 0, len(s))
+	for i, val := range s {
+		var ref IncludeRef
+		switch v := val.(type) {
+		case string:
+			ref.Path = v
+		case map[string]any:
+			for key := range v {
+				if key != "path" && key != "when" {
+					return nil, fmt.Errorf("includes[%d]: unknown key %q: %w", i, key, ErrInvalidInclude)
+				}
+			}
+			p, ok := v["path"].(string)
+			if !ok || p == "" {
+				return nil, fmt.Errorf("includes[%d]: path is required: %w", i, ErrInvalidInclude)
+			}
+			ref.Path = p
+			if w, ok := v["when"]; ok {
+				when, ok := w.(string)
+				if !ok {
+					return nil, fmt.Errorf("includes[%d]: when must be a string: %w", i, ErrInvalidInclude)
+				}
+				if _, err := parseCondition(when); err != nil {
+					return nil, fmt.Errorf("includes[%d]: %w", i, err)
+				}
+				ref.When = when
+			}
+		default:
+			return nil, fmt.Errorf("includes[%d]: invalid type %T: %w", i, val, ErrInvalidInclude)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// Paths returns the path of every entry, whatever its condition. Malformed
+// entries are skipped.
+func (s RawIncludeSlice) Paths() []string {
+	var paths []string
+	for _, val := range s {
+		switch v := val.(type) {
+		case string:
+			paths = append(paths, v)
+		case map[string]any:
+			if p, ok := v["path"].(string); ok && p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
+
+// ActivePaths returns the paths of the entries whose condition holds on
+// this machine.
+func (s RawIncludeSlice) ActivePaths() ([]string, error) {
+	refs, err := s.Refs()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, ref := range refs {
+		if ref.When != "" {
+			ok, err := EvalCondition(ref.When, conditionFacts())
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		paths = append(paths, ref.Path)
+	}
+	return paths, nil
+}
+
+// includesFromPaths converts plain paths to the raw includes form.
+func includesFromPaths(paths []string) RawIncludeSlice {
+	if len(paths) == 0 {
+		return nil
+	}
+	raw := make(RawIncludeSlice, len(paths))
+	for i, p := range paths {
+		raw[i] = p
+	}
+	return raw
+}
+
+// ConditionVariables lists the names include conditions can test.
+var ConditionVariables = []string{"platform", "arch", "hostname", "user"}
+
+// conditionFacts returns the values of ConditionVariables on this machine.
+// A variable so tests can fake a machine.
+var conditionFacts = func() map[string]string {
+	hostname, _ := os.Hostname()
+	user := os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USERNAME")
+	}
+	return map[string]string{
+		"platform": goruntime.GOOS,
+		"arch":     goruntime.GOARCH,
+		"hostname": hostname,
+		"user":     user,
+	}
+}
+
+// EvalCondition evaluates an include condition against facts.
+//
+// Grammar:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = variable ( "==" | "!=" | "=~" | "!~" ) string
+//
+// Strings are single- or double-quoted. =~ and !~ match a glob pattern
+// (*, ?, [...]); == and != compare exactly.
+func EvalCondition(expr string, facts map[string]string) (bool, error) {
+	node, err := parseCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	return node.eval(facts), nil
+}
+
+// condNode is a parsed condition.
+type condNode interface {
+	eval(facts map[string]string) bool
+}
+
+type condOr struct{ left, right condNode }
+
+func (n condOr) eval(f map[string]string) bool { return n.left.eval(f) || n.right.eval(f) }
+
+type condAnd struct{ left, right condNode }
+
+func (n condAnd) eval(f map[string]string) bool { return n.left.eval(f) && n.right.eval(f) }
+
+type condNot struct{ operand condNode }
+
+func (n condNot) eval(f map[string]string) bool { return !n.operand.eval(f) }
+
+type condCompare struct {
+	variable string
+	op       string
+	value    string
+}
+
+func (n condCompare) eval(f map[string]string) bool {
+	actual := f[n.variable]
+	switch n.op {
+	case "==":
+		return actual == n.value
+	case "!=":
+		return actual != n.value
+	case "=~":
+		matched, _ := path.Match(n.value, actual)
+		return matched
+	default: // "!~"
+		matched, _ := path.Match(n.value, actual)
+		return !matched
+	}
+}
+
+// condParser is a recursive-descent parser over condition tokens.
+type condParser struct {
+	expr   string
+	tokens []string
+	pos    int
+}
+
+// parseCondition parses expr into a condNode.
+func parseCondition(expr string) (condNode, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w: %w", expr, err, ErrInvalidInclude)
+	}
+	p := &condParser{expr: expr, tokens: tokens}
+	node, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w: %w", expr, err, ErrInvalidInclude)
+	}
+	return node, nil
+}
+
+func (p *condParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *condParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *condParser) parseOr() (condNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = condOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *condParser) parseAnd() (condNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = condAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *condParser) parseUnary() (condNode, error) {
+	switch p.peek() {
+	case "!":
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return condNot{operand}, nil
+	case "(":
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *condParser) parseComparison() (condNode, error) {
+	variable := p.next()
+	if variable == "" {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	if !slices.Contains(ConditionVariables, variable) {
+		return nil, fmt.Errorf("unknown variable %q: expected one of %s", variable, strings.Join(ConditionVariables, ", "))
+	}
+	op := p.next()
+	if !slices.Contains([]string{"==", "!=", "=~", "!~"}, op) {
+		return nil, fmt.Errorf("expected ==, !=, =~ or !~ after %s", variable)
+	}
+	value := p.next()
+	if len(value) < 2 || (value[0] != '\'' && value[0] != '"') {
+		return nil, fmt.Errorf("expected a quoted string after %s %s", variable, op)
+	}
+	value = value[1 : len(value)-1]
+	if op == "=~" || op == "!~" {
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", value, err)
+		}
+	}
+	return condCompare{variable: variable, op: op, value: value}, nil
+}
+
+// tokenizeCondition splits expr into identifiers, operators, parentheses
+// and quoted strings (kept with their quotes).
+func tokenizeCondition(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, expr[i:i+end+2])
+			i += end + 2
+		case strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"),
+			strings.HasPrefix(expr[i:], "=="), strings.HasPrefix(expr[i:], "!="),
+			strings.HasPrefix(expr[i:], "=~"), strings.HasPrefix(expr[i:], "!~"):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case c == '!':
+			tokens = append(tokens, "!")
+			i++
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(expr) && (expr[i] == '_' || expr[i] >= 'a' && expr[i] <= 'z' || expr[i] >= 'A' && expr[i] <= 'Z' || expr[i] >= '0' && expr[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, expr[start:i])
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
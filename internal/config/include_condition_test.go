@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestEvalCondition(t *testing.T) {
+	facts := map[string]string{"platform": "darwin", "arch": "arm64", "hostname": "corp-laptop-7", "user": "alice"}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`platform == 'darwin'`, true},
+		{`platform == "linux"`, false},
+		{`platform != 'linux'`, true},
+		{`hostname =~ 'corp-*'`, true},
+		{`hostname !~ 'corp-*'`, false},
+		{`hostname =~ 'home-?'`, false},
+		{`platform == 'darwin' && arch == 'amd64'`, false},
+		{`platform == 'linux' || arch == 'arm64'`, true},
+		{`!(platform == 'linux')`, true},
+		{`platform == 'linux' || user == 'alice' && arch == 'arm64'`, true},
+		{`(platform == 'linux' || user == 'alice') && !(arch == 'arm64')`, false},
+	}
+	for _, tt := range tests {
+		got, err := EvalCondition(tt.expr, facts)
+		if err != nil {
+			t.Errorf("EvalCondition(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("EvalCondition(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvalCondition_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`os == 'darwin'`,
+		`platform = 'darwin'`,
+		`platform == darwin`,
+		`platform == 'darwin`,
+		`(platform == 'darwin'`,
+		`platform == 'darwin' &&`,
+		`platform == 'darwin' arch`,
+		`hostname =~ '[corp'`,
+	} {
+		if _, err := EvalCondition(expr, nil); !errors.Is(err, ErrInvalidInclude) {
+			t.Errorf("EvalCondition(%q) error = %v, want ErrInvalidInclude", expr, err)
+		}
+	}
+}
+
+func TestLoadWithIncludes_Conditional(t *testing.T) {
+	orig := conditionFacts
+	t.Cleanup(func() { conditionFacts = orig })
+	conditionFacts = func() map[string]string {
+		return map[string]string{"platform": "darwin", "arch": "arm64", "hostname": "home-mac", "user": "alice"}
+	}
+
+	env, memFs := newTestEnv(t)
+	files := map[string]string{
+		"/test/.alca.toml": `
+image = "main:latest"
+includes = [
+  { path = ".alca.macos.toml", when = "platform == 'darwin'" },
+  { path = ".alca.work.toml", when = "hostname =~ 'corp-*'" },
+  ".alca.local.toml",
+]
+`,
+		"/test/.alca.macos.toml": "workdir = \"/mac\"\n",
+		"/test/.alca.work.toml":  "image = \"work:latest\"\n",
+		"/test/.alca.local.toml": "[envs]\nLOCAL = \"1\"\n",
+	}
+	for path, content := range files {
+		if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	cfg, err := LoadWithIncludes(env, "/test/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadWithIncludes failed: %v", err)
+	}
+	if cfg.Workdir != "/mac" {
+		t.Errorf("workdir = %q, want /mac from the darwin include", cfg.Workdir)
+	}
+	if cfg.Image != "main:latest" {
+		t.Errorf("image = %q, the work include should be skipped", cfg.Image)
+	}
+	if cfg.Envs["LOCAL"].Value != "1" {
+		t.Errorf("unconditional include not applied: envs = %v", cfg.Envs)
+	}
+	if IncludesFile(env, "/test/.alca.toml", "/test/.alca.work.toml", noExpandEnv) {
+		t.Error("IncludesFile should not report an include whose condition does not hold")
+	}
+}
+
+func TestLoadWithIncludes_InvalidIncludeEntry(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	content := "image = \"main:latest\"\nincludes = [{ path = \".alca.x.toml\", when = \"os == 'darwin'\" }]\n"
+	if err := afero.WriteFile(memFs, "/test/.alca.toml", []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := LoadWithIncludes(env, "/test/.alca.toml", noExpandEnv); !errors.Is(err, ErrInvalidInclude) {
+		t.Errorf("expected ErrInvalidInclude, got %v", err)
+	}
+}
@@ -17,32 +17,62 @@ import (
 // LoadWithIncludes loads config with extends/includes support.
 // It processes extends and includes recursively, merging configs per AGD-033 priority rules.
 // expandEnv expands ${VAR} references in include/extend paths (use os.ExpandEnv for production).
-func LoadWithIncludes(env *util.Env, path string, expandEnv func(string) (string, error)) (Config, error) {
-	return loadWithIncludes(env, path, expandEnv, make(map[string]bool))
+// Once trusted config keys are configured, referenced files outside the
+// project directory must be signed (see signature.go).
+func LoadWithIncludes(env *util.Env, path string, expandEnv func(string) (string, error), opts ...LoadOption) (Config, error) {
+	o := newLoadOptions(opts)
+	verifier, err := newIncludeVerifier(env.Fs, path, o)
+	if err != nil {
+		return Config{}, err
+	}
+	return loadWithIncludes(env, path, expandEnv, verifier, o, make(map[string]bool))
 }
 
 // loadWithIncludes is the internal recursive implementation.
 // Processing order (AGD-033):
 //  1. Load and parse raw config
-//  2. Process extends files (they become the base)
+//  2. Process extends files (they become the base), then the parent
+//     project config of a nested ProjectConfigFilename (below them)
 //  3. Convert current file to Config, merge: current overlays extends result
 //  4. Process includes files (they overlay current)
-func loadWithIncludes(env *util.Env, path string, expandEnv func(string) (string, error), visited map[string]bool) (Config, error) {
+func loadWithIncludes(env *util.Env, path string, expandEnv func(string) (string, error), verifier *includeVerifier, opts loadOptions, visited map[string]bool) (Config, error) {
 	absPath, err := validateAndMarkVisited(path, visited)
 	if err != nil {
 		return Config{}, err
 	}
 
-	raw, err := readRawConfig(env, path)
+	// Verify and parse the same bytes, so a file swapped after the
+	// signature check is never loaded
+	data, err := afero.ReadFile(env.Fs, path)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := verifier.verify(env.Fs, absPath, data); err != nil {
+		return Config{}, err
+	}
+	raw, err := parseRawConfig(env, path, data, opts)
 	if err != nil {
 		return Config{}, err
 	}
 
 	// Step 1: Process extends (current file wins over extended files)
-	extendsResult, err := processExtends(env, raw.Extends, absPath, expandEnv, visited)
+	extendsResult, err := processExtends(env, raw.Extends, absPath, expandEnv, verifier, opts, visited)
 	if err != nil {
 		return Config{}, err
 	}
+	extended := len(raw.Extends) > 0
+
+	// A subproject that sets inherit = true extends its parent project's
+	// config, unless it already extends it explicitly. The parent is
+	// outside the project, so it is verified like any extended file.
+	if parent := parentConfigPath(env.Fs, absPath, raw); parent != "" && !visited[parent] {
+		parentConfig, err := loadWithIncludes(env, parent, expandEnv, verifier, opts, visited)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to load parent project config %s: %w", parent, err)
+		}
+		extendsResult = mergeConfigs(parentConfig, extendsResult)
+		extended = true
+	}
 
 	// Step 2: Convert current file
 	currentConfig, err := rawToConfig(raw, expandEnv)
@@ -51,15 +81,19 @@ func loadWithIncludes(env *util.Env, path string, expandEnv func(string) (string
 	}
 
 	// Step 3: Merge extends: current overlays extends result (current wins)
-	if len(raw.Extends) > 0 {
+	if extended {
 		currentConfig = mergeConfigs(extendsResult, currentConfig)
 	}
 
 	// Step 4: Process includes (included files win over current)
 	// Fold includes one-by-one onto currentConfig so each append sees
 	// the accumulated result (not just other includes merged together).
-	if len(raw.Includes) > 0 {
-		includeConfigs, err := loadFileRefs(env, raw.Includes, absPath, expandEnv, visited)
+	includes, err := raw.Includes.ActivePaths()
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(includes) > 0 {
+		includeConfigs, err := loadFileRefs(env, includes, absPath, expandEnv, verifier, opts, visited)
 		if err != nil {
 			return Config{}, err
 		}
@@ -85,11 +119,30 @@ func validateAndMarkVisited(path string, visited map[string]bool) (string, error
 }
 
 // readRawConfig reads and parses a TOML config file.
-func readRawConfig(env *util.Env, path string) (RawConfig, error) {
+// Files ending in EncryptedConfigSuffix are decrypted in memory first.
+// Older schema versions are migrated in memory before decoding.
+func readRawConfig(env *util.Env, path string, opts loadOptions) (RawConfig, error) {
 	data, err := afero.ReadFile(env.Fs, path)
 	if err != nil {
 		return RawConfig{}, err
 	}
+	return parseRawConfig(env, path, data, opts)
+}
+
+// parseRawConfig parses data read from the config file at path, like
+// readRawConfig.
+func parseRawConfig(env *util.Env, path string, data []byte, opts loadOptions) (RawConfig, error) {
+	var err error
+	if IsEncryptedConfigPath(path) {
+		data, err = decryptConfig(env.Fs, path, data, opts)
+		if err != nil {
+			return RawConfig{}, err
+		}
+	}
+	data, _, err = migrateConfigData(data)
+	if err != nil {
+		return RawConfig{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
 	var raw RawConfig
 	if err := toml.Unmarshal(data, &raw); err != nil {
 		return RawConfig{}, fmt.Errorf("failed to parse %s: %w", path, err)
@@ -97,10 +150,34 @@ func readRawConfig(env *util.Env, path string) (RawConfig, error) {
 	return raw, nil
 }
 
+// IncludesFile reports whether the config at path lists file among its
+// includes, directly or through a glob, under a condition that holds here.
+func IncludesFile(env *util.Env, path, file string, expandEnv func(string) (string, error), opts ...LoadOption) bool {
+	raw, err := readRawConfig(env, path, newLoadOptions(opts))
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	includes, err := raw.Includes.ActivePaths()
+	if err != nil {
+		return false
+	}
+	for _, rawRef := range includes {
+		files, err := NewConfigFileRef(absPath, rawRef).Expand(expandEnv, env.Fs)
+		if err == nil && slices.Contains(files, file) {
+			return true
+		}
+	}
+	return false
+}
+
 // processExtends loads and merges extends refs with first-entry-wins priority.
 // Fold right-to-left: start from last, each earlier entry is overlay (wins).
-func processExtends(env *util.Env, refs []string, configFilePath string, expandEnv func(string) (string, error), visited map[string]bool) (Config, error) {
-	configs, err := loadFileRefs(env, refs, configFilePath, expandEnv, visited)
+func processExtends(env *util.Env, refs []string, configFilePath string, expandEnv func(string) (string, error), verifier *includeVerifier, opts loadOptions, visited map[string]bool) (Config, error) {
+	configs, err := loadFileRefs(env, refs, configFilePath, expandEnv, verifier, opts, visited)
 	if err != nil {
 		return Config{}, err
 	}
@@ -112,7 +189,7 @@ func processExtends(env *util.Env, refs []string, configFilePath string, expandE
 }
 
 // loadFileRefs loads all referenced configs, expanding globs and resolving recursively.
-func loadFileRefs(env *util.Env, refs []string, configFilePath string, expandEnv func(string) (string, error), visited map[string]bool) ([]Config, error) {
+func loadFileRefs(env *util.Env, refs []string, configFilePath string, expandEnv func(string) (string, error), verifier *includeVerifier, opts loadOptions, visited map[string]bool) ([]Config, error) {
 	var configs []Config
 	for _, rawPath := range refs {
 		ref := NewConfigFileRef(configFilePath, rawPath)
@@ -122,7 +199,7 @@ func loadFileRefs(env *util.Env, refs []string, configFilePath string, expandEnv
 		}
 
 		for _, file := range files {
-			cfg, err := loadWithIncludes(env, file, expandEnv, visited)
+			cfg, err := loadWithIncludes(env, file, expandEnv, verifier, opts, visited)
 			if err != nil {
 				return nil, fmt.Errorf("failed to load referenced config %s: %w", file, err)
 			}
@@ -137,19 +214,43 @@ func loadFileRefs(env *util.Env, refs []string, configFilePath string, expandEnv
 func rawToConfig(raw RawConfig, expandEnv func(string) (string, error)) (Config, error) {
 	// Mirror type ensures all RawConfig fields are explicitly handled (AGD-015).
 	type rawConfigFields struct {
-		Extends        []string
-		Includes       []string
-		Image          string
-		Workdir        string
-		WorkdirExclude []string
-		Runtime        RuntimeType
-		Commands       RawCommands
-		Mounts         RawMountSlice
-		Resources      Resources
-		Envs           RawEnvValueMap
-		Network        RawNetwork
-		Caps           RawCaps
-		Hooks          Hooks
+		SchemaVersion      int
+		Inherit            bool
+		Extends            []string
+		Includes           RawIncludeSlice
+		Image              string
+		ImageCheck         ImageCheckMode
+		Platform           string
+		RegistryMirror     string
+		Workdir            string
+		WorkdirSource      string
+		WorkdirExclude     []string
+		WorkdirExcludeFrom []string
+		WorkdirSync        MountSync
+		Runtime            RuntimeType
+		RuntimeContext     string
+		User               string
+		EnterTmux          bool
+		NestedContainers   NestedContainersMode
+		Userns             UsernsMode
+		Commands           RawCommands
+		Mounts             RawMountSlice
+		Resources          Resources
+		Envs               RawEnvValueMap
+		Network            RawNetwork
+		SSH                SSH
+		Caps               RawCaps
+		Hooks              Hooks
+		Rebuild            Rebuild
+		Clock              Clock
+		Timeouts           Timeouts
+		IdleTimeout        string
+		ContainerName      string
+		NameTemplate       string
+		Plugins            []string
+		Cron               map[string]string
+		ForwardFileEvents  bool
+		UI                 UI
 	}
 	// Verify: if a field is added to RawConfig but not here, this line fails to compile.
 	_ = rawConfigFields(raw)
@@ -164,6 +265,12 @@ func rawToConfig(raw RawConfig, expandEnv func(string) (string, error)) (Config,
 		envs[key] = env
 	}
 
+	// Expand ${VAR} in workdir_source like other mount sources
+	workdirSource, err := expandEnv(raw.WorkdirSource)
+	if err != nil {
+		return Config{}, fmt.Errorf("workdir_source: %w", err)
+	}
+
 	// Convert raw mounts to MountConfig
 	mounts, err := parseMounts(raw.Mounts, expandEnv)
 	if err != nil {
@@ -181,10 +288,20 @@ func rawToConfig(raw RawConfig, expandEnv func(string) (string, error)) (Config,
 	if err != nil {
 		return Config{}, fmt.Errorf("commands.up: %w", err)
 	}
+	if cmdUp.User != "" {
+		return Config{}, fmt.Errorf("commands.up: user is only supported for commands.enter")
+	}
 	cmdEnter, err := parseCommandValue(raw.Commands.Enter)
 	if err != nil {
 		return Config{}, fmt.Errorf("commands.enter: %w", err)
 	}
+	cmdTest, err := parseCommandValue(raw.Commands.Test)
+	if err != nil {
+		return Config{}, fmt.Errorf("commands.test: %w", err)
+	}
+	if cmdTest.User != "" {
+		return Config{}, fmt.Errorf("commands.test: user is only supported for commands.enter")
+	}
 
 	// Convert raw ports to PortConfig
 	ports, err := parsePorts(raw.Network.Ports)
@@ -194,42 +311,85 @@ func rawToConfig(raw RawConfig, expandEnv func(string) (string, error)) (Config,
 
 	// Mirror type ensures all RawNetwork fields are explicitly handled (AGD-015).
 	type rawNetworkFields struct {
-		LANAccess []string
-		Ports     RawPortSlice
-		Proxy     string
+		LANAccess       []string
+		Ports           RawPortSlice
+		Proxy           string
+		Shaping         Shaping
+		LogConnections  bool
+		AllowHostAccess bool
+		Isolation       string
+		Peers           []string
+		Preset          string
+		Presets         map[string]NetworkPreset
 	}
 	_ = rawNetworkFields(raw.Network)
 
 	// Mirror type ensures all Network fields are explicitly handled (AGD-015).
 	type networkFields struct {
-		LANAccess []string
-		Ports     []PortConfig
-		Proxy     string
+		LANAccess       []string
+		Ports           []PortConfig
+		Proxy           string
+		Shaping         Shaping
+		LogConnections  bool
+		AllowHostAccess bool
+		Isolation       string
+		Peers           []string
+		Preset          string
+		Presets         map[string]NetworkPreset
 	}
 	network := Network{
-		LANAccess: raw.Network.LANAccess,
-		Ports:     ports,
-		Proxy:     raw.Network.Proxy,
+		LANAccess:       raw.Network.LANAccess,
+		Ports:           ports,
+		Proxy:           raw.Network.Proxy,
+		Shaping:         raw.Network.Shaping,
+		LogConnections:  raw.Network.LogConnections,
+		AllowHostAccess: raw.Network.AllowHostAccess,
+		Isolation:       raw.Network.Isolation,
+		Peers:           raw.Network.Peers,
+		Preset:          raw.Network.Preset,
+		Presets:         raw.Network.Presets,
 	}
 	_ = networkFields(network)
 
 	return Config{
-		Image:          raw.Image,
-		Workdir:        raw.Workdir,
-		WorkdirExclude: raw.WorkdirExclude,
-		Runtime:        raw.Runtime,
-		Commands:       Commands{Up: cmdUp, Enter: cmdEnter},
-		Mounts:         mounts,
-		Resources:      raw.Resources,
-		Envs:           envs,
-		Network:        network,
-		Caps:           caps,
-		Hooks:          raw.Hooks,
+		Image:              raw.Image,
+		ImageCheck:         raw.ImageCheck,
+		Platform:           raw.Platform,
+		RegistryMirror:     raw.RegistryMirror,
+		Workdir:            raw.Workdir,
+		WorkdirSource:      workdirSource,
+		WorkdirExclude:     raw.WorkdirExclude,
+		WorkdirExcludeFrom: raw.WorkdirExcludeFrom,
+		WorkdirSync:        raw.WorkdirSync,
+		Runtime:            raw.Runtime,
+		RuntimeContext:     raw.RuntimeContext,
+		User:               raw.User,
+		EnterTmux:          raw.EnterTmux,
+		NestedContainers:   raw.NestedContainers,
+		Userns:             raw.Userns,
+		Commands:           Commands{Up: cmdUp, Enter: cmdEnter, Test: cmdTest},
+		Mounts:             mounts,
+		Resources:          raw.Resources,
+		Envs:               envs,
+		Network:            network,
+		SSH:                raw.SSH,
+		Caps:               caps,
+		Hooks:              raw.Hooks,
+		Rebuild:            raw.Rebuild,
+		Clock:              raw.Clock,
+		Timeouts:           raw.Timeouts,
+		IdleTimeout:        raw.IdleTimeout,
+		ContainerName:      raw.ContainerName,
+		NameTemplate:       raw.NameTemplate,
+		Plugins:            raw.Plugins,
+		Cron:               raw.Cron,
+		ForwardFileEvents:  raw.ForwardFileEvents,
+		UI:                 raw.UI,
 	}, nil
 }
 
 // parseCommandValue converts a raw value to CommandValue.
-// Accepts string or map[string]any with command and append fields.
+// Accepts string or map[string]any with command, append and user fields.
 func parseCommandValue(val any) (CommandValue, error) {
 	if val == nil {
 		return CommandValue{}, nil
@@ -245,6 +405,9 @@ func parseCommandValue(val any) (CommandValue, error) {
 		if append, ok := v["append"].(bool); ok {
 			cv.Append = append
 		}
+		if user, ok := v["user"].(string); ok {
+			cv.User = user
+		}
 		return cv, nil
 	default:
 		return CommandValue{}, fmt.Errorf("expected string or object, got %T", val)
@@ -256,6 +419,7 @@ func parseCommandValue(val any) (CommandValue, error) {
 //   - nil: returns empty Caps (defaults applied later in LoadConfig)
 //   - array of strings: additive mode (Drop=["ALL"], Add=defaults+user)
 //   - object with drop/add: full control mode (use as-is)
+//   - string: a named preset from CapsPresets
 //
 // See AGD-026 for design rationale.
 func parseCaps(val any) (caps Caps, err error) {
@@ -265,6 +429,9 @@ func parseCaps(val any) (caps Caps, err error) {
 	}
 
 	switch v := val.(type) {
+	case string:
+		return capsPreset(v)
+
 	case []any:
 		// Array mode (additive): user specifies additional caps beyond defaults
 		userCaps, err := toStringSlice(v, "caps")
@@ -301,12 +468,12 @@ func parseCaps(val any) (caps Caps, err error) {
 		return caps, nil
 
 	default:
-		return Caps{}, fmt.Errorf("caps: expected array or object, got %T", val)
+		return Caps{}, fmt.Errorf("caps: expected preset name, array or object, got %T", val)
 	}
 }
 
 // parseEnvValue converts a raw value to EnvValue.
-// Accepts string or map[string]any with value and override_on_enter fields.
+// Accepts string or map[string]any with value, override_on_enter and sensitive fields.
 func parseEnvValue(val any) (EnvValue, error) {
 	switch v := val.(type) {
 	case string:
@@ -319,6 +486,9 @@ func parseEnvValue(val any) (EnvValue, error) {
 		if override, ok := v["override_on_enter"].(bool); ok {
 			env.OverrideOnEnter = override
 		}
+		if sensitive, ok := v["sensitive"].(bool); ok {
+			env.Sensitive = sensitive
+		}
 		return env, nil
 	default:
 		return EnvValue{}, fmt.Errorf("invalid type: %T: %w", val, ErrInvalidType)
@@ -333,17 +503,39 @@ func mergeConfigs(base, overlay Config) Config {
 	// Mirror type ensures all Config fields are explicitly handled (AGD-015).
 	// Adding a new field to Config will cause a compile error here.
 	type configFields struct {
-		Image          string
-		Workdir        string
-		WorkdirExclude []string
-		Runtime        RuntimeType
-		Commands       Commands
-		Mounts         []MountConfig
-		Resources      Resources
-		Envs           map[string]EnvValue
-		Network        Network
-		Caps           Caps
-		Hooks          Hooks
+		Image              string
+		ImageCheck         ImageCheckMode
+		Platform           string
+		RegistryMirror     string
+		Workdir            string
+		WorkdirSource      string
+		WorkdirExclude     []string
+		WorkdirExcludeFrom []string
+		WorkdirSync        MountSync
+		Runtime            RuntimeType
+		RuntimeContext     string
+		User               string
+		EnterTmux          bool
+		NestedContainers   NestedContainersMode
+		Userns             UsernsMode
+		Commands           Commands
+		Mounts             []MountConfig
+		Resources          Resources
+		Envs               map[string]EnvValue
+		Network            Network
+		SSH                SSH
+		Caps               Caps
+		Hooks              Hooks
+		Rebuild            Rebuild
+		Clock              Clock
+		Timeouts           Timeouts
+		IdleTimeout        string
+		ContainerName      string
+		NameTemplate       string
+		Plugins            []string
+		Cron               map[string]string
+		ForwardFileEvents  bool
+		UI                 UI
 	}
 	_ = configFields(base)
 	_ = configFields(overlay)
@@ -354,6 +546,7 @@ func mergeConfigs(base, overlay Config) Config {
 	result.Envs = maps.Clone(base.Envs)
 	result.Mounts = slices.Clone(base.Mounts)
 	result.Network.LANAccess = slices.Clone(base.Network.LANAccess)
+	result.Network.Peers = slices.Clone(base.Network.Peers)
 	result.Network.Ports = slices.Clone(base.Network.Ports)
 	// Network.Proxy is a string — no cloning needed
 
@@ -361,19 +554,58 @@ func mergeConfigs(base, overlay Config) Config {
 	if overlay.Image != "" {
 		result.Image = overlay.Image
 	}
+	if overlay.ImageCheck != "" {
+		result.ImageCheck = overlay.ImageCheck
+	}
+	if overlay.Platform != "" {
+		result.Platform = overlay.Platform
+	}
+	if overlay.RegistryMirror != "" {
+		result.RegistryMirror = overlay.RegistryMirror
+	}
 	if overlay.Workdir != "" {
 		result.Workdir = overlay.Workdir
 	}
+	if overlay.WorkdirSource != "" {
+		result.WorkdirSource = overlay.WorkdirSource
+	}
 	if len(overlay.WorkdirExclude) > 0 {
 		result.WorkdirExclude = overlay.WorkdirExclude
 	}
+	if len(overlay.WorkdirExcludeFrom) > 0 {
+		result.WorkdirExcludeFrom = overlay.WorkdirExcludeFrom
+	}
+	if !overlay.WorkdirSync.IsZero() {
+		result.WorkdirSync = overlay.WorkdirSync
+	}
 	if overlay.Runtime != "" {
 		result.Runtime = overlay.Runtime
 	}
+	if overlay.RuntimeContext != "" {
+		result.RuntimeContext = overlay.RuntimeContext
+	}
+	if overlay.User != "" {
+		result.User = overlay.User
+	}
+	if overlay.NestedContainers != "" {
+		result.NestedContainers = overlay.NestedContainers
+	}
+	if overlay.Userns != "" {
+		result.Userns = overlay.Userns
+	}
+	// EnterTmux: enabling in any layer enables it
+	if overlay.EnterTmux {
+		result.EnterTmux = true
+	}
+	// ForwardFileEvents: enabling in any layer enables it
+	if overlay.ForwardFileEvents {
+		result.ForwardFileEvents = true
+	}
 
 	// Commands: deep merge with append support (AGD-033)
 	result.Commands.Up = mergeCommandValue(base.Commands.Up, overlay.Commands.Up)
 	result.Commands.Enter = mergeCommandValue(base.Commands.Enter, overlay.Commands.Enter)
+	result.Commands.Test = mergeCommandValue(base.Commands.Test, overlay.Commands.Test)
 
 	// Mounts: append (concatenate arrays)
 	if len(overlay.Mounts) > 0 {
@@ -387,6 +619,7 @@ func mergeConfigs(base, overlay Config) Config {
 	if overlay.Resources.CPUs != 0 {
 		result.Resources.CPUs = overlay.Resources.CPUs
 	}
+	result.Resources.Cgroup = mergeCgroup(result.Resources.Cgroup, overlay.Resources.Cgroup)
 
 	// Envs: merge maps (overlay wins for same keys)
 	if result.Envs == nil && len(overlay.Envs) > 0 {
@@ -400,6 +633,12 @@ func mergeConfigs(base, overlay Config) Config {
 	if len(overlay.Network.LANAccess) > 0 {
 		result.Network.LANAccess = append(result.Network.LANAccess, overlay.Network.LANAccess...)
 	}
+	// Peers: append, skipping duplicates
+	for _, peer := range overlay.Network.Peers {
+		if !slices.Contains(result.Network.Peers, peer) {
+			result.Network.Peers = append(result.Network.Peers, peer)
+		}
+	}
 	// Ports: overlay replaces if non-empty (complete specification, not append)
 	if len(overlay.Network.Ports) > 0 {
 		result.Network.Ports = overlay.Network.Ports
@@ -408,6 +647,44 @@ func mergeConfigs(base, overlay Config) Config {
 	if overlay.Network.Proxy != "" {
 		result.Network.Proxy = overlay.Network.Proxy
 	}
+	// LogConnections: enabling in any layer enables it
+	if overlay.Network.LogConnections {
+		result.Network.LogConnections = true
+	}
+	// AllowHostAccess: enabling in any layer enables it
+	if overlay.Network.AllowHostAccess {
+		result.Network.AllowHostAccess = true
+	}
+	// Isolation: overlay wins if non-empty
+	if overlay.Network.Isolation != "" {
+		result.Network.Isolation = overlay.Network.Isolation
+	}
+	// Preset: overlay wins if non-empty; definitions merge by name (overlay wins)
+	if overlay.Network.Preset != "" {
+		result.Network.Preset = overlay.Network.Preset
+	}
+	if len(overlay.Network.Presets) > 0 {
+		result.Network.Presets = maps.Clone(base.Network.Presets)
+		if result.Network.Presets == nil {
+			result.Network.Presets = make(map[string]NetworkPreset)
+		}
+		maps.Copy(result.Network.Presets, overlay.Network.Presets)
+	}
+	// Shaping: deep merge, overlay wins per field
+	if overlay.Network.Shaping.Rate != "" {
+		result.Network.Shaping.Rate = overlay.Network.Shaping.Rate
+	}
+	if overlay.Network.Shaping.Delay != "" {
+		result.Network.Shaping.Delay = overlay.Network.Shaping.Delay
+	}
+
+	// SSH: overlay wins per field if set
+	if overlay.SSH.Enabled {
+		result.SSH.Enabled = true
+	}
+	if overlay.SSH.Port != 0 {
+		result.SSH.Port = overlay.SSH.Port
+	}
 
 	// Caps: overlay wins if non-empty (full replacement, not merge)
 	if len(overlay.Caps.Drop) > 0 || len(overlay.Caps.Add) > 0 {
@@ -422,6 +699,60 @@ func mergeConfigs(base, overlay Config) Config {
 		result.Hooks.PreDown = overlay.Hooks.PreDown
 	}
 
+	// Plugins: append, skipping duplicates
+	for _, name := range overlay.Plugins {
+		if !slices.Contains(result.Plugins, name) {
+			result.Plugins = append(result.Plugins, name)
+		}
+	}
+
+	// Cron: merge by schedule, overlay wins
+	if len(overlay.Cron) > 0 {
+		result.Cron = maps.Clone(base.Cron)
+		if result.Cron == nil {
+			result.Cron = make(map[string]string)
+		}
+		maps.Copy(result.Cron, overlay.Cron)
+	}
+
+	// Rebuild: overlay replaces preserve list if non-empty
+	if len(overlay.Rebuild.Preserve) > 0 {
+		result.Rebuild.Preserve = overlay.Rebuild.Preserve
+	}
+
+	// UI: overlay wins per field if non-empty
+	if overlay.UI.Title != "" {
+		result.UI.Title = overlay.UI.Title
+	}
+	if overlay.UI.PromptPrefix != "" {
+		result.UI.PromptPrefix = overlay.UI.PromptPrefix
+	}
+
+	// Clock: overlay wins if non-empty
+	if overlay.Clock.DriftThreshold != "" {
+		result.Clock.DriftThreshold = overlay.Clock.DriftThreshold
+	}
+
+	// Timeouts: overlay wins per field if non-empty
+	if overlay.Timeouts.Pull != "" {
+		result.Timeouts.Pull = overlay.Timeouts.Pull
+	}
+	if overlay.Timeouts.UpCommand != "" {
+		result.Timeouts.UpCommand = overlay.Timeouts.UpCommand
+	}
+
+	// IdleTimeout: overlay wins if non-empty
+	if overlay.IdleTimeout != "" {
+		result.IdleTimeout = overlay.IdleTimeout
+	}
+
+	// ContainerName/NameTemplate: an overlay setting either replaces both,
+	// so a base's name and an overlay's template never conflict
+	if overlay.ContainerName != "" || overlay.NameTemplate != "" {
+		result.ContainerName = overlay.ContainerName
+		result.NameTemplate = overlay.NameTemplate
+	}
+
 	return result
 }
 
@@ -441,19 +772,48 @@ func toStringSlice(items []any, context string) ([]string, error) {
 // mergeCommandValue merges two CommandValues with append support.
 // If overlay is empty, base is returned unchanged.
 // If overlay has Append=true and base is non-empty, commands are space-concatenated.
-// Otherwise overlay replaces base.
+// Otherwise overlay replaces base. User is merged on its own: overlay wins
+// if non-empty, even when overlay sets no command.
 func mergeCommandValue(base, overlay CommandValue) CommandValue {
+	user := base.User
+	if overlay.User != "" {
+		user = overlay.User
+	}
 	if overlay.Command == "" {
+		base.User = user
 		return base
 	}
 	if overlay.Append && base.Command != "" {
 		return CommandValue{
 			Command: base.Command + " " + overlay.Command,
 			Append:  false, // append is consumed during merge
+			User:    user,
 		}
 	}
 	return CommandValue{
 		Command: overlay.Command,
 		Append:  overlay.Append, // preserve for later merges in layered resolution
+		User:    user,
+	}
+}
+
+// mergeCgroup merges resources.cgroup field by field: overlay wins for each
+// field it sets. The io_latency device and target are one setting.
+func mergeCgroup(base, overlay Cgroup) Cgroup {
+	if overlay.Parent != "" {
+		base.Parent = overlay.Parent
+	}
+	if overlay.CPUWeight != 0 {
+		base.CPUWeight = overlay.CPUWeight
+	}
+	if overlay.CpusetCPUs != "" {
+		base.CpusetCPUs = overlay.CpusetCPUs
+	}
+	if overlay.CpusetMems != "" {
+		base.CpusetMems = overlay.CpusetMems
+	}
+	if overlay.IOLatency != (IOLatency{}) {
+		base.IOLatency = overlay.IOLatency
 	}
+	return base
 }
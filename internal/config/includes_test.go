@@ -1690,3 +1690,16 @@ image = "alpine:latest"
 		t.Errorf("Hooks.PreDown = %q, want %q (base should be preserved)", cfg.Hooks.PreDown, "base-pre-down")
 	}
 }
+
+// TestMergeConfigs_PluginsAppendUnique verifies that overlay plugins are
+// appended to base plugins without duplicates.
+func TestMergeConfigs_PluginsAppendUnique(t *testing.T) {
+	base := Config{Plugins: []string{"notify"}}
+	overlay := Config{Plugins: []string{"tunnel", "notify"}}
+
+	result := mergeConfigs(base, overlay)
+
+	if len(result.Plugins) != 2 || result.Plugins[0] != "notify" || result.Plugins[1] != "tunnel" {
+		t.Errorf("expected Plugins=[notify tunnel], got %v", result.Plugins)
+	}
+}
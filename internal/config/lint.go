@@ -0,0 +1,226 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// LintSeverity ranks how risky a lint finding is.
+type LintSeverity string
+
+const (
+	// LintError marks settings that defeat the sandbox.
+	LintError LintSeverity = "error"
+	// LintWarning marks settings that weaken isolation or leak data.
+	LintWarning LintSeverity = "warning"
+	// LintInfo marks redundant or ineffective settings.
+	LintInfo LintSeverity = "info"
+)
+
+// Rank orders severities from info (0) to error (2); unknown severities rank -1.
+func (s LintSeverity) Rank() int {
+	return slices.Index([]LintSeverity{LintInfo, LintWarning, LintError}, s)
+}
+
+// LintFinding is one risky or redundant setting found by Lint.
+type LintFinding struct {
+	Rule     string       `json:"rule"`
+	Severity LintSeverity `json:"severity"`
+	Field    string       `json:"field"`
+	Message  string       `json:"message"`
+}
+
+// lintGOOS is the host OS mount options are checked against. A variable so
+// tests can check both platforms.
+var lintGOOS = runtime.GOOS
+
+// lintCaseInsensitive reports whether a host directory is on a
+// case-insensitive filesystem. A variable so tests need no such filesystem.
+var lintCaseInsensitive = caseInsensitiveDir
+
+// dangerousCaps are capabilities that let a process escape or inspect
+// beyond the container.
+var dangerousCaps = []string{"ALL", "SYS_ADMIN", "SYS_PTRACE", "SYS_MODULE"}
+
+// secretNamePattern matches env names that usually carry credentials.
+var secretNamePattern = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSWORD|PASSWD|API_?KEY|PRIVATE_KEY|CREDENTIAL)`)
+
+// secretValuePattern matches well-known credential formats.
+var secretValuePattern = regexp.MustCompile(`^(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_\w{20,}|sk-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|xox[abprs]-[A-Za-z0-9-]{10,})`)
+
+// Lint reports risky or redundant settings in a loaded config. path is the
+// top-level config file (used to find include and extends references) and
+// home is the user's home directory. Findings are ordered by severity, most
+// severe first.
+func Lint(env *util.Env, path string, cfg *Config, home string, expandEnv func(string) (string, error)) []LintFinding {
+	var findings []LintFinding
+	add := func(rule string, severity LintSeverity, field, format string, args ...any) {
+		findings = append(findings, LintFinding{Rule: rule, Severity: severity, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Relative sources (including the "." or workdir_source workdir mount) are
+	// relative to the project directory.
+	projectDir := filepath.Dir(path)
+	targets := make(map[string]bool)
+	for _, m := range cfg.Mounts {
+		field := "mounts[" + m.Target + "]"
+		if m.Target == cfg.Workdir && (m.Source == "." || m.Source == cfg.WorkdirSource) {
+			field = "workdir"
+		}
+		source := m.Source
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(projectDir, source)
+		}
+		source = filepath.Clean(source)
+		switch {
+		case source == "/":
+			add("mount-root", LintError, field, "mounts the host root filesystem")
+		case home != "" && source == filepath.Clean(home):
+			add("mount-home", LintWarning, field, "mounts $HOME (%s); mount only the directories the agent needs", home)
+		}
+		if targets[m.Target] {
+			add("duplicate-mount", LintWarning, field, "target %s is mounted more than once", m.Target)
+		}
+		targets[m.Target] = true
+		if m.Propagation != "" && lintGOOS != "linux" {
+			add("mount-option-ignored", LintInfo, field, "propagation %q only applies on Linux hosts", m.Propagation)
+		}
+		if m.Consistency != "" && lintGOOS != "darwin" {
+			add("mount-option-ignored", LintInfo, field, "consistency %q only applies on macOS hosts", m.Consistency)
+		}
+		if !m.Sync.IsZero() && !m.HasExcludes() && lintGOOS == "linux" {
+			add("mount-option-ignored", LintInfo, field, "sync options only apply to Mutagen-synced mounts; on Linux a mount is only synced when it has excludes")
+		}
+		if !m.Readonly && lintCaseInsensitive(env.Fs, source) {
+			add("case-insensitive-mount", LintWarning, field, "%s is on a case-insensitive filesystem; files created in the container whose names differ only in case (Makefile, makefile) collide on the host", source)
+		}
+	}
+
+	if slices.Contains(cfg.Network.LANAccess, "*") {
+		add("lan-access-all", LintWarning, "network.lan-access", `"*" allows the container to reach every host on the local network`)
+	}
+
+	switch cfg.NestedContainers {
+	case NestedContainersSocket:
+		add("nested-socket", LintError, "nested_containers", "the host runtime socket gives the sandbox root-equivalent control of the host; containers it starts bypass the sandbox firewall")
+	case NestedContainersDind:
+		add("nested-dind", LintWarning, "nested_containers", "the Docker daemon sidecar runs --privileged")
+	}
+
+	for _, c := range cfg.Caps.Add {
+		if slices.Contains(dangerousCaps, normalizeCapName(c)) {
+			add("dangerous-cap", LintWarning, "caps.add", "%s lets the container escape or inspect beyond its isolation", c)
+		}
+	}
+
+	for _, name := range sortedKeys(cfg.Envs) {
+		v := cfg.Envs[name]
+		if v.Value == "" || v.IsInterpolated() {
+			continue
+		}
+		if secretValuePattern.MatchString(v.Value) || secretNamePattern.MatchString(name) {
+			add("plaintext-secret", LintWarning, "envs."+name, "value looks like a plaintext secret; reference a host variable with ${%s} instead", name)
+		}
+	}
+
+	findings = append(findings, lintFileRefs(env, path, expandEnv, map[string]bool{})...)
+
+	slices.SortStableFunc(findings, func(a, b LintFinding) int {
+		return b.Severity.Rank() - a.Severity.Rank()
+	})
+	return findings
+}
+
+// caseInsensitiveDir reports whether dir, or its nearest existing parent
+// with a letter in its name, is also found under its case-swapped name as
+// the same file: the default on macOS and on Windows drives under WSL.
+func caseInsensitiveDir(fs afero.Fs, dir string) bool {
+	for p := filepath.Clean(dir); p != filepath.Dir(p); p = filepath.Dir(p) {
+		base := filepath.Base(p)
+		swapped := swapCase(base)
+		if swapped == base {
+			continue
+		}
+		info, err := fs.Stat(p)
+		if err != nil {
+			continue
+		}
+		other, err := fs.Stat(filepath.Join(filepath.Dir(p), swapped))
+		return err == nil && os.SameFile(info, other)
+	}
+	return false
+}
+
+// swapCase swaps the case of every letter in s.
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// lintFileRefs reports include and extends glob patterns that match no file,
+// following the references recursively.
+func lintFileRefs(env *util.Env, path string, expandEnv func(string) (string, error), visited map[string]bool) []LintFinding {
+	absPath, err := filepath.Abs(path)
+	if err != nil || visited[absPath] {
+		return nil
+	}
+	visited[absPath] = true
+
+	raw, err := readRawConfig(env, path, loadOptions{})
+	if err != nil {
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, refs := range []struct {
+		field string
+		paths []string
+	}{{"extends", raw.Extends}, {"includes", raw.Includes.Paths()}} {
+		for _, p := range refs.paths {
+			files, err := NewConfigFileRef(absPath, p).Expand(expandEnv, env.Fs)
+			if err != nil {
+				continue // LoadConfig reports missing literal paths
+			}
+			if len(files) == 0 {
+				findings = append(findings, LintFinding{
+					Rule:     "unreachable-include",
+					Severity: LintInfo,
+					Field:    refs.field,
+					Message:  fmt.Sprintf("%q in %s matches no files", p, filepath.Base(path)),
+				})
+			}
+			for _, f := range files {
+				findings = append(findings, lintFileRefs(env, f, expandEnv, visited)...)
+			}
+		}
+	}
+	return findings
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// FormatLintFinding renders a finding as a single human-readable line.
+func FormatLintFinding(f LintFinding) string {
+	return fmt.Sprintf("%-7s %s: %s (%s)", strings.ToUpper(string(f.Severity)), f.Field, f.Message, f.Rule)
+}
@@ -0,0 +1,142 @@
+// migrate.go implements schema versioning for .alca.toml and state.json.
+// Files declare schema_version; older files are upgraded on load by applying
+// registered migrations in order. A missing schema_version means version 1.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+)
+
+const (
+	// SchemaVersionKey is the document key holding the schema version.
+	SchemaVersionKey = "schema_version"
+	// CurrentSchemaVersion is the .alca.toml schema version this binary writes.
+	CurrentSchemaVersion = 1
+)
+
+// Migration upgrades a decoded document from schema version From to From+1.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]any) error
+}
+
+// configMigrations upgrades .alca.toml documents. Append an entry whenever
+// CurrentSchemaVersion is bumped; entries must be ordered by From.
+var configMigrations []Migration
+
+// SchemaVersion returns the schema_version of a decoded document.
+// A missing key means version 1, the schema before versioning was introduced.
+func SchemaVersion(doc map[string]any) (int, error) {
+	v, ok := doc[SchemaVersionKey]
+	if !ok {
+		return 1, nil
+	}
+
+	var n int
+	switch v := v.(type) {
+	case int64:
+		n = int(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%s %v: %w", SchemaVersionKey, v, ErrInvalidSchemaVersion)
+		}
+		n = int(v)
+	default:
+		return 0, fmt.Errorf("%s %v: must be an integer: %w", SchemaVersionKey, v, ErrInvalidSchemaVersion)
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("%s %d: must be at least 1: %w", SchemaVersionKey, n, ErrInvalidSchemaVersion)
+	}
+	return n, nil
+}
+
+// ApplyMigrations upgrades doc in place to version current and returns the
+// migrations that ran. Documents from a newer version than current fail with
+// ErrSchemaTooNew, since an older binary cannot know what changed.
+func ApplyMigrations(doc map[string]any, current int, migrations []Migration) ([]Migration, error) {
+	version, err := SchemaVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version > current {
+		return nil, fmt.Errorf("%s %d, this alca supports up to %d; upgrade alca: %w", SchemaVersionKey, version, current, ErrSchemaTooNew)
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if m.From != version {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return nil, fmt.Errorf("migrate schema %d -> %d (%s): %w", m.From, m.From+1, m.Description, err)
+		}
+		applied = append(applied, m)
+		version++
+	}
+	if version != current {
+		return nil, fmt.Errorf("%s %d: no migration path to %d: %w", SchemaVersionKey, version, current, ErrInvalidSchemaVersion)
+	}
+
+	if len(applied) > 0 {
+		doc[SchemaVersionKey] = current
+	}
+	return applied, nil
+}
+
+// migrateConfigData upgrades raw .alca.toml content to CurrentSchemaVersion.
+// When no migration applies, data is returned unchanged so that comments and
+// formatting survive.
+func migrateConfigData(data []byte) ([]byte, []Migration, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	applied, err := ApplyMigrations(doc, CurrentSchemaVersion, configMigrations)
+	if err != nil || len(applied) == 0 {
+		return data, nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, nil, fmt.Errorf("encode migrated config: %w", err)
+	}
+	return buf.Bytes(), applied, nil
+}
+
+// MigrateConfigFile upgrades the config file at path and, when write is true
+// and migrations applied, rewrites it. Rewriting re-encodes the document, so
+// comments are not preserved. Encrypted configs are never rewritten.
+func MigrateConfigFile(fs afero.Fs, path string, write bool, opts ...LoadOption) ([]Migration, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	if IsEncryptedConfigPath(path) {
+		if data, err = decryptConfig(fs, path, data, newLoadOptions(opts)); err != nil {
+			return nil, err
+		}
+	}
+
+	migrated, applied, err := migrateConfigData(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if !write || len(applied) == 0 {
+		return applied, nil
+	}
+	if IsEncryptedConfigPath(path) {
+		return applied, fmt.Errorf("%s: encrypted configs must be migrated by hand", path)
+	}
+
+	if err := afero.WriteFile(fs, path, migrated, 0644); err != nil {
+		return applied, fmt.Errorf("write %s: %w", path, err)
+	}
+	return applied, nil
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     map[string]any
+		want    int
+		wantErr error
+	}{
+		{name: "missing means 1", doc: map[string]any{}, want: 1},
+		{name: "toml integer", doc: map[string]any{"schema_version": int64(3)}, want: 3},
+		{name: "json number", doc: map[string]any{"schema_version": float64(2)}, want: 2},
+		{name: "fractional", doc: map[string]any{"schema_version": 1.5}, wantErr: ErrInvalidSchemaVersion},
+		{name: "string", doc: map[string]any{"schema_version": "1"}, wantErr: ErrInvalidSchemaVersion},
+		{name: "zero", doc: map[string]any{"schema_version": int64(0)}, wantErr: ErrInvalidSchemaVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SchemaVersion(tt.doc)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SchemaVersion() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SchemaVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMigrations(t *testing.T) {
+	migrations := []Migration{
+		{From: 1, Description: "rename foo to bar", Apply: func(doc map[string]any) error {
+			doc["bar"] = doc["foo"]
+			delete(doc, "foo")
+			return nil
+		}},
+		{From: 2, Description: "wrap bar", Apply: func(doc map[string]any) error {
+			doc["bar"] = []any{doc["bar"]}
+			return nil
+		}},
+	}
+
+	t.Run("upgrades through every step", func(t *testing.T) {
+		doc := map[string]any{"foo": "x"}
+		applied, err := ApplyMigrations(doc, 3, migrations)
+		if err != nil {
+			t.Fatalf("ApplyMigrations() error = %v", err)
+		}
+		if len(applied) != 2 {
+			t.Fatalf("applied %d migrations, want 2", len(applied))
+		}
+		if bar, ok := doc["bar"].([]any); !ok || bar[0] != "x" {
+			t.Errorf("bar = %v, want [x]", doc["bar"])
+		}
+		if doc["schema_version"] != 3 {
+			t.Errorf("schema_version = %v, want 3", doc["schema_version"])
+		}
+	})
+
+	t.Run("starts from declared version", func(t *testing.T) {
+		doc := map[string]any{"schema_version": int64(2), "bar": "x"}
+		applied, err := ApplyMigrations(doc, 3, migrations)
+		if err != nil {
+			t.Fatalf("ApplyMigrations() error = %v", err)
+		}
+		if len(applied) != 1 || applied[0].From != 2 {
+			t.Errorf("applied = %v, want only the 2 -> 3 step", applied)
+		}
+	})
+
+	t.Run("current document is untouched", func(t *testing.T) {
+		doc := map[string]any{"schema_version": int64(3)}
+		applied, err := ApplyMigrations(doc, 3, migrations)
+		if err != nil || len(applied) != 0 {
+			t.Errorf("ApplyMigrations() = %v, %v; want no steps", applied, err)
+		}
+	})
+
+	t.Run("newer document is rejected", func(t *testing.T) {
+		doc := map[string]any{"schema_version": int64(4)}
+		_, err := ApplyMigrations(doc, 3, migrations)
+		if !errors.Is(err, ErrSchemaTooNew) {
+			t.Errorf("ApplyMigrations() error = %v, want ErrSchemaTooNew", err)
+		}
+	})
+
+	t.Run("gap in registry is an error", func(t *testing.T) {
+		doc := map[string]any{}
+		_, err := ApplyMigrations(doc, 3, migrations[:1])
+		if !errors.Is(err, ErrInvalidSchemaVersion) {
+			t.Errorf("ApplyMigrations() error = %v, want ErrInvalidSchemaVersion", err)
+		}
+	})
+
+	t.Run("failing step reports its description", func(t *testing.T) {
+		failing := []Migration{{From: 1, Description: "boom", Apply: func(map[string]any) error {
+			return errors.New("bad input")
+		}}}
+		_, err := ApplyMigrations(map[string]any{}, 2, failing)
+		if err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Errorf("ApplyMigrations() error = %v, want mention of boom", err)
+		}
+	})
+}
+
+func TestLoadConfigSchemaVersion(t *testing.T) {
+	env := &util.Env{Fs: afero.NewMemMapFs()}
+
+	t.Run("current version loads", func(t *testing.T) {
+		content := "schema_version = 1\nimage = \"alpine\"\n"
+		if err := afero.WriteFile(env.Fs, "/p/.alca.toml", []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(env, "/p/.alca.toml", StrictExpandEnv); err != nil {
+			t.Errorf("LoadConfig() error = %v", err)
+		}
+	})
+
+	t.Run("newer version is rejected", func(t *testing.T) {
+		content := "schema_version = 99\nimage = \"alpine\"\n"
+		if err := afero.WriteFile(env.Fs, "/p/.alca.toml", []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(env, "/p/.alca.toml", StrictExpandEnv)
+		if !errors.Is(err, ErrSchemaTooNew) {
+			t.Errorf("LoadConfig() error = %v, want ErrSchemaTooNew", err)
+		}
+	})
+}
+
+func TestGenerateConfigStampsSchemaVersion(t *testing.T) {
+	content, err := generateConfigContent(TemplateConfig{Config: Config{Image: "alpine"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "schema_version = 1\n") {
+		t.Errorf("generated config missing schema_version:\n%s", content)
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	content := "# keep me\nimage = \"alpine\"\n"
+	if err := afero.WriteFile(fs, "/p/.alca.toml", []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := MigrateConfigFile(fs, "/p/.alca.toml", true)
+	if err != nil {
+		t.Fatalf("MigrateConfigFile() error = %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("applied %d migrations, want 0", len(applied))
+	}
+	data, _ := afero.ReadFile(fs, "/p/.alca.toml")
+	if string(data) != content {
+		t.Errorf("up-to-date config was rewritten:\n%s", data)
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ProjectConfigFilename is the name of a project's config file. A project
+// config in a subdirectory of another project (a monorepo subproject)
+// that sets inherit = true extends the nearest one above it.
+const ProjectConfigFilename = ".alca.toml"
+
+// parentConfigPath returns the parent project config the project config at
+// absPath (with contents raw) inherits, or "" when it does not set
+// inherit = true, is not named ProjectConfigFilename, or no directory above
+// it has one.
+func parentConfigPath(fs afero.Fs, absPath string, raw RawConfig) string {
+	if !raw.Inherit || filepath.Base(absPath) != ProjectConfigFilename {
+		return ""
+	}
+	dir := filepath.Dir(absPath)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+		path := filepath.Join(dir, ProjectConfigFilename)
+		if info, err := fs.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+}
+
+// MonorepoRoot returns the directory of the topmost project config the
+// project in projectDir inherits from through parent project configs, or
+// "" when it inherits from none (it is not a subproject). Unreadable
+// configs end the chain.
+func MonorepoRoot(fs afero.Fs, projectDir string) string {
+	root := ""
+	path := filepath.Join(projectDir, ProjectConfigFilename)
+	for {
+		raw, err := readRawConfig(&util.Env{Fs: fs}, path, loadOptions{})
+		if err != nil {
+			return root
+		}
+		parent := parentConfigPath(fs, path, raw)
+		if parent == "" {
+			return root
+		}
+		root, path = filepath.Dir(parent), parent
+	}
+}
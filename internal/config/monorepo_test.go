@@ -0,0 +1,111 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func writeTestConfigs(t *testing.T, fs afero.Fs, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		if err := afero.WriteFile(fs, path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadWithIncludes_SubprojectInheritsRoot(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	writeTestConfigs(t, memFs, map[string]string{
+		"/repo/.alca.toml": `
+image = "base:latest"
+workdir = "/repo"
+[envs]
+SHARED = "1"
+`,
+		"/repo/services/api/.alca.toml": `
+inherit = true
+workdir = "/api"
+[envs]
+SERVICE = "api"
+`,
+	})
+
+	cfg, err := LoadWithIncludes(env, "/repo/services/api/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadWithIncludes failed: %v", err)
+	}
+	if cfg.Image != "base:latest" || cfg.Workdir != "/api" {
+		t.Errorf("image, workdir = %q, %q; want base:latest, /api", cfg.Image, cfg.Workdir)
+	}
+	if cfg.Envs["SHARED"].Value != "1" || cfg.Envs["SERVICE"].Value != "api" {
+		t.Errorf("envs not merged: %+v", cfg.Envs)
+	}
+}
+
+func TestLoadWithIncludes_SubprojectBelowExtends(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	writeTestConfigs(t, memFs, map[string]string{
+		"/repo/.alca.toml":           `image = "root:latest"` + "\nworkdir = \"/root\"\nuser = \"dev\"\n",
+		"/repo/app/.alca.base.toml":  `image = "base:latest"` + "\nworkdir = \"/base\"\n",
+		"/repo/app/.alca.toml":       "inherit = true\n" + `extends = [".alca.base.toml"]` + "\n",
+		"/repo/lib/.alca.toml":       "inherit = true\n" + `extends = ["../.alca.toml"]` + "\nworkdir = \"/lib\"\n",
+		"/repo/tool/.alca.toml":      "image = \"tool:latest\"\n",
+		"/repo/tool/sub/.alca.toml":  "inherit = true\nworkdir = \"/sub\"\n",
+		"/repo/nested/a/.alca.toml":  "inherit = true\nworkdir = \"/a\"\n",
+		"/repo/nested/.alca.toml":    "inherit = true\nuser = \"nested\"\n",
+		"/repo/other/.alca.dev.toml": "inherit = true\nimage = \"ignored:latest\"\n",
+	})
+
+	tests := []struct {
+		name                string
+		path                string
+		image, workdir, usr string
+	}{
+		{"explicit extends win over the parent", "/repo/app/.alca.toml", "base:latest", "/base", "dev"},
+		{"explicitly extended parent is loaded once", "/repo/lib/.alca.toml", "root:latest", "/lib", "dev"},
+		{"no inheritance without inherit = true", "/repo/tool/.alca.toml", "tool:latest", "", ""},
+		{"chain stops where inherit is unset", "/repo/tool/sub/.alca.toml", "tool:latest", "/sub", ""},
+		{"chain through every ancestor", "/repo/nested/a/.alca.toml", "root:latest", "/a", "nested"},
+		{"only project configs inherit", "/repo/other/.alca.dev.toml", "ignored:latest", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadWithIncludes(env, tt.path, noExpandEnv)
+			if err != nil {
+				t.Fatalf("LoadWithIncludes failed: %v", err)
+			}
+			if cfg.Image != tt.image || cfg.Workdir != tt.workdir || cfg.User != tt.usr {
+				t.Errorf("image, workdir, user = %q, %q, %q; want %q, %q, %q", cfg.Image, cfg.Workdir, cfg.User, tt.image, tt.workdir, tt.usr)
+			}
+		})
+	}
+}
+
+func TestMonorepoRoot(t *testing.T) {
+	memFs := afero.NewMemMapFs()
+	writeTestConfigs(t, memFs, map[string]string{
+		"/repo/.alca.toml":          "image = \"a\"\n",
+		"/repo/api/.alca.toml":      "inherit = true\n",
+		"/repo/api/v2/.alca.toml":   "inherit = true\n",
+		"/repo/tool/.alca.toml":     "",
+		"/repo/tool/sub/.alca.toml": "inherit = true\n",
+		"/solo/.alca.toml":          "image = \"a\"\n",
+	})
+
+	tests := map[string]string{
+		"/repo":          "",
+		"/repo/api":      "/repo",
+		"/repo/api/v2":   "/repo",
+		"/repo/tool":     "",
+		"/repo/tool/sub": "/repo/tool",
+		"/solo":          "",
+		"/gone":          "",
+	}
+	for dir, want := range tests {
+		if got := MonorepoRoot(memFs, dir); got != want {
+			t.Errorf("MonorepoRoot(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
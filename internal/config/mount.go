@@ -5,6 +5,8 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/invopop/jsonschema"
@@ -17,8 +19,79 @@ type MountConfig struct {
 	Target   string   `toml:"target" json:"target" jsonschema:"description=Container path (required)"`
 	Readonly bool     `toml:"readonly,omitempty" json:"readonly,omitempty" jsonschema:"description=Read-only mount (default: false)"`
 	Exclude  []string `toml:"exclude,omitempty" json:"exclude,omitempty" jsonschema:"description=Glob patterns to exclude (optional)"`
+	// ExcludeFrom names ignore files (relative to Source) whose patterns are
+	// added to Exclude when the config is loaded.
+	ExcludeFrom []string `toml:"exclude_from,omitempty" json:"exclude_from,omitempty" jsonschema:"description=Ignore files (e.g. .gitignore or .alcaignore) whose patterns are added to exclude (optional)"`
+	// Propagation controls whether mounts made under the target on either
+	// side become visible on the other (Linux only).
+	Propagation string `toml:"propagation,omitempty" json:"propagation,omitempty" jsonschema:"description=Bind propagation: private/rprivate/shared/rshared/slave/rslave (Linux only)"`
+	// Consistency is a file-sharing performance hint (macOS only).
+	Consistency string `toml:"consistency,omitempty" json:"consistency,omitempty" jsonschema:"description=File-sharing consistency hint: consistent/cached/delegated (macOS only)"`
+	// Sync tunes the Mutagen session when the mount is synced.
+	Sync MountSync `toml:"sync,omitempty" json:"sync,omitempty" jsonschema:"description=Mutagen session limits and symlink handling (synced mounts only)"`
 }
 
+// MountSync tunes the Mutagen session of a synced mount, so generated
+// artifacts cannot blow up the sync. Zero values keep Mutagen's defaults.
+type MountSync struct {
+	MaxEntryCount      int    `toml:"max_entry_count,omitempty" json:"max_entry_count,omitempty"`
+	MaxStagingFileSize string `toml:"max_staging_file_size,omitempty" json:"max_staging_file_size,omitempty"`
+	SymlinkMode        string `toml:"symlink_mode,omitempty" json:"symlink_mode,omitempty"`
+	// PermissionsMode and the default modes control how permissions cross
+	// hosts that do not keep them, such as Windows drives under WSL.
+	PermissionsMode      string `toml:"permissions_mode,omitempty" json:"permissions_mode,omitempty"`
+	DefaultFileMode      string `toml:"default_file_mode,omitempty" json:"default_file_mode,omitempty"`
+	DefaultDirectoryMode string `toml:"default_directory_mode,omitempty" json:"default_directory_mode,omitempty"`
+}
+
+// SymlinkModes lists the valid Mutagen symlink modes.
+var SymlinkModes = []string{"ignore", "portable", "posix-raw"}
+
+// PermissionsModes lists the valid Mutagen permissions modes.
+var PermissionsModes = []string{"portable", "manual"}
+
+// fileModePattern matches octal permission modes such as 644 or 0755.
+var fileModePattern = regexp.MustCompile(`^0?[0-7]{3}$`)
+
+// stagingFileSizePattern matches sizes Mutagen accepts, e.g. 500, 100MB, 1.5 GiB.
+var stagingFileSizePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)? ?([kKmMgGtT]i?)?[bB]?$`)
+
+// IsZero reports whether no sync option is set.
+func (s MountSync) IsZero() bool {
+	return s == MountSync{}
+}
+
+// ValidateMountSync checks sync options against what Mutagen accepts.
+func ValidateMountSync(s MountSync) error {
+	if s.MaxEntryCount < 0 {
+		return fmt.Errorf("max_entry_count %d: must not be negative: %w", s.MaxEntryCount, ErrInvalidMountOption)
+	}
+	if s.MaxStagingFileSize != "" && !stagingFileSizePattern.MatchString(s.MaxStagingFileSize) {
+		return fmt.Errorf("max_staging_file_size %q: expected a size such as 100MB: %w", s.MaxStagingFileSize, ErrInvalidMountOption)
+	}
+	if s.SymlinkMode != "" && !slices.Contains(SymlinkModes, s.SymlinkMode) {
+		return fmt.Errorf("symlink_mode %q: expected one of %s: %w", s.SymlinkMode, strings.Join(SymlinkModes, ", "), ErrInvalidMountOption)
+	}
+	if s.PermissionsMode != "" && !slices.Contains(PermissionsModes, s.PermissionsMode) {
+		return fmt.Errorf("permissions_mode %q: expected one of %s: %w", s.PermissionsMode, strings.Join(PermissionsModes, ", "), ErrInvalidMountOption)
+	}
+	for _, m := range []struct{ key, mode string }{
+		{"default_file_mode", s.DefaultFileMode},
+		{"default_directory_mode", s.DefaultDirectoryMode},
+	} {
+		if m.mode != "" && !fileModePattern.MatchString(m.mode) {
+			return fmt.Errorf("%s %q: expected an octal mode such as 0644: %w", m.key, m.mode, ErrInvalidMountOption)
+		}
+	}
+	return nil
+}
+
+// MountPropagations lists the valid mount propagation modes.
+var MountPropagations = []string{"private", "rprivate", "shared", "rshared", "slave", "rslave"}
+
+// MountConsistencies lists the valid mount consistency hints.
+var MountConsistencies = []string{"consistent", "cached", "delegated"}
+
 // UnmarshalJSON supports both string ("source:target[:ro]") and object formats.
 // This provides backward compatibility with state files saved before MountConfig
 // was changed from string to struct.
@@ -75,20 +148,24 @@ func ParseMount(s string) (MountConfig, error) {
 }
 
 // String returns the mount in docker -v format.
-// Returns empty string if the mount has excludes (cannot be represented in string format).
+// Returns empty string if the mount has excludes, exclude_from, propagation,
+// consistency or sync options (cannot be represented in string format).
 // Use CanBeSimpleString() to check before calling.
 func (m MountConfig) String() string {
 	// Mirror type ensures all MountConfig fields are explicitly handled (AGD-015).
 	type fields struct {
-		Source   string
-		Target   string
-		Readonly bool
-		Exclude  []string
+		Source      string
+		Target      string
+		Readonly    bool
+		Exclude     []string
+		ExcludeFrom []string
+		Propagation string
+		Consistency string
+		Sync        MountSync
 	}
 	_ = fields(m)
 
-	// Mounts with excludes cannot be represented in string format
-	if m.HasExcludes() {
+	if !m.CanBeSimpleString() {
 		return ""
 	}
 
@@ -100,9 +177,10 @@ func (m MountConfig) String() string {
 }
 
 // CanBeSimpleString returns true if the mount can be represented as a simple string.
-// Returns false if the mount has excludes which require the extended object format.
+// Returns false if the mount has excludes, exclude_from, propagation,
+// consistency or sync options, which require the extended object format.
 func (m MountConfig) CanBeSimpleString() bool {
-	return !m.HasExcludes()
+	return !m.HasExcludes() && len(m.ExcludeFrom) == 0 && m.Propagation == "" && m.Consistency == "" && m.Sync.IsZero()
 }
 
 // HasExcludes returns true if the mount has exclude patterns.
@@ -114,26 +192,23 @@ func (m MountConfig) HasExcludes() bool {
 func (m MountConfig) Equals(other MountConfig) bool {
 	// Mirror type ensures all MountConfig fields are explicitly handled (AGD-015).
 	type fields struct {
-		Source   string
-		Target   string
-		Readonly bool
-		Exclude  []string
+		Source      string
+		Target      string
+		Readonly    bool
+		Exclude     []string
+		ExcludeFrom []string
+		Propagation string
+		Consistency string
+		Sync        MountSync
 	}
 	_ = fields(m)
 	_ = fields(other)
 
-	if m.Source != other.Source || m.Target != other.Target || m.Readonly != other.Readonly {
-		return false
-	}
-	if len(m.Exclude) != len(other.Exclude) {
+	if m.Source != other.Source || m.Target != other.Target || m.Readonly != other.Readonly ||
+		m.Propagation != other.Propagation || m.Consistency != other.Consistency || m.Sync != other.Sync {
 		return false
 	}
-	for i, e := range m.Exclude {
-		if e != other.Exclude[i] {
-			return false
-		}
-	}
-	return true
+	return StringSlicesEqual(m.Exclude, other.Exclude) && StringSlicesEqual(m.ExcludeFrom, other.ExcludeFrom)
 }
 
 // MountsEqual compares two slices of MountConfig for equality.
@@ -164,6 +239,22 @@ func (RawMountSlice) JSONSchema() *jsonschema.Schema {
 		Items:       &jsonschema.Schema{Type: "string"},
 		Description: "Glob patterns to exclude (optional)",
 	})
+	mountProps.Set("exclude_from", &jsonschema.Schema{
+		Type:        "array",
+		Items:       &jsonschema.Schema{Type: "string"},
+		Description: "Ignore files (e.g. .gitignore or .alcaignore) whose patterns are added to exclude (optional)",
+	})
+	mountProps.Set("propagation", &jsonschema.Schema{
+		Type:        "string",
+		Enum:        enumOf(MountPropagations),
+		Description: "Bind propagation (Linux only)",
+	})
+	mountProps.Set("consistency", &jsonschema.Schema{
+		Type:        "string",
+		Enum:        enumOf(MountConsistencies),
+		Description: "File-sharing consistency hint (macOS only)",
+	})
+	mountProps.Set("sync", mountSyncSchema())
 
 	return &jsonschema.Schema{
 		Type: "array",
@@ -175,7 +266,7 @@ func (RawMountSlice) JSONSchema() *jsonschema.Schema {
 					Properties:           mountProps,
 					Required:             []string{"source", "target"},
 					AdditionalProperties: jsonschema.FalseSchema,
-					Description:          "Extended format with excludes",
+					Description:          "Extended format with excludes and mount options",
 				},
 			},
 		},
@@ -183,6 +274,37 @@ func (RawMountSlice) JSONSchema() *jsonschema.Schema {
 	}
 }
 
+// JSONSchema implements jsonschema.JSONSchemer to generate correct schema.
+func (MountSync) JSONSchema() *jsonschema.Schema {
+	return mountSyncSchema()
+}
+
+// mountSyncSchema is the schema of a MountSync table.
+func mountSyncSchema() *jsonschema.Schema {
+	props := jsonschema.NewProperties()
+	props.Set("max_entry_count", &jsonschema.Schema{Type: "integer", Minimum: "0", Description: "Maximum number of files and directories to sync (Mutagen --max-entry-count)"})
+	props.Set("max_staging_file_size", &jsonschema.Schema{Type: "string", Pattern: stagingFileSizePattern.String(), Description: "Largest file to sync, e.g. 100MB (Mutagen --max-staging-file-size)"})
+	props.Set("symlink_mode", &jsonschema.Schema{Type: "string", Enum: enumOf(SymlinkModes), Description: "How symlinks are synced (Mutagen --symlink-mode)"})
+	props.Set("permissions_mode", &jsonschema.Schema{Type: "string", Enum: enumOf(PermissionsModes), Description: "Whether executable bits are synced (portable) or every file gets the default modes (manual) (Mutagen --permissions-mode)"})
+	props.Set("default_file_mode", &jsonschema.Schema{Type: "string", Pattern: fileModePattern.String(), Description: "Octal mode of files created by the sync, e.g. 0644 (Mutagen --default-file-mode)"})
+	props.Set("default_directory_mode", &jsonschema.Schema{Type: "string", Pattern: fileModePattern.String(), Description: "Octal mode of directories created by the sync, e.g. 0755 (Mutagen --default-directory-mode)"})
+	return &jsonschema.Schema{
+		Type:                 "object",
+		Properties:           props,
+		AdditionalProperties: jsonschema.FalseSchema,
+		Description:          "Mutagen session limits and symlink and permissions handling (synced mounts only)",
+	}
+}
+
+// enumOf converts values to a JSON schema enum.
+func enumOf(values []string) []any {
+	enum := make([]any, len(values))
+	for i, v := range values {
+		enum[i] = v
+	}
+	return enum
+}
+
 // parseMounts converts raw mount values to MountConfig slice.
 // Accepts both string format ("source:target[:ro]") and object format.
 // expandEnv expands ${VAR} references in mount source paths only (not target).
@@ -224,7 +346,8 @@ func parseMountValue(val any, expandEnv func(string) (string, error)) (MountConf
 	}
 }
 
-// parseMountObject parses a mount object with source, target, readonly, exclude fields.
+// parseMountObject parses a mount object with source, target, readonly,
+// exclude, exclude_from, propagation, consistency and sync fields.
 // expandEnv expands ${VAR} references in source paths only (not target).
 func parseMountObject(m map[string]any, expandEnv func(string) (string, error)) (MountConfig, error) {
 	var mc MountConfig
@@ -259,5 +382,85 @@ func parseMountObject(m map[string]any, expandEnv func(string) (string, error))
 		}
 	}
 
+	if excludeFrom, ok := m["exclude_from"].([]any); ok {
+		for i, e := range excludeFrom {
+			s, ok := e.(string)
+			if !ok {
+				return MountConfig{}, fmt.Errorf("exclude_from[%d]: expected string, got %T", i, e)
+			}
+			mc.ExcludeFrom = append(mc.ExcludeFrom, s)
+		}
+	}
+
+	if propagation, ok := m["propagation"].(string); ok {
+		if !slices.Contains(MountPropagations, propagation) {
+			return MountConfig{}, fmt.Errorf("invalid propagation %q: expected one of %s: %w", propagation, strings.Join(MountPropagations, ", "), ErrInvalidMountOption)
+		}
+		mc.Propagation = propagation
+	}
+
+	if consistency, ok := m["consistency"].(string); ok {
+		if !slices.Contains(MountConsistencies, consistency) {
+			return MountConfig{}, fmt.Errorf("invalid consistency %q: expected one of %s: %w", consistency, strings.Join(MountConsistencies, ", "), ErrInvalidMountOption)
+		}
+		mc.Consistency = consistency
+	}
+
+	if sync, ok := m["sync"].(map[string]any); ok {
+		s, err := parseMountSync(sync)
+		if err != nil {
+			return MountConfig{}, fmt.Errorf("sync: %w", err)
+		}
+		mc.Sync = s
+	}
+
 	return mc, nil
 }
+
+// parseMountSync parses and validates a mount's sync table.
+func parseMountSync(m map[string]any) (MountSync, error) {
+	var s MountSync
+	for key, val := range m {
+		switch key {
+		case "max_entry_count":
+			n, ok := val.(int64)
+			if !ok {
+				return MountSync{}, fmt.Errorf("max_entry_count: expected integer, got %T: %w", val, ErrInvalidMountOption)
+			}
+			s.MaxEntryCount = int(n)
+		case "max_staging_file_size":
+			size, ok := val.(string)
+			if !ok {
+				return MountSync{}, fmt.Errorf("max_staging_file_size: expected string, got %T: %w", val, ErrInvalidMountOption)
+			}
+			s.MaxStagingFileSize = size
+		case "symlink_mode":
+			mode, ok := val.(string)
+			if !ok {
+				return MountSync{}, fmt.Errorf("symlink_mode: expected string, got %T: %w", val, ErrInvalidMountOption)
+			}
+			s.SymlinkMode = mode
+		case "permissions_mode":
+			mode, ok := val.(string)
+			if !ok {
+				return MountSync{}, fmt.Errorf("permissions_mode: expected string, got %T: %w", val, ErrInvalidMountOption)
+			}
+			s.PermissionsMode = mode
+		case "default_file_mode":
+			mode, ok := val.(string)
+			if !ok {
+				return MountSync{}, fmt.Errorf("default_file_mode: expected a quoted octal string, got %T: %w", val, ErrInvalidMountOption)
+			}
+			s.DefaultFileMode = mode
+		case "default_directory_mode":
+			mode, ok := val.(string)
+			if !ok {
+				return MountSync{}, fmt.Errorf("default_directory_mode: expected a quoted octal string, got %T: %w", val, ErrInvalidMountOption)
+			}
+			s.DefaultDirectoryMode = mode
+		default:
+			return MountSync{}, fmt.Errorf("unknown key %q: %w", key, ErrInvalidMountOption)
+		}
+	}
+	return s, ValidateMountSync(s)
+}
@@ -169,6 +169,18 @@ func TestMountConfigEquals(t *testing.T) {
 			b:    MountConfig{Source: "/a", Target: "/b", Exclude: []string{"*.log"}},
 			want: false,
 		},
+		{
+			name: "different propagation",
+			a:    MountConfig{Source: "/a", Target: "/b", Propagation: "rshared"},
+			b:    MountConfig{Source: "/a", Target: "/b"},
+			want: false,
+		},
+		{
+			name: "different consistency",
+			a:    MountConfig{Source: "/a", Target: "/b", Consistency: "cached"},
+			b:    MountConfig{Source: "/a", Target: "/b", Consistency: "delegated"},
+			want: false,
+		},
 		{
 			name: "nil vs empty excludes",
 			a:    MountConfig{Source: "/a", Target: "/b", Exclude: nil},
@@ -571,3 +583,134 @@ mounts = [
 		t.Errorf("expected mount[2] target '/config', got %q", cfg.Mounts[2].Target)
 	}
 }
+
+func TestParseMountObject_Options(t *testing.T) {
+	m, err := parseMountObject(map[string]any{
+		"source":      "/var/lib/containers",
+		"target":      "/var/lib/containers",
+		"propagation": "rshared",
+		"consistency": "cached",
+	}, noExpandEnv)
+	if err != nil {
+		t.Fatalf("parseMountObject failed: %v", err)
+	}
+	if m.Propagation != "rshared" || m.Consistency != "cached" {
+		t.Errorf("got propagation=%q consistency=%q", m.Propagation, m.Consistency)
+	}
+	if m.CanBeSimpleString() {
+		t.Error("mount with options must not be written in string format")
+	}
+	if got := mountConfigToMap(m); got["propagation"] != "rshared" || got["consistency"] != "cached" {
+		t.Errorf("mountConfigToMap() = %v", got)
+	}
+
+	for _, opt := range []map[string]any{
+		{"propagation": "shared-ish"},
+		{"consistency": "fast"},
+	} {
+		raw := map[string]any{"source": "/a", "target": "/b"}
+		for k, v := range opt {
+			raw[k] = v
+		}
+		if _, err := parseMountObject(raw, noExpandEnv); !errors.Is(err, ErrInvalidMountOption) {
+			t.Errorf("parseMountObject(%v) error = %v, want ErrInvalidMountOption", opt, err)
+		}
+	}
+}
+
+func TestParseMountObject_Sync(t *testing.T) {
+	m, err := parseMountObject(map[string]any{
+		"source": ".",
+		"target": "/workspace",
+		"sync": map[string]any{
+			"max_entry_count":       int64(50000),
+			"max_staging_file_size": "100MB",
+			"symlink_mode":          "ignore",
+		},
+	}, noExpandEnv)
+	if err != nil {
+		t.Fatalf("parseMountObject failed: %v", err)
+	}
+	want := MountSync{MaxEntryCount: 50000, MaxStagingFileSize: "100MB", SymlinkMode: "ignore"}
+	if m.Sync != want {
+		t.Errorf("Sync = %+v, want %+v", m.Sync, want)
+	}
+	if m.CanBeSimpleString() {
+		t.Error("mount with sync options must not be written in string format")
+	}
+	if m.Equals(MountConfig{Source: ".", Target: "/workspace"}) {
+		t.Error("mounts with different sync options should differ")
+	}
+
+	for _, sync := range []map[string]any{
+		{"max_entry_count": int64(-1)},
+		{"max_entry_count": "many"},
+		{"max_staging_file_size": "huge"},
+		{"symlink_mode": "follow"},
+		{"permissions_mode": "posix"},
+		{"default_file_mode": "rw-r--r--"},
+		{"default_file_mode": int64(644)},
+		{"default_directory_mode": "0888"},
+		{"max_files": int64(1)},
+	} {
+		raw := map[string]any{"source": "/a", "target": "/b", "sync": sync}
+		if _, err := parseMountObject(raw, noExpandEnv); !errors.Is(err, ErrInvalidMountOption) {
+			t.Errorf("parseMountObject(sync %v) error = %v, want ErrInvalidMountOption", sync, err)
+		}
+	}
+}
+
+func TestParseMountObject_SyncPermissions(t *testing.T) {
+	m, err := parseMountObject(map[string]any{
+		"source": "/mnt/c/src",
+		"target": "/src",
+		"sync": map[string]any{
+			"permissions_mode":       "manual",
+			"default_file_mode":      "0644",
+			"default_directory_mode": "755",
+		},
+	}, noExpandEnv)
+	if err != nil {
+		t.Fatalf("parseMountObject failed: %v", err)
+	}
+	want := MountSync{PermissionsMode: "manual", DefaultFileMode: "0644", DefaultDirectoryMode: "755"}
+	if m.Sync != want {
+		t.Errorf("Sync = %+v, want %+v", m.Sync, want)
+	}
+}
+
+func TestValidateMountSync_Sizes(t *testing.T) {
+	for _, size := range []string{"500", "100MB", "1.5 GiB", "64kb", "2G"} {
+		if err := ValidateMountSync(MountSync{MaxStagingFileSize: size}); err != nil {
+			t.Errorf("ValidateMountSync(%q) = %v, want nil", size, err)
+		}
+	}
+	for _, size := range []string{"MB", "-1MB", "10 XB"} {
+		if err := ValidateMountSync(MountSync{MaxStagingFileSize: size}); err == nil {
+			t.Errorf("ValidateMountSync(%q) = nil, want error", size)
+		}
+	}
+}
+
+func TestLoadConfig_WorkdirSync(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	path := "/project/.alca.toml"
+	content := "image = \"alpine\"\n[workdir_sync]\nmax_staging_file_size = \"50MB\"\n"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	cfg, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Mounts[0].Sync.MaxStagingFileSize != "50MB" {
+		t.Errorf("workdir mount Sync = %+v, want workdir_sync", cfg.Mounts[0].Sync)
+	}
+
+	if err := afero.WriteFile(memFs, path, []byte("image = \"alpine\"\n[workdir_sync]\nsymlink_mode = \"copy\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := LoadConfig(env, path, noExpandEnv); !errors.Is(err, ErrInvalidMountOption) {
+		t.Errorf("LoadConfig error = %v, want ErrInvalidMountOption", err)
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Network isolation levels (network.isolation). Each level stands for a
+// combination of the lower-level network settings, so most projects set one
+// key instead of several.
+const (
+	// IsolationNone gives the container no network at all (--network none).
+	IsolationNone = "none"
+	// IsolationDefault blocks RFC1918 and other private ranges; public
+	// egress is allowed.
+	IsolationDefault = "default"
+	// IsolationStrict drops all egress except the lan-access entries, which
+	// become an allowlist, and the proxy. DNS is filtered the same way:
+	// resolvers must be allowlisted or reached through the proxy.
+	IsolationStrict = "strict"
+	// IsolationCustom applies lan-access and proxy exactly as configured.
+	IsolationCustom = "custom"
+)
+
+// IsolationLevels lists the valid network.isolation values.
+var IsolationLevels = []string{IsolationNone, IsolationDefault, IsolationStrict, IsolationCustom}
+
+// IsolationLevel returns the effective isolation level. An unset level is
+// derived from the low-level settings: custom when lan-access or a proxy is
+// configured, default otherwise — which is how configs without the key
+// always behaved.
+func (n Network) IsolationLevel() string {
+	if n.Isolation != "" {
+		return n.Isolation
+	}
+	if len(n.LANAccess) > 0 || n.Proxy != "" {
+		return IsolationCustom
+	}
+	return IsolationDefault
+}
+
+// normalizeNetworkIsolation resolves network.isolation to its effective
+// level and rejects low-level settings the level contradicts.
+func normalizeNetworkIsolation(net *Network) error {
+	level := net.IsolationLevel()
+	if !slices.Contains(IsolationLevels, level) {
+		return fmt.Errorf("network.isolation %q: must be one of %s: %w", net.Isolation, displayNames(IsolationLevels), ErrInvalidIsolation)
+	}
+
+	switch level {
+	case IsolationNone:
+		for _, s := range []struct {
+			key string
+			set bool
+		}{
+			{"lan-access", len(net.LANAccess) > 0},
+			{"ports", len(net.Ports) > 0},
+			{"peers", len(net.Peers) > 0},
+			{"proxy", net.Proxy != ""},
+			{"shaping", !net.Shaping.IsZero()},
+			{"log_connections", net.LogConnections},
+			{"allow_host_access", net.AllowHostAccess},
+		} {
+			if s.set {
+				return fmt.Errorf("network.isolation %q: network.%s needs a network: %w", level, s.key, ErrInvalidIsolation)
+			}
+		}
+	case IsolationDefault:
+		if len(net.LANAccess) > 0 {
+			return fmt.Errorf("network.isolation %q: lan-access requires isolation \"strict\" or \"custom\": %w", level, ErrInvalidIsolation)
+		}
+	case IsolationStrict:
+		if slices.Contains(net.LANAccess, "*") {
+			return fmt.Errorf("network.isolation %q: lan-access \"*\" would allow everything: %w", level, ErrInvalidIsolation)
+		}
+	}
+
+	net.Isolation = level
+	return nil
+}
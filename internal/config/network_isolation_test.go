@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_NetworkIsolation(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		want    string
+		wantErr bool
+	}{
+		{name: "unset", network: "", want: IsolationDefault},
+		{name: "unset with lan-access", network: `lan-access = ["192.168.1.9:22"]`, want: IsolationCustom},
+		{name: "unset with proxy", network: `proxy = "127.0.0.1:8080"`, want: IsolationCustom},
+		{name: "none", network: `isolation = "none"`, want: IsolationNone},
+		{name: "strict with allowlist", network: "isolation = \"strict\"\nlan-access = [\"203.0.113.10:443\"]", want: IsolationStrict},
+		{name: "custom", network: `isolation = "custom"`, want: IsolationCustom},
+		{name: "unknown", network: `isolation = "paranoid"`, wantErr: true},
+		{name: "none with ports", network: "isolation = \"none\"\nports = [\"8080\"]", wantErr: true},
+		{name: "none with proxy", network: "isolation = \"none\"\nproxy = \"127.0.0.1:8080\"", wantErr: true},
+		{name: "none with host access", network: "isolation = \"none\"\nallow_host_access = true", wantErr: true},
+		{name: "default with host access", network: "allow_host_access = true", want: IsolationDefault},
+		{name: "default with lan-access", network: "isolation = \"default\"\nlan-access = [\"192.168.1.9:22\"]", wantErr: true},
+		{name: "strict with wildcard", network: "isolation = \"strict\"\nlan-access = [\"*\"]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			content := "image = \"alpine\"\n\n[network]\n" + tt.network + "\n"
+			_ = afero.WriteFile(memFs, "/p/.alca.toml", []byte(content), 0644)
+
+			cfg, err := LoadConfig(env, "/p/.alca.toml", noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIsolation) {
+					t.Fatalf("LoadConfig error = %v, want ErrInvalidIsolation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Network.Isolation != tt.want {
+				t.Errorf("Isolation = %q, want %q", cfg.Network.Isolation, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_NetworkIsolationFromPreset(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	withUserNetworkPresets(t, "/home/u/.alcatraz/network-presets.toml")
+	content := `image = "alpine"
+
+[network]
+preset = "locked"
+
+[network.presets.locked]
+isolation = "strict"
+lan-access = ["203.0.113.10:443"]
+`
+	_ = afero.WriteFile(memFs, "/p/.alca.toml", []byte(content), 0644)
+
+	cfg, err := LoadConfig(env, "/p/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Network.Isolation != IsolationStrict {
+		t.Errorf("Isolation = %q, want the preset's %q", cfg.Network.Isolation, IsolationStrict)
+	}
+}
+
+func TestNetworkIsolationLevel_UnsetMatchesNormalized(t *testing.T) {
+	// State written before network.isolation existed has it unset; the
+	// effective level must equal what loading the same config yields.
+	stored := Network{LANAccess: []string{"192.168.1.9:22"}}
+	loaded := stored
+	if err := normalizeNetworkIsolation(&loaded); err != nil {
+		t.Fatalf("normalizeNetworkIsolation failed: %v", err)
+	}
+	if stored.IsolationLevel() != loaded.IsolationLevel() {
+		t.Errorf("IsolationLevel() = %q before and %q after normalizing", stored.IsolationLevel(), loaded.IsolationLevel())
+	}
+}
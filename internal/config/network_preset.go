@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// NetworkPreset is a named bundle of network policy that platform teams can
+// distribute once and projects select with network.preset. Presets are
+// defined under [network.presets.<name>] in any config layer (typically an
+// included or extended shared file) or in the user-level presets file.
+type NetworkPreset struct {
+	LANAccess      []string `toml:"lan-access,omitempty" json:"lan-access,omitempty" jsonschema:"description=LAN access rules granted by the preset"`
+	Proxy          string   `toml:"proxy,omitempty" json:"proxy,omitempty" jsonschema:"description=Transparent proxy address (host:port) used unless the project sets network.proxy"`
+	Shaping        Shaping  `toml:"shaping,omitempty" json:"shaping,omitempty" jsonschema:"description=Bandwidth and latency limits used unless the project sets them"`
+	LogConnections bool     `toml:"log_connections,omitempty" json:"log_connections,omitempty" jsonschema:"description=Log every new outbound connection"`
+	Isolation      string   `toml:"isolation,omitempty" json:"isolation,omitempty" jsonschema:"enum=none,enum=default,enum=strict,enum=custom,description=Isolation level used unless the project sets network.isolation"`
+}
+
+// UserNetworkPresetsFile holds user-level presets as [<name>] tables, in
+// ~/.alcatraz. Presets defined in the project's config layers win.
+const UserNetworkPresetsFile = "network-presets.toml"
+
+// userNetworkPresetsPath returns the user-level presets file path.
+var userNetworkPresetsPath = func() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, util.AlcatrazDir, UserNetworkPresetsFile), nil
+}
+
+// loadUserNetworkPresets reads the user-level presets file; a missing file
+// defines no presets.
+func loadUserNetworkPresets(fs afero.Fs) (map[string]NetworkPreset, error) {
+	path, err := userNetworkPresetsPath()
+	if err != nil {
+		return nil, nil
+	}
+	data, err := afero.ReadFile(fs, path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var presets map[string]NetworkPreset
+	if err := toml.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return presets, nil
+}
+
+// resolveNetworkPreset applies the preset selected by network.preset beneath
+// the project's own network settings: lan-access rules are combined, and
+// proxy, shaping and isolation come from the preset only where the project
+// leaves them unset. The definitions are dropped afterwards; the preset name is kept.
+func resolveNetworkPreset(fs afero.Fs, cfg *Config) error {
+	name := cfg.Network.Preset
+	defined := cfg.Network.Presets
+	cfg.Network.Presets = nil
+	if name == "" {
+		return nil
+	}
+
+	preset, ok := defined[name]
+	if !ok {
+		user, err := loadUserNetworkPresets(fs)
+		if err != nil {
+			return err
+		}
+		if preset, ok = user[name]; !ok {
+			available := slices.Sorted(maps.Keys(defined))
+			for n := range user {
+				if !slices.Contains(available, n) {
+					available = append(available, n)
+				}
+			}
+			slices.Sort(available)
+			return fmt.Errorf("network.preset %q: not defined (available: %s): %w", name, displayNames(available), ErrUnknownNetworkPreset)
+		}
+	}
+
+	net := &cfg.Network
+	net.LANAccess = append(slices.Clone(preset.LANAccess), net.LANAccess...)
+	if net.Proxy == "" {
+		net.Proxy = preset.Proxy
+	}
+	if net.Shaping.Rate == "" {
+		net.Shaping.Rate = preset.Shaping.Rate
+	}
+	if net.Shaping.Delay == "" {
+		net.Shaping.Delay = preset.Shaping.Delay
+	}
+	net.LogConnections = net.LogConnections || preset.LogConnections
+	if net.Isolation == "" {
+		net.Isolation = preset.Isolation
+	}
+	return nil
+}
+
+// displayNames joins names for messages, or "none".
+func displayNames(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
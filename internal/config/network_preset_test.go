@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// withUserNetworkPresets points the user-level presets file at path.
+func withUserNetworkPresets(t *testing.T, path string) {
+	t.Helper()
+	orig := userNetworkPresetsPath
+	userNetworkPresetsPath = func() (string, error) { return path, nil }
+	t.Cleanup(func() { userNetworkPresetsPath = orig })
+}
+
+func TestLoadConfig_NetworkPresetFromInclude(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	withUserNetworkPresets(t, "/home/u/.alcatraz/network-presets.toml")
+	shared := `[network.presets.corp-dev]
+lan-access = ["10.0.0.0/8:443"]
+proxy = "10.0.0.1:3128"
+log_connections = true
+
+[network.presets.corp-dev.shaping]
+rate = "10mbit"
+`
+	project := `image = "alpine"
+includes = ["./shared.toml"]
+
+[network]
+preset = "corp-dev"
+lan-access = ["192.168.1.9:22"]
+proxy = "127.0.0.1:8080"
+`
+	_ = afero.WriteFile(memFs, "/p/shared.toml", []byte(shared), 0644)
+	_ = afero.WriteFile(memFs, "/p/.alca.toml", []byte(project), 0644)
+
+	cfg, err := LoadConfig(env, "/p/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	net := cfg.Network
+	if want := []string{"10.0.0.0/8:443", "192.168.1.9:22"}; !slices.Equal(net.LANAccess, want) {
+		t.Errorf("LANAccess = %v, want %v", net.LANAccess, want)
+	}
+	if net.Proxy != "127.0.0.1:8080" {
+		t.Errorf("Proxy = %q, want the project's proxy", net.Proxy)
+	}
+	if net.Shaping.Rate != "10mbit" || !net.LogConnections {
+		t.Errorf("Shaping = %+v, LogConnections = %v, want the preset's", net.Shaping, net.LogConnections)
+	}
+	if net.Preset != "corp-dev" || net.Presets != nil {
+		t.Errorf("Preset = %q, Presets = %v, want name kept and definitions dropped", net.Preset, net.Presets)
+	}
+}
+
+func TestLoadConfig_NetworkPresetFromUserFile(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	withUserNetworkPresets(t, "/home/u/.alcatraz/network-presets.toml")
+	_ = afero.WriteFile(memFs, "/home/u/.alcatraz/network-presets.toml", []byte("[corp-dev]\nlan-access = [\"10.1.2.3:5432\"]\n"), 0644)
+	_ = afero.WriteFile(memFs, "/p/.alca.toml", []byte("image = \"alpine\"\n\n[network]\npreset = \"corp-dev\"\n"), 0644)
+
+	cfg, err := LoadConfig(env, "/p/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if want := []string{"10.1.2.3:5432"}; !slices.Equal(cfg.Network.LANAccess, want) {
+		t.Errorf("LANAccess = %v, want %v", cfg.Network.LANAccess, want)
+	}
+}
+
+func TestLoadConfig_NetworkPresetUnknown(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	withUserNetworkPresets(t, "/home/u/.alcatraz/network-presets.toml")
+	_ = afero.WriteFile(memFs, "/p/.alca.toml", []byte("image = \"alpine\"\n\n[network]\npreset = \"nope\"\n"), 0644)
+
+	if _, err := LoadConfig(env, "/p/.alca.toml", noExpandEnv); !errors.Is(err, ErrUnknownNetworkPreset) {
+		t.Errorf("LoadConfig() error = %v, want ErrUnknownNetworkPreset", err)
+	}
+}
@@ -0,0 +1,184 @@
+// overrides.go implements --set config overrides: dotted-path assignments
+// layered on top of the merged config like a final include.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+)
+
+// Override is one key=value or key+=value assignment.
+type Override struct {
+	Path   []string // dotted key split into segments, e.g. [resources memory]
+	Value  any      // decoded TOML value, or the raw string if not valid TOML
+	Append bool     // key+=value appends to an array
+}
+
+// String formats the override as it is written on the command line.
+func (o Override) String() string {
+	op := "="
+	if o.Append {
+		op = "+="
+	}
+	return fmt.Sprintf("%s%s%v", strings.Join(o.Path, "."), op, o.Value)
+}
+
+// overrideRoots are keys that shape file loading and cannot be overridden.
+var overrideRoots = []string{"extends", "includes", SchemaVersionKey}
+
+// ParseOverride parses "key=value" or "key+=value". The value is read as a
+// TOML value (4, true, ["a", "b"]) and otherwise taken as a plain string,
+// so resources.memory=8g needs no quoting.
+func ParseOverride(s string) (Override, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return Override{}, fmt.Errorf("%q: expected key=value or key+=value: %w", s, ErrInvalidOverride)
+	}
+
+	var o Override
+	var err error
+	key, o.Append = strings.CutSuffix(key, "+")
+	if o.Path, err = ParseKey(key); err != nil {
+		return Override{}, fmt.Errorf("%q: %w", s, err)
+	}
+	for _, root := range overrideRoots {
+		if o.Path[0] == root {
+			return Override{}, fmt.Errorf("%q: %s cannot be overridden: %w", s, root, ErrInvalidOverride)
+		}
+	}
+	o.Value = ParseValue(value)
+	return o, nil
+}
+
+// ParseKey splits a dotted config key such as resources.memory into its
+// segments.
+func ParseKey(key string) ([]string, error) {
+	path := strings.Split(strings.TrimSpace(key), ".")
+	for _, seg := range path {
+		if seg == "" {
+			return nil, fmt.Errorf("empty key segment: %w", ErrInvalidOverride)
+		}
+	}
+	return path, nil
+}
+
+// ParseValue reads a command-line value as a TOML value (4, true,
+// ["a", "b"]), falling back to the plain string.
+func ParseValue(value string) any {
+	var doc map[string]any
+	if err := toml.Unmarshal([]byte("v = "+value), &doc); err == nil {
+		return doc["v"]
+	}
+	return value
+}
+
+// setOverride applies o to a decoded TOML document, creating tables as needed.
+func setOverride(doc map[string]any, o Override) error {
+	table := doc
+	for _, seg := range o.Path[:len(o.Path)-1] {
+		switch next := table[seg].(type) {
+		case map[string]any:
+			table = next
+		case nil:
+			child := make(map[string]any)
+			table[seg] = child
+			table = child
+		default:
+			return fmt.Errorf("%s: %s is not a table: %w", o, seg, ErrInvalidOverride)
+		}
+	}
+
+	leaf := o.Path[len(o.Path)-1]
+	if !o.Append {
+		table[leaf] = o.Value
+		return nil
+	}
+	var items []any
+	switch existing := table[leaf].(type) {
+	case nil:
+	case []any:
+		items = existing
+	default:
+		return fmt.Errorf("%s: %s is not an array: %w", o, leaf, ErrInvalidOverride)
+	}
+	if values, ok := o.Value.([]any); ok {
+		table[leaf] = append(items, values...)
+	} else {
+		table[leaf] = append(items, o.Value)
+	}
+	return nil
+}
+
+// applyOverrides layers overrides on cfg with the include merge rules, after
+// checking them against the RawConfig schema (unknown keys, wrong types).
+func applyOverrides(cfg Config, overrides []Override, expandEnv func(string) (string, error)) (Config, error) {
+	raw, err := decodeOverrides(overrides)
+	if err != nil {
+		return Config{}, err
+	}
+	overlay, err := rawToConfig(raw, expandEnv)
+	if err != nil {
+		return Config{}, fmt.Errorf("%v: %w", err, ErrInvalidOverride)
+	}
+	return mergeConfigs(cfg, overlay), nil
+}
+
+// decodeOverrides decodes overrides as a config file of their own,
+// rejecting unknown keys and values of the wrong type.
+func decodeOverrides(overrides []Override) (RawConfig, error) {
+	doc := make(map[string]any)
+	for _, o := range overrides {
+		if err := setOverride(doc, o); err != nil {
+			return RawConfig{}, err
+		}
+	}
+	data, err := toml.Marshal(doc)
+	if err != nil {
+		return RawConfig{}, fmt.Errorf("encode overrides: %w", err)
+	}
+
+	var raw RawConfig
+	dec := toml.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		var missing *toml.StrictMissingError
+		if errors.As(err, &missing) {
+			keys := make([]string, 0, len(missing.Errors))
+			for _, e := range missing.Errors {
+				keys = append(keys, strings.Join(e.Key(), "."))
+			}
+			return RawConfig{}, fmt.Errorf("unknown key %s: %w", strings.Join(keys, ", "), ErrInvalidOverride)
+		}
+		return RawConfig{}, fmt.Errorf("%v: %w", err, ErrInvalidOverride)
+	}
+	return raw, nil
+}
+
+// SaveOverrides writes overrides into the config file at path (typically
+// .alca.local.toml), creating it if needed. Keys are edited in place (see
+// SetKey), so comments are preserved.
+func SaveOverrides(fs afero.Fs, path string, overrides []Override) error {
+	if IsEncryptedConfigPath(path) {
+		return fmt.Errorf("%s: encrypted configs must be edited by hand", path)
+	}
+
+	data, err := afero.ReadFile(fs, path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, o := range overrides {
+		if data, err = SetKey(data, o); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+)
+
+func TestParseOverride(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Override
+		wantErr bool
+	}{
+		{"resources.memory=8g", Override{Path: []string{"resources", "memory"}, Value: "8g"}, false},
+		{"resources.cpus=4", Override{Path: []string{"resources", "cpus"}, Value: int64(4)}, false},
+		{"network.log_connections=true", Override{Path: []string{"network", "log_connections"}, Value: true}, false},
+		{"network.lan-access+=192.168.1.9:443", Override{Path: []string{"network", "lan-access"}, Value: "192.168.1.9:443", Append: true}, false},
+		{`plugins=["a", "b"]`, Override{Path: []string{"plugins"}, Value: []any{"a", "b"}}, false},
+		{"image=", Override{Path: []string{"image"}, Value: ""}, false},
+		{"resources.memory", Override{}, true},
+		{"resources..memory=1g", Override{}, true},
+		{"includes+=other.toml", Override{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseOverride(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidOverride) {
+					t.Errorf("ParseOverride() error = %v, want ErrInvalidOverride", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOverride() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOverride() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustParseOverrides(t *testing.T, sets ...string) []Override {
+	t.Helper()
+	overrides := make([]Override, 0, len(sets))
+	for _, s := range sets {
+		o, err := ParseOverride(s)
+		if err != nil {
+			t.Fatalf("ParseOverride(%q): %v", s, err)
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides
+}
+
+func TestLoadConfigWithOverrides(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	content := `image = "alpine"
+
+[resources]
+memory = "2g"
+cpus = 2
+
+[network]
+lan-access = ["10.0.0.1:22"]
+`
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	overrides := mustParseOverrides(t, "resources.memory=8g", "network.lan-access+=192.168.1.9:443", "image=alpine:3")
+	cfg, err := LoadConfigWithOverrides(env, path, noExpandEnv, overrides)
+	if err != nil {
+		t.Fatalf("LoadConfigWithOverrides failed: %v", err)
+	}
+	if cfg.Resources.Memory != "8g" || cfg.Resources.CPUs != 2 {
+		t.Errorf("Resources = %+v, want memory 8g and cpus 2", cfg.Resources)
+	}
+	if want := []string{"10.0.0.1:22", "192.168.1.9:443"}; !slices.Equal(cfg.Network.LANAccess, want) {
+		t.Errorf("LANAccess = %v, want %v", cfg.Network.LANAccess, want)
+	}
+	if cfg.Image != "alpine:3" {
+		t.Errorf("Image = %q, want alpine:3", cfg.Image)
+	}
+}
+
+func TestLoadConfigWithOverrides_Invalid(t *testing.T) {
+	tests := []struct {
+		set     string
+		wantErr error
+	}{
+		{"resources.mem=8g", ErrInvalidOverride},
+		{"resources.cpus=many", ErrInvalidOverride},
+		{"resources=8g", ErrInvalidOverride},
+		{"platform=arm64", ErrInvalidPlatform},
+	}
+	for _, tt := range tests {
+		t.Run(tt.set, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			if err := afero.WriteFile(memFs, path, []byte("image = \"alpine\"\n"), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := LoadConfigWithOverrides(env, path, noExpandEnv, mustParseOverrides(t, tt.set))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoadConfigWithOverrides() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveOverrides(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/test/.alca.local.toml"
+	existing := `[resources]
+cpus = 2
+
+[network]
+lan-access = ["10.0.0.1:22"]
+`
+	if err := afero.WriteFile(fs, path, []byte(existing), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if err := SaveOverrides(fs, path, mustParseOverrides(t, "resources.memory=8g", "network.lan-access+=192.168.1.9:443")); err != nil {
+		t.Fatalf("SaveOverrides failed: %v", err)
+	}
+
+	data, _ := afero.ReadFile(fs, path)
+	var raw RawConfig
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("saved file does not parse: %v\n%s", err, data)
+	}
+	if raw.Resources.Memory != "8g" || raw.Resources.CPUs != 2 {
+		t.Errorf("Resources = %+v", raw.Resources)
+	}
+	if want := []string{"10.0.0.1:22", "192.168.1.9:443"}; !slices.Equal(raw.Network.LANAccess, want) {
+		t.Errorf("LANAccess = %v, want %v", raw.Network.LANAccess, want)
+	}
+}
+
+func TestIncludesFile(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	_ = afero.WriteFile(memFs, "/p/.alca.local.toml", nil, 0644)
+	_ = afero.WriteFile(memFs, "/p/.alca.toml", []byte("image = \"alpine\"\nincludes = [\"./.alca.*.toml\"]\n"), 0644)
+	_ = afero.WriteFile(memFs, "/q/.alca.local.toml", nil, 0644)
+	_ = afero.WriteFile(memFs, "/q/.alca.toml", []byte("image = \"alpine\"\n"), 0644)
+
+	if !IncludesFile(env, "/p/.alca.toml", "/p/.alca.local.toml", noExpandEnv) {
+		t.Error("glob include not detected")
+	}
+	if IncludesFile(env, "/q/.alca.toml", "/q/.alca.local.toml", noExpandEnv) {
+		t.Error("config without includes reported as including the file")
+	}
+}
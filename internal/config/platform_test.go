@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_Platform(t *testing.T) {
+	tests := []struct {
+		platform string
+		wantErr  bool
+	}{
+		{"linux/arm64", false},
+		{"linux/arm/v7", false},
+		{"linux/amd64", false},
+		{"arm64", true},
+		{"linux/arm64/v8/extra", true},
+		{"Linux/ARM64", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			content := "image = \"alpine\"\nplatform = \"" + tt.platform + "\"\n"
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cfg, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPlatform) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidPlatform", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Platform != tt.platform {
+				t.Errorf("Platform = %q, want %q", cfg.Platform, tt.platform)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_RegistryMirror(t *testing.T) {
+	tests := []struct {
+		mirror  string
+		wantErr bool
+	}{
+		{"http://localhost:5000", false},
+		{"https://mirror.example.com/", false},
+		{"localhost:5000", true},
+		{"ftp://mirror.example.com", true},
+		{"http://localhost:5000/v2/library", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mirror, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			content := "image = \"alpine\"\nregistry_mirror = \"" + tt.mirror + "\"\n"
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cfg, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRegistryMirror) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidRegistryMirror", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.RegistryMirror != tt.mirror {
+				t.Errorf("RegistryMirror = %q, want %q", cfg.RegistryMirror, tt.mirror)
+			}
+		})
+	}
+}
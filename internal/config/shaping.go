@@ -0,0 +1,24 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// shapingRatePattern matches tc rate values such as "10mbit", "1.5gbit" or "800kbps".
+var shapingRatePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([kmgt]?(bit|bps))$`)
+
+// ValidateShaping checks that rate and delay use syntax tc understands.
+func ValidateShaping(s Shaping) error {
+	if s.Rate != "" && !shapingRatePattern.MatchString(s.Rate) {
+		return fmt.Errorf("rate %q: expected a number followed by bit, kbit, mbit, gbit or a bps unit: %w", s.Rate, ErrInvalidShaping)
+	}
+	if s.Delay != "" {
+		d, err := time.ParseDuration(s.Delay)
+		if err != nil || d < time.Microsecond {
+			return fmt.Errorf("delay %q: expected a positive duration such as 50ms: %w", s.Delay, ErrInvalidShaping)
+		}
+	}
+	return nil
+}
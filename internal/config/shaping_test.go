@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestValidateShaping(t *testing.T) {
+	tests := []struct {
+		name    string
+		shaping Shaping
+		wantErr bool
+	}{
+		{"empty", Shaping{}, false},
+		{"rate and delay", Shaping{Rate: "10mbit", Delay: "50ms"}, false},
+		{"fractional rate", Shaping{Rate: "1.5gbit"}, false},
+		{"byte rate", Shaping{Rate: "800kbps"}, false},
+		{"bare bits", Shaping{Rate: "9600bit"}, false},
+		{"delay in seconds", Shaping{Delay: "1.5s"}, false},
+		{"rate without unit", Shaping{Rate: "10"}, true},
+		{"rate with unknown unit", Shaping{Rate: "10mb"}, true},
+		{"rate with space", Shaping{Rate: "10 mbit"}, true},
+		{"delay without unit", Shaping{Delay: "50"}, true},
+		{"zero delay", Shaping{Delay: "0s"}, true},
+		{"negative delay", Shaping{Delay: "-5ms"}, true},
+		{"delay below tc resolution", Shaping{Delay: "500ns"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateShaping(tt.shaping)
+			if tt.wantErr && !errors.Is(err, ErrInvalidShaping) {
+				t.Errorf("ValidateShaping(%+v) error = %v, want ErrInvalidShaping", tt.shaping, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("ValidateShaping(%+v) unexpected error: %v", tt.shaping, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Shaping(t *testing.T) {
+	content := `
+image = "alpine"
+
+[network.shaping]
+rate = "10mbit"
+delay = "50ms"
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	want := Shaping{Rate: "10mbit", Delay: "50ms"}
+	if cfg.Network.Shaping != want {
+		t.Errorf("Network.Shaping = %+v, want %+v", cfg.Network.Shaping, want)
+	}
+}
+
+func TestLoadConfig_InvalidShaping(t *testing.T) {
+	content := `
+image = "alpine"
+
+[network.shaping]
+rate = "fast"
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if _, err := LoadConfig(env, path, noExpandEnv); !errors.Is(err, ErrInvalidShaping) {
+		t.Errorf("LoadConfig() error = %v, want ErrInvalidShaping", err)
+	}
+}
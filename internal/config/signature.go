@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/minisign"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Shared config signatures: once trusted keys are configured at the machine
+// or user level, every extends/includes file outside the project directory
+// must carry a minisign signature (<file>.minisig) from one of them. Files
+// inside the project are the project's own and are not checked.
+
+// TrustedConfigKeysFile lists minisign public keys trusted to sign shared
+// configs, one per line. It is read from /etc/alcatraz and ~/.alcatraz;
+// "untrusted comment:" and # lines are ignored, so .pub files can be
+// appended as they are.
+const TrustedConfigKeysFile = "trusted-config-keys"
+
+// ConfigSignatureSuffix is appended to a config path to find its signature.
+const ConfigSignatureSuffix = ".minisig"
+
+// EnvInsecureIncludes, when "1", makes alca load shared configs without
+// verifying their signatures, like the --insecure-includes flag.
+const EnvInsecureIncludes = "ALCA_INSECURE_INCLUDES"
+
+// trustedConfigKeysPaths returns the machine-level and user-level trusted
+// key files.
+var trustedConfigKeysPaths = func() []string {
+	paths := []string{filepath.Join("/etc/alcatraz", TrustedConfigKeysFile)}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, util.AlcatrazDir, TrustedConfigKeysFile))
+	}
+	return paths
+}
+
+// loadTrustedConfigKeys reads the trusted key files; missing files add no keys.
+func loadTrustedConfigKeys(fs afero.Fs) ([]minisign.Key, error) {
+	var keys []minisign.Key
+	for _, path := range trustedConfigKeysPaths() {
+		data, err := afero.ReadFile(fs, path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := minisign.ParseKeys(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, parsed...)
+	}
+	return keys, nil
+}
+
+// verifyMinisign checks a minisign signature file over data against keys,
+// reporting a signature that does not verify as ErrInvalidConfigSignature.
+func verifyMinisign(keys []minisign.Key, data, sigFile []byte) error {
+	err := minisign.Verify(keys, data, sigFile)
+	var sigErr *minisign.Error
+	if errors.As(err, &sigErr) {
+		return fmt.Errorf("%w: %s", ErrInvalidConfigSignature, sigErr.Reason)
+	}
+	return err
+}
+
+// includeVerifier enforces signatures on shared configs during a load.
+type includeVerifier struct {
+	projectDir string
+	keys       []minisign.Key
+}
+
+// newIncludeVerifier returns the verifier for loading the config at
+// rootPath, or nil when no keys are trusted or opts skip verification.
+func newIncludeVerifier(fs afero.Fs, rootPath string, opts loadOptions) (*includeVerifier, error) {
+	if opts.insecureIncludes {
+		return nil, nil
+	}
+	keys, err := loadTrustedConfigKeys(fs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trusted config keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	absRoot, err := filepath.Abs(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	return &includeVerifier{projectDir: filepath.Dir(absRoot), keys: keys}, nil
+}
+
+// verify checks the signature over data, the contents of the config file
+// at absPath, when it is outside the project directory.
+func (v *includeVerifier) verify(fs afero.Fs, absPath string, data []byte) error {
+	if v == nil {
+		return nil
+	}
+	if rel, err := filepath.Rel(v.projectDir, absPath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	sigFile, err := afero.ReadFile(fs, absPath+ConfigSignatureSuffix)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s is outside the project and has no %s signature from a trusted key (pass --insecure-includes to load it anyway): %w",
+			absPath, ConfigSignatureSuffix, ErrUnsignedConfig)
+	}
+	if err != nil {
+		return err
+	}
+	if err := verifyMinisign(v.keys, data, sigFile); err != nil {
+		return fmt.Errorf("%s%s: %w (pass --insecure-includes to load it anyway)", absPath, ConfigSignatureSuffix, err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/bolasblack/alcatraz/internal/minisign"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// testSigner is a minisign key pair for signing test configs.
+type testSigner struct {
+	id   [8]byte
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func newTestSigner(t *testing.T, id byte) testSigner {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{id: [8]byte{id, 1, 2, 3, 4, 5, 6, 7}, pub: pub, priv: priv}
+}
+
+// publicKey returns the key as minisign writes it to a .pub file.
+func (s testSigner) publicKey() string {
+	raw := append(append([]byte(minisign.AlgPure), s.id[:]...), s.pub...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+}
+
+// sign returns a minisign signature file for data.
+func (s testSigner) sign(data []byte, prehash bool) []byte {
+	alg, message := minisign.AlgPure, data
+	if prehash {
+		sum := blake2b.Sum512(data)
+		alg, message = minisign.AlgPrehashed, sum[:]
+	}
+	sig := ed25519.Sign(s.priv, message)
+	trusted := "timestamp:1767225600\tfile:base.alca.toml\thashed"
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), trusted...))
+	blob := append(append([]byte(alg), s.id[:]...), sig...)
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(blob) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+// setupSignedConfigTest writes a project config extending a shared config
+// outside the project, and trusts signer via the user-level key file.
+func setupSignedConfigTest(t *testing.T, signer testSigner) (afero.Fs, []byte) {
+	t.Helper()
+	orig := trustedConfigKeysPaths
+	trustedConfigKeysPaths = func() []string {
+		return []string{"/etc/alcatraz/" + TrustedConfigKeysFile, "/home/me/.alcatraz/" + TrustedConfigKeysFile}
+	}
+	t.Cleanup(func() { trustedConfigKeysPaths = orig })
+
+	fs := afero.NewMemMapFs()
+	shared := []byte("image = \"platform/base:1\"\n")
+	files := map[string][]byte{
+		"/home/me/.alcatraz/" + TrustedConfigKeysFile: []byte("# platform team\n" + signer.publicKey()),
+		"/shared/base.alca.toml":                      shared,
+		"/project/.alca.toml":                         []byte("extends = [\"/shared/base.alca.toml\", \"local.toml\"]\n"),
+		"/project/local.toml":                         []byte("workdir = \"/src\"\n"),
+	}
+	for path, data := range files {
+		if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fs, shared
+}
+
+func TestLoadConfig_SignedSharedConfig(t *testing.T) {
+	signer := newTestSigner(t, 0xA1)
+
+	for _, prehash := range []bool{true, false} {
+		fs, shared := setupSignedConfigTest(t, signer)
+		if err := afero.WriteFile(fs, "/shared/base.alca.toml"+ConfigSignatureSuffix, signer.sign(shared, prehash), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadConfig(&util.Env{Fs: fs}, "/project/.alca.toml", noExpandEnv)
+		if err != nil {
+			t.Fatalf("prehash=%v: LoadConfig() error: %v", prehash, err)
+		}
+		if cfg.Image != "platform/base:1" || cfg.Workdir != "/src" {
+			t.Errorf("prehash=%v: cfg = %q %q", prehash, cfg.Image, cfg.Workdir)
+		}
+	}
+}
+
+func TestLoadConfig_SharedConfigSignatureErrors(t *testing.T) {
+	signer := newTestSigner(t, 0xA1)
+	other := newTestSigner(t, 0xB2)
+
+	tests := []struct {
+		name    string
+		sig     func(shared []byte) []byte
+		wantErr error
+	}{
+		{"unsigned", nil, ErrUnsignedConfig},
+		{"tampered", func(shared []byte) []byte { return signer.sign(append(shared, '#'), true) }, ErrInvalidConfigSignature},
+		{"untrusted key", func(shared []byte) []byte { return other.sign(shared, true) }, ErrInvalidConfigSignature},
+		{"garbage", func([]byte) []byte { return []byte("not a signature") }, ErrInvalidConfigSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, shared := setupSignedConfigTest(t, signer)
+			if tt.sig != nil {
+				if err := afero.WriteFile(fs, "/shared/base.alca.toml"+ConfigSignatureSuffix, tt.sig(shared), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := LoadConfig(&util.Env{Fs: fs}, "/project/.alca.toml", noExpandEnv)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadConfig() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// --insecure-includes loads it anyway
+			if _, err := LoadConfig(&util.Env{Fs: fs}, "/project/.alca.toml", noExpandEnv, WithInsecureIncludes()); err != nil {
+				t.Errorf("insecure LoadConfig() error: %v", err)
+			}
+		})
+	}
+}
+
+// swapOnReadFs replaces the contents of path with swap once it has been
+// opened, like a file swapped between two reads.
+type swapOnReadFs struct {
+	afero.Fs
+	path string
+	swap []byte
+}
+
+func (s *swapOnReadFs) Open(name string) (afero.File, error) {
+	f, err := s.Fs.Open(name)
+	if err != nil || name != s.path {
+		return f, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := afero.WriteFile(s.Fs, name, s.swap, 0644); err != nil {
+		return nil, err
+	}
+	snapshot := afero.NewMemMapFs()
+	if err := afero.WriteFile(snapshot, name, data, 0644); err != nil {
+		return nil, err
+	}
+	return snapshot.Open(name)
+}
+
+func TestLoadConfig_SharedConfigSwappedAfterRead(t *testing.T) {
+	signer := newTestSigner(t, 0xA1)
+	fs, shared := setupSignedConfigTest(t, signer)
+	if err := afero.WriteFile(fs, "/shared/base.alca.toml"+ConfigSignatureSuffix, signer.sign(shared, true), 0644); err != nil {
+		t.Fatal(err)
+	}
+	swapping := &swapOnReadFs{Fs: fs, path: "/shared/base.alca.toml", swap: []byte("image = \"evil:1\"\n")}
+
+	cfg, err := LoadConfig(&util.Env{Fs: swapping}, "/project/.alca.toml", noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.Image != "platform/base:1" {
+		t.Errorf("Image = %q, want the verified contents", cfg.Image)
+	}
+}
+
+func TestLoadConfig_InheritedParentIsVerified(t *testing.T) {
+	fs, _ := setupSignedConfigTest(t, newTestSigner(t, 0xA1))
+	if err := afero.WriteFile(fs, "/project/sub/.alca.toml", []byte("inherit = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(&util.Env{Fs: fs}, "/project/sub/.alca.toml", noExpandEnv)
+	if !errors.Is(err, ErrUnsignedConfig) {
+		t.Fatalf("LoadConfig() error = %v, want %v", err, ErrUnsignedConfig)
+	}
+}
+
+func TestLoadConfig_NoTrustedKeysSkipsVerification(t *testing.T) {
+	fs, _ := setupSignedConfigTest(t, newTestSigner(t, 0xA1))
+	if err := fs.Remove("/home/me/.alcatraz/" + TrustedConfigKeysFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(&util.Env{Fs: fs}, "/project/.alca.toml", noExpandEnv); err != nil {
+		t.Errorf("LoadConfig() without trusted keys: %v", err)
+	}
+}
+
+func TestLoadTrustedConfigKeys_Invalid(t *testing.T) {
+	fs, _ := setupSignedConfigTest(t, newTestSigner(t, 0xA1))
+	if err := afero.WriteFile(fs, "/etc/alcatraz/"+TrustedConfigKeysFile, []byte("RWQnotakey\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(&util.Env{Fs: fs}, "/project/.alca.toml", noExpandEnv); err == nil {
+		t.Error("expected error for a malformed trusted key")
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_SSH(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		want    SSH
+		wantErr bool
+	}{
+		{"unset", "", SSH{}, false},
+		{"enabled", "enabled = true\n", SSH{Enabled: true}, false},
+		{"port", "enabled = true\nport = 2200\n", SSH{Enabled: true, Port: 2200}, false},
+		{"port out of range", "enabled = true\nport = 70000\n", SSH{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			content := "image = \"alpine\"\n"
+			if tt.table != "" {
+				content += "[ssh]\n" + tt.table
+			}
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cfg, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPort) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidPort", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error: %v", err)
+			}
+			if cfg.SSH != tt.want {
+				t.Errorf("SSH = %+v, want %+v", cfg.SSH, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeConfigs_SSH(t *testing.T) {
+	base := Config{SSH: SSH{Enabled: true, Port: 2200}}
+	if got := mergeConfigs(base, Config{}).SSH; got != base.SSH {
+		t.Errorf("empty overlay: SSH = %+v, want %+v", got, base.SSH)
+	}
+	if got := mergeConfigs(Config{}, Config{SSH: SSH{Port: 2300}}).SSH; got != (SSH{Port: 2300}) {
+		t.Errorf("port overlay: SSH = %+v", got)
+	}
+}
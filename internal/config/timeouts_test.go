@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_Timeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		pull    time.Duration
+		upCmd   time.Duration
+		wantErr bool
+	}{
+		{"unset", "", 0, 0, false},
+		{"both", "pull = \"10m\"\nup_command = \"1h\"\n", 10 * time.Minute, time.Hour, false},
+		{"pull only", "pull = \"90s\"\n", 90 * time.Second, 0, false},
+		{"zero", "pull = \"0\"\n", 0, 0, true},
+		{"no unit", "up_command = \"30\"\n", 0, 0, true},
+		{"negative", "up_command = \"-1m\"\n", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			content := "image = \"alpine\"\n"
+			if tt.table != "" {
+				content += "[timeouts]\n" + tt.table
+			}
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cfg, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimeout) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidTimeout", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if got := cfg.Timeouts.PullTimeout(); got != tt.pull {
+				t.Errorf("PullTimeout() = %v, want %v", got, tt.pull)
+			}
+			if got := cfg.Timeouts.UpCommandTimeout(); got != tt.upCmd {
+				t.Errorf("UpCommandTimeout() = %v, want %v", got, tt.upCmd)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_IdleTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"unset", "", 0, false},
+		{"hours", "2h", 2 * time.Hour, false},
+		{"minimum", "10m", 10 * time.Minute, false},
+		{"below minimum", "5m", 0, true},
+		{"no unit", "120", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			content := "image = \"alpine\"\n"
+			if tt.value != "" {
+				content += "idle_timeout = \"" + tt.value + "\"\n"
+			}
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cfg, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimeout) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidTimeout", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if got := cfg.IdleTimeoutDuration(); got != tt.want {
+				t.Errorf("IdleTimeoutDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
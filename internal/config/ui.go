@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Placeholders of ui.title and ui.prompt_prefix.
+const (
+	UIPlaceholderProjectName = NamePlaceholderProjectName
+	UIPlaceholderContainer   = "{container}"
+)
+
+// DefaultUITitle is the terminal title set on enter when ui.title is unset.
+const DefaultUITitle = "alca: " + UIPlaceholderProjectName + " (" + UIPlaceholderContainer + ")"
+
+// UITitleNone leaves the terminal title alone.
+const UITitleNone = "none"
+
+// UI configures how alca enter marks a shell as running in the sandbox.
+type UI struct {
+	Title        string `toml:"title,omitempty" json:"title,omitempty" jsonschema:"description=Terminal title set while alca enter runs with {project.name} and {container} placeholders. Defaults to 'alca: {project.name} ({container})'; none leaves the title alone."`
+	PromptPrefix string `toml:"prompt_prefix,omitempty" json:"prompt_prefix,omitempty" jsonschema:"description=Text prepended to the shell prompt (PS1) of alca enter with {project.name} and {container} placeholders (e.g. '(alca) '). Unset leaves the prompt alone."`
+}
+
+// UIVars are the values substituted into ui.title and ui.prompt_prefix.
+type UIVars struct {
+	ProjectName string // base name of the project directory
+	Container   string // container name
+}
+
+// TerminalTitle returns the rendered terminal title, or "" when ui.title is none.
+func (u UI) TerminalTitle(vars UIVars) string {
+	switch u.Title {
+	case UITitleNone:
+		return ""
+	case "":
+		return vars.render(DefaultUITitle)
+	}
+	return vars.render(u.Title)
+}
+
+// Prompt returns the rendered prompt prefix, or "" when unset.
+func (u UI) Prompt(vars UIVars) string {
+	return vars.render(u.PromptPrefix)
+}
+
+func (v UIVars) render(template string) string {
+	return strings.NewReplacer(
+		UIPlaceholderProjectName, v.ProjectName,
+		UIPlaceholderContainer, v.Container,
+	).Replace(template)
+}
+
+// validateUI rejects unknown placeholders and control characters, which
+// would end the title escape sequence early or garble the prompt.
+func validateUI(u UI) error {
+	for _, f := range []struct{ name, value string }{
+		{"ui.title", u.Title},
+		{"ui.prompt_prefix", u.PromptPrefix},
+	} {
+		if strings.ContainsFunc(f.value, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+			return fmt.Errorf("%s %q: must not contain control characters: %w", f.name, f.value, ErrInvalidUI)
+		}
+		for _, p := range namePlaceholderPattern.FindAllString(f.value, -1) {
+			if p != UIPlaceholderProjectName && p != UIPlaceholderContainer {
+				return fmt.Errorf("%s %q: unknown placeholder %s (use %s or %s): %w", f.name, f.value, p,
+					UIPlaceholderProjectName, UIPlaceholderContainer, ErrInvalidUI)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_UI(t *testing.T) {
+	vars := UIVars{ProjectName: "myapp", Container: "alca-1234"}
+	tests := []struct {
+		name       string
+		ui         string
+		wantTitle  string
+		wantPrompt string
+		wantErr    bool
+	}{
+		{"unset", "", "alca: myapp (alca-1234)", "", false},
+		{"custom", "title = \"{container}\"\nprompt_prefix = \"[{project.name}] \"\n", "alca-1234", "[myapp] ", false},
+		{"none", "title = \"none\"\n", "", "", false},
+		{"unknown placeholder", "title = \"{branch}\"\n", "", "", true},
+		{"control character", "prompt_prefix = \"\\u001b[31m\"\n", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			content := "image = \"alpine\"\n"
+			if tt.ui != "" {
+				content += "[ui]\n" + tt.ui
+			}
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cfg, err := LoadConfig(env, path, noExpandEnv)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidUI) {
+					t.Errorf("LoadConfig() error = %v, want ErrInvalidUI", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if got := cfg.UI.TerminalTitle(vars); got != tt.wantTitle {
+				t.Errorf("TerminalTitle() = %q, want %q", got, tt.wantTitle)
+			}
+			if got := cfg.UI.Prompt(vars); got != tt.wantPrompt {
+				t.Errorf("Prompt() = %q, want %q", got, tt.wantPrompt)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_User(t *testing.T) {
+	content := `
+image = "alpine"
+user = "dev"
+
+[commands.enter]
+command = ". ~/.profile"
+user = "root"
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.User != "dev" {
+		t.Errorf("User = %q, want dev", cfg.User)
+	}
+	if cfg.Commands.Enter.User != "root" {
+		t.Errorf("Commands.Enter.User = %q, want root", cfg.Commands.Enter.User)
+	}
+	if got := cfg.ExecUser(); got != "root" {
+		t.Errorf("ExecUser() = %q, want root", got)
+	}
+}
+
+func TestLoadConfig_UserOnlyForEnter(t *testing.T) {
+	for _, section := range []string{"up", "test"} {
+		t.Run(section, func(t *testing.T) {
+			content := `
+image = "alpine"
+
+[commands.` + section + `]
+command = "true"
+user = "root"
+`
+			env, memFs := newTestEnv(t)
+			path := "/test/.alca.toml"
+			if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := LoadConfig(env, path, noExpandEnv)
+			if err == nil || !strings.Contains(err.Error(), "commands."+section+": user is only supported") {
+				t.Errorf("LoadConfig() error = %v, want user rejected for commands.%s", err, section)
+			}
+		})
+	}
+}
+
+func TestExecUser(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"unset", Config{}, ""},
+		{"top-level", Config{User: "dev"}, "dev"},
+		{"enter overrides", Config{User: "dev", Commands: Commands{Enter: CommandValue{User: "root"}}}, "root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ExecUser(); got != tt.want {
+				t.Errorf("ExecUser() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeCommandValue_User(t *testing.T) {
+	base := CommandValue{Command: "source env", User: "dev"}
+
+	// Overlay with only a user keeps the base command
+	got := mergeCommandValue(base, CommandValue{User: "root"})
+	if got.Command != "source env" || got.User != "root" {
+		t.Errorf("user-only overlay = %+v, want base command with user root", got)
+	}
+
+	// Overlay command without a user keeps the base user
+	got = mergeCommandValue(base, CommandValue{Command: "more", Append: true})
+	if got.Command != "source env more" || got.User != "dev" {
+		t.Errorf("append overlay = %+v, want appended command with user dev", got)
+	}
+}
+
+func TestCommandValueToRaw_User(t *testing.T) {
+	raw := commandValueToRaw(CommandValue{User: "root"})
+	m, ok := raw.(map[string]any)
+	if !ok {
+		t.Fatalf("commandValueToRaw() = %#v, want object form", raw)
+	}
+	if m["user"] != "root" {
+		t.Errorf("user = %v, want root", m["user"])
+	}
+	if _, ok := m["command"]; ok {
+		t.Errorf("empty command should be omitted: %v", m)
+	}
+
+	parsed, err := parseCommandValue(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.User != "root" {
+		t.Errorf("round trip User = %q, want root", parsed.User)
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfig_WorkdirSource(t *testing.T) {
+	content := `
+image = "alpine"
+workdir_source = "../monorepo"
+workdir_exclude = ["node_modules"]
+`
+	env, memFs := newTestEnv(t)
+	path := "/test/project/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.WorkdirSource != "../monorepo" {
+		t.Errorf("WorkdirSource = %q, want ../monorepo", cfg.WorkdirSource)
+	}
+	workdirMount := cfg.Mounts[0]
+	if workdirMount.Source != "../monorepo" || workdirMount.Target != DefaultWorkdir {
+		t.Errorf("workdir mount = %+v, want ../monorepo -> %s", workdirMount, DefaultWorkdir)
+	}
+	if len(workdirMount.Exclude) != 1 {
+		t.Errorf("workdir mount should keep workdir_exclude, got %+v", workdirMount)
+	}
+}
+
+func TestLoadConfig_WorkdirSourceExpandsEnv(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte("image = \"alpine\"\nworkdir_source = \"${REPO}\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	expand := func(s string) (string, error) {
+		if s == "${REPO}" {
+			return "/src/repo", nil
+		}
+		return s, nil
+	}
+	cfg, err := LoadConfig(env, path, expand)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Mounts[0].Source != "/src/repo" {
+		t.Errorf("workdir mount source = %q, want /src/repo", cfg.Mounts[0].Source)
+	}
+}
+
+func TestLoadConfig_WorkdirSourceDefault(t *testing.T) {
+	env, memFs := newTestEnv(t)
+	path := "/test/.alca.toml"
+	if err := afero.WriteFile(memFs, path, []byte(`image = "alpine"`), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(env, path, noExpandEnv)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Mounts[0].Source != "." {
+		t.Errorf("workdir mount source = %q, want project directory (.)", cfg.Mounts[0].Source)
+	}
+}
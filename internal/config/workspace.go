@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+)
+
+// Workspace is the content of an .alca-workspace.toml file, which groups
+// several project directories so they can be managed together.
+type Workspace struct {
+	// Members are project directories relative to the workspace file.
+	// Glob patterns (e.g. "services/*") are supported.
+	Members []string `toml:"members"`
+}
+
+// LoadWorkspace reads and parses a workspace file.
+func LoadWorkspace(fs afero.Fs, path string) (Workspace, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return Workspace{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var ws Workspace
+	if err := toml.Unmarshal(data, &ws); err != nil {
+		return Workspace{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(ws.Members) == 0 {
+		return Workspace{}, fmt.Errorf("%s: members must not be empty: %w", path, ErrNoWorkspaceMembers)
+	}
+	return ws, nil
+}
@@ -0,0 +1,115 @@
+// Package credstore keeps secrets out of plaintext files: in the macOS
+// Keychain, in the Linux Secret Service (GNOME Keyring, KWallet), or, where
+// neither is available, in a passphrase-encrypted file under ~/.alcatraz.
+// alca reads one of them itself, the age identity (KeyAgeIdentity) that
+// decrypts encrypted configs.
+package credstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Service names the alca entries in the keychain and the secret service.
+const Service = "alcatraz"
+
+// Backends, selectable with EnvBackend.
+const (
+	BackendKeychain      = "keychain"
+	BackendSecretService = "secret-service"
+	BackendFile          = "file"
+)
+
+// EnvBackend forces a backend instead of picking the platform's.
+const EnvBackend = "ALCA_CREDENTIAL_STORE"
+
+// Well-known keys.
+const (
+	// KeyAgeIdentity holds the age identities that decrypt .age configs
+	// when ALCA_AGE_IDENTITY and ALCA_AGE_IDENTITY_FILE are unset.
+	KeyAgeIdentity = "age-identity"
+)
+
+var (
+	ErrNotFound       = errors.New("credential not found")
+	ErrUnknownBackend = errors.New("unknown credential store")
+	ErrInvalidKey     = errors.New("invalid credential key")
+)
+
+// Store reads and writes named secrets.
+type Store interface {
+	// Name returns the backend name (one of the Backend constants).
+	Name() string
+
+	// Get returns the value stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) (string, error)
+
+	// Set stores value under key, replacing any previous value.
+	Set(ctx context.Context, key, value string) error
+
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the store selected by EnvBackend, else the platform's: the
+// Keychain on macOS, the Secret Service on Linux when a session bus and
+// secret-tool are available, and the encrypted file otherwise.
+func New(env *util.Env) (Store, error) {
+	switch backend := os.Getenv(EnvBackend); backend {
+	case BackendKeychain:
+		return &keychainStore{cmd: env.Cmd}, nil
+	case BackendSecretService:
+		return &secretServiceStore{cmd: env.Cmd}, nil
+	case BackendFile:
+		return newFileStore(env.Fs)
+	case "":
+	default:
+		return nil, fmt.Errorf("%s=%q (valid: %s, %s, %s): %w", EnvBackend, backend,
+			BackendKeychain, BackendSecretService, BackendFile, ErrUnknownBackend)
+	}
+
+	switch {
+	case goruntime.GOOS == "darwin":
+		return &keychainStore{cmd: env.Cmd}, nil
+	case goruntime.GOOS == "linux" && secretServiceAvailable():
+		return &secretServiceStore{cmd: env.Cmd}, nil
+	default:
+		return newFileStore(env.Fs)
+	}
+}
+
+// secretServiceAvailable reports whether secret-tool is installed and a
+// D-Bus session (which the secret service lives on) is reachable.
+func secretServiceAvailable() bool {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return false
+	}
+	_, err := exec.LookPath(secretToolCommand)
+	return err == nil
+}
+
+// ValidateKey checks that key is usable by every backend: non-empty
+// letters, digits, '.', '_', '-' and '/'.
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidKey)
+	}
+	for _, r := range key {
+		if !isKeyRune(r) {
+			return fmt.Errorf("%w: %q (use letters, digits, '.', '_', '-', '/')", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+func isKeyRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune("._-/", r)
+}
@@ -0,0 +1,209 @@
+package credstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestKeychainStore(t *testing.T) {
+	ctx := context.Background()
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("security find-generic-password -s alcatraz -a age-identity -g",
+		[]byte("keychain: \"/Users/me/Library/Keychains/login.keychain-db\"\nclass: \"genp\"\nattributes:\n    \"acct\"<blob>=\"age-identity\"\npassword: \"AGE-SECRET-KEY-1X\"\n"))
+	cmd.Expect("security find-generic-password -s alcatraz -a missing -g",
+		[]byte("security: SecKeychainSearchCopyNext: The specified item could not be found in the keychain.\n"), errors.New("exit status 44"))
+	cmd.ExpectSuccess("security -i", nil)
+	cmd.Expect("security delete-generic-password -s alcatraz -a missing",
+		[]byte("security: SecKeychainSearchCopyNext: The specified item could not be found in the keychain.\n"), errors.New("exit status 44"))
+	s := &keychainStore{cmd: cmd}
+
+	got, err := s.Get(ctx, "age-identity")
+	if err != nil || got != "AGE-SECRET-KEY-1X" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Set(ctx, "token", "s3cret value"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	call := cmd.Calls[len(cmd.Calls)-1]
+	// "s3cret value" in hex; the value itself must not be an argument
+	want := "add-generic-password -U -s alcatraz -a token -l alcatraz:token -X 7333637265742076616c7565\n"
+	if call.Input != want {
+		t.Errorf("Set input = %q, want %q", call.Input, want)
+	}
+
+	if err := s.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Delete(missing) = %v, want nil", err)
+	}
+	cmd.AssertAllExpectationsMet(t)
+}
+
+func TestKeychainStore_GetMultiLine(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	// security prints a value with a newline in hex, escaped text after it
+	cmd.ExpectSuccess("security find-generic-password -s alcatraz -a age-identity -g",
+		[]byte("keychain: \"/Users/me/Library/Keychains/login.keychain-db\"\nattributes:\n    \"acct\"<blob>=\"age-identity\"\npassword: 0x4147452D312D410A4147452D312D42  \"AGE-1-A\\012AGE-1-B\"\n"))
+	cmd.ExpectSuccess("security find-generic-password -s alcatraz -a hexlike -g", []byte("password: \"deadbeef\"\n"))
+	cmd.ExpectSuccess("security find-generic-password -s alcatraz -a empty -g", []byte("password: \n"))
+	s := &keychainStore{cmd: cmd}
+
+	for key, want := range map[string]string{
+		"age-identity": "AGE-1-A\nAGE-1-B",
+		"hexlike":      "deadbeef",
+		"empty":        "",
+	} {
+		got, err := s.Get(context.Background(), key)
+		if err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+	cmd.AssertAllExpectationsMet(t)
+}
+
+func TestKeychainStore_SetReportsInteractiveErrors(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("security -i", []byte("security: SecKeychainItemCreateFromContent (<default>): User interaction is not allowed.\n"))
+	s := &keychainStore{cmd: cmd}
+
+	if err := s.Set(context.Background(), "token", "x"); err == nil {
+		t.Error("expected an error from the security output")
+	}
+}
+
+func TestSecretServiceStore(t *testing.T) {
+	ctx := context.Background()
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("secret-tool lookup service alcatraz account token", []byte("value"))
+	cmd.ExpectFailure("secret-tool lookup service alcatraz account missing", errors.New("exit status 1"))
+	cmd.ExpectSuccess("secret-tool store --label=alcatraz:token service alcatraz account token", nil)
+	cmd.ExpectFailure("secret-tool clear service alcatraz account missing", errors.New("exit status 1"))
+	s := &secretServiceStore{cmd: cmd}
+
+	got, err := s.Get(ctx, "token")
+	if err != nil || got != "value" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if err := s.Set(ctx, "token", "new value"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if input := cmd.Calls[len(cmd.Calls)-1].Input; input != "new value" {
+		t.Errorf("Set input = %q, want the value on stdin", input)
+	}
+	if err := s.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Delete(missing) = %v, want nil", err)
+	}
+	cmd.AssertAllExpectationsMet(t)
+}
+
+func TestSecretServiceStore_LockedCollection(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.Expect("secret-tool lookup service alcatraz account token",
+		[]byte("secret-tool: Cannot autolaunch D-Bus without X11 $DISPLAY\n"), errors.New("exit status 1"))
+	s := &secretServiceStore{cmd: cmd}
+
+	_, err := s.Get(context.Background(), "token")
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get error = %v, want a failure other than ErrNotFound", err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	scryptWorkFactor = 10
+	t.Cleanup(func() { scryptWorkFactor = 18 })
+	t.Setenv(EnvPassphrase, "correct horse")
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+	s := &fileStore{fs: fs, path: "/home/u/.alcatraz/credentials.age"}
+
+	if _, err := s.Get(ctx, "token"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get on a missing file error = %v, want ErrNotFound", err)
+	}
+	if err := s.Set(ctx, "token", "abc"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, "other", "def"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	data, err := afero.ReadFile(fs, s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[:len("age-encryption.org/")]) != "age-encryption.org/" {
+		t.Errorf("file is not age-encrypted: %q", data[:20])
+	}
+	info, _ := fs.Stat(s.path)
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("file mode = %o, want 600", perm)
+	}
+
+	if got, err := s.Get(ctx, "token"); err != nil || got != "abc" {
+		t.Errorf("Get = %q, %v", got, err)
+	}
+	if err := s.Delete(ctx, "token"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, "token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if got, _ := s.Get(ctx, "other"); got != "def" {
+		t.Errorf("other = %q, want def", got)
+	}
+
+	t.Setenv(EnvPassphrase, "wrong")
+	if _, err := s.Get(ctx, "other"); err == nil {
+		t.Error("expected a decryption error with the wrong passphrase")
+	}
+	t.Setenv(EnvPassphrase, "")
+	if _, err := s.Get(ctx, "other"); !errors.Is(err, ErrNoPassphrase) {
+		t.Errorf("Get without passphrase error = %v, want ErrNoPassphrase", err)
+	}
+}
+
+func TestNew_Backend(t *testing.T) {
+	env := util.NewTestEnv()
+
+	for backend, want := range map[string]string{
+		BackendKeychain:      BackendKeychain,
+		BackendSecretService: BackendSecretService,
+		BackendFile:          BackendFile,
+	} {
+		t.Setenv(EnvBackend, backend)
+		s, err := New(env)
+		if err != nil {
+			t.Fatalf("New(%s): %v", backend, err)
+		}
+		if s.Name() != want {
+			t.Errorf("New(%s).Name() = %s", backend, s.Name())
+		}
+	}
+
+	t.Setenv(EnvBackend, "vault")
+	if _, err := New(env); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("New(vault) error = %v, want ErrUnknownBackend", err)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"age-identity", "ci/token", "a.b_c"} {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("ValidateKey(%q) = %v", key, err)
+		}
+	}
+	for _, key := range []string{"", "with space", "semi;colon", "quote\""} {
+		if err := ValidateKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ValidateKey(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package credstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// CredentialsFile is the encrypted file store, in ~/.alcatraz.
+const CredentialsFile = "credentials.age"
+
+// EnvPassphrase holds the passphrase of the encrypted file store.
+const EnvPassphrase = "ALCA_CREDENTIALS_PASSPHRASE"
+
+var ErrNoPassphrase = errors.New("no credentials passphrase")
+
+// scryptWorkFactor is the age scrypt cost (log2 N) for the file store;
+// tests lower it.
+var scryptWorkFactor = 18
+
+// fileStore keeps all secrets in one JSON object, age-encrypted with a
+// passphrase (scrypt), for hosts without a keychain or secret service.
+// The file is replaced atomically and readable only by the user.
+type fileStore struct {
+	fs   afero.Fs
+	path string
+}
+
+func newFileStore(fs afero.Fs) (*fileStore, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate the credentials file: %w", err)
+	}
+	return &fileStore{fs: fs, path: filepath.Join(home, util.AlcatrazDir, CredentialsFile)}, nil
+}
+
+func (s *fileStore) Name() string { return BackendFile }
+
+func (s *fileStore) Get(_ context.Context, key string) (string, error) {
+	creds, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := creds[key]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return value, nil
+}
+
+func (s *fileStore) Set(_ context.Context, key, value string) error {
+	creds, err := s.load()
+	if err != nil {
+		return err
+	}
+	creds[key] = value
+	return s.save(creds)
+}
+
+func (s *fileStore) Delete(_ context.Context, key string) error {
+	creds, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := creds[key]; !ok {
+		return nil
+	}
+	delete(creds, key)
+	return s.save(creds)
+}
+
+// load decrypts the file; a missing file holds no credentials.
+func (s *fileStore) load() (map[string]string, error) {
+	data, err := afero.ReadFile(s.fs, s.path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+
+	passphrase, err := filePassphrase()
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), identity)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s (wrong %s?): %w", s.path, EnvPassphrase, err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s: %w", s.path, err)
+	}
+
+	creds := map[string]string{}
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, fmt.Errorf("corrupt credentials file %s: %w", s.path, err)
+	}
+	return creds, nil
+}
+
+// save encrypts creds and atomically replaces the file.
+func (s *fileStore) save(creds map[string]string) error {
+	passphrase, err := filePassphrase()
+	if err != nil {
+		return err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+	recipient.SetWorkFactor(scryptWorkFactor)
+
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if err := s.fs.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(s.path), err)
+	}
+	if err := util.WriteFileAtomic(s.fs, s.path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func filePassphrase() (string, error) {
+	passphrase := os.Getenv(EnvPassphrase)
+	if passphrase == "" {
+		return "", fmt.Errorf("no keychain or secret service available; set %s to use the encrypted file store: %w", EnvPassphrase, ErrNoPassphrase)
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,99 @@
+package credstore
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const securityCommand = "security"
+
+// keychainStore keeps secrets as generic passwords in the user's default
+// macOS keychain, via the security tool.
+type keychainStore struct {
+	cmd util.CommandRunner
+}
+
+func (s *keychainStore) Name() string { return BackendKeychain }
+
+// Get reads the password from the -g output rather than -w: both print a
+// value with a newline or other unprintable byte as bare hex, which -w
+// leaves indistinguishable from a value made of hex digits, while -g marks
+// it with 0x.
+func (s *keychainStore) Get(ctx context.Context, key string) (string, error) {
+	output, err := s.cmd.RunQuiet(ctx, securityCommand, "find-generic-password", "-s", Service, "-a", key, "-g")
+	if err != nil {
+		if keychainItemMissing(output) {
+			return "", fmt.Errorf("%s: %w", key, ErrNotFound)
+		}
+		return "", fmt.Errorf("failed to read %s from the keychain: %w: %s", key, err, strings.TrimSpace(string(output)))
+	}
+	value, err := parseKeychainPassword(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from the keychain: %w", key, err)
+	}
+	return value, nil
+}
+
+// parseKeychainPassword extracts the value from the "password:" line of
+// find-generic-password -g, which is either password: "value" or, for
+// values that are not printable text, password: 0x<hex>  "<escaped>".
+func parseKeychainPassword(output []byte) (string, error) {
+	for _, line := range strings.Split(string(output), "\n") {
+		rest, ok := strings.CutPrefix(line, "password: ")
+		if !ok {
+			continue
+		}
+		if hexValue, ok := strings.CutPrefix(rest, "0x"); ok {
+			hexValue, _, _ = strings.Cut(hexValue, " ")
+			value, err := hex.DecodeString(hexValue)
+			if err != nil {
+				return "", fmt.Errorf("malformed password: %w", err)
+			}
+			return string(value), nil
+		}
+		if rest == "" {
+			return "", nil
+		}
+		if len(rest) < 2 || rest[0] != '"' || rest[len(rest)-1] != '"' {
+			return "", fmt.Errorf("malformed password line %q", line)
+		}
+		return rest[1 : len(rest)-1], nil
+	}
+	return "", errors.New("no password in security output")
+}
+
+// Set feeds the command to security's interactive mode on stdin, so the
+// value never shows up in the process list; hex encoding (-X) sidesteps
+// its quoting rules. Interactive mode exits 0 even when the command fails,
+// so its "security: ..." error lines are checked too.
+func (s *keychainStore) Set(ctx context.Context, key, value string) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %s -X %s\n",
+		Service, key, Service+":"+key, hex.EncodeToString([]byte(value)))
+	output, err := s.cmd.RunWithInput(ctx, command, securityCommand, "-i")
+	if err == nil && strings.Contains(string(output), "security: ") {
+		err = errors.New("security failed")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s to the keychain: %w: %s", key, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (s *keychainStore) Delete(ctx context.Context, key string) error {
+	output, err := s.cmd.RunQuiet(ctx, securityCommand, "delete-generic-password", "-s", Service, "-a", key)
+	if err != nil && !keychainItemMissing(output) {
+		return fmt.Errorf("failed to delete %s from the keychain: %w: %s", key, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// keychainItemMissing reports whether security failed because the item does
+// not exist (errSecItemNotFound).
+func keychainItemMissing(output []byte) bool {
+	return strings.Contains(string(output), "could not be found")
+}
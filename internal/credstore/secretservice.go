@@ -0,0 +1,51 @@
+package credstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const secretToolCommand = "secret-tool"
+
+// secretServiceStore keeps secrets in the freedesktop Secret Service
+// (GNOME Keyring, KWallet), via secret-tool from libsecret. Items carry the
+// attributes service=alcatraz and account=<key>.
+type secretServiceStore struct {
+	cmd util.CommandRunner
+}
+
+func (s *secretServiceStore) Name() string { return BackendSecretService }
+
+func (s *secretServiceStore) Get(ctx context.Context, key string) (string, error) {
+	output, err := s.cmd.RunQuiet(ctx, secretToolCommand, "lookup", "service", Service, "account", key)
+	if err != nil {
+		// secret-tool exits 1 without output when nothing matches
+		if len(strings.TrimSpace(string(output))) == 0 {
+			return "", fmt.Errorf("%s: %w", key, ErrNotFound)
+		}
+		return "", fmt.Errorf("failed to read %s from the secret service: %w: %s", key, err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// Set passes the value on stdin, so it never shows up in the process list.
+func (s *secretServiceStore) Set(ctx context.Context, key, value string) error {
+	output, err := s.cmd.RunWithInput(ctx, value, secretToolCommand, "store",
+		"--label="+Service+":"+key, "service", Service, "account", key)
+	if err != nil {
+		return fmt.Errorf("failed to write %s to the secret service: %w: %s", key, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (s *secretServiceStore) Delete(ctx context.Context, key string) error {
+	output, err := s.cmd.RunQuiet(ctx, secretToolCommand, "clear", "service", Service, "account", key)
+	// clear also exits 1 when nothing matched
+	if err != nil && len(strings.TrimSpace(string(output))) > 0 {
+		return fmt.Errorf("failed to delete %s from the secret service: %w: %s", key, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
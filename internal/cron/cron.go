@@ -0,0 +1,179 @@
+// Package cron parses cron schedules and decides when they are due.
+//
+// Schedules are matched against wall-clock minutes in a time zone: the
+// host's local zone, or the zone named by a CRON_TZ= prefix. They never
+// depend on the container's TZ, which images set inconsistently.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCatchUp is the longest jump of the wall clock whose skipped minutes
+// are still checked, so tasks inside a daylight saving gap run once the
+// gap is over. Longer jumps (the host slept) only check the current
+// minute, instead of running everything missed at once.
+const maxCatchUp = 61 * time.Minute
+
+// tzPrefix names the schedule's time zone, as in cronie and Vixie cron.
+const tzPrefix = "CRON_TZ="
+
+// macros are the @-schedules cron implementations commonly accept.
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field bounds, in schedule order: minute, hour, day of month, month, day of week.
+var bounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// Schedule is a parsed cron schedule.
+type Schedule struct {
+	// Location is the zone the schedule is matched in.
+	Location *time.Location
+	fields   [5]uint64
+	// domStar and dowStar record unrestricted day fields: when both day
+	// fields are restricted, a day matching either one matches (cron's
+	// long-standing rule).
+	domStar, dowStar bool
+}
+
+// Parse parses a five-field schedule ("0 * * * *") or an @-macro, with an
+// optional "CRON_TZ=<zone> " prefix. Schedules without a zone use local.
+func Parse(spec string, local *time.Location) (*Schedule, error) {
+	s := &Schedule{Location: local}
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, tzPrefix); ok {
+		zone, expr, _ := strings.Cut(rest, " ")
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q: %w", zone, err)
+		}
+		s.Location = loc
+		spec = strings.TrimSpace(expr)
+	}
+	if expanded, ok := macros[spec]; ok {
+		spec = expanded
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("schedule %q must have 5 fields (minute hour day-of-month month day-of-week)", spec)
+	}
+	for i, part := range parts {
+		bits, err := parseField(part, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		s.fields[i] = bits
+	}
+	// 7 is Sunday too
+	if s.fields[4]&(1<<7) != 0 {
+		s.fields[4] |= 1
+	}
+	s.domStar = parts[2] == "*" || strings.HasPrefix(parts[2], "*/")
+	s.dowStar = parts[4] == "*" || strings.HasPrefix(parts[4], "*/")
+	return s, nil
+}
+
+// parseField parses a comma-separated list of values, ranges (1-5) and
+// steps (*/15, 0-30/10) into a bit set.
+func parseField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", item)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", item)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid range %q", item)
+				}
+			} else if hasStep {
+				end = hi
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q is out of range %d-%d", item, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Wall returns t's wall-clock minute in loc, as a UTC time so wall minutes
+// can be compared and stepped without daylight saving shifts.
+func Wall(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+// Matches reports whether the schedule matches a wall-clock minute (see Wall).
+func (s *Schedule) Matches(wall time.Time) bool {
+	has := func(field, v int) bool { return s.fields[field]&(1<<uint(v)) != 0 }
+	if !has(0, wall.Minute()) || !has(1, wall.Hour()) || !has(3, int(wall.Month())) {
+		return false
+	}
+	dom, dow := has(2, wall.Day()), has(4, int(wall.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Due reports whether the schedule matches a wall-clock minute after last
+// and up to now (both from Wall). A clock that went back (the repeated
+// hour when daylight saving ends) is never due, so callers must keep last
+// at the latest wall minute seen.
+func (s *Schedule) Due(last, now time.Time) bool {
+	if !now.After(last) {
+		return false
+	}
+	if now.Sub(last) > maxCatchUp {
+		return s.Matches(now)
+	}
+	for w := last.Add(time.Minute); !w.After(now); w = w.Add(time.Minute) {
+		if s.Matches(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the first time after t at which the schedule is due,
+// searching up to a year ahead. Returns the zero time if there is none
+// (e.g. "0 0 31 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.Location).Truncate(time.Minute)
+	for i := 0; i < 366*24*60; i++ {
+		t = t.Add(time.Minute)
+		if s.Matches(Wall(t, s.Location)) {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,136 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, spec string) *Schedule {
+	t.Helper()
+	s, err := Parse(spec, time.UTC)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", spec, err)
+	}
+	return s
+}
+
+func wall(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"CRON_TZ=Nowhere/City 0 * * * *",
+		"@every 5m",
+	} {
+		if _, err := Parse(spec, time.UTC); err == nil {
+			t.Errorf("Parse(%q): expected error", spec)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		spec string
+		at   string
+		want bool
+	}{
+		{"0 * * * *", "2026-03-02 10:00", true},
+		{"0 * * * *", "2026-03-02 10:01", false},
+		{"*/15 9-17 * * *", "2026-03-02 09:45", true},
+		{"*/15 9-17 * * *", "2026-03-02 18:00", false},
+		{"0,30 * * * *", "2026-03-02 10:30", true},
+		{"10-50/20 * * * *", "2026-03-02 10:30", true},
+		{"10-50/20 * * * *", "2026-03-02 10:40", false},
+		{"5/20 * * * *", "2026-03-02 10:45", true},
+		{"@daily", "2026-03-02 00:00", true},
+		{"@hourly", "2026-03-02 07:00", true},
+		// 2026-03-01 is a Sunday; 7 is Sunday too
+		{"0 0 * * 7", "2026-03-01 00:00", true},
+		{"0 0 * * 1-5", "2026-03-01 00:00", false},
+		// Both day fields restricted: either matches
+		{"0 0 15 * 1", "2026-03-02 00:00", true},
+		{"0 0 15 * 1", "2026-03-15 00:00", true},
+		{"0 0 15 * 1", "2026-03-03 00:00", false},
+		// One day field restricted: it must match
+		{"0 0 15 * *", "2026-03-02 00:00", false},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.spec).Matches(wall(tt.at)); got != tt.want {
+			t.Errorf("%q at %s: Matches = %v, want %v", tt.spec, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestParse_TimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	s, err := Parse("CRON_TZ=Europe/Berlin 30 9 * * *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Location.String() != "Europe/Berlin" {
+		t.Fatalf("Location = %s", s.Location)
+	}
+	// 07:30 UTC is 09:30 in Berlin in summer
+	at := time.Date(2026, 7, 1, 7, 30, 0, 0, time.UTC)
+	if !s.Matches(Wall(at, s.Location)) {
+		t.Error("expected match at 09:30 Berlin time")
+	}
+	if next := s.Next(time.Date(2026, 7, 1, 8, 0, 0, 0, time.UTC)); !next.Equal(time.Date(2026, 7, 2, 9, 30, 0, 0, berlin)) {
+		t.Errorf("Next = %s", next)
+	}
+}
+
+func TestDue(t *testing.T) {
+	half := mustParse(t, "30 2 * * *")
+	tests := []struct {
+		name      string
+		last, now string
+		want      bool
+	}{
+		{"due minute", "2026-03-29 02:29", "2026-03-29 02:30", true},
+		{"not due", "2026-03-29 02:30", "2026-03-29 02:31", false},
+		// Spring forward: 02:00-02:59 is skipped, the 02:30 task runs at 03:00
+		{"dst gap caught up", "2026-03-29 01:59", "2026-03-29 03:00", true},
+		// Fall back: the wall clock repeats 02:00-02:59, last stays at 02:59
+		{"repeated hour", "2026-10-25 02:59", "2026-10-25 02:30", false},
+		// The host slept: only the current minute counts
+		{"after sleep", "2026-03-28 01:00", "2026-03-29 09:00", false},
+	}
+	for _, tt := range tests {
+		if got := half.Due(wall(tt.last), wall(tt.now)); got != tt.want {
+			t.Errorf("%s: Due = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWall_DaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	// 01:59 UTC on 2026-03-29 is 03:59 CEST, a minute after 00:59 UTC (01:59 CET)
+	before := Wall(time.Date(2026, 3, 29, 0, 59, 0, 0, time.UTC), berlin)
+	after := Wall(time.Date(2026, 3, 29, 1, 0, 0, 0, time.UTC), berlin)
+	if before != wall("2026-03-29 01:59") || after != wall("2026-03-29 03:00") {
+		t.Errorf("Wall = %s, %s", before, after)
+	}
+	if !mustParse(t, "30 2 * * *").Due(before, after) {
+		t.Error("02:30 task in the skipped hour did not run")
+	}
+}
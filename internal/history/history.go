@@ -0,0 +1,92 @@
+// Package history records commands executed in the sandbox via 'alca run'.
+// Entries are appended to .alca/history.jsonl, one JSON object per line,
+// so they can be listed and replayed when reproducing agent sessions.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// HistoryFilename is the name of the history file inside the .alca directory.
+	HistoryFilename = "history.jsonl"
+
+	historyDir      = ".alca"
+	historyDirPerm  = 0755
+	historyFilePerm = 0644
+)
+
+// Entry is a single executed command.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Command  []string  `json:"command"`
+	ExitCode int       `json:"exit_code"`
+}
+
+// FilePath returns the path to the history file for the given project directory.
+func FilePath(projectDir string) string {
+	return filepath.Join(projectDir, historyDir, HistoryFilename)
+}
+
+// Append adds an entry to the end of the history file, creating it if needed.
+func Append(fs afero.Fs, projectDir string, entry Entry) error {
+	if err := fs.MkdirAll(filepath.Join(projectDir, historyDir), historyDirPerm); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	// Commands may carry sensitive values; they are stored masked
+	entry.Command = util.RedactAll(entry.Command)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	f, err := fs.OpenFile(FilePath(projectDir), os.O_CREATE|os.O_WRONLY|os.O_APPEND, historyFilePerm)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// Load reads all entries in the order they were recorded.
+// Returns nil and no error if the history file does not exist.
+// Malformed lines (e.g. a partially written last line) are skipped.
+func Load(fs afero.Fs, projectDir string) ([]Entry, error) {
+	data, err := afero.ReadFile(fs, FilePath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || len(e.Command) == 0 {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return entries, nil
+}
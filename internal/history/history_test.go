@@ -0,0 +1,89 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	entries := []Entry{
+		{Time: now, Command: []string{"ls", "-la"}, ExitCode: 0},
+		{Time: now.Add(time.Minute), Command: []string{"make", "test"}, ExitCode: 2},
+	}
+	for _, e := range entries {
+		if err := Append(fs, "/project", e); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	got, err := Load(fs, "/project")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("got %d entries, want %d", len(got), len(entries))
+	}
+	for i := range entries {
+		if !got[i].Time.Equal(entries[i].Time) || got[i].ExitCode != entries[i].ExitCode ||
+			len(got[i].Command) != len(entries[i].Command) || got[i].Command[0] != entries[i].Command[0] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], entries[i])
+		}
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	got, err := Load(afero.NewMemMapFs(), "/project")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got != nil {
+		t.Errorf("expected nil entries, got %v", got)
+	}
+}
+
+func TestLoad_SkipsMalformedLines(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	content := `{"time":"2026-01-02T03:04:05Z","command":["ls"],"exit_code":0}
+not json
+{"time":"2026-01-02T03:04:06Z","command":["pwd"],"exit_code":1}
+{"time":"2026-01-02T03:04:07Z","comm`
+	_ = afero.WriteFile(fs, FilePath("/project"), []byte(content), 0644)
+
+	got, err := Load(fs, "/project")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(got) != 2 || got[0].Command[0] != "ls" || got[1].Command[0] != "pwd" {
+		t.Errorf("unexpected entries: %+v", got)
+	}
+}
+
+func TestAppendRedactsSecrets(t *testing.T) {
+	util.ResetSecrets()
+	t.Cleanup(util.ResetSecrets)
+	util.RegisterSecret("tok-1234567890")
+
+	fs := afero.NewMemMapFs()
+	entry := Entry{Time: time.Now(), Command: []string{"curl", "-H", "Authorization: tok-1234567890"}}
+	if err := Append(fs, "/project", entry); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	got, err := Load(fs, "/project")
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Load() = %v, %v", got, err)
+	}
+	if got[0].Command[2] != "Authorization: "+util.RedactedValue {
+		t.Errorf("stored command = %q, want the secret masked", got[0].Command)
+	}
+	if entry.Command[2] != "Authorization: tok-1234567890" {
+		t.Error("Append must not modify the caller's command slice")
+	}
+}
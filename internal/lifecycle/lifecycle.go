@@ -0,0 +1,139 @@
+// Package lifecycle records sandbox lifecycle events: containers created,
+// started and stopped, sync sessions created and conflicted, firewall rules
+// applied, configuration drift detected. Events are appended to
+// .alca/events.jsonl, one JSON object per line, for external automation to
+// react to; the file is never rewritten.
+package lifecycle
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// EventsFilename is the name of the events file inside the .alca directory.
+	EventsFilename = "events.jsonl"
+
+	eventsDir      = ".alca"
+	eventsDirPerm  = 0755
+	eventsFilePerm = 0644
+)
+
+// Event types.
+const (
+	ContainerCreated = "container.created"
+	ContainerStarted = "container.started"
+	ContainerStopped = "container.stopped"
+	ContainerPaused  = "container.paused"
+	ContainerResumed = "container.resumed"
+	ContainerRemoved = "container.removed"
+	// ContainerExited is a crash, restart or kill of a process in the
+	// container, as shown by alca status; Details["kind"] says which.
+	ContainerExited = "container.exited"
+	SyncCreated     = "sync.created"
+	SyncConflicted  = "sync.conflicted"
+	FirewallApplied = "firewall.applied"
+	FirewallRemoved = "firewall.removed"
+	DriftDetected   = "config.drift_detected"
+)
+
+// Event is a single lifecycle event.
+type Event struct {
+	Time      time.Time         `json:"time"`
+	Type      string            `json:"type"`
+	ProjectID string            `json:"project_id,omitempty"`
+	Container string            `json:"container,omitempty"`
+	Message   string            `json:"message,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// FilePath returns the path to the events file for the given project directory.
+func FilePath(projectDir string) string {
+	return filepath.Join(projectDir, eventsDir, EventsFilename)
+}
+
+// Append adds an event to the end of the events file, creating it if needed.
+func Append(fs afero.Fs, projectDir string, e Event) error {
+	if err := fs.MkdirAll(filepath.Join(projectDir, eventsDir), eventsDirPerm); err != nil {
+		return fmt.Errorf("failed to create events directory: %w", err)
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	f, err := fs.OpenFile(FilePath(projectDir), os.O_CREATE|os.O_WRONLY|os.O_APPEND, eventsFilePerm)
+	if err != nil {
+		return fmt.Errorf("failed to open events file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// One write per line, so concurrent writers do not interleave
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write events file: %w", err)
+	}
+	return nil
+}
+
+// Load reads all events in the order they were recorded.
+// Returns nil and no error if the events file does not exist.
+func Load(fs afero.Fs, projectDir string) ([]Event, error) {
+	events, _, err := ReadFrom(fs, projectDir, 0)
+	return events, err
+}
+
+// ReadFrom reads the events recorded after byte offset, and returns the
+// offset to continue from. A partially written last line is left for the
+// next read; other malformed lines are skipped. An events file shorter than
+// offset was replaced, and is read from the start.
+func ReadFrom(fs afero.Fs, projectDir string, offset int64) ([]Event, int64, error) {
+	f, err := fs.Open(FilePath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, offset, fmt.Errorf("failed to read events file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read events file: %w", err)
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("failed to read events file: %w", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read events file: %w", err)
+	}
+
+	// Only complete lines are consumed
+	end := bytes.LastIndexByte(data, '\n') + 1
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data[:end]))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Type == "" {
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, offset, fmt.Errorf("failed to read events file: %w", err)
+	}
+	return events, offset + int64(end), nil
+}
@@ -0,0 +1,84 @@
+package lifecycle
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	events := []Event{
+		{Time: now, Type: ContainerCreated, Container: "alca-x"},
+		{Time: now.Add(time.Second), Type: FirewallApplied, Details: map[string]string{"firewall": "nftables"}},
+	}
+	for _, e := range events {
+		if err := Append(fs, "/project", e); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	got, err := Load(fs, "/project")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(got) != 2 || got[0].Type != ContainerCreated || got[0].Container != "alca-x" ||
+		!got[1].Time.Equal(events[1].Time) || got[1].Details["firewall"] != "nftables" {
+		t.Errorf("Load() = %+v", got)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	got, err := Load(afero.NewMemMapFs(), "/project")
+	if err != nil || got != nil {
+		t.Errorf("Load() = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestReadFrom(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := Append(fs, "/project", Event{Type: ContainerStarted}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, offset, err := ReadFrom(fs, "/project", 0)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("first read = %v, %v", got, err)
+	}
+
+	// Nothing new
+	got, next, err := ReadFrom(fs, "/project", offset)
+	if err != nil || len(got) != 0 || next != offset {
+		t.Fatalf("second read = %v, %d, %v", got, next, err)
+	}
+
+	// A partially written line waits for the rest
+	f, _ := fs.OpenFile(FilePath("/project"), os.O_WRONLY|os.O_APPEND, 0)
+	_, _ = f.WriteString(`{"type":"container.stopped"`)
+	got, next, err = ReadFrom(fs, "/project", offset)
+	if err != nil || len(got) != 0 || next != offset {
+		t.Fatalf("partial read = %v, %d, %v", got, next, err)
+	}
+	_, _ = f.WriteString("}\nnot json\n")
+	_ = f.Close()
+	got, _, err = ReadFrom(fs, "/project", offset)
+	if err != nil || len(got) != 1 || got[0].Type != ContainerStopped {
+		t.Fatalf("completed read = %v, %v", got, err)
+	}
+
+	// A replaced file is read from the start
+	if err := fs.Remove(FilePath("/project")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(fs, "/project", Event{Type: ContainerRemoved}); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err = ReadFrom(fs, "/project", 1<<20)
+	if err != nil || len(got) != 1 || got[0].Type != ContainerRemoved {
+		t.Fatalf("read after replace = %v, %v", got, err)
+	}
+}
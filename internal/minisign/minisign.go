@@ -0,0 +1,124 @@
+// Package minisign verifies minisign signatures (https://jedisct1.github.io/minisign/).
+// Shared configs and release checksums are signed with minisign.
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Key and signature algorithms: AlgPure signs the file itself, AlgPrehashed
+// its BLAKE2b-512 hash (the default since minisign 0.10).
+const (
+	AlgPure      = "Ed"
+	AlgPrehashed = "ED"
+)
+
+// Key is a minisign public key.
+type Key struct {
+	ID  [8]byte
+	Pub ed25519.PublicKey
+}
+
+// Error is a signature that does not verify against the trusted keys.
+type Error struct {
+	Reason string
+}
+
+func (e *Error) Error() string { return "invalid signature: " + e.Reason }
+
+// ParseKey decodes the base64 line of a minisign public key.
+func ParseKey(line string) (Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(line))
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != AlgPure {
+		return Key{}, fmt.Errorf("not a minisign public key: %q", line)
+	}
+	var k Key
+	copy(k.ID[:], raw[2:10])
+	k.Pub = ed25519.PublicKey(raw[10:])
+	return k, nil
+}
+
+// ParseKeys decodes a list of public keys, one per line. "untrusted
+// comment:" and # lines are ignored, so .pub files can be appended as they
+// are.
+func ParseKeys(data string) ([]Key, error) {
+	var keys []Key
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		k, err := ParseKey(line)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// Verify checks a minisign signature file over data against keys. A
+// signature that does not verify is reported as an *Error.
+func Verify(keys []Key, data, sigFile []byte) error {
+	var lines []string
+	for _, line := range strings.Split(string(sigFile), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return &Error{Reason: "not a minisign signature"}
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return &Error{Reason: "malformed signature"}
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return &Error{Reason: "malformed trusted comment signature"}
+	}
+
+	var key *Key
+	for i := range keys {
+		if bytes.Equal(keys[i].ID[:], sig[2:10]) {
+			key = &keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return &Error{Reason: fmt.Sprintf("signed by key %X, which is not trusted", reverse(sig[2:10]))}
+	}
+
+	message := data
+	switch string(sig[:2]) {
+	case AlgPure:
+	case AlgPrehashed:
+		sum := blake2b.Sum512(data)
+		message = sum[:]
+	default:
+		return &Error{Reason: fmt.Sprintf("unknown algorithm %q", sig[:2])}
+	}
+	if !ed25519.Verify(key.Pub, message, sig[10:]) {
+		return &Error{Reason: "signature does not match the file"}
+	}
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(key.Pub, append(append([]byte{}, sig[10:]...), trusted...), globalSig) {
+		return &Error{Reason: "trusted comment was altered"}
+	}
+	return nil
+}
+
+// reverse returns b reversed: minisign prints key IDs as little-endian hex.
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
@@ -0,0 +1,85 @@
+package minisign
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Known answers produced by the minisign CLI (from go-minisign's tests):
+// both signatures sign the 4-byte file "test" with minisignKey.
+const (
+	minisignKey = "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+	// otherKey is an unrelated minisign public key.
+	otherKey = "RWTk1xXqcTODeYttYMCMLo0YJHaFEHn7a3akqHlb/7QvIQXHVPxKbjB5"
+
+	pureSig = "untrusted comment: signature from minisign secret key\n" +
+		"RWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\n" +
+		"trusted comment: timestamp:1635442742\tfile:test\n" +
+		"0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n"
+	prehashedSig = "untrusted comment: signature from minisign secret key\n" +
+		"RUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\n" +
+		"trusted comment: timestamp:1635443258\tfile:test\thashed\n" +
+		"/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n"
+)
+
+func TestVerify_KnownAnswers(t *testing.T) {
+	trusted := mustParseKeys(t, "untrusted comment: minisign public key E7620F1842B4E81F\n"+minisignKey)
+	other := mustParseKeys(t, otherKey)
+
+	tests := []struct {
+		name       string
+		keys       []Key
+		data       string
+		sig        string
+		wantReason string // empty when the signature verifies
+	}{
+		{"valid", trusted, "test", pureSig, ""},
+		{"valid prehashed", trusted, "test", prehashedSig, ""},
+		{"valid with CRLF", trusted, "test", strings.ReplaceAll(prehashedSig, "\n", "\r\n"), ""},
+		{"tampered payload", trusted, "test!", pureSig, "signature does not match the file"},
+		{"tampered prehashed payload", trusted, "Test", prehashedSig, "signature does not match the file"},
+		{"wrong key ID", other, "test", prehashedSig, "signed by key E7620F1842B4E81F, which is not trusted"},
+		{"bad trusted comment", trusted, "test", strings.Replace(pureSig, "timestamp:1635442742", "timestamp:1635442743", 1), "trusted comment was altered"},
+		{"not a signature", trusted, "test", "test\n", "not a minisign signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.keys, []byte(tt.data), []byte(tt.sig))
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				return
+			}
+			var sigErr *Error
+			if !errors.As(err, &sigErr) || sigErr.Reason != tt.wantReason {
+				t.Fatalf("Verify() error = %v, want reason %q", err, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := mustParseKeys(t, "# release keys\nuntrusted comment: minisign public key\n"+minisignKey+"\n\n"+otherKey+"\n")
+	if len(keys) != 2 {
+		t.Fatalf("ParseKeys() = %d keys, want 2", len(keys))
+	}
+
+	if _, err := ParseKeys("not-a-key"); err == nil {
+		t.Error("ParseKeys() accepted a malformed key")
+	}
+	// A signature line has the key's prefix but is no key
+	if _, err := ParseKeys(strings.Split(pureSig, "\n")[1]); err == nil {
+		t.Error("ParseKeys() accepted a signature line")
+	}
+}
+
+func mustParseKeys(t *testing.T, data string) []Key {
+	t.Helper()
+	keys, err := ParseKeys(data)
+	if err != nil {
+		t.Fatalf("ParseKeys() error: %v", err)
+	}
+	return keys
+}
@@ -0,0 +1,120 @@
+package network
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/network/darwin/vmhelper"
+	"github.com/bolasblack/alcatraz/internal/network/nft"
+	alcaruntime "github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+// ConnectionLogPrefix returns the kernel log prefix of a container's
+// connection log entries (network.log_connections).
+var ConnectionLogPrefix = nft.ConnectionLogPrefix
+
+// connectionLogAnyPrefix matches the log prefix of every alca container.
+const connectionLogAnyPrefix = "alca["
+
+// ConnectionLogEntry is one new outbound connection parsed from the kernel log.
+type ConnectionLogEntry struct {
+	// SinceBoot is the kernel timestamp (time since the host/VM booted).
+	SinceBoot time.Duration
+	// Table is the nftables table (one per container) that logged the connection.
+	Table   string
+	Proto   string
+	Src     string
+	SrcPort string
+	Dst     string
+	DstPort string
+}
+
+// ConnectionLogCommand returns the command streaming the kernel log that
+// nftables log rules write to. /dev/kmsg blocks for new records after
+// replaying the buffer, which gives `tail -f` semantics on every kernel.
+// On macOS the rules live inside the runtime VM, so the log is read through
+// the privileged network helper container, or over ssh on a Podman machine.
+func ConnectionLogCommand(platform alcaruntime.RuntimePlatform) ([]string, error) {
+	switch {
+	case platform == alcaruntime.PlatformMacPodman:
+		return []string{"podman", "machine", "ssh", "sudo", "cat", "/dev/kmsg"}, nil
+	case alcaruntime.IsDarwin(platform):
+		return []string{"docker", "exec", vmhelper.ContainerName, "cat", "/dev/kmsg"}, nil
+	case platform == alcaruntime.PlatformLinux:
+		return []string{"sudo", "cat", "/dev/kmsg"}, nil
+	default:
+		return nil, fmt.Errorf("connection logs are not available for platform %q", platform)
+	}
+}
+
+// ParseConnectionLogLine parses a /dev/kmsg record written by a connection
+// log rule. prefix restricts matches to one container; empty matches any
+// alca container. Returns false for unrelated kernel messages.
+//
+// Record format: "<prio>,<seq>,<usec>,<flags>;<prefix>IN=... SRC=... DST=... PROTO=TCP SPT=... DPT=...".
+func ParseConnectionLogLine(line string, prefix string) (ConnectionLogEntry, bool) {
+	header, msg, ok := strings.Cut(line, ";")
+	if !ok {
+		return ConnectionLogEntry{}, false
+	}
+	if prefix == "" {
+		prefix = connectionLogAnyPrefix
+	}
+	if !strings.HasPrefix(msg, prefix) {
+		return ConnectionLogEntry{}, false
+	}
+
+	var entry ConnectionLogEntry
+	if fields := strings.Split(header, ","); len(fields) >= 3 {
+		if usec, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			entry.SinceBoot = time.Duration(usec) * time.Microsecond
+		}
+	}
+	if tag, _, ok := strings.Cut(msg, "] "); ok {
+		entry.Table = strings.TrimPrefix(tag, connectionLogAnyPrefix)
+	}
+
+	for _, field := range strings.Fields(msg) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "SRC":
+			entry.Src = value
+		case "DST":
+			entry.Dst = value
+		case "PROTO":
+			entry.Proto = value
+		case "SPT":
+			entry.SrcPort = value
+		case "DPT":
+			entry.DstPort = value
+		}
+	}
+	if entry.Dst == "" {
+		return ConnectionLogEntry{}, false
+	}
+	return entry, true
+}
+
+// String formats the entry as a dmesg-style line, e.g.
+// "[  1234.567890] alca-abc123 TCP 172.17.0.2:51234 → 140.82.112.3:443".
+func (e ConnectionLogEntry) String() string {
+	return fmt.Sprintf("[%12.6f] %s %-4s %s → %s",
+		e.SinceBoot.Seconds(), e.Table, e.Proto,
+		joinHostPort(e.Src, e.SrcPort), joinHostPort(e.Dst, e.DstPort))
+}
+
+// joinHostPort renders an address with an optional port (ICMP has none).
+func joinHostPort(host, port string) string {
+	if port == "" {
+		return host
+	}
+	if strings.Contains(host, ":") {
+		return "[" + host + "]:" + port
+	}
+	return host + ":" + port
+}
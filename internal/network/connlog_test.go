@@ -0,0 +1,95 @@
+package network
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	alcaruntime "github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+const testConnLogLine = "6,2210,5678901234,-;alca[alca-abc123] IN=docker0 OUT=eth0 PHYSIN=veth1 MAC=02:42 SRC=172.17.0.2 DST=140.82.112.3 LEN=60 TOS=0x00 PREC=0x00 TTL=63 ID=1 DF PROTO=TCP SPT=51234 DPT=443 WINDOW=64240 RES=0x00 SYN URGP=0"
+
+func TestParseConnectionLogLine(t *testing.T) {
+	entry, ok := ParseConnectionLogLine(testConnLogLine, "alca[alca-abc123] ")
+	if !ok {
+		t.Fatal("ParseConnectionLogLine() should match")
+	}
+	want := ConnectionLogEntry{
+		SinceBoot: 5678901234 * time.Microsecond,
+		Table:     "alca-abc123",
+		Proto:     "TCP",
+		Src:       "172.17.0.2",
+		SrcPort:   "51234",
+		Dst:       "140.82.112.3",
+		DstPort:   "443",
+	}
+	if entry != want {
+		t.Errorf("ParseConnectionLogLine() = %+v, want %+v", entry, want)
+	}
+	if got := entry.String(); got != "[ 5678.901234] alca-abc123 TCP  172.17.0.2:51234 → 140.82.112.3:443" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParseConnectionLogLine_Filtering(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		prefix string
+		want   bool
+	}{
+		{"any container", testConnLogLine, "", true},
+		{"other container", testConnLogLine, "alca[alca-def456] ", false},
+		{"unrelated kernel message", "6,2211,5678901300,-;eth0: link becomes ready", "", false},
+		{"no record header", "alca[alca-abc123] SRC=1.1.1.1 DST=2.2.2.2", "", false},
+		{"prefix without addresses", "6,1,1,-;alca[alca-abc123] truncated", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ParseConnectionLogLine(tt.line, tt.prefix); ok != tt.want {
+				t.Errorf("ParseConnectionLogLine() ok = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionLogEntry_StringWithoutPorts(t *testing.T) {
+	entry := ConnectionLogEntry{Table: "alca-abc123", Proto: "ICMP", Src: "172.17.0.2", Dst: "1.1.1.1"}
+	if got := entry.String(); got != "[    0.000000] alca-abc123 ICMP 172.17.0.2 → 1.1.1.1" {
+		t.Errorf("String() = %q", got)
+	}
+
+	entry = ConnectionLogEntry{Table: "alca-abc123", Proto: "TCP", Src: "2001:db8::2", SrcPort: "5000", Dst: "2001:db8::1", DstPort: "80"}
+	if got := entry.String(); got != "[    0.000000] alca-abc123 TCP  [2001:db8::2]:5000 → [2001:db8::1]:80" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestConnectionLogCommand(t *testing.T) {
+	tests := []struct {
+		platform alcaruntime.RuntimePlatform
+		want     []string
+	}{
+		{alcaruntime.PlatformLinux, []string{"sudo", "cat", "/dev/kmsg"}},
+		{alcaruntime.PlatformMacOrbStack, []string{"docker", "exec", "alcatraz-network-helper", "cat", "/dev/kmsg"}},
+		{alcaruntime.PlatformMacDockerDesktop, []string{"docker", "exec", "alcatraz-network-helper", "cat", "/dev/kmsg"}},
+		{alcaruntime.PlatformMacPodman, []string{"podman", "machine", "ssh", "sudo", "cat", "/dev/kmsg"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.platform), func(t *testing.T) {
+			got, err := ConnectionLogCommand(tt.platform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ConnectionLogCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ConnectionLogCommand(alcaruntime.PlatformRemote); err == nil {
+		t.Error("ConnectionLogCommand(remote) should fail: the kernel log is on another machine")
+	}
+}
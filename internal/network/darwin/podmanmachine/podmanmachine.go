@@ -0,0 +1,177 @@
+// Package podmanmachine manages nftables rules inside a Podman machine, the
+// Fedora CoreOS VM Podman runs containers in on macOS. Unlike the other VM
+// platforms it needs no helper container: nft runs in the VM through
+// 'podman machine ssh', and a systemd unit reloads the rule files when the
+// machine boots. The rule directory is under $HOME, which Podman mounts into
+// the machine at the same path.
+// It has no build constraints for testability — all platform-specific behavior
+// is injected via DI.
+package podmanmachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/network/shared"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ErrRuleLoad is returned when loading a ruleset into the machine fails.
+var ErrRuleLoad = errors.New("podmanmachine: rule load failed")
+
+// ErrTableDelete is returned when deleting an nftables table from the machine fails.
+var ErrTableDelete = errors.New("podmanmachine: table delete failed")
+
+// ErrRootless is returned when installing into a rootless machine. Its
+// containers live in a user network namespace whose traffic leaves through
+// a userspace proxy, so it never crosses the forward hook the rules use.
+var ErrRootless = errors.New("podmanmachine: the Podman machine is rootless; the firewall needs a rootful machine. Run: podman machine stop && podman machine set --rootful && podman machine start")
+
+const (
+	// UnitName is the systemd unit that loads the rule files at boot.
+	UnitName = "alcatraz-nft.service"
+	unitPath = "/etc/systemd/system/" + UnitName
+)
+
+// MachineEnv provides dependency injection for podmanmachine operations.
+type MachineEnv struct {
+	Cmd util.CommandRunner
+}
+
+// NewMachineEnv creates a MachineEnv with an externally provided command runner.
+func NewMachineEnv(cmd util.CommandRunner) *MachineEnv {
+	return &MachineEnv{Cmd: cmd}
+}
+
+// ssh runs a command as root in the default Podman machine.
+func ssh(ctx context.Context, env *MachineEnv, args ...string) ([]byte, error) {
+	return env.Cmd.RunQuiet(ctx, "podman", append([]string{"machine", "ssh", "sudo"}, args...)...)
+}
+
+// sshWithInput is ssh with input on the command's stdin.
+func sshWithInput(ctx context.Context, env *MachineEnv, input string, args ...string) ([]byte, error) {
+	return env.Cmd.RunWithInput(ctx, input, "podman", append([]string{"machine", "ssh", "sudo"}, args...)...)
+}
+
+// unit returns the systemd unit loading every rule file in nftDir.
+// '$$' is a literal '$' in unit files.
+func unit(nftDir string) string {
+	return fmt.Sprintf(`[Unit]
+Description=Alcatraz network isolation rules
+RequiresMountsFor=%[1]s
+Before=podman-restart.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/bin/sh -c 'for f in "%[1]s"/*.nft; do [ -f "$$f" ] || continue; nft -f "$$f" || echo "failed to load $$f" >&2; done'
+
+[Install]
+WantedBy=multi-user.target
+`, nftDir)
+}
+
+// LoadRules loads an nft ruleset into the machine synchronously. The script
+// is piped to nft, so it does not depend on the shared mount.
+func LoadRules(ctx context.Context, env *MachineEnv, ruleset string) error {
+	output, err := sshWithInput(ctx, env, ruleset, "nft", "-f", "-")
+	if err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrRuleLoad, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DeleteTable deletes an nftables table inside the machine.
+// Returns nil if the table does not exist.
+func DeleteTable(ctx context.Context, env *MachineEnv, family string, table string) error {
+	output, err := ssh(ctx, env, "nft", "delete", "table", family, table)
+	if err != nil {
+		combined := string(output) + " " + err.Error()
+		if strings.Contains(combined, "No such file or directory") {
+			return nil
+		}
+		return fmt.Errorf("%w: %s %s: %s", ErrTableDelete, family, table, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Reload reloads every rule file by restarting the boot unit.
+func Reload(ctx context.Context, env *MachineEnv) error {
+	if output, err := ssh(ctx, env, "systemctl", "restart", UnitName); err != nil {
+		return fmt.Errorf("podmanmachine: failed to reload rules: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// InstallHelper installs and starts the boot unit for the rule files in
+// nftDir, loading the current ones. The machine must be rootful.
+func InstallHelper(ctx context.Context, env *MachineEnv, nftDir string, progress shared.ProgressFunc) error {
+	progress = shared.SafeProgress(progress)
+
+	progress("Checking Podman machine mode...\n")
+	output, err := env.Cmd.RunQuiet(ctx, "podman", "machine", "inspect", "--format", "{{.Rootful}}")
+	if err != nil {
+		return fmt.Errorf("podmanmachine: failed to inspect the Podman machine: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	if strings.TrimSpace(string(output)) != "true" {
+		return ErrRootless
+	}
+
+	progress("Installing " + UnitName + " in the Podman machine...\n")
+	if output, err := sshWithInput(ctx, env, unit(nftDir), "tee", unitPath); err != nil {
+		return fmt.Errorf("podmanmachine: failed to write %s: %w: %s", unitPath, err, strings.TrimSpace(string(output)))
+	}
+	for _, args := range [][]string{
+		{"systemctl", "daemon-reload"},
+		{"systemctl", "enable", UnitName},
+		{"systemctl", "restart", UnitName},
+	} {
+		if output, err := ssh(ctx, env, args...); err != nil {
+			return fmt.Errorf("podmanmachine: %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	progress("Podman machine helper installed successfully\n")
+	return nil
+}
+
+// UninstallHelper disables and removes the boot unit. Loaded tables stay
+// until their containers are cleaned up or the machine restarts.
+func UninstallHelper(ctx context.Context, env *MachineEnv, progress shared.ProgressFunc) error {
+	progress = shared.SafeProgress(progress)
+
+	progress("Removing " + UnitName + " from the Podman machine...\n")
+	// disable fails when the unit is already gone, which is fine here
+	_, _ = ssh(ctx, env, "systemctl", "disable", UnitName)
+	if output, err := ssh(ctx, env, "rm", "-f", unitPath); err != nil {
+		return fmt.Errorf("podmanmachine: failed to remove %s: %w: %s", unitPath, err, strings.TrimSpace(string(output)))
+	}
+	if output, err := ssh(ctx, env, "systemctl", "daemon-reload"); err != nil {
+		return fmt.Errorf("podmanmachine: systemctl daemon-reload failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	progress("Podman machine helper removed\n")
+	return nil
+}
+
+// IsInstalled checks if the boot unit is enabled in the machine.
+func IsInstalled(ctx context.Context, env *MachineEnv) (bool, error) {
+	output, err := ssh(ctx, env, "systemctl", "is-enabled", UnitName)
+	if err != nil {
+		// Unit doesn't exist or is disabled
+		return false, nil
+	}
+	return strings.TrimSpace(string(output)) == "enabled", nil
+}
+
+// NeedsUpdate checks if the installed unit differs from the one for nftDir.
+func NeedsUpdate(ctx context.Context, env *MachineEnv, nftDir string) (bool, error) {
+	output, err := ssh(ctx, env, "cat", unitPath)
+	if err != nil {
+		// Unit can't be read — needs update
+		return true, nil
+	}
+	return string(output) != unit(nftDir), nil
+}
@@ -0,0 +1,170 @@
+package podmanmachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const testNftDir = "/Users/me/.alcatraz/files/alcatraz_nft"
+
+// =============================================================================
+// LoadRules / DeleteTable Tests
+// =============================================================================
+
+func TestLoadRules_PipesRulesetToNft(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("podman machine ssh sudo nft -f -", nil)
+	env := NewMachineEnv(mockCmd)
+
+	err := LoadRules(context.Background(), env, "table inet alca-abc {}\n")
+	require.NoError(t, err)
+
+	require.Len(t, mockCmd.Calls, 1)
+	assert.Equal(t, "table inet alca-abc {}\n", mockCmd.Calls[0].Input)
+}
+
+func TestLoadRules_WrapsErrRuleLoad(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.Expect("podman machine ssh sudo nft -f -", []byte("Error: syntax error\n"), assert.AnError)
+	env := NewMachineEnv(mockCmd)
+
+	err := LoadRules(context.Background(), env, "bogus")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRuleLoad))
+	assert.Contains(t, err.Error(), "syntax error")
+}
+
+func TestDeleteTable_IgnoresMissingTable(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.Expect("podman machine ssh sudo nft delete table inet alca-abc",
+		[]byte("Error: Could not process rule: No such file or directory"), assert.AnError)
+	env := NewMachineEnv(mockCmd)
+
+	assert.NoError(t, DeleteTable(context.Background(), env, "inet", "alca-abc"))
+}
+
+func TestDeleteTable_WrapsErrTableDelete(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.Expect("podman machine ssh sudo nft delete table ip alca-proxy-abc", []byte("Operation not permitted"), assert.AnError)
+	env := NewMachineEnv(mockCmd)
+
+	err := DeleteTable(context.Background(), env, "ip", "alca-proxy-abc")
+	assert.True(t, errors.Is(err, ErrTableDelete))
+}
+
+// =============================================================================
+// InstallHelper / UninstallHelper Tests
+// =============================================================================
+
+func TestInstallHelper_WritesAndStartsUnit(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.InOrder().
+		ExpectSuccess("podman machine inspect --format {{.Rootful}}", []byte("true\n")).
+		ExpectSuccess("podman machine ssh sudo tee "+unitPath, nil).
+		ExpectSuccess("podman machine ssh sudo systemctl daemon-reload", nil).
+		ExpectSuccess("podman machine ssh sudo systemctl enable "+UnitName, nil).
+		ExpectSuccess("podman machine ssh sudo systemctl restart "+UnitName, nil)
+	env := NewMachineEnv(mockCmd)
+
+	err := InstallHelper(context.Background(), env, testNftDir, nil)
+	require.NoError(t, err)
+	mockCmd.AssertAllExpectationsMet(t)
+
+	assert.Equal(t, unit(testNftDir), mockCmd.Calls[1].Input)
+}
+
+func TestInstallHelper_RejectsRootlessMachine(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("podman machine inspect --format {{.Rootful}}", []byte("false\n"))
+	env := NewMachineEnv(mockCmd)
+
+	err := InstallHelper(context.Background(), env, testNftDir, nil)
+	assert.ErrorIs(t, err, ErrRootless)
+	mockCmd.AssertNotCalled(t, "podman machine ssh sudo tee "+unitPath)
+}
+
+func TestInstallHelper_PropagatesSystemctlError(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("podman machine inspect --format {{.Rootful}}", []byte("true\n"))
+	mockCmd.ExpectSuccess("podman machine ssh sudo tee "+unitPath, nil)
+	mockCmd.ExpectFailure("podman machine ssh sudo systemctl daemon-reload", assert.AnError)
+	env := NewMachineEnv(mockCmd)
+
+	err := InstallHelper(context.Background(), env, testNftDir, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "daemon-reload")
+}
+
+func TestUninstallHelper_RemovesUnit(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectFailure("podman machine ssh sudo systemctl disable "+UnitName, assert.AnError)
+	mockCmd.ExpectSuccess("podman machine ssh sudo rm -f "+unitPath, nil)
+	mockCmd.ExpectSuccess("podman machine ssh sudo systemctl daemon-reload", nil)
+	env := NewMachineEnv(mockCmd)
+
+	require.NoError(t, UninstallHelper(context.Background(), env, nil))
+	mockCmd.AssertAllExpectationsMet(t)
+}
+
+// =============================================================================
+// IsInstalled / NeedsUpdate Tests
+// =============================================================================
+
+func TestIsInstalled(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		err    error
+		want   bool
+	}{
+		{"enabled", "enabled\n", nil, true},
+		{"disabled", "disabled\n", assert.AnError, false},
+		{"not found", "", assert.AnError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCmd := util.NewMockCommandRunner()
+			mockCmd.Expect("podman machine ssh sudo systemctl is-enabled "+UnitName, []byte(tt.output), tt.err)
+			installed, err := IsInstalled(context.Background(), NewMachineEnv(mockCmd))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, installed)
+		})
+	}
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		err    error
+		want   bool
+	}{
+		{"up to date", unit(testNftDir), nil, false},
+		{"other directory", unit("/Users/other/.alcatraz/files/alcatraz_nft"), nil, true},
+		{"missing", "", assert.AnError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCmd := util.NewMockCommandRunner()
+			mockCmd.Expect("podman machine ssh sudo cat "+unitPath, []byte(tt.output), tt.err)
+			needsUpdate, err := NeedsUpdate(context.Background(), NewMachineEnv(mockCmd), testNftDir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, needsUpdate)
+		})
+	}
+}
+
+func TestUnit_LoadsEveryRuleFile(t *testing.T) {
+	u := unit(testNftDir)
+	assert.Contains(t, u, "RequiresMountsFor="+testNftDir)
+	assert.Contains(t, u, `for f in "`+testNftDir+`"/*.nft`)
+	// systemd expands $f itself unless written as $$f
+	assert.NotContains(t, u, ` "$f"`)
+	assert.Contains(t, u, "WantedBy=multi-user.target")
+}
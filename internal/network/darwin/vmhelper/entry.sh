@@ -41,6 +41,23 @@ readiness_check() {
       done
       log "WARNING: nftables not available, proceeding anyway"
       ;;
+    colima)
+      # nft runs from the Lima VM's root filesystem, which may not ship it
+      log "Checking nftables in the Colima VM..."
+      nsenter -t 1 -m -u -n -i modprobe nf_tables 2>/dev/null || true
+      for i in $(seq 1 30); do
+        if nsenter -t 1 -m -u -n -i nft list tables >/dev/null 2>&1; then
+          log "nftables ready"
+          return 0
+        fi
+        if ! nsenter -t 1 -m -u -n -i sh -c 'command -v nft' >/dev/null 2>&1; then
+          log "WARNING: nft not found in the Colima VM; install it with: colima ssh -- sudo apt-get install -y nftables"
+          return 0
+        fi
+        sleep 1
+      done
+      log "WARNING: nftables not available, proceeding anyway"
+      ;;
     *)
       log "Unknown platform '$PLATFORM', skipping readiness check"
       ;;
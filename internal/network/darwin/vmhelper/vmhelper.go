@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/afero"
 
@@ -132,14 +133,20 @@ func InstallHelper(ctx context.Context, env *VMHelperEnv, platform runtime.Runti
 		return fmt.Errorf("vmhelper: failed to start helper container: %w", err)
 	}
 
-	// Verify container is running
+	// Verify container is running; it may take a moment to come up
 	progress("Verifying helper container...\n")
-	running, err := IsInstalled(ctx, env)
+	err = util.Retry(ctx, helperBackoff, func(int) error {
+		running, err := IsInstalled(ctx, env)
+		if err != nil {
+			return util.Permanent(fmt.Errorf("vmhelper: failed to verify container status: %w", err))
+		}
+		if !running {
+			return fmt.Errorf("vmhelper: helper container is not running after start")
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("vmhelper: failed to verify container status: %w", err)
-	}
-	if !running {
-		return fmt.Errorf("vmhelper: helper container is not running after start")
+		return err
 	}
 
 	progress("Helper container installed successfully\n")
@@ -165,11 +172,9 @@ func UninstallHelper(ctx context.Context, env *VMHelperEnv, progress shared.Prog
 // complete when this function returns. For synchronous rule loading, use
 // LoadRuleFile instead.
 func Reload(ctx context.Context, env *VMHelperEnv) error {
-	_, err := env.Cmd.RunQuiet(ctx, "docker", "exec", ContainerName, "sh", "-c", "kill -HUP 1")
-	if err != nil {
+	return execHelper(ctx, env, func(output []byte, err error) error {
 		return fmt.Errorf("vmhelper: failed to reload helper: %w", err)
-	}
-	return nil
+	}, "sh", "-c", "kill -HUP 1")
 }
 
 // LoadRuleFile loads a single nft rule file synchronously via the helper container.
@@ -180,11 +185,35 @@ func LoadRuleFile(ctx context.Context, env *VMHelperEnv, containerPath string) e
 	// Shell redirection opens the file from container FS before nsenter switches
 	// to the host mount namespace, then nft reads from /dev/stdin.
 	script := fmt.Sprintf("nsenter -t 1 -m -u -n -i sh -c 'nft -f /dev/stdin' < '%s'", containerPath)
-	output, err := env.Cmd.RunQuiet(ctx, "docker", "exec", ContainerName, "sh", "-c", script)
-	if err != nil {
+	return execHelper(ctx, env, func(output []byte, err error) error {
 		return fmt.Errorf("%w: %s: %s", ErrRuleLoad, containerPath, strings.TrimSpace(string(output)))
-	}
-	return nil
+	}, "sh", "-c", script)
+}
+
+// helperBackoff waits for the helper container to come up, e.g. right after
+// installation or while Docker Desktop restarts it.
+var helperBackoff = util.Backoff{Initial: 200 * time.Millisecond, Max: 2 * time.Second, Jitter: 0.2, MaxElapsed: 10 * time.Second}
+
+// execHelper runs args in the helper container, retrying while the container
+// is not running. wrap builds the returned error from a failed exec.
+func execHelper(ctx context.Context, env *VMHelperEnv, wrap func(output []byte, err error) error, args ...string) error {
+	execArgs := append([]string{"exec", ContainerName}, args...)
+	return util.Retry(ctx, helperBackoff, func(int) error {
+		output, err := env.Cmd.RunQuiet(ctx, "docker", execArgs...)
+		if err == nil {
+			return nil
+		}
+		if !isHelperStarting(string(output)) {
+			return util.Permanent(wrap(output, err))
+		}
+		return wrap(output, err)
+	})
+}
+
+// isHelperStarting reports whether docker exec failed because the helper
+// container is not running (yet).
+func isHelperStarting(output string) bool {
+	return strings.Contains(output, "is not running") || strings.Contains(output, "is restarting")
 }
 
 // DeleteTable deletes an nftables table inside the VM via the helper container.
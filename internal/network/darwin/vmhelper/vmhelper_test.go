@@ -68,6 +68,12 @@ func TestWriteEntryScript_WritesEntryScript(t *testing.T) {
 	assert.Equal(t, entryScript, string(content), "WriteEntryScript must write the embedded entry script")
 }
 
+func TestEntryScript_HandlesEveryDarwinPlatform(t *testing.T) {
+	for _, platform := range []runtime.RuntimePlatform{runtime.PlatformMacOrbStack, runtime.PlatformMacDockerDesktop, runtime.PlatformMacColima} {
+		assert.Contains(t, entryScript, "\n    "+string(platform)+")\n", "readiness_check needs a case for %s", platform)
+	}
+}
+
 // =============================================================================
 // InstallHelper Tests
 // =============================================================================
@@ -167,6 +173,7 @@ func TestInstallHelper_ECIPassesOnDockerDesktop(t *testing.T) {
 }
 
 func TestInstallHelper_FailsWhenContainerNotRunning(t *testing.T) {
+	withHelperBackoff(t, util.Backoff{MaxAttempts: 2})
 	mockFs := afero.NewMemMapFs()
 	mockCmd := util.NewMockCommandRunner().AllowUnexpected()
 	// docker inspect returns "false"
@@ -178,6 +185,28 @@ func TestInstallHelper_FailsWhenContainerNotRunning(t *testing.T) {
 	assert.Contains(t, err.Error(), "not running")
 }
 
+func TestInstallHelper_WaitsForContainerToStart(t *testing.T) {
+	withHelperBackoff(t, util.Backoff{MaxAttempts: 3})
+	mockFs := afero.NewMemMapFs()
+	mockCmd := util.NewMockCommandRunner().AllowUnexpected()
+	inspect := "docker inspect --format {{.State.Running}} " + ContainerName
+	mockCmd.ExpectSequence(inspect, []byte("false\n"), nil)
+	mockCmd.ExpectSequence(inspect, []byte("true\n"), nil)
+	env := NewVMHelperEnv(mockFs, mockCmd)
+
+	err := InstallHelper(context.Background(), env, runtime.PlatformMacOrbStack, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, mockCmd.CallCount(inspect))
+}
+
+// withHelperBackoff replaces helperBackoff for the duration of the test.
+func withHelperBackoff(t *testing.T, b util.Backoff) {
+	t.Helper()
+	orig := helperBackoff
+	helperBackoff = b
+	t.Cleanup(func() { helperBackoff = orig })
+}
+
 // =============================================================================
 // UninstallHelper Tests
 // =============================================================================
@@ -228,6 +257,19 @@ func TestReload_PropagatesError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestReload_RetriesWhileHelperRestarts(t *testing.T) {
+	withHelperBackoff(t, util.Backoff{MaxAttempts: 3})
+	mockFs := afero.NewMemMapFs()
+	mockCmd := util.NewMockCommandRunner()
+	key := "docker exec " + ContainerName + " sh -c kill -HUP 1"
+	mockCmd.ExpectSequence(key, []byte("Error response from daemon: container abc is restarting, wait until the container is running"), assert.AnError)
+	mockCmd.ExpectSequence(key, nil, nil)
+	env := NewVMHelperEnv(mockFs, mockCmd)
+
+	require.NoError(t, Reload(context.Background(), env))
+	assert.Equal(t, 2, mockCmd.CallCount(key))
+}
+
 // =============================================================================
 // IsInstalled Tests
 // =============================================================================
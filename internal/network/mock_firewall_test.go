@@ -1,10 +1,6 @@
 package network
 
-import (
-	"context"
-
-	"github.com/bolasblack/alcatraz/internal/network/shared"
-)
+import "context"
 
 // MockFirewall implements Firewall for testing.
 // Provides test configuration fields and call recording.
@@ -20,20 +16,12 @@ type MockFirewall struct {
 	// ========== Call Recording (check after calling) ==========
 
 	// ApplyRulesCalls records all ApplyRules() invocations
-	ApplyRulesCalls []ApplyRulesCall
+	ApplyRulesCalls []RuleSpec
 
 	// CleanupCalls records all Cleanup() invocations
 	CleanupCalls []CleanupCall
 }
 
-// ApplyRulesCall records a call to ApplyRules()
-type ApplyRulesCall struct {
-	ContainerID string
-	ContainerIP string
-	Rules       []shared.LANAccessRule
-	Proxy       *shared.ProxyConfig
-}
-
 // CleanupCall records a call to Cleanup()
 type CleanupCall struct {
 	ContainerID string
@@ -42,13 +30,8 @@ type CleanupCall struct {
 // Compile-time interface assertion.
 var _ Firewall = (*MockFirewall)(nil)
 
-func (m *MockFirewall) ApplyRules(containerID string, containerIP string, rules []LANAccessRule, proxy *ProxyConfig) (*PostCommitAction, error) {
-	m.ApplyRulesCalls = append(m.ApplyRulesCalls, ApplyRulesCall{
-		ContainerID: containerID,
-		ContainerIP: containerIP,
-		Rules:       rules,
-		Proxy:       proxy,
-	})
+func (m *MockFirewall) ApplyRules(spec RuleSpec) (*PostCommitAction, error) {
+	m.ApplyRulesCalls = append(m.ApplyRulesCalls, spec)
 	return &PostCommitAction{}, m.ReturnApplyError
 }
 
@@ -62,3 +45,7 @@ func (m *MockFirewall) Cleanup(containerID string) (*PostCommitAction, error) {
 func (m *MockFirewall) CleanupStaleFiles(_ context.Context) (int, error) {
 	return 0, nil
 }
+
+func (m *MockFirewall) ReloadRules(_ context.Context) error {
+	return nil
+}
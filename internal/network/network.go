@@ -42,15 +42,18 @@ type (
 	LANAccessRule = shared.LANAccessRule
 	// ProxyConfig holds parsed transparent proxy configuration (AGD-037).
 	ProxyConfig = shared.ProxyConfig
+	// RuleSpec describes the firewall rules to apply for one container.
+	RuleSpec = shared.RuleSpec
 )
 
 // Re-export constants from shared package.
 const (
-	TypeNone     = shared.TypeNone
-	TypeNFTables = shared.TypeNFTables
-	ProtoAll     = shared.ProtoAll
-	ProtoTCP     = shared.ProtoTCP
-	ProtoUDP     = shared.ProtoUDP
+	LanAccessWildcard = shared.LanAccessWildcard
+	TypeNone          = shared.TypeNone
+	TypeNFTables      = shared.TypeNFTables
+	ProtoAll          = shared.ProtoAll
+	ProtoTCP          = shared.ProtoTCP
+	ProtoUDP          = shared.ProtoUDP
 )
 
 // Re-export functions from shared package.
@@ -60,6 +63,7 @@ var (
 	ParseLANAccessRule  = shared.ParseLANAccessRule
 	ParseLANAccessRules = shared.ParseLANAccessRules
 	HasAllLAN           = shared.HasAllLAN
+	ReadRuleFilesSudo   = shared.ReadRuleFilesSudo
 )
 
 // Detect returns the available firewall type for the given platform.
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
 )
 
 const alcatrazIncludeLineOnLinux = `include "/etc/nftables.d/alcatraz/*.nft"`
@@ -24,7 +26,7 @@ func (h *nftLinuxHelper) addIncludeLineOnLinux(fs afero.Fs) error {
 	if err != nil {
 		// If file doesn't exist, create it with shebang + include
 		newFile := "#!/usr/sbin/nft -f\n# Alcatraz nftables configuration\n\n" + alcatrazIncludeLineOnLinux + "\n"
-		return afero.WriteFile(fs, nftablesConfPathOnLinux, []byte(newFile), 0644)
+		return util.WriteFileAtomic(fs, nftablesConfPathOnLinux, []byte(newFile), 0644)
 	}
 
 	// Append include line
@@ -34,7 +36,7 @@ func (h *nftLinuxHelper) addIncludeLineOnLinux(fs afero.Fs) error {
 	}
 	newContent += alcatrazIncludeLineOnLinux + "\n"
 
-	return afero.WriteFile(fs, nftablesConfPathOnLinux, []byte(newContent), 0644)
+	return util.WriteFileAtomic(fs, nftablesConfPathOnLinux, []byte(newContent), 0644)
 }
 
 // removeIncludeLineOnLinux removes the alcatraz include line from nftables.conf.
@@ -58,5 +60,5 @@ func (h *nftLinuxHelper) removeIncludeLineOnLinux(fs afero.Fs) error {
 		newContent += "\n"
 	}
 
-	return afero.WriteFile(fs, nftablesConfPathOnLinux, []byte(newContent), 0644)
+	return util.WriteFileAtomic(fs, nftablesConfPathOnLinux, []byte(newContent), 0644)
 }
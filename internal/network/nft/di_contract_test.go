@@ -33,7 +33,7 @@ func TestApplyRules_UsesInjectedFs(t *testing.T) {
 		{IP: "192.168.1.100", Port: 80, Protocol: shared.ProtoTCP},
 	}
 
-	_, err := firewall.ApplyRules("container123", "172.17.0.2", rules, nil)
+	_, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2", Rules: rules})
 	if err != nil {
 		t.Fatalf("ApplyRules failed: %v", err)
 	}
@@ -61,7 +61,7 @@ func TestApplyRules_UsesInjectedCmd(t *testing.T) {
 		{IP: "192.168.1.100", Port: 80, Protocol: shared.ProtoTCP},
 	}
 
-	action, _ := firewall.ApplyRules("container123", "172.17.0.2", rules, nil)
+	action, _ := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2", Rules: rules})
 
 	// Run post-commit action to trigger the nft command
 	if action != nil && action.Run != nil {
@@ -75,8 +75,9 @@ func TestApplyRules_UsesInjectedCmd(t *testing.T) {
 }
 
 // TestApplyRules_CmdReceivesCorrectArgs verifies that the injected Cmd
-// receives the expected arguments for nft -f.
+// checks and then loads the consolidated rule files through nft's stdin.
 func TestApplyRules_CmdReceivesCorrectArgs(t *testing.T) {
+	useTestRuleLock(t)
 	mockFs := afero.NewMemMapFs()
 	mockCmd := util.NewMockCommandRunner().AllowUnexpected()
 	env := shared.NewNetworkEnv(mockFs, mockCmd, "/test/project", "", "")
@@ -86,40 +87,35 @@ func TestApplyRules_CmdReceivesCorrectArgs(t *testing.T) {
 		{IP: "10.0.0.1", Port: 443, Protocol: shared.ProtoTCP},
 	}
 
-	action, _ := firewall.ApplyRules("abc123", "172.17.0.2", rules, nil)
+	action, _ := firewall.ApplyRules(shared.RuleSpec{ContainerID: "abc123", ContainerIP: "172.17.0.2", Rules: rules})
 
 	// Run post-commit action to trigger the nft command
 	if action != nil && action.Run != nil {
 		_ = action.Run(context.Background(), nil)
 	}
 
-	// Find the nft call
-	var nftCall *util.CommandCall
+	// Find the check and the load
+	var checkCall, loadCall *util.CommandCall
 	for i := range mockCmd.Calls {
-		if mockCmd.Calls[i].Name == "sudo nft" {
-			nftCall = &mockCmd.Calls[i]
-			break
+		switch mockCmd.Calls[i].Key {
+		case "sudo nft -c -f -":
+			checkCall = &mockCmd.Calls[i]
+		case "sudo nft -f -":
+			loadCall = &mockCmd.Calls[i]
 		}
 	}
 
-	if nftCall == nil {
-		t.Fatal("Expected nft call")
+	if checkCall == nil || loadCall == nil {
+		t.Fatalf("Expected 'nft -c -f -' and 'nft -f -' calls, got: %v", mockCmd.CallKeys())
 	}
-
-	// Verify arguments: -f <rulepath>
-	if len(nftCall.Args) < 2 {
-		t.Fatalf("Expected 'nft -f <path>', got: nft %v", nftCall.Args)
-	}
-
-	if nftCall.Args[0] != "-f" {
-		t.Errorf("First arg should be '-f', got: %s", nftCall.Args[0])
+	if checkCall.Input != loadCall.Input {
+		t.Error("The loaded script should be the one that was checked")
 	}
 
-	// Should point to the project-path-based rule file
-	rulePath := nftCall.Args[1]
+	// Should carry the project-path-based rule file
 	expectedFileName := nftFileName("/test/project")
-	if !strings.Contains(rulePath, expectedFileName) {
-		t.Errorf("Rule path should contain project-path filename %s, got: %s", expectedFileName, rulePath)
+	if !strings.Contains(loadCall.Input, expectedFileName) || !strings.Contains(loadCall.Input, "table inet alca-abc123") {
+		t.Errorf("Loaded script should contain rule file %s, got:\n%s", expectedFileName, loadCall.Input)
 	}
 }
 
@@ -135,7 +131,7 @@ func TestApplyRules_FsReceivesCorrectContent(t *testing.T) {
 		{IP: "192.168.1.100", Port: 8080, Protocol: shared.ProtoTCP},
 	}
 
-	_, err := firewall.ApplyRules("testcontainer", "172.17.0.2", rules, nil)
+	_, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "testcontainer", ContainerIP: "172.17.0.2", Rules: rules})
 	if err != nil {
 		t.Fatalf("ApplyRules failed: %v", err)
 	}
@@ -361,7 +357,7 @@ func TestApplyRules_WithProxy_WritesProxyDNATRules(t *testing.T) {
 
 	proxy := &shared.ProxyConfig{Host: "10.0.0.1", Port: 1080}
 
-	_, err := firewall.ApplyRules("container123", "172.17.0.2", nil, proxy)
+	_, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2", Proxy: proxy})
 	if err != nil {
 		t.Fatalf("ApplyRules failed: %v", err)
 	}
@@ -396,13 +392,16 @@ func TestApplyRules_ReturnsErrorFromInjectedCmd(t *testing.T) {
 	mockFs := afero.NewMemMapFs()
 	expectedErr := errors.New("nft command failed")
 	mockCmd := util.NewMockCommandRunner()
+	useTestRuleLock(t)
 	// Return error for nft -f command
-	mockCmd.ExpectFailure("sudo nft -f /etc/nftables.d/alcatraz/"+nftFileName("/test/project"), expectedErr)
+	mockCmd.ExpectSuccess("sudo nft -c -f -", nil)
+	mockCmd.ExpectSuccess("sudo nft list tables", nil)
+	mockCmd.ExpectFailure("sudo nft -f -", expectedErr)
 
 	env := shared.NewNetworkEnv(mockFs, mockCmd, "/test/project", "", "")
 	firewall := New(env)
 
-	action, err := firewall.ApplyRules("container123", "172.17.0.2", nil, nil)
+	action, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2"})
 	if err != nil {
 		t.Fatalf("ApplyRules file write phase should not error: %v", err)
 	}
@@ -411,8 +410,36 @@ func TestApplyRules_ReturnsErrorFromInjectedCmd(t *testing.T) {
 	if action != nil && action.Run != nil {
 		err = action.Run(context.Background(), nil)
 	}
-	if err == nil {
-		t.Error("ApplyRules PostCommitAction should propagate errors from the injected Cmd")
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("ApplyRules PostCommitAction should propagate errors from the injected Cmd, got: %v", err)
+	}
+}
+
+// TestApplyRules_RetriesWhenKernelBusy verifies that a rule load colliding
+// with another netlink transaction is retried.
+func TestApplyRules_RetriesWhenKernelBusy(t *testing.T) {
+	orig := nftLoadBackoff
+	nftLoadBackoff = util.Backoff{MaxAttempts: 3}
+	t.Cleanup(func() { nftLoadBackoff = orig })
+
+	useTestRuleLock(t)
+	key := "sudo nft -f -"
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("sudo nft -c -f -", nil)
+	mockCmd.ExpectSuccess("sudo nft list tables", nil)
+	mockCmd.ExpectSequence(key, []byte("netlink: Error: Could not process rule: Device or resource busy"), errors.New("exit status 1"))
+	mockCmd.ExpectSequence(key, nil, nil)
+
+	env := shared.NewNetworkEnv(afero.NewMemMapFs(), mockCmd, "/test/project", "", "")
+	action, err := New(env).ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2"})
+	if err != nil {
+		t.Fatalf("ApplyRules file write phase should not error: %v", err)
+	}
+	if err := action.Run(context.Background(), nil); err != nil {
+		t.Fatalf("PostCommitAction.Run() error = %v, want success after retry", err)
+	}
+	if got := mockCmd.CallCount(key); got != 2 {
+		t.Errorf("nft -f called %d times, want 2", got)
 	}
 }
 
@@ -428,7 +455,7 @@ func TestApplyRules_SkipsWhenAllLAN(t *testing.T) {
 		{AllLAN: true},
 	}
 
-	_, err := firewall.ApplyRules("container123", "172.17.0.2", rules, nil)
+	_, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2", Rules: rules})
 
 	if err != nil {
 		t.Errorf("ApplyRules with AllLAN should not error, got: %v", err)
@@ -460,7 +487,7 @@ func TestApplyRules_CreatesDirViaInjectedFs(t *testing.T) {
 		t.Fatal("Setup error: directory should not exist initially")
 	}
 
-	_, _ = firewall.ApplyRules("container123", "172.17.0.2", nil, nil)
+	_, _ = firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2"})
 
 	// Directory should now exist on mockFs
 	exists, _ = afero.DirExists(mockFs, "/etc/nftables.d/alcatraz")
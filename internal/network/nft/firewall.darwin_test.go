@@ -66,7 +66,7 @@ func TestGenerateRulesetWithPriority(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ruleset := generateRuleset("alca-test", "172.17.0.2", nil, nil, false, tt.priority, "/test/project", "")
+			ruleset := generateRuleset("alca-test", shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, tt.priority, "/test/project", "")
 			if !strings.Contains(ruleset, tt.expected) {
 				t.Errorf("ruleset should contain %q\nGot:\n%s", tt.expected, ruleset)
 			}
@@ -88,7 +88,7 @@ func TestApplyRulesOnDarwin_WritesPerContainerFile(t *testing.T) {
 		{IP: "192.168.1.100", Port: 80, Protocol: shared.ProtoTCP},
 	}
 
-	_, err := firewall.ApplyRules("container123", "172.17.0.2", rules, nil)
+	_, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2", Rules: rules})
 	if err != nil {
 		t.Fatalf("ApplyRules failed: %v", err)
 	}
@@ -111,7 +111,7 @@ func TestApplyRulesOnDarwin_LoadsRuleSynchronously(t *testing.T) {
 	env := shared.NewNetworkEnv(mockFs, mockCmd, "/Users/alice/myproject", "", runtime.PlatformMacOrbStack)
 	firewall := New(env)
 
-	action, _ := firewall.ApplyRules("container123", "172.17.0.2", nil, nil)
+	action, _ := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2"})
 
 	// Run post-commit action to load rules synchronously
 	if action != nil && action.Run != nil {
@@ -147,7 +147,7 @@ func TestApplyRulesOnDarwin_LoadFailsReturnsError(t *testing.T) {
 	env := shared.NewNetworkEnv(mockFs, mockCmd, "/Users/alice/myproject", "", runtime.PlatformMacOrbStack)
 	firewall := New(env)
 
-	action, err := firewall.ApplyRules("container123", "172.17.0.2", nil, nil)
+	action, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2"})
 	if err != nil {
 		t.Fatalf("ApplyRules should not fail (file write phase): %v", err)
 	}
@@ -174,7 +174,7 @@ func TestApplyRulesOnDarwin_WritesPerContainerRuleset(t *testing.T) {
 		{IP: "192.168.1.100", Port: 8080, Protocol: shared.ProtoTCP},
 	}
 
-	_, err := firewall.ApplyRules("container123", "172.17.0.2", rules, nil)
+	_, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2", Rules: rules})
 	if err != nil {
 		t.Fatalf("ApplyRules failed: %v", err)
 	}
@@ -243,7 +243,7 @@ func TestApplyRulesOnDarwin_SkipsWhenAllLAN(t *testing.T) {
 		{AllLAN: true},
 	}
 
-	_, err := firewall.ApplyRules("container123", "172.17.0.2", rules, nil)
+	_, err := firewall.ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "172.17.0.2", Rules: rules})
 	if err != nil {
 		t.Errorf("ApplyRules with AllLAN should not error, got: %v", err)
 	}
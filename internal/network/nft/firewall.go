@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/afero"
 
+	"github.com/bolasblack/alcatraz/internal/network/darwin/podmanmachine"
 	"github.com/bolasblack/alcatraz/internal/network/darwin/vmhelper"
 	"github.com/bolasblack/alcatraz/internal/network/shared"
 	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
 )
 
 // Compile-time interface assertion.
@@ -21,7 +24,8 @@ var _ shared.Firewall = (*NFTables)(nil)
 // Each container gets its own table for isolation and clean teardown (AGD-030).
 type NFTables struct {
 	env         *shared.NetworkEnv
-	vmHelperEnv *vmhelper.VMHelperEnv // pre-constructed for Darwin; nil on Linux
+	vmHelperEnv *vmhelper.VMHelperEnv     // pre-constructed for Darwin; nil on Linux and Podman machine
+	machineEnv  *podmanmachine.MachineEnv // pre-constructed for Podman machine; nil elsewhere
 }
 
 // isDarwin reports whether this instance targets macOS (Darwin).
@@ -31,12 +35,30 @@ func (n *NFTables) isDarwin() bool {
 	return runtime.IsDarwin(n.env.Runtime)
 }
 
+// isPodmanMachine reports whether nft runs in a Podman machine, reached over
+// 'podman machine ssh' instead of the helper container.
+func (n *NFTables) isPodmanMachine() bool {
+	return n.env.Runtime == runtime.PlatformMacPodman
+}
+
 // tableName returns the nftables table name for a container.
 // Uses short container ID prefix to keep names manageable.
 func tableName(containerID string) string {
 	return "alca-" + shared.ShortContainerID(containerID)
 }
 
+// logPrefix returns the kernel log prefix for connections logged by a table.
+// The bracketed table name lets log readers filter by container.
+func logPrefix(table string) string {
+	return "alca[" + table + "] "
+}
+
+// ConnectionLogPrefix returns the kernel log prefix used for a container's
+// connection log entries.
+func ConnectionLogPrefix(containerID string) string {
+	return logPrefix(tableName(containerID))
+}
+
 // nftFileName returns the nft rule filename for a project.
 // Uses the project directory path encoded as a safe filename.
 func nftFileName(projectDir string) string {
@@ -67,8 +89,17 @@ func writeNftAllowRule(sb *strings.Builder, containerIP string, containerIsV6 bo
 
 	base := fmt.Sprintf("\t\t%s saddr %s %s daddr %s", srcIPCmd, containerIP, dstIPCmd, rule.IP)
 
+	// Temporary grants are enforced by the kernel clock, so they stop
+	// matching at the deadline even before alca rewrites this file.
+	// An integer meta time is a UNIX timestamp (nftables >= 0.9.4, kernel >= 5.4).
+	expiry := ""
+	if !rule.Expires.IsZero() {
+		fmt.Fprintf(sb, "\t\t# %s expires %s\n", rule.Key(), rule.Expires.UTC().Format(time.RFC3339))
+		expiry = fmt.Sprintf(" meta time < %d", rule.Expires.Unix())
+	}
+
 	for _, suffix := range formatProtocolSuffixes(rule.Protocol, rule.Port) {
-		sb.WriteString(base + suffix + " accept\n")
+		sb.WriteString(base + suffix + expiry + " accept\n")
 	}
 }
 
@@ -161,21 +192,23 @@ func parseTableName(content string) string {
 }
 
 // ApplyRules creates nftables rules for network isolation and optional transparent proxy.
-// On Linux: persisted to /etc/nftables.d/alcatraz/<container-id>.nft, loaded via `nft -f`.
-// On macOS: persisted to ~/.alcatraz/files/alcatraz_nft/<container-table>.nft, reload via docker exec.
+// On Linux: persisted to /etc/nftables.d/alcatraz/<project>.nft, then all rule files are
+// reloaded together under a lock (see reloadRulesOnLinux).
+// On macOS: persisted to ~/.alcatraz/files/alcatraz_nft/<container-table>.nft, loaded via docker exec
+// (or podman machine ssh).
 // Returns PostCommitAction that MUST be called after TransactFs.Commit().
-func (n *NFTables) ApplyRules(containerID string, containerIP string, rules []shared.LANAccessRule, proxy *shared.ProxyConfig) (*shared.PostCommitAction, error) {
+func (n *NFTables) ApplyRules(spec shared.RuleSpec) (*shared.PostCommitAction, error) {
 	// Call once and store — used for early return and passed to platform-specific methods.
-	allLAN := shared.HasAllLAN(rules)
+	allLAN := shared.HasAllLAN(spec.Rules)
 
-	// If all LAN is allowed and no proxy, skip entirely
-	if allLAN && proxy == nil {
+	// If all LAN is allowed and nothing else needs a rule, skip entirely
+	if allLAN && spec.Proxy == nil && !spec.LogConnections {
 		return &shared.PostCommitAction{}, nil
 	}
 	if n.isDarwin() {
-		return n.applyRulesOnDarwin(containerID, containerIP, rules, proxy, allLAN)
+		return n.applyRulesOnDarwin(spec, allLAN)
 	}
-	return n.applyRulesOnLinux(containerID, containerIP, rules, proxy, allLAN)
+	return n.applyRulesOnLinux(spec, allLAN)
 }
 
 // writeRuleFile creates the directory and writes the ruleset file atomically.
@@ -184,40 +217,47 @@ func writeRuleFile(fs afero.Fs, dir string, fileName string, ruleset string) (st
 		return "", fmt.Errorf("failed to create nft directory %s: %w", dir, err)
 	}
 	rulePath := filepath.Join(dir, fileName)
-	if err := afero.WriteFile(fs, rulePath, []byte(ruleset), 0644); err != nil {
+	if err := util.WriteFileAtomic(fs, rulePath, []byte(ruleset), 0644); err != nil {
 		return "", fmt.Errorf("failed to write ruleset to %s: %w", rulePath, err)
 	}
 	return rulePath, nil
 }
 
 // applyRulesOnLinux applies per-container rules on Linux.
-// Writes the rule file via Fs, returns PostCommitAction to load rules via nft.
-func (n *NFTables) applyRulesOnLinux(containerID string, containerIP string, rules []shared.LANAccessRule, proxy *shared.ProxyConfig, allLAN bool) (*shared.PostCommitAction, error) {
-	table := tableName(containerID)
-	ruleset := generateRuleset(table, containerIP, rules, proxy, allLAN, "filter - 1", n.env.ProjectDir, n.env.ProjectID)
+// Writes the rule file via Fs, returns PostCommitAction to reload all rule
+// files via nft (see reloadRulesOnLinux).
+func (n *NFTables) applyRulesOnLinux(spec shared.RuleSpec, allLAN bool) (*shared.PostCommitAction, error) {
+	table := tableName(spec.ContainerID)
+	ruleset := generateRuleset(table, spec, allLAN, "filter - 1", n.env.ProjectDir, n.env.ProjectID)
 
-	rulePath, err := writeRuleFile(n.env.Fs, nftDirOnLinux(), nftFileName(n.env.ProjectDir), ruleset)
-	if err != nil {
+	if _, err := writeRuleFile(n.env.Fs, nftDirOnLinux(), nftFileName(n.env.ProjectDir), ruleset); err != nil {
 		return nil, err
 	}
 
-	// Post-commit: load ruleset atomically (idempotent format handles existing table)
+	// Post-commit: reload all rule files in one checked transaction
+	// (idempotent format handles existing tables)
 	return &shared.PostCommitAction{
 		Run: func(ctx context.Context, _ shared.ProgressFunc) error {
-			output, err := n.env.Cmd.SudoRunQuiet(ctx, "nft", "-f", rulePath)
-			if err != nil {
-				return fmt.Errorf("failed to load nftables rules from %s for table %s: %w: %s", rulePath, table, err, strings.TrimSpace(string(output)))
-			}
-			return nil
+			return n.reloadRulesOnLinux(ctx)
 		},
 	}, nil
 }
 
+// nftLoadBackoff retries rule loads that collide with another netlink
+// transaction, e.g. a concurrent 'alca up' or a distro firewall reload.
+var nftLoadBackoff = util.Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Jitter: 0.2, MaxAttempts: 5}
+
+// isTransientNftError reports whether nft failed because the kernel was busy.
+func isTransientNftError(output string) bool {
+	return strings.Contains(output, "Device or resource busy") ||
+		strings.Contains(output, "Resource temporarily unavailable")
+}
+
 // applyRulesOnDarwin applies per-container rules on macOS per AGD-030.
 // Writes the rule file via Fs, returns PostCommitAction to load rules synchronously.
-func (n *NFTables) applyRulesOnDarwin(containerID string, containerIP string, rules []shared.LANAccessRule, proxy *shared.ProxyConfig, allLAN bool) (*shared.PostCommitAction, error) {
-	table := tableName(containerID)
-	ruleset := generateRuleset(table, containerIP, rules, proxy, allLAN, chainPriority(n.env.Runtime), n.env.ProjectDir, n.env.ProjectID)
+func (n *NFTables) applyRulesOnDarwin(spec shared.RuleSpec, allLAN bool) (*shared.PostCommitAction, error) {
+	table := tableName(spec.ContainerID)
+	ruleset := generateRuleset(table, spec, allLAN, chainPriority(n.env.Runtime), n.env.ProjectDir, n.env.ProjectID)
 
 	dir, err := nftDirOnDarwin()
 	if err != nil {
@@ -232,9 +272,16 @@ func (n *NFTables) applyRulesOnDarwin(containerID string, containerIP string, ru
 
 	// Post-commit: load rule file synchronously via helper container.
 	// The file is on a volume mount visible inside the container.
+	// A Podman machine has no helper container; the ruleset is piped to nft over ssh.
 	containerRulePath := filepath.Join(shared.NftDirInContainer, fileName)
 	return &shared.PostCommitAction{
 		Run: func(ctx context.Context, _ shared.ProgressFunc) error {
+			if n.isPodmanMachine() {
+				if err := podmanmachine.LoadRules(ctx, n.machineEnv, ruleset); err != nil {
+					return fmt.Errorf("failed to load nft rules into the Podman machine for %s: %w", rulePath, err)
+				}
+				return nil
+			}
 			if err := vmhelper.LoadRuleFile(ctx, n.vmHelperEnv, containerRulePath); err != nil {
 				return fmt.Errorf("failed to load nft rules on darwin for %s: %w", rulePath, err)
 			}
@@ -243,6 +290,19 @@ func (n *NFTables) applyRulesOnDarwin(containerID string, containerIP string, ru
 	}, nil
 }
 
+// ReloadRules reloads every persisted rule file.
+// On Linux: the same locked, checked reload ApplyRules runs.
+// On macOS: the helper reloads its rule directory.
+func (n *NFTables) ReloadRules(ctx context.Context) error {
+	switch {
+	case n.isPodmanMachine():
+		return podmanmachine.Reload(ctx, n.machineEnv)
+	case n.isDarwin():
+		return vmhelper.Reload(ctx, n.vmHelperEnv)
+	}
+	return n.reloadRulesOnLinux(ctx)
+}
+
 // Cleanup removes all firewall rules for a container.
 // On Linux: deletes the persistent rule file and the nftables table.
 // On macOS: removes the per-container .nft file and triggers reload.
@@ -266,6 +326,13 @@ func (n *NFTables) cleanupOnLinux(containerID string) (*shared.PostCommitAction,
 	pTable := proxyTableName(containerID)
 	return &shared.PostCommitAction{
 		Run: func(ctx context.Context, _ shared.ProgressFunc) error {
+			// Don't delete a table while another project's reload is in flight
+			unlock, err := lockRules(ctx)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
 			if err := n.deleteTable(ctx, table); err != nil {
 				return err
 			}
@@ -291,17 +358,26 @@ func (n *NFTables) cleanupOnDarwin(containerID string) (*shared.PostCommitAction
 	pTable := proxyTableFromIsolationTable(table)
 	return &shared.PostCommitAction{
 		Run: func(ctx context.Context, _ shared.ProgressFunc) error {
-			if err := vmhelper.DeleteTable(ctx, n.vmHelperEnv, "inet", table); err != nil {
+			if err := n.deleteTableInVM(ctx, "inet", table); err != nil {
 				return err
 			}
 			if pTable != "" {
-				return vmhelper.DeleteTable(ctx, n.vmHelperEnv, "ip", pTable)
+				return n.deleteTableInVM(ctx, "ip", pTable)
 			}
 			return nil
 		},
 	}, nil
 }
 
+// deleteTableInVM deletes a table inside the macOS VM: over ssh on a Podman
+// machine, through the helper container elsewhere.
+func (n *NFTables) deleteTableInVM(ctx context.Context, family string, table string) error {
+	if n.isPodmanMachine() {
+		return podmanmachine.DeleteTable(ctx, n.machineEnv, family, table)
+	}
+	return vmhelper.DeleteTable(ctx, n.vmHelperEnv, family, table)
+}
+
 // tryDeleteTablesFromContent attempts to delete all nftables tables referenced in a rule file.
 // A single file may contain both an inet isolation table and an ip proxy table.
 // Errors are intentionally ignored (fire-and-forget): during stale cleanup, tables may
@@ -315,10 +391,11 @@ func (n *NFTables) tryDeleteTablesFromContent(ctx context.Context, content strin
 	proxyTable := proxyTableFromIsolationTable(table)
 
 	if n.isDarwin() {
-		// On macOS, nft runs inside the VM — must go through the helper container.
-		_ = vmhelper.DeleteTable(ctx, n.vmHelperEnv, "inet", table)
+		// On macOS, nft runs inside the VM — must go through the helper
+		// container or podman machine ssh.
+		_ = n.deleteTableInVM(ctx, "inet", table)
 		if proxyTable != "" {
-			_ = vmhelper.DeleteTable(ctx, n.vmHelperEnv, "ip", proxyTable)
+			_ = n.deleteTableInVM(ctx, "ip", proxyTable)
 		}
 	} else {
 		// On Linux, nft is available directly on the host.
@@ -345,6 +422,9 @@ func (n *NFTables) deleteTableFamily(ctx context.Context, family string, table s
 		if strings.Contains(combined, "No such file or directory") {
 			return nil
 		}
+		if sudoErr := util.SudoError("removing firewall rules", output, err); sudoErr != err {
+			return sudoErr
+		}
 		return fmt.Errorf("failed to delete table %s %s: %w: %s", family, table, err, strings.TrimSpace(string(output)))
 	}
 	return nil
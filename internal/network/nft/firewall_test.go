@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 
@@ -98,7 +99,7 @@ func TestGenerateRulesetNoRules(t *testing.T) {
 	table := "alca-abc123def456"
 	containerIP := "172.17.0.2"
 
-	ruleset := generateRuleset(table, containerIP, nil, nil, false, "filter - 1", "/test/project", "")
+	ruleset := generateRuleset(table, shared.RuleSpec{ContainerIP: containerIP}, false, "filter - 1", "/test/project", "")
 
 	// Verify idempotent header (shebang and delete pattern)
 	if !strings.Contains(ruleset, "#!/usr/sbin/nft -f") {
@@ -155,7 +156,7 @@ func TestGenerateRulesetWithAllowRules(t *testing.T) {
 		{IP: "10.0.0.0/8", Port: 0, Protocol: shared.ProtoAll, IsIPv6: false},
 	}
 
-	ruleset := generateRuleset(table, containerIP, rules, nil, false, "filter - 1", "/test/project", "")
+	ruleset := generateRuleset(table, shared.RuleSpec{ContainerIP: containerIP, Rules: rules}, false, "filter - 1", "/test/project", "")
 
 	// Verify allow rules are present
 	if !strings.Contains(ruleset, "ip saddr 172.17.0.2 ip daddr 192.168.1.100 tcp dport 8080 accept") {
@@ -187,7 +188,7 @@ func TestGenerateRulesetIPv6Container(t *testing.T) {
 	table := "alca-test"
 	containerIP := "2001:db8::2"
 
-	ruleset := generateRuleset(table, containerIP, nil, nil, false, "filter - 1", "/test/project", "")
+	ruleset := generateRuleset(table, shared.RuleSpec{ContainerIP: containerIP}, false, "filter - 1", "/test/project", "")
 
 	// Verify IPv6 private ranges are blocked
 	if !strings.Contains(ruleset, "ip6 saddr 2001:db8::2 ip6 daddr fe80::/10 drop") {
@@ -248,7 +249,7 @@ func TestGenerateRulesetProtocolVariants(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ruleset := generateRuleset(table, containerIP, []shared.LANAccessRule{tt.rule}, nil, false, "filter - 1", "/test/project", "")
+			ruleset := generateRuleset(table, shared.RuleSpec{ContainerIP: containerIP, Rules: []shared.LANAccessRule{tt.rule}}, false, "filter - 1", "/test/project", "")
 
 			for _, exp := range tt.expected {
 				if !strings.Contains(ruleset, exp) {
@@ -269,7 +270,7 @@ func TestGenerateRulesetSkipsAllLANRule(t *testing.T) {
 		{IP: "10.0.0.1", Port: 443, Protocol: shared.ProtoTCP, IsIPv6: false},
 	}
 
-	ruleset := generateRuleset(table, containerIP, rules, nil, false, "filter - 1", "/test/project", "")
+	ruleset := generateRuleset(table, shared.RuleSpec{ContainerIP: containerIP, Rules: rules}, false, "filter - 1", "/test/project", "")
 
 	// Verify normal rules are present
 	if !strings.Contains(ruleset, "192.168.1.100 tcp dport 8080 accept") {
@@ -291,7 +292,7 @@ func TestGenerateRulesetIPv6AllowRule(t *testing.T) {
 		{IP: "fe80::1", Port: 8080, Protocol: shared.ProtoTCP, IsIPv6: true},
 	}
 
-	ruleset := generateRuleset(table, containerIP, rules, nil, false, "filter - 1", "/test/project", "")
+	ruleset := generateRuleset(table, shared.RuleSpec{ContainerIP: containerIP, Rules: rules}, false, "filter - 1", "/test/project", "")
 
 	// IPv6 container to IPv6 destination
 	if !strings.Contains(ruleset, "ip6 saddr 2001:db8::2 ip6 daddr fe80::1 tcp dport 8080 accept") {
@@ -308,7 +309,7 @@ func TestGenerateRulesetMixedIPVersionAllowRules(t *testing.T) {
 		{IP: "fe80::1", Port: 443, Protocol: shared.ProtoTCP, IsIPv6: true},
 	}
 
-	ruleset := generateRuleset(table, containerIP, rules, nil, false, "filter - 1", "/test/project", "")
+	ruleset := generateRuleset(table, shared.RuleSpec{ContainerIP: containerIP, Rules: rules}, false, "filter - 1", "/test/project", "")
 
 	// IPv4 container to IPv4 destination
 	if !strings.Contains(ruleset, "ip saddr 172.17.0.2 ip daddr 192.168.1.100 tcp dport 8080 accept") {
@@ -600,7 +601,7 @@ func TestNew_VMHelperEnvNilForEmptyPlatform(t *testing.T) {
 // =============================================================================
 
 func TestGenerateRulesetIncludesProjectDir(t *testing.T) {
-	ruleset := generateRuleset("alca-test", "172.17.0.2", nil, nil, false, "filter - 1", "/Users/alice/myproject", "")
+	ruleset := generateRuleset("alca-test", shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, "filter - 1", "/Users/alice/myproject", "")
 
 	if !strings.Contains(ruleset, "# project-dir: /Users/alice/myproject") {
 		t.Errorf("ruleset should contain project-dir comment\nGot:\n%s", ruleset)
@@ -608,7 +609,7 @@ func TestGenerateRulesetIncludesProjectDir(t *testing.T) {
 }
 
 func TestGenerateRulesetIncludesProjectID(t *testing.T) {
-	ruleset := generateRuleset("alca-test", "172.17.0.2", nil, nil, false, "filter - 1", "/test/project", "test-uuid-1234")
+	ruleset := generateRuleset("alca-test", shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, "filter - 1", "/test/project", "test-uuid-1234")
 
 	if !strings.Contains(ruleset, "# project-id: test-uuid-1234") {
 		t.Errorf("ruleset should contain project-id comment\nGot:\n%s", ruleset)
@@ -800,12 +801,12 @@ func TestCleanupStaleFiles(t *testing.T) {
 	existingDir := "/existing/project"
 	_ = mockFs.MkdirAll(existingDir+"/.alca", 0755)
 	_ = afero.WriteFile(mockFs, existingDir+"/.alca/state.json", []byte(`{"project_id":"proj-aaa"}`), 0644)
-	rulesetA := generateRuleset("alca-aaa", "172.17.0.2", nil, nil, false, "filter - 1", existingDir, "proj-aaa")
+	rulesetA := generateRuleset("alca-aaa", shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, "filter - 1", existingDir, "proj-aaa")
 	_ = afero.WriteFile(mockFs, fmt.Sprintf("%s/%s", dir, nftFileName(existingDir)), []byte(rulesetA), 0644)
 
 	// File b: project-dir does NOT exist → should be deleted
 	missingDir := "/missing/project"
-	rulesetB := generateRuleset("alca-bbb", "172.17.0.3", nil, nil, false, "filter - 1", missingDir, "proj-bbb")
+	rulesetB := generateRuleset("alca-bbb", shared.RuleSpec{ContainerIP: "172.17.0.3"}, false, "filter - 1", missingDir, "proj-bbb")
 	_ = afero.WriteFile(mockFs, fmt.Sprintf("%s/%s", dir, nftFileName(missingDir)), []byte(rulesetB), 0644)
 
 	// File c: old format without project-dir comment → should be deleted (stale)
@@ -853,7 +854,7 @@ func TestCleanupStaleFiles_DeletesInMemoryTables(t *testing.T) {
 
 	// File a: stale project — project dir does NOT exist → should be deleted
 	staleDir := "/gone/project1"
-	staleRuleset := generateRuleset("alca-stale1", "172.17.0.2", nil, nil, false, "filter - 1", staleDir, "proj-stale1")
+	staleRuleset := generateRuleset("alca-stale1", shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, "filter - 1", staleDir, "proj-stale1")
 	_ = afero.WriteFile(mockFs, fmt.Sprintf("%s/%s", dir, nftFileName(staleDir)), []byte(staleRuleset), 0644)
 
 	// File b: old-format file without project-dir comment → treated as stale
@@ -888,7 +889,7 @@ func TestCleanupStaleFiles_StateJsonMissing(t *testing.T) {
 	// Dir exists but no .alca/state.json → stale
 	projectDir := "/orphan/project"
 	_ = mockFs.MkdirAll(projectDir, 0755)
-	ruleset := generateRuleset("alca-orphan", "172.17.0.2", nil, nil, false, "filter - 1", projectDir, "some-id")
+	ruleset := generateRuleset("alca-orphan", shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, "filter - 1", projectDir, "some-id")
 	_ = afero.WriteFile(mockFs, fmt.Sprintf("%s/%s", dir, nftFileName(projectDir)), []byte(ruleset), 0644)
 
 	count, err := n.CleanupStaleFiles(context.Background())
@@ -913,7 +914,7 @@ func TestCleanupStaleFiles_ProjectIDMismatch(t *testing.T) {
 	projectDir := "/reused/project"
 	_ = mockFs.MkdirAll(projectDir+"/.alca", 0755)
 	_ = afero.WriteFile(mockFs, projectDir+"/.alca/state.json", []byte(`{"project_id":"new-id"}`), 0644)
-	ruleset := generateRuleset("alca-reused", "172.17.0.2", nil, nil, false, "filter - 1", projectDir, "old-id")
+	ruleset := generateRuleset("alca-reused", shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, "filter - 1", projectDir, "old-id")
 	_ = afero.WriteFile(mockFs, fmt.Sprintf("%s/%s", dir, nftFileName(projectDir)), []byte(ruleset), 0644)
 
 	count, err := n.CleanupStaleFiles(context.Background())
@@ -966,3 +967,110 @@ func TestCleanupStaleFiles_EmptyDir(t *testing.T) {
 		t.Errorf("CleanupStaleFiles() count = %d, want 0", count)
 	}
 }
+
+func TestGenerateRuleset_LogConnections(t *testing.T) {
+	spec := shared.RuleSpec{ContainerIP: "172.17.0.2", LogConnections: true}
+	ruleset := generateRuleset("alca-abc123", spec, false, "filter - 1", "/test/project", "")
+
+	logRule := `ip saddr 172.17.0.2 ct state new log prefix "alca[alca-abc123] " level info`
+	if !strings.Contains(ruleset, logRule) {
+		t.Errorf("ruleset should contain log rule %q:\n%s", logRule, ruleset)
+	}
+	// Logging must come before the block rules, or dropped connections go unlogged
+	if strings.Index(ruleset, logRule) > strings.Index(ruleset, "ip daddr 10.0.0.0/8 drop") {
+		t.Error("log rule should precede the RFC1918 block rules")
+	}
+
+	ruleset = generateRuleset("alca-abc123", shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, "filter - 1", "/test/project", "")
+	if strings.Contains(ruleset, " log ") {
+		t.Error("ruleset should not log when LogConnections is off")
+	}
+}
+
+func TestGenerateRuleset_LogConnectionsIPv6(t *testing.T) {
+	spec := shared.RuleSpec{ContainerIP: "2001:db8::2", LogConnections: true}
+	ruleset := generateRuleset("alca-abc123", spec, false, "filter - 1", "/test/project", "")
+
+	if !strings.Contains(ruleset, `ip6 saddr 2001:db8::2 ct state new log prefix "alca[alca-abc123] "`) {
+		t.Errorf("IPv6 container should get an ip6 log rule:\n%s", ruleset)
+	}
+}
+
+func TestGenerateRuleset_Strict(t *testing.T) {
+	spec := shared.RuleSpec{
+		ContainerIP: "172.17.0.2",
+		Rules:       []shared.LANAccessRule{{IP: "203.0.113.10", Port: 443, Protocol: shared.ProtoTCP}},
+		Proxy:       &shared.ProxyConfig{Host: "192.168.1.1", Port: 3128},
+		Strict:      true,
+	}
+	ruleset := generateRuleset("alca-abc123", spec, false, "filter - 1", "/test/project", "")
+
+	dropAll := "ip saddr 172.17.0.2 drop\n"
+	dropPos := strings.Index(ruleset, dropAll)
+	if dropPos < 0 {
+		t.Fatalf("strict ruleset should drop all remaining egress:\n%s", ruleset)
+	}
+	for _, allowed := range []string{
+		"ip daddr 203.0.113.10 tcp dport 443 accept",
+		"ip daddr 192.168.1.1 tcp dport 3128 accept",
+		"ip daddr 10.0.0.0/8 drop",
+	} {
+		if pos := strings.Index(ruleset, allowed); pos < 0 || pos > dropPos {
+			t.Errorf("%q should precede the drop-all rule:\n%s", allowed, ruleset)
+		}
+	}
+
+	spec.Strict = false
+	if strings.Contains(generateRuleset("alca-abc123", spec, false, "filter - 1", "/test/project", ""), dropAll) {
+		t.Error("non-strict ruleset should not drop all egress")
+	}
+}
+
+func TestApplyRules_LogConnectionsWithAllLAN(t *testing.T) {
+	mockFs := afero.NewMemMapFs()
+	env := shared.NewNetworkEnv(mockFs, util.NewMockCommandRunner().AllowUnexpected(), "/test/project", "", "")
+	firewall := New(env)
+
+	_, err := firewall.ApplyRules(shared.RuleSpec{
+		ContainerID:    "container123",
+		ContainerIP:    "172.17.0.2",
+		Rules:          []shared.LANAccessRule{{AllLAN: true}},
+		LogConnections: true,
+	})
+	if err != nil {
+		t.Fatalf("ApplyRules failed: %v", err)
+	}
+
+	content, err := afero.ReadFile(mockFs, "/etc/nftables.d/alcatraz/"+nftFileName("/test/project"))
+	if err != nil {
+		t.Fatalf("logging with lan-access = [\"*\"] should still write a rule file: %v", err)
+	}
+	if !strings.Contains(string(content), "log prefix") {
+		t.Error("rule file should contain the log rule")
+	}
+	if strings.Contains(string(content), "drop\n") {
+		t.Error("lan-access = [\"*\"] should not add block rules")
+	}
+}
+
+func TestConnectionLogPrefix(t *testing.T) {
+	if got := ConnectionLogPrefix("abc123def456789"); got != "alca["+tableName("abc123def456789")+"] " {
+		t.Errorf("ConnectionLogPrefix() = %q", got)
+	}
+}
+
+func TestGenerateRuleset_ExpiringRule(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	rules := []shared.LANAccessRule{
+		{Raw: "192.168.1.50:5432 ttl=2h", IP: "192.168.1.50", Port: 5432, Protocol: shared.ProtoTCP, Expires: expires},
+	}
+	ruleset := generateRuleset("alca-abc123", shared.RuleSpec{ContainerIP: "172.17.0.2", Rules: rules}, false, "filter - 1", "/test/project", "")
+
+	if !strings.Contains(ruleset, "# 192.168.1.50:5432 expires 2030-01-02T03:04:05Z") {
+		t.Errorf("ruleset should annotate the expiry:\n%s", ruleset)
+	}
+	want := fmt.Sprintf("ip saddr 172.17.0.2 ip daddr 192.168.1.50 tcp dport 5432 meta time < %d accept", expires.Unix())
+	if !strings.Contains(ruleset, want) {
+		t.Errorf("ruleset should contain %q:\n%s", want, ruleset)
+	}
+}
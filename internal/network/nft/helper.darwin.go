@@ -3,10 +3,6 @@ package nft
 import (
 	"context"
 	"fmt"
-	"path/filepath"
-	"strings"
-
-	"github.com/spf13/afero"
 
 	"github.com/bolasblack/alcatraz/internal/network/darwin/vmhelper"
 	"github.com/bolasblack/alcatraz/internal/network/shared"
@@ -66,38 +62,23 @@ func (h *nftDarwinHelper) HelperStatus(ctx context.Context, env *shared.NetworkE
 }
 
 func (h *nftDarwinHelper) DetailedStatus(env *shared.NetworkEnv) shared.DetailedStatusInfo {
-	info := shared.DetailedStatusInfo{}
+	return darwinRuleDirStatus(env)
+}
 
-	// List rule files in macOS nft directory.
-	// Errors are intentionally ignored: the directory may not exist if the helper
-	// has not been installed yet, and this function is for display purposes only.
+// darwinRuleDirStatus lists the rule files of the macOS rule directory.
+func darwinRuleDirStatus(env *shared.NetworkEnv) shared.DetailedStatusInfo {
 	nftDirPath, err := nftDirOnDarwin()
 	if err != nil {
-		return info
-	}
-
-	files, err := afero.ReadDir(env.Fs, nftDirPath)
-	if err != nil {
-		return info
+		return shared.DetailedStatusInfo{ReadErr: err}
 	}
 
-	for _, f := range files {
-		if f.IsDir() || !strings.HasSuffix(f.Name(), ".nft") {
-			continue
-		}
-		path := filepath.Join(nftDirPath, f.Name())
-		// Skip unreadable files rather than failing the entire status display.
-		content, err := afero.ReadFile(env.Fs, path)
-		if err != nil {
-			continue
-		}
-		info.RuleFiles = append(info.RuleFiles, shared.RuleFileInfo{
-			Name:    f.Name(),
-			Content: string(content),
-		})
+	// The directory may not exist if the helper has not been installed yet.
+	files, err := shared.ReadRuleFiles(env.Fs, nftDirPath)
+	return shared.DetailedStatusInfo{
+		RuleFiles: files,
+		RuleDir:   nftDirPath,
+		ReadErr:   err,
 	}
-
-	return info
 }
 
 func (h *nftDarwinHelper) InstallHelper(env *shared.NetworkEnv, progress shared.ProgressFunc) (*shared.PostCommitAction, error) {
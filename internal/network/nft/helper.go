@@ -7,9 +7,13 @@ import (
 )
 
 // NewHelperForProject creates a platform-specific NetworkHelper based on the runtime platform.
-// Returns non-nil when network helper is needed: lan-access rules or proxy configured.
+// Returns non-nil when network helper is needed: lan-access rules, host
+// access, proxy, connection logging or strict isolation configured.
 func NewHelperForProject(cfg config.Network, platform runtime.RuntimePlatform) shared.NetworkHelper {
-	if !hasLANAccess(cfg.LANAccess) && cfg.Proxy == "" {
+	if cfg.IsolationLevel() == config.IsolationNone {
+		return nil
+	}
+	if !hasLANAccess(cfg.LANAccess) && !cfg.AllowHostAccess && cfg.Proxy == "" && !cfg.LogConnections && cfg.IsolationLevel() != config.IsolationStrict {
 		return nil
 	}
 	return NewHelperForSystem(platform)
@@ -19,6 +23,9 @@ func NewHelperForProject(cfg config.Network, platform runtime.RuntimePlatform) s
 // (install/uninstall/status). Unlike NewHelperForProject, this does not check LANAccess
 // config — system operations are platform-level, not project-level.
 func NewHelperForSystem(platform runtime.RuntimePlatform) shared.NetworkHelper {
+	if platform == runtime.PlatformMacPodman {
+		return NewPodmanMachineHelper()
+	}
 	if runtime.IsDarwin(platform) {
 		return NewDarwinHelper(platform)
 	}
@@ -52,35 +52,15 @@ func (h *nftLinuxHelper) HelperStatus(_ context.Context, env *shared.NetworkEnv)
 }
 
 func (h *nftLinuxHelper) DetailedStatus(env *shared.NetworkEnv) shared.DetailedStatusInfo {
-	fs := env.Fs
-
-	info := shared.DetailedStatusInfo{}
-
-	// List rule files in alcatraz directory.
-	// Errors are intentionally ignored: the directory may not exist if the helper
-	// has not been installed yet, and this function is for display purposes only.
-	files, err := afero.ReadDir(fs, alcatrazNftDirOnLinux)
-	if err != nil {
-		return info
+	// The directory may not exist if the helper has not been installed yet.
+	// Rule files are written by root, so reading them may need sudo; the
+	// error is kept so status can say so instead of showing no rules.
+	files, err := shared.ReadRuleFiles(env.Fs, alcatrazNftDirOnLinux)
+	return shared.DetailedStatusInfo{
+		RuleFiles: files,
+		RuleDir:   alcatrazNftDirOnLinux,
+		ReadErr:   err,
 	}
-
-	for _, f := range files {
-		if f.IsDir() || !strings.HasSuffix(f.Name(), ".nft") {
-			continue
-		}
-		path := filepath.Join(alcatrazNftDirOnLinux, f.Name())
-		// Skip unreadable files rather than failing the entire status display.
-		content, err := afero.ReadFile(fs, path)
-		if err != nil {
-			continue
-		}
-		info.RuleFiles = append(info.RuleFiles, shared.RuleFileInfo{
-			Name:    f.Name(),
-			Content: string(content),
-		})
-	}
-
-	return info
 }
 
 func (h *nftLinuxHelper) InstallHelper(env *shared.NetworkEnv, progress shared.ProgressFunc) (*shared.PostCommitAction, error) {
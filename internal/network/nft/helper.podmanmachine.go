@@ -0,0 +1,89 @@
+package nft
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bolasblack/alcatraz/internal/network/darwin/podmanmachine"
+	"github.com/bolasblack/alcatraz/internal/network/shared"
+)
+
+// nftPodmanMachineHelper implements shared.NetworkHelper for a Podman machine
+// on macOS. Rules are loaded over 'podman machine ssh' and reloaded at boot
+// by a systemd unit in the machine, instead of by a helper container.
+type nftPodmanMachineHelper struct{}
+
+// Compile-time interface assertion.
+var _ shared.NetworkHelper = (*nftPodmanMachineHelper)(nil)
+
+// NewPodmanMachineHelper creates a NetworkHelper for a Podman machine on macOS.
+func NewPodmanMachineHelper() shared.NetworkHelper {
+	return &nftPodmanMachineHelper{}
+}
+
+func (h *nftPodmanMachineHelper) Setup(env *shared.NetworkEnv, projectDir string, progress shared.ProgressFunc) (*shared.PostCommitAction, error) {
+	// Per-container rules are applied via Firewall.ApplyRules.
+	return &shared.PostCommitAction{}, nil
+}
+
+func (h *nftPodmanMachineHelper) Teardown(env *shared.NetworkEnv, projectDir string) error {
+	// No-op: per-container .nft files are cleaned up via Firewall.Cleanup().
+	return nil
+}
+
+func (h *nftPodmanMachineHelper) HelperStatus(ctx context.Context, env *shared.NetworkEnv) shared.HelperStatus {
+	machineEnv := podmanmachine.NewMachineEnv(env.Cmd)
+
+	installed, err := podmanmachine.IsInstalled(ctx, machineEnv)
+	if err != nil {
+		return shared.HelperStatus{Installed: false}
+	}
+
+	needsUpdate := false
+	if installed {
+		if dir, err := nftDirOnDarwin(); err == nil {
+			needsUpdate, _ = podmanmachine.NeedsUpdate(ctx, machineEnv, dir)
+		}
+	}
+
+	return shared.HelperStatus{
+		Installed:   installed,
+		NeedsUpdate: needsUpdate,
+	}
+}
+
+func (h *nftPodmanMachineHelper) DetailedStatus(env *shared.NetworkEnv) shared.DetailedStatusInfo {
+	return darwinRuleDirStatus(env)
+}
+
+func (h *nftPodmanMachineHelper) InstallHelper(env *shared.NetworkEnv, progress shared.ProgressFunc) (*shared.PostCommitAction, error) {
+	progress = shared.SafeProgress(progress)
+
+	dir, err := nftDirOnDarwin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine nft directory: %w", err)
+	}
+
+	// The boot unit loads the files in this directory; create it pre-commit.
+	progress("Creating rule directory...\n")
+	if err := env.Fs.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create nft directory %s: %w", dir, err)
+	}
+
+	machineEnv := podmanmachine.NewMachineEnv(env.Cmd)
+	return &shared.PostCommitAction{
+		Run: func(ctx context.Context, progress shared.ProgressFunc) error {
+			return podmanmachine.InstallHelper(ctx, machineEnv, dir, progress)
+		},
+	}, nil
+}
+
+func (h *nftPodmanMachineHelper) UninstallHelper(env *shared.NetworkEnv, _ shared.ProgressFunc) (*shared.PostCommitAction, error) {
+	machineEnv := podmanmachine.NewMachineEnv(env.Cmd)
+
+	return &shared.PostCommitAction{
+		Run: func(ctx context.Context, progress shared.ProgressFunc) error {
+			return podmanmachine.UninstallHelper(ctx, machineEnv, progress)
+		},
+	}, nil
+}
@@ -0,0 +1,145 @@
+package nft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/network/darwin/podmanmachine"
+	"github.com/bolasblack/alcatraz/internal/network/shared"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func newTestPodmanMachineEnv(cmd util.CommandRunner) *shared.NetworkEnv {
+	return shared.NewNetworkEnv(afero.NewMemMapFs(), cmd, "/Users/alice/myproject", "", runtime.PlatformMacPodman)
+}
+
+// =============================================================================
+// Factory Tests
+// =============================================================================
+
+func TestNewHelperForProject_ReturnsPodmanMachineHelper(t *testing.T) {
+	cfg := config.Network{LANAccess: []string{"192.168.1.0/24"}}
+	helper := NewHelperForProject(cfg, runtime.PlatformMacPodman)
+
+	_, ok := helper.(*nftPodmanMachineHelper)
+	assert.True(t, ok, "a Podman machine should get the Podman machine helper, got %T", helper)
+}
+
+// =============================================================================
+// Firewall Tests
+// =============================================================================
+
+func TestApplyRulesOnPodmanMachine_LoadsRulesetOverSSH(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("podman machine ssh sudo nft -f -", nil)
+	env := newTestPodmanMachineEnv(mockCmd)
+
+	action, err := New(env).ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "10.88.0.2"})
+	require.NoError(t, err)
+	require.NoError(t, action.Run(context.Background(), nil))
+	mockCmd.AssertAllExpectationsMet(t)
+
+	// The persisted file and the loaded ruleset are the same
+	dir, _ := nftDirOnDarwin()
+	content, err := afero.ReadFile(env.Fs, dir+"/"+nftFileName("/Users/alice/myproject"))
+	require.NoError(t, err)
+	assert.Equal(t, string(content), mockCmd.Calls[0].Input)
+	assert.Contains(t, string(content), "priority filter - 1")
+}
+
+func TestApplyRulesOnPodmanMachine_LoadFailsReturnsError(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectFailure("podman machine ssh sudo nft -f -", assert.AnError)
+	env := newTestPodmanMachineEnv(mockCmd)
+
+	action, err := New(env).ApplyRules(shared.RuleSpec{ContainerID: "container123", ContainerIP: "10.88.0.2"})
+	require.NoError(t, err)
+	err = action.Run(context.Background(), nil)
+	assert.True(t, errors.Is(err, podmanmachine.ErrRuleLoad), "got: %v", err)
+}
+
+func TestCleanupOnPodmanMachine_DeletesTablesOverSSH(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	table := tableName("container123")
+	mockCmd.ExpectSuccess("podman machine ssh sudo nft delete table inet "+table, nil)
+	mockCmd.ExpectSuccess("podman machine ssh sudo nft delete table ip "+proxyTableFromIsolationTable(table), nil)
+	env := newTestPodmanMachineEnv(mockCmd)
+
+	action, err := New(env).Cleanup("container123")
+	require.NoError(t, err)
+	require.NoError(t, action.Run(context.Background(), nil))
+	mockCmd.AssertAllExpectationsMet(t)
+}
+
+func TestCleanupStaleFilesOnPodmanMachine_DeletesTablesOverSSH(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("podman machine ssh sudo nft delete table inet alca-stale", nil)
+	mockCmd.ExpectSuccess("podman machine ssh sudo nft delete table ip alca-proxy-stale", nil)
+	env := newTestPodmanMachineEnv(mockCmd)
+
+	dir, _ := nftDirOnDarwin()
+	staleDir := "/Users/alice/deleted-project"
+	staleRuleset := generateRuleset("alca-stale", shared.RuleSpec{ContainerIP: "10.88.0.2"}, false, "filter - 1", staleDir, "stale-uuid")
+	_ = afero.WriteFile(env.Fs, fmt.Sprintf("%s/%s", dir, nftFileName(staleDir)), []byte(staleRuleset), 0644)
+
+	count, err := New(env).(*NFTables).CleanupStaleFiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	mockCmd.AssertAllExpectationsMet(t)
+	mockCmd.AssertNotCalled(t, "docker exec alcatraz-network-helper nsenter -t 1 -m -u -n -i nft delete table inet alca-stale")
+}
+
+// =============================================================================
+// Helper Tests
+// =============================================================================
+
+func TestPodmanMachineInstallHelper_CreatesRuleDirAndInstallsUnit(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("podman machine inspect --format {{.Rootful}}", []byte("true\n"))
+	mockCmd.ExpectSuccess("podman machine ssh sudo tee /etc/systemd/system/"+podmanmachine.UnitName, nil)
+	mockCmd.ExpectSuccess("podman machine ssh sudo systemctl daemon-reload", nil)
+	mockCmd.ExpectSuccess("podman machine ssh sudo systemctl enable "+podmanmachine.UnitName, nil)
+	mockCmd.ExpectSuccess("podman machine ssh sudo systemctl restart "+podmanmachine.UnitName, nil)
+	env := newTestPodmanMachineEnv(mockCmd)
+
+	action, err := NewPodmanMachineHelper().InstallHelper(env, nil)
+	require.NoError(t, err)
+
+	dir, _ := nftDirOnDarwin()
+	exists, _ := afero.DirExists(env.Fs, dir)
+	assert.True(t, exists, "InstallHelper must create the rule directory pre-commit")
+	assert.Empty(t, mockCmd.Calls, "nothing runs in the machine before commit")
+
+	require.NoError(t, action.Run(context.Background(), nil))
+	mockCmd.AssertAllExpectationsMet(t)
+	assert.Contains(t, mockCmd.Calls[1].Input, "RequiresMountsFor="+dir)
+}
+
+func TestPodmanMachineHelperStatus(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("podman machine ssh sudo systemctl is-enabled "+podmanmachine.UnitName, []byte("enabled\n"))
+	mockCmd.ExpectSuccess("podman machine ssh sudo cat /etc/systemd/system/"+podmanmachine.UnitName, []byte("[Unit]\nold\n"))
+	env := newTestPodmanMachineEnv(mockCmd)
+
+	status := NewPodmanMachineHelper().HelperStatus(context.Background(), env)
+	assert.True(t, status.Installed)
+	assert.True(t, status.NeedsUpdate, "a unit differing from the current one needs an update")
+}
+
+func TestPodmanMachineSetupAndTeardown_AreNoOps(t *testing.T) {
+	env := newTestPodmanMachineEnv(util.NewMockCommandRunner())
+	helper := NewPodmanMachineHelper()
+
+	action, err := helper.Setup(env, "/Users/alice/myproject", nil)
+	require.NoError(t, err)
+	assert.Nil(t, action.Run)
+	assert.NoError(t, helper.Teardown(env, "/Users/alice/myproject"))
+}
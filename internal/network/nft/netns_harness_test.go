@@ -0,0 +1,236 @@
+//go:build linux && integration
+
+package nft
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"golang.org/x/sys/unix"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// netnsLab is a routed test network built from network namespaces, standing
+// in for a container host: traffic from the container namespace to the lan
+// and wan namespaces crosses the router namespace's forward hook, where the
+// generated rulesets are loaded with the real nft binary.
+//
+//	container 172.30.0.2 ─┐
+//	                      router ─── lan 192.168.77.2, 192.168.77.3
+//	                      └───────── wan 198.51.100.2
+//
+// Needs root, iproute2 and nftables; tests skip when any is missing.
+type netnsLab struct {
+	prefix string
+	cmd    util.CommandRunner
+	fs     afero.Fs
+}
+
+// Namespace roles of a netnsLab.
+const (
+	nsContainer = "container"
+	nsRouter    = "router"
+	nsLAN       = "lan"
+	nsWAN       = "wan"
+)
+
+// Addresses of a netnsLab.
+const (
+	labContainerIP = "172.30.0.2"
+	labLANIP       = "192.168.77.2"
+	labProxyIP     = "192.168.77.3"
+	labWANIP       = "198.51.100.2"
+)
+
+// labDialTimeout bounds a connection attempt; a dropped SYN shows up as a
+// timeout, so it also bounds how long a blocked check takes.
+const labDialTimeout = 500 * time.Millisecond
+
+// newNetnsLab builds the test network and removes it when the test ends.
+func newNetnsLab(t *testing.T) *netnsLab {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("netns firewall tests need root")
+	}
+	for _, bin := range []string{"ip", "nft"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("netns firewall tests need %s: %v", bin, err)
+		}
+	}
+
+	l := &netnsLab{
+		prefix: fmt.Sprintf("alca-test-%d-", os.Getpid()),
+		cmd:    util.NewCommandRunner(),
+		fs:     afero.NewOsFs(),
+	}
+	for _, ns := range []string{nsContainer, nsRouter, nsLAN, nsWAN} {
+		l.run(t, "ip", "netns", "add", l.ns(ns))
+		t.Cleanup(func() { _, _ = l.cmd.RunQuiet(context.Background(), "ip", "netns", "del", l.ns(ns)) })
+		l.exec(t, ns, "ip", "link", "set", "lo", "up")
+	}
+
+	l.link(t, nsContainer, labContainerIP+"/24", "172.30.0.1/24")
+	l.link(t, nsLAN, labLANIP+"/24", "192.168.77.1/24")
+	l.exec(t, nsLAN, "ip", "addr", "add", labProxyIP+"/24", "dev", "eth0")
+	l.link(t, nsWAN, labWANIP+"/24", "198.51.100.1/24")
+	l.inNetns(t, nsRouter, func() error {
+		return afero.WriteFile(l.fs, "/proc/sys/net/ipv4/ip_forward", []byte("1"), 0644)
+	})
+	return l
+}
+
+// ns returns the system-wide name of a lab namespace.
+func (l *netnsLab) ns(role string) string {
+	return l.prefix + role
+}
+
+// link connects a leaf namespace to the router with a veth pair: eth0 in
+// the leaf with leafAddr, and a port named after the leaf in the router
+// with routerAddr, which becomes the leaf's default gateway.
+func (l *netnsLab) link(t *testing.T, leaf, leafAddr, routerAddr string) {
+	l.run(t, "ip", "link", "add", "eth0", "netns", l.ns(leaf), "type", "veth", "peer", "name", leaf, "netns", l.ns(nsRouter))
+	l.exec(t, leaf, "ip", "addr", "add", leafAddr, "dev", "eth0")
+	l.exec(t, leaf, "ip", "link", "set", "eth0", "up")
+	l.exec(t, nsRouter, "ip", "addr", "add", routerAddr, "dev", leaf)
+	l.exec(t, nsRouter, "ip", "link", "set", leaf, "up")
+	gateway, _, _ := strings.Cut(routerAddr, "/")
+	l.exec(t, leaf, "ip", "route", "add", "default", "via", gateway)
+}
+
+// run runs a command on the host, failing the test on error.
+func (l *netnsLab) run(t *testing.T, name string, args ...string) {
+	t.Helper()
+	if out, err := l.cmd.RunQuiet(context.Background(), name, args...); err != nil {
+		t.Fatalf("%s %s: %v: %s", name, strings.Join(args, " "), err, out)
+	}
+}
+
+// exec runs a command in a lab namespace, failing the test on error.
+func (l *netnsLab) exec(t *testing.T, role string, args ...string) {
+	t.Helper()
+	l.run(t, "ip", append([]string{"netns", "exec", l.ns(role)}, args...)...)
+}
+
+// resetRuleset removes every table from the router namespace.
+func (l *netnsLab) resetRuleset(t *testing.T) {
+	t.Helper()
+	l.exec(t, nsRouter, "nft", "flush", "ruleset")
+}
+
+// loadRuleset loads an nft ruleset into the router namespace.
+func (l *netnsLab) loadRuleset(t *testing.T, ruleset string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ruleset.nft")
+	if err := afero.WriteFile(l.fs, path, []byte(ruleset), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l.exec(t, nsRouter, "nft", "-f", path)
+}
+
+// inNetns runs fn on an OS thread switched into a lab namespace. Sockets
+// created by fn stay in that namespace after it returns.
+func (l *netnsLab) inNetns(t *testing.T, role string, fn func() error) {
+	t.Helper()
+	errc := make(chan error, 1)
+	go func() {
+		// The thread is never unlocked, so it exits with the goroutine
+		// instead of returning to the scheduler in another namespace.
+		goruntime.LockOSThread()
+		f, err := os.Open(filepath.Join("/run/netns", l.ns(role))) //nolint:fslint // setns needs the namespace file's descriptor
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer func() { _ = f.Close() }()
+		if err := unix.Setns(int(f.Fd()), unix.CLONE_NEWNET); err != nil {
+			errc <- fmt.Errorf("setns %s: %w", role, err)
+			return
+		}
+		errc <- fn()
+	}()
+	if err := <-errc; err != nil {
+		t.Fatalf("in netns %s: %v", role, err)
+	}
+}
+
+// listen serves TCP on addr in a lab namespace. Every connection is greeted
+// with name, so a dial can tell which listener answered (after a DNAT).
+func (l *netnsLab) listen(t *testing.T, role, addr, name string) {
+	t.Helper()
+	var ln net.Listener
+	l.inNetns(t, role, func() error {
+		var err error
+		ln, err = net.Listen("tcp", addr)
+		return err
+	})
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = fmt.Fprintln(conn, name)
+			_ = conn.Close()
+		}
+	}()
+}
+
+// dial connects to addr from the container namespace and returns the
+// greeting of the listener that answered.
+func (l *netnsLab) dial(t *testing.T, addr string) (greeting string, err error) {
+	t.Helper()
+	l.inNetns(t, nsContainer, func() error {
+		conn, dialErr := net.DialTimeout("tcp", addr, labDialTimeout)
+		if dialErr != nil {
+			err = dialErr
+			return nil
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.SetReadDeadline(time.Now().Add(labDialTimeout))
+		greeting, err = bufio.NewReader(conn).ReadString('\n')
+		greeting = strings.TrimSpace(greeting)
+		return nil
+	})
+	return greeting, err
+}
+
+// assertReachable fails unless the container reaches the listener named want at addr.
+func (l *netnsLab) assertReachable(t *testing.T, addr, want string) {
+	t.Helper()
+	got, err := l.dial(t, addr)
+	if err != nil {
+		t.Errorf("dial %s: %v, want it reachable", addr, err)
+		return
+	}
+	if got != want {
+		t.Errorf("dial %s answered by %q, want %q", addr, got, want)
+	}
+}
+
+// assertBlocked fails unless the container's connection to addr times out,
+// which is how a dropped SYN looks.
+func (l *netnsLab) assertBlocked(t *testing.T, addr string) {
+	t.Helper()
+	got, err := l.dial(t, addr)
+	if err == nil {
+		t.Errorf("dial %s answered by %q, want it blocked", addr, got)
+		return
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("dial %s: %v, want a timeout (dropped)", addr, err)
+	}
+}
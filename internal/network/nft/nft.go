@@ -1,10 +1,12 @@
 // Package nft implements network isolation using nftables.
 // On Linux: per-container tables via direct nft execution (AGD-027).
-// On macOS: per-project rules via VM nftables (AGD-030).
+// On macOS: per-project rules via VM nftables (AGD-030), loaded by a helper
+// container or, on a Podman machine, over 'podman machine ssh'.
 // See AGD-028 for the lan-access rule syntax specification.
 package nft
 
 import (
+	"github.com/bolasblack/alcatraz/internal/network/darwin/podmanmachine"
 	"github.com/bolasblack/alcatraz/internal/network/darwin/vmhelper"
 	"github.com/bolasblack/alcatraz/internal/network/shared"
 	"github.com/bolasblack/alcatraz/internal/runtime"
@@ -12,9 +14,12 @@ import (
 
 // New creates a new NFTables firewall instance.
 func New(env *shared.NetworkEnv) shared.Firewall {
-	var vmHelperEnv *vmhelper.VMHelperEnv
-	if runtime.IsDarwin(env.Runtime) {
-		vmHelperEnv = vmhelper.NewVMHelperEnv(env.Fs, env.Cmd)
+	n := &NFTables{env: env}
+	switch {
+	case env.Runtime == runtime.PlatformMacPodman:
+		n.machineEnv = podmanmachine.NewMachineEnv(env.Cmd)
+	case runtime.IsDarwin(env.Runtime):
+		n.vmHelperEnv = vmhelper.NewVMHelperEnv(env.Fs, env.Cmd)
 	}
-	return &NFTables{env: env, vmHelperEnv: vmHelperEnv}
+	return n
 }
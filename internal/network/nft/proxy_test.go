@@ -31,9 +31,8 @@ func TestGenerateRuleset_WithProxy(t *testing.T) {
 	proxy := &shared.ProxyConfig{Host: "172.17.0.1", Port: 1080}
 	ruleset := generateRuleset(
 		"alca-abc123",
-		"172.17.0.2",
-		nil,
-		proxy, false,
+		shared.RuleSpec{ContainerIP: "172.17.0.2", Proxy: proxy},
+		false,
 		"filter - 1",
 		"/home/user/project",
 		"test-project-id",
@@ -69,9 +68,8 @@ func TestGenerateRuleset_WithProxy(t *testing.T) {
 func TestGenerateRuleset_WithoutProxy(t *testing.T) {
 	ruleset := generateRuleset(
 		"alca-abc123",
-		"172.17.0.2",
-		nil,
-		nil, false,
+		shared.RuleSpec{ContainerIP: "172.17.0.2"},
+		false,
 		"filter - 1",
 		"/test",
 		"id",
@@ -97,9 +95,8 @@ func TestGenerateRuleset_WithProxyIPv6ContainerNoProxyDNAT(t *testing.T) {
 	proxy := &shared.ProxyConfig{Host: "172.17.0.1", Port: 1080}
 	ruleset := generateRuleset(
 		"alca-v6test",
-		"2001:db8::2",
-		nil,
-		proxy, false,
+		shared.RuleSpec{ContainerIP: "2001:db8::2", Proxy: proxy},
+		false,
 		"filter - 1",
 		"/home/user/project",
 		"test-project-id",
@@ -119,9 +116,8 @@ func TestGenerateRuleset_WithProxy_AutoAllowsProxyInFilterTable(t *testing.T) {
 	proxy := &shared.ProxyConfig{Host: "192.168.1.100", Port: 1080}
 	ruleset := generateRuleset(
 		"alca-test",
-		"172.17.0.2",
-		nil,
-		proxy, false,
+		shared.RuleSpec{ContainerIP: "172.17.0.2", Proxy: proxy},
+		false,
 		"filter - 1",
 		"/test",
 		"id",
@@ -145,9 +141,8 @@ func TestGenerateRuleset_WithProxyAndAllLAN(t *testing.T) {
 	}
 	ruleset := generateRuleset(
 		"alca-abc123",
-		"172.17.0.2",
-		rules,
-		proxy, true,
+		shared.RuleSpec{ContainerIP: "172.17.0.2", Rules: rules, Proxy: proxy},
+		true,
 		"filter - 1",
 		"/home/user/project",
 		"test-project-id",
@@ -176,9 +171,8 @@ func TestGenerateRuleset_WithProxyAndRules(t *testing.T) {
 
 	ruleset := generateRuleset(
 		"alca-test",
-		"172.17.0.2",
-		rules,
-		proxy, false,
+		shared.RuleSpec{ContainerIP: "172.17.0.2", Rules: rules, Proxy: proxy},
+		false,
 		"filter - 1",
 		"/test",
 		"id",
@@ -0,0 +1,253 @@
+package nft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/bolasblack/alcatraz/internal/network/shared"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ruleLockPath serializes rule reloads between concurrent alca processes.
+// It lives in the temp directory rather than the root-owned rule directory,
+// since alca itself runs unprivileged and only nft goes through sudo.
+var ruleLockPath = filepath.Join(os.TempDir(), "alcatraz-nft.lock")
+
+// ruleLockPoll is how often a waiting reload retries the lock.
+const ruleLockPoll = 50 * time.Millisecond
+
+// ErrRuleCheck is returned when the consolidated rule files fail 'nft -c'.
+// Nothing is applied in that case.
+var ErrRuleCheck = errors.New("nft: rule files failed validation")
+
+// lockRules takes an exclusive lock on ruleLockPath, waiting until it is
+// free or ctx is done. The returned function releases the lock.
+func lockRules(ctx context.Context) (func(), error) {
+	// Open an existing lock read-only first: with fs.protected_regular,
+	// O_CREAT on another user's file in a sticky directory is refused.
+	f, err := os.Open(ruleLockPath) //nolint:fslint // flock needs a real file descriptor
+	if errors.Is(err, fs.ErrNotExist) {
+		f, err = os.OpenFile(ruleLockPath, os.O_RDONLY|os.O_CREATE, 0644) //nolint:fslint // flock needs a real file descriptor
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rule lock %s: %w", ruleLockPath, err)
+	}
+
+	fd := int(f.Fd())
+	for {
+		err := unix.Flock(fd, unix.LOCK_EX|unix.LOCK_NB)
+		if err == nil {
+			return func() {
+				_ = unix.Flock(fd, unix.LOCK_UN)
+				_ = f.Close()
+			}, nil
+		}
+		if !errors.Is(err, unix.EWOULDBLOCK) {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", ruleLockPath, err)
+		}
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, fmt.Errorf("waiting for rule lock %s: %w", ruleLockPath, ctx.Err())
+		case <-time.After(ruleLockPoll):
+		}
+	}
+}
+
+// readRuleFilesForReload reads the rule files in dir, through sudo when
+// they are not readable directly.
+func (n *NFTables) readRuleFilesForReload(ctx context.Context, dir string) ([]shared.RuleFileInfo, error) {
+	files, err := shared.ReadRuleFiles(n.env.Fs, dir)
+	if err == nil {
+		return files, nil
+	}
+	files, err = shared.ReadRuleFilesSudo(ctx, n.env.Cmd, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule files: %w", err)
+	}
+	return files, nil
+}
+
+// consolidateRuleFiles joins rule files into one nft script, so they load
+// as a single transaction. Each file resets its own tables first, so the
+// script replaces whatever the previous load left.
+func consolidateRuleFiles(dir string, files []shared.RuleFileInfo) string {
+	var sb strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&sb, "# %s\n", filepath.Join(dir, f.Name))
+		sb.WriteString(f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// reloadRulesOnLinux loads all rule files in the rule directory while
+// holding the rule lock, so concurrent projects cannot interleave their
+// loads. The consolidated script is checked with 'nft -c' before anything
+// is applied; if applying still fails, the alcatraz tables loaded before
+// are restored from a snapshot.
+func (n *NFTables) reloadRulesOnLinux(ctx context.Context) error {
+	unlock, err := lockRules(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	dir := nftDirOnLinux()
+	files, err := n.readRuleFilesForReload(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	script := consolidateRuleFiles(dir, files)
+
+	if output, err := n.env.Cmd.RunWithInput(ctx, script, "sudo", "nft", "-c", "-f", "-"); err != nil {
+		if sudoErr := util.SudoError("checking firewall rules", output, err); sudoErr != err {
+			return sudoErr
+		}
+		return fmt.Errorf("%w: %s", ErrRuleCheck, n.invalidRuleFiles(ctx, dir, files, output))
+	}
+
+	snapshot, err := n.snapshotTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = util.Retry(ctx, nftLoadBackoff, func(int) error {
+		output, err := n.env.Cmd.RunWithInput(ctx, script, "sudo", "nft", "-f", "-")
+		if err == nil {
+			return nil
+		}
+		if sudoErr := util.SudoError("loading firewall rules", output, err); sudoErr != err {
+			return util.Permanent(sudoErr)
+		}
+		err = fmt.Errorf("failed to load nftables rules from %s: %w: %s", dir, err, strings.TrimSpace(string(output)))
+		if !isTransientNftError(string(output)) {
+			return util.Permanent(err)
+		}
+		return err
+	})
+	if err == nil {
+		return nil
+	}
+	if rbErr := n.restoreSnapshot(ctx, snapshot); rbErr != nil {
+		return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+	}
+	return fmt.Errorf("%w (previous rules restored)", err)
+}
+
+// invalidRuleFiles checks each file on its own to name the ones failing
+// validation. Falls back to the consolidated check's output if none fails
+// alone, e.g. when two files declare conflicting tables.
+func (n *NFTables) invalidRuleFiles(ctx context.Context, dir string, files []shared.RuleFileInfo, output []byte) string {
+	var failed []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name)
+		out, err := n.env.Cmd.SudoRunQuiet(ctx, "nft", "-c", "-f", path)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", path, strings.TrimSpace(string(out))))
+		}
+	}
+	if len(failed) == 0 {
+		return strings.TrimSpace(string(output))
+	}
+	return strings.Join(failed, "\n")
+}
+
+// nftTable identifies a table.
+type nftTable struct {
+	Family string
+	Name   string
+}
+
+// tableSnapshot is the alcatraz tables loaded at one point in time.
+type tableSnapshot struct {
+	tables []nftTable
+	// script recreates the tables, as printed by 'nft list table'.
+	script string
+}
+
+// listAlcatrazTables returns the loaded alca-* tables.
+func (n *NFTables) listAlcatrazTables(ctx context.Context) ([]nftTable, error) {
+	output, err := n.env.Cmd.SudoRunQuiet(ctx, "nft", "list", "tables")
+	if err != nil {
+		if sudoErr := util.SudoError("listing firewall rules", output, err); sudoErr != err {
+			return nil, sudoErr
+		}
+		return nil, fmt.Errorf("failed to list nftables tables: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	var tables []nftTable
+	for _, line := range strings.Split(string(output), "\n") {
+		// Line format: "table inet alca-abc123"
+		parts := strings.Fields(line)
+		if len(parts) >= 3 && parts[0] == "table" && strings.HasPrefix(parts[2], "alca-") {
+			tables = append(tables, nftTable{Family: parts[1], Name: parts[2]})
+		}
+	}
+	return tables, nil
+}
+
+// snapshotTables records the loaded alcatraz tables so a failed reload can
+// put them back.
+func (n *NFTables) snapshotTables(ctx context.Context) (tableSnapshot, error) {
+	tables, err := n.listAlcatrazTables(ctx)
+	if err != nil {
+		return tableSnapshot{}, err
+	}
+	var sb strings.Builder
+	for _, t := range tables {
+		output, err := n.env.Cmd.SudoRunQuiet(ctx, "nft", "list", "table", t.Family, t.Name)
+		if err != nil {
+			return tableSnapshot{}, fmt.Errorf("failed to snapshot table %s %s: %w: %s", t.Family, t.Name, err, strings.TrimSpace(string(output)))
+		}
+		sb.Write(output)
+		if !strings.HasSuffix(string(output), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return tableSnapshot{tables: tables, script: sb.String()}, nil
+}
+
+// restoreSnapshot replaces the loaded alcatraz tables with the snapshot in
+// one transaction: every alca-* table loaded now or in the snapshot is
+// reset, then the snapshot is loaded.
+func (n *NFTables) restoreSnapshot(ctx context.Context, snapshot tableSnapshot) error {
+	current, err := n.listAlcatrazTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	seen := make(map[nftTable]bool)
+	for _, t := range append(current, snapshot.tables...) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		fmt.Fprintf(&sb, "table %s %s\n", t.Family, t.Name)
+		fmt.Fprintf(&sb, "delete table %s %s\n", t.Family, t.Name)
+	}
+	if sb.Len() == 0 {
+		return nil
+	}
+	sb.WriteString(snapshot.script)
+
+	output, err := n.env.Cmd.RunWithInput(ctx, sb.String(), "sudo", "nft", "-f", "-")
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package nft
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bolasblack/alcatraz/internal/network/darwin/podmanmachine"
+	"github.com/bolasblack/alcatraz/internal/network/shared"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// useTestRuleLock points the rule lock at a file private to the test.
+func useTestRuleLock(t *testing.T) {
+	t.Helper()
+	orig := ruleLockPath
+	ruleLockPath = filepath.Join(t.TempDir(), "alcatraz-nft.lock")
+	t.Cleanup(func() { ruleLockPath = orig })
+}
+
+// writeTestRuleFile writes a rule file for projectDir into the Linux rule directory.
+func writeTestRuleFile(t *testing.T, fs afero.Fs, projectDir string, containerID string) string {
+	t.Helper()
+	ruleset := generateRuleset(tableName(containerID), shared.RuleSpec{ContainerIP: "172.17.0.2"}, false, "filter - 1", projectDir, "")
+	path, err := writeRuleFile(fs, nftDirOnLinux(), nftFileName(projectDir), ruleset)
+	require.NoError(t, err)
+	return path
+}
+
+// =============================================================================
+// Lock Tests
+// =============================================================================
+
+func TestLockRules_WaitsForHolder(t *testing.T) {
+	useTestRuleLock(t)
+
+	unlock, err := lockRules(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*ruleLockPoll)
+	defer cancel()
+	_, err = lockRules(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	unlock()
+	unlock2, err := lockRules(context.Background())
+	require.NoError(t, err, "the lock must be free once released")
+	unlock2()
+}
+
+// =============================================================================
+// Reload Tests
+// =============================================================================
+
+func TestReloadRulesOnLinux_LoadsAllFilesInOneTransaction(t *testing.T) {
+	useTestRuleLock(t)
+	fs := afero.NewMemMapFs()
+	writeTestRuleFile(t, fs, "/projects/a", "aaaa")
+	writeTestRuleFile(t, fs, "/projects/b", "bbbb")
+
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.InOrder().
+		ExpectSuccess("sudo nft -c -f -", nil).
+		ExpectSuccess("sudo nft list tables", nil).
+		ExpectSuccess("sudo nft -f -", nil)
+	env := shared.NewNetworkEnv(fs, mockCmd, "/projects/a", "", runtime.PlatformLinux)
+
+	require.NoError(t, New(env).ReloadRules(context.Background()))
+	mockCmd.AssertAllExpectationsMet(t)
+
+	script := mockCmd.Calls[2].Input
+	assert.Equal(t, mockCmd.Calls[0].Input, script, "the loaded script must be the checked one")
+	assert.Contains(t, script, "table inet alca-aaaa {")
+	assert.Contains(t, script, "table inet alca-bbbb {")
+}
+
+func TestReloadRulesOnLinux_NoFilesDoesNothing(t *testing.T) {
+	useTestRuleLock(t)
+	mockCmd := util.NewMockCommandRunner()
+	env := shared.NewNetworkEnv(afero.NewMemMapFs(), mockCmd, "", "", runtime.PlatformLinux)
+
+	require.NoError(t, New(env).ReloadRules(context.Background()))
+	assert.Empty(t, mockCmd.Calls)
+}
+
+func TestReloadRulesOnLinux_CheckFailureNamesFileAndAppliesNothing(t *testing.T) {
+	useTestRuleLock(t)
+	fs := afero.NewMemMapFs()
+	good := writeTestRuleFile(t, fs, "/projects/a", "aaaa")
+	bad := filepath.Join(nftDirOnLinux(), "broken.nft")
+	require.NoError(t, afero.WriteFile(fs, bad, []byte("table inet alca-broken { bogus }\n"), 0644))
+
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.Expect("sudo nft -c -f -", []byte("Error: syntax error"), errors.New("exit status 1"))
+	mockCmd.ExpectSuccess("sudo nft -c -f "+good, nil)
+	mockCmd.Expect("sudo nft -c -f "+bad, []byte("Error: syntax error, unexpected string"), errors.New("exit status 1"))
+	env := shared.NewNetworkEnv(fs, mockCmd, "/projects/a", "", runtime.PlatformLinux)
+
+	err := New(env).ReloadRules(context.Background())
+	require.ErrorIs(t, err, ErrRuleCheck)
+	assert.Contains(t, err.Error(), bad)
+	assert.NotContains(t, err.Error(), good)
+	mockCmd.AssertNotCalled(t, "sudo nft -f -")
+}
+
+func TestReloadRulesOnLinux_LoadFailureRestoresSnapshot(t *testing.T) {
+	useTestRuleLock(t)
+	orig := nftLoadBackoff
+	nftLoadBackoff = util.Backoff{MaxAttempts: 1}
+	t.Cleanup(func() { nftLoadBackoff = orig })
+
+	fs := afero.NewMemMapFs()
+	writeTestRuleFile(t, fs, "/projects/a", "aaaa")
+
+	snapshot := "table inet alca-aaaa {\n\tchain forward {\n\t}\n}\n"
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("sudo nft -c -f -", nil)
+	mockCmd.ExpectSuccess("sudo nft list tables", []byte("table inet filter\ntable inet alca-aaaa\n"))
+	mockCmd.ExpectSuccess("sudo nft list table inet alca-aaaa", []byte(snapshot))
+	mockCmd.ExpectSequence("sudo nft -f -", []byte("Error: Could not process rule: No space left on device"), errors.New("exit status 1"))
+	mockCmd.ExpectSequence("sudo nft -f -", nil, nil)
+	env := shared.NewNetworkEnv(fs, mockCmd, "/projects/a", "", runtime.PlatformLinux)
+
+	err := New(env).ReloadRules(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "previous rules restored")
+
+	restore := mockCmd.Calls[len(mockCmd.Calls)-1]
+	require.Equal(t, "sudo nft -f -", restore.Key)
+	assert.Equal(t, "table inet alca-aaaa\ndelete table inet alca-aaaa\n"+snapshot, restore.Input)
+	assert.NotContains(t, restore.Input, "inet filter", "only alcatraz tables are restored")
+}
+
+func TestCleanupOnLinux_WaitsForRuleLock(t *testing.T) {
+	useTestRuleLock(t)
+	unlock, err := lockRules(context.Background())
+	require.NoError(t, err)
+	defer unlock()
+
+	mockCmd := util.NewMockCommandRunner()
+	env := shared.NewNetworkEnv(afero.NewMemMapFs(), mockCmd, "/projects/a", "", runtime.PlatformLinux)
+	action, err := New(env).Cleanup("aaaa")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*ruleLockPoll)
+	defer cancel()
+	assert.ErrorIs(t, action.Run(ctx, nil), context.DeadlineExceeded)
+	assert.Empty(t, mockCmd.Calls, "no table is deleted while a reload holds the lock")
+}
+
+func TestReloadRules_PodmanMachineRestartsUnit(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("podman machine ssh sudo systemctl restart "+podmanmachine.UnitName, nil)
+	env := shared.NewNetworkEnv(afero.NewMemMapFs(), mockCmd, "", "", runtime.PlatformMacPodman)
+
+	require.NoError(t, New(env).ReloadRules(context.Background()))
+	mockCmd.AssertAllExpectationsMet(t)
+}
+
+func TestReloadRules_DarwinSignalsHelper(t *testing.T) {
+	mockCmd := util.NewMockCommandRunner()
+	mockCmd.ExpectSuccess("docker exec alcatraz-network-helper sh -c kill -HUP 1", nil)
+	env := shared.NewNetworkEnv(afero.NewMemMapFs(), mockCmd, "", "", runtime.PlatformMacOrbStack)
+
+	require.NoError(t, New(env).ReloadRules(context.Background()))
+	mockCmd.AssertAllExpectationsMet(t)
+}
@@ -0,0 +1,118 @@
+//go:build linux && integration
+
+package nft
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/network/shared"
+)
+
+// The tests in this file load generated rulesets into a netnsLab and check
+// what the container can reach, which the string checks of firewall_test.go
+// cannot. Run them as root with:
+//
+//	go test -tags integration -run Netns ./internal/network/nft/
+
+func labAddr(ip string, port int) string {
+	return net.JoinHostPort(ip, strconv.Itoa(port))
+}
+
+// labRuleset renders the ruleset the Linux firewall loads for spec.
+func labRuleset(t *testing.T, spec shared.RuleSpec) string {
+	t.Helper()
+	spec.ContainerID = "netnslab0000"
+	spec.ContainerIP = labContainerIP
+	return generateRuleset(tableName(spec.ContainerID), spec, shared.HasAllLAN(spec.Rules), "filter - 1", "/project", "netns-lab")
+}
+
+// labRules parses lan-access rules.
+func labRules(t *testing.T, rules ...string) []shared.LANAccessRule {
+	t.Helper()
+	parsed, err := shared.ParseLANAccessRules(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestNetns_Ruleset(t *testing.T) {
+	lab := newNetnsLab(t)
+	lab.listen(t, nsLAN, labAddr(labLANIP, 8080), "lan-8080")
+	lab.listen(t, nsLAN, labAddr(labLANIP, 8081), "lan-8081")
+	lab.listen(t, nsLAN, labAddr(labProxyIP, 1080), "proxy")
+	lab.listen(t, nsWAN, labAddr(labWANIP, 80), "wan-80")
+	lab.listen(t, nsWAN, labAddr(labWANIP, 443), "wan-443")
+
+	t.Run("no rules: the lab is open", func(t *testing.T) {
+		lab.resetRuleset(t)
+		lab.assertReachable(t, labAddr(labLANIP, 8080), "lan-8080")
+		lab.assertReachable(t, labAddr(labWANIP, 443), "wan-443")
+	})
+
+	t.Run("default blocks private ranges only", func(t *testing.T) {
+		lab.resetRuleset(t)
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{}))
+		lab.assertBlocked(t, labAddr(labLANIP, 8080))
+		lab.assertReachable(t, labAddr(labWANIP, 443), "wan-443")
+	})
+
+	t.Run("lan-access host and port", func(t *testing.T) {
+		lab.resetRuleset(t)
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{Rules: labRules(t, labLANIP+":8080")}))
+		lab.assertReachable(t, labAddr(labLANIP, 8080), "lan-8080")
+		lab.assertBlocked(t, labAddr(labLANIP, 8081))
+	})
+
+	t.Run("lan-access udp rule does not open tcp", func(t *testing.T) {
+		lab.resetRuleset(t)
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{Rules: labRules(t, "udp://"+labLANIP+":8080")}))
+		lab.assertBlocked(t, labAddr(labLANIP, 8080))
+	})
+
+	t.Run("lan-access all", func(t *testing.T) {
+		lab.resetRuleset(t)
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{Rules: labRules(t, "*"), LogConnections: true}))
+		lab.assertReachable(t, labAddr(labLANIP, 8081), "lan-8081")
+	})
+
+	t.Run("strict drops everything not allowed", func(t *testing.T) {
+		lab.resetRuleset(t)
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{Rules: labRules(t, labLANIP+":8080"), Strict: true}))
+		lab.assertReachable(t, labAddr(labLANIP, 8080), "lan-8080")
+		lab.assertBlocked(t, labAddr(labWANIP, 443))
+	})
+
+	t.Run("temporary grants stop matching at their deadline", func(t *testing.T) {
+		expired := labRules(t, labLANIP+":8080")[0]
+		expired.Expires = time.Now().Add(-time.Minute)
+		current := labRules(t, labLANIP+":8081")[0]
+		current.Expires = time.Now().Add(time.Hour)
+
+		lab.resetRuleset(t)
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{Rules: []shared.LANAccessRule{expired, current}}))
+		lab.assertBlocked(t, labAddr(labLANIP, 8080))
+		lab.assertReachable(t, labAddr(labLANIP, 8081), "lan-8081")
+	})
+
+	t.Run("proxy receives all outbound tcp", func(t *testing.T) {
+		lab.resetRuleset(t)
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{Proxy: &shared.ProxyConfig{Host: labProxyIP, Port: 1080}}))
+		lab.assertReachable(t, labAddr(labWANIP, 80), "proxy")
+		lab.assertReachable(t, labAddr(labLANIP, 8080), "proxy")
+		lab.assertReachable(t, labAddr(labProxyIP, 1080), "proxy")
+	})
+
+	t.Run("reloading replaces the previous rules", func(t *testing.T) {
+		lab.resetRuleset(t)
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{Strict: true}))
+		lab.assertBlocked(t, labAddr(labWANIP, 443))
+		lab.loadRuleset(t, labRuleset(t, shared.RuleSpec{Rules: labRules(t, labLANIP+":8081")}))
+		lab.assertReachable(t, labAddr(labWANIP, 443), "wan-443")
+		lab.assertReachable(t, labAddr(labLANIP, 8081), "lan-8081")
+		lab.assertBlocked(t, labAddr(labLANIP, 8080))
+	})
+}
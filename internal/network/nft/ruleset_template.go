@@ -20,6 +20,8 @@ type rulesetData struct {
 	AllowRules  string // Pre-rendered allow rules (complex per-rule logic)
 	BlockRules  string // Pre-rendered block rules (IPv4 vs IPv6 ranges)
 	SkipBlock   bool   // True when AllLAN — skip block rules to honor user intent
+	LogRule     string // Pre-rendered connection log rule; empty when logging is off
+	DropRule    string // Pre-rendered drop-all-egress rule; empty unless strict
 	Proxy       *shared.ProxyConfig
 	ProxyAddr   string // "host:port" for DNAT target
 }
@@ -48,12 +50,17 @@ table inet {{.TableName}} {
 		# Allow established/related connections (return traffic)
 		ct state established,related accept
 
-{{.AllowRules}}{{- if .Proxy}}		# Allow traffic to proxy address (auto-injected, AGD-037)
+{{if .LogRule}}		# Log new outbound connections before they are accepted or dropped
+{{.LogRule}}
+{{end}}{{.AllowRules}}{{- if .Proxy}}		# Allow traffic to proxy address (auto-injected, AGD-037)
 		ip saddr {{.ContainerIP}} ip daddr {{.Proxy.Host}} tcp dport {{.Proxy.Port}} accept
 		ip saddr {{.ContainerIP}} ip daddr {{.Proxy.Host}} udp dport {{.Proxy.Port}} accept
 
 {{end}}{{- if not .SkipBlock}}		# Block RFC1918 and other private ranges from container
 {{.BlockRules}}{{- end}}
+{{- if .DropRule}}
+		# Strict isolation: drop all other egress, DNS included
+{{.DropRule}}{{- end}}
 	}
 }
 {{- if .Proxy}}
@@ -124,11 +131,32 @@ func renderBlockRules(containerIP string, containerIsV6 bool) string {
 	return sb.String()
 }
 
+// renderLogRule pre-renders the rule logging each new outbound connection.
+// The prefix identifies the container so `alca network log` can filter.
+func renderLogRule(tableName string, containerIP string, containerIsV6 bool) string {
+	family := "ip"
+	if containerIsV6 {
+		family = "ip6"
+	}
+	return fmt.Sprintf("\t\t%s saddr %s ct state new log prefix \"%s\" level info\n", family, containerIP, logPrefix(tableName))
+}
+
+// renderDropRule pre-renders the rule dropping all egress not accepted
+// earlier, used by strict isolation.
+func renderDropRule(containerIP string, containerIsV6 bool) string {
+	family := "ip"
+	if containerIsV6 {
+		family = "ip6"
+	}
+	return fmt.Sprintf("\t\t%s saddr %s drop\n", family, containerIP)
+}
+
 // generateRuleset generates the nftables ruleset using the template.
 // Includes isolation rules (inet filter table) and optional proxy DNAT rules (ip nat table).
 // Uses idempotent flush+recreate pattern per AGD-028.
 // allLAN=true skips RFC1918 block rules (user explicitly allows all LAN access).
-func generateRuleset(tableName string, containerIP string, rules []shared.LANAccessRule, proxy *shared.ProxyConfig, allLAN bool, priority string, projectDir string, projectID string) string {
+func generateRuleset(tableName string, spec shared.RuleSpec, allLAN bool, priority string, projectDir string, projectID string) string {
+	containerIP, rules, proxy := spec.ContainerIP, spec.Rules, spec.Proxy
 	containerIsV6 := shared.IsIPv6(containerIP)
 
 	data := rulesetData{
@@ -143,6 +171,12 @@ func generateRuleset(tableName string, containerIP string, rules []shared.LANAcc
 		SkipBlock:   allLAN,
 		Proxy:       proxy,
 	}
+	if spec.LogConnections {
+		data.LogRule = renderLogRule(tableName, containerIP, containerIsV6)
+	}
+	if spec.Strict {
+		data.DropRule = renderDropRule(containerIP, containerIsV6)
+	}
 	if proxy != nil {
 		data.ProxyAddr = fmt.Sprintf("%s:%d", proxy.Host, proxy.Port)
 	}
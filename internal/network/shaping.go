@@ -0,0 +1,95 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	alcaruntime "github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ErrShapingUnsupported is returned when traffic shaping cannot be applied
+// because the container does not share the host kernel (macOS VMs, remote daemons).
+var ErrShapingUnsupported = errors.New("network shaping is only supported for containers on a local Linux host")
+
+// defaultShapingInterface is used when the container has no default route.
+const defaultShapingInterface = "eth0"
+
+// ApplyShaping installs a netem qdisc on the container's outbound interface,
+// limiting bandwidth and adding latency as configured. The qdisc lives in the
+// container's network namespace, so it disappears with the container and
+// needs no cleanup. Uses "qdisc replace" so repeated calls are idempotent.
+func ApplyShaping(ctx context.Context, env *NetworkEnv, pid int, s config.Shaping) error {
+	if s.IsZero() {
+		return nil
+	}
+	if env.Runtime != alcaruntime.PlatformLinux {
+		return ErrShapingUnsupported
+	}
+
+	dev := shapingInterface(ctx, env, pid)
+	args, err := BuildShapingArgs(pid, dev, s)
+	if err != nil {
+		return err
+	}
+	if output, err := env.Cmd.SudoRunQuiet(ctx, args[0], args[1:]...); err != nil {
+		if sudoErr := util.SudoError("network shaping", output, err); sudoErr != err {
+			return sudoErr
+		}
+		return fmt.Errorf("tc qdisc replace failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// BuildShapingArgs returns the command that installs the netem qdisc inside
+// the network namespace of the process with the given PID.
+// Delay is passed to tc in microseconds because tc does not accept every
+// unit Go durations do (e.g. "1m30s").
+func BuildShapingArgs(pid int, dev string, s config.Shaping) ([]string, error) {
+	if err := config.ValidateShaping(s); err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"nsenter", "-t", strconv.Itoa(pid), "-n",
+		"tc", "qdisc", "replace", "dev", dev, "root", "netem",
+	}
+	if s.Delay != "" {
+		d, _ := time.ParseDuration(s.Delay) // validated above
+		args = append(args, "delay", fmt.Sprintf("%dus", d.Microseconds()))
+	}
+	if s.Rate != "" {
+		args = append(args, "rate", s.Rate)
+	}
+	return args, nil
+}
+
+// shapingInterface finds the interface carrying the container's default route.
+// Docker names it eth0, but rootless Podman with pasta mirrors the host's
+// interface name, so look it up instead of assuming.
+func shapingInterface(ctx context.Context, env *NetworkEnv, pid int) string {
+	output, err := env.Cmd.SudoRunQuiet(ctx, "nsenter", "-t", strconv.Itoa(pid), "-n", "ip", "-o", "route", "show", "default")
+	if err != nil {
+		return defaultShapingInterface
+	}
+	if dev := parseRouteDevice(string(output)); dev != "" {
+		return dev
+	}
+	return defaultShapingInterface
+}
+
+// parseRouteDevice extracts the interface name following "dev" in `ip route` output.
+func parseRouteDevice(output string) string {
+	fields := strings.Fields(output)
+	for i, f := range fields {
+		if f == "dev" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	alcaruntime "github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestBuildShapingArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		shaping config.Shaping
+		want    []string
+	}{
+		{
+			name:    "rate and delay",
+			shaping: config.Shaping{Rate: "10mbit", Delay: "50ms"},
+			want:    []string{"nsenter", "-t", "42", "-n", "tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "50000us", "rate", "10mbit"},
+		},
+		{
+			name:    "rate only",
+			shaping: config.Shaping{Rate: "512kbit"},
+			want:    []string{"nsenter", "-t", "42", "-n", "tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "rate", "512kbit"},
+		},
+		{
+			name:    "delay in units tc does not know",
+			shaping: config.Shaping{Delay: "1m30s"},
+			want:    []string{"nsenter", "-t", "42", "-n", "tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "90000000us"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildShapingArgs(42, "eth0", tt.shaping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("BuildShapingArgs() =\n  %v\nwant\n  %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildShapingArgs_Invalid(t *testing.T) {
+	_, err := BuildShapingArgs(42, "eth0", config.Shaping{Rate: "fast"})
+	if !errors.Is(err, config.ErrInvalidShaping) {
+		t.Errorf("BuildShapingArgs() error = %v, want ErrInvalidShaping", err)
+	}
+}
+
+func TestApplyShaping_Linux(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("sudo nsenter -t 42 -n ip -o route show default", []byte("default via 10.0.2.2 dev enp0s3 proto static\n"))
+	cmd.ExpectSuccess("sudo nsenter -t 42 -n tc qdisc replace dev enp0s3 root netem delay 50000us", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	env := NewNetworkEnv(afero.NewMemMapFs(), cmd, "", "", alcaruntime.PlatformLinux)
+	if err := ApplyShaping(context.Background(), env, 42, config.Shaping{Delay: "50ms"}); err != nil {
+		t.Fatalf("ApplyShaping() unexpected error: %v", err)
+	}
+}
+
+func TestApplyShaping_FallsBackToEth0(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectFailure("sudo nsenter -t 42 -n ip -o route show default", fmt.Errorf("ip: not found"))
+	cmd.ExpectSuccess("sudo nsenter -t 42 -n tc qdisc replace dev eth0 root netem rate 1mbit", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	env := NewNetworkEnv(afero.NewMemMapFs(), cmd, "", "", alcaruntime.PlatformLinux)
+	if err := ApplyShaping(context.Background(), env, 42, config.Shaping{Rate: "1mbit"}); err != nil {
+		t.Fatalf("ApplyShaping() unexpected error: %v", err)
+	}
+}
+
+func TestApplyShaping_Unsupported(t *testing.T) {
+	for _, platform := range []alcaruntime.RuntimePlatform{
+		alcaruntime.PlatformMacDockerDesktop,
+		alcaruntime.PlatformMacOrbStack,
+		alcaruntime.PlatformRemote,
+	} {
+		t.Run(string(platform), func(t *testing.T) {
+			env := NewNetworkEnv(afero.NewMemMapFs(), util.NewMockCommandRunner(), "", "", platform)
+			err := ApplyShaping(context.Background(), env, 42, config.Shaping{Rate: "1mbit"})
+			if !errors.Is(err, ErrShapingUnsupported) {
+				t.Errorf("ApplyShaping() error = %v, want ErrShapingUnsupported", err)
+			}
+		})
+	}
+}
+
+func TestApplyShaping_NoopWhenUnset(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	env := NewNetworkEnv(afero.NewMemMapFs(), cmd, "", "", alcaruntime.PlatformMacOrbStack)
+	if err := ApplyShaping(context.Background(), env, 42, config.Shaping{}); err != nil {
+		t.Errorf("ApplyShaping() with no shaping should be a no-op, got %v", err)
+	}
+}
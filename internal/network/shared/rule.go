@@ -5,6 +5,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // LanAccessWildcard is the special value that allows all LAN access.
@@ -37,12 +38,24 @@ func (p Protocol) String() string {
 // LANAccessRule represents a parsed lan-access configuration entry.
 // See AGD-028 for the rule syntax specification.
 type LANAccessRule struct {
-	Raw      string   // Original rule string for error messages
-	IP       string   // IP address or CIDR (e.g., "192.168.1.100", "10.0.0.0/8", "fe80::1", "2001:db8::/32")
-	Port     int      // Port number, 0 means all ports
-	Protocol Protocol // TCP, UDP, or All
-	IsIPv6   bool     // Whether this is an IPv6 address
-	AllLAN   bool     // true if rule is "*" (allow all LAN)
+	Raw      string        // Original rule string for error messages
+	IP       string        // IP address or CIDR (e.g., "192.168.1.100", "10.0.0.0/8", "fe80::1", "2001:db8::/32")
+	Port     int           // Port number, 0 means all ports
+	Protocol Protocol      // TCP, UDP, or All
+	IsIPv6   bool          // Whether this is an IPv6 address
+	AllLAN   bool          // true if rule is "*" (allow all LAN)
+	TTL      time.Duration // From a " ttl=2h" suffix; 0 means the rule is permanent
+	Expires  time.Time     // Absolute expiry resolved from TTL; zero means never
+}
+
+// Key returns the rule without options such as ttl, identifying the
+// destination it grants access to.
+func (r LANAccessRule) Key() string {
+	fields := strings.Fields(r.Raw)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
 }
 
 // ParseLANAccessRule parses a lan-access rule string.
@@ -60,6 +73,9 @@ type LANAccessRule struct {
 //	"[fe80::1]:8080"            → IPv6, port 8080, TCP default
 //	"tcp://[2001:db8::1]:443"   → IPv6, port 443, TCP
 //	"[2001:db8::/32]:*"         → IPv6 CIDR, all ports
+//
+// Any rule except "*" may be followed by " ttl=<duration>" (e.g.
+// "192.168.1.50:5432 ttl=2h") to make the grant temporary.
 func ParseLANAccessRule(s string) (LANAccessRule, error) {
 	raw := s
 	s = strings.TrimSpace(s)
@@ -68,8 +84,16 @@ func ParseLANAccessRule(s string) (LANAccessRule, error) {
 		return LANAccessRule{}, fmt.Errorf("lan-access rule: empty rule string")
 	}
 
+	s, ttl, err := parseRuleOptions(s, raw)
+	if err != nil {
+		return LANAccessRule{}, err
+	}
+
 	// Handle wildcard (allow all LAN)
 	if s == "*" {
+		if ttl > 0 {
+			return LANAccessRule{}, fmt.Errorf("lan-access rule %q: ttl is not supported for \"*\"", raw)
+		}
 		return LANAccessRule{
 			Raw:    raw,
 			AllLAN: true,
@@ -139,9 +163,27 @@ func ParseLANAccessRule(s string) (LANAccessRule, error) {
 		Protocol: proto,
 		IsIPv6:   isIPv6,
 		AllLAN:   false,
+		TTL:      ttl,
 	}, nil
 }
 
+// parseRuleOptions splits trailing "key=value" options off a rule string.
+// Only ttl is supported; it must be a positive Go duration.
+func parseRuleOptions(s string, raw string) (rule string, ttl time.Duration, err error) {
+	fields := strings.Fields(s)
+	for _, opt := range fields[1:] {
+		key, value, _ := strings.Cut(opt, "=")
+		if key != "ttl" {
+			return "", 0, fmt.Errorf("lan-access rule %q: unknown option %q (supported: ttl=<duration>)", raw, opt)
+		}
+		ttl, err = time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return "", 0, fmt.Errorf("lan-access rule %q: invalid ttl %q: expected a positive duration such as 2h", raw, value)
+		}
+	}
+	return fields[0], ttl, nil
+}
+
 // parseIPv6WithBrackets parses bracketed IPv6 notation: [ip]:port or [ip].
 func parseIPv6WithBrackets(s string, raw string) (ipStr string, portStr string, err error) {
 	closeBracket := strings.Index(s, "]")
@@ -3,6 +3,7 @@ package shared
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseLANAccessRule(t *testing.T) {
@@ -423,3 +424,31 @@ func TestProtocolString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLANAccessRule_TTL(t *testing.T) {
+	rule, err := ParseLANAccessRule("192.168.1.50:5432 ttl=2h")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.IP != "192.168.1.50" || rule.Port != 5432 || rule.Protocol != ProtoTCP {
+		t.Errorf("address parsed wrongly: %+v", rule)
+	}
+	if rule.TTL != 2*time.Hour {
+		t.Errorf("TTL = %v, want 2h", rule.TTL)
+	}
+	if rule.Key() != "192.168.1.50:5432" {
+		t.Errorf("Key() = %q, want 192.168.1.50:5432", rule.Key())
+	}
+
+	errCases := map[string]string{
+		"192.168.1.50 ttl=soon":  "invalid ttl",
+		"192.168.1.50 ttl=-1h":   "invalid ttl",
+		"192.168.1.50 expires=1": "unknown option",
+		"* ttl=1h":               "not supported",
+	}
+	for input, want := range errCases {
+		if _, err := ParseLANAccessRule(input); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseLANAccessRule(%q) error = %v, want containing %q", input, err, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ReadRuleFiles reads the .nft rule files in dir. A missing dir means no
+// rules. Unlike a plain listing it reports why files could not be read, so
+// a status display can tell "no rules" from "rules not readable" (e.g.
+// permission denied without root). Files read before an error are returned.
+func ReadRuleFiles(fsys afero.Fs, dir string) ([]RuleFileInfo, error) {
+	entries, err := afero.ReadDir(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []RuleFileInfo
+	var readErr error
+	for _, f := range entries {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".nft") {
+			continue
+		}
+		content, err := afero.ReadFile(fsys, filepath.Join(dir, f.Name()))
+		if err != nil {
+			if readErr == nil {
+				readErr = err
+			}
+			continue
+		}
+		files = append(files, RuleFileInfo{Name: f.Name(), Content: string(content)})
+	}
+	return files, readErr
+}
+
+// ReadRuleFilesSudo reads the .nft rule files in dir as root. Callers make
+// sure sudo will not prompt (cached credentials or NOPASSWD) before calling.
+func ReadRuleFilesSudo(ctx context.Context, cmd util.CommandRunner, dir string) ([]RuleFileInfo, error) {
+	output, err := cmd.SudoRunQuiet(ctx, "find", dir, "-maxdepth", "1", "-type", "f", "-name", "*.nft")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w: %s", dir, err, strings.TrimSpace(string(output)))
+	}
+	var paths []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	slices.Sort(paths)
+
+	files := make([]RuleFileInfo, 0, len(paths))
+	for _, path := range paths {
+		content, err := cmd.SudoRunQuiet(ctx, "cat", path)
+		if err != nil {
+			return files, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		files = append(files, RuleFileInfo{Name: filepath.Base(path), Content: string(content)})
+	}
+	return files, nil
+}
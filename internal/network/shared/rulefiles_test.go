@@ -0,0 +1,71 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// denyFs fails reads of one file with a permission error, which MemMapFs
+// does not model.
+type denyFs struct {
+	afero.Fs
+	denied string
+}
+
+func (d denyFs) Open(name string) (afero.File, error) {
+	if name == d.denied {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.Fs.Open(name)
+}
+
+func (d denyFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if name == d.denied {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.Fs.OpenFile(name, flag, perm)
+}
+
+func TestReadRuleFiles(t *testing.T) {
+	memFs := afero.NewMemMapFs()
+	files, err := ReadRuleFiles(memFs, "/rules")
+	if err != nil || len(files) != 0 {
+		t.Fatalf("missing dir: ReadRuleFiles() = %v, %v; want no files and no error", files, err)
+	}
+
+	for name, content := range map[string]string{"/rules/a.nft": "a", "/rules/b.nft": "b", "/rules/notes.txt": "x"} {
+		if err := afero.WriteFile(memFs, name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err = ReadRuleFiles(denyFs{Fs: memFs, denied: "/rules/a.nft"}, "/rules")
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("error = %v, want permission denied", err)
+	}
+	if len(files) != 1 || files[0].Name != "b.nft" || files[0].Content != "b" {
+		t.Errorf("readable files = %+v, want only b.nft", files)
+	}
+}
+
+func TestReadRuleFilesSudo(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("sudo find /rules -maxdepth 1 -type f -name *.nft", []byte("/rules/b.nft\n/rules/a.nft\n"))
+	cmd.ExpectSuccess("sudo cat /rules/a.nft", []byte("table a"))
+	cmd.ExpectSuccess("sudo cat /rules/b.nft", []byte("table b"))
+
+	files, err := ReadRuleFilesSudo(context.Background(), cmd, "/rules")
+	if err != nil {
+		t.Fatalf("ReadRuleFilesSudo() error: %v", err)
+	}
+	want := []RuleFileInfo{{Name: "a.nft", Content: "table a"}, {Name: "b.nft", Content: "table b"}}
+	if len(files) != len(want) || files[0] != want[0] || files[1] != want[1] {
+		t.Errorf("ReadRuleFilesSudo() = %+v, want %+v", files, want)
+	}
+}
@@ -71,18 +71,31 @@ type ProxyConfig struct {
 	Port int
 }
 
+// RuleSpec describes the firewall rules to apply for one container.
+type RuleSpec struct {
+	// ContainerID is used to create an isolated ruleset that can be cleaned up.
+	ContainerID string
+	// ContainerIP is the container's IP address.
+	ContainerIP string
+	// Rules are parsed lan-access entries (allow-listed destinations).
+	// If empty, all RFC1918 traffic is blocked.
+	// If any rule has AllLAN=true, no blocking is applied.
+	Rules []LANAccessRule
+	// Proxy is the transparent proxy config; nil means no proxy (AGD-037).
+	Proxy *ProxyConfig
+	// LogConnections logs every new outbound connection to the kernel log.
+	LogConnections bool
+	// Strict drops all outbound traffic not allowed by Rules or Proxy,
+	// instead of only private ranges (network.isolation = "strict").
+	Strict bool
+}
+
 // Firewall manages network isolation rules for containers.
 type Firewall interface {
-	// ApplyRules applies network rules for a container: isolation (lan-access)
-	// and optional transparent proxy (AGD-037).
-	// containerID is used to create an isolated ruleset that can be cleaned up.
-	// containerIP is the container's IP address.
-	// rules are parsed lan-access entries (allow-listed destinations).
-	// If rules is empty, all RFC1918 traffic is blocked.
-	// If any rule has AllLAN=true, no blocking is applied.
-	// proxy is the transparent proxy config; nil means no proxy.
+	// ApplyRules applies network rules for a container: isolation (lan-access),
+	// optional transparent proxy (AGD-037) and optional connection logging.
 	// Returns PostCommitAction that MUST be called after TransactFs.Commit().
-	ApplyRules(containerID string, containerIP string, rules []LANAccessRule, proxy *ProxyConfig) (*PostCommitAction, error)
+	ApplyRules(spec RuleSpec) (*PostCommitAction, error)
 
 	// Cleanup removes all firewall rules for a container.
 	// Returns PostCommitAction that MUST be called after TransactFs.Commit().
@@ -91,6 +104,10 @@ type Firewall interface {
 	// CleanupStaleFiles removes rule files for projects whose directory no longer exists.
 	// Returns the count of cleaned-up files.
 	CleanupStaleFiles(ctx context.Context) (int, error)
+
+	// ReloadRules reloads every persisted rule file, e.g. after editing them
+	// by hand or when a load was interrupted.
+	ReloadRules(ctx context.Context) error
 }
 
 // =============================================================================
@@ -136,6 +153,11 @@ type HelperStatus struct {
 type DetailedStatusInfo struct {
 	// RuleFiles lists the rule files managed by this helper.
 	RuleFiles []RuleFileInfo
+	// RuleDir is the directory RuleFiles are read from.
+	RuleDir string
+	// ReadErr is why rule files could not be read (e.g. permission denied
+	// without root), in which case RuleFiles may be incomplete.
+	ReadErr error
 }
 
 // RuleFileInfo describes a single rule file.
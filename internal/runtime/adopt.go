@@ -0,0 +1,132 @@
+package runtime
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+// ContainerSpec is what alca can recover of an existing container's run
+// options from its inspect document, to adopt it into a project.
+type ContainerSpec struct {
+	Name       string
+	Image      string
+	WorkingDir string
+	// Env is the container's environment as KEY=value, image defaults included.
+	Env []string
+	// Binds are the bind mounts; named volumes and tmpfs are not carried over.
+	Binds   []config.MountConfig
+	Ports   []config.PortConfig
+	Memory  string
+	CPUs    int
+	Running bool
+	Labels  map[string]string
+}
+
+// inspectDocument is the subset of docker/podman inspect output used by
+// ParseContainerSpec.
+type inspectDocument struct {
+	Name   string
+	Config struct {
+		Image      string
+		WorkingDir string
+		Env        []string
+		Labels     map[string]string
+	}
+	State struct {
+		Running bool
+	}
+	Mounts []struct {
+		Type        string
+		Source      string
+		Destination string
+		RW          bool
+	}
+	HostConfig struct {
+		Memory       int64
+		NanoCpus     int64
+		PortBindings map[string][]struct {
+			HostIp   string
+			HostPort string
+		}
+	}
+}
+
+// ParseContainerSpec extracts the adoptable run options from an inspect
+// document (see InspectContainer).
+func ParseContainerSpec(doc json.RawMessage) (ContainerSpec, error) {
+	var d inspectDocument
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return ContainerSpec{}, fmt.Errorf("failed to parse inspect output: %w", err)
+	}
+
+	spec := ContainerSpec{
+		Name:       strings.TrimPrefix(d.Name, "/"),
+		Image:      d.Config.Image,
+		WorkingDir: d.Config.WorkingDir,
+		Env:        d.Config.Env,
+		Running:    d.State.Running,
+		Labels:     d.Config.Labels,
+		CPUs:       int(d.HostConfig.NanoCpus / 1e9),
+		Memory:     formatMemory(d.HostConfig.Memory),
+	}
+	for _, m := range d.Mounts {
+		if m.Type != "bind" {
+			continue
+		}
+		spec.Binds = append(spec.Binds, config.MountConfig{Source: m.Source, Target: m.Destination, Readonly: !m.RW})
+	}
+	for containerPort, bindings := range d.HostConfig.PortBindings {
+		port, protocol, _ := strings.Cut(containerPort, "/")
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			continue
+		}
+		for _, b := range bindings {
+			pc := config.PortConfig{Port: p, HostIP: b.HostIp}
+			if hp, err := strconv.Atoi(b.HostPort); err == nil && hp != p {
+				pc.HostPort = hp
+			}
+			if protocol != "" && protocol != "tcp" {
+				pc.Protocol = protocol
+			}
+			spec.Ports = append(spec.Ports, pc)
+		}
+	}
+	// Map order is random; keep the generated config stable
+	slices.SortStableFunc(spec.Ports, func(a, b config.PortConfig) int { return cmp.Compare(a.Port, b.Port) })
+	return spec, nil
+}
+
+// ContainerSpec returns the run options recovered from the container's
+// inspect document.
+func (r *dockerCLICompatibleRuntime) ContainerSpec(ctx context.Context, env *RuntimeEnv, containerName string) (ContainerSpec, error) {
+	doc, err := r.InspectContainer(ctx, env, containerName)
+	if err != nil {
+		return ContainerSpec{}, err
+	}
+	return ParseContainerSpec(doc)
+}
+
+// formatMemory renders a byte count as a run -m value, in the largest
+// whole unit; 0 (no limit) is "".
+func formatMemory(bytes int64) string {
+	if bytes <= 0 {
+		return ""
+	}
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}} {
+		if bytes%unit.size == 0 {
+			return strconv.FormatInt(bytes/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
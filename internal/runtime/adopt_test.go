@@ -0,0 +1,60 @@
+package runtime
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+func TestParseContainerSpec(t *testing.T) {
+	doc := []byte(`{
+		"Name": "/legacy",
+		"Config": {"Image": "node:20", "WorkingDir": "/app", "Env": ["PATH=/bin", "A=1"], "Labels": {"k": "v"}},
+		"State": {"Running": true},
+		"Mounts": [
+			{"Type": "bind", "Source": "/src", "Destination": "/app", "RW": true},
+			{"Type": "volume", "Source": "/var/lib/docker/volumes/x", "Destination": "/cache", "RW": true},
+			{"Type": "bind", "Source": "/etc/ssl", "Destination": "/etc/ssl", "RW": false}
+		],
+		"HostConfig": {
+			"Memory": 2147483648,
+			"NanoCpus": 2000000000,
+			"PortBindings": {
+				"8080/tcp": [{"HostIp": "", "HostPort": "9090"}],
+				"53/udp": [{"HostIp": "127.0.0.1", "HostPort": "53"}]
+			}
+		}
+	}`)
+
+	spec, err := ParseContainerSpec(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Name != "legacy" || spec.Image != "node:20" || spec.WorkingDir != "/app" || !spec.Running {
+		t.Errorf("spec = %+v", spec)
+	}
+	if spec.Memory != "2g" || spec.CPUs != 2 {
+		t.Errorf("resources = %q, %d", spec.Memory, spec.CPUs)
+	}
+	wantBinds := []config.MountConfig{
+		{Source: "/src", Target: "/app"},
+		{Source: "/etc/ssl", Target: "/etc/ssl", Readonly: true},
+	}
+	if !reflect.DeepEqual(spec.Binds, wantBinds) {
+		t.Errorf("binds = %+v, want %+v", spec.Binds, wantBinds)
+	}
+	if len(spec.Ports) != 2 ||
+		spec.Ports[0].Port != 53 || spec.Ports[0].Protocol != "udp" || spec.Ports[0].HostIP != "127.0.0.1" || spec.Ports[0].HostPort != 0 ||
+		spec.Ports[1].Port != 8080 || spec.Ports[1].HostPort != 9090 || spec.Ports[1].Protocol != "" {
+		t.Errorf("ports = %+v", spec.Ports)
+	}
+}
+
+func TestFormatMemory(t *testing.T) {
+	for bytes, want := range map[int64]string{0: "", 512 << 20: "512m", 3 << 30: "3g", 1536 << 10: "1536k", 1000: "1000"} {
+		if got := formatMemory(bytes); got != want {
+			t.Errorf("formatMemory(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
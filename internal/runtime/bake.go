@@ -0,0 +1,97 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+// BakeImage commits the container's filesystem to image. The container's
+// environment (set from envs, which may hold host secrets) is part of the
+// committed image config, so every configured env is reset to the base
+// image's value, or emptied if the base image does not set it. Containers
+// created from the image get their envs from the config as usual.
+func (r *dockerCLICompatibleRuntime) BakeImage(ctx context.Context, env *RuntimeEnv, cfg *config.Config, containerName, image string) error {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "image", "inspect", "--format", "{{json .Config.Env}}", cfg.Image)
+	if err != nil {
+		return fmt.Errorf("%s image inspect %s failed: %w: %s", r.command, cfg.Image, err, strings.TrimSpace(string(output)))
+	}
+	var baseEnv []string
+	if err := json.Unmarshal(output, &baseEnv); err != nil {
+		return fmt.Errorf("failed to parse env of %s: %w", cfg.Image, err)
+	}
+
+	args := []string{"commit"}
+	for _, change := range bakeEnvChanges(cfg, baseEnv) {
+		args = append(args, "--change", change)
+	}
+	args = append(args, containerName, image)
+	if output, err := env.Cmd.RunQuiet(ctx, r.command, args...); err != nil {
+		return fmt.Errorf("%s commit failed: %w: %s", r.command, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// bakeEnvChanges returns the Dockerfile ENV instructions that restore the
+// configured envs to their values in the base image's env (KEY=VALUE list).
+func bakeEnvChanges(cfg *config.Config, baseEnv []string) []string {
+	base := make(map[string]string, len(baseEnv))
+	for _, kv := range baseEnv {
+		k, v, _ := strings.Cut(kv, "=")
+		base[k] = v
+	}
+
+	keys := make([]string, 0, len(cfg.Envs))
+	for k := range cfg.Envs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	changes := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if v, ok := base[k]; ok && v != "" {
+			// Whitespace form: the rest of the line is the value, unquoted.
+			changes = append(changes, "ENV "+k+" "+v)
+		} else {
+			changes = append(changes, "ENV "+k+`=""`)
+		}
+	}
+	return changes
+}
+
+// RemoveImage removes a local image.
+func (r *dockerCLICompatibleRuntime) RemoveImage(ctx context.Context, env *RuntimeEnv, image string) error {
+	if output, err := env.Cmd.RunQuiet(ctx, r.command, "image", "rm", image); err != nil {
+		return fmt.Errorf("%s image rm %s failed: %w: %s", r.command, image, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// SaveImage writes image to path with '<command> save'. Podman writes an
+// OCI archive; Docker's archive carries an OCI layout since Docker 25.
+func (r *dockerCLICompatibleRuntime) SaveImage(ctx context.Context, env *RuntimeEnv, image, path string) error {
+	args := []string{"save", "-o", path}
+	if r.saveFormat != "" {
+		args = append(args, "--format", r.saveFormat)
+	}
+	args = append(args, image)
+	if output, err := env.Cmd.RunQuiet(ctx, r.command, args...); err != nil {
+		return fmt.Errorf("%s save %s failed: %w: %s", r.command, image, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// runImage returns the image a new container is created from: the image
+// baked by 'alca bake' when it was baked from the configured image, else
+// the configured image.
+func runImage(cfg *config.Config, st *state.State) string {
+	if st.Bake != nil && st.Bake.Base == cfg.Image {
+		return st.Bake.Image
+	}
+	return cfg.Image
+}
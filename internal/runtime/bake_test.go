@@ -0,0 +1,77 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestBakeEnvChanges(t *testing.T) {
+	cfg := &config.Config{Envs: map[string]config.EnvValue{
+		"TOKEN":      {Value: "${TOKEN}"},
+		"PATH":       {Value: "/extra:/usr/bin"},
+		"NIX_CONFIG": {Value: "extra-experimental-features = nix-command flakes"},
+	}}
+	base := []string{"PATH=/usr/local/bin:/usr/bin", "NIX_CONFIG=", "USER=root"}
+
+	got := strings.Join(bakeEnvChanges(cfg, base), "\n")
+	want := "ENV NIX_CONFIG=\"\"\nENV PATH /usr/local/bin:/usr/bin\nENV TOKEN=\"\""
+	if got != want {
+		t.Errorf("bakeEnvChanges() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBakeImage(t *testing.T) {
+	cfg := &config.Config{Image: "nixos/nix", Envs: map[string]config.EnvValue{"TOKEN": {Value: "secret"}}}
+
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("podman image inspect --format {{json .Config.Env}} nixos/nix", []byte(`["PATH=/bin"]`+"\n"))
+	cmd.ExpectSuccess(`podman commit --change ENV TOKEN="" alca-test alca-bake/p:abc`, nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	if err := NewPodman().BakeImage(context.Background(), NewRuntimeEnv(cmd), cfg, "alca-test", "alca-bake/p:abc"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSaveImage(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   *dockerCLICompatibleRuntime
+		want string
+	}{
+		{"docker", NewDocker().dockerCLICompatibleRuntime, "docker save -o /tmp/image.tar alca-export/p:1"},
+		{"podman", NewPodman().dockerCLICompatibleRuntime, "podman save -o /tmp/image.tar --format oci-archive alca-export/p:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := util.NewMockCommandRunner()
+			cmd.ExpectSuccess(tt.want, nil)
+			defer cmd.AssertAllExpectationsMet(t)
+
+			if err := tt.rt.SaveImage(context.Background(), NewRuntimeEnv(cmd), "alca-export/p:1", "/tmp/image.tar"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunImage(t *testing.T) {
+	cfg := &config.Config{Image: "nixos/nix"}
+	bake := &state.BakeInfo{Image: "alca-bake/p:abc", Base: "nixos/nix"}
+
+	if got := runImage(cfg, &state.State{}); got != "nixos/nix" {
+		t.Errorf("without bake: got %q", got)
+	}
+	if got := runImage(cfg, &state.State{Bake: bake}); got != "alca-bake/p:abc" {
+		t.Errorf("with bake: got %q", got)
+	}
+	other := &config.Config{Image: "alpine"}
+	if got := runImage(other, &state.State{Bake: bake}); got != "alpine" {
+		t.Errorf("bake of another image: got %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Capabilities describes optional features of the runtime daemon, so callers
+// branch on what the runtime supports rather than on which runtime it is.
+type Capabilities struct {
+	// Rootless is true when the daemon runs without root privileges.
+	Rootless bool
+	// CgroupV2 is true on the unified cgroup hierarchy.
+	CgroupV2 bool
+	// UsernsKeepID is true when --userns=keep-id can map the host user
+	// into the container (Podman).
+	UsernsKeepID bool
+	// UsernsAuto is true when --userns=auto can allocate a private ID
+	// range for the container (Podman).
+	UsernsAuto bool
+	// UsernsRemap is true when the daemon remaps container IDs for every
+	// container (Docker's userns-remap).
+	UsernsRemap bool
+	// CgroupConf is true when --cgroup-conf can set cgroup v2 files such
+	// as io.latency directly (Podman on cgroup v2).
+	CgroupConf bool
+	// GPUs is true when --gpus can expose NVIDIA GPUs.
+	GPUs bool
+	// HostArch is the daemon host's CPU architecture in GOARCH form.
+	HostArch string
+	// RegistryMirrors lists the daemon-level Docker Hub mirrors (Docker).
+	RegistryMirrors []string
+}
+
+// ResourceLimits reports whether memory and CPU limits can be applied.
+// Rootless containers can only be limited through cgroup v2 delegation.
+func (c Capabilities) ResourceLimits() bool {
+	return !c.Rootless || c.CgroupV2
+}
+
+// CgroupTuning reports whether resources.cgroup settings can be applied:
+// they target cgroup v2 controllers, and rootless containers also need the
+// resource limits delegation.
+func (c Capabilities) CgroupTuning() bool {
+	return c.CgroupV2 && c.ResourceLimits()
+}
+
+// String lists the supported features, e.g. "rootless, cgroup v2, arch arm64".
+func (c Capabilities) String() string {
+	var parts []string
+	if c.Rootless {
+		parts = append(parts, "rootless")
+	}
+	if c.CgroupV2 {
+		parts = append(parts, "cgroup v2")
+	} else {
+		parts = append(parts, "cgroup v1")
+	}
+	if c.UsernsKeepID {
+		parts = append(parts, "userns keep-id")
+	}
+	if c.UsernsAuto {
+		parts = append(parts, "userns auto")
+	}
+	if c.UsernsRemap {
+		parts = append(parts, "userns-remap")
+	}
+	if c.CgroupConf {
+		parts = append(parts, "cgroup-conf")
+	}
+	if c.GPUs {
+		parts = append(parts, "gpus")
+	}
+	if c.HostArch != "" {
+		parts = append(parts, "arch "+c.HostArch)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// capabilitiesCache caches probed capabilities per RuntimeEnv and command,
+// so one invocation asks the daemon once.
+var (
+	capabilitiesCacheMu sync.Mutex
+	capabilitiesCache   = make(map[capabilitiesKey]Capabilities)
+)
+
+type capabilitiesKey struct {
+	env     *RuntimeEnv
+	command string
+}
+
+// Capabilities probes the daemon with a single info call. Results are cached
+// per RuntimeEnv.
+func (r *dockerCLICompatibleRuntime) Capabilities(ctx context.Context, env *RuntimeEnv) (Capabilities, error) {
+	key := capabilitiesKey{env, r.command}
+	capabilitiesCacheMu.Lock()
+	cached, ok := capabilitiesCache[key]
+	capabilitiesCacheMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	output, err := probe(ctx, env, r.command, "info", "--format", "{{json .}}")
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("%s info failed: %w: %s", r.command, err, strings.TrimSpace(string(output)))
+	}
+	caps, err := parseCapabilities(output)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("%s info: %w", r.command, err)
+	}
+
+	capabilitiesCacheMu.Lock()
+	capabilitiesCache[key] = caps
+	capabilitiesCacheMu.Unlock()
+	return caps, nil
+}
+
+// runtimeInfo is the subset of `docker info` and `podman info` JSON used for
+// capabilities. Docker reports top-level fields; Podman nests them in host.
+type runtimeInfo struct {
+	// Docker
+	Architecture    string
+	CgroupVersion   string
+	SecurityOptions []string
+	Runtimes        map[string]json.RawMessage
+	RegistryConfig  struct {
+		Mirrors []string
+	}
+
+	// Podman
+	Host *struct {
+		Arch          string
+		CgroupVersion string
+		Security      struct {
+			Rootless bool
+		}
+	}
+	Version struct {
+		Version string
+	}
+}
+
+// parseCapabilities derives capabilities from info JSON.
+func parseCapabilities(data []byte) (Capabilities, error) {
+	var info runtimeInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return Capabilities{}, fmt.Errorf("unexpected info output: %w", err)
+	}
+
+	if h := info.Host; h != nil {
+		// keep-id works rootful since Podman 4.3; --gpus arrived in 5.0
+		return Capabilities{
+			Rootless:     h.Security.Rootless,
+			CgroupV2:     h.CgroupVersion == "v2",
+			UsernsKeepID: h.Security.Rootless || versionAtLeast(info.Version.Version, 4, 3),
+			UsernsAuto:   true,
+			CgroupConf:   h.CgroupVersion == "v2",
+			GPUs:         versionAtLeast(info.Version.Version, 5, 0),
+			HostArch:     normalizeArch(h.Arch),
+		}, nil
+	}
+
+	_, nvidia := info.Runtimes["nvidia"]
+	return Capabilities{
+		Rootless:        slices.Contains(info.SecurityOptions, "name=rootless"),
+		CgroupV2:        info.CgroupVersion == "2",
+		UsernsRemap:     slices.Contains(info.SecurityOptions, "name=userns"),
+		GPUs:            nvidia,
+		HostArch:        normalizeArch(info.Architecture),
+		RegistryMirrors: info.RegistryConfig.Mirrors,
+	}, nil
+}
+
+// versionAtLeast reports whether a "major.minor[.patch]" version is at least
+// major.minor. Unparseable versions report false.
+func versionAtLeast(version string, major, minor int) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	maj, err1 := strconv.Atoi(parts[0])
+	mnr, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return maj > major || (maj == major && mnr >= minor)
+}
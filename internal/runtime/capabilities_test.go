@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	tests := []struct {
+		name string
+		info string
+		want Capabilities
+	}{
+		{
+			name: "docker rootful with nvidia",
+			info: `{"Architecture":"x86_64","CgroupVersion":"2","SecurityOptions":["name=seccomp,profile=builtin","name=cgroupns"],"Runtimes":{"runc":{},"nvidia":{}},"RegistryConfig":{"Mirrors":["http://localhost:5000/"]}}`,
+			want: Capabilities{CgroupV2: true, GPUs: true, HostArch: "amd64", RegistryMirrors: []string{"http://localhost:5000/"}},
+		},
+		{
+			name: "docker rootless cgroup v1",
+			info: `{"Architecture":"aarch64","CgroupVersion":"1","SecurityOptions":["name=seccomp,profile=builtin","name=rootless"]}`,
+			want: Capabilities{Rootless: true, HostArch: "arm64"},
+		},
+		{
+			name: "podman 4.9 rootless",
+			info: `{"host":{"arch":"amd64","cgroupVersion":"v2","security":{"rootless":true}},"version":{"Version":"4.9.3"}}`,
+			want: Capabilities{Rootless: true, CgroupV2: true, UsernsKeepID: true, UsernsAuto: true, CgroupConf: true, HostArch: "amd64"},
+		},
+		{
+			name: "podman 5 rootful",
+			info: `{"host":{"arch":"arm64","cgroupVersion":"v2","security":{"rootless":false}},"version":{"Version":"5.2.0"}}`,
+			want: Capabilities{CgroupV2: true, UsernsKeepID: true, UsernsAuto: true, CgroupConf: true, GPUs: true, HostArch: "arm64"},
+		},
+		{
+			name: "podman 4.2 rootful",
+			info: `{"host":{"arch":"amd64","cgroupVersion":"v1","security":{"rootless":false}},"version":{"Version":"4.2.1"}}`,
+			want: Capabilities{UsernsAuto: true, HostArch: "amd64"},
+		},
+		{
+			name: "docker with userns-remap",
+			info: `{"Architecture":"x86_64","CgroupVersion":"2","SecurityOptions":["name=seccomp,profile=builtin","name=userns"]}`,
+			want: Capabilities{CgroupV2: true, UsernsRemap: true, HostArch: "amd64"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCapabilities([]byte(tt.info))
+			if err != nil {
+				t.Fatalf("parseCapabilities() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCapabilities() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilities_ProbedOncePerEnv(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("podman info --format {{json .}}", []byte(`{"host":{"arch":"amd64","cgroupVersion":"v2","security":{"rootless":true}}}`))
+	env := NewRuntimeEnv(cmd)
+
+	for range 2 {
+		caps, err := NewPodman().Capabilities(context.Background(), env)
+		if err != nil || !caps.Rootless {
+			t.Fatalf("Capabilities() = %+v, %v", caps, err)
+		}
+	}
+	if n := cmd.CallCount("podman info --format {{json .}}"); n != 1 {
+		t.Errorf("info called %d times, want 1", n)
+	}
+}
+
+func TestBuildRunArgs_ResourceLimitsNeedCgroupV2WhenRootless(t *testing.T) {
+	cfg := &config.Config{Image: "alpine", Workdir: "/workspace", Resources: config.Resources{Memory: "2g", CPUs: 2}}
+	st := &state.State{ProjectID: "p", ContainerName: "alca-p"}
+	env := &RuntimeEnv{Cmd: util.NewMockCommandRunner().AllowUnexpected()}
+	rt := NewPodman()
+
+	args := strings.Join(rt.buildRunArgs(context.Background(), env, cfg, "/p", st, "alca-p", Capabilities{Rootless: true}), " ")
+	if strings.Contains(args, "-m 2g") || strings.Contains(args, "--cpus") {
+		t.Errorf("rootless cgroup v1 should drop limits: %s", args)
+	}
+
+	args = strings.Join(rt.buildRunArgs(context.Background(), env, cfg, "/p", st, "alca-p", Capabilities{Rootless: true, CgroupV2: true}), " ")
+	if !strings.Contains(args, "-m 2g") || !strings.Contains(args, "--cpus 2") {
+		t.Errorf("rootless cgroup v2 should keep limits: %s", args)
+	}
+}
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+// ErrClockSyncUnsupported is returned by SyncClock when the runtime shares
+// the host kernel's clock, so there is no VM clock to correct.
+var ErrClockSyncUnsupported = errors.New("clock correction is only supported for VM-backed runtimes")
+
+// clockNow is the host clock, replaceable in tests.
+var clockNow = time.Now
+
+// ClockDrift returns how far the container's clock is ahead of the host's
+// (negative when behind). The container reports whole seconds, so the
+// result is accurate to about a second.
+func (r *dockerCLICompatibleRuntime) ClockDrift(ctx context.Context, env *RuntimeEnv, containerName string) (time.Duration, error) {
+	before := clockNow()
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "exec", containerName, "date", "+%s")
+	after := clockNow()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read container clock: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	secs, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse container clock %q: %w", strings.TrimSpace(string(output)), err)
+	}
+	// Compare against the midpoint of the exec round trip.
+	host := before.Add(after.Sub(before) / 2)
+	return time.Unix(secs, 0).Sub(host.Truncate(time.Second)), nil
+}
+
+// SyncClock steps the runtime VM's clock to the host's time. Containers
+// share the VM kernel clock, so this fixes every container at once.
+//
+// Podman machine runs chronyd, which is told to step immediately. Docker
+// Desktop, OrbStack and Colima have no exposed time service, so the clock is set
+// from a short-lived privileged container using the sandbox image.
+func (r *dockerCLICompatibleRuntime) SyncClock(ctx context.Context, env *RuntimeEnv, cfg *config.Config) error {
+	return r.syncClock(ctx, env, cfg, DetectPlatform(ctx, env))
+}
+
+func (r *dockerCLICompatibleRuntime) syncClock(ctx context.Context, env *RuntimeEnv, cfg *config.Config, platform RuntimePlatform) error {
+	if !IsDarwin(platform) {
+		return ErrClockSyncUnsupported
+	}
+
+	var output []byte
+	var err error
+	if r.machine {
+		output, err = env.Cmd.RunQuiet(ctx, r.command, "machine", "ssh", "sudo", "chronyc", "-a", "makestep")
+	} else {
+		args := []string{"run", "--rm", "--privileged"}
+		if cfg.Platform != "" {
+			args = append(args, "--platform", cfg.Platform)
+		}
+		args = append(args, "--entrypoint", "date", cfg.Image, "-u", "-s", "@"+strconv.FormatInt(clockNow().Unix(), 10))
+		output, err = env.Cmd.RunQuiet(ctx, r.command, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to correct VM clock: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func stubClock(t *testing.T, now time.Time) {
+	t.Helper()
+	orig := clockNow
+	clockNow = func() time.Time { return now }
+	t.Cleanup(func() { clockNow = orig })
+}
+
+func TestClockDrift(t *testing.T) {
+	stubClock(t, time.Unix(1_700_000_000, 400_000_000))
+
+	tests := []struct {
+		name   string
+		output string
+		want   time.Duration
+	}{
+		{"in sync", "1700000000\n", 0},
+		{"behind", "1699999958\n", -42 * time.Second},
+		{"ahead", "1700000003\n", 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := util.NewMockCommandRunner()
+			cmd.ExpectSuccess("docker exec alca-test date +%s", []byte(tt.output))
+			defer cmd.AssertAllExpectationsMet(t)
+
+			got, err := NewDocker().ClockDrift(context.Background(), NewRuntimeEnv(cmd), "alca-test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ClockDrift() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClockDrift_BadOutput(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker exec alca-test date +%s", []byte("Thu Jan  1 00:00:00 UTC 1970"))
+
+	if _, err := NewDocker().ClockDrift(context.Background(), NewRuntimeEnv(cmd), "alca-test"); err == nil {
+		t.Fatal("expected error for non-numeric date output")
+	}
+}
+
+func TestSyncClock(t *testing.T) {
+	stubClock(t, time.Unix(1_700_000_000, 0))
+	cfg := &config.Config{Image: testImage}
+
+	t.Run("docker desktop sets the clock from a privileged container", func(t *testing.T) {
+		cmd := util.NewMockCommandRunner()
+		cmd.ExpectSuccess("docker run --rm --privileged --entrypoint date "+testImage+" -u -s @1700000000", nil)
+		defer cmd.AssertAllExpectationsMet(t)
+
+		if err := NewDocker().syncClock(context.Background(), NewRuntimeEnv(cmd), cfg, PlatformMacDockerDesktop); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("podman machine steps chrony", func(t *testing.T) {
+		cmd := util.NewMockCommandRunner()
+		cmd.ExpectSuccess("podman machine ssh sudo chronyc -a makestep", nil)
+		defer cmd.AssertAllExpectationsMet(t)
+
+		if err := NewPodman().syncClock(context.Background(), NewRuntimeEnv(cmd), cfg, PlatformMacDockerDesktop); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("linux shares the host clock", func(t *testing.T) {
+		cmd := util.NewMockCommandRunner()
+		err := NewDocker().syncClock(context.Background(), NewRuntimeEnv(cmd), cfg, PlatformLinux)
+		if !errors.Is(err, ErrClockSyncUnsupported) {
+			t.Errorf("syncClock() error = %v, want ErrClockSyncUnsupported", err)
+		}
+		if len(cmd.CallKeys()) != 0 {
+			t.Errorf("expected no commands, got %v", cmd.CallKeys())
+		}
+	})
+}
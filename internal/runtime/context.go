@@ -0,0 +1,113 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+// Environment variables that select the container runtime endpoint.
+// They are read by the docker/podman CLIs and by Mutagen's docker transport.
+const (
+	EnvDockerHost       = "DOCKER_HOST"
+	EnvDockerContext    = "DOCKER_CONTEXT"
+	EnvPodmanHost       = "CONTAINER_HOST"
+	EnvPodmanConnection = "CONTAINER_CONNECTION"
+)
+
+// ErrRuntimeContextConflict is returned when runtime_context is configured but
+// DOCKER_HOST is also set, which would silently take precedence in the docker CLI.
+var ErrRuntimeContextConflict = errors.New("runtime context conflicts with DOCKER_HOST")
+
+// ApplyRuntimeContext selects the configured named context for every runtime
+// invocation of this process by exporting DOCKER_CONTEXT (Docker) and
+// CONTAINER_CONNECTION (Podman). All docker, podman and mutagen child
+// processes inherit it. Mutagen records these variables when a sync session
+// is created, so docker:// sync targets resolve against the same daemon.
+func ApplyRuntimeContext(cfg *config.Config) error {
+	if cfg.RuntimeContext == "" {
+		return nil
+	}
+	if os.Getenv(EnvDockerHost) != "" {
+		return fmt.Errorf("%w: unset %s to use runtime_context %q", ErrRuntimeContextConflict, EnvDockerHost, cfg.RuntimeContext)
+	}
+	if err := os.Setenv(EnvDockerContext, cfg.RuntimeContext); err != nil {
+		return err
+	}
+	return os.Setenv(EnvPodmanConnection, cfg.RuntimeContext)
+}
+
+// DockerHost returns a DOCKER_HOST value that points docker-compatible
+// clients on the host (docker compose, testcontainers) at rt's daemon, or
+// "" when their default endpoint already is that daemon. Only Podman on
+// Linux needs one: elsewhere its API socket is inside the machine VM.
+func DockerHost(ctx context.Context, env *RuntimeEnv, rt Runtime) string {
+	p, ok := rt.(*Podman)
+	if !ok || runtime.GOOS != "linux" || hasEndpointOverride() {
+		return ""
+	}
+	output, err := env.Cmd.RunQuiet(ctx, p.command, "info", "--format", p.socketFormat)
+	path := strings.TrimSpace(string(output))
+	if err != nil || path == "" {
+		return ""
+	}
+	return "unix://" + path
+}
+
+// hasEndpointOverride returns true if any runtime endpoint variable is set.
+func hasEndpointOverride() bool {
+	for _, key := range []string{EnvDockerHost, EnvDockerContext, EnvPodmanHost, EnvPodmanConnection} {
+		if os.Getenv(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isRemoteDaemon reports whether the selected runtime endpoint is on another
+// machine (ssh://, tcp://), in which case host paths cannot be bind-mounted.
+func isRemoteDaemon(ctx context.Context, env *RuntimeEnv) bool {
+	if host := os.Getenv(EnvDockerHost); host != "" {
+		return isRemoteEndpoint(host)
+	}
+	if name := os.Getenv(EnvDockerContext); name != "" && name != "default" {
+		output, err := env.Cmd.RunQuiet(ctx, "docker", "context", "inspect", name,
+			"--format", "{{.Endpoints.docker.Host}}")
+		if err == nil && isRemoteEndpoint(strings.TrimSpace(string(output))) {
+			return true
+		}
+	}
+	if host := os.Getenv(EnvPodmanHost); host != "" {
+		return isRemoteEndpoint(host)
+	}
+	if name := os.Getenv(EnvPodmanConnection); name != "" {
+		output, err := env.Cmd.RunQuiet(ctx, "podman", "system", "connection", "list",
+			"--format", "{{.Name}}\t{{.URI}}")
+		if err == nil {
+			for _, line := range strings.Split(string(output), "\n") {
+				connName, uri, ok := strings.Cut(strings.TrimSpace(line), "\t")
+				if ok && connName == name {
+					return isRemoteEndpoint(uri)
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isRemoteEndpoint returns true for endpoints that are not local sockets.
+func isRemoteEndpoint(host string) bool {
+	if host == "" {
+		return false
+	}
+	scheme, _, ok := strings.Cut(host, "://")
+	if !ok {
+		return false
+	}
+	return scheme != "unix" && scheme != "npipe"
+}
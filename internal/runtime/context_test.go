@@ -0,0 +1,130 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"os"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// clearEndpointEnv unsets all runtime endpoint variables for the test.
+func clearEndpointEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{EnvDockerHost, EnvDockerContext, EnvPodmanHost, EnvPodmanConnection} {
+		t.Setenv(key, "")
+		_ = os.Unsetenv(key)
+	}
+}
+
+func TestApplyRuntimeContext(t *testing.T) {
+	clearEndpointEnv(t)
+
+	if err := ApplyRuntimeContext(&config.Config{RuntimeContext: "remote-builder"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := os.Getenv(EnvDockerContext); got != "remote-builder" {
+		t.Errorf("%s = %q, want remote-builder", EnvDockerContext, got)
+	}
+	if got := os.Getenv(EnvPodmanConnection); got != "remote-builder" {
+		t.Errorf("%s = %q, want remote-builder", EnvPodmanConnection, got)
+	}
+}
+
+func TestApplyRuntimeContext_Empty(t *testing.T) {
+	clearEndpointEnv(t)
+
+	if err := ApplyRuntimeContext(&config.Config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasEndpointOverride() {
+		t.Error("expected no endpoint override without runtime_context")
+	}
+}
+
+func TestApplyRuntimeContext_DockerHostConflict(t *testing.T) {
+	clearEndpointEnv(t)
+	t.Setenv(EnvDockerHost, "tcp://10.0.0.5:2376")
+
+	err := ApplyRuntimeContext(&config.Config{RuntimeContext: "remote-builder"})
+	if !errors.Is(err, ErrRuntimeContextConflict) {
+		t.Fatalf("expected ErrRuntimeContextConflict, got %v", err)
+	}
+}
+
+func TestIsRemoteEndpoint(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"", false},
+		{"unix:///var/run/docker.sock", false},
+		{"npipe:////./pipe/docker_engine", false},
+		{"ssh://builder@10.0.0.5", true},
+		{"tcp://10.0.0.5:2376", true},
+	}
+
+	for _, tt := range tests {
+		if got := isRemoteEndpoint(tt.host); got != tt.want {
+			t.Errorf("isRemoteEndpoint(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestIsRemoteDaemon_DockerContext(t *testing.T) {
+	clearEndpointEnv(t)
+	t.Setenv(EnvDockerContext, "remote-builder")
+
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker context inspect remote-builder --format {{.Endpoints.docker.Host}}",
+		[]byte("ssh://builder@10.0.0.5\n"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	if !isRemoteDaemon(context.Background(), NewRuntimeEnv(cmd)) {
+		t.Error("expected ssh:// context to be remote")
+	}
+}
+
+func TestIsRemoteDaemon_PodmanConnection(t *testing.T) {
+	clearEndpointEnv(t)
+	t.Setenv(EnvPodmanConnection, "builder")
+
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("podman system connection list --format {{.Name}}\t{{.URI}}",
+		[]byte("local\tunix:///run/podman/podman.sock\nbuilder\tssh://core@10.0.0.5:22/run/podman/podman.sock\n"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	if !isRemoteDaemon(context.Background(), NewRuntimeEnv(cmd)) {
+		t.Error("expected ssh:// connection to be remote")
+	}
+}
+
+func TestDockerHost(t *testing.T) {
+	clearEndpointEnv(t)
+	ctx := context.Background()
+
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("podman info --format {{.Host.RemoteSocket.Path}}", []byte("/run/user/1000/podman/podman.sock\n"))
+
+	got := DockerHost(ctx, NewRuntimeEnv(cmd), NewPodman())
+	if goruntime.GOOS == "linux" {
+		if got != "unix:///run/user/1000/podman/podman.sock" {
+			t.Errorf("DockerHost(podman) = %q", got)
+		}
+		cmd.AssertAllExpectationsMet(t)
+	} else if got != "" {
+		t.Errorf("DockerHost(podman) = %q on %s, want empty", got, goruntime.GOOS)
+	}
+
+	if got := DockerHost(ctx, NewRuntimeEnv(util.NewMockCommandRunner()), NewDocker()); got != "" {
+		t.Errorf("DockerHost(docker) = %q, want empty", got)
+	}
+
+	t.Setenv(EnvDockerHost, "unix:///tmp/other.sock")
+	if got := DockerHost(ctx, NewRuntimeEnv(util.NewMockCommandRunner()), NewPodman()); got != "" {
+		t.Errorf("DockerHost with DOCKER_HOST set = %q, want empty", got)
+	}
+}
@@ -0,0 +1,163 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrPathNotFound is returned when a copy source does not exist in the container.
+var ErrPathNotFound = errors.New("path not found in container")
+
+// CopyFromContainer copies src inside the container to dst on the host.
+func (r *dockerCLICompatibleRuntime) CopyFromContainer(ctx context.Context, env *RuntimeEnv, containerName, src, dst string) error {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "cp", containerName+":"+src, dst)
+	if err != nil {
+		if containsPathNotFound(string(output)) {
+			return fmt.Errorf("%w: %s", ErrPathNotFound, src)
+		}
+		return fmt.Errorf("%s cp failed: %w: %s", r.command, err, string(output))
+	}
+	return nil
+}
+
+// CopyToContainer copies src on the host to dst inside the container.
+// A src ending in "/." copies the directory contents rather than the directory itself.
+func (r *dockerCLICompatibleRuntime) CopyToContainer(ctx context.Context, env *RuntimeEnv, containerName, src, dst string) error {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "cp", src, containerName+":"+dst)
+	if err != nil {
+		return fmt.Errorf("%s cp failed: %w: %s", r.command, err, string(output))
+	}
+	return nil
+}
+
+// stageLabel marks the volumes of a stage (see StagePaths) with its name.
+const stageLabel = "alca.stage"
+
+// stageRoot is where a stage container mounts its volumes: each staged
+// path under its own container path, e.g. /alca-stage/root/.cache.
+const stageRoot = "/alca-stage"
+
+// copyStreamScript pipes '$1 cp $2 -' into '$1 cp - $3', so the tar
+// stream goes from one container to another without touching the host
+// disk. Plain sh has no pipefail: each side reports its exit code on fd 3,
+// and the script exits with the first failing one.
+const copyStreamScript = `exec 4>&1
+codes=$( { { "$1" cp "$2" - 3>&- 4>&-; echo "$?" >&3; } | "$1" cp - "$3" 3>&- >&4; echo "$?" >&3; } 3>&1 )
+for code in $codes; do
+	[ "$code" -eq 0 ] || exit "$code"
+done`
+
+// copyBetweenContainers copies src (container:path) into the directory dst
+// (container:dir), as 'cp src dst' would through the host.
+func (r *dockerCLICompatibleRuntime) copyBetweenContainers(ctx context.Context, env *RuntimeEnv, src, dst string) error {
+	output, err := env.Cmd.RunQuiet(ctx, "sh", "-c", copyStreamScript, "sh", r.command, src, dst)
+	if err != nil {
+		if containsPathNotFound(string(output)) {
+			return fmt.Errorf("%w: %s", ErrPathNotFound, src)
+		}
+		return fmt.Errorf("%s cp failed: %w: %s", r.command, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// StagePaths creates the stage container, never started, from the image of
+// containerName with one labeled volume per path, and copies each path into
+// its volume.
+func (r *dockerCLICompatibleRuntime) StagePaths(ctx context.Context, env *RuntimeEnv, containerName, stage string, paths []string) ([]string, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "inspect", "--format", "{{.Config.Image}}", containerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s: %w: %s", containerName, err, strings.TrimSpace(string(output)))
+	}
+	image := strings.TrimSpace(string(output))
+
+	args := []string{"create", "--name", stage, "--label", stageLabel + "=" + stage, "--entrypoint", "true"}
+	for i, p := range paths {
+		volume := fmt.Sprintf("%s-%d", stage, i)
+		if output, err := env.Cmd.RunQuiet(ctx, r.command, "volume", "create", "--label", stageLabel+"="+stage, volume); err != nil {
+			return nil, fmt.Errorf("failed to create volume %s: %w: %s", volume, err, strings.TrimSpace(string(output)))
+		}
+		args = append(args, "-v", volume+":"+path.Join(stageRoot, p))
+	}
+	if output, err := env.Cmd.RunQuiet(ctx, r.command, append(args, image)...); err != nil {
+		return nil, fmt.Errorf("failed to create stage container %s: %w: %s", stage, err, strings.TrimSpace(string(output)))
+	}
+
+	var staged []string
+	for _, p := range paths {
+		err := r.copyBetweenContainers(ctx, env, containerName+":"+p, stage+":"+path.Dir(path.Join(stageRoot, p)))
+		if errors.Is(err, ErrPathNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stage %s: %w", p, err)
+		}
+		staged = append(staged, p)
+	}
+	return staged, nil
+}
+
+// RestoreStagedPaths copies each staged path into its parent directory in
+// the running container, so an existing directory is merged into.
+func (r *dockerCLICompatibleRuntime) RestoreStagedPaths(ctx context.Context, env *RuntimeEnv, stage, containerName string, paths []string) error {
+	for _, p := range paths {
+		parent := path.Dir(p)
+		if output, err := env.Cmd.RunQuiet(ctx, r.command, "exec", "-u", "root", containerName, "mkdir", "-p", parent); err != nil {
+			return fmt.Errorf("failed to create %s in container: %w: %s", parent, err, strings.TrimSpace(string(output)))
+		}
+		if err := r.copyBetweenContainers(ctx, env, stage+":"+path.Join(stageRoot, p), containerName+":"+parent); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// RemoveStage removes the stage container and the volumes labeled with it.
+func (r *dockerCLICompatibleRuntime) RemoveStage(ctx context.Context, env *RuntimeEnv, stage string) error {
+	if output, err := env.Cmd.RunQuiet(ctx, r.command, "rm", "-f", stage); err != nil && !strings.Contains(strings.ToLower(string(output)), "no such container") {
+		return fmt.Errorf("failed to remove stage container %s: %w: %s", stage, err, strings.TrimSpace(string(output)))
+	}
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "volume", "ls", "-q", "--filter", "label="+stageLabel+"="+stage)
+	if err != nil {
+		return fmt.Errorf("failed to list stage volumes: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	volumes := strings.Fields(string(output))
+	if len(volumes) == 0 {
+		return nil
+	}
+	if output, err := env.Cmd.RunQuiet(ctx, r.command, append([]string{"volume", "rm"}, volumes...)...); err != nil {
+		return fmt.Errorf("failed to remove stage volumes: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// writeFileScript replaces $1 with $ALCA_FILE_CONTENT through a temporary
+// file in the same directory, so readers and concurrent writers only ever
+// see a complete file.
+const writeFileScript = `set -e
+mkdir -p "$(dirname "$1")"
+tmp=$(mktemp "$1.XXXXXX")
+printf '%s' "$ALCA_FILE_CONTENT" > "$tmp"
+chmod 644 "$tmp"
+mv -f "$tmp" "$1"`
+
+// WriteFile atomically replaces path in the running container with content.
+func (r *dockerCLICompatibleRuntime) WriteFile(ctx context.Context, env *RuntimeEnv, containerName, path, content string) error {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "exec", "-u", "root",
+		"-e", "ALCA_FILE_CONTENT="+content,
+		containerName, "sh", "-c", writeFileScript, "sh", path)
+	if err != nil {
+		return fmt.Errorf("failed to write %s in container: %w: %s", path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// containsPathNotFound checks if cp output reports a missing source path.
+// Docker says "Could not find the file", Podman says "no such file or directory".
+func containsPathNotFound(output string) bool {
+	lower := strings.ToLower(output)
+	return strings.Contains(lower, "could not find the file") ||
+		strings.Contains(lower, "no such file or directory")
+}
@@ -0,0 +1,144 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestCopyFromContainer_NotFound(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		rt      Runtime
+		output  string
+	}{
+		{"docker", "docker", NewDocker(), "Error response from daemon: Could not find the file /root/.cache in container alca-test"},
+		{"podman", "podman", NewPodman(), "Error: \"/root/.cache\" could not be found on container alca-test: no such file or directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := util.NewMockCommandRunner()
+			cmd.Expect(tt.command+" cp alca-test:/root/.cache /tmp/backup/0", []byte(tt.output), errors.New("exit status 1"))
+			defer cmd.AssertAllExpectationsMet(t)
+
+			err := tt.rt.CopyFromContainer(context.Background(), NewRuntimeEnv(cmd), "alca-test", "/root/.cache", "/tmp/backup/0")
+			if !errors.Is(err, ErrPathNotFound) {
+				t.Fatalf("expected ErrPathNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCopyToContainer(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker cp /tmp/backup/0/. alca-test:/root/.cache", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	err := NewDocker().CopyToContainer(context.Background(), NewRuntimeEnv(cmd), "alca-test", "/tmp/backup/0/.", "/root/.cache")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker exec -u root -e ALCA_FILE_CONTENT=export A='1'\n alca-test sh -c "+writeFileScript+" sh /run/alca/env", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	err := NewDocker().WriteFile(context.Background(), NewRuntimeEnv(cmd), "alca-test", "/run/alca/env", "export A='1'\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStagePaths(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker inspect --format {{.Config.Image}} alca-test", []byte("alpine:3\n"))
+	cmd.ExpectSuccess("docker volume create --label alca.stage=stage stage-0", nil)
+	cmd.ExpectSuccess("docker volume create --label alca.stage=stage stage-1", nil)
+	cmd.ExpectSuccess("docker create --name stage --label alca.stage=stage --entrypoint true -v stage-0:/alca-stage/root/.cache -v stage-1:/alca-stage/root/.missing alpine:3", nil)
+	cmd.ExpectSuccess("sh -c "+copyStreamScript+" sh docker alca-test:/root/.cache stage:/alca-stage/root", nil)
+	cmd.Expect("sh -c "+copyStreamScript+" sh docker alca-test:/root/.missing stage:/alca-stage/root",
+		[]byte("Error response from daemon: Could not find the file /root/.missing in container alca-test"), errors.New("exit status 1"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	staged, err := NewDocker().StagePaths(context.Background(), NewRuntimeEnv(cmd), "alca-test", "stage", []string{"/root/.cache", "/root/.missing"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(staged) != 1 || staged[0] != "/root/.cache" {
+		t.Errorf("staged = %v, want [/root/.cache]", staged)
+	}
+}
+
+func TestRestoreStagedPaths(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("podman exec -u root alca-test mkdir -p /root", nil)
+	cmd.ExpectSuccess("sh -c "+copyStreamScript+" sh podman stage:/alca-stage/root/.cache alca-test:/root", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	if err := NewPodman().RestoreStagedPaths(context.Background(), NewRuntimeEnv(cmd), "stage", "alca-test", []string{"/root/.cache"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRemoveStage(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	// A stage whose container is already gone still has its volumes removed
+	cmd.Expect("podman rm -f stage", []byte("Error: no such container stage"), errors.New("exit status 1"))
+	cmd.ExpectSuccess("podman volume ls -q --filter label=alca.stage=stage", []byte("stage-0\nstage-1\n"))
+	cmd.ExpectSuccess("podman volume rm stage-0 stage-1", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	if err := NewPodman().RemoveStage(context.Background(), NewRuntimeEnv(cmd), "stage"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// fakeCpCLI stands in for 'docker cp': "cp SRC -" writes SRC to stdout,
+// failing like docker for a missing path; "cp - DST" reads stdin into DST,
+// failing for DST "bad:/".
+const fakeCpCLI = `#!/bin/sh
+if [ "$3" = "-" ]; then
+	[ "$2" = "missing:/" ] && { echo "Error response from daemon: Could not find the file /" >&2; exit 1; }
+	echo "$2"
+	exit 0
+fi
+[ "$3" = "bad:/" ] && { cat >/dev/null; echo "Error: read-only file system" >&2; exit 3; }
+cat >/dev/null
+`
+
+func TestCopyBetweenContainers_ExitCodes(t *testing.T) {
+	cli := filepath.Join(t.TempDir(), "docker")
+	if err := afero.WriteFile(afero.NewOsFs(), cli, []byte(fakeCpCLI), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	r := &dockerCLICompatibleRuntime{command: cli}
+	env := NewRuntimeEnv(util.NewCommandRunner())
+
+	tests := []struct {
+		name         string
+		src, dst     string
+		wantErr      bool
+		wantNotFound bool
+	}{
+		{"copies", "ok:/", "ok:/", false, false},
+		{"missing source", "missing:/", "ok:/", true, true},
+		{"failing destination", "ok:/", "bad:/", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.copyBetweenContainers(context.Background(), env, tt.src, tt.dst)
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrPathNotFound) != tt.wantNotFound {
+				t.Errorf("copyBetweenContainers() error = %v, wantErr %v, wantNotFound %v", err, tt.wantErr, tt.wantNotFound)
+			}
+		})
+	}
+}
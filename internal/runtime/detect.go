@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/util"
@@ -19,6 +20,18 @@ var (
 	platformCache   = make(map[*RuntimeEnv]RuntimePlatform)
 )
 
+// probeTimeout bounds quick daemon queries (version, info), so a hung daemon
+// fails detection with a timeout instead of blocking the command forever.
+var probeTimeout = 15 * time.Second
+
+// probe runs a quick daemon query bounded by probeTimeout.
+func probe(ctx context.Context, env *RuntimeEnv, command string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	output, err := env.Cmd.RunQuiet(ctx, command, args...)
+	return output, util.TimeoutError(ctx, command+" "+args[0], probeTimeout, err)
+}
+
 // RuntimePlatform represents the detected platform for mount strategy decisions.
 // See AGD-025 for platform-specific mount optimization.
 type RuntimePlatform string
@@ -30,6 +43,14 @@ const (
 	PlatformMacDockerDesktop RuntimePlatform = "docker-desktop"
 	// PlatformMacOrbStack represents macOS with OrbStack.
 	PlatformMacOrbStack RuntimePlatform = "orbstack"
+	// PlatformMacColima represents macOS with Colima (Docker in a Lima VM).
+	PlatformMacColima RuntimePlatform = "colima"
+	// PlatformMacPodman represents macOS with a Podman machine (Podman in a
+	// Fedora CoreOS VM) serving the Docker API.
+	PlatformMacPodman RuntimePlatform = "podman-machine"
+	// PlatformRemote represents a daemon on another machine (runtime_context,
+	// DOCKER_HOST). Host paths are not visible to it, so all mounts use Mutagen.
+	PlatformRemote RuntimePlatform = "remote"
 )
 
 // DetectPlatform returns the current runtime platform.
@@ -37,8 +58,8 @@ const (
 // See AGD-025 for platform detection rationale.
 // Results are cached per RuntimeEnv instance to avoid repeated shell calls.
 func DetectPlatform(ctx context.Context, env *RuntimeEnv) RuntimePlatform {
-	// Fast path for Linux - no shell calls needed
-	if runtime.GOOS == "linux" {
+	// Fast path for local Linux - no shell calls needed
+	if runtime.GOOS == "linux" && !hasEndpointOverride() {
 		return PlatformLinux
 	}
 
@@ -52,9 +73,16 @@ func DetectPlatform(ctx context.Context, env *RuntimeEnv) RuntimePlatform {
 
 	// Detect platform (requires shell call)
 	var platform RuntimePlatform
-	isOrb, err := IsOrbStack(ctx, env)
-	if err == nil && isOrb {
+	if isRemoteDaemon(ctx, env) {
+		platform = PlatformRemote
+	} else if runtime.GOOS == "linux" {
+		platform = PlatformLinux
+	} else if isOrb, err := IsOrbStack(ctx, env); err == nil && isOrb {
 		platform = PlatformMacOrbStack
+	} else if isColima, err := IsColima(ctx, env); err == nil && isColima {
+		platform = PlatformMacColima
+	} else if isPodman, err := IsPodmanMachine(ctx, env); err == nil && isPodman {
+		platform = PlatformMacPodman
 	} else {
 		platform = PlatformMacDockerDesktop
 	}
@@ -67,9 +95,14 @@ func DetectPlatform(ctx context.Context, env *RuntimeEnv) RuntimePlatform {
 	return platform
 }
 
-// IsDarwin returns true if the platform is macOS (OrbStack or Docker Desktop).
+// IsDarwin returns true if the platform is macOS (OrbStack, Docker Desktop,
+// Colima or a Podman machine). All of them run containers in a Linux VM.
 func IsDarwin(platform RuntimePlatform) bool {
-	return platform == PlatformMacOrbStack || platform == PlatformMacDockerDesktop
+	switch platform {
+	case PlatformMacOrbStack, PlatformMacDockerDesktop, PlatformMacColima, PlatformMacPodman:
+		return true
+	}
+	return false
 }
 
 // ShouldUseMutagen determines if Mutagen sync should be used for a mount.
@@ -82,17 +115,26 @@ func IsDarwin(platform RuntimePlatform) bool {
 // | macOS + Docker Desktop| Always       | Yes         |
 // | macOS + OrbStack      | Has excludes | Yes         |
 // | macOS + OrbStack      | No excludes  | No          |
+// | macOS + Colima        | Has excludes | Yes         |
+// | macOS + Colima        | No excludes  | No          |
+// | macOS + Podman machine| Always       | Yes         |
+// | Remote daemon         | Always       | Yes         |
 //
 // Rationale:
 // - Docker Desktop has poor bind mount performance (~35%), Mutagen brings it to ~90-95%
 // - OrbStack already achieves 75-95% native performance, Mutagen overhead unnecessary without excludes
+// - Colima shares files with virtiofs by default (vz VMs), close to OrbStack's performance
+// - Podman machine is treated like Docker Desktop, whose VM file sharing it resembles
 // - Linux bind mounts are native performance (100%), Mutagen adds sync latency (50-200ms)
+// - A remote daemon cannot see host paths at all, so bind mounts are not an option
 func ShouldUseMutagen(platform RuntimePlatform, hasExcludes bool) bool {
 	switch platform {
-	case PlatformMacDockerDesktop:
-		// Always use Mutagen on Docker Desktop for performance
+	case PlatformMacDockerDesktop, PlatformMacPodman, PlatformRemote:
+		// Always use Mutagen on Docker Desktop and Podman machine for
+		// performance, and on remote daemons because bind mounts would
+		// refer to the remote host
 		return true
-	case PlatformMacOrbStack, PlatformLinux:
+	case PlatformMacOrbStack, PlatformMacColima, PlatformLinux:
 		// Only use Mutagen when excludes are needed
 		return hasExcludes
 	default:
@@ -118,13 +160,21 @@ func SelectRuntime(ctx context.Context, env *RuntimeEnv, cfg *config.Config) (Ru
 
 // SelectRuntimeWithOutput returns a runtime with optional progress output.
 func SelectRuntimeWithOutput(ctx context.Context, env *RuntimeEnv, cfg *config.Config, progressOut io.Writer) (Runtime, error) {
+	if fakeSelected() {
+		return SharedFake(), nil
+	}
+
+	if err := ApplyRuntimeContext(cfg); err != nil {
+		return nil, err
+	}
+
 	runtimeType := cfg.NormalizeRuntime()
 
 	// Handle explicit runtime configuration
 	if runtimeType == config.RuntimeDocker {
 		docker := NewDocker()
 		if !docker.Available(ctx, env) {
-			return nil, fmt.Errorf("Docker not available (configured runtime=docker)")
+			return nil, runtimeUnavailable("Docker not available (configured runtime=docker)")
 		}
 		return docker, nil
 	}
@@ -153,7 +203,7 @@ func selectLinuxRuntime(ctx context.Context, env *RuntimeEnv, progressOut io.Wri
 		return docker, nil
 	}
 
-	return nil, fmt.Errorf("no container runtime available: neither Podman nor Docker found")
+	return nil, runtimeUnavailable("no container runtime available: neither Podman nor Docker found")
 }
 
 // selectDefaultRuntime tries Docker as fallback for unsupported platforms.
@@ -162,7 +212,17 @@ func selectDefaultRuntime(ctx context.Context, env *RuntimeEnv, progressOut io.W
 	if docker.Available(ctx, env) {
 		return docker, nil
 	}
-	return nil, fmt.Errorf("no container runtime available: Docker not found")
+	return nil, runtimeUnavailable("no container runtime available: Docker not found")
+}
+
+// runtimeUnavailable returns a CodeRuntimeUnavailable error. Runtimes are
+// probed with 'version', which fails both when the CLI is missing and when
+// its daemon or VM is not running, so the hint covers both.
+func runtimeUnavailable(message string) error {
+	return util.NewHintedError(util.CodeRuntimeUnavailable, message,
+		"Install Docker, OrbStack or Podman, or start its daemon: open Docker Desktop or OrbStack, "+
+			"or run 'colima start', 'podman machine start' or 'sudo systemctl start docker'. "+
+			"'docker version' (or 'podman version') should then show the server.", nil)
 }
 
 // All returns all supported runtime implementations.
@@ -188,17 +248,40 @@ func ByName(name string) Runtime {
 // IsOrbStack returns true if Docker is running on OrbStack.
 // It checks the Docker info output for "OrbStack" in the OperatingSystem field.
 func IsOrbStack(ctx context.Context, env *RuntimeEnv) (bool, error) {
-	output, err := env.Cmd.RunQuiet(ctx, "docker", "info", "--format", "{{.OperatingSystem}}")
+	output, err := probe(ctx, env, "docker", "info", "--format", "{{.OperatingSystem}}")
 	if err != nil {
 		return false, fmt.Errorf("failed to get docker info: %w", err)
 	}
 	return strings.Contains(string(output), "OrbStack"), nil
 }
 
+// IsColima returns true if Docker is running in a Colima VM. Colima names
+// the VM "colima", or "colima-<profile>" for other profiles, and the daemon
+// reports the VM hostname as its name.
+func IsColima(ctx context.Context, env *RuntimeEnv) (bool, error) {
+	output, err := probe(ctx, env, "docker", "info", "--format", "{{.Name}}")
+	if err != nil {
+		return false, fmt.Errorf("failed to get docker info: %w", err)
+	}
+	name := strings.TrimSpace(string(output))
+	return name == "colima" || strings.HasPrefix(name, "colima-"), nil
+}
+
+// IsPodmanMachine returns true if the Docker API is served by Podman, which
+// on macOS runs in a Podman machine. Podman lists itself as the "Podman
+// Engine" server component.
+func IsPodmanMachine(ctx context.Context, env *RuntimeEnv) (bool, error) {
+	output, err := probe(ctx, env, "docker", "version", "--format", "{{range .Server.Components}}{{.Name}};{{end}}")
+	if err != nil {
+		return false, fmt.Errorf("failed to get docker version: %w", err)
+	}
+	return strings.Contains(string(output), "Podman Engine"), nil
+}
+
 // IsRootlessPodman returns true if Podman is running in rootless mode.
 // See AGD-025 for why rootless Podman blocks mount excludes.
 func IsRootlessPodman(ctx context.Context, env *RuntimeEnv) (bool, error) {
-	output, err := env.Cmd.RunQuiet(ctx, "podman", "info", "--format", "{{.Host.Security.Rootless}}")
+	output, err := probe(ctx, env, "podman", "info", "--format", "{{.Host.Security.Rootless}}")
 	if err != nil {
 		return false, fmt.Errorf("failed to get podman info: %w", err)
 	}
@@ -206,9 +289,10 @@ func IsRootlessPodman(ctx context.Context, env *RuntimeEnv) (bool, error) {
 }
 
 // ErrMutagenNotFound is returned when Mutagen is required but not installed.
-var ErrMutagenNotFound = fmt.Errorf("mutagen is required but not installed.\n\n" +
-	"Install Mutagen: https://mutagen.io/documentation/introduction/installation/\n\n" +
-	"Mutagen is needed when mount excludes are configured (workdir_exclude or mounts.exclude)")
+var ErrMutagenNotFound = util.NewHintedError(util.CodeMutagenMissing,
+	"mutagen is required but not installed",
+	"Install Mutagen: https://mutagen.io/documentation/introduction/installation/\n"+
+		"Mutagen is needed when mount excludes are configured (workdir_exclude or mounts.exclude).", nil)
 
 // minMutagen is the minimum Mutagen version required on all platforms.
 // v0.18.0 has a known protocol handshake bug (mutagen-io/mutagen#531).
@@ -258,12 +342,13 @@ func checkMutagenMinVersion(version string, min [3]int) error {
 			return nil
 		}
 		if current[i] < min[i] {
-			return fmt.Errorf("mutagen %s has a known protocol bug that causes sync failures.\n\n"+
-				"Please upgrade to %d.%d.%d or later.\n"+
-				"  Homebrew: brew upgrade mutagen\n"+
-				"  Nix:      use a nixpkgs with mutagen >= %d.%d.%d\n\n"+
-				"See: https://github.com/mutagen-io/mutagen/issues/531",
-				version, min[0], min[1], min[2], min[0], min[1], min[2])
+			return util.NewHintedError(util.CodeMutagenOutdated,
+				fmt.Sprintf("mutagen %s has a known protocol bug that causes sync failures", version),
+				fmt.Sprintf("Upgrade to %d.%d.%d or later.\n"+
+					"  Homebrew: brew upgrade mutagen\n"+
+					"  Nix:      use a nixpkgs with mutagen >= %d.%d.%d\n"+
+					"See: https://github.com/mutagen-io/mutagen/issues/531",
+					min[0], min[1], min[2], min[0], min[1], min[2]), nil)
 		}
 	}
 	return nil // equal
@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -21,6 +22,9 @@ func TestIsDarwin(t *testing.T) {
 		{"Linux", PlatformLinux, false},
 		{"macOS OrbStack", PlatformMacOrbStack, true},
 		{"macOS Docker Desktop", PlatformMacDockerDesktop, true},
+		{"macOS Colima", PlatformMacColima, true},
+		{"macOS Podman machine", PlatformMacPodman, true},
+		{"Remote daemon", PlatformRemote, false},
 	}
 
 	for _, tt := range tests {
@@ -119,6 +123,9 @@ func TestSelectRuntime_DockerNotAvailable(t *testing.T) {
 	if !strings.Contains(err.Error(), "Docker not available") {
 		t.Errorf("unexpected error: %v", err)
 	}
+	if !errors.Is(err, &util.HintedError{Code: util.CodeRuntimeUnavailable}) {
+		t.Errorf("error %v is not a %s error", err, util.CodeRuntimeUnavailable)
+	}
 }
 
 func TestParseContainerState(t *testing.T) {
@@ -129,6 +136,7 @@ func TestParseContainerState(t *testing.T) {
 		{"running", StateRunning},
 		{"exited", StateStopped},
 		{"stopped", StateStopped},
+		{"paused", StatePaused},
 		{"unknown", StateUnknown},
 		{"", StateUnknown},
 		{"other", StateUnknown},
@@ -340,6 +348,37 @@ func TestBuildRunArgs(t *testing.T) {
 			contName: "alca-noports",
 			dontWant: []string{"-p"},
 		},
+		{
+			name: "isolation none disables networking",
+			cfg: &config.Config{
+				Image:   "test-image",
+				Workdir: "/workspace",
+				Mounts:  []config.MountConfig{{Source: ".", Target: "/workspace"}},
+				Network: config.Network{Isolation: config.IsolationNone},
+			},
+			projectDir: "/project",
+			state: &state.State{
+				ProjectID:     "uuid-nonet",
+				ContainerName: "alca-nonet",
+			},
+			contName:  "alca-nonet",
+			wantParts: []string{"--network", "none"},
+		},
+		{
+			name: "default isolation keeps the default network",
+			cfg: &config.Config{
+				Image:   "test-image",
+				Workdir: "/workspace",
+				Mounts:  []config.MountConfig{{Source: ".", Target: "/workspace"}},
+			},
+			projectDir: "/project",
+			state: &state.State{
+				ProjectID:     "uuid-net",
+				ContainerName: "alca-net",
+			},
+			contName: "alca-net",
+			dontWant: []string{"--network"},
+		},
 		{
 			name: "relative mount source resolved to projectDir",
 			cfg: &config.Config{
@@ -403,6 +442,41 @@ func TestBuildRunArgs(t *testing.T) {
 				"-v", "/tmp/data:/data",
 			},
 		},
+		{
+			name: "platform override",
+			cfg: &config.Config{
+				Image:    "test-image",
+				Workdir:  "/workspace",
+				Platform: "linux/amd64",
+			},
+			projectDir: "/project",
+			state: &state.State{
+				ProjectID:     "uuid-platform",
+				ContainerName: "alca-platform",
+			},
+			contName:  "alca-platform",
+			wantParts: []string{"--platform", "linux/amd64"},
+		},
+		{
+			name: "workdir_source outside project root",
+			cfg: &config.Config{
+				Image:         "test-image",
+				Workdir:       "/workspace",
+				WorkdirSource: "../monorepo",
+				Mounts: []config.MountConfig{
+					{Source: "../monorepo", Target: "/workspace"},
+				},
+			},
+			projectDir: "/home/user/project",
+			state: &state.State{
+				ProjectID:     "uuid-src",
+				ContainerName: "alca-src",
+			},
+			contName: "alca-src",
+			wantParts: []string{
+				"-v", "/home/user/monorepo:/workspace",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -415,7 +489,7 @@ func TestBuildRunArgs(t *testing.T) {
 			// On macOS, DetectPlatform defaults to DockerDesktop which always uses Mutagen.
 			mockCmd := util.NewMockCommandRunner().AllowUnexpected()
 			mockCmd.ExpectSuccess("docker info --format {{.OperatingSystem}}", []byte("OrbStack"))
-			args := rt.buildRunArgs(context.Background(), &RuntimeEnv{Cmd: mockCmd}, tt.cfg, tt.projectDir, tt.state, tt.contName)
+			args := rt.buildRunArgs(context.Background(), &RuntimeEnv{Cmd: mockCmd}, tt.cfg, tt.projectDir, tt.state, tt.contName, Capabilities{})
 
 			argsStr := strings.Join(args, " ")
 			for _, want := range tt.wantParts {
@@ -485,6 +559,37 @@ func TestBuildExecArgs(t *testing.T) {
 				"NO_OVERRIDE=static_val",
 			},
 		},
+		{
+			name: "exec with top-level user",
+			cfg: &config.Config{
+				Workdir: "/workspace",
+				User:    "dev",
+			},
+			containerName: "user-container",
+			command:       []string{"sh"},
+			wantParts:     []string{"-u dev -w /workspace user-container"},
+		},
+		{
+			name: "commands.enter user overrides top-level user",
+			cfg: &config.Config{
+				Workdir:  "/workspace",
+				User:     "dev",
+				Commands: config.Commands{Enter: config.CommandValue{User: "root"}},
+			},
+			containerName: "user-container",
+			command:       []string{"sh"},
+			wantParts:     []string{"-u root"},
+			dontWant:      []string{"-u dev"},
+		},
+		{
+			name: "no user leaves image default",
+			cfg: &config.Config{
+				Workdir: "/workspace",
+			},
+			containerName: "user-container",
+			command:       []string{"sh"},
+			dontWant:      []string{"-u "},
+		},
 	}
 
 	for _, tt := range tests {
@@ -544,3 +649,72 @@ func TestBuildExecArgsDefaultEnvs(t *testing.T) {
 		t.Errorf("buildExecArgs() should include default TERM env, got: %v", args)
 	}
 }
+
+func TestBuildReadonlyExecArgs(t *testing.T) {
+	rt := &dockerCLICompatibleRuntime{
+		displayName: "Docker",
+		command:     "docker",
+	}
+
+	t.Run("isolates the session", func(t *testing.T) {
+		cfg := &config.Config{
+			Image:   "alpine:latest",
+			Workdir: "/workspace",
+			Mounts:  []config.MountConfig{{Source: ".", Target: "/workspace"}},
+		}
+		args := rt.buildReadonlyExecArgs(cfg, "test-container", []string{"sh"})
+		argsStr := strings.Join(args, " ")
+
+		for _, want := range []string{
+			"docker run --rm -i",
+			"--network none",
+			"--user " + InspectionUser,
+			"--read-only",
+			"--cap-drop ALL",
+			"--security-opt no-new-privileges",
+			"--volumes-from test-container:ro",
+			"--tmpfs /tmp",
+			"-w /workspace alpine:latest sh",
+		} {
+			if !strings.Contains(argsStr, want) {
+				t.Errorf("buildReadonlyExecArgs() missing %q in args: %v", want, args)
+			}
+		}
+		if strings.Contains(argsStr, " exec ") {
+			t.Errorf("buildReadonlyExecArgs() must not exec into the sandbox: %v", args)
+		}
+	})
+
+	t.Run("keeps a mounted /tmp", func(t *testing.T) {
+		cfg := &config.Config{
+			Image:   "alpine:latest",
+			Workdir: "/workspace",
+			Mounts:  []config.MountConfig{{Source: "/host/tmp", Target: "/tmp"}},
+		}
+		args := rt.buildReadonlyExecArgs(cfg, "test-container", []string{"sh"})
+		if strings.Contains(strings.Join(args, " "), "--tmpfs") {
+			t.Errorf("buildReadonlyExecArgs() should not shadow a mounted /tmp: %v", args)
+		}
+	})
+}
+
+func TestBindMountOptions(t *testing.T) {
+	mount := config.MountConfig{Source: "/a", Target: "/b", Readonly: true, Propagation: "rslave", Consistency: "cached"}
+	tests := []struct {
+		platform RuntimePlatform
+		want     string
+	}{
+		{PlatformLinux, "ro,rslave"},
+		{PlatformMacDockerDesktop, "ro,cached"},
+		{PlatformMacOrbStack, "ro,cached"},
+		{PlatformRemote, "ro"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(bindMountOptions(mount, tt.platform), ","); got != tt.want {
+			t.Errorf("bindMountOptions(%s) = %q, want %q", tt.platform, got, tt.want)
+		}
+	}
+	if got := bindMountOptions(config.MountConfig{Source: "/a", Target: "/b"}, PlatformLinux); len(got) != 0 {
+		t.Errorf("plain mount options = %v, want none", got)
+	}
+}
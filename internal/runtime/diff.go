@@ -0,0 +1,52 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ChangeKind is how a path in a container's writable layer differs from
+// its image.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeChanged ChangeKind = "changed"
+	ChangeDeleted ChangeKind = "deleted"
+)
+
+// FileChange is a path added, changed or deleted in a container's
+// writable layer. Directories are listed as changed when an entry inside
+// them changed.
+type FileChange struct {
+	Kind ChangeKind `json:"kind"`
+	Path string     `json:"path"`
+}
+
+// diffKinds maps the letters of docker/podman diff output to kinds.
+var diffKinds = map[string]ChangeKind{"A": ChangeAdded, "C": ChangeChanged, "D": ChangeDeleted}
+
+// Diff lists the changes in the container's writable layer since it was
+// created. Mounts are not part of the layer and are never listed.
+func (r *dockerCLICompatibleRuntime) Diff(ctx context.Context, env *RuntimeEnv, containerName string) ([]FileChange, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "diff", containerName)
+	if err != nil {
+		return nil, fmt.Errorf("%s diff failed: %w: %s", r.command, err, strings.TrimSpace(string(output)))
+	}
+	return parseDiff(string(output)), nil
+}
+
+// parseDiff parses "A /path" lines, skipping lines it does not recognize.
+func parseDiff(output string) []FileChange {
+	var changes []FileChange
+	for _, line := range strings.Split(output, "\n") {
+		letter, path, ok := strings.Cut(strings.TrimRight(line, "\r"), " ")
+		kind, known := diffKinds[letter]
+		if !ok || !known || path == "" {
+			continue
+		}
+		changes = append(changes, FileChange{Kind: kind, Path: path})
+	}
+	return changes
+}
@@ -0,0 +1,29 @@
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestDiff(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker diff alca-test", []byte("C /etc\nA /etc/motd\nD /usr/share/doc\nA /tmp/with space\r\nwarning: ignored\n"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	got, err := NewDocker().Diff(context.Background(), NewRuntimeEnv(cmd), "alca-test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []FileChange{
+		{Kind: ChangeChanged, Path: "/etc"},
+		{Kind: ChangeAdded, Path: "/etc/motd"},
+		{Kind: ChangeDeleted, Path: "/usr/share/doc"},
+		{Kind: ChangeAdded, Path: "/tmp/with space"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+}
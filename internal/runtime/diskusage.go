@@ -0,0 +1,109 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DiskUsageKind is what a DiskUsageItem measures.
+type DiskUsageKind string
+
+const (
+	// DiskUsageImage is a local image, including layers it may share with
+	// other images.
+	DiskUsageImage DiskUsageKind = "image"
+	// DiskUsageContainer is the writable layer of a container.
+	DiskUsageContainer DiskUsageKind = "container"
+	// DiskUsageVolume is the data in a named volume.
+	DiskUsageVolume DiskUsageKind = "volume"
+)
+
+// DiskUsageItem is the disk space one image, container or volume takes on
+// the runtime host.
+type DiskUsageItem struct {
+	Kind  DiskUsageKind
+	Name  string
+	Bytes int64
+}
+
+// DiskUsage measures the given images, the writable layer of containerName
+// and the given volumes. Images and volumes that do not exist are left out.
+// Items that could not be measured are left out too, and their errors
+// joined into the returned error.
+func (r *dockerCLICompatibleRuntime) DiskUsage(ctx context.Context, env *RuntimeEnv, containerName string, images, volumes []string) ([]DiskUsageItem, error) {
+	var items []DiskUsageItem
+	var errs []error
+
+	for _, image := range images {
+		output, err := env.Cmd.RunQuiet(ctx, r.command, "image", "inspect", "--format", "{{.Size}}", image)
+		if err != nil {
+			// Not pulled (or removed) locally
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("image %s: unexpected size %q", image, strings.TrimSpace(string(output))))
+			continue
+		}
+		items = append(items, DiskUsageItem{Kind: DiskUsageImage, Name: image, Bytes: n})
+	}
+
+	if containerName != "" {
+		output, err := env.Cmd.RunQuiet(ctx, r.command, "inspect", "--size", "--format", "{{.SizeRw}}", containerName)
+		switch {
+		case err != nil && containsNoSuchContainer(string(output)):
+		case err != nil:
+			errs = append(errs, fmt.Errorf("container %s: %s inspect failed: %w: %s", containerName, r.command, err, strings.TrimSpace(string(output))))
+		default:
+			n, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("container %s: unexpected size %q", containerName, strings.TrimSpace(string(output))))
+				break
+			}
+			items = append(items, DiskUsageItem{Kind: DiskUsageContainer, Name: containerName, Bytes: n})
+		}
+	}
+
+	for _, volume := range volumes {
+		if _, err := env.Cmd.RunQuiet(ctx, r.command, "volume", "inspect", "--format", "{{.Name}}", volume); err != nil {
+			continue
+		}
+		n, err := r.volumeSize(ctx, env, volume)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("volume %s: %w", volume, err))
+			continue
+		}
+		items = append(items, DiskUsageItem{Kind: DiskUsageVolume, Name: volume, Bytes: n})
+	}
+
+	return items, errors.Join(errs...)
+}
+
+// volumeSize runs du over a volume in a throwaway container. The runtimes
+// only report volume sizes for all volumes at once, and the volume may
+// live in a VM. Only volumes of dind sidecars exist, so their image is
+// already local and has du.
+func (r *dockerCLICompatibleRuntime) volumeSize(ctx context.Context, env *RuntimeEnv, volume string) (int64, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "run", "--rm", "--pull", "never", "--network", "none",
+		"-v", volume+":/v:ro", DindImage, "du", "-sk", "/v")
+	if err != nil {
+		return 0, fmt.Errorf("du failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return parseDuKilobytes(string(output))
+}
+
+// parseDuKilobytes reads the size from du -sk output, in bytes.
+func parseDuKilobytes(output string) (int64, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected du output %q", output)
+	}
+	kb, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected du output %q", output)
+	}
+	return kb << 10, nil
+}
@@ -0,0 +1,50 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestDockerDiskUsage(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker image inspect --format {{.Size}} alpine:3", []byte("8700000\n"))
+	mock.ExpectFailure("docker image inspect --format {{.Size}} alca-baked:1", errors.New("exit status 1"))
+	mock.ExpectSuccess("docker inspect --size --format {{.SizeRw}} alca-p", []byte("2048\n"))
+	mock.ExpectSuccess("docker volume inspect --format {{.Name}} alca-dind-p", []byte("alca-dind-p\n"))
+	mock.ExpectSuccess("docker run --rm --pull never --network none -v alca-dind-p:/v:ro docker:dind du -sk /v", []byte("300\t/v\n"))
+	mock.ExpectFailure("docker volume inspect --format {{.Name}} alca-other", errors.New("exit status 1"))
+	defer mock.AssertAllExpectationsMet(t)
+
+	items, err := NewDocker().DiskUsage(context.Background(), newMockEnv(mock), "alca-p",
+		[]string{"alpine:3", "alca-baked:1"}, []string{"alca-dind-p", "alca-other"})
+	if err != nil {
+		t.Fatalf("DiskUsage() unexpected error: %v", err)
+	}
+	want := []DiskUsageItem{
+		{Kind: DiskUsageImage, Name: "alpine:3", Bytes: 8700000},
+		{Kind: DiskUsageContainer, Name: "alca-p", Bytes: 2048},
+		{Kind: DiskUsageVolume, Name: "alca-dind-p", Bytes: 300 << 10},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("DiskUsage() = %+v, want %+v", items, want)
+	}
+}
+
+func TestDockerDiskUsage_KeepsMeasuredItemsOnError(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker image inspect --format {{.Size}} alpine:3", []byte("100"))
+	mock.Expect("docker inspect --size --format {{.SizeRw}} alca-p", []byte("Cannot connect to the Docker daemon"), errors.New("exit status 1"))
+
+	items, err := NewDocker().DiskUsage(context.Background(), newMockEnv(mock), "alca-p", []string{"alpine:3"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "alca-p") {
+		t.Errorf("DiskUsage() error = %v, want the container's failure", err)
+	}
+	if len(items) != 1 || items[0].Name != "alpine:3" {
+		t.Errorf("DiskUsage() items = %+v, want the image", items)
+	}
+}
@@ -9,8 +9,10 @@ type Docker struct {
 func NewDocker() *Docker {
 	return &Docker{
 		dockerCLICompatibleRuntime: &dockerCLICompatibleRuntime{
-			displayName: "Docker",
-			command:     "docker",
+			displayName:   "Docker",
+			command:       "docker",
+			versionFormat: "{{.Server.Version}}",
+			daemonMirrors: true,
 		},
 	}
 }
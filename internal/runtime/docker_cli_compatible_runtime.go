@@ -2,13 +2,17 @@ package runtime
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/term"
 
@@ -29,9 +33,16 @@ const (
 
 // dockerCLICompatibleRuntime provides a common implementation for Docker CLI-compatible container runtimes.
 // Both Docker and Podman share this implementation with different command names.
+// CLI dialect differences are fields set by the constructors; daemon
+// features are probed at run time (see Capabilities).
 type dockerCLICompatibleRuntime struct {
-	displayName string // Human-readable name (e.g., "Docker", "Podman")
-	command     string // CLI command (e.g., "docker", "podman")
+	displayName   string // Human-readable name (e.g., "Docker", "Podman")
+	command       string // CLI command (e.g., "docker", "podman")
+	versionFormat string // version --format template that fails without a daemon
+	machine       bool   // manages its VM via "<command> machine" (Podman)
+	daemonMirrors bool   // daemon applies registry-mirrors itself (Docker)
+	socketFormat  string // info --format template printing the API socket path; empty for /var/run/docker.sock
+	saveFormat    string // save --format value; empty for the CLI's default archive
 }
 
 // Name returns the runtime name.
@@ -41,14 +52,7 @@ func (r *dockerCLICompatibleRuntime) Name() string {
 
 // Available checks if the CLI is installed and accessible.
 func (r *dockerCLICompatibleRuntime) Available(ctx context.Context, env *RuntimeEnv) bool {
-	var versionFormat string
-	if r.command == "docker" {
-		versionFormat = "{{.Server.Version}}"
-	} else {
-		versionFormat = "{{.Version}}"
-	}
-
-	_, err := env.Cmd.RunQuiet(ctx, r.command, "version", "--format", versionFormat)
+	_, err := probe(ctx, env, r.command, "version", "--format", r.versionFormat)
 	return err == nil
 }
 
@@ -66,7 +70,8 @@ func (r *dockerCLICompatibleRuntime) Up(ctx context.Context, env *RuntimeEnv, cf
 	status, err := r.Status(ctx, env, projectDir, st)
 	if err == nil && status.State == StateRunning {
 		util.ProgressStep(progressOut, "Container already running: %s\n", name)
-		return nil
+		// The SSH server does not survive restarts by the restart policy
+		return r.setupSSH(ctx, env, cfg, projectDir, status.Name, progressOut)
 	}
 
 	// Start existing stopped container (no config drift - see up.go flow)
@@ -79,26 +84,52 @@ func (r *dockerCLICompatibleRuntime) Up(ctx context.Context, env *RuntimeEnv, cf
 		}
 		util.ProgressStep(progressOut, "Container started\n")
 
+		if err := r.setupNestedContainers(ctx, env, cfg, st, status.Name, progressOut); err != nil {
+			return err
+		}
+
 		// Re-setup Mutagen syncs for stopped container restart
 		// Container ID may have changed, need to refresh syncs
 		if _, err := r.setupMutagenSyncs(ctx, env, cfg, st, name, projectDir, progressOut); err != nil {
 			return fmt.Errorf("failed to setup Mutagen syncs: %w", err)
 		}
 
-		return nil
+		return r.setupSSH(ctx, env, cfg, projectDir, status.Name, progressOut)
 	}
 
 	util.ProgressStep(progressOut, "Pulling image: %s\n", cfg.Image)
 
-	args := r.buildRunArgs(ctx, env, cfg, projectDir, st, name)
+	// Unknown capabilities (probe failed) leave every optional feature off
+	caps, capsErr := r.Capabilities(ctx, env)
+	if (cfg.Resources.Memory != "" || cfg.Resources.CPUs > 0) && !caps.ResourceLimits() {
+		util.ProgressStep(progressOut, "Warning: resources ignored: rootless %s cannot limit containers without cgroup v2\n", r.displayName)
+	}
+	r.warnCgroup(cfg, caps, progressOut)
+	if err := r.checkUserns(cfg, caps, capsErr); err != nil {
+		return err
+	}
+	r.warnUsernsRemap(cfg, caps, progressOut)
+	if cfg.RegistryMirror != "" {
+		r.useRegistryMirror(ctx, env, cfg, caps, capsErr, progressOut)
+	}
+	args := r.buildRunArgs(ctx, env, cfg, projectDir, st, name, caps)
 
 	util.ProgressStep(progressOut, "Creating container: %s\n", name)
-	output, err := env.Cmd.RunQuiet(ctx, r.command, args...)
+	// run pulls a missing image, so timeouts.pull bounds it
+	pullTimeout := cfg.Timeouts.PullTimeout()
+	runCtx, cancel := util.WithTimeout(ctx, pullTimeout)
+	output, err := env.Cmd.RunStream(runCtx, progressOut, newPullProgress().runLine, r.command, args...)
+	err = util.TimeoutError(runCtx, "container creation (timeouts.pull)", pullTimeout, err)
+	cancel()
 	if err != nil {
-		return fmt.Errorf("%s run failed: %w: %s", r.command, err, string(output))
+		return r.pullError(cfg.Image, output, fmt.Errorf("%s run failed: %w: %s", r.command, err, string(output)))
 	}
 	util.ProgressStep(progressOut, "Container started\n")
 
+	if err := r.setupNestedContainers(ctx, env, cfg, st, name, progressOut); err != nil {
+		return err
+	}
+
 	// Setup Mutagen syncs for mounts that require it
 	// See AGD-025 for platform-specific mount optimization
 	syncs, err := r.setupMutagenSyncs(ctx, env, cfg, st, name, projectDir, progressOut)
@@ -119,17 +150,39 @@ func (r *dockerCLICompatibleRuntime) Up(ctx context.Context, env *RuntimeEnv, cf
 		}
 	}
 
-	return nil
+	return r.setupSSH(ctx, env, cfg, projectDir, name, progressOut)
+}
+
+// bindMountOptions returns the -v options for a bind mount. Propagation is
+// only passed on Linux, where the mount is a real bind mount in the host's
+// kernel; consistency is only passed on macOS, where it tunes file sharing
+// into the VM. Elsewhere each is dropped ('alca config lint' reports it).
+func bindMountOptions(mount config.MountConfig, platform RuntimePlatform) []string {
+	var opts []string
+	if mount.Readonly {
+		opts = append(opts, "ro")
+	}
+	if mount.Propagation != "" && platform == PlatformLinux {
+		opts = append(opts, mount.Propagation)
+	}
+	if mount.Consistency != "" && IsDarwin(platform) {
+		opts = append(opts, mount.Consistency)
+	}
+	return opts
 }
 
 // buildRunArgs constructs the arguments for the container run command.
-func (r *dockerCLICompatibleRuntime) buildRunArgs(ctx context.Context, env *RuntimeEnv, cfg *config.Config, projectDir string, st *state.State, name string) []string {
+// caps gates flags the daemon cannot honor.
+func (r *dockerCLICompatibleRuntime) buildRunArgs(ctx context.Context, env *RuntimeEnv, cfg *config.Config, projectDir string, st *state.State, name string, caps Capabilities) []string {
 	args := []string{
 		"run", "-d",
 		"--name", name,
 		"--restart=unless-stopped",
 		"-w", cfg.Workdir,
 	}
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
 
 	// Add labels for container identity
 	for key, value := range st.ContainerLabels(projectDir) {
@@ -139,7 +192,8 @@ func (r *dockerCLICompatibleRuntime) buildRunArgs(ctx context.Context, env *Runt
 	// Add mounts (only those not requiring Mutagen sync)
 	// Mounts with excludes are handled separately via Mutagen.
 	// See AGD-025 for mount strategy decisions.
-	// Note: cfg.Mounts[0] is the workdir mount (Source="."), resolved to projectDir here.
+	// Note: cfg.Mounts[0] is the workdir mount (Source="." or workdir_source),
+	// resolved against projectDir here.
 	platform := DetectPlatform(ctx, env)
 	for _, mount := range cfg.Mounts {
 		if ShouldUseMutagen(platform, mount.HasExcludes()) {
@@ -154,32 +208,47 @@ func (r *dockerCLICompatibleRuntime) buildRunArgs(ctx context.Context, env *Runt
 			source = filepath.Join(projectDir, source)
 		}
 		mountStr := fmt.Sprintf("%s:%s", source, mount.Target)
-		if mount.Readonly {
-			mountStr += ":ro"
+		if opts := bindMountOptions(mount, platform); len(opts) > 0 {
+			mountStr += ":" + strings.Join(opts, ",")
 		}
 		args = append(args, "-v", mountStr)
 	}
 
-	// Add resource limits if configured
-	if cfg.Resources.Memory != "" {
+	// Add resource limits if configured and enforceable
+	if cfg.Resources.Memory != "" && caps.ResourceLimits() {
 		args = append(args, "-m", cfg.Resources.Memory)
 	}
-	if cfg.Resources.CPUs > 0 {
+	if cfg.Resources.CPUs > 0 && caps.ResourceLimits() {
 		args = append(args, "--cpus", fmt.Sprintf("%d", cfg.Resources.CPUs))
 	}
+	if caps.CgroupTuning() {
+		args = append(args, cgroupRunArgs(cfg.Resources.Cgroup, caps)...)
+	}
 
 	// Add environment variables (all merged envs at container creation)
-	for key, ev := range cfg.MergedEnvs() {
-		expanded := ev.Expand(os.Getenv)
-		if expanded != "" {
-			args = append(args, "-e", key+"="+expanded)
-		}
+	for key, value := range cfg.ResolvedEnvs(os.Getenv, false) {
+		args = append(args, "-e", key+"="+value)
+	}
+
+	// network.isolation = "none": no interfaces besides loopback
+	if cfg.Network.IsolationLevel() == config.IsolationNone {
+		args = append(args, "--network", "none")
 	}
 
+	// host.alca.internal on every platform
+	args = append(args, r.hostAliasRunArgs(ctx, env, cfg)...)
+
+	// User namespace (userns); checked against caps by checkUserns
+	args = append(args, usernsRunArgs(cfg)...)
+
+	// Give the sandbox a container daemon (nested_containers)
+	args = append(args, r.nestedContainerArgs(ctx, env, cfg)...)
+
 	// Add port mappings
 	for _, p := range cfg.Network.Ports {
 		args = append(args, "-p", config.FormatPortArg(p))
 	}
+	args = append(args, sshRunArgs(cfg)...)
 
 	// Add capability flags (AGD-026)
 	for _, cap := range cfg.Caps.Drop {
@@ -189,8 +258,8 @@ func (r *dockerCLICompatibleRuntime) buildRunArgs(ctx context.Context, env *Runt
 		args = append(args, "--cap-add", cap)
 	}
 
-	// Add image and keep-alive command
-	args = append(args, cfg.Image, KeepAliveCommand, KeepAliveArg)
+	// Add image (the baked image if any) and keep-alive command
+	args = append(args, runImage(cfg, st), KeepAliveCommand, KeepAliveArg)
 
 	return args
 }
@@ -215,7 +284,11 @@ func (r *dockerCLICompatibleRuntime) flushMutagenSyncs(ctx context.Context, env
 func (r *dockerCLICompatibleRuntime) executeUpCommand(ctx context.Context, env *RuntimeEnv, cfg *config.Config, containerName string, progressOut io.Writer) error {
 	util.ProgressStep(progressOut, "Running setup command...\n")
 	execArgs := []string{"exec", containerName, "sh", "-c", cfg.Commands.Up.Command}
-	output, err := env.Cmd.Run(ctx, r.command, execArgs...)
+	timeout := cfg.Timeouts.UpCommandTimeout()
+	ctx, cancel := util.WithTimeout(ctx, timeout)
+	defer cancel()
+	output, err := env.Cmd.RunStream(ctx, progressOut, indentOutput, r.command, execArgs...)
+	err = util.TimeoutError(ctx, "up command (timeouts.up_command)", timeout, err)
 	if err != nil {
 		return fmt.Errorf("up command failed: %w: %s", err, string(output))
 	}
@@ -261,6 +334,7 @@ func (r *dockerCLICompatibleRuntime) setupMutagenSyncs(ctx context.Context, env
 			Source:  source,
 			Target:  MutagenTarget(containerID, mount.Target),
 			Ignores: mount.Exclude,
+			Options: mount.Sync,
 		}
 
 		// Terminate any existing session with this exact name before creating.
@@ -295,22 +369,34 @@ func (r *dockerCLICompatibleRuntime) Down(ctx context.Context, env *RuntimeEnv,
 	}
 
 	if status.State == StateNotFound {
-		// Still try to clean up any orphaned Mutagen syncs
+		// Still try to clean up any orphaned Mutagen syncs and dind sidecar
 		if st != nil {
 			_ = TerminateProjectSyncs(ctx, env, st.ProjectID)
+			if hasDindSidecar(st) {
+				_ = r.removeDindSidecar(ctx, env, st.ContainerName)
+			}
 		}
 		return nil
 	}
 
 	containerName := status.Name
 
-	// Terminate Mutagen syncs before stopping container
+	// The dind sidecar lives in the container's network namespace; remove
+	// it first
+	if hasDindSidecar(st) {
+		if err := r.removeDindSidecar(ctx, env, containerName); err != nil {
+			util.ProgressStep(nil, "Warning: %v\n", err)
+		}
+	}
+
+	// Pause Mutagen syncs before stopping container so files removed or
+	// half-written during shutdown are not propagated back to the host.
+	// If stopping fails the sessions stay paused and 'alca sync resume'
+	// picks up where they left off.
 	// See AGD-025 for Mutagen integration design
 	if st != nil {
-		if err := TerminateProjectSyncs(ctx, env, st.ProjectID); err != nil {
-			// Log warning but continue with container removal
-			// Mutagen sessions will be orphaned but can be cleaned up manually
-			util.ProgressStep(nil, "Warning: failed to terminate Mutagen syncs: %v\n", err)
+		if _, err := PauseProjectSyncs(ctx, env, st.ProjectID); err != nil {
+			util.ProgressStep(nil, "Warning: failed to pause Mutagen syncs: %v\n", err)
 		}
 	}
 
@@ -322,11 +408,45 @@ func (r *dockerCLICompatibleRuntime) Down(ctx context.Context, env *RuntimeEnv,
 		}
 	}
 
-	return r.removeContainer(ctx, env, containerName)
+	if err := r.removeContainer(ctx, env, containerName); err != nil {
+		return err
+	}
+
+	if st != nil {
+		if err := TerminateProjectSyncs(ctx, env, st.ProjectID); err != nil {
+			// Mutagen sessions will be orphaned but can be cleaned up manually
+			util.ProgressStep(nil, "Warning: failed to terminate Mutagen syncs: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// Stop stops the container and keeps it for Up to start again.
+func (r *dockerCLICompatibleRuntime) Stop(ctx context.Context, env *RuntimeEnv, projectDir string, st *state.State) error {
+	status, err := r.Status(ctx, env, projectDir, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State == StateNotFound || status.State == StateStopped {
+		return nil
+	}
+
+	// As in Down, nothing written during shutdown is synced back. Up
+	// recreates the sessions for the restarted container.
+	if _, err := PauseProjectSyncs(ctx, env, st.ProjectID); err != nil {
+		util.ProgressStep(nil, "Warning: failed to pause Mutagen syncs: %v\n", err)
+	}
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "stop", status.Name)
+	if err != nil && !containsNoSuchContainer(string(output)) {
+		return fmt.Errorf("%s stop failed: %w: %s", r.command, err, string(output))
+	}
+	return nil
 }
 
 // Exec runs a command inside the container.
-// For interactive commands, this uses syscall.Exec to replace the current process.
+// The runtime CLI runs as a child process with inherited stdio, so the caller
+// can observe the exit status (returned as *exec.ExitError) and run follow-up
+// work such as recording history or showing the sync conflict banner.
 // See AGD-017 for environment variable design.
 func (r *dockerCLICompatibleRuntime) Exec(ctx context.Context, env *RuntimeEnv, cfg *config.Config, projectDir string, st *state.State, command []string) error {
 	status, err := r.Status(ctx, env, projectDir, st)
@@ -338,18 +458,95 @@ func (r *dockerCLICompatibleRuntime) Exec(ctx context.Context, env *RuntimeEnv,
 		return ErrNotRunning
 	}
 
+	if user := cfg.ExecUser(); user != "" {
+		if err := r.checkExecUser(ctx, env, status.Name, user); err != nil {
+			return err
+		}
+	}
+
 	args := r.buildExecArgs(cfg, status.Name, command)
+	return r.runInteractive(args)
+}
 
+// checkExecUser verifies that user (name or uid[:gid]) exists in the
+// container, so a typo fails with a clear error instead of the runtime's
+// "unable to find user". Numeric uids are accepted as-is, since the runtime
+// runs them without a passwd entry.
+func (r *dockerCLICompatibleRuntime) checkExecUser(ctx context.Context, env *RuntimeEnv, containerName, user string) error {
+	name, _, _ := strings.Cut(user, ":")
+	if _, err := strconv.Atoi(name); err == nil {
+		return nil
+	}
+	if _, err := env.Cmd.RunQuiet(ctx, r.command, "exec", containerName, "id", "-u", name); err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownUser, name)
+	}
+	return nil
+}
+
+// ExecReadonly opens an inspection session on the running container.
+// Instead of exec'ing into the container, it starts a throwaway container
+// from the same image that borrows the sandbox's mounts read-only, runs as
+// nobody, and has no network. Nothing the session does can reach the sandbox.
+// Mounts synced by Mutagen live inside the sandbox's own filesystem and
+// cannot be borrowed, so a synced workdir returns ErrReadonlySyncedWorkdir.
+func (r *dockerCLICompatibleRuntime) ExecReadonly(ctx context.Context, env *RuntimeEnv, cfg *config.Config, projectDir string, st *state.State, command []string) error {
+	status, err := r.Status(ctx, env, projectDir, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+
+	if status.State != StateRunning {
+		return ErrNotRunning
+	}
+
+	platform := DetectPlatform(ctx, env)
+	for _, mount := range cfg.Mounts {
+		if mount.Target == cfg.Workdir && ShouldUseMutagen(platform, mount.HasExcludes()) {
+			return ErrReadonlySyncedWorkdir
+		}
+	}
+
+	args := r.buildReadonlyExecArgs(cfg, status.Name, command)
+	return r.runInteractive(args)
+}
+
+// runInteractive runs the runtime CLI as a child process with inherited stdio.
+func (r *dockerCLICompatibleRuntime) runInteractive(args []string) error {
 	cliPath, err := exec.LookPath(r.command)
 	if err != nil {
 		return fmt.Errorf("%s not found: %w", r.command, err)
 	}
 
 	if os.Getenv(EnvDebug) != "" {
-		fmt.Fprintf(os.Stderr, "→ Executing: %s\n", strings.Join(args, " "))
-	}
+		fmt.Fprintf(os.Stderr, "→ Executing: %s\n", util.Redact(strings.Join(args, " ")))
+	}
+
+	c := exec.Command(cliPath, args[1:]...) //nolint:fslint // interactive exec needs inherited stdio
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", r.command, err)
+	}
+
+	// The terminal delivers SIGINT to the whole process group, so the child
+	// already sees it; alca only needs to survive it. SIGTERM and SIGHUP are
+	// aimed at alca alone and are relayed to the child.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer func() {
+		signal.Stop(sigs)
+		close(sigs)
+	}()
+	go func() {
+		for sig := range sigs {
+			if sig != os.Interrupt {
+				_ = c.Process.Signal(sig)
+			}
+		}
+	}()
 
-	return syscall.Exec(cliPath, args, os.Environ())
+	return c.Wait()
 }
 
 // buildExecArgs constructs the arguments for the container exec command.
@@ -360,13 +557,12 @@ func (r *dockerCLICompatibleRuntime) buildExecArgs(cfg *config.Config, container
 	}
 
 	// Add environment variables with override_on_enter=true
-	for key, env := range cfg.MergedEnvs() {
-		if env.OverrideOnEnter {
-			expanded := env.Expand(os.Getenv)
-			if expanded != "" {
-				args = append(args, "-e", key+"="+expanded)
-			}
-		}
+	for key, value := range cfg.ResolvedEnvs(os.Getenv, true) {
+		args = append(args, "-e", key+"="+value)
+	}
+
+	if user := cfg.ExecUser(); user != "" {
+		args = append(args, "-u", user)
 	}
 
 	args = append(args, "-w", cfg.Workdir, containerName)
@@ -374,6 +570,49 @@ func (r *dockerCLICompatibleRuntime) buildExecArgs(cfg *config.Config, container
 	return args
 }
 
+// buildReadonlyExecArgs constructs the arguments for an inspection session.
+// --volumes-from with :ro re-attaches every mount of the sandbox read-only;
+// the root filesystem is read-only too, with a tmpfs /tmp so shells still work.
+func (r *dockerCLICompatibleRuntime) buildReadonlyExecArgs(cfg *config.Config, containerName string, command []string) []string {
+	args := []string{r.command, "run", "--rm", "-i"}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		args = append(args, "-t")
+	}
+
+	args = append(args,
+		"--network", "none",
+		"--user", InspectionUser,
+		"--read-only",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--volumes-from", containerName+":ro",
+	)
+	if !hasMountAt(cfg.Mounts, "/tmp") {
+		args = append(args, "--tmpfs", "/tmp")
+	}
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
+
+	for key, value := range cfg.ResolvedEnvs(os.Getenv, true) {
+		args = append(args, "-e", key+"="+value)
+	}
+
+	args = append(args, "-w", cfg.Workdir, cfg.Image)
+	args = append(args, command...)
+	return args
+}
+
+// hasMountAt reports whether any mount targets the given container path.
+func hasMountAt(mounts []config.MountConfig, target string) bool {
+	for _, m := range mounts {
+		if m.Target == target {
+			return true
+		}
+	}
+	return false
+}
+
 // Status returns the current status of the container.
 //
 // Dual lookup strategy:
@@ -425,6 +664,24 @@ func (r *dockerCLICompatibleRuntime) inspectContainer(ctx context.Context, env *
 	}, nil
 }
 
+// InspectContainer returns the runtime's raw inspect document for a container.
+func (r *dockerCLICompatibleRuntime) InspectContainer(ctx context.Context, env *RuntimeEnv, containerName string) (json.RawMessage, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "inspect", containerName)
+	if err != nil {
+		return nil, fmt.Errorf("%s inspect failed: %w: %s", r.command, err, strings.TrimSpace(string(output)))
+	}
+
+	// inspect prints an array with one entry per requested container
+	var docs []json.RawMessage
+	if err := json.Unmarshal(output, &docs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s inspect output: %w", r.command, err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%s inspect returned no data for %s", r.command, containerName)
+	}
+	return docs[0], nil
+}
+
 // findContainerByLabel finds a container by its project label.
 func (r *dockerCLICompatibleRuntime) findContainerByLabel(ctx context.Context, env *RuntimeEnv, projectID string) (ContainerStatus, error) {
 	labelFilter := state.LabelFilter(projectID)
@@ -502,8 +759,8 @@ func (r *dockerCLICompatibleRuntime) ListContainers(ctx context.Context, env *Ru
 func (r *dockerCLICompatibleRuntime) batchInspectContainers(ctx context.Context, env *RuntimeEnv, names []string) ([]ContainerInfo, error) {
 	// Build format string for inspect output
 	// Using a unique separator (|||) to avoid conflicts with data values
-	format := fmt.Sprintf("{{.Name}}|||{{.State.Status}}|||{{.Created}}|||{{.Config.Image}}|||{{index .Config.Labels \"%s\"}}|||{{index .Config.Labels \"%s\"}}",
-		state.LabelProjectID, state.LabelProjectPath)
+	format := fmt.Sprintf("{{.Name}}|||{{.State.Status}}|||{{.Created}}|||{{.Config.Image}}|||{{index .Config.Labels \"%s\"}}|||{{index .Config.Labels \"%s\"}}|||{{index .Config.Labels \"%s\"}}",
+		state.LabelProjectID, state.LabelProjectPath, state.LabelVersion)
 
 	// Build args: inspect --format <format> name1 name2 name3 ...
 	args := []string{"inspect", "--format", format}
@@ -529,14 +786,18 @@ func (r *dockerCLICompatibleRuntime) batchInspectContainers(ctx context.Context,
 			continue
 		}
 
-		containers = append(containers, ContainerInfo{
+		info := ContainerInfo{
 			Name:        strings.TrimPrefix(parts[0], "/"),
 			State:       parseContainerState(parts[1]),
 			CreatedAt:   parts[2],
 			Image:       parts[3],
 			ProjectID:   parts[4],
 			ProjectPath: parts[5],
-		})
+		}
+		if len(parts) > 6 {
+			info.Version = parts[6]
+		}
+		containers = append(containers, info)
 	}
 
 	return containers, nil
@@ -575,6 +836,8 @@ func parseContainerState(status string) ContainerState {
 		return StateRunning
 	case "exited", "stopped":
 		return StateStopped
+	case "paused":
+		return StatePaused
 	default:
 		return StateUnknown
 	}
@@ -589,19 +852,51 @@ func containsNoSuchContainer(output string) bool {
 
 // GetContainerIP returns the IP address of a container.
 // Used by firewall rules to restrict container network access.
+// A container that has just started may not be attached to its network
+// yet, so an empty address is retried for a few seconds.
 func (r *dockerCLICompatibleRuntime) GetContainerIP(ctx context.Context, env *RuntimeEnv, containerName string) (string, error) {
-	// Get the IP from the first network (usually bridge)
-	output, err := env.Cmd.RunQuiet(ctx, r.command, "inspect",
-		"--format", "{{range .NetworkSettings.Networks}}{{.IPAddress}}{{end}}",
-		containerName)
+	return r.getContainerIP(ctx, env, containerName, containerIPBackoff)
+}
+
+// containerIPFormat lists each network the container is attached to with
+// its address there.
+const containerIPFormat = "{{range $name, $net := .NetworkSettings.Networks}}{{$name}}={{$net.IPAddress}}\n{{end}}"
+
+// containerIPBackoff waits for a just-started container to get an address.
+var containerIPBackoff = util.Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Jitter: 0.2, MaxElapsed: 5 * time.Second}
+
+func (r *dockerCLICompatibleRuntime) getContainerIP(ctx context.Context, env *RuntimeEnv, containerName string, b util.Backoff) (string, error) {
+	var ip string
+	err := util.Retry(ctx, b, func(int) error {
+		// One "network=ip" line per network; peer networks are skipped
+		output, err := env.Cmd.RunQuiet(ctx, r.command, "inspect",
+			"--format", containerIPFormat,
+			containerName)
+		if err != nil {
+			return util.Permanent(fmt.Errorf("failed to inspect container IP: %w", err))
+		}
+
+		ip = primaryContainerIP(string(output))
+		if ip == "" {
+			return fmt.Errorf("container has no IP address")
+		}
+		return nil
+	})
+	return ip, err
+}
+
+// GetContainerPID returns the host PID of a container's init process.
+// Used by traffic shaping to enter the container's network namespace.
+func (r *dockerCLICompatibleRuntime) GetContainerPID(ctx context.Context, env *RuntimeEnv, containerName string) (int, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "inspect", "--format", "{{.State.Pid}}", containerName)
 	if err != nil {
-		return "", fmt.Errorf("failed to inspect container IP: %w", err)
+		return 0, fmt.Errorf("failed to inspect container PID: %w", err)
 	}
 
-	ip := strings.TrimSpace(string(output))
-	if ip == "" {
-		return "", fmt.Errorf("container has no IP address")
+	pid, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("container has no running process")
 	}
 
-	return ip, nil
+	return pid, nil
 }
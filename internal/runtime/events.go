@@ -0,0 +1,82 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContainerExit describes how a container's main process last ended.
+type ContainerExit struct {
+	// OOMKilled is set when the out-of-memory killer ended the main process.
+	OOMKilled bool
+	// ExitCode is the main process's last exit code.
+	ExitCode int
+	// RestartCount counts restarts by the restart policy since creation.
+	RestartCount int
+	// FinishedAt is when the main process last ended; zero if it never has.
+	FinishedAt time.Time
+}
+
+// containerExitFormat prints the ContainerExit fields, "|"-separated.
+const containerExitFormat = "{{.State.OOMKilled}}|{{.State.ExitCode}}|{{.RestartCount}}|{{.State.FinishedAt}}"
+
+// oomEventsScript prints the cgroup's OOM counters: memory.events on cgroup
+// v2, memory.oom_control on v1. Both have an "oom_kill N" line.
+const oomEventsScript = "cat /sys/fs/cgroup/memory.events 2>/dev/null || cat /sys/fs/cgroup/memory/memory.oom_control"
+
+// ContainerExit returns how the container's main process last ended.
+func (r *dockerCLICompatibleRuntime) ContainerExit(ctx context.Context, env *RuntimeEnv, containerName string) (ContainerExit, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "inspect", "--format", containerExitFormat, containerName)
+	if err != nil {
+		return ContainerExit{}, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return parseContainerExit(strings.TrimSpace(string(output)))
+}
+
+// parseContainerExit parses output printed with containerExitFormat.
+func parseContainerExit(output string) (ContainerExit, error) {
+	parts := strings.Split(output, "|")
+	if len(parts) != 4 {
+		return ContainerExit{}, fmt.Errorf("unexpected inspect output %q", output)
+	}
+	var exit ContainerExit
+	var err error
+	if exit.OOMKilled, err = strconv.ParseBool(parts[0]); err != nil {
+		return ContainerExit{}, fmt.Errorf("unexpected OOMKilled %q", parts[0])
+	}
+	if exit.ExitCode, err = strconv.Atoi(parts[1]); err != nil {
+		return ContainerExit{}, fmt.Errorf("unexpected exit code %q", parts[1])
+	}
+	if exit.RestartCount, err = strconv.Atoi(parts[2]); err != nil {
+		return ContainerExit{}, fmt.Errorf("unexpected restart count %q", parts[2])
+	}
+	// Never-finished containers report the zero time (Docker) or nothing
+	if t, err := time.Parse(time.RFC3339Nano, parts[3]); err == nil && t.Year() > 1 {
+		exit.FinishedAt = t
+	}
+	return exit, nil
+}
+
+// OOMKillCount returns how many processes the out-of-memory killer has
+// ended in the running container's cgroup since it was created.
+func (r *dockerCLICompatibleRuntime) OOMKillCount(ctx context.Context, env *RuntimeEnv, containerName string) (int, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "exec", containerName, "sh", "-c", oomEventsScript)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cgroup memory events: %w", err)
+	}
+	return parseOOMKillCount(string(output))
+}
+
+// parseOOMKillCount finds the "oom_kill N" line in cgroup memory events.
+func parseOOMKillCount(output string) (int, error) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			return strconv.Atoi(fields[1])
+		}
+	}
+	return 0, fmt.Errorf("no oom_kill counter in cgroup memory events")
+}
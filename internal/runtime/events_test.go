@@ -0,0 +1,67 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestParseContainerExit(t *testing.T) {
+	exit, err := parseContainerExit("true|137|2|2026-03-04T05:06:07.123456789Z")
+	if err != nil {
+		t.Fatalf("parseContainerExit() error: %v", err)
+	}
+	want := ContainerExit{OOMKilled: true, ExitCode: 137, RestartCount: 2, FinishedAt: time.Date(2026, 3, 4, 5, 6, 7, 123456789, time.UTC)}
+	if exit != want {
+		t.Errorf("parseContainerExit() = %+v, want %+v", exit, want)
+	}
+
+	// Docker reports the zero time for containers that never stopped
+	exit, err = parseContainerExit("false|0|0|0001-01-01T00:00:00Z")
+	if err != nil {
+		t.Fatalf("parseContainerExit() error: %v", err)
+	}
+	if !exit.FinishedAt.IsZero() {
+		t.Errorf("FinishedAt = %v, want zero", exit.FinishedAt)
+	}
+
+	if _, err := parseContainerExit("running"); err == nil {
+		t.Error("expected an error for malformed output")
+	}
+}
+
+func TestParseOOMKillCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   int
+	}{
+		{name: "cgroup v2", output: "low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\noom_group_kill 0\n", want: 2},
+		{name: "cgroup v1", output: "oom_kill_disable 0\nunder_oom 0\noom_kill 5\n", want: 5},
+	}
+	for _, tt := range tests {
+		got, err := parseOOMKillCount(tt.output)
+		if err != nil {
+			t.Fatalf("%s: parseOOMKillCount() error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: parseOOMKillCount() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if _, err := parseOOMKillCount("max 0\n"); err == nil {
+		t.Error("expected an error without an oom_kill line")
+	}
+}
+
+func TestOOMKillCount(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker exec alca-test sh -c "+oomEventsScript, []byte("oom 1\noom_kill 1\n"))
+	defer mock.AssertAllExpectationsMet(t)
+
+	count, err := NewDocker().OOMKillCount(context.Background(), newMockEnv(mock), "alca-test")
+	if err != nil || count != 1 {
+		t.Errorf("OOMKillCount() = %d, %v; want 1", count, err)
+	}
+}
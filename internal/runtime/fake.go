@@ -0,0 +1,951 @@
+package runtime
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	goruntime "runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// EnvFakeRuntime selects the shared in-memory Fake runtime when set to "1",
+// bypassing runtime detection. Containers live only as long as the process,
+// so this is for tests and CLI smoke runs, never for real use.
+const EnvFakeRuntime = "ALCA_FAKE_RUNTIME"
+
+// FakePeerSubnet is the subnet Fake reports for every peer network.
+const FakePeerSubnet = "10.89.0.0/24"
+
+// FakeImageSize is what DiskUsage reports for every local image.
+const FakeImageSize = 64 << 20
+
+// FakeRuntimeName is the name the Fake runtime reports (and state records).
+const FakeRuntimeName = "Fake"
+
+// Fake is an in-memory Runtime for CLI-level tests. Containers get
+// deterministic IDs and IPs in creation order, every call is recorded in
+// Calls, and FailNext scripts failures. It never runs a command.
+type Fake struct {
+	mu         sync.Mutex
+	containers map[string]*FakeContainer
+	images     map[string]bool
+	packages   map[string]PackageList
+	failures   map[string][]error
+	stages     map[string]map[string]string
+	created    int
+
+	// Calls records each method call as "Method arg..." in order.
+	Calls []string
+}
+
+// FakeContainer is a container held by Fake.
+type FakeContainer struct {
+	ID        string
+	Name      string
+	Image     string
+	State     ContainerState
+	Labels    map[string]string
+	IP        string
+	PID       int
+	CreatedAt string
+	// Execs records the commands run in the container, including commands.up.
+	Execs [][]string
+	// Networks are the peer networks the container is connected to.
+	Networks []string
+	// Exit is what ContainerExit reports; set it to simulate crashes.
+	Exit ContainerExit
+	// OOMKills is what OOMKillCount reports.
+	OOMKills int
+	// Logs is what Logs writes.
+	Logs string
+	// Ports maps published container ports to host ports.
+	Ports map[int]int
+	// Files holds the files written by WriteFile, by path; CopyFromContainer
+	// copies them out.
+	Files map[string]string
+	// Packages is what ContainerPackages reports.
+	Packages PackageList
+	// Env, WorkingDir and Binds are what ContainerSpec reports, for
+	// containers added with AddContainer.
+	Env        []string
+	WorkingDir string
+	Binds      []config.MountConfig
+	// Resources are the limits set by UpdateResources.
+	Resources config.Resources
+	// DiskSize is what DiskUsage reports for the writable layer.
+	DiskSize int64
+	// Changes is what Diff reports.
+	Changes []FileChange
+}
+
+var _ Runtime = (*Fake)(nil)
+
+// fakeEpoch is the creation time of every fake container, for stable output.
+var fakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	sharedFakeMu sync.Mutex
+	sharedFake   *Fake
+)
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{
+		containers: make(map[string]*FakeContainer),
+		images:     make(map[string]bool),
+		packages:   make(map[string]PackageList),
+		failures:   make(map[string][]error),
+		stages:     make(map[string]map[string]string),
+	}
+}
+
+// SharedFake returns the process-wide Fake selected by EnvFakeRuntime.
+func SharedFake() *Fake {
+	sharedFakeMu.Lock()
+	defer sharedFakeMu.Unlock()
+	if sharedFake == nil {
+		sharedFake = NewFake()
+	}
+	return sharedFake
+}
+
+// ResetSharedFake replaces the shared Fake with an empty one and returns it.
+func ResetSharedFake() *Fake {
+	sharedFakeMu.Lock()
+	defer sharedFakeMu.Unlock()
+	sharedFake = NewFake()
+	return sharedFake
+}
+
+// fakeSelected reports whether EnvFakeRuntime selects the Fake.
+func fakeSelected() bool {
+	return os.Getenv(EnvFakeRuntime) == "1"
+}
+
+// FailNext makes the next call to method (e.g. "Up", "Exec") return err.
+// Calls queue, so FailNext twice fails the next two calls. Available
+// reports false instead of returning the error.
+func (f *Fake) FailNext(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method] = append(f.failures[method], err)
+}
+
+// Container returns a copy of the named container, or nil if it does not exist.
+func (f *Fake) Container(name string) *FakeContainer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.containers[name]
+	if !ok {
+		return nil
+	}
+	cp := *c
+	cp.Execs = append([][]string(nil), c.Execs...)
+	cp.Files = maps.Clone(c.Files)
+	return &cp
+}
+
+// AddContainer adds a container as if it had been created outside alca,
+// e.g. to test adoption or cleanup of orphans. ID, IP and PID are assigned
+// if empty.
+func (f *Fake) AddContainer(c FakeContainer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.assignIdentity(&c)
+	if c.State == "" {
+		c.State = StateRunning
+	}
+	f.containers[c.Name] = &c
+}
+
+// SetImagePackages sets what ImagePackages reports for image.
+func (f *Fake) SetImagePackages(image string, list PackageList) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.packages[image] = list
+}
+
+// HasImage reports whether image has been pulled or baked.
+func (f *Fake) HasImage(image string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.images[image]
+}
+
+// record logs a call and returns the scripted failure for method, if any.
+func (f *Fake) record(method string, args ...string) error {
+	f.Calls = append(f.Calls, strings.TrimSpace(method+" "+strings.Join(args, " ")))
+	queue := f.failures[method]
+	if len(queue) == 0 {
+		return nil
+	}
+	f.failures[method] = queue[1:]
+	return queue[0]
+}
+
+// assignIdentity fills in a deterministic ID, IP and PID for c.
+func (f *Fake) assignIdentity(c *FakeContainer) {
+	f.created++
+	n := f.created
+	if c.ID == "" {
+		c.ID = fmt.Sprintf("%064x", n)
+	}
+	if c.IP == "" {
+		c.IP = fmt.Sprintf("10.88.0.%d", n+1)
+	}
+	if c.PID == 0 {
+		c.PID = 1000 + n
+	}
+	if c.CreatedAt == "" {
+		c.CreatedAt = fakeEpoch.Format(time.RFC3339)
+	}
+}
+
+// running returns the named container if it is running.
+func (f *Fake) running(name string) (*FakeContainer, error) {
+	c, ok := f.containers[name]
+	if !ok || c.State != StateRunning {
+		return nil, fmt.Errorf("%w: %s", ErrNotRunning, name)
+	}
+	return c, nil
+}
+
+// Name returns the runtime name.
+func (f *Fake) Name() string { return FakeRuntimeName }
+
+// Available reports true unless a failure is scripted.
+func (f *Fake) Available(_ context.Context, _ *RuntimeEnv) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.record("Available") == nil
+}
+
+// Up creates and starts the container, or starts it if stopped.
+// commands.up is recorded as the container's first exec.
+func (f *Fake) Up(_ context.Context, _ *RuntimeEnv, cfg *config.Config, projectDir string, st *state.State, progressOut io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := st.ContainerName
+	if err := f.record("Up", name); err != nil {
+		return err
+	}
+
+	if c, ok := f.containers[name]; ok {
+		if c.State != StateRunning {
+			util.ProgressStep(progressOut, "Starting stopped container: %s\n", name)
+			c.State = StateRunning
+		} else {
+			util.ProgressStep(progressOut, "Container already running: %s\n", name)
+		}
+		return nil
+	}
+
+	image := runImage(cfg, st)
+	util.ProgressStep(progressOut, "Creating container: %s\n", name)
+	c := &FakeContainer{
+		Name:   name,
+		Image:  image,
+		State:  StateRunning,
+		Labels: st.ContainerLabels(projectDir),
+	}
+	f.assignIdentity(c)
+	if cfg.SSH.Enabled {
+		// Unset ports get a deterministic stand-in for the runtime's pick
+		hostPort := cfg.SSH.Port
+		if hostPort == 0 {
+			hostPort = 32768 + f.created
+		}
+		c.Ports = map[int]int{SSHContainerPort: hostPort}
+	}
+	f.images[image] = true
+	f.containers[name] = c
+
+	if cfg.Commands.Up.Command != "" {
+		util.ProgressStep(progressOut, "Running setup command...\n")
+		c.Execs = append(c.Execs, []string{"sh", "-c", cfg.Commands.Up.Command})
+	}
+	return nil
+}
+
+// Down removes the container. A missing container is not an error.
+func (f *Fake) Down(_ context.Context, _ *RuntimeEnv, _ string, st *state.State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Down", st.ContainerName); err != nil {
+		return err
+	}
+	delete(f.containers, st.ContainerName)
+	return nil
+}
+
+// Stop marks the container stopped; a missing container is not an error.
+func (f *Fake) Stop(_ context.Context, _ *RuntimeEnv, _ string, st *state.State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Stop", st.ContainerName); err != nil {
+		return err
+	}
+	if c, ok := f.containers[st.ContainerName]; ok {
+		c.State = StateStopped
+	}
+	return nil
+}
+
+// Pause marks the running container paused.
+func (f *Fake) Pause(_ context.Context, _ *RuntimeEnv, _ string, st *state.State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Pause", st.ContainerName); err != nil {
+		return err
+	}
+	c, err := f.running(st.ContainerName)
+	if err != nil {
+		return err
+	}
+	c.State = StatePaused
+	return nil
+}
+
+// Unpause marks the paused container running again.
+func (f *Fake) Unpause(_ context.Context, _ *RuntimeEnv, _ string, st *state.State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Unpause", st.ContainerName); err != nil {
+		return err
+	}
+	c, ok := f.containers[st.ContainerName]
+	if !ok || c.State != StatePaused {
+		return fmt.Errorf("%w: %s", ErrNotPaused, st.ContainerName)
+	}
+	c.State = StateRunning
+	return nil
+}
+
+// Exec records command in the running container.
+func (f *Fake) Exec(_ context.Context, _ *RuntimeEnv, _ *config.Config, _ string, st *state.State, command []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Exec", append([]string{st.ContainerName}, command...)...); err != nil {
+		return err
+	}
+	c, err := f.running(st.ContainerName)
+	if err != nil {
+		return err
+	}
+	c.Execs = append(c.Execs, command)
+	return nil
+}
+
+// ExecReadonly records the call; the container is not touched.
+func (f *Fake) ExecReadonly(_ context.Context, _ *RuntimeEnv, _ *config.Config, _ string, st *state.State, command []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ExecReadonly", append([]string{st.ContainerName}, command...)...); err != nil {
+		return err
+	}
+	_, err := f.running(st.ContainerName)
+	return err
+}
+
+// Status returns the container's state, StateNotFound if it does not exist.
+func (f *Fake) Status(_ context.Context, _ *RuntimeEnv, _ string, st *state.State) (ContainerStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if st == nil {
+		return ContainerStatus{State: StateNotFound}, nil
+	}
+	if err := f.record("Status", st.ContainerName); err != nil {
+		return ContainerStatus{}, err
+	}
+	c, ok := f.containers[st.ContainerName]
+	if !ok {
+		return ContainerStatus{State: StateNotFound}, nil
+	}
+	return ContainerStatus{State: c.State, ID: c.ID, Name: c.Name, Image: c.Image, StartedAt: c.CreatedAt}, nil
+}
+
+// Reload records the call; mounts are not modelled.
+func (f *Fake) Reload(_ context.Context, _ *RuntimeEnv, _ *config.Config, _ string, st *state.State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Reload", st.ContainerName); err != nil {
+		return err
+	}
+	_, err := f.running(st.ContainerName)
+	return err
+}
+
+// InspectContainer returns the container as JSON.
+func (f *Fake) InspectContainer(_ context.Context, _ *RuntimeEnv, containerName string) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("InspectContainer", containerName); err != nil {
+		return nil, err
+	}
+	c, ok := f.containers[containerName]
+	if !ok {
+		return nil, fmt.Errorf("no such container: %s", containerName)
+	}
+	return json.Marshal(c)
+}
+
+// ListContainers returns the containers labelled with a project ID, by name.
+func (f *Fake) ListContainers(_ context.Context, _ *RuntimeEnv) ([]ContainerInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListContainers"); err != nil {
+		return nil, err
+	}
+	var infos []ContainerInfo
+	for _, c := range f.containers {
+		if c.Labels[state.LabelProjectID] == "" {
+			continue
+		}
+		infos = append(infos, ContainerInfo{
+			Name:        c.Name,
+			State:       c.State,
+			ProjectID:   c.Labels[state.LabelProjectID],
+			ProjectPath: c.Labels[state.LabelProjectPath],
+			CreatedAt:   c.CreatedAt,
+			Image:       c.Image,
+			Version:     c.Labels[state.LabelVersion],
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// RemoveContainer removes a container by name.
+func (f *Fake) RemoveContainer(_ context.Context, _ *RuntimeEnv, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RemoveContainer", name); err != nil {
+		return err
+	}
+	delete(f.containers, name)
+	return nil
+}
+
+// GetContainerIP returns the container's deterministic IP.
+func (f *Fake) GetContainerIP(_ context.Context, _ *RuntimeEnv, containerName string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetContainerIP", containerName); err != nil {
+		return "", err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return "", err
+	}
+	return c.IP, nil
+}
+
+// ConnectPeerNetwork records the network on the container and returns
+// FakePeerSubnet.
+func (f *Fake) ConnectPeerNetwork(_ context.Context, _ *RuntimeEnv, containerName, network, alias string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ConnectPeerNetwork", containerName, network, alias); err != nil {
+		return "", err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(c.Networks, network) {
+		c.Networks = append(c.Networks, network)
+	}
+	return FakePeerSubnet, nil
+}
+
+// GetContainerPID returns the container's deterministic PID.
+func (f *Fake) GetContainerPID(_ context.Context, _ *RuntimeEnv, containerName string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetContainerPID", containerName); err != nil {
+		return 0, err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return 0, err
+	}
+	return c.PID, nil
+}
+
+// ContainerExit returns the container's scripted exit.
+func (f *Fake) ContainerExit(_ context.Context, _ *RuntimeEnv, containerName string) (ContainerExit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ContainerExit", containerName); err != nil {
+		return ContainerExit{}, err
+	}
+	c, ok := f.containers[containerName]
+	if !ok {
+		return ContainerExit{}, fmt.Errorf("no such container: %s", containerName)
+	}
+	return c.Exit, nil
+}
+
+// OOMKillCount returns the container's scripted OOM kill count.
+func (f *Fake) OOMKillCount(_ context.Context, _ *RuntimeEnv, containerName string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("OOMKillCount", containerName); err != nil {
+		return 0, err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return 0, err
+	}
+	return c.OOMKills, nil
+}
+
+// Logs writes the container's scripted logs.
+func (f *Fake) Logs(_ context.Context, _ *RuntimeEnv, containerName string, _ LogsOptions, out io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Logs", containerName); err != nil {
+		return err
+	}
+	c, ok := f.containers[containerName]
+	if !ok {
+		return fmt.Errorf("no such container: %s", containerName)
+	}
+	_, err := io.WriteString(out, c.Logs)
+	return err
+}
+
+// PublishedPort returns the host port from the container's Ports.
+func (f *Fake) PublishedPort(_ context.Context, _ *RuntimeEnv, containerName string, containerPort int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("PublishedPort", containerName, strconv.Itoa(containerPort)); err != nil {
+		return 0, err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return 0, err
+	}
+	port, ok := c.Ports[containerPort]
+	if !ok {
+		return 0, fmt.Errorf("%w: %d", ErrPortNotPublished, containerPort)
+	}
+	return port, nil
+}
+
+// GetHostIP returns the gateway of the fake network.
+func (f *Fake) GetHostIP(_ context.Context, _ *RuntimeEnv) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetHostIP"); err != nil {
+		return "", err
+	}
+	return "10.88.0.1", nil
+}
+
+// HostGatewayIP returns the gateway of the fake network.
+func (f *Fake) HostGatewayIP(_ context.Context, _ *RuntimeEnv) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("HostGatewayIP"); err != nil {
+		return "", err
+	}
+	return "10.88.0.1", nil
+}
+
+// PullImage marks image as present.
+func (f *Fake) PullImage(_ context.Context, _ *RuntimeEnv, image, _ string, _ io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("PullImage", image); err != nil {
+		return err
+	}
+	f.images[image] = true
+	return nil
+}
+
+// ImagePresent reports whether the image has been pulled, run or baked.
+func (f *Fake) ImagePresent(_ context.Context, _ *RuntimeEnv, image string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ImagePresent", image); err != nil {
+		return false, err
+	}
+	return f.images[image], nil
+}
+
+// Capabilities reports a rootful cgroup v2 daemon on the Go architecture.
+func (f *Fake) Capabilities(_ context.Context, _ *RuntimeEnv) (Capabilities, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Capabilities"); err != nil {
+		return Capabilities{}, err
+	}
+	return Capabilities{CgroupV2: true, HostArch: goruntime.GOARCH}, nil
+}
+
+// ImageArch reports the Go architecture for both the host and a present image.
+func (f *Fake) ImageArch(_ context.Context, _ *RuntimeEnv, image, _ string) (string, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ImageArch", image); err != nil {
+		return "", "", err
+	}
+	if !f.images[image] {
+		return goruntime.GOARCH, "", nil
+	}
+	return goruntime.GOARCH, goruntime.GOARCH, nil
+}
+
+// ImageUpdateAvailable always reports the image as current.
+func (f *Fake) ImageUpdateAvailable(_ context.Context, _ *RuntimeEnv, image string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return false, f.record("ImageUpdateAvailable", image)
+}
+
+// ClockDrift reports no drift.
+func (f *Fake) ClockDrift(_ context.Context, _ *RuntimeEnv, containerName string) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return 0, f.record("ClockDrift", containerName)
+}
+
+// SyncClock reports that containers share the host clock.
+func (f *Fake) SyncClock(_ context.Context, _ *RuntimeEnv, _ *config.Config) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("SyncClock"); err != nil {
+		return err
+	}
+	return ErrClockSyncUnsupported
+}
+
+// BakeImage marks image as present.
+func (f *Fake) BakeImage(_ context.Context, _ *RuntimeEnv, _ *config.Config, containerName, image string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("BakeImage", containerName, image); err != nil {
+		return err
+	}
+	if _, err := f.running(containerName); err != nil {
+		return err
+	}
+	f.images[image] = true
+	return nil
+}
+
+// RemoveImage forgets image.
+func (f *Fake) RemoveImage(_ context.Context, _ *RuntimeEnv, image string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RemoveImage", image); err != nil {
+		return err
+	}
+	delete(f.images, image)
+	return nil
+}
+
+// SaveImage writes a placeholder archive naming image to path on env.Fs.
+func (f *Fake) SaveImage(_ context.Context, env *RuntimeEnv, image, path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("SaveImage", image, path); err != nil {
+		return err
+	}
+	if !f.images[image] {
+		return fmt.Errorf("no such image: %s", image)
+	}
+	return afero.WriteFile(env.Fs, path, []byte("fake image "+image+"\n"), 0o644)
+}
+
+// CopyFromContainer writes a file held in the container's Files to dst on
+// env.Fs; every other path is missing.
+func (f *Fake) CopyFromContainer(_ context.Context, env *RuntimeEnv, containerName, src, dst string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CopyFromContainer", containerName, src, dst); err != nil {
+		return err
+	}
+	if c, ok := f.containers[containerName]; ok {
+		if content, ok := c.Files[src]; ok {
+			return afero.WriteFile(env.Fs, dst, []byte(content), 0o644)
+		}
+	}
+	return ErrPathNotFound
+}
+
+// CopyToContainer records the copy.
+func (f *Fake) CopyToContainer(_ context.Context, _ *RuntimeEnv, containerName, src, dst string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.record("CopyToContainer", containerName, src, dst)
+}
+
+// StagePaths copies the container's Files at or below each path into
+// stage.
+func (f *Fake) StagePaths(_ context.Context, _ *RuntimeEnv, containerName, stage string, paths []string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("StagePaths", append([]string{containerName, stage}, paths...)...); err != nil {
+		return nil, err
+	}
+	c, ok := f.containers[containerName]
+	if !ok {
+		return nil, fmt.Errorf("no such container: %s", containerName)
+	}
+	if _, ok := f.stages[stage]; ok {
+		return nil, fmt.Errorf("stage %s already exists", stage)
+	}
+	files := map[string]string{}
+	var staged []string
+	for _, p := range paths {
+		found := false
+		for name, content := range c.Files {
+			if name == p || strings.HasPrefix(name, p+"/") {
+				files[name] = content
+				found = true
+			}
+		}
+		if found {
+			staged = append(staged, p)
+		}
+	}
+	f.stages[stage] = files
+	return staged, nil
+}
+
+// RestoreStagedPaths copies the staged Files at or below each path into
+// the running container.
+func (f *Fake) RestoreStagedPaths(_ context.Context, _ *RuntimeEnv, stage, containerName string, paths []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RestoreStagedPaths", append([]string{stage, containerName}, paths...)...); err != nil {
+		return err
+	}
+	files, ok := f.stages[stage]
+	if !ok {
+		return fmt.Errorf("no such stage: %s", stage)
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return err
+	}
+	if c.Files == nil {
+		c.Files = map[string]string{}
+	}
+	for _, p := range paths {
+		for name, content := range files {
+			if name == p || strings.HasPrefix(name, p+"/") {
+				c.Files[name] = content
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveStage deletes the stage.
+func (f *Fake) RemoveStage(_ context.Context, _ *RuntimeEnv, stage string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RemoveStage", stage); err != nil {
+		return err
+	}
+	delete(f.stages, stage)
+	return nil
+}
+
+// Stage returns a copy of the files held by stage, or nil if it does not
+// exist.
+func (f *Fake) Stage(stage string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	files, ok := f.stages[stage]
+	if !ok {
+		return nil
+	}
+	return maps.Clone(files)
+}
+
+// WriteFile stores content in the container's Files.
+func (f *Fake) WriteFile(_ context.Context, _ *RuntimeEnv, containerName, path, content string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("WriteFile", containerName, path); err != nil {
+		return err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return err
+	}
+	if c.Files == nil {
+		c.Files = map[string]string{}
+	}
+	c.Files[path] = content
+	return nil
+}
+
+// Diff returns the container's Changes.
+func (f *Fake) Diff(_ context.Context, _ *RuntimeEnv, containerName string) ([]FileChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Diff", containerName); err != nil {
+		return nil, err
+	}
+	c, ok := f.containers[containerName]
+	if !ok {
+		return nil, fmt.Errorf("no such container: %s", containerName)
+	}
+	return slices.Clone(c.Changes), nil
+}
+
+// RunTask records the command in the container's Execs as sh -c and
+// writes nothing.
+func (f *Fake) RunTask(_ context.Context, _ *RuntimeEnv, _ *config.Config, containerName, command string, _ io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RunTask", containerName, command); err != nil {
+		return err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return err
+	}
+	c.Execs = append(c.Execs, []string{"sh", "-c", command})
+	return nil
+}
+
+// TouchFiles records paths as an exec in the running container.
+func (f *Fake) TouchFiles(_ context.Context, _ *RuntimeEnv, containerName string, paths []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("TouchFiles", append([]string{containerName}, paths...)...); err != nil {
+		return err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return err
+	}
+	c.Execs = append(c.Execs, append([]string{"touch"}, paths...))
+	return nil
+}
+
+// ImagePackages returns the list set by SetImagePackages.
+func (f *Fake) ImagePackages(_ context.Context, _ *RuntimeEnv, image, _ string) (PackageList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ImagePackages", image); err != nil {
+		return PackageList{}, err
+	}
+	return f.packages[image], nil
+}
+
+// ContainerPackages returns the container's Packages.
+func (f *Fake) ContainerPackages(_ context.Context, _ *RuntimeEnv, containerName string) (PackageList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ContainerPackages", containerName); err != nil {
+		return PackageList{}, err
+	}
+	c, err := f.running(containerName)
+	if err != nil {
+		return PackageList{}, err
+	}
+	return c.Packages, nil
+}
+
+// ContainerSpec reports the container's image, labels and ports, plus the
+// Env, WorkingDir and Binds set on it.
+func (f *Fake) ContainerSpec(_ context.Context, _ *RuntimeEnv, containerName string) (ContainerSpec, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ContainerSpec", containerName); err != nil {
+		return ContainerSpec{}, err
+	}
+	c, ok := f.containers[containerName]
+	if !ok {
+		return ContainerSpec{}, fmt.Errorf("no such container: %s", containerName)
+	}
+	spec := ContainerSpec{
+		Name:       c.Name,
+		Image:      c.Image,
+		WorkingDir: c.WorkingDir,
+		Env:        slices.Clone(c.Env),
+		Binds:      slices.Clone(c.Binds),
+		Running:    c.State == StateRunning,
+		Labels:     maps.Clone(c.Labels),
+	}
+	for containerPort, hostPort := range c.Ports {
+		p := config.PortConfig{Port: containerPort}
+		if hostPort != containerPort {
+			p.HostPort = hostPort
+		}
+		spec.Ports = append(spec.Ports, p)
+	}
+	slices.SortFunc(spec.Ports, func(a, b config.PortConfig) int { return cmp.Compare(a.Port, b.Port) })
+	return spec, nil
+}
+
+// UpdateResources records the new limits on the container.
+func (f *Fake) UpdateResources(_ context.Context, _ *RuntimeEnv, containerName string, res config.Resources) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("UpdateResources", containerName); err != nil {
+		return err
+	}
+	c, ok := f.containers[containerName]
+	if !ok {
+		return fmt.Errorf("no such container: %s", containerName)
+	}
+	if res.Memory != "" {
+		c.Resources.Memory = res.Memory
+	}
+	if res.CPUs > 0 {
+		c.Resources.CPUs = res.CPUs
+	}
+	if res.Cgroup.CPUWeight > 0 {
+		c.Resources.Cgroup.CPUWeight = res.Cgroup.CPUWeight
+	}
+	if res.Cgroup.CpusetCPUs != "" {
+		c.Resources.Cgroup.CpusetCPUs = res.Cgroup.CpusetCPUs
+	}
+	if res.Cgroup.CpusetMems != "" {
+		c.Resources.Cgroup.CpusetMems = res.Cgroup.CpusetMems
+	}
+	return nil
+}
+
+// DiskUsage reports FakeImageSize for each local image and DiskSize for the
+// container. Fake has no volumes.
+func (f *Fake) DiskUsage(_ context.Context, _ *RuntimeEnv, containerName string, images, _ []string) ([]DiskUsageItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("DiskUsage", containerName); err != nil {
+		return nil, err
+	}
+	var items []DiskUsageItem
+	for _, image := range images {
+		if f.images[image] {
+			items = append(items, DiskUsageItem{Kind: DiskUsageImage, Name: image, Bytes: FakeImageSize})
+		}
+	}
+	if c, ok := f.containers[containerName]; ok {
+		items = append(items, DiskUsageItem{Kind: DiskUsageContainer, Name: containerName, Bytes: c.DiskSize})
+	}
+	return items, nil
+}
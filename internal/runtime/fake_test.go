@@ -0,0 +1,92 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+func TestFake_DeterministicIdentity(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Image: "alpine"}
+
+	f := NewFake()
+	for _, name := range []string{"alca-a", "alca-b"} {
+		st := &state.State{ProjectID: name, ContainerName: name}
+		if err := f.Up(ctx, nil, cfg, "/p/"+name, st, nil); err != nil {
+			t.Fatalf("Up(%s) error: %v", name, err)
+		}
+	}
+
+	b := f.Container("alca-b")
+	if b.ID != "0000000000000000000000000000000000000000000000000000000000000002" || b.IP != "10.88.0.3" || b.PID != 1002 {
+		t.Errorf("second container identity = %s %s %d", b.ID, b.IP, b.PID)
+	}
+
+	infos, err := f.ListContainers(ctx, nil)
+	if err != nil || len(infos) != 2 || infos[0].Name != "alca-a" || infos[1].ProjectPath != "/p/alca-b" {
+		t.Errorf("ListContainers() = %+v, %v", infos, err)
+	}
+}
+
+func TestFake_FailNext(t *testing.T) {
+	ctx := context.Background()
+	st := &state.State{ContainerName: "alca-test"}
+	errBoom := errors.New("boom")
+
+	f := NewFake()
+	f.FailNext("Available", errBoom)
+	if f.Available(ctx, nil) {
+		t.Error("Available() = true with a scripted failure")
+	}
+	if !f.Available(ctx, nil) {
+		t.Error("failure was not consumed")
+	}
+
+	f.FailNext("Up", errBoom)
+	if err := f.Up(ctx, nil, &config.Config{Image: "alpine"}, "/p", st, nil); !errors.Is(err, errBoom) {
+		t.Errorf("Up() error = %v, want boom", err)
+	}
+	if f.Container("alca-test") != nil {
+		t.Error("failed Up created a container")
+	}
+
+	if err := f.Exec(ctx, nil, nil, "/p", st, []string{"true"}); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Exec() on a missing container: error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestFake_WritesToHostFs(t *testing.T) {
+	ctx := context.Background()
+	env := &RuntimeEnv{Fs: afero.NewMemMapFs()}
+	st := &state.State{ContainerName: "alca-test"}
+
+	f := NewFake()
+	if err := f.Up(ctx, env, &config.Config{Image: "alpine"}, "/p", st, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.WriteFile(ctx, env, "alca-test", "/etc/motd", "hi\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.CopyFromContainer(ctx, env, "alca-test", "/etc/motd", "/backup/motd"); err != nil {
+		t.Fatalf("CopyFromContainer() error = %v", err)
+	}
+	if err := f.CopyFromContainer(ctx, env, "alca-test", "/missing", "/backup/missing"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("CopyFromContainer(missing) error = %v, want ErrPathNotFound", err)
+	}
+	if err := f.SaveImage(ctx, env, "alpine", "/image.tar"); err != nil {
+		t.Fatalf("SaveImage() error = %v", err)
+	}
+
+	for path, want := range map[string]string{"/backup/motd": "hi\n", "/image.tar": "fake image alpine\n"} {
+		if got, err := afero.ReadFile(env.Fs, path); err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", path, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package runtime
+
+import (
+	"context"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+// HostAlias is the hostname containers reach the host machine by, on every
+// platform. Docker Desktop's host.docker.internal does not exist on Linux,
+// and Podman names it host.containers.internal.
+const HostAlias = "host.alca.internal"
+
+// hostGatewayDaemon is the --add-host value the daemon resolves to the host
+// itself, used when the address cannot be determined.
+const hostGatewayDaemon = "host-gateway"
+
+// Addresses of the host machine as seen from containers of VM-based
+// runtimes on macOS. The bridge gateway there is the VM, not the Mac.
+const (
+	dockerDesktopHostIP = "192.168.65.254"
+	orbStackHostIP      = "0.250.250.254"
+	colimaHostIP        = "192.168.5.2"
+	podmanMachineHostIP = "192.168.127.254"
+)
+
+// HostGatewayIP returns the address HostAlias resolves to in containers:
+// the bridge gateway on Linux (and for remote daemons, whose host is the
+// daemon's machine), or the fixed address through which each macOS VM
+// forwards to the Mac.
+func (r *dockerCLICompatibleRuntime) HostGatewayIP(ctx context.Context, env *RuntimeEnv) (string, error) {
+	if ip := vmHostIP(DetectPlatform(ctx, env), r.machine); ip != "" {
+		return ip, nil
+	}
+	return r.GetHostIP(ctx, env)
+}
+
+// vmHostIP returns the host address of a macOS VM platform, or "" where
+// the bridge gateway is the host. machine is set when the runtime manages a
+// Podman machine itself, whichever platform the Docker API reported.
+func vmHostIP(platform RuntimePlatform, machine bool) string {
+	switch {
+	case !IsDarwin(platform):
+		return ""
+	case machine, platform == PlatformMacPodman:
+		return podmanMachineHostIP
+	case platform == PlatformMacOrbStack:
+		return orbStackHostIP
+	case platform == PlatformMacColima:
+		return colimaHostIP
+	default:
+		return dockerDesktopHostIP
+	}
+}
+
+// hostAliasRunArgs maps HostAlias to the host. If the address cannot be
+// resolved the daemon's host-gateway is used. Containers without a network
+// get no alias.
+func (r *dockerCLICompatibleRuntime) hostAliasRunArgs(ctx context.Context, env *RuntimeEnv, cfg *config.Config) []string {
+	if cfg.Network.IsolationLevel() == config.IsolationNone {
+		return nil
+	}
+	ip, err := r.HostGatewayIP(ctx, env)
+	if err != nil || ip == "" {
+		ip = hostGatewayDaemon
+	}
+	return []string{"--add-host", HostAlias + ":" + ip}
+}
@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestVMHostIP(t *testing.T) {
+	tests := []struct {
+		platform RuntimePlatform
+		machine  bool
+		want     string
+	}{
+		{PlatformLinux, false, ""},
+		{PlatformLinux, true, ""},
+		{PlatformRemote, false, ""},
+		{PlatformMacDockerDesktop, false, dockerDesktopHostIP},
+		{PlatformMacOrbStack, false, orbStackHostIP},
+		{PlatformMacColima, false, colimaHostIP},
+		{PlatformMacDockerDesktop, true, podmanMachineHostIP},
+		{PlatformMacPodman, false, podmanMachineHostIP},
+	}
+	for _, tt := range tests {
+		if got := vmHostIP(tt.platform, tt.machine); got != tt.want {
+			t.Errorf("vmHostIP(%s, %t) = %q, want %q", tt.platform, tt.machine, got, tt.want)
+		}
+	}
+}
+
+func TestHostAliasRunArgs(t *testing.T) {
+	gatewayCmd := "docker network inspect bridge --format {{(index .IPAM.Config 0).Gateway}}"
+
+	t.Run("bridge gateway", func(t *testing.T) {
+		cmd := util.NewMockCommandRunner().ExpectSuccess(gatewayCmd, []byte("172.17.0.1\n"))
+		args := NewDocker().hostAliasRunArgs(context.Background(), NewRuntimeEnv(cmd), &config.Config{})
+		want := []string{"--add-host", "host.alca.internal:172.17.0.1"}
+		if !slices.Equal(args, want) {
+			t.Errorf("args = %v, want %v", args, want)
+		}
+	})
+
+	t.Run("unresolved falls back to host-gateway", func(t *testing.T) {
+		cmd := util.NewMockCommandRunner().ExpectFailure(gatewayCmd, fmt.Errorf("daemon down"))
+		args := NewDocker().hostAliasRunArgs(context.Background(), NewRuntimeEnv(cmd), &config.Config{})
+		want := []string{"--add-host", "host.alca.internal:host-gateway"}
+		if !slices.Equal(args, want) {
+			t.Errorf("args = %v, want %v", args, want)
+		}
+	})
+
+	t.Run("no network", func(t *testing.T) {
+		cfg := &config.Config{Network: config.Network{Isolation: config.IsolationNone}}
+		args := NewDocker().hostAliasRunArgs(context.Background(), NewRuntimeEnv(util.NewMockCommandRunner()), cfg)
+		if args != nil {
+			t.Errorf("args = %v, want none", args)
+		}
+	})
+}
@@ -0,0 +1,250 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ErrImageDigestResolution is returned when the upstream digest of an image cannot be resolved.
+var ErrImageDigestResolution = errors.New("image digest resolution failed")
+
+// indentOutput indents streamed command output under the current progress step.
+var indentOutput = util.PrefixLines("  ")
+
+// containerIDLine matches the container ID that `run -d` prints on success.
+var containerIDLine = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// lastLine returns the last non-empty line of output, which for a failed
+// runtime command is the error message.
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// pullBackoff retries a pull that a flaky network cut off. The runtime keeps
+// the layers it finished, so each attempt only downloads what is missing.
+var pullBackoff = util.Backoff{Initial: 2 * time.Second, Max: 15 * time.Second, Jitter: 0.2, MaxAttempts: 3}
+
+// PullImage pulls the image from its registry, streaming the runtime's progress output.
+func (r *dockerCLICompatibleRuntime) PullImage(ctx context.Context, env *RuntimeEnv, image, platform string, progressOut io.Writer) error {
+	return r.pullWithRetry(ctx, env, image, platform, progressOut, pullBackoff)
+}
+
+func (r *dockerCLICompatibleRuntime) pullWithRetry(ctx context.Context, env *RuntimeEnv, image, platform string, progressOut io.Writer, b util.Backoff) error {
+	args := []string{"pull"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	args = append(args, image)
+
+	var output []byte
+	err := util.Retry(ctx, b, func(attempt int) error {
+		if attempt > 0 {
+			util.ProgressStep(progressOut, "Pull interrupted, retrying (attempt %d of %d); downloaded layers are kept\n", attempt+1, b.MaxAttempts)
+		}
+		var err error
+		output, err = env.Cmd.RunStream(ctx, progressOut, newPullProgress().line, r.command, args...)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !pullInterrupted(output) {
+			return util.Permanent(err)
+		}
+		return err
+	})
+	if err != nil {
+		return r.pullError(image, output, fmt.Errorf("%s pull %s failed: %w: %s", r.command, image, err, lastLine(output)))
+	}
+	return nil
+}
+
+// pullAuthFailures are what registries answer, through the runtime, when
+// they refuse to serve an image to the current credentials.
+var pullAuthFailures = []string{
+	"unauthorized",
+	"authentication required",
+	"pull access denied",
+	"requested access to the resource is denied",
+	"no basic auth credentials",
+	"denied:",
+}
+
+// pullRateLimitFailures are what registries answer when the client has
+// pulled too often (Docker Hub limits anonymous pulls per IP).
+var pullRateLimitFailures = []string{
+	"toomanyrequests",
+	"too many requests",
+	"rate limit",
+}
+
+// pullNetworkFailures are the errors of a download cut off mid-way, which
+// a retry resumes.
+var pullNetworkFailures = []string{
+	"connection reset",
+	"connection refused",
+	"unexpected eof",
+	"i/o timeout",
+	"tls handshake timeout",
+	"broken pipe",
+	"timeout exceeded",
+	"temporary failure",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
+
+// pullInterrupted reports whether a failed pull's output shows a network
+// failure rather than a refusal.
+func pullInterrupted(output []byte) bool {
+	lower := strings.ToLower(string(output))
+	return !containsAny(lower, pullAuthFailures) && !containsAny(lower, pullRateLimitFailures) &&
+		containsAny(lower, pullNetworkFailures)
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// pullError wraps err in a HintedError when the output of a pull (or of a
+// run that pulled) shows why it failed: the registry refused the image,
+// rate-limited the client, or the network dropped mid-download. Returns err
+// unchanged otherwise.
+func (r *dockerCLICompatibleRuntime) pullError(image string, output []byte, err error) error {
+	lower := strings.ToLower(string(output))
+	switch {
+	case containsAny(lower, pullAuthFailures):
+		return util.NewHintedError(util.CodeImagePullAuth,
+			fmt.Sprintf("the registry refused to serve %s", image),
+			fmt.Sprintf("Check the image name, then log in to its registry with '%s login <registry>' "+
+				"(Docker Hub: '%s login'); private images need credentials with pull access.", r.command, r.command), err)
+	case containsAny(lower, pullRateLimitFailures):
+		return util.NewHintedError(util.CodeImagePullRateLimit,
+			fmt.Sprintf("the registry rate-limited the pull of %s", image),
+			fmt.Sprintf("Wait for the limit to reset, log in with '%s login' to get a higher limit, "+
+				"or set registry_mirror to pull through a mirror.", r.command), err)
+	case containsAny(lower, pullNetworkFailures):
+		return util.NewHintedError(util.CodeImagePullInterrupted,
+			fmt.Sprintf("the network failed while pulling %s", image),
+			"Run 'alca pull' again: layers that finished downloading are kept, so the pull resumes where it stopped. "+
+				"On a slow network, raise timeouts.pull.", err)
+	}
+	return err
+}
+
+// ImagePresent reports whether the image is available locally.
+func (r *dockerCLICompatibleRuntime) ImagePresent(ctx context.Context, env *RuntimeEnv, image string) (bool, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "image", "inspect", "--format", "{{.Id}}", image)
+	if err == nil {
+		return true, nil
+	}
+	lower := strings.ToLower(string(output))
+	if strings.Contains(lower, "no such image") || strings.Contains(lower, "image not known") {
+		return false, nil
+	}
+	return false, fmt.Errorf("%s image inspect %s failed: %w: %s", r.command, image, err, lastLine(output))
+}
+
+// ImageArch returns the runtime host's architecture (from Capabilities) and
+// the architecture the image runs as, both in GOARCH form.
+func (r *dockerCLICompatibleRuntime) ImageArch(ctx context.Context, env *RuntimeEnv, image, platform string) (string, string, error) {
+	caps, err := r.Capabilities(ctx, env)
+	if err != nil {
+		return "", "", err
+	}
+	hostArch := caps.HostArch
+
+	if platform != "" {
+		return hostArch, PlatformArch(platform), nil
+	}
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "image", "inspect", "--format", "{{.Architecture}}", image)
+	if err != nil {
+		return hostArch, "", nil // not pulled yet
+	}
+	return hostArch, normalizeArch(strings.TrimSpace(string(output))), nil
+}
+
+// PlatformArch returns the architecture of an os/arch[/variant] platform.
+func PlatformArch(platform string) string {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return normalizeArch(parts[1])
+}
+
+// normalizeArch maps uname -m architecture names to GOARCH names.
+func normalizeArch(arch string) string {
+	switch arch {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	}
+	return arch
+}
+
+// ImageUpdateAvailable reports whether the registry has a different digest for the
+// image tag than the locally pulled copy. Returns false if the image is not present
+// locally or was built locally (no repo digests), since there is nothing to compare.
+func (r *dockerCLICompatibleRuntime) ImageUpdateAvailable(ctx context.Context, env *RuntimeEnv, image string) (bool, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "image", "inspect",
+		"--format", `{{join .RepoDigests " "}}`, image)
+	if err != nil {
+		return false, nil
+	}
+	localDigests := strings.Fields(string(output))
+	if len(localDigests) == 0 {
+		return false, nil
+	}
+
+	remote, err := r.remoteImageDigest(ctx, env.Cmd, image)
+	if err != nil {
+		return false, err
+	}
+
+	for _, d := range localDigests {
+		if strings.HasSuffix(d, "@"+remote) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// remoteImageDigest queries the registry for the manifest digest of the image tag
+// without pulling it. Docker uses buildx imagetools; Podman has no equivalent
+// subcommand, so skopeo is used instead.
+func (r *dockerCLICompatibleRuntime) remoteImageDigest(ctx context.Context, cmd util.CommandRunner, image string) (string, error) {
+	var output []byte
+	var err error
+	switch r.command {
+	case "docker":
+		output, err = cmd.RunQuiet(ctx, "docker", "buildx", "imagetools", "inspect",
+			"--format", "{{.Manifest.Digest}}", image)
+	case "podman":
+		output, err = cmd.RunQuiet(ctx, "skopeo", "inspect",
+			"--format", "{{.Digest}}", "docker://"+image)
+	default:
+		return "", fmt.Errorf("%w: unsupported runtime %q", ErrImageDigestResolution, r.command)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v: %s", ErrImageDigestResolution, err, strings.TrimSpace(string(output)))
+	}
+
+	digest := strings.TrimSpace(string(output))
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", fmt.Errorf("%w: unexpected digest %q", ErrImageDigestResolution, digest)
+	}
+	return digest, nil
+}
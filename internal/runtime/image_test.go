@@ -0,0 +1,294 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	testImage        = "nixos/nix:latest"
+	testDigest       = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testNewerDigest  = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	dockerInspectCmd = `docker image inspect --format {{join .RepoDigests " "}} ` + testImage
+	dockerRemoteCmd  = "docker buildx imagetools inspect --format {{.Manifest.Digest}} " + testImage
+)
+
+func TestPullImage(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("podman pull "+testImage, nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	if err := NewPodman().PullImage(context.Background(), NewRuntimeEnv(cmd), testImage, "", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPullImage_AuthFailure(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.Expect("docker pull "+testImage,
+		[]byte("Error response from daemon: pull access denied for nixos/nix, repository does not exist or may require 'docker login'\n"),
+		errors.New("exit status 1"))
+
+	err := NewDocker().PullImage(context.Background(), NewRuntimeEnv(cmd), testImage, "", nil)
+	h, ok := util.AsHintedError(err)
+	if !ok || h.Code != util.CodeImagePullAuth {
+		t.Fatalf("PullImage() = %v, want a %s error", err, util.CodeImagePullAuth)
+	}
+	if !strings.Contains(h.Remediation, "docker login") {
+		t.Errorf("remediation %q does not mention docker login", h.Remediation)
+	}
+}
+
+func TestPullImage_OtherFailureHasNoHint(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.Expect("docker pull "+testImage, []byte("Error response from daemon: manifest unknown\n"), errors.New("exit status 1"))
+
+	err := NewDocker().PullImage(context.Background(), NewRuntimeEnv(cmd), testImage, "", nil)
+	if _, ok := util.AsHintedError(err); ok || err == nil {
+		t.Fatalf("PullImage() = %v, want a plain error", err)
+	}
+}
+
+func TestPullImage_StreamsProgress(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker pull "+testImage, []byte("latest: Pulling from nixos/nix\nStatus: Downloaded newer image\n"))
+
+	var out bytes.Buffer
+	if err := NewDocker().PullImage(context.Background(), NewRuntimeEnv(cmd), testImage, "", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "  latest: Pulling from nixos/nix\n  Status: Downloaded newer image\n"; out.String() != want {
+		t.Errorf("progress = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPullImage_RetriesInterruptedPull(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSequence("docker pull "+testImage,
+		[]byte("latest: Pulling from nixos/nix\nread tcp 10.0.0.2:51234->104.18.1.1:443: read: connection reset by peer\n"),
+		errors.New("exit status 1"))
+	cmd.ExpectSequence("docker pull "+testImage, []byte("Status: Downloaded newer image\n"), nil)
+
+	var out bytes.Buffer
+	err := NewDocker().pullWithRetry(context.Background(), NewRuntimeEnv(cmd), testImage, "", &out, util.Backoff{MaxAttempts: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(cmd.Calls); n != 2 {
+		t.Errorf("pull ran %d times, want 2", n)
+	}
+	if !strings.Contains(out.String(), "retrying (attempt 2 of 3); downloaded layers are kept") {
+		t.Errorf("progress = %q, want a retry note", out.String())
+	}
+}
+
+func TestPullImage_FailureHints(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		code    util.ErrorCode
+		attempt int
+	}{
+		{"rate limit", "Error response from daemon: toomanyrequests: You have reached your pull rate limit.\n", util.CodeImagePullRateLimit, 1},
+		{"network", "Error response from daemon: Get \"https://registry-1.docker.io/v2/\": net/http: TLS handshake timeout\n", util.CodeImagePullInterrupted, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := util.NewMockCommandRunner()
+			cmd.Expect("docker pull "+testImage, []byte(tt.output), errors.New("exit status 1"))
+
+			err := NewDocker().pullWithRetry(context.Background(), NewRuntimeEnv(cmd), testImage, "", nil, util.Backoff{MaxAttempts: 2})
+			h, ok := util.AsHintedError(err)
+			if !ok || h.Code != tt.code {
+				t.Fatalf("pullWithRetry() = %v, want a %s error", err, tt.code)
+			}
+			if n := len(cmd.Calls); n != tt.attempt {
+				t.Errorf("pull ran %d times, want %d", n, tt.attempt)
+			}
+		})
+	}
+}
+
+func TestImagePresent(t *testing.T) {
+	inspect := "docker image inspect --format {{.Id}} " + testImage
+	tests := []struct {
+		name    string
+		output  string
+		err     error
+		want    bool
+		wantErr bool
+	}{
+		{"present", "sha256:abc\n", nil, true, false},
+		{"missing", "Error response from daemon: No such image: " + testImage + "\n", errors.New("exit status 1"), false, false},
+		{"daemon down", "Cannot connect to the Docker daemon\n", errors.New("exit status 1"), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := util.NewMockCommandRunner()
+			cmd.Expect(inspect, []byte(tt.output), tt.err)
+			got, err := NewDocker().ImagePresent(context.Background(), NewRuntimeEnv(cmd), testImage)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("ImagePresent() = %v, %v; want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageUpdateAvailable_Docker(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote string
+		want   bool
+	}{
+		{"same digest", testDigest, false},
+		{"newer digest", testNewerDigest, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := util.NewMockCommandRunner()
+			cmd.ExpectSuccess(dockerInspectCmd, []byte("nixos/nix@"+testDigest+"\n"))
+			cmd.ExpectSuccess(dockerRemoteCmd, []byte(tt.remote+"\n"))
+			defer cmd.AssertAllExpectationsMet(t)
+
+			got, err := NewDocker().ImageUpdateAvailable(context.Background(), NewRuntimeEnv(cmd), testImage)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageUpdateAvailable_Podman(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(`podman image inspect --format {{join .RepoDigests " "}} `+testImage,
+		[]byte("docker.io/nixos/nix@"+testDigest))
+	cmd.ExpectSuccess("skopeo inspect --format {{.Digest}} docker://"+testImage, []byte(testNewerDigest))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	got, err := NewPodman().ImageUpdateAvailable(context.Background(), NewRuntimeEnv(cmd), testImage)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got {
+		t.Error("expected update to be available")
+	}
+}
+
+func TestImageUpdateAvailable_NotPulled(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectFailure(dockerInspectCmd, errors.New("no such image"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	got, err := NewDocker().ImageUpdateAvailable(context.Background(), NewRuntimeEnv(cmd), testImage)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got {
+		t.Error("expected no update for an image that is not pulled")
+	}
+}
+
+func TestImageUpdateAvailable_LocallyBuilt(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(dockerInspectCmd, []byte("\n"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	got, err := NewDocker().ImageUpdateAvailable(context.Background(), NewRuntimeEnv(cmd), testImage)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got {
+		t.Error("expected no update for a locally built image")
+	}
+}
+
+func TestImageUpdateAvailable_RegistryError(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(dockerInspectCmd, []byte("nixos/nix@"+testDigest))
+	cmd.ExpectFailure(dockerRemoteCmd, errors.New("unauthorized"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	_, err := NewDocker().ImageUpdateAvailable(context.Background(), NewRuntimeEnv(cmd), testImage)
+	if !errors.Is(err, ErrImageDigestResolution) {
+		t.Fatalf("expected ErrImageDigestResolution, got %v", err)
+	}
+}
+
+func TestPullImage_Platform(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker pull --platform linux/arm64 "+testImage, nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	if err := NewDocker().PullImage(context.Background(), NewRuntimeEnv(cmd), testImage, "linux/arm64", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestImageArch(t *testing.T) {
+	tests := []struct {
+		name      string
+		rt        Runtime
+		platform  string
+		expect    map[string]string
+		wantHost  string
+		wantImage string
+	}{
+		{
+			name: "docker local image",
+			rt:   NewDocker(),
+			expect: map[string]string{
+				"docker info --format {{json .}}":                              `{"Architecture":"aarch64"}`,
+				"docker image inspect --format {{.Architecture}} " + testImage: "amd64\n",
+			},
+			wantHost:  "arm64",
+			wantImage: "amd64",
+		},
+		{
+			name:     "platform override",
+			rt:       NewPodman(),
+			platform: "linux/arm/v7",
+			expect: map[string]string{
+				"podman info --format {{json .}}": `{"host":{"arch":"amd64"}}`,
+			},
+			wantHost:  "amd64",
+			wantImage: "arm",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := util.NewMockCommandRunner()
+			for k, v := range tt.expect {
+				cmd.ExpectSuccess(k, []byte(v))
+			}
+			defer cmd.AssertAllExpectationsMet(t)
+
+			host, image, err := tt.rt.ImageArch(context.Background(), NewRuntimeEnv(cmd), testImage, tt.platform)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if host != tt.wantHost || image != tt.wantImage {
+				t.Errorf("ImageArch() = (%q, %q), want (%q, %q)", host, image, tt.wantHost, tt.wantImage)
+			}
+		})
+	}
+}
+
+func TestImageArch_NotPulled(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker info --format {{json .}}", []byte(`{"Architecture":"x86_64"}`))
+	cmd.ExpectFailure("docker image inspect --format {{.Architecture}} "+testImage, errors.New("no such image"))
+
+	host, image, err := NewDocker().ImageArch(context.Background(), NewRuntimeEnv(cmd), testImage, "")
+	if err != nil || host != "amd64" || image != "" {
+		t.Errorf("ImageArch() = (%q, %q, %v), want (amd64, \"\", nil)", host, image, err)
+	}
+}
@@ -0,0 +1,47 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// LogsOptions selects the container logs Logs writes.
+type LogsOptions struct {
+	// Follow keeps streaming new output until the container stops or ctx
+	// is canceled.
+	Follow bool
+	// Since only shows logs after a duration ago (10m) or a timestamp.
+	Since string
+	// Tail only shows this many lines from the end, or "all".
+	Tail string
+	// Timestamps prefixes each line with its time.
+	Timestamps bool
+}
+
+// logsArgs returns the logs command arguments for opts.
+func logsArgs(containerName string, opts LogsOptions) []string {
+	args := []string{"logs"}
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Tail != "" {
+		args = append(args, "--tail", opts.Tail)
+	}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	return append(args, containerName)
+}
+
+// Logs writes the container's stdout and stderr to out as they are read,
+// without buffering, so followed logs can run indefinitely.
+func (r *dockerCLICompatibleRuntime) Logs(ctx context.Context, env *RuntimeEnv, containerName string, opts LogsOptions, out io.Writer) error {
+	if err := env.Cmd.RunTo(ctx, out, r.command, logsArgs(containerName, opts)...); err != nil {
+		return fmt.Errorf("failed to read container logs: %w", err)
+	}
+	return nil
+}
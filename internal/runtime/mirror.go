@@ -0,0 +1,97 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// dockerHubHosts are the registry names that refer to Docker Hub.
+var dockerHubHosts = []string{"docker.io", "index.docker.io", "registry-1.docker.io"}
+
+// MirrorImage rewrites a Docker Hub image reference to pull through the
+// registry mirror (http(s)://host[:port]), e.g. alpine:3 becomes
+// localhost:5000/library/alpine:3. ok is false for images hosted elsewhere,
+// which a pull-through cache for Docker Hub cannot serve.
+func MirrorImage(mirror, image string) (mirrored string, ok bool) {
+	u, err := url.Parse(mirror)
+	if err != nil || u.Host == "" {
+		return image, false
+	}
+
+	repo := image
+	if first, rest, found := strings.Cut(image, "/"); found {
+		switch {
+		case slices.Contains(dockerHubHosts, first):
+			repo = rest
+		case strings.ContainsAny(first, ".:") || first == "localhost":
+			return image, false
+		}
+	}
+	if !strings.Contains(repo, "/") {
+		repo = "library/" + repo
+	}
+	return u.Host + "/" + repo, true
+}
+
+// mirrorConfigured reports whether mirror is among the daemon's
+// registry-mirrors, ignoring trailing slashes.
+func mirrorConfigured(daemonMirrors []string, mirror string) bool {
+	want := strings.TrimRight(mirror, "/")
+	return slices.ContainsFunc(daemonMirrors, func(m string) bool {
+		return strings.TrimRight(m, "/") == want
+	})
+}
+
+// useRegistryMirror applies cfg.RegistryMirror before the container is
+// created. Runtimes whose daemon handles mirrors only get a warning when the
+// mirror is not configured there; others pull the image through the mirror
+// and tag it under its original name, so run finds it locally. Mirror
+// problems never fail up: the run falls back to pulling upstream.
+func (r *dockerCLICompatibleRuntime) useRegistryMirror(ctx context.Context, env *RuntimeEnv, cfg *config.Config, caps Capabilities, capsErr error, progressOut io.Writer) {
+	mirror := cfg.RegistryMirror
+	if r.daemonMirrors {
+		if capsErr == nil && !mirrorConfigured(caps.RegistryMirrors, mirror) {
+			util.ProgressStep(progressOut, "Warning: registry_mirror %s is not in the %s daemon's registry-mirrors; pulling from upstream\n", mirror, r.displayName)
+		}
+		return
+	}
+
+	mirrored, ok := MirrorImage(mirror, cfg.Image)
+	if !ok {
+		return
+	}
+	if _, err := env.Cmd.RunQuiet(ctx, r.command, "image", "inspect", "--format", "{{.Id}}", cfg.Image); err == nil {
+		return
+	}
+
+	args := []string{"pull"}
+	if strings.HasPrefix(mirror, "http://") {
+		args = append(args, "--tls-verify=false")
+	}
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
+	args = append(args, mirrored)
+
+	util.ProgressStep(progressOut, "Pulling through registry mirror: %s\n", mirrored)
+	pullTimeout := cfg.Timeouts.PullTimeout()
+	pullCtx, cancel := util.WithTimeout(ctx, pullTimeout)
+	output, err := env.Cmd.RunStream(pullCtx, progressOut, newPullProgress().line, r.command, args...)
+	err = util.TimeoutError(pullCtx, "mirror pull (timeouts.pull)", pullTimeout, err)
+	cancel()
+	if err == nil {
+		output, err = env.Cmd.RunQuiet(ctx, r.command, "tag", mirrored, cfg.Image)
+	}
+	if err != nil {
+		util.ProgressStep(progressOut, "Warning: registry mirror unavailable, pulling from upstream: %v: %s\n", err, lastLine(output))
+		return
+	}
+	// Drop the mirror name; the image stays under its original tag
+	_, _ = env.Cmd.RunQuiet(ctx, r.command, "rmi", mirrored)
+}
@@ -0,0 +1,96 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestMirrorImage(t *testing.T) {
+	tests := []struct {
+		image  string
+		want   string
+		wantOK bool
+	}{
+		{"alpine:3", "localhost:5000/library/alpine:3", true},
+		{"nixos/nix:latest", "localhost:5000/nixos/nix:latest", true},
+		{"docker.io/library/node:22", "localhost:5000/library/node:22", true},
+		{"docker.io/golang", "localhost:5000/library/golang", true},
+		{"ghcr.io/org/tool:1", "ghcr.io/org/tool:1", false},
+		{"localhost/dev:latest", "localhost/dev:latest", false},
+		{"registry:5000/app", "registry:5000/app", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, ok := MirrorImage("http://localhost:5000", tt.image)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("MirrorImage() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestUseRegistryMirror_PodmanPullsThroughMirror(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectFailure("podman image inspect --format {{.Id}} alpine:3", errors.New("no such image"))
+	cmd.ExpectSuccess("podman pull --tls-verify=false localhost:5000/library/alpine:3", nil)
+	cmd.ExpectSuccess("podman tag localhost:5000/library/alpine:3 alpine:3", nil)
+	cmd.ExpectSuccess("podman rmi localhost:5000/library/alpine:3", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	cfg := &config.Config{Image: "alpine:3", RegistryMirror: "http://localhost:5000"}
+	NewPodman().useRegistryMirror(context.Background(), NewRuntimeEnv(cmd), cfg, Capabilities{}, nil, nil)
+}
+
+func TestUseRegistryMirror_PodmanFallsBackOnFailure(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectFailure("podman image inspect --format {{.Id}} alpine:3", errors.New("no such image"))
+	cmd.ExpectFailure("podman pull --tls-verify=false localhost:5000/library/alpine:3", errors.New("connection refused"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	var out bytes.Buffer
+	cfg := &config.Config{Image: "alpine:3", RegistryMirror: "http://localhost:5000"}
+	NewPodman().useRegistryMirror(context.Background(), NewRuntimeEnv(cmd), cfg, Capabilities{}, nil, &out)
+	if !strings.Contains(out.String(), "pulling from upstream") {
+		t.Errorf("expected a fallback warning, got %q", out.String())
+	}
+}
+
+func TestUseRegistryMirror_PodmanSkipsLocalImage(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("podman image inspect --format {{.Id}} alpine:3", []byte("sha256:abc\n"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	cfg := &config.Config{Image: "alpine:3", RegistryMirror: "https://mirror.example.com"}
+	NewPodman().useRegistryMirror(context.Background(), NewRuntimeEnv(cmd), cfg, Capabilities{}, nil, nil)
+}
+
+func TestUseRegistryMirror_DockerDaemonHint(t *testing.T) {
+	cfg := &config.Config{Image: "alpine:3", RegistryMirror: "http://localhost:5000"}
+	tests := []struct {
+		name     string
+		mirrors  []string
+		wantWarn bool
+	}{
+		{"configured", []string{"http://localhost:5000/"}, false},
+		{"missing", []string{"https://mirror.gcr.io"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := util.NewMockCommandRunner()
+			var out bytes.Buffer
+			NewDocker().useRegistryMirror(context.Background(), NewRuntimeEnv(cmd), cfg, Capabilities{RegistryMirrors: tt.mirrors}, nil, &out)
+			if got := strings.Contains(out.String(), "Warning"); got != tt.wantWarn {
+				t.Errorf("warned = %v, want %v: %q", got, tt.wantWarn, out.String())
+			}
+			if len(cmd.Calls) != 0 {
+				t.Errorf("docker should not run commands, got %v", cmd.Calls)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
 
@@ -16,48 +17,68 @@ import (
 // Each session syncs files from a host source to a container target,
 // with optional ignore patterns for excluding files.
 type MutagenSync struct {
-	Name    string   // Session name (unique per project+mount)
-	Source  string   // Host path
-	Target  string   // Container path (format: docker://container-id/path)
-	Ignores []string // Patterns to ignore (gitignore-like syntax)
+	Name    string           // Session name (unique per project+mount)
+	Source  string           // Host path
+	Target  string           // Container path (format: docker://container-id/path)
+	Ignores []string         // Patterns to ignore (gitignore-like syntax)
+	Options config.MountSync // Session limits, symlink and permissions handling (zero values keep Mutagen's defaults)
 }
 
 // Create creates a new Mutagen sync session.
-// CLI command: mutagen sync create --name=<name> [--ignore=<pattern>]... <source> <target>
+// CLI command: mutagen sync create --name=<name> [--ignore=<pattern>]... [<options>] <source> <target>
+// Retries if the container endpoint is not reachable yet (e.g. just started).
 func (m *MutagenSync) Create(ctx context.Context, env *RuntimeEnv) error {
+	return m.createWithRetry(ctx, env, createBackoff)
+}
+
+var (
+	// createBackoff covers a container that is still starting.
+	createBackoff = util.Backoff{Initial: 500 * time.Millisecond, Max: 4 * time.Second, Jitter: 0.2, MaxAttempts: 5}
+	// flushBackoff covers a just-created session that is still connecting.
+	flushBackoff = util.Backoff{Initial: 250 * time.Millisecond, Max: 2 * time.Second, Jitter: 0.2, MaxElapsed: 30 * time.Second}
+)
+
+func (m *MutagenSync) createWithRetry(ctx context.Context, env *RuntimeEnv, b util.Backoff) error {
 	args := m.buildCreateArgs()
-	output, err := env.Cmd.RunQuiet(ctx, "mutagen", args...)
-	if err != nil {
-		return fmt.Errorf("mutagen sync create failed: %w: %s", err, string(output))
-	}
-	return nil
+	return util.Retry(ctx, b, func(int) error {
+		output, err := env.Cmd.RunQuiet(ctx, "mutagen", args...)
+		if err == nil {
+			return nil
+		}
+		err = fmt.Errorf("mutagen sync create failed: %w: %s", err, string(output))
+		if !isCreateRetryable(string(output)) {
+			return util.Permanent(err)
+		}
+		return err
+	})
+}
+
+// isCreateRetryable returns true if the create error indicates the
+// container endpoint could not be reached yet and a retry may succeed.
+func isCreateRetryable(output string) bool {
+	return strings.Contains(output, "unable to connect to")
 }
 
 // Flush waits for a Mutagen sync session to complete its current sync cycle.
 // Retries if the session is not yet connected (e.g. just created).
 // CLI command: mutagen sync flush <name>
 func (m *MutagenSync) Flush(ctx context.Context, env *RuntimeEnv) error {
-	return m.flushWithRetry(ctx, env, flushMaxRetries, flushRetryInterval)
+	return m.flushWithRetry(ctx, env, flushBackoff)
 }
 
-const (
-	flushMaxRetries    = 30
-	flushRetryInterval = time.Second
-)
-
-func (m *MutagenSync) flushWithRetry(ctx context.Context, env *RuntimeEnv, maxRetries int, interval time.Duration) error {
+func (m *MutagenSync) flushWithRetry(ctx context.Context, env *RuntimeEnv, b util.Backoff) error {
 	args := []string{"sync", "flush", m.Name}
-	for attempt := range maxRetries {
+	return util.Retry(ctx, b, func(int) error {
 		output, err := env.Cmd.RunQuiet(ctx, "mutagen", args...)
 		if err == nil {
 			return nil
 		}
-		if attempt == maxRetries-1 || !isFlushRetryable(string(output)) {
-			return fmt.Errorf("mutagen sync flush failed: %w: %s", err, string(output))
+		err = fmt.Errorf("mutagen sync flush failed: %w: %s", err, string(output))
+		if !isFlushRetryable(string(output)) {
+			return util.Permanent(err)
 		}
-		time.Sleep(interval)
-	}
-	return nil // unreachable
+		return err
+	})
 }
 
 // isFlushRetryable returns true if the flush error indicates the session
@@ -89,6 +110,26 @@ func (m *MutagenSync) buildCreateArgs() []string {
 		args = append(args, "--ignore="+pattern)
 	}
 
+	// Add session limits, symlink and permissions handling
+	if m.Options.MaxEntryCount > 0 {
+		args = append(args, fmt.Sprintf("--max-entry-count=%d", m.Options.MaxEntryCount))
+	}
+	if m.Options.MaxStagingFileSize != "" {
+		args = append(args, "--max-staging-file-size="+m.Options.MaxStagingFileSize)
+	}
+	if m.Options.SymlinkMode != "" {
+		args = append(args, "--symlink-mode="+m.Options.SymlinkMode)
+	}
+	if m.Options.PermissionsMode != "" {
+		args = append(args, "--permissions-mode="+m.Options.PermissionsMode)
+	}
+	if m.Options.DefaultFileMode != "" {
+		args = append(args, "--default-file-mode="+m.Options.DefaultFileMode)
+	}
+	if m.Options.DefaultDirectoryMode != "" {
+		args = append(args, "--default-directory-mode="+m.Options.DefaultDirectoryMode)
+	}
+
 	// Add source and target
 	args = append(args, m.Source, m.Target)
 
@@ -170,3 +211,105 @@ func TerminateProjectSyncs(ctx context.Context, env *RuntimeEnv, projectID strin
 
 	return lastErr
 }
+
+// Pause pauses a Mutagen sync session, leaving it in place so it can be
+// resumed later.
+// CLI command: mutagen sync pause <name>
+func (m *MutagenSync) Pause(ctx context.Context, env *RuntimeEnv) error {
+	output, err := env.Cmd.RunQuiet(ctx, "mutagen", "sync", "pause", m.Name)
+	if err != nil {
+		return fmt.Errorf("mutagen sync pause failed: %w: %s", err, string(output))
+	}
+	return nil
+}
+
+// Resume resumes a paused Mutagen sync session.
+// CLI command: mutagen sync resume <name>
+func (m *MutagenSync) Resume(ctx context.Context, env *RuntimeEnv) error {
+	output, err := env.Cmd.RunQuiet(ctx, "mutagen", "sync", "resume", m.Name)
+	if err != nil {
+		return fmt.Errorf("mutagen sync resume failed: %w: %s", err, string(output))
+	}
+	return nil
+}
+
+// PauseProjectSyncs pauses all Mutagen sync sessions for a project and
+// returns the names of the paused sessions.
+// Used by 'alca sync pause' and before stopping the container in 'alca down'.
+func PauseProjectSyncs(ctx context.Context, env *RuntimeEnv, projectID string) ([]string, error) {
+	return forEachProjectSync(ctx, env, projectID, (*MutagenSync).Pause)
+}
+
+// ResumeProjectSyncs resumes all Mutagen sync sessions for a project and
+// returns the names of the resumed sessions.
+func ResumeProjectSyncs(ctx context.Context, env *RuntimeEnv, projectID string) ([]string, error) {
+	return forEachProjectSync(ctx, env, projectID, (*MutagenSync).Resume)
+}
+
+// forEachProjectSync applies action to every session of a project,
+// continuing past failures and returning the last error.
+func forEachProjectSync(ctx context.Context, env *RuntimeEnv, projectID string, action func(*MutagenSync, context.Context, *RuntimeEnv) error) ([]string, error) {
+	sessions, err := ListMutagenSyncs(ctx, env, util.MutagenSessionPrefix(projectID))
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, name := range sessions {
+		sync := MutagenSync{Name: name}
+		if err := action(&sync, ctx, env); err != nil {
+			lastErr = err
+		}
+	}
+
+	return sessions, lastErr
+}
+
+// PausedProjectSyncs returns the names of a project's paused sessions.
+// CLI command: mutagen sync list --template='{{range .}}{{.Name}} {{.Paused}}{{"\n"}}{{end}}'
+func PausedProjectSyncs(ctx context.Context, env *RuntimeEnv, projectID string) ([]string, error) {
+	output, err := env.Cmd.RunQuiet(ctx, "mutagen", buildListPausedArgs()...)
+	if err != nil {
+		return nil, fmt.Errorf("mutagen sync list failed: %w: %s", err, string(output))
+	}
+	return parsePausedListOutput(string(output), util.MutagenSessionPrefix(projectID)), nil
+}
+
+// buildListPausedArgs constructs the arguments for listing sessions with
+// their paused flag.
+func buildListPausedArgs() []string {
+	return []string{"sync", "list", `--template={{range .}}{{.Name}} {{.Paused}}{{"\n"}}{{end}}`}
+}
+
+// parsePausedListOutput returns the paused session names matching a prefix
+// from "<name> <paused>" lines.
+func parsePausedListOutput(output string, namePrefix string) []string {
+	var result []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		name, paused, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if ok && paused == "true" && strings.HasPrefix(name, namePrefix) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// ProjectSyncIdentifiers returns the identifiers of a project's sessions,
+// which name their caches and staging directories in Mutagen's data
+// directory. Returns nothing if Mutagen is not installed.
+// CLI command: mutagen sync list --template='{{range .}}{{.Name}} {{.Identifier}}{{"\n"}}{{end}}'
+func ProjectSyncIdentifiers(ctx context.Context, env *RuntimeEnv, projectID string) []string {
+	output, err := env.Cmd.RunQuiet(ctx, "mutagen", "sync", "list", `--template={{range .}}{{.Name}} {{.Identifier}}{{"\n"}}{{end}}`)
+	if err != nil {
+		return nil
+	}
+	prefix := util.MutagenSessionPrefix(projectID)
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		name, id, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if ok && id != "" && strings.HasPrefix(name, prefix) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
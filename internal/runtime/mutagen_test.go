@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
 
@@ -65,6 +66,33 @@ func TestShouldUseMutagen(t *testing.T) {
 			hasExcludes: false,
 			expected:    false,
 		},
+		// macOS + Colima cases (virtiofs, only use Mutagen with excludes)
+		{
+			name:        "Colima with excludes",
+			platform:    PlatformMacColima,
+			hasExcludes: true,
+			expected:    true,
+		},
+		{
+			name:        "Colima without excludes",
+			platform:    PlatformMacColima,
+			hasExcludes: false,
+			expected:    false,
+		},
+		// macOS + Podman machine cases (always use Mutagen, like Docker Desktop)
+		{
+			name:        "Podman machine without excludes",
+			platform:    PlatformMacPodman,
+			hasExcludes: false,
+			expected:    true,
+		},
+		// Remote daemon (host paths are not visible, always use Mutagen)
+		{
+			name:        "Remote without excludes",
+			platform:    PlatformRemote,
+			hasExcludes: false,
+			expected:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +145,44 @@ func TestMutagenSyncBuildCreateArgs(t *testing.T) {
 				"docker://container-id/workspace",
 			},
 		},
+		{
+			name: "sync with session options",
+			sync: MutagenSync{
+				Name:    "alca-project-workspace",
+				Source:  "/Users/me/project",
+				Target:  "docker://container-id/workspace",
+				Ignores: []string{"target/"},
+				Options: config.MountSync{MaxEntryCount: 50000, MaxStagingFileSize: "100MB", SymlinkMode: "ignore"},
+			},
+			want: []string{
+				"sync", "create",
+				"--name=alca-project-workspace",
+				"--ignore=target/",
+				"--max-entry-count=50000",
+				"--max-staging-file-size=100MB",
+				"--symlink-mode=ignore",
+				"/Users/me/project",
+				"docker://container-id/workspace",
+			},
+		},
+		{
+			name: "sync with permissions handling",
+			sync: MutagenSync{
+				Name:    "alca-project-workspace",
+				Source:  "/mnt/c/src/project",
+				Target:  "docker://container-id/workspace",
+				Options: config.MountSync{PermissionsMode: "manual", DefaultFileMode: "0644", DefaultDirectoryMode: "0755"},
+			},
+			want: []string{
+				"sync", "create",
+				"--name=alca-project-workspace",
+				"--permissions-mode=manual",
+				"--default-file-mode=0644",
+				"--default-directory-mode=0755",
+				"/mnt/c/src/project",
+				"docker://container-id/workspace",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -136,7 +202,7 @@ func TestMutagenSyncFlush_Success(t *testing.T) {
 	env := newMockEnv(mock)
 
 	sync := MutagenSync{Name: "alca-project-workspace"}
-	err := sync.flushWithRetry(context.Background(), env, 1, 0)
+	err := sync.flushWithRetry(context.Background(), env, util.Backoff{MaxAttempts: 1})
 	if err != nil {
 		t.Fatalf("Flush() unexpected error: %v", err)
 	}
@@ -151,7 +217,7 @@ func TestMutagenSyncFlush_NonRetryableError(t *testing.T) {
 	env := newMockEnv(mock)
 
 	sync := MutagenSync{Name: "test-session"}
-	err := sync.flushWithRetry(context.Background(), env, 3, 0)
+	err := sync.flushWithRetry(context.Background(), env, util.Backoff{MaxAttempts: 3})
 	if err == nil {
 		t.Fatal("Flush() should return error when mutagen fails")
 	}
@@ -177,7 +243,7 @@ func TestMutagenSyncFlush_RetriesOnNotReady(t *testing.T) {
 	env := newMockEnv(mock)
 
 	sync := MutagenSync{Name: "test-session"}
-	err := sync.flushWithRetry(context.Background(), env, 5, 0)
+	err := sync.flushWithRetry(context.Background(), env, util.Backoff{MaxAttempts: 5})
 	if err != nil {
 		t.Fatalf("Flush() should succeed after retries, got: %v", err)
 	}
@@ -198,7 +264,7 @@ func TestMutagenSyncFlush_ExhaustsRetries(t *testing.T) {
 	env := newMockEnv(mock)
 
 	sync := MutagenSync{Name: "test-session"}
-	err := sync.flushWithRetry(context.Background(), env, 3, 0)
+	err := sync.flushWithRetry(context.Background(), env, util.Backoff{MaxAttempts: 3})
 	if err == nil {
 		t.Fatal("Flush() should fail after exhausting retries")
 	}
@@ -386,6 +452,23 @@ func TestMutagenSyncCreate_Failure(t *testing.T) {
 	}
 }
 
+// TestMutagenSyncCreate_RetriesWhileUnreachable tests that Create retries until the container endpoint is reachable.
+func TestMutagenSyncCreate_RetriesWhileUnreachable(t *testing.T) {
+	key := "mutagen sync create --name=test-session /src docker://cid/workspace"
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSequence(key, []byte("Error: unable to create session: unable to connect to beta: container not running"), errors.New("exit status 1"))
+	mock.ExpectSequence(key, []byte(""), nil)
+	env := newMockEnv(mock)
+
+	sync := MutagenSync{Name: "test-session", Source: "/src", Target: "docker://cid/workspace"}
+	if err := sync.createWithRetry(context.Background(), env, util.Backoff{MaxAttempts: 3}); err != nil {
+		t.Fatalf("createWithRetry() should succeed after a retry, got: %v", err)
+	}
+	if mock.CallCount(key) != 2 {
+		t.Errorf("expected 2 calls, got %d", mock.CallCount(key))
+	}
+}
+
 // TestMutagenSyncTerminate_Success tests Terminate via mock command runner.
 func TestMutagenSyncTerminate_Success(t *testing.T) {
 	mock := util.NewMockCommandRunner()
@@ -0,0 +1,122 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// DindImage is the Docker daemon image for nested_containers = "dind".
+	DindImage = "docker:dind"
+	// DindHost is DOCKER_HOST inside the sandbox in dind mode. The sidecar
+	// shares the sandbox's network namespace, so its daemon is on loopback.
+	DindHost = "tcp://127.0.0.1:2375"
+	// LabelDindProject marks dind sidecars with their project ID. It is not
+	// alca.project.id, so sidecars are not listed as project containers.
+	LabelDindProject = "alca.dind.project"
+	// NestedSocketPath is where socket mode mounts the host runtime's socket.
+	NestedSocketPath = "/var/run/docker.sock"
+	// defaultDockerSocket is the Docker daemon socket on the runtime host.
+	defaultDockerSocket = "/var/run/docker.sock"
+)
+
+// DindSidecarName returns the name of the dind sidecar of a container.
+func DindSidecarName(containerName string) string {
+	return containerName + "-dind"
+}
+
+// DindVolumeName returns the volume holding the dind daemon's images and
+// containers. It outlives the sidecar, so rebuilds keep the nested cache.
+func DindVolumeName(projectID string) string {
+	return "alca-dind-" + projectID
+}
+
+// nestedContainerArgs returns the run flags that give the sandbox a
+// container daemon for cfg.NestedContainers.
+func (r *dockerCLICompatibleRuntime) nestedContainerArgs(ctx context.Context, env *RuntimeEnv, cfg *config.Config) []string {
+	switch cfg.NestedContainers {
+	case config.NestedContainersDind:
+		return []string{"-e", "DOCKER_HOST=" + DindHost}
+	case config.NestedContainersSocket:
+		return []string{
+			"-v", r.hostSocketPath(ctx, env) + ":" + NestedSocketPath,
+			"-e", "DOCKER_HOST=unix://" + NestedSocketPath,
+		}
+	}
+	return nil
+}
+
+// hostSocketPath returns the runtime's API socket on the runtime host (inside
+// the VM on macOS, which is where bind sources are resolved).
+func (r *dockerCLICompatibleRuntime) hostSocketPath(ctx context.Context, env *RuntimeEnv) string {
+	if r.socketFormat == "" {
+		return defaultDockerSocket
+	}
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "info", "--format", r.socketFormat)
+	if path := strings.TrimSpace(string(output)); err == nil && path != "" {
+		return path
+	}
+	return defaultDockerSocket
+}
+
+// startDindSidecar (re)creates the dind sidecar of the sandbox container. The
+// sidecar joins the sandbox's network namespace: the daemon, its pulls and
+// the containers it starts are all behind the sandbox's firewall. It is
+// recreated whenever the sandbox starts, since a network namespace shared
+// with a stopped container is gone.
+func (r *dockerCLICompatibleRuntime) startDindSidecar(ctx context.Context, env *RuntimeEnv, st *state.State, containerName string, progressOut io.Writer) error {
+	sidecar := DindSidecarName(containerName)
+	if err := r.removeDindSidecar(ctx, env, containerName); err != nil {
+		return err
+	}
+
+	util.ProgressStep(progressOut, "Starting nested Docker daemon: %s\n", sidecar)
+	args := []string{
+		"run", "-d",
+		"--name", sidecar,
+		"--privileged",
+		"--network", "container:" + containerName,
+		"--label", LabelDindProject + "=" + st.ProjectID,
+		"-e", "DOCKER_TLS_CERTDIR=",
+		"-v", DindVolumeName(st.ProjectID) + ":/var/lib/docker",
+		DindImage,
+		"dockerd", "--host=" + DindHost,
+	}
+	if output, err := env.Cmd.RunQuiet(ctx, r.command, args...); err != nil {
+		return fmt.Errorf("failed to start dind sidecar: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// removeDindSidecar removes the dind sidecar if it exists. The storage
+// volume is kept.
+func (r *dockerCLICompatibleRuntime) removeDindSidecar(ctx context.Context, env *RuntimeEnv, containerName string) error {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "rm", "-f", DindSidecarName(containerName))
+	if err != nil && !containsNoSuchContainer(string(output)) {
+		return fmt.Errorf("failed to remove dind sidecar: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// hasDindSidecar reports whether the container was created with a dind
+// sidecar, going by the config it was created with.
+func hasDindSidecar(st *state.State) bool {
+	return st != nil && st.Config != nil && st.Config.NestedContainers == config.NestedContainersDind
+}
+
+// setupNestedContainers prepares cfg.NestedContainers for a started sandbox.
+func (r *dockerCLICompatibleRuntime) setupNestedContainers(ctx context.Context, env *RuntimeEnv, cfg *config.Config, st *state.State, containerName string, progressOut io.Writer) error {
+	switch cfg.NestedContainers {
+	case config.NestedContainersDind:
+		return r.startDindSidecar(ctx, env, st, containerName, progressOut)
+	case config.NestedContainersSocket:
+		util.ProgressStep(progressOut, "Warning: nested_containers = \"socket\" gives the sandbox full control of the host's %s daemon; containers it starts run outside the sandbox and its firewall\n", r.displayName)
+	}
+	return nil
+}
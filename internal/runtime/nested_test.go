@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestNestedContainerArgs(t *testing.T) {
+	ctx := context.Background()
+
+	dind := NewDocker().nestedContainerArgs(ctx, newMockEnv(util.NewMockCommandRunner()), &config.Config{NestedContainers: config.NestedContainersDind})
+	if got := strings.Join(dind, " "); got != "-e DOCKER_HOST="+DindHost {
+		t.Errorf("dind args = %q", got)
+	}
+
+	socket := NewDocker().nestedContainerArgs(ctx, newMockEnv(util.NewMockCommandRunner()), &config.Config{NestedContainers: config.NestedContainersSocket})
+	if got := strings.Join(socket, " "); got != "-v /var/run/docker.sock:/var/run/docker.sock -e DOCKER_HOST=unix:///var/run/docker.sock" {
+		t.Errorf("docker socket args = %q", got)
+	}
+
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("podman info --format {{.Host.RemoteSocket.Path}}", []byte("/run/user/1000/podman/podman.sock\n"))
+	socket = NewPodman().nestedContainerArgs(ctx, newMockEnv(mock), &config.Config{NestedContainers: config.NestedContainersSocket})
+	if !slices.Contains(socket, "/run/user/1000/podman/podman.sock:/var/run/docker.sock") {
+		t.Errorf("podman socket args = %v, want the podman socket mounted", socket)
+	}
+
+	if args := NewDocker().nestedContainerArgs(ctx, nil, &config.Config{}); args != nil {
+		t.Errorf("default args = %v, want none", args)
+	}
+}
+
+func TestStartDindSidecar(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker rm -f alca-test-dind", nil)
+	mock.ExpectSuccess("docker run -d --name alca-test-dind --privileged --network container:alca-test --label alca.dind.project=test-uuid -e DOCKER_TLS_CERTDIR= -v alca-dind-test-uuid:/var/lib/docker docker:dind dockerd --host=tcp://127.0.0.1:2375", nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	st := &state.State{ProjectID: "test-uuid", ContainerName: "alca-test"}
+	if err := NewDocker().startDindSidecar(context.Background(), newMockEnv(mock), st, "alca-test", nil); err != nil {
+		t.Fatalf("startDindSidecar() error: %v", err)
+	}
+}
+
+func TestDockerDown_RemovesDindSidecarFirst(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess(
+		"docker ps -a --filter label=alca.project.id=test-uuid --format {{.Names}}",
+		[]byte("alca-test"),
+	)
+	mock.ExpectSuccess(
+		"docker inspect --format {{.State.Status}}|{{.Id}}|{{.Name}}|{{.Config.Image}}|{{.State.StartedAt}} alca-test",
+		[]byte("running|abc123|/alca-test|test-image:latest|2024-01-15T10:00:00Z"),
+	)
+	mock.ExpectSuccess(listSyncsKey, nil)
+	mock.ExpectSuccess("docker rm -f alca-test-dind", nil)
+	mock.ExpectSuccess("docker stop alca-test", nil)
+	mock.ExpectSuccess("docker rm -f alca-test", nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	st := &state.State{
+		ProjectID:     "test-uuid",
+		ContainerName: "alca-test",
+		Config:        &config.Config{NestedContainers: config.NestedContainersDind},
+	}
+	if err := NewDocker().Down(context.Background(), newMockEnv(mock), "/project", st); err != nil {
+		t.Fatalf("Down() unexpected error: %v", err)
+	}
+
+	keys := mock.CallKeys()
+	if slices.Index(keys, "docker rm -f alca-test-dind") >= slices.Index(keys, "docker stop alca-test") {
+		t.Errorf("expected the sidecar removed before stopping, got calls %v", keys)
+	}
+}
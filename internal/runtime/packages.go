@@ -0,0 +1,99 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Package is an OS package installed in an image or container.
+type Package struct {
+	// Manager is the package manager that installed it: apk, dpkg or rpm.
+	Manager string
+	Name    string
+	Version string
+	Arch    string
+}
+
+// PackageList is the OS release and packages found in an image or container.
+type PackageList struct {
+	// OSID and OSVersion are ID and VERSION_ID from /etc/os-release.
+	OSID      string
+	OSVersion string
+	// Packages are sorted by manager and name. Empty when the image has no
+	// supported package manager (e.g. nix or distroless images).
+	Packages []Package
+}
+
+// listPackagesScript prints "os\t<id>\t<version>" and then one
+// "<manager>\t<name>\t<version>\t<arch>" line per installed package, for
+// every package database present. apk's database is read directly since
+// its CLI output does not separate names from versions.
+const listPackagesScript = `if [ -r /etc/os-release ]; then (. /etc/os-release; printf 'os\t%s\t%s\n' "$ID" "$VERSION_ID"); fi
+if [ -r /lib/apk/db/installed ]; then
+  awk -F: '/^P:/ { p = substr($0, 3) } /^V:/ { v = substr($0, 3) } /^A:/ { a = substr($0, 3) }
+    /^$/ { if (p != "") print "apk\t" p "\t" v "\t" a; p = "" }
+    END { if (p != "") print "apk\t" p "\t" v "\t" a }' /lib/apk/db/installed
+fi
+if command -v dpkg-query >/dev/null 2>&1; then
+  dpkg-query -W -f '${db:Status-Status}\t${Package}\t${Version}\t${Architecture}\n' 2>/dev/null |
+    awk -F'\t' '$1 == "installed" { print "dpkg\t" $2 "\t" $3 "\t" $4 }'
+fi
+if command -v rpm >/dev/null 2>&1; then
+  rpm -qa --qf 'rpm\t%{NAME}\t%{VERSION}-%{RELEASE}\t%{ARCH}\n' 2>/dev/null
+fi
+exit 0`
+
+// ImagePackages lists the OS packages of an image from a throwaway
+// container without network.
+func (r *dockerCLICompatibleRuntime) ImagePackages(ctx context.Context, env *RuntimeEnv, image, platform string) (PackageList, error) {
+	args := []string{"run", "--rm", "--network", "none", "--entrypoint", "sh"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	args = append(args, image, "-c", listPackagesScript)
+	output, err := env.Cmd.RunQuiet(ctx, r.command, args...)
+	if err != nil {
+		return PackageList{}, fmt.Errorf("failed to list packages of image %s: %w: %s", image, err, strings.TrimSpace(string(output)))
+	}
+	return parsePackageList(string(output)), nil
+}
+
+// ContainerPackages lists the OS packages of a running container.
+func (r *dockerCLICompatibleRuntime) ContainerPackages(ctx context.Context, env *RuntimeEnv, containerName string) (PackageList, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "exec", "-u", "root", containerName, "sh", "-c", listPackagesScript)
+	if err != nil {
+		return PackageList{}, fmt.Errorf("failed to list packages of container %s: %w: %s", containerName, err, strings.TrimSpace(string(output)))
+	}
+	return parsePackageList(string(output)), nil
+}
+
+// parsePackageList parses listPackagesScript output, skipping lines it
+// does not recognize (e.g. warnings printed by the package managers).
+func parsePackageList(output string) PackageList {
+	var list PackageList
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		switch {
+		case len(fields) == 3 && fields[0] == "os":
+			list.OSID, list.OSVersion = fields[1], fields[2]
+		case len(fields) == 4 && fields[1] != "":
+			switch fields[0] {
+			case "apk", "dpkg", "rpm":
+				list.Packages = append(list.Packages, Package{Manager: fields[0], Name: fields[1], Version: fields[2], Arch: fields[3]})
+			}
+		}
+	}
+	sort.Slice(list.Packages, func(i, j int) bool {
+		a, b := list.Packages[i], list.Packages[j]
+		if a.Manager != b.Manager {
+			return a.Manager < b.Manager
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Arch < b.Arch
+	})
+	return list
+}
@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestParsePackageList(t *testing.T) {
+	output := "os\talpine\t3.21.0\n" +
+		"apk\tmusl\t1.2.5-r8\tx86_64\n" +
+		"apk\tbusybox\t1.37.0-r12\tx86_64\n" +
+		"WARNING: something odd\n" +
+		"dpkg\tbash\t5.2.15-2+b7\tamd64\n" +
+		"npm\tleft-pad\t1.3.0\tall\n" +
+		"\n"
+
+	got := parsePackageList(output)
+	want := PackageList{
+		OSID:      "alpine",
+		OSVersion: "3.21.0",
+		Packages: []Package{
+			{Manager: "apk", Name: "busybox", Version: "1.37.0-r12", Arch: "x86_64"},
+			{Manager: "apk", Name: "musl", Version: "1.2.5-r8", Arch: "x86_64"},
+			{Manager: "dpkg", Name: "bash", Version: "5.2.15-2+b7", Arch: "amd64"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePackageList() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestImagePackages(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker run --rm --network none --entrypoint sh --platform linux/arm64 alpine:3 -c "+listPackagesScript,
+		[]byte("os\talpine\t3.21.0\napk\tmusl\t1.2.5-r8\taarch64\n"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	list, err := NewDocker().ImagePackages(context.Background(), NewRuntimeEnv(cmd), "alpine:3", "linux/arm64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.OSID != "alpine" || len(list.Packages) != 1 || list.Packages[0].Name != "musl" {
+		t.Errorf("ImagePackages() = %+v", list)
+	}
+}
+
+func TestContainerPackages(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("podman exec -u root alca-test sh -c "+listPackagesScript,
+		[]byte("rpm\tgit\t2.47.1-1.fc41\tx86_64\n"))
+	defer cmd.AssertAllExpectationsMet(t)
+
+	list, err := NewPodman().ContainerPackages(context.Background(), NewRuntimeEnv(cmd), "alca-test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Packages) != 1 || list.Packages[0] != (Package{Manager: "rpm", Name: "git", Version: "2.47.1-1.fc41", Arch: "x86_64"}) {
+		t.Errorf("ContainerPackages() = %+v", list)
+	}
+}
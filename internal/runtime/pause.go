@@ -0,0 +1,53 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Pause freezes the container with docker/podman pause. Mutagen syncs are
+// paused first, so nothing is synced from a container that cannot answer.
+func (r *dockerCLICompatibleRuntime) Pause(ctx context.Context, env *RuntimeEnv, projectDir string, st *state.State) error {
+	status, err := r.Status(ctx, env, projectDir, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State != StateRunning {
+		return ErrNotRunning
+	}
+
+	if _, err := PauseProjectSyncs(ctx, env, st.ProjectID); err != nil {
+		util.ProgressStep(nil, "Warning: failed to pause Mutagen syncs: %v\n", err)
+	}
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "pause", status.Name)
+	if err != nil {
+		// The container keeps running, so its syncs should too
+		_, _ = ResumeProjectSyncs(ctx, env, st.ProjectID)
+		return fmt.Errorf("%s pause failed: %w: %s", r.command, err, string(output))
+	}
+	return nil
+}
+
+// Unpause thaws the container with docker/podman unpause, then resumes its
+// Mutagen syncs.
+func (r *dockerCLICompatibleRuntime) Unpause(ctx context.Context, env *RuntimeEnv, projectDir string, st *state.State) error {
+	status, err := r.Status(ctx, env, projectDir, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State != StatePaused {
+		return ErrNotPaused
+	}
+
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "unpause", status.Name)
+	if err != nil {
+		return fmt.Errorf("%s unpause failed: %w: %s", r.command, err, string(output))
+	}
+	if _, err := ResumeProjectSyncs(ctx, env, st.ProjectID); err != nil {
+		util.ProgressStep(nil, "Warning: failed to resume Mutagen syncs: %v\n", err)
+	}
+	return nil
+}
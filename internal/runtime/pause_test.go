@@ -0,0 +1,64 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	pauseStatusLabelKey   = "docker ps -a --filter label=alca.project.id=test-uuid --format {{.Names}}"
+	pauseStatusInspectKey = "docker inspect --format {{.State.Status}}|{{.Id}}|{{.Name}}|{{.Config.Image}}|{{.State.StartedAt}} alca-test"
+)
+
+func TestDockerPause_PausesSyncsFirst(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess(pauseStatusLabelKey, []byte("alca-test"))
+	mock.ExpectSuccess(pauseStatusInspectKey, []byte("running|abc123|/alca-test|img|2024-01-15T10:00:00Z"))
+	mock.ExpectSuccess(listSyncsKey, []byte("alca-test-uuid-0\n"))
+	mock.ExpectSuccess("mutagen sync pause alca-test-uuid-0", nil)
+	mock.ExpectSuccess("docker pause alca-test", nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	st := &state.State{ProjectID: "test-uuid", ContainerName: "alca-test"}
+	if err := NewDocker().Pause(context.Background(), newMockEnv(mock), "/project", st); err != nil {
+		t.Fatalf("Pause() unexpected error: %v", err)
+	}
+	keys := mock.CallKeys()
+	if slices.Index(keys, "mutagen sync pause alca-test-uuid-0") > slices.Index(keys, "docker pause alca-test") {
+		t.Errorf("syncs must be paused before the container, calls: %v", keys)
+	}
+}
+
+func TestDockerPause_FailureResumesSyncs(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess(pauseStatusLabelKey, []byte("alca-test"))
+	mock.ExpectSuccess(pauseStatusInspectKey, []byte("running|abc123|/alca-test|img|2024-01-15T10:00:00Z"))
+	mock.ExpectSuccess(listSyncsKey, []byte("alca-test-uuid-0\n"))
+	mock.ExpectSuccess("mutagen sync pause alca-test-uuid-0", nil)
+	mock.Expect("docker pause alca-test", []byte("cgroups: freezer not supported"), errors.New("exit status 1"))
+	mock.ExpectSuccess("mutagen sync resume alca-test-uuid-0", nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	st := &state.State{ProjectID: "test-uuid", ContainerName: "alca-test"}
+	if err := NewDocker().Pause(context.Background(), newMockEnv(mock), "/project", st); err == nil {
+		t.Fatal("Pause() expected an error")
+	}
+}
+
+func TestDockerUnpause_NotPaused(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess(pauseStatusLabelKey, []byte("alca-test"))
+	mock.ExpectSuccess(pauseStatusInspectKey, []byte("running|abc123|/alca-test|img|2024-01-15T10:00:00Z"))
+
+	st := &state.State{ProjectID: "test-uuid", ContainerName: "alca-test"}
+	err := NewDocker().Unpause(context.Background(), newMockEnv(mock), "/project", st)
+	if !errors.Is(err, ErrNotPaused) {
+		t.Fatalf("Unpause() error = %v, want ErrNotPaused", err)
+	}
+	mock.AssertNotCalled(t, "docker unpause alca-test")
+}
@@ -0,0 +1,118 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// PeerNetworkPrefix starts the name of every network shared by two peer
+// projects (network.peers).
+const PeerNetworkPrefix = "alca-peer-"
+
+// LabelPeerNetwork marks networks created for network.peers.
+const LabelPeerNetwork = "alca.peer"
+
+// PeerNetworkName returns the network shared by the projects in dirA and
+// dirB. The name depends only on the pair, so both projects derive the same
+// network whichever of them comes up first.
+func PeerNetworkName(dirA, dirB string) string {
+	pair := []string{dirA, dirB}
+	slices.Sort(pair)
+	sum := sha256.Sum256([]byte(pair[0] + "\n" + pair[1]))
+	return PeerNetworkPrefix + hex.EncodeToString(sum[:])[:12]
+}
+
+// ConnectPeerNetwork attaches the container to the named peer network,
+// creating it first if needed, with alias as its DNS name there. Peer
+// networks are internal: they carry traffic between the peers only, never
+// to the outside. Returns the network's IPv4 subnet. Idempotent.
+func (r *dockerCLICompatibleRuntime) ConnectPeerNetwork(ctx context.Context, env *RuntimeEnv, containerName, network, alias string) (string, error) {
+	subnet, err := r.peerNetworkSubnet(ctx, env, network)
+	if err != nil {
+		output, createErr := env.Cmd.RunQuiet(ctx, r.command, "network", "create", "--internal",
+			"--label", LabelPeerNetwork+"=true", network)
+		if createErr != nil {
+			return "", fmt.Errorf("failed to create network %s: %w: %s", network, createErr, strings.TrimSpace(string(output)))
+		}
+		if subnet, err = r.peerNetworkSubnet(ctx, env, network); err != nil {
+			return "", err
+		}
+	}
+
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "inspect", "--format", "{{json .NetworkSettings.Networks}}", containerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container networks: %w", err)
+	}
+	var attached map[string]json.RawMessage
+	if err := json.Unmarshal(output, &attached); err != nil {
+		return "", fmt.Errorf("failed to parse container networks: %w", err)
+	}
+	if _, ok := attached[network]; ok {
+		return subnet, nil
+	}
+
+	output, err = env.Cmd.RunQuiet(ctx, r.command, "network", "connect", "--alias", alias, network, containerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect %s to network %s: %w: %s", containerName, network, err, strings.TrimSpace(string(output)))
+	}
+	return subnet, nil
+}
+
+// peerNetworkSubnet returns the IPv4 subnet of an existing network.
+func (r *dockerCLICompatibleRuntime) peerNetworkSubnet(ctx context.Context, env *RuntimeEnv, network string) (string, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "network", "inspect", network)
+	if err != nil {
+		return "", fmt.Errorf("network %s not found: %w", network, err)
+	}
+	return parseNetworkSubnet(output)
+}
+
+// parseNetworkSubnet extracts the first IPv4 subnet from `network inspect`
+// output. Docker lists subnets under IPAM.Config, Podman under subnets; JSON
+// field matching is case-insensitive, so one struct reads both.
+func parseNetworkSubnet(output []byte) (string, error) {
+	var networks []struct {
+		IPAM struct {
+			Config []struct{ Subnet string }
+		}
+		Subnets []struct{ Subnet string }
+	}
+	if err := json.Unmarshal(output, &networks); err != nil {
+		return "", fmt.Errorf("failed to parse network inspect output: %w", err)
+	}
+	for _, n := range networks {
+		var subnets []string
+		for _, c := range n.IPAM.Config {
+			subnets = append(subnets, c.Subnet)
+		}
+		for _, s := range n.Subnets {
+			subnets = append(subnets, s.Subnet)
+		}
+		for _, s := range subnets {
+			if ip, _, err := net.ParseCIDR(s); err == nil && ip.To4() != nil {
+				return s, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("network has no IPv4 subnet")
+}
+
+// primaryContainerIP picks the container's address from `name=ip` lines,
+// skipping peer networks: firewall rules are keyed on the address of the
+// network that reaches the outside.
+func primaryContainerIP(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		name, ip, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || ip == "" || strings.HasPrefix(name, PeerNetworkPrefix) {
+			continue
+		}
+		return ip
+	}
+	return ""
+}
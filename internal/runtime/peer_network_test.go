@@ -0,0 +1,90 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestPeerNetworkName_SameForBothSides(t *testing.T) {
+	a := PeerNetworkName("/src/frontend", "/src/backend")
+	b := PeerNetworkName("/src/backend", "/src/frontend")
+	if a != b {
+		t.Errorf("PeerNetworkName differs by order: %q vs %q", a, b)
+	}
+	if !strings.HasPrefix(a, PeerNetworkPrefix) {
+		t.Errorf("PeerNetworkName() = %q, want prefix %q", a, PeerNetworkPrefix)
+	}
+	if a == PeerNetworkName("/src/frontend", "/src/other") {
+		t.Error("different pairs should get different networks")
+	}
+}
+
+func TestParseNetworkSubnet(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "docker", output: `[{"Name":"n","IPAM":{"Config":[{"Subnet":"fd00::/64"},{"Subnet":"172.20.0.0/16","Gateway":"172.20.0.1"}]}}]`, want: "172.20.0.0/16"},
+		{name: "podman", output: `[{"name":"n","subnets":[{"subnet":"10.89.1.0/24","gateway":"10.89.1.1"}]}]`, want: "10.89.1.0/24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNetworkSubnet([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("parseNetworkSubnet() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseNetworkSubnet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseNetworkSubnet([]byte(`[{"IPAM":{"Config":[]}}]`)); err == nil {
+		t.Error("expected an error for a network without an IPv4 subnet")
+	}
+}
+
+func TestPrimaryContainerIP_SkipsPeerNetworks(t *testing.T) {
+	output := PeerNetworkPrefix + "0123456789ab=172.20.0.2\nbridge=172.17.0.2\n"
+	if got := primaryContainerIP(output); got != "172.17.0.2" {
+		t.Errorf("primaryContainerIP() = %q, want the bridge address", got)
+	}
+}
+
+func TestConnectPeerNetwork_CreatesAndConnects(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	inspect := "docker network inspect alca-peer-x"
+	mock.ExpectSequence(inspect, []byte("Error: no such network"), errors.New("exit status 1"))
+	mock.ExpectSuccess("docker network create --internal --label alca.peer=true alca-peer-x", nil)
+	mock.ExpectSequence(inspect, []byte(`[{"IPAM":{"Config":[{"Subnet":"172.20.0.0/16"}]}}]`), nil)
+	mock.ExpectSuccess("docker inspect --format {{json .NetworkSettings.Networks}} alca-front", []byte(`{"bridge":{}}`))
+	mock.ExpectSuccess("docker network connect --alias frontend alca-peer-x alca-front", nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	subnet, err := NewDocker().ConnectPeerNetwork(context.Background(), newMockEnv(mock), "alca-front", "alca-peer-x", "frontend")
+	if err != nil {
+		t.Fatalf("ConnectPeerNetwork() error: %v", err)
+	}
+	if subnet != "172.20.0.0/16" {
+		t.Errorf("subnet = %q, want 172.20.0.0/16", subnet)
+	}
+}
+
+func TestConnectPeerNetwork_AlreadyConnected(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker network inspect alca-peer-x", []byte(`[{"IPAM":{"Config":[{"Subnet":"172.20.0.0/16"}]}}]`))
+	mock.ExpectSuccess("docker inspect --format {{json .NetworkSettings.Networks}} alca-front", []byte(`{"alca-peer-x":{},"bridge":{}}`))
+	defer mock.AssertAllExpectationsMet(t)
+
+	if _, err := NewDocker().ConnectPeerNetwork(context.Background(), newMockEnv(mock), "alca-front", "alca-peer-x", "frontend"); err != nil {
+		t.Fatalf("ConnectPeerNetwork() error: %v", err)
+	}
+	if mock.CallCount("docker network connect --alias frontend alca-peer-x alca-front") != 0 {
+		t.Error("an attached container should not be connected again")
+	}
+}
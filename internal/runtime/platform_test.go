@@ -13,6 +13,7 @@ func TestRuntimePlatformConstants(t *testing.T) {
 		{PlatformLinux, "linux"},
 		{PlatformMacDockerDesktop, "docker-desktop"},
 		{PlatformMacOrbStack, "orbstack"},
+		{PlatformMacColima, "colima"},
 	}
 
 	for _, tt := range tests {
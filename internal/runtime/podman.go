@@ -10,8 +10,12 @@ type Podman struct {
 func NewPodman() *Podman {
 	return &Podman{
 		dockerCLICompatibleRuntime: &dockerCLICompatibleRuntime{
-			displayName: "Podman",
-			command:     "podman",
+			displayName:   "Podman",
+			command:       "podman",
+			versionFormat: "{{.Version}}",
+			machine:       true,
+			socketFormat:  "{{.Host.RemoteSocket.Path}}",
+			saveFormat:    "oci-archive",
 		},
 	}
 }
@@ -0,0 +1,180 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pullProgressStep is how far (in percent) a layer's download must advance
+// before its progress is reported again, so a slow pull does not flood the
+// progress output with one line per chunk.
+const pullProgressStep = 10
+
+var (
+	// dockerLayerLine matches Docker's per-layer pull status, e.g.
+	// "3f4ca61aafcd: Downloading [==>    ]  1.2MB/30.4MB".
+	dockerLayerLine = regexp.MustCompile(`^([0-9a-f]{12,64}): (.+)$`)
+	// podmanBlobLine matches Podman's per-blob copy status, e.g.
+	// "Copying blob 3f4ca61aafcd [==>----] 1.2MiB / 30.4MiB".
+	podmanBlobLine = regexp.MustCompile(`^Copying blob (?:sha256:)?([0-9a-f]{12,64})(.*)$`)
+	// pullSizes matches the "current/total" sizes of a progress line.
+	pullSizes = regexp.MustCompile(`([0-9.]+\s*[kKMGT]?i?B)\s*/\s*([0-9.]+\s*[kKMGT]?i?B)`)
+)
+
+// layerProgress tracks the download of one image layer.
+type layerProgress struct {
+	percent  int // last computed download percentage
+	reported int // percentage last written to the progress output, -1 if none
+	done     bool
+}
+
+// pullProgress turns the per-layer status lines of a pull into a short
+// progress report: each layer's download percentage in pullProgressStep
+// increments, and a count of completed layers. Lines that are not about a
+// layer (the tag being pulled, the digest, errors) are forwarded indented.
+// One pullProgress follows one pull; it is not safe for concurrent use.
+type pullProgress struct {
+	layers map[string]*layerProgress
+	order  []string
+}
+
+func newPullProgress() *pullProgress {
+	return &pullProgress{layers: make(map[string]*layerProgress)}
+}
+
+// line is a util.LineFunc for the output of '<runtime> pull'.
+func (p *pullProgress) line(line string) (string, bool) {
+	id, status, ok := parseLayerLine(line)
+	if !ok {
+		return indentOutput(line)
+	}
+	return p.update(id, status)
+}
+
+// runLine is a util.LineFunc for the output of '<runtime> run -d', which
+// pulls a missing image and then prints the container ID.
+func (p *pullProgress) runLine(line string) (string, bool) {
+	if containerIDLine.MatchString(line) {
+		return "", false
+	}
+	return p.line(line)
+}
+
+// parseLayerLine splits a Docker or Podman layer status line into the short
+// layer ID and its status.
+func parseLayerLine(line string) (id, status string, ok bool) {
+	line = strings.TrimSpace(line)
+	if m := podmanBlobLine.FindStringSubmatch(line); m != nil {
+		return shortLayerID(m[1]), strings.TrimSpace(m[2]), true
+	}
+	if m := dockerLayerLine.FindStringSubmatch(line); m != nil {
+		return shortLayerID(m[1]), m[2], true
+	}
+	return "", "", false
+}
+
+// shortLayerID returns the 12-character form Docker prints layer IDs in.
+func shortLayerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// update records a layer's status and returns the progress line to print,
+// if any.
+func (p *pullProgress) update(id, status string) (string, bool) {
+	l, ok := p.layers[id]
+	if !ok {
+		l = &layerProgress{reported: -1}
+		p.layers[id] = l
+		p.order = append(p.order, id)
+	}
+	if l.done {
+		return "", false
+	}
+
+	lower := strings.ToLower(status)
+	switch {
+	case strings.HasPrefix(lower, "pull complete"), strings.HasPrefix(lower, "already exists"),
+		strings.HasPrefix(lower, "done"), strings.HasPrefix(lower, "skipped"):
+		l.done, l.percent = true, 100
+		return fmt.Sprintf("  %s: complete (%d/%d layers)", id, p.completed(), len(p.layers)), true
+	case strings.HasPrefix(lower, "download complete"):
+		l.percent = 100
+	case strings.HasPrefix(lower, "downloading"), strings.HasPrefix(status, "["):
+		if current, total, ok := parseSizes(status); ok && total > 0 {
+			l.percent = int(current * 100 / total)
+		}
+	default:
+		// Pulling fs layer, Waiting, Verifying Checksum, Extracting
+		return "", false
+	}
+
+	step := l.percent / pullProgressStep * pullProgressStep
+	if step <= l.reported {
+		return "", false
+	}
+	l.reported = step
+	return fmt.Sprintf("  %s: downloading %d%%", id, step), true
+}
+
+// completed returns the number of layers that finished.
+func (p *pullProgress) completed() int {
+	n := 0
+	for _, id := range p.order {
+		if p.layers[id].done {
+			n++
+		}
+	}
+	return n
+}
+
+// parseSizes returns the current and total byte counts of a progress line.
+func parseSizes(status string) (current, total float64, ok bool) {
+	m := pullSizes.FindStringSubmatch(status)
+	if m == nil {
+		return 0, 0, false
+	}
+	current, err1 := parseByteSize(m[1])
+	total, err2 := parseByteSize(m[2])
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return current, total, true
+}
+
+// byteUnits maps the size suffixes Docker (decimal) and Podman (binary)
+// print to their multipliers.
+var byteUnits = map[string]float64{
+	"B":   1,
+	"kB":  1e3,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// parseByteSize parses a size such as "1.2MB" or "30.4 MiB".
+func parseByteSize(s string) (float64, error) {
+	s = strings.ReplaceAll(s, " ", "")
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	mult, ok := byteUnits[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit %q", s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * mult, nil
+}
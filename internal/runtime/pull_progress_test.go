@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+)
+
+func feedPullProgress(lineFunc func(string) (string, bool), lines ...string) []string {
+	var out []string
+	for _, l := range lines {
+		if line, keep := lineFunc(l); keep {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func TestPullProgress_Docker(t *testing.T) {
+	got := feedPullProgress(newPullProgress().line,
+		"latest: Pulling from library/alpine",
+		"3f4ca61aafcd: Pulling fs layer",
+		"9b2a5a8d2b1c: Already exists",
+		"3f4ca61aafcd: Downloading [>      ]  1.1MB/30MB",
+		"3f4ca61aafcd: Downloading [=>     ]  2.2MB/30MB",
+		"3f4ca61aafcd: Downloading [===>   ]  15.2MB/30MB",
+		"3f4ca61aafcd: Downloading [===>   ]  15.9MB/30MB",
+		"3f4ca61aafcd: Verifying Checksum",
+		"3f4ca61aafcd: Download complete",
+		"3f4ca61aafcd: Extracting [=====>] 30MB/30MB",
+		"3f4ca61aafcd: Pull complete",
+		"Digest: sha256:abc",
+	)
+	want := []string{
+		"  latest: Pulling from library/alpine",
+		"  9b2a5a8d2b1c: complete (1/2 layers)",
+		"  3f4ca61aafcd: downloading 0%",
+		"  3f4ca61aafcd: downloading 50%",
+		"  3f4ca61aafcd: downloading 100%",
+		"  3f4ca61aafcd: complete (2/2 layers)",
+		"  Digest: sha256:abc",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("progress =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPullProgress_Podman(t *testing.T) {
+	got := feedPullProgress(newPullProgress().line,
+		"Trying to pull docker.io/library/alpine:latest...",
+		"Copying blob sha256:3f4ca61aafcd5e0f2e0e4d4b2ab9a1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8",
+		"Copying blob 3f4ca61aafcd [==>-----] 1.5MiB / 3.0MiB",
+		"Copying blob 3f4ca61aafcd done",
+		"Copying config 1d34ffeaf190 done",
+	)
+	want := []string{
+		"  Trying to pull docker.io/library/alpine:latest...",
+		"  3f4ca61aafcd: downloading 50%",
+		"  3f4ca61aafcd: complete (1/1 layers)",
+		"  Copying config 1d34ffeaf190 done",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("progress =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPullProgress_RunDropsContainerID(t *testing.T) {
+	p := newPullProgress()
+	if _, keep := p.runLine(strings.Repeat("ab", 32)); keep {
+		t.Error("container ID line should be dropped")
+	}
+	if line, keep := p.runLine("Unable to find image 'alpine:latest' locally"); !keep || line != "  Unable to find image 'alpine:latest' locally" {
+		t.Errorf("runLine() = %q, %v", line, keep)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"512B", 512},
+		{"1.5kB", 1500},
+		{"30.4MB", 30.4e6},
+		{"2 MiB", 2 << 20},
+		{"1GiB", 1 << 30},
+	}
+	for _, tt := range tests {
+		if got, err := parseByteSize(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseByteSize(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseByteSize("12 parsecs"); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
@@ -0,0 +1,97 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// UpdateResources changes the memory and CPU limits, the CPU weight and the
+// cpuset pinning of a container in place with docker/podman update (Podman
+// 4.3+). The cgroup settings are left out when the daemon cannot apply them.
+func (r *dockerCLICompatibleRuntime) UpdateResources(ctx context.Context, env *RuntimeEnv, containerName string, res config.Resources) error {
+	if !res.Cgroup.IsZero() {
+		if caps, err := r.Capabilities(ctx, env); err != nil || !caps.CgroupTuning() {
+			res.Cgroup = config.Cgroup{}
+		}
+	}
+	args := updateResourcesArgs(containerName, res)
+	if args == nil {
+		return nil
+	}
+	output, err := env.Cmd.RunQuiet(ctx, r.command, args...)
+	if err != nil {
+		return fmt.Errorf("%s update failed: %w: %s", r.command, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// updateResourcesArgs returns the update arguments for res, or nil when it
+// sets no limit. The cgroup parent and io.latency cannot be updated.
+func updateResourcesArgs(containerName string, res config.Resources) []string {
+	cg := res.Cgroup
+	if res.Memory == "" && res.CPUs <= 0 && cg.CPUWeight <= 0 && cg.CpusetCPUs == "" && cg.CpusetMems == "" {
+		return nil
+	}
+	args := []string{"update"}
+	if res.Memory != "" {
+		args = append(args, "--memory", res.Memory)
+		// run -m leaves swap at twice the memory. Set it with the memory,
+		// as the daemon refuses a memory limit above the current swap limit.
+		if bytes, err := util.ParseSize(res.Memory); err == nil {
+			args = append(args, "--memory-swap", strconv.FormatInt(2*bytes, 10))
+		}
+	}
+	if res.CPUs > 0 {
+		args = append(args, "--cpus", strconv.Itoa(res.CPUs))
+	}
+	args = append(args, cpuCgroupArgs(cg)...)
+	return append(args, containerName)
+}
+
+// cgroupRunArgs returns the run arguments for resources.cgroup. io.latency
+// has no dedicated flag and is only set where --cgroup-conf exists.
+func cgroupRunArgs(cg config.Cgroup, caps Capabilities) []string {
+	var args []string
+	if cg.Parent != "" {
+		args = append(args, "--cgroup-parent", cg.Parent)
+	}
+	args = append(args, cpuCgroupArgs(cg)...)
+	if v := cg.IOLatencyValue(); v != "" && caps.CgroupConf {
+		args = append(args, "--cgroup-conf", "io.latency="+v)
+	}
+	return args
+}
+
+// cpuCgroupArgs returns the CPU weight and cpuset flags, shared by run and
+// update. The runtime converts --cpu-shares to cpu.weight on cgroup v2.
+func cpuCgroupArgs(cg config.Cgroup) []string {
+	var args []string
+	if shares := cg.CPUShares(); shares > 0 {
+		args = append(args, "--cpu-shares", strconv.FormatInt(shares, 10))
+	}
+	if cg.CpusetCPUs != "" {
+		args = append(args, "--cpuset-cpus", cg.CpusetCPUs)
+	}
+	if cg.CpusetMems != "" {
+		args = append(args, "--cpuset-mems", cg.CpusetMems)
+	}
+	return args
+}
+
+// warnCgroup reports resources.cgroup settings the daemon cannot apply.
+func (r *dockerCLICompatibleRuntime) warnCgroup(cfg *config.Config, caps Capabilities, out io.Writer) {
+	cg := cfg.Resources.Cgroup
+	switch {
+	case cg.IsZero():
+	case !caps.CgroupTuning():
+		util.ProgressStep(out, "Warning: resources.cgroup ignored: %s needs cgroup v2 (with controller delegation when rootless) to apply it\n", r.displayName)
+	case cg.IOLatency != (config.IOLatency{}) && !caps.CgroupConf:
+		util.ProgressStep(out, "Warning: resources.cgroup.io_latency ignored: %s cannot set io.latency; Podman can\n", r.displayName)
+	}
+}
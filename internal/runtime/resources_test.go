@@ -0,0 +1,88 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestUpdateResourcesArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		res  config.Resources
+		want []string
+	}{
+		{"memory and cpus", config.Resources{Memory: "4g", CPUs: 2}, []string{"update", "--memory", "4g", "--memory-swap", "8589934592", "--cpus", "2", "alca-p"}},
+		{"cpus only", config.Resources{CPUs: 1}, []string{"update", "--cpus", "1", "alca-p"}},
+		{"nothing set", config.Resources{}, nil},
+		{"cpu weight and cpuset", config.Resources{Cgroup: config.Cgroup{CPUWeight: 100, CpusetCPUs: "0-3", CpusetMems: "0"}}, []string{"update", "--cpu-shares", "2598", "--cpuset-cpus", "0-3", "--cpuset-mems", "0", "alca-p"}},
+		{"parent and io latency only", config.Resources{Cgroup: config.Cgroup{Parent: "alca.slice", IOLatency: config.IOLatency{Device: "259:0", Target: "10ms"}}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateResourcesArgs("alca-p", tt.res); !slices.Equal(got, tt.want) {
+				t.Errorf("updateResourcesArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateResources_ReportsFailure(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.Expect("podman update --cpus 2 alca-p", []byte("Error: cgroup v1 is not supported"), errors.New("exit status 125"))
+	err := NewPodman().UpdateResources(context.Background(), &RuntimeEnv{Cmd: cmd}, "alca-p", config.Resources{CPUs: 2})
+	if err == nil || !strings.Contains(err.Error(), "cgroup v1") {
+		t.Fatalf("UpdateResources() error = %v, want the runtime's message", err)
+	}
+	cmd.AssertAllExpectationsMet(t)
+}
+
+func TestCgroupRunArgs(t *testing.T) {
+	cg := config.Cgroup{Parent: "alca.slice", CPUWeight: 200, IOLatency: config.IOLatency{Device: "259:0", Target: "10ms"}}
+
+	got := cgroupRunArgs(cg, Capabilities{CgroupV2: true, CgroupConf: true})
+	want := []string{"--cgroup-parent", "alca.slice", "--cpu-shares", "5220", "--cgroup-conf", "io.latency=259:0 target=10000"}
+	if !slices.Equal(got, want) {
+		t.Errorf("cgroupRunArgs(podman) = %v, want %v", got, want)
+	}
+
+	got = cgroupRunArgs(cg, Capabilities{CgroupV2: true})
+	want = []string{"--cgroup-parent", "alca.slice", "--cpu-shares", "5220"}
+	if !slices.Equal(got, want) {
+		t.Errorf("cgroupRunArgs(docker) = %v, want %v", got, want)
+	}
+}
+
+func TestBuildRunArgs_CgroupNeedsCgroupV2(t *testing.T) {
+	cfg := &config.Config{Image: "alpine", Workdir: "/workspace", Resources: config.Resources{Cgroup: config.Cgroup{Parent: "alca.slice", CpusetCPUs: "0-1"}}}
+	st := &state.State{ProjectID: "p", ContainerName: "alca-p"}
+	env := &RuntimeEnv{Cmd: util.NewMockCommandRunner().AllowUnexpected()}
+	rt := NewDocker()
+
+	args := strings.Join(rt.buildRunArgs(context.Background(), env, cfg, "/p", st, "alca-p", Capabilities{}), " ")
+	if strings.Contains(args, "--cgroup-parent") || strings.Contains(args, "--cpuset-cpus") {
+		t.Errorf("cgroup v1 should drop resources.cgroup: %s", args)
+	}
+
+	args = strings.Join(rt.buildRunArgs(context.Background(), env, cfg, "/p", st, "alca-p", Capabilities{CgroupV2: true}), " ")
+	if !strings.Contains(args, "--cgroup-parent alca.slice") || !strings.Contains(args, "--cpuset-cpus 0-1") {
+		t.Errorf("cgroup v2 should apply resources.cgroup: %s", args)
+	}
+}
+
+func TestUpdateResources_SkipsCgroupWithoutCgroupV2(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess("docker info --format {{json .}}", []byte(`{"CgroupVersion":"1"}`))
+	cmd.ExpectSuccess("docker update --cpus 2 alca-p", nil)
+	res := config.Resources{CPUs: 2, Cgroup: config.Cgroup{CPUWeight: 50}}
+	if err := NewDocker().UpdateResources(context.Background(), &RuntimeEnv{Cmd: cmd}, "alca-p", res); err != nil {
+		t.Fatalf("UpdateResources() error: %v", err)
+	}
+	cmd.AssertAllExpectationsMet(t)
+}
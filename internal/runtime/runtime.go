@@ -4,8 +4,12 @@ package runtime
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"time"
+
+	"github.com/spf13/afero"
 
 	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/state"
@@ -16,6 +20,9 @@ import (
 // Used for dependency injection to enable testing.
 type RuntimeEnv struct {
 	Cmd util.CommandRunner
+	// Fs is the host filesystem that commands such as 'docker save' and
+	// 'docker cp' write to; the fake runtime writes there in their place.
+	Fs afero.Fs
 }
 
 // NewRuntimeEnv creates a new RuntimeEnv with the given CommandRunner.
@@ -28,8 +35,17 @@ var (
 	ErrNotAvailable    = errors.New("runtime not available")
 	ErrContainerExists = errors.New("container already exists")
 	ErrNotRunning      = errors.New("container is not running")
+	ErrNotPaused       = errors.New("container is not paused")
+	ErrUnknownUser     = errors.New("user does not exist in the container")
+
+	// ErrReadonlySyncedWorkdir is returned by ExecReadonly when the workdir
+	// is synced by Mutagen and therefore has no mount to borrow.
+	ErrReadonlySyncedWorkdir = errors.New("read-only inspection is unavailable: workdir is synced by Mutagen, not bind-mounted")
 )
 
+// InspectionUser is the uid:gid read-only inspection sessions run as (nobody).
+const InspectionUser = "65534:65534"
+
 // ContainerState represents the state of a container.
 type ContainerState string
 
@@ -37,6 +53,7 @@ const (
 	StateUnknown  ContainerState = "unknown"
 	StateRunning  ContainerState = "running"
 	StateStopped  ContainerState = "stopped"
+	StatePaused   ContainerState = "paused"
 	StateNotFound ContainerState = "not_found"
 )
 
@@ -57,6 +74,8 @@ type ContainerInfo struct {
 	ProjectPath string
 	CreatedAt   string
 	Image       string
+	// Version is the alca.version label (state version at creation).
+	Version string
 }
 
 // Runtime defines the interface for container runtime operations.
@@ -77,11 +96,28 @@ type Runtime interface {
 	// The state provides container identity for lookup.
 	Down(ctx context.Context, env *RuntimeEnv, projectDir string, st *state.State) error
 
+	// Stop stops the container without removing it, pausing its Mutagen
+	// syncs first; Up starts it again. A missing or stopped container is
+	// not an error.
+	Stop(ctx context.Context, env *RuntimeEnv, projectDir string, st *state.State) error
+
+	// Pause freezes the processes of the running container and pauses its
+	// Mutagen syncs. Returns ErrNotRunning if it is not running.
+	Pause(ctx context.Context, env *RuntimeEnv, projectDir string, st *state.State) error
+
+	// Unpause thaws a paused container and resumes its Mutagen syncs.
+	// Returns ErrNotPaused if it is not paused.
+	Unpause(ctx context.Context, env *RuntimeEnv, projectDir string, st *state.State) error
+
 	// Exec runs a command inside the container for the given project directory.
 	// The state provides container identity for lookup.
 	// The config provides environment variables with override_on_enter support.
 	Exec(ctx context.Context, env *RuntimeEnv, cfg *config.Config, projectDir string, st *state.State, command []string) error
 
+	// ExecReadonly runs a command in a read-only, network-less view of the
+	// container for the given project directory, without touching the container itself.
+	ExecReadonly(ctx context.Context, env *RuntimeEnv, cfg *config.Config, projectDir string, st *state.State, command []string) error
+
 	// Status returns the current status of the container for the given project directory.
 	// The state provides container identity for lookup. If state is nil, uses legacy name lookup.
 	Status(ctx context.Context, env *RuntimeEnv, projectDir string, st *state.State) (ContainerStatus, error)
@@ -90,6 +126,9 @@ type Runtime interface {
 	// This is an experimental feature - see AGD-009 for design rationale.
 	Reload(ctx context.Context, env *RuntimeEnv, cfg *config.Config, projectDir string, st *state.State) error
 
+	// InspectContainer returns the runtime's raw inspect document for a container.
+	InspectContainer(ctx context.Context, env *RuntimeEnv, containerName string) (json.RawMessage, error)
+
 	// ListContainers returns all containers managed by alca (those with alca.project.id label).
 	ListContainers(ctx context.Context, env *RuntimeEnv) ([]ContainerInfo, error)
 
@@ -100,7 +139,134 @@ type Runtime interface {
 	// Used by firewall rules to restrict container network access.
 	GetContainerIP(ctx context.Context, env *RuntimeEnv, containerName string) (string, error)
 
+	// ConnectPeerNetwork attaches a running container to the internal network
+	// shared with a peer project (created if missing), reachable there as
+	// alias. Returns the network's IPv4 subnet.
+	ConnectPeerNetwork(ctx context.Context, env *RuntimeEnv, containerName, network, alias string) (string, error)
+
+	// GetContainerPID returns the host PID of a running container's init process.
+	// Used to enter the container's network namespace for traffic shaping.
+	GetContainerPID(ctx context.Context, env *RuntimeEnv, containerName string) (int, error)
+
+	// ContainerExit returns how the container's main process last ended
+	// (exit code, OOM kill) and how often the restart policy restarted it.
+	ContainerExit(ctx context.Context, env *RuntimeEnv, containerName string) (ContainerExit, error)
+
+	// OOMKillCount returns how many processes the out-of-memory killer has
+	// ended in a running container, exec'd processes included.
+	OOMKillCount(ctx context.Context, env *RuntimeEnv, containerName string) (int, error)
+
+	// Logs writes the container's output to out. With opts.Follow it keeps
+	// streaming until the container stops or ctx is canceled.
+	Logs(ctx context.Context, env *RuntimeEnv, containerName string, opts LogsOptions, out io.Writer) error
+
+	// PublishedPort returns the host port a container TCP port is published
+	// on. Returns ErrPortNotPublished if it is not published.
+	PublishedPort(ctx context.Context, env *RuntimeEnv, containerName string, containerPort int) (int, error)
+
 	// GetHostIP returns the IP address at which the host machine is reachable
 	// from inside containers. Used to resolve ${alca:HOST_IP} tokens.
 	GetHostIP(ctx context.Context, env *RuntimeEnv) (string, error)
+
+	// HostGatewayIP returns the address HostAlias resolves to in containers,
+	// which unlike GetHostIP is the host machine itself on macOS VMs.
+	HostGatewayIP(ctx context.Context, env *RuntimeEnv) (string, error)
+
+	// PullImage pulls the image from its registry, streaming progress output
+	// to progressOut (nil discards). A non-empty platform (os/arch[/variant])
+	// selects the image variant.
+	PullImage(ctx context.Context, env *RuntimeEnv, image, platform string, progressOut io.Writer) error
+
+	// ImagePresent reports whether the image is available locally.
+	ImagePresent(ctx context.Context, env *RuntimeEnv, image string) (bool, error)
+
+	// Capabilities reports the optional features the daemon supports.
+	Capabilities(ctx context.Context, env *RuntimeEnv) (Capabilities, error)
+
+	// ImageArch returns the CPU architecture of the runtime host and the one
+	// the image runs as (the platform override, else the local image's), in
+	// GOARCH form (amd64, arm64). imageArch is empty if the image is not pulled.
+	ImageArch(ctx context.Context, env *RuntimeEnv, image, platform string) (hostArch, imageArch string, err error)
+
+	// ImageUpdateAvailable reports whether the registry has a newer digest
+	// for the image tag than the local copy.
+	ImageUpdateAvailable(ctx context.Context, env *RuntimeEnv, image string) (bool, error)
+
+	// ClockDrift returns how far the running container's clock is ahead of
+	// the host's (negative when behind), to about a second.
+	ClockDrift(ctx context.Context, env *RuntimeEnv, containerName string) (time.Duration, error)
+
+	// SyncClock steps the runtime VM's clock to the host's time.
+	// Returns ErrClockSyncUnsupported when containers share the host clock.
+	SyncClock(ctx context.Context, env *RuntimeEnv, cfg *config.Config) error
+
+	// BakeImage commits the container's filesystem to image, with the
+	// configured envs scrubbed from the image config.
+	BakeImage(ctx context.Context, env *RuntimeEnv, cfg *config.Config, containerName, image string) error
+
+	// RemoveImage removes a local image.
+	RemoveImage(ctx context.Context, env *RuntimeEnv, image string) error
+
+	// SaveImage writes a local image to path as a tar archive that
+	// 'docker load' and 'podman load' accept.
+	SaveImage(ctx context.Context, env *RuntimeEnv, image, path string) error
+
+	// CopyFromContainer copies a path from the container to the host.
+	// Returns ErrPathNotFound if the source does not exist in the container.
+	CopyFromContainer(ctx context.Context, env *RuntimeEnv, containerName, src, dst string) error
+
+	// CopyToContainer copies a path from the host into the container.
+	CopyToContainer(ctx context.Context, env *RuntimeEnv, containerName, src, dst string) error
+
+	// StagePaths copies paths out of the container into stage, a container
+	// on the runtime host that is never started and holds one volume per
+	// path, so the data survives removing containerName without passing
+	// through the host disk. Paths missing in the container are left out of
+	// the returned list. stage must be a new container name.
+	StagePaths(ctx context.Context, env *RuntimeEnv, containerName, stage string, paths []string) ([]string, error)
+
+	// RestoreStagedPaths copies paths staged by StagePaths into the running
+	// container, merging directories into existing ones.
+	RestoreStagedPaths(ctx context.Context, env *RuntimeEnv, stage, containerName string, paths []string) error
+
+	// RemoveStage removes a stage and its volumes. A missing stage is not
+	// an error.
+	RemoveStage(ctx context.Context, env *RuntimeEnv, stage string) error
+
+	// WriteFile atomically replaces a file in the running container with
+	// content, as root and world-readable, creating its directory.
+	WriteFile(ctx context.Context, env *RuntimeEnv, containerName, path, content string) error
+
+	// ImagePackages lists the OS packages installed in an image. A
+	// non-empty platform selects the image variant, as for PullImage.
+	ImagePackages(ctx context.Context, env *RuntimeEnv, image, platform string) (PackageList, error)
+
+	// ContainerPackages lists the OS packages installed in a running container.
+	ContainerPackages(ctx context.Context, env *RuntimeEnv, containerName string) (PackageList, error)
+
+	// ContainerSpec returns the run options of any container, alca-managed
+	// or not (image, environment, bind mounts, ports, limits).
+	ContainerSpec(ctx context.Context, env *RuntimeEnv, containerName string) (ContainerSpec, error)
+
+	// DiskUsage measures the disk space the given images, the writable
+	// layer of containerName and the given named volumes take on the
+	// runtime host. Missing images and volumes are left out.
+	DiskUsage(ctx context.Context, env *RuntimeEnv, containerName string, images, volumes []string) ([]DiskUsageItem, error)
+
+	// UpdateResources changes the memory and CPU limits of a container in
+	// place. Unset fields are left as they are.
+	UpdateResources(ctx context.Context, env *RuntimeEnv, containerName string, res config.Resources) error
+
+	// RunTask runs a shell command in the running container without a
+	// terminal, as the configured exec user in the workdir, writing its
+	// output to out. Used for [cron] tasks.
+	RunTask(ctx context.Context, env *RuntimeEnv, cfg *config.Config, containerName, command string, out io.Writer) error
+
+	// Diff lists the paths added, changed and deleted in the container's
+	// writable layer since it was created. Works on stopped containers.
+	Diff(ctx context.Context, env *RuntimeEnv, containerName string) ([]FileChange, error)
+
+	// TouchFiles makes the container's inotify watchers see paths change
+	// without changing them. Missing paths are skipped.
+	TouchFiles(ctx context.Context, env *RuntimeEnv, containerName string, paths []string) error
 }
@@ -3,8 +3,12 @@ package runtime
 import (
 	"context"
 	"errors"
+	"io"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/state"
@@ -142,6 +146,79 @@ func TestIsOrbStack_DockerNotAvailable(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// IsColima() Tests
+// =============================================================================
+
+func TestIsColima(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"colima", true},
+		{"colima-work", true},
+		{"docker-desktop", false},
+		{"colimator", false},
+	}
+	for _, tt := range tests {
+		mock := util.NewMockCommandRunner()
+		mock.ExpectSuccess("docker info --format {{.Name}}", []byte(tt.name+"\n"))
+
+		got, err := IsColima(context.Background(), newMockEnv(mock))
+		if err != nil {
+			t.Fatalf("IsColima() unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("IsColima() with daemon name %q = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsColima_DockerNotAvailable(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectFailure("docker info --format {{.Name}}", errCommandNotFound)
+
+	if _, err := IsColima(context.Background(), newMockEnv(mock)); err == nil {
+		t.Error("IsColima() should return error when docker not available")
+	}
+}
+
+// =============================================================================
+// IsPodmanMachine() Tests
+// =============================================================================
+
+func TestIsPodmanMachine(t *testing.T) {
+	tests := []struct {
+		components string
+		want       bool
+	}{
+		{"Podman Engine;Conmon;OCI Runtime (crun);", true},
+		{"Engine;containerd;runc;docker-init;", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		mock := util.NewMockCommandRunner()
+		mock.ExpectSuccess("docker version --format {{range .Server.Components}}{{.Name}};{{end}}", []byte(tt.components+"\n"))
+
+		got, err := IsPodmanMachine(context.Background(), newMockEnv(mock))
+		if err != nil {
+			t.Fatalf("IsPodmanMachine() unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("IsPodmanMachine() with components %q = %v, want %v", tt.components, got, tt.want)
+		}
+	}
+}
+
+func TestIsPodmanMachine_DockerNotAvailable(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectFailure("docker version --format {{range .Server.Components}}{{.Name}};{{end}}", errCommandNotFound)
+
+	if _, err := IsPodmanMachine(context.Background(), newMockEnv(mock)); err == nil {
+		t.Error("IsPodmanMachine() should return error when docker not available")
+	}
+}
+
 // =============================================================================
 // IsRootlessPodman() Tests
 // =============================================================================
@@ -232,6 +309,39 @@ func TestDetectPlatform_MacOrbStack(t *testing.T) {
 	}
 }
 
+func TestDetectPlatform_MacColima(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("Test only runs on macOS")
+	}
+
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker info --format {{.OperatingSystem}}", []byte("Ubuntu 24.04.1 LTS"))
+	mock.ExpectSuccess("docker info --format {{.Name}}", []byte("colima"))
+	env := newMockEnv(mock)
+
+	result := DetectPlatform(context.Background(), env)
+	if result != PlatformMacColima {
+		t.Errorf("DetectPlatform() with Colima should return PlatformMacColima, got %v", result)
+	}
+}
+
+func TestDetectPlatform_MacPodmanMachine(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("Test only runs on macOS")
+	}
+
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker info --format {{.OperatingSystem}}", []byte("fedora"))
+	mock.ExpectSuccess("docker info --format {{.Name}}", []byte("localhost.localdomain"))
+	mock.ExpectSuccess("docker version --format {{range .Server.Components}}{{.Name}};{{end}}", []byte("Podman Engine;Conmon;"))
+	env := newMockEnv(mock)
+
+	result := DetectPlatform(context.Background(), env)
+	if result != PlatformMacPodman {
+		t.Errorf("DetectPlatform() with a Podman machine should return PlatformMacPodman, got %v", result)
+	}
+}
+
 func TestDetectPlatform_MacDockerDesktop(t *testing.T) {
 	if runtime.GOOS != "darwin" {
 		t.Skip("Test only runs on macOS")
@@ -540,3 +650,241 @@ func TestFlushMutagenSyncs_StopsOnError(t *testing.T) {
 	mock.AssertCalled(t, "mutagen sync flush session-0")
 	mock.AssertNotCalled(t, "mutagen sync flush session-1")
 }
+
+// =============================================================================
+// InspectContainer() Tests
+// =============================================================================
+
+func TestInspectContainer_ReturnsFirstEntry(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker inspect alca-test", []byte(`[{"Id": "abc", "Name": "/alca-test"}]`))
+	defer mock.AssertAllExpectationsMet(t)
+
+	doc, err := NewDocker().InspectContainer(context.Background(), newMockEnv(mock), "alca-test")
+	if err != nil {
+		t.Fatalf("InspectContainer() unexpected error: %v", err)
+	}
+	if string(doc) != `{"Id": "abc", "Name": "/alca-test"}` {
+		t.Errorf("InspectContainer() = %s", doc)
+	}
+}
+
+func TestInspectContainer_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output []byte
+		err    error
+	}{
+		{"command failure", []byte("Error: No such object: alca-test"), errDaemonNotRunning},
+		{"invalid json", []byte("not json"), nil},
+		{"empty array", []byte("[]"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := util.NewMockCommandRunner()
+			mock.Expect("podman inspect alca-test", tt.output, tt.err)
+
+			if _, err := NewPodman().InspectContainer(context.Background(), newMockEnv(mock), "alca-test"); err == nil {
+				t.Error("InspectContainer() expected error")
+			}
+		})
+	}
+}
+
+// =============================================================================
+// checkExecUser() Tests
+// =============================================================================
+
+func TestCheckExecUser(t *testing.T) {
+	t.Run("existing user", func(t *testing.T) {
+		mock := util.NewMockCommandRunner()
+		mock.ExpectSuccess("docker exec alca-test id -u dev", []byte("1000"))
+		defer mock.AssertAllExpectationsMet(t)
+
+		if err := NewDocker().checkExecUser(context.Background(), newMockEnv(mock), "alca-test", "dev"); err != nil {
+			t.Errorf("checkExecUser() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("group suffix is ignored", func(t *testing.T) {
+		mock := util.NewMockCommandRunner()
+		mock.ExpectSuccess("docker exec alca-test id -u dev", []byte("1000"))
+		defer mock.AssertAllExpectationsMet(t)
+
+		if err := NewDocker().checkExecUser(context.Background(), newMockEnv(mock), "alca-test", "dev:staff"); err != nil {
+			t.Errorf("checkExecUser() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mock := util.NewMockCommandRunner()
+		mock.ExpectFailure("docker exec alca-test id -u nobodyy", errors.New("exit status 1"))
+
+		err := NewDocker().checkExecUser(context.Background(), newMockEnv(mock), "alca-test", "nobodyy")
+		if !errors.Is(err, ErrUnknownUser) {
+			t.Errorf("checkExecUser() error = %v, want ErrUnknownUser", err)
+		}
+	})
+
+	t.Run("numeric uid is not checked", func(t *testing.T) {
+		mock := util.NewMockCommandRunner()
+		defer mock.AssertAllExpectationsMet(t)
+
+		if err := NewDocker().checkExecUser(context.Background(), newMockEnv(mock), "alca-test", "1000:1000"); err != nil {
+			t.Errorf("checkExecUser() unexpected error: %v", err)
+		}
+		if len(mock.Calls) != 0 {
+			t.Errorf("numeric uid should not run commands, got %v", mock.Calls)
+		}
+	})
+}
+
+// =============================================================================
+// Sync Pause/Resume Tests
+// =============================================================================
+
+const (
+	listSyncsKey  = `mutagen sync list --template={{range .}}{{.Name}}{{"\n"}}{{end}}`
+	listPausedKey = `mutagen sync list --template={{range .}}{{.Name}} {{.Paused}}{{"\n"}}{{end}}`
+)
+
+func TestPauseProjectSyncs(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess(listSyncsKey, []byte("alca-p1-0\nalca-p2-0\nalca-p1-1\n"))
+	mock.ExpectSuccess("mutagen sync pause alca-p1-0", nil)
+	mock.ExpectSuccess("mutagen sync pause alca-p1-1", nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	sessions, err := PauseProjectSyncs(context.Background(), newMockEnv(mock), "p1")
+	if err != nil {
+		t.Fatalf("PauseProjectSyncs() unexpected error: %v", err)
+	}
+	if !slices.Equal(sessions, []string{"alca-p1-0", "alca-p1-1"}) {
+		t.Errorf("PauseProjectSyncs() = %v", sessions)
+	}
+	mock.AssertNotCalled(t, "mutagen sync pause alca-p2-0")
+}
+
+func TestResumeProjectSyncs_ContinuesPastFailure(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess(listSyncsKey, []byte("alca-p1-0\nalca-p1-1\n"))
+	mock.ExpectFailure("mutagen sync resume alca-p1-0", errDaemonNotRunning)
+	mock.ExpectSuccess("mutagen sync resume alca-p1-1", nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	if _, err := ResumeProjectSyncs(context.Background(), newMockEnv(mock), "p1"); err == nil {
+		t.Error("ResumeProjectSyncs() should return the session error")
+	}
+}
+
+func TestPausedProjectSyncs(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess(listPausedKey, []byte("alca-p1-0 true\nalca-p1-1 false\nalca-p2-0 true\n"))
+
+	paused, err := PausedProjectSyncs(context.Background(), newMockEnv(mock), "p1")
+	if err != nil {
+		t.Fatalf("PausedProjectSyncs() unexpected error: %v", err)
+	}
+	if !slices.Equal(paused, []string{"alca-p1-0"}) {
+		t.Errorf("PausedProjectSyncs() = %v, want [alca-p1-0]", paused)
+	}
+}
+
+func TestDockerDown_PausesSyncsBeforeStopping(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess(
+		"docker ps -a --filter label=alca.project.id=test-uuid --format {{.Names}}",
+		[]byte("alca-test"),
+	)
+	mock.ExpectSuccess(
+		"docker inspect --format {{.State.Status}}|{{.Id}}|{{.Name}}|{{.Config.Image}}|{{.State.StartedAt}} alca-test",
+		[]byte("running|abc123|/alca-test|test-image:latest|2024-01-15T10:00:00Z"),
+	)
+	mock.ExpectSuccess(listSyncsKey, []byte("alca-test-uuid-0\n"))
+	mock.ExpectSuccess("mutagen sync pause alca-test-uuid-0", nil)
+	mock.ExpectSuccess("docker stop alca-test", nil)
+	mock.ExpectSuccess("docker rm -f alca-test", nil)
+	mock.ExpectSuccess("mutagen sync terminate alca-test-uuid-0", nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	st := &state.State{ProjectID: "test-uuid", ContainerName: "alca-test"}
+	if err := NewDocker().Down(context.Background(), newMockEnv(mock), "/project", st); err != nil {
+		t.Fatalf("Down() unexpected error: %v", err)
+	}
+
+	keys := mock.CallKeys()
+	pause := slices.Index(keys, "mutagen sync pause alca-test-uuid-0")
+	stop := slices.Index(keys, "docker stop alca-test")
+	terminate := slices.Index(keys, "mutagen sync terminate alca-test-uuid-0")
+	if pause >= stop || stop >= terminate {
+		t.Errorf("expected pause < stop < terminate, got calls %v", keys)
+	}
+}
+
+func TestGetContainerIP_RetriesUntilAttached(t *testing.T) {
+	key := "docker inspect --format " + containerIPFormat + " alca-test"
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSequence(key, []byte("bridge=\n"), nil)
+	mock.ExpectSequence(key, []byte("bridge=172.17.0.2\n"), nil)
+
+	ip, err := NewDocker().getContainerIP(context.Background(), newMockEnv(mock), "alca-test", util.Backoff{MaxAttempts: 3})
+	if err != nil {
+		t.Fatalf("getContainerIP() error: %v", err)
+	}
+	if ip != "172.17.0.2" {
+		t.Errorf("ip = %q, want 172.17.0.2", ip)
+	}
+	if mock.CallCount(key) != 2 {
+		t.Errorf("expected 2 calls, got %d", mock.CallCount(key))
+	}
+}
+
+// hangingRunner blocks every quiet/streamed command until its context is done,
+// simulating a hung daemon.
+type hangingRunner struct{ *util.MockCommandRunner }
+
+func (h hangingRunner) Run(ctx context.Context, _ string, _ ...string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, errors.New("signal: killed")
+}
+
+func (h hangingRunner) RunQuiet(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return h.Run(ctx, name, args...)
+}
+
+func (h hangingRunner) RunStream(ctx context.Context, _ io.Writer, _ util.LineFunc, name string, args ...string) ([]byte, error) {
+	return h.Run(ctx, name, args...)
+}
+
+func TestDockerAvailable_HungDaemonTimesOut(t *testing.T) {
+	orig := probeTimeout
+	probeTimeout = 10 * time.Millisecond
+	defer func() { probeTimeout = orig }()
+
+	env := &RuntimeEnv{Cmd: hangingRunner{util.NewMockCommandRunner()}}
+	if NewDocker().Available(context.Background(), env) {
+		t.Error("Available() should return false when the daemon hangs")
+	}
+
+	_, err := IsOrbStack(context.Background(), env)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("IsOrbStack() error = %v, want a deadline error", err)
+	}
+}
+
+func TestExecuteUpCommand_Timeout(t *testing.T) {
+	cfg := &config.Config{
+		Commands: config.Commands{Up: config.CommandValue{Command: "nix develop"}},
+		Timeouts: config.Timeouts{UpCommand: "10ms"},
+	}
+	env := &RuntimeEnv{Cmd: hangingRunner{util.NewMockCommandRunner()}}
+
+	err := NewDocker().executeUpCommand(context.Background(), env, cfg, "alca-test", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("executeUpCommand() error = %v, want a deadline error", err)
+	}
+	if !strings.Contains(err.Error(), "timeouts.up_command") {
+		t.Errorf("error should name the setting: %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package runtime
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"time"
 
 	"github.com/bolasblack/alcatraz/internal/config"
 	"github.com/bolasblack/alcatraz/internal/state"
@@ -28,12 +30,18 @@ func (s *StubRuntime) Down(_ context.Context, _ *RuntimeEnv, _ string, _ *state.
 func (s *StubRuntime) Exec(_ context.Context, _ *RuntimeEnv, _ *config.Config, _ string, _ *state.State, _ []string) error {
 	return nil
 }
+func (s *StubRuntime) ExecReadonly(_ context.Context, _ *RuntimeEnv, _ *config.Config, _ string, _ *state.State, _ []string) error {
+	return nil
+}
 func (s *StubRuntime) Status(_ context.Context, _ *RuntimeEnv, _ string, _ *state.State) (ContainerStatus, error) {
 	return ContainerStatus{}, nil
 }
 func (s *StubRuntime) Reload(_ context.Context, _ *RuntimeEnv, _ *config.Config, _ string, _ *state.State) error {
 	return nil
 }
+func (s *StubRuntime) InspectContainer(_ context.Context, _ *RuntimeEnv, _ string) (json.RawMessage, error) {
+	return nil, nil
+}
 func (s *StubRuntime) ListContainers(_ context.Context, _ *RuntimeEnv) ([]ContainerInfo, error) {
 	return nil, nil
 }
@@ -43,6 +51,108 @@ func (s *StubRuntime) RemoveContainer(_ context.Context, _ *RuntimeEnv, _ string
 func (s *StubRuntime) GetContainerIP(_ context.Context, _ *RuntimeEnv, _ string) (string, error) {
 	return "", nil
 }
+func (s *StubRuntime) ConnectPeerNetwork(_ context.Context, _ *RuntimeEnv, _, _, _ string) (string, error) {
+	return "", nil
+}
+func (s *StubRuntime) GetContainerPID(_ context.Context, _ *RuntimeEnv, _ string) (int, error) {
+	return 0, nil
+}
+func (s *StubRuntime) ContainerExit(_ context.Context, _ *RuntimeEnv, _ string) (ContainerExit, error) {
+	return ContainerExit{}, nil
+}
+func (s *StubRuntime) OOMKillCount(_ context.Context, _ *RuntimeEnv, _ string) (int, error) {
+	return 0, nil
+}
+func (s *StubRuntime) Logs(_ context.Context, _ *RuntimeEnv, _ string, _ LogsOptions, _ io.Writer) error {
+	return nil
+}
+func (s *StubRuntime) PublishedPort(_ context.Context, _ *RuntimeEnv, _ string, _ int) (int, error) {
+	return 0, nil
+}
 func (s *StubRuntime) GetHostIP(_ context.Context, _ *RuntimeEnv) (string, error) {
 	return "", nil
 }
+func (s *StubRuntime) HostGatewayIP(_ context.Context, _ *RuntimeEnv) (string, error) {
+	return "", nil
+}
+func (s *StubRuntime) PullImage(_ context.Context, _ *RuntimeEnv, _, _ string, _ io.Writer) error {
+	return nil
+}
+func (s *StubRuntime) ImagePresent(_ context.Context, _ *RuntimeEnv, _ string) (bool, error) {
+	return false, nil
+}
+func (s *StubRuntime) Capabilities(_ context.Context, _ *RuntimeEnv) (Capabilities, error) {
+	return Capabilities{}, nil
+}
+func (s *StubRuntime) ImageArch(_ context.Context, _ *RuntimeEnv, _, _ string) (string, string, error) {
+	return "", "", nil
+}
+func (s *StubRuntime) ImageUpdateAvailable(_ context.Context, _ *RuntimeEnv, _ string) (bool, error) {
+	return false, nil
+}
+func (s *StubRuntime) ClockDrift(_ context.Context, _ *RuntimeEnv, _ string) (time.Duration, error) {
+	return 0, nil
+}
+func (s *StubRuntime) SyncClock(_ context.Context, _ *RuntimeEnv, _ *config.Config) error {
+	return nil
+}
+func (s *StubRuntime) BakeImage(_ context.Context, _ *RuntimeEnv, _ *config.Config, _, _ string) error {
+	return nil
+}
+func (s *StubRuntime) RemoveImage(_ context.Context, _ *RuntimeEnv, _ string) error {
+	return nil
+}
+func (s *StubRuntime) SaveImage(_ context.Context, _ *RuntimeEnv, _, _ string) error {
+	return nil
+}
+func (s *StubRuntime) CopyFromContainer(_ context.Context, _ *RuntimeEnv, _, _, _ string) error {
+	return nil
+}
+func (s *StubRuntime) CopyToContainer(_ context.Context, _ *RuntimeEnv, _, _, _ string) error {
+	return nil
+}
+func (s *StubRuntime) StagePaths(_ context.Context, _ *RuntimeEnv, _, _ string, paths []string) ([]string, error) {
+	return paths, nil
+}
+func (s *StubRuntime) RestoreStagedPaths(_ context.Context, _ *RuntimeEnv, _, _ string, _ []string) error {
+	return nil
+}
+func (s *StubRuntime) RemoveStage(_ context.Context, _ *RuntimeEnv, _ string) error {
+	return nil
+}
+func (s *StubRuntime) WriteFile(_ context.Context, _ *RuntimeEnv, _, _, _ string) error {
+	return nil
+}
+func (s *StubRuntime) ImagePackages(_ context.Context, _ *RuntimeEnv, _, _ string) (PackageList, error) {
+	return PackageList{}, nil
+}
+func (s *StubRuntime) ContainerPackages(_ context.Context, _ *RuntimeEnv, _ string) (PackageList, error) {
+	return PackageList{}, nil
+}
+func (s *StubRuntime) ContainerSpec(_ context.Context, _ *RuntimeEnv, _ string) (ContainerSpec, error) {
+	return ContainerSpec{}, nil
+}
+func (s *StubRuntime) Stop(_ context.Context, _ *RuntimeEnv, _ string, _ *state.State) error {
+	return nil
+}
+func (s *StubRuntime) Pause(_ context.Context, _ *RuntimeEnv, _ string, _ *state.State) error {
+	return nil
+}
+func (s *StubRuntime) Unpause(_ context.Context, _ *RuntimeEnv, _ string, _ *state.State) error {
+	return nil
+}
+func (s *StubRuntime) DiskUsage(_ context.Context, _ *RuntimeEnv, _ string, _, _ []string) ([]DiskUsageItem, error) {
+	return nil, nil
+}
+func (s *StubRuntime) UpdateResources(_ context.Context, _ *RuntimeEnv, _ string, _ config.Resources) error {
+	return nil
+}
+func (s *StubRuntime) Diff(_ context.Context, _ *RuntimeEnv, _ string) ([]FileChange, error) {
+	return nil, nil
+}
+func (s *StubRuntime) RunTask(_ context.Context, _ *RuntimeEnv, _ *config.Config, _, _ string, _ io.Writer) error {
+	return nil
+}
+func (s *StubRuntime) TouchFiles(_ context.Context, _ *RuntimeEnv, _ string, _ []string) error {
+	return nil
+}
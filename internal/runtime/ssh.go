@@ -0,0 +1,165 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// SSHContainerPort is the port the in-container SSH server listens on. It
+// is not 22 so an sshd the image already runs is left alone.
+const SSHContainerPort = 2222
+
+// ErrPortNotPublished is returned by PublishedPort when the container does
+// not publish the port (e.g. it was created before ssh was enabled).
+var ErrPortNotPublished = errors.New("port not published")
+
+// SSHKeyPath returns the private key alca ssh-config points clients at. Its
+// public half is authorized in the container at every alca up.
+func SSHKeyPath(projectDir string) string {
+	return filepath.Join(state.StateDirPath(projectDir), "ssh", "id_ed25519")
+}
+
+// SSHUser returns the user SSH clients log in as: the enter/run user, or
+// root when the image's default is used.
+func SSHUser(cfg *config.Config) string {
+	user, _, _ := strings.Cut(cfg.ExecUser(), ":")
+	if user == "" {
+		return "root"
+	}
+	return user
+}
+
+// sshRunArgs publishes the SSH server on the host's loopback only.
+func sshRunArgs(cfg *config.Config) []string {
+	if !cfg.SSH.Enabled {
+		return nil
+	}
+	hostPort := ""
+	if cfg.SSH.Port != 0 {
+		hostPort = strconv.Itoa(cfg.SSH.Port)
+	}
+	return []string{"-p", fmt.Sprintf("127.0.0.1:%s:%d", hostPort, SSHContainerPort)}
+}
+
+// ensureSSHKey creates the project's key pair if missing and returns the
+// public key in authorized_keys format.
+func ensureSSHKey(ctx context.Context, env *RuntimeEnv, projectDir string) (string, error) {
+	keyPath := SSHKeyPath(projectDir)
+	if output, err := env.Cmd.RunQuiet(ctx, "ssh-keygen", "-y", "-f", keyPath); err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+	if output, err := env.Cmd.RunQuiet(ctx, "mkdir", "-p", filepath.Dir(keyPath)); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w: %s", filepath.Dir(keyPath), err, strings.TrimSpace(string(output)))
+	}
+	if output, err := env.Cmd.RunQuiet(ctx, "ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "alca", "-f", keyPath); err != nil {
+		return "", fmt.Errorf("failed to generate SSH key (is ssh-keygen installed?): %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	output, err := env.Cmd.RunQuiet(ctx, "ssh-keygen", "-y", "-f", keyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SSH key %s: %w", keyPath, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// sshSetupScript authorizes $ALCA_SSH_KEY for user $1 and starts an SSH
+// server on SSHContainerPort unless one is already running: the image's
+// OpenSSH sshd, else dropbear, else dropbear installed with the image's
+// package manager. Accounts locked by a "!" password (adduser -D) are
+// unlocked to "*", which still allows no password login but lets sshd
+// accept keys.
+const sshSetupScript = `set -e
+user="$1"
+port="$2"
+home=$(awk -F: -v u="$user" '$1 == u { print $6 }' /etc/passwd)
+[ -n "$home" ] || { echo "user $user not found" >&2; exit 1; }
+group=$(id -g "$user")
+mkdir -p "$home/.ssh"
+touch "$home/.ssh/authorized_keys"
+grep -qxF "$ALCA_SSH_KEY" "$home/.ssh/authorized_keys" || echo "$ALCA_SSH_KEY" >> "$home/.ssh/authorized_keys"
+chmod 700 "$home/.ssh"
+chmod 600 "$home/.ssh/authorized_keys"
+chown -R "$user:$group" "$home/.ssh"
+[ ! -f /etc/shadow ] || sed -i "s/^$user:!/$user:*/" /etc/shadow
+
+pidfile=/run/alca-sshd.pid
+if [ -f "$pidfile" ] && kill -0 "$(cat "$pidfile")" 2>/dev/null; then
+  exit 0
+fi
+if ! command -v sshd >/dev/null 2>&1 && [ ! -x /usr/sbin/sshd ] && ! command -v dropbear >/dev/null 2>&1; then
+  if command -v apk >/dev/null 2>&1; then
+    apk add --no-cache dropbear >/dev/null
+  elif command -v apt-get >/dev/null 2>&1; then
+    apt-get update -qq >/dev/null && DEBIAN_FRONTEND=noninteractive apt-get install -y -qq --no-install-recommends dropbear-bin >/dev/null
+  else
+    echo "no SSH server in the image and no apk or apt-get to install dropbear" >&2
+    exit 1
+  fi
+fi
+mkdir -p /run
+if command -v sshd >/dev/null 2>&1 || [ -x /usr/sbin/sshd ]; then
+  mkdir -p /run/sshd
+  ssh-keygen -A >/dev/null
+  PATH="$PATH:/usr/sbin" sshd -p "$port" -o PidFile="$pidfile" \
+    -o PasswordAuthentication=no -o KbdInteractiveAuthentication=no \
+    -o PermitRootLogin=prohibit-password -o AuthorizedKeysFile=.ssh/authorized_keys
+else
+  mkdir -p /etc/dropbear
+  dropbear -R -s -p "$port" -P "$pidfile"
+fi
+`
+
+// setupSSH authorizes the project key and starts the SSH server in a
+// started container. It runs at every alca up, since the server does not
+// survive a container restart.
+func (r *dockerCLICompatibleRuntime) setupSSH(ctx context.Context, env *RuntimeEnv, cfg *config.Config, projectDir, containerName string, progressOut io.Writer) error {
+	if !cfg.SSH.Enabled {
+		return nil
+	}
+	publicKey, err := ensureSSHKey(ctx, env, projectDir)
+	if err != nil {
+		return err
+	}
+	util.ProgressStep(progressOut, "Starting SSH server...\n")
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "exec", "-u", "root",
+		"-e", "ALCA_SSH_KEY="+publicKey,
+		containerName, "sh", "-c", sshSetupScript, "sh", SSHUser(cfg), strconv.Itoa(SSHContainerPort))
+	if err != nil {
+		return fmt.Errorf("failed to start SSH server: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// PublishedPort returns the host port a container port is published on.
+func (r *dockerCLICompatibleRuntime) PublishedPort(ctx context.Context, env *RuntimeEnv, containerName string, containerPort int) (int, error) {
+	output, err := env.Cmd.RunQuiet(ctx, r.command, "port", containerName, fmt.Sprintf("%d/tcp", containerPort))
+	if err != nil {
+		if strings.Contains(string(output), "No public port") || strings.Contains(string(output), "no public port") {
+			return 0, fmt.Errorf("%w: %d", ErrPortNotPublished, containerPort)
+		}
+		return 0, fmt.Errorf("failed to get published port: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return parsePortOutput(string(output), containerPort)
+}
+
+// parsePortOutput parses `docker port` output such as "127.0.0.1:49153",
+// one binding per line, returning the first host port.
+func parsePortOutput(output string, containerPort int) (int, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.LastIndex(line, ":"); i >= 0 {
+			if port, err := strconv.Atoi(line[i+1:]); err == nil {
+				return port, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: %d", ErrPortNotPublished, containerPort)
+}
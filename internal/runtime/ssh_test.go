@@ -0,0 +1,68 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestSSHRunArgs(t *testing.T) {
+	tests := []struct {
+		ssh  config.SSH
+		want string
+	}{
+		{config.SSH{}, ""},
+		{config.SSH{Enabled: true}, "-p 127.0.0.1::2222"},
+		{config.SSH{Enabled: true, Port: 2200}, "-p 127.0.0.1:2200:2222"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(sshRunArgs(&config.Config{SSH: tt.ssh}), " "); got != tt.want {
+			t.Errorf("sshRunArgs(%+v) = %q, want %q", tt.ssh, got, tt.want)
+		}
+	}
+}
+
+func TestSSHUser(t *testing.T) {
+	if got := SSHUser(&config.Config{}); got != "root" {
+		t.Errorf("default SSHUser = %q, want root", got)
+	}
+	if got := SSHUser(&config.Config{User: "dev:dev"}); got != "dev" {
+		t.Errorf("SSHUser with user dev:dev = %q, want dev", got)
+	}
+}
+
+func TestSetupSSH(t *testing.T) {
+	keyPath := SSHKeyPath("/project")
+	mock := util.NewMockCommandRunner()
+	// No key yet: generated, then read back
+	mock.ExpectSequence("ssh-keygen -y -f "+keyPath, nil, errors.New("no such file"))
+	mock.ExpectSequence("ssh-keygen -y -f "+keyPath, []byte("ssh-ed25519 AAAA alca\n"), nil)
+	mock.ExpectSuccess("mkdir -p /project/.alca/ssh", nil)
+	mock.ExpectSuccess("ssh-keygen -q -t ed25519 -N  -C alca -f "+keyPath, nil)
+	mock.ExpectRegexp(`(?s)^docker exec -u root -e ALCA_SSH_KEY=ssh-ed25519 AAAA alca alca-test sh -c .* sh dev 2222$`, nil, nil)
+	defer mock.AssertAllExpectationsMet(t)
+
+	cfg := &config.Config{User: "dev", SSH: config.SSH{Enabled: true}}
+	if err := NewDocker().setupSSH(context.Background(), newMockEnv(mock), cfg, "/project", "alca-test", nil); err != nil {
+		t.Fatalf("setupSSH() error: %v", err)
+	}
+}
+
+func TestPublishedPort(t *testing.T) {
+	mock := util.NewMockCommandRunner()
+	mock.ExpectSuccess("docker port alca-test 2222/tcp", []byte("127.0.0.1:49153\n"))
+	port, err := NewDocker().PublishedPort(context.Background(), newMockEnv(mock), "alca-test", SSHContainerPort)
+	if err != nil || port != 49153 {
+		t.Errorf("PublishedPort() = %d, %v; want 49153", port, err)
+	}
+
+	mock = util.NewMockCommandRunner()
+	mock.Expect("docker port alca-test 2222/tcp", []byte("Error: No public port '2222/tcp' published for alca-test\n"), errors.New("exit status 1"))
+	if _, err := NewDocker().PublishedPort(context.Background(), newMockEnv(mock), "alca-test", SSHContainerPort); !errors.Is(err, ErrPortNotPublished) {
+		t.Errorf("unpublished port error = %v, want ErrPortNotPublished", err)
+	}
+}
@@ -0,0 +1,28 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+// RunTask runs command with sh -c in the container, like an enter session
+// (exec user, workdir, override_on_enter envs) but without stdin or a
+// terminal, so it can run unattended.
+func (r *dockerCLICompatibleRuntime) RunTask(ctx context.Context, env *RuntimeEnv, cfg *config.Config, containerName, command string, out io.Writer) error {
+	return env.Cmd.RunTo(ctx, out, r.command, buildTaskArgs(cfg, containerName, command)...)
+}
+
+// buildTaskArgs returns the exec arguments for RunTask.
+func buildTaskArgs(cfg *config.Config, containerName, command string) []string {
+	args := []string{"exec"}
+	for key, value := range cfg.ResolvedEnvs(os.Getenv, true) {
+		args = append(args, "-e", key+"="+value)
+	}
+	if user := cfg.ExecUser(); user != "" {
+		args = append(args, "-u", user)
+	}
+	return append(args, "-w", cfg.Workdir, containerName, "sh", "-c", command)
+}
@@ -0,0 +1,34 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestTouchFiles(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(`docker exec -u 0 alca-p sh -c for f; do touch -c -m -r "$f" "$f"; done sh /workspace/a.go /workspace/b c.go`, nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	rt := NewDocker()
+	if err := rt.TouchFiles(context.Background(), NewRuntimeEnv(cmd), "alca-p", []string{"/workspace/a.go", "/workspace/b c.go"}); err != nil {
+		t.Fatalf("TouchFiles: %v", err)
+	}
+	// Nothing to touch runs nothing
+	if err := rt.TouchFiles(context.Background(), NewRuntimeEnv(cmd), "alca-p", nil); err != nil {
+		t.Fatalf("TouchFiles(nil): %v", err)
+	}
+}
+
+func TestTouchFiles_Failure(t *testing.T) {
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectFailure(`docker exec -u 0 alca-p sh -c for f; do touch -c -m -r "$f" "$f"; done sh /workspace/a.go`, errors.New("exit 1"))
+
+	err := NewDocker().TouchFiles(context.Background(), NewRuntimeEnv(cmd), "alca-p", []string{"/workspace/a.go"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,53 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ErrUsernsUnsupported is returned by Up when the runtime cannot apply the
+// configured userns mode.
+var ErrUsernsUnsupported = errors.New("userns mode not supported by the runtime")
+
+// checkUserns verifies the runtime can apply cfg.Userns, with advice on
+// what to change when it cannot. Unknown capabilities (probe failed) pass:
+// the run itself then reports the runtime's own error.
+func (r *dockerCLICompatibleRuntime) checkUserns(cfg *config.Config, caps Capabilities, capsErr error) error {
+	if capsErr != nil {
+		return nil
+	}
+	switch cfg.Userns {
+	case config.UsernsKeepID:
+		if !caps.UsernsKeepID {
+			if caps.UsernsAuto {
+				return fmt.Errorf("userns = %q needs rootless Podman or Podman 4.3+: upgrade Podman or run it rootless: %w", cfg.Userns, ErrUsernsUnsupported)
+			}
+			return fmt.Errorf("userns = %q is Podman-only; %s cannot map the host user into the container: run alca with Podman, or remove userns: %w", cfg.Userns, r.displayName, ErrUsernsUnsupported)
+		}
+	case config.UsernsAuto:
+		if !caps.UsernsAuto {
+			return fmt.Errorf("userns = %q is Podman-only; on %s enable userns-remap in the daemon config (daemon.json) instead and remove userns: %w", cfg.Userns, r.displayName, ErrUsernsUnsupported)
+		}
+	}
+	return nil
+}
+
+// warnUsernsRemap explains the ownership shift Docker's daemon-wide
+// userns-remap causes in bind mounts, unless the config chose a mode.
+func (r *dockerCLICompatibleRuntime) warnUsernsRemap(cfg *config.Config, caps Capabilities, progressOut io.Writer) {
+	if caps.UsernsRemap && cfg.Userns == "" {
+		util.ProgressStep(progressOut, "Note: %s userns-remap is on: container users map to unprivileged host IDs, so mounted project files may be read-only or owned by nobody in the container; set userns = \"host\" to keep host ownership\n", r.displayName)
+	}
+}
+
+// usernsRunArgs returns the --userns flag for cfg.Userns.
+func usernsRunArgs(cfg *config.Config) []string {
+	if cfg.Userns == "" {
+		return nil
+	}
+	return []string{"--userns", string(cfg.Userns)}
+}
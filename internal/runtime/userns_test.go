@@ -0,0 +1,84 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestCheckUserns(t *testing.T) {
+	podman5 := Capabilities{UsernsKeepID: true, UsernsAuto: true}
+	podman42 := Capabilities{UsernsAuto: true}
+	docker := Capabilities{}
+
+	tests := []struct {
+		name    string
+		rt      *dockerCLICompatibleRuntime
+		mode    config.UsernsMode
+		caps    Capabilities
+		capsErr error
+		wantErr string
+	}{
+		{"unset", NewDocker().dockerCLICompatibleRuntime, "", docker, nil, ""},
+		{"keep-id on podman", NewPodman().dockerCLICompatibleRuntime, config.UsernsKeepID, podman5, nil, ""},
+		{"keep-id on old rootful podman", NewPodman().dockerCLICompatibleRuntime, config.UsernsKeepID, podman42, nil, "Podman 4.3+"},
+		{"keep-id on docker", NewDocker().dockerCLICompatibleRuntime, config.UsernsKeepID, docker, nil, "Podman-only"},
+		{"auto on docker", NewDocker().dockerCLICompatibleRuntime, config.UsernsAuto, docker, nil, "userns-remap"},
+		{"host on docker", NewDocker().dockerCLICompatibleRuntime, config.UsernsHost, docker, nil, ""},
+		{"unknown capabilities", NewDocker().dockerCLICompatibleRuntime, config.UsernsKeepID, docker, errors.New("info failed"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rt.checkUserns(&config.Config{Userns: tt.mode}, tt.caps, tt.capsErr)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkUserns() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUsernsUnsupported) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkUserns() = %v, want ErrUsernsUnsupported mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildRunArgs_Userns(t *testing.T) {
+	st := &state.State{ProjectID: "p", ContainerName: "alca-p"}
+	env := &RuntimeEnv{Cmd: util.NewMockCommandRunner().AllowUnexpected()}
+	rt := NewPodman()
+
+	cfg := &config.Config{Image: "alpine", Workdir: "/workspace", Userns: config.UsernsKeepID}
+	args := strings.Join(rt.buildRunArgs(context.Background(), env, cfg, "/p", st, "alca-p", Capabilities{}), " ")
+	if !strings.Contains(args, "--userns keep-id") {
+		t.Errorf("expected --userns keep-id: %s", args)
+	}
+
+	cfg.Userns = ""
+	args = strings.Join(rt.buildRunArgs(context.Background(), env, cfg, "/p", st, "alca-p", Capabilities{}), " ")
+	if strings.Contains(args, "--userns") {
+		t.Errorf("unset userns should keep the runtime default: %s", args)
+	}
+}
+
+func TestWarnUsernsRemap(t *testing.T) {
+	rt := NewDocker()
+	var out bytes.Buffer
+
+	rt.warnUsernsRemap(&config.Config{}, Capabilities{UsernsRemap: true}, &out)
+	if !strings.Contains(out.String(), `userns = "host"`) {
+		t.Errorf("expected a userns-remap note, got %q", out.String())
+	}
+
+	out.Reset()
+	rt.warnUsernsRemap(&config.Config{Userns: config.UsernsHost}, Capabilities{UsernsRemap: true}, &out)
+	if out.Len() != 0 {
+		t.Errorf("an explicit userns should silence the note, got %q", out.String())
+	}
+}
@@ -0,0 +1,138 @@
+// Package sandbox is the core of alca: the up, down, run, enter and status
+// flows over a project's container, network, firewall and sync. The
+// commands in internal/cli and the embedding API in pkg/alcatraz only
+// translate their input into the options here. Every flow takes the host
+// filesystem and command runner as a *util.Env, so callers and tests choose
+// them.
+package sandbox
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// UpOptions configures Up. The zero value is a plain 'alca up' that prints
+// nothing and fails instead of asking for confirmation.
+type UpOptions struct {
+	// Force rebuilds the container on config changes without confirmation.
+	Force bool
+	// Resume skips the steps an interrupted up completed.
+	Resume bool
+	// Verbose also reports risky or redundant config settings.
+	Verbose bool
+	// Set overlays config values for this run (key=value or key+=value).
+	Set []string
+	// SaveSet also writes the Set values to the local config file.
+	SaveSet bool
+	// Idempotent does nothing when the container already runs with the
+	// current config.
+	Idempotent bool
+	// PullPolicy says when to pull the image of a container being created;
+	// empty means PullMissing.
+	PullPolicy PullPolicy
+	// AssumeYes answers yes to every confirmation.
+	AssumeYes bool
+	// Interactive asks confirmations on the terminal.
+	Interactive bool
+	// Out receives progress output; nil discards it.
+	Out io.Writer
+}
+
+// promptMode returns how the up flow answers confirmations.
+func (o UpOptions) promptMode() PromptMode {
+	return PromptMode{AssumeYes: o.AssumeYes, NonInteractive: !o.Interactive}
+}
+
+// DownOptions configures Down.
+type DownOptions struct {
+	// Force proceeds despite unresolved sync conflicts.
+	Force bool
+	// Out receives progress output; nil discards it.
+	Out io.Writer
+}
+
+// ExecOptions configures Exec and Enter.
+type ExecOptions struct {
+	// User runs the command as this user (name or uid[:gid]) instead of
+	// the configured one.
+	User string
+	// Dir is the host directory the command is run from; it starts in the
+	// matching container directory. Empty starts it in the workdir.
+	Dir string
+	// Recovery, if set, may bring a container that is not running back up
+	// first.
+	Recovery *Recovery
+}
+
+// ProjectStatus is the status of a project, as gathered by Status.
+type ProjectStatus struct {
+	// ConfigPath is the project's config file.
+	ConfigPath string
+	// Initialized reports whether ConfigPath exists.
+	Initialized bool
+	// Config is the loaded config.
+	Config *config.Config
+	// Runtime is the name of the selected runtime.
+	Runtime string
+	// RuntimeErr is why no runtime could be selected.
+	RuntimeErr error
+	// State is the project's state; nil before the first up.
+	State *state.State
+	// StateErr is why the state could not be loaded.
+	StateErr error
+	// Container is the status of the project's container.
+	Container runtime.ContainerStatus
+	// ContainerErr is why the container status could not be read.
+	ContainerErr error
+}
+
+// FindProjectDir returns the nearest directory at or above dir holding a
+// ConfigFilename on fs, and whether one was found.
+func FindProjectDir(fs afero.Fs, dir string) (string, bool) {
+	projectDir := FindProjectDirFrom(fs, dir)
+	if _, err := fs.Stat(filepath.Join(projectDir, ConfigFilename)); err != nil {
+		return dir, false
+	}
+	return projectDir, true
+}
+
+// LoadConfig loads the config of the project in cwd from host, with
+// includes, the local config and the monorepo root applied.
+func LoadConfig(ctx context.Context, host *util.Env, cwd string) (*config.Config, error) {
+	env := &util.Env{Fs: afero.NewReadOnlyFs(host.Fs), Cmd: host.Cmd}
+	cfg, _, err := LoadConfigFromCwd(ctx, env, cwd)
+	return cfg, err
+}
+
+// CheckVersionSkew refuses to modify a project last managed by a much
+// newer alca, as the destructive commands do. Warnings go to w.
+func CheckVersionSkew(fs afero.Fs, cwd string, w io.Writer) error {
+	env := &util.Env{Fs: afero.NewReadOnlyFs(fs)}
+	st, err := state.Load(env, cwd)
+	if err != nil || st == nil {
+		return nil
+	}
+	return VersionSkewCheck(w, st, state.CLIVersion, true, false)
+}
+
+// Exec runs args in the container of the project in cwd, attached to the
+// process's standard streams, and records it in the project history. A
+// command that exits non-zero returns its *exec.ExitError.
+func Exec(ctx context.Context, host *util.Env, cwd string, args []string, opts ExecOptions) error {
+	return runSandboxSession(ctx, host, cwd, args, opts, false)
+}
+
+// Enter opens shell in the container of the project in cwd, as 'alca
+// enter' does; commands.enter and enter_tmux apply, and the session is
+// marked per [ui]: the terminal title and the shell prompt.
+func Enter(ctx context.Context, host *util.Env, cwd, shell string, opts ExecOptions) error {
+	return runSandboxSession(ctx, host, cwd, enterShellCommand(ctx, host, cwd, shell), opts, true)
+}
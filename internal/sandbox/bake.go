@@ -0,0 +1,89 @@
+package sandbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/transact"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// bakeInputFiles are workdir files whose contents determine what commands.up
+// installs. A change to any of them invalidates the baked image.
+var bakeInputFiles = []string{"flake.nix", "flake.lock"}
+
+// BakeHash digests the inputs that determine the baked image's contents:
+// the configured image, commands.up and the workdir's bakeInputFiles.
+func BakeHash(fs afero.Fs, projectDir string, cfg *config.Config) (string, error) {
+	workdir := projectDir
+	if cfg.WorkdirSource != "" {
+		workdir = cfg.WorkdirSource
+		if !filepath.IsAbs(workdir) {
+			workdir = filepath.Join(projectDir, workdir)
+		}
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "image\x00%s\x00up\x00%s\x00", cfg.Image, cfg.Commands.Up.Command)
+	for _, name := range bakeInputFiles {
+		data, err := afero.ReadFile(fs, filepath.Join(workdir, name))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			_, _ = fmt.Fprintf(h, "%s\x00-\x00", name)
+		case err != nil:
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		default:
+			_, _ = fmt.Fprintf(h, "%s\x00%d\x00", name, len(data))
+			_, _ = h.Write(data)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// discardStaleBake drops the baked image when its inputs have changed, so
+// the next container is created from the configured image.
+// Best-effort: failures are reported as warnings and never block 'alca up'.
+func discardStaleBake(ctx context.Context, env *util.Env, tfs *transact.TransactFs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, st *state.State, cwd string, out io.Writer) {
+	if st.Bake == nil {
+		return
+	}
+	hash, err := BakeHash(env.Fs, cwd, cfg)
+	if err != nil {
+		util.ProgressStep(out, "Warning: %v\n", err)
+		return
+	}
+	if hash == st.Bake.Hash && st.Bake.Base == cfg.Image {
+		return
+	}
+
+	util.ProgressStep(out, "Discarding baked image %s: image, commands.up, flake.nix or flake.lock changed (run 'alca bake' to bake again)\n", st.Bake.Image)
+	if err := rt.RemoveImage(ctx, runtimeEnv, st.Bake.Image); err != nil {
+		util.ProgressStep(out, "Warning: %v\n", err)
+	}
+	st.Bake = nil
+	if err := SaveBakeState(ctx, env, tfs, cwd, st, out); err != nil {
+		util.ProgressStep(out, "Warning: %v\n", err)
+	}
+}
+
+// SaveBakeState persists st after its Bake changed.
+func SaveBakeState(ctx context.Context, env *util.Env, tfs *transact.TransactFs, cwd string, st *state.State, out io.Writer) error {
+	if err := state.Save(env, cwd, st); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	if err := CommitWithSudo(ctx, env, tfs, out, ""); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+func TestBakeHash(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := &config.Config{Image: "nixos/nix", Commands: config.Commands{Up: config.CommandValue{Command: "nix develop"}}}
+	_ = afero.WriteFile(fs, "/p/flake.lock", []byte(`{"rev":"1"}`), 0644)
+
+	hash := func(cfg *config.Config) string {
+		t.Helper()
+		h, err := BakeHash(fs, "/p", cfg)
+		if err != nil {
+			t.Fatalf("bakeHash() error: %v", err)
+		}
+		return h
+	}
+
+	base := hash(cfg)
+	if base != hash(cfg) {
+		t.Error("hash is not deterministic")
+	}
+
+	_ = afero.WriteFile(fs, "/p/flake.lock", []byte(`{"rev":"2"}`), 0644)
+	locked := hash(cfg)
+	if locked == base {
+		t.Error("flake.lock change did not change the hash")
+	}
+
+	_ = afero.WriteFile(fs, "/p/flake.nix", []byte(`{}`), 0644)
+	if hash(cfg) == locked {
+		t.Error("new flake.nix did not change the hash")
+	}
+
+	changedUp := *cfg
+	changedUp.Commands.Up.Command = "nix develop --impure"
+	if hash(&changedUp) == hash(cfg) {
+		t.Error("commands.up change did not change the hash")
+	}
+
+	// Inputs are read from workdir_source when set
+	_ = afero.WriteFile(fs, "/src/flake.lock", []byte(`{"rev":"9"}`), 0644)
+	elsewhere := *cfg
+	elsewhere.WorkdirSource = "../src"
+	if hash(&elsewhere) == hash(cfg) {
+		t.Error("workdir_source flake.lock was not used")
+	}
+}
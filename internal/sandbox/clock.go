@@ -0,0 +1,28 @@
+package sandbox
+
+import (
+	"context"
+	"io"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// correctClockDrift steps the VM clock when the container clock has drifted
+// past clock.drift_threshold, e.g. after the host slept. Best-effort:
+// failures are reported as warnings and never block the caller.
+func correctClockDrift(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, platform runtime.RuntimePlatform, containerName string, out io.Writer) {
+	threshold := cfg.Clock.Threshold()
+	if threshold == 0 || !runtime.IsDarwin(platform) {
+		return
+	}
+	drift, err := rt.ClockDrift(ctx, runtimeEnv, containerName)
+	if err != nil || drift.Abs() <= threshold {
+		return
+	}
+	util.ProgressStep(out, "Container clock is off by %s (threshold %s), correcting VM clock...\n", drift, threshold)
+	if err := rt.SyncClock(ctx, runtimeEnv, cfg); err != nil {
+		util.ProgressStep(out, "Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,63 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+// clockRuntime reports a sequence of clock drifts and records SyncClock calls.
+type clockRuntime struct {
+	runtime.StubRuntime
+	drifts []time.Duration
+	synced int
+}
+
+func (c *clockRuntime) ClockDrift(_ context.Context, _ *runtime.RuntimeEnv, _ string) (time.Duration, error) {
+	d := c.drifts[0]
+	if len(c.drifts) > 1 {
+		c.drifts = c.drifts[1:]
+	}
+	return d, nil
+}
+
+func (c *clockRuntime) SyncClock(_ context.Context, _ *runtime.RuntimeEnv, _ *config.Config) error {
+	c.synced++
+	return nil
+}
+
+func TestCorrectClockDrift(t *testing.T) {
+	tests := []struct {
+		name       string
+		platform   runtime.RuntimePlatform
+		threshold  string
+		drift      time.Duration
+		wantSynced int
+	}{
+		{"vm drift corrected", runtime.PlatformMacDockerDesktop, "", 10 * time.Second, 1},
+		{"vm within threshold", runtime.PlatformMacDockerDesktop, "", 5 * time.Second, 0},
+		{"disabled", runtime.PlatformMacDockerDesktop, "0", time.Hour, 0},
+		{"linux", runtime.PlatformLinux, "", time.Hour, 0},
+		{"remote", runtime.PlatformRemote, "", time.Hour, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &clockRuntime{drifts: []time.Duration{tt.drift}}
+			cfg := &config.Config{Clock: config.Clock{DriftThreshold: tt.threshold}}
+			var out bytes.Buffer
+
+			correctClockDrift(context.Background(), nil, rt, cfg, tt.platform, "alca-test", &out)
+			if rt.synced != tt.wantSynced {
+				t.Errorf("SyncClock called %d times, want %d", rt.synced, tt.wantSynced)
+			}
+			if tt.wantSynced > 0 && !strings.Contains(out.String(), "correcting VM clock") {
+				t.Errorf("expected correction message, got %q", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/credstore"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ConfigFilename is the standard configuration file name.
+// See AGD-009 for configuration format design.
+const ConfigFilename = config.ProjectConfigFilename
+
+// LocalConfigFilename is the untracked per-machine config that the generated
+// .alca.toml includes. alca up --set-save writes to it.
+const LocalConfigFilename = ".alca.local.toml"
+
+// LintConfig lints cfg against the current user's home directory.
+func LintConfig(env *util.Env, configPath string, cfg *config.Config) []config.LintFinding {
+	home, _ := os.UserHomeDir()
+	return config.Lint(env, configPath, cfg, home, config.StrictExpandEnv)
+}
+
+// ConfigLoadOptions returns the options for loading a config with env:
+// age identities kept in the credential store on env decrypt *.age
+// configs, looked up with ctx, and shared configs skip signature checks
+// under a context from WithInsecureIncludes.
+func ConfigLoadOptions(ctx context.Context, env *util.Env) []config.LoadOption {
+	opts := []config.LoadOption{
+		config.WithStoredAgeIdentity(func() (string, error) {
+			return storedAgeIdentity(ctx, env)
+		}),
+	}
+	if InsecureIncludes(ctx) {
+		opts = append(opts, config.WithInsecureIncludes())
+	}
+	return opts
+}
+
+// insecureIncludesKey marks a context from WithInsecureIncludes.
+type insecureIncludesKey struct{}
+
+// WithInsecureIncludes returns a copy of ctx under which the configs this
+// package loads skip signature checks on shared configs
+// (--insecure-includes).
+func WithInsecureIncludes(ctx context.Context) context.Context {
+	return context.WithValue(ctx, insecureIncludesKey{}, true)
+}
+
+// InsecureIncludes reports whether ctx comes from WithInsecureIncludes.
+func InsecureIncludes(ctx context.Context) bool {
+	insecure, _ := ctx.Value(insecureIncludesKey{}).(bool)
+	return insecure
+}
+
+// storedAgeIdentity returns the age identities kept in the credential
+// store on env, or "" when none are.
+func storedAgeIdentity(ctx context.Context, env *util.Env) (string, error) {
+	store, err := credstore.New(env)
+	if err != nil {
+		return "", err
+	}
+	identity, err := store.Get(ctx, credstore.KeyAgeIdentity)
+	if errors.Is(err, credstore.ErrNotFound) {
+		return "", nil
+	}
+	return identity, err
+}
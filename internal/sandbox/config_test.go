@@ -0,0 +1,40 @@
+package sandbox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/credstore"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestConfigFilenameConstant(t *testing.T) {
+	if ConfigFilename != ".alca.toml" {
+		t.Errorf("expected ConfigFilename to be '.alca.toml', got %q", ConfigFilename)
+	}
+}
+
+func TestStoredAgeIdentity_FileStore(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+	t.Setenv(credstore.EnvBackend, credstore.BackendFile)
+	t.Setenv(credstore.EnvPassphrase, "test passphrase")
+	env := util.NewTestEnv()
+	ctx := context.Background()
+
+	got, err := storedAgeIdentity(ctx, env)
+	if err != nil || got != "" {
+		t.Fatalf("storedAgeIdentity() with nothing stored = %q, %v", got, err)
+	}
+
+	store, err := credstore.New(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ctx, credstore.KeyAgeIdentity, "AGE-SECRET-KEY-1TEST"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = storedAgeIdentity(ctx, env)
+	if err != nil || got != "AGE-SECRET-KEY-1TEST" {
+		t.Errorf("storedAgeIdentity() = %q, %v", got, err)
+	}
+}
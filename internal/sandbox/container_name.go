@@ -0,0 +1,77 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// applyContainerName sets the name of the container about to be created
+// from container_name or name_template, falling back to the generated
+// alca-<id> name. A name held by another project's container, or by a
+// container alca did not create, is an error. The container is still found
+// by its project label, so only the name changes.
+func applyContainerName(ctx context.Context, cmdRunner util.CommandRunner, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, st *state.State, cwd string, out io.Writer) error {
+	vars := config.ContainerNameVars{ProjectName: filepath.Base(cwd), ProjectID: st.ShortProjectID()}
+	if strings.Contains(cfg.NameTemplate, config.NamePlaceholderBranch) {
+		vars.Branch = gitBranch(ctx, cmdRunner, cwd)
+	}
+	name := cfg.ContainerNameFor(vars)
+	if name == "" {
+		name = st.GeneratedContainerName()
+	}
+
+	if err := checkContainerNameFree(ctx, runtimeEnv, rt, st, name); err != nil {
+		return err
+	}
+	if name != st.ContainerName {
+		util.ProgressStep(out, "Container name: %s\n", name)
+		st.ContainerName = name
+	}
+	return nil
+}
+
+// checkContainerNameFree returns ErrContainerNameTaken if a container other
+// than the project's own is named name.
+func checkContainerNameFree(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, name string) error {
+	containers, err := rt.ListContainers(ctx, runtimeEnv)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, c := range containers {
+		if c.Name != name {
+			continue
+		}
+		if c.ProjectID == st.ProjectID {
+			return nil
+		}
+		owner := c.ProjectPath
+		if owner == "" {
+			owner = "another project"
+		}
+		return fmt.Errorf("container name %q is used by the container of %s; change container_name or name_template, or remove that container: %w",
+			name, owner, ErrContainerNameTaken)
+	}
+	if _, err := rt.InspectContainer(ctx, runtimeEnv, name); err == nil {
+		return fmt.Errorf("container name %q is used by a container alca did not create; change container_name or name_template, or remove that container: %w",
+			name, ErrContainerNameTaken)
+	}
+	return nil
+}
+
+// gitBranch returns the current branch of the repository containing dir,
+// or "" outside a repository or on a detached HEAD.
+func gitBranch(ctx context.Context, cmdRunner util.CommandRunner, dir string) string {
+	output, err := cmdRunner.RunQuiet(ctx, "git", "-C", dir, "symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
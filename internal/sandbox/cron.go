@@ -0,0 +1,116 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// CronPidFile in .alca holds the PID of the project's scheduler.
+	CronPidFile = "cron.pid"
+	// cronSchedulerLog is the scheduler's own log in the cron log directory.
+	cronSchedulerLog = "scheduler.log"
+)
+
+// CronLogDir is where task logs are written.
+func CronLogDir(projectDir string) string {
+	return filepath.Join(state.StateDirPath(projectDir), "logs", "cron")
+}
+
+// CronSchedulerPID returns the PID of the project's scheduler if it is
+// running.
+func CronSchedulerPID(fs afero.Fs, projectDir string) (int, bool) {
+	return RunningPID(fs, filepath.Join(state.StateDirPath(projectDir), CronPidFile))
+}
+
+// ReadPIDFile returns the PID recorded in a pid file.
+func ReadPIDFile(fs afero.Fs, path string) (int, bool) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil && pid > 0
+}
+
+// RunningPID returns the PID recorded in a pid file if that process is
+// running.
+func RunningPID(fs afero.Fs, path string) (int, bool) {
+	pid, ok := ReadPIDFile(fs, path)
+	if !ok {
+		return 0, false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil || p.Signal(syscall.Signal(0)) != nil {
+		return 0, false
+	}
+	return pid, true
+}
+
+// spawnCronScheduler starts 'alca cron run' for projectDir in the
+// background, logging to the cron log directory.
+func spawnCronScheduler(env *util.Env, projectDir string) error {
+	return spawnBackground(env, projectDir, filepath.Join(CronLogDir(projectDir), cronSchedulerLog), "cron", "run")
+}
+
+// spawnBackground starts alca with args in projectDir as a background
+// process that outlives this one, appending its output to logPath.
+func spawnBackground(env *util.Env, projectDir, logPath string, args ...string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := env.Fs.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return err
+	}
+	return env.Cmd.Start(util.StartOptions{
+		Env:     []string{EnvNoIdleCheck + "=1"},
+		Dir:     projectDir,
+		LogFile: logPath,
+	}, self, args...)
+}
+
+// ensureCronScheduler starts the project's scheduler when [cron] has tasks
+// and none is running. Failures are warnings: the container is usable.
+func ensureCronScheduler(env *util.Env, cfg *config.Config, projectDir string, out io.Writer) {
+	if len(cfg.Cron) == 0 {
+		return
+	}
+	if _, ok := CronSchedulerPID(env.Fs, projectDir); ok {
+		return
+	}
+	if err := spawnCronScheduler(env, projectDir); err != nil {
+		util.ProgressStep(out, "Warning: failed to start the cron scheduler: %v\n", err)
+		return
+	}
+	util.ProgressStep(out, "Started cron scheduler for %d task(s), logging to %s\n", len(cfg.Cron), CronLogDir(projectDir))
+}
+
+// stopCronScheduler stops the project's scheduler, if one is running,
+// interrupting the tasks it is running.
+func stopCronScheduler(fs afero.Fs, projectDir string) error {
+	pid, ok := CronSchedulerPID(fs, projectDir)
+	if !ok {
+		return nil
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to stop cron scheduler (pid %d): %w", pid, err)
+	}
+	return nil
+}
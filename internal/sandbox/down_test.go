@@ -1,4 +1,4 @@
-package cli
+package sandbox
 
 import (
 	"bytes"
@@ -39,7 +39,7 @@ func (m *mockSyncSessionClient) FlushSyncSession(_ context.Context, _ string) er
 	return nil
 }
 
-// mockRuntime implements runtime.Runtime for testing cleanupFirewall.
+// mockRuntime implements runtime.Runtime for testing CleanupFirewall.
 type mockRuntime struct {
 	runtime.StubRuntime
 	statusResult runtime.ContainerStatus
@@ -53,7 +53,7 @@ func (m *mockRuntime) Status(_ context.Context, _ *runtime.RuntimeEnv, _ string,
 }
 
 func TestCleanupFirewall_NoFirewallAvailable(t *testing.T) {
-	// When no firewall is available, fw is nil — cleanupFirewall returns nil immediately
+	// When no firewall is available, fw is nil — CleanupFirewall returns nil immediately
 	cmd := util.NewMockCommandRunner()
 	fs := afero.NewMemMapFs()
 	tfs := transact.New(transact.WithActualFs(fs))
@@ -63,7 +63,7 @@ func TestCleanupFirewall_NoFirewallAvailable(t *testing.T) {
 	st := &state.State{ContainerName: "alca-test"}
 
 	var buf bytes.Buffer
-	err := cleanupFirewall(context.Background(), nil, env, tfs, runtimeEnv, rt, st, &buf)
+	err := CleanupFirewall(context.Background(), nil, env, tfs, runtimeEnv, rt, st, &buf)
 
 	if err != nil {
 		t.Errorf("expected nil error when no firewall available, got: %v", err)
@@ -89,7 +89,7 @@ func TestCleanupFirewall_StatusError(t *testing.T) {
 	fw, _ := network.New(context.Background(), networkEnv)
 
 	var buf bytes.Buffer
-	err := cleanupFirewall(context.Background(), fw, env, tfs, runtimeEnv, rt, st, &buf)
+	err := CleanupFirewall(context.Background(), fw, env, tfs, runtimeEnv, rt, st, &buf)
 
 	// Status error causes early return nil (not propagated)
 	if err != nil {
@@ -117,7 +117,7 @@ func TestCleanupFirewall_ContainerNotFound(t *testing.T) {
 	fw, _ := network.New(context.Background(), networkEnv)
 
 	var buf bytes.Buffer
-	err := cleanupFirewall(context.Background(), fw, env, tfs, runtimeEnv, rt, st, &buf)
+	err := CleanupFirewall(context.Background(), fw, env, tfs, runtimeEnv, rt, st, &buf)
 
 	// StateNotFound causes early return nil
 	if err != nil {
@@ -141,7 +141,7 @@ func TestGuardSyncConflicts_BlocksWhenConflictsExist(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := guardSyncConflicts(context.Background(), fs, nil, projectRoot, "test-id", false, &buf)
+	err := GuardSyncConflicts(context.Background(), fs, nil, projectRoot, "test-id", false, &buf)
 
 	if !errors.Is(err, errSyncConflicts) {
 		t.Fatalf("expected errSyncConflicts, got: %v", err)
@@ -167,7 +167,7 @@ func TestGuardSyncConflicts_ForceBypassesCheck(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := guardSyncConflicts(context.Background(), fs, nil, projectRoot, "test-id", true, &buf)
+	err := GuardSyncConflicts(context.Background(), fs, nil, projectRoot, "test-id", true, &buf)
 
 	if err != nil {
 		t.Errorf("expected no error with --force, got: %v", err)
@@ -191,7 +191,7 @@ func TestGuardSyncConflicts_ProceedsWhenNoConflicts(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := guardSyncConflicts(context.Background(), fs, nil, projectRoot, "test-id", false, &buf)
+	err := GuardSyncConflicts(context.Background(), fs, nil, projectRoot, "test-id", false, &buf)
 
 	if err != nil {
 		t.Errorf("expected no error when no conflicts, got: %v", err)
@@ -0,0 +1,36 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// enterSessionName names the shared multiplexer session used by enter_tmux.
+const enterSessionName = "alca"
+
+// enterShellCommand returns the command alca enter runs: the shell, or with
+// enter_tmux a script attaching to the shared session. Config errors are
+// left for Enter to report.
+func enterShellCommand(ctx context.Context, host *util.Env, projectDir, shell string) []string {
+	env := &util.Env{Fs: afero.NewReadOnlyFs(host.Fs), Cmd: host.Cmd}
+	cfg, _, err := LoadConfigFromCwd(ctx, env, projectDir)
+	if err != nil || !cfg.EnterTmux {
+		return []string{shell}
+	}
+	return multiplexedShell(shell)
+}
+
+// multiplexedShell attaches to the shared enterSessionName session with tmux,
+// else zellij, creating it on first use; without either it falls back to
+// the plain shell.
+func multiplexedShell(shell string) []string {
+	script := fmt.Sprintf(`if command -v tmux >/dev/null 2>&1; then exec tmux new-session -A -s %[1]s %[2]s; fi
+if command -v zellij >/dev/null 2>&1; then exec zellij attach --create %[1]s; fi
+echo 'alca: enter_tmux is set but neither tmux nor zellij is installed; starting a plain shell' >&2
+exec %[2]s`, enterSessionName, ShellQuote(shell))
+	return []string{"sh", "-c", script}
+}
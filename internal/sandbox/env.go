@@ -0,0 +1,70 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+// Output formats for the env command.
+const (
+	EnvFormatDotenv = "dotenv"
+	envFormatJSON   = "json"
+	envFormatExport = "export"
+)
+
+// ContainerEnvFile holds the export lines for the override_on_enter envs
+// that 'alca env sync' writes in the container.
+const ContainerEnvFile = "/run/alca/env"
+
+// WriteEnvs writes envs in the given format, sorted by key.
+func WriteEnvs(w io.Writer, envs map[string]string, format string) error {
+	keys := make([]string, 0, len(envs))
+	for k := range envs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	switch format {
+	case EnvFormatDotenv:
+		for _, k := range keys {
+			_, _ = fmt.Fprintf(w, "%s=%s\n", k, strconv.Quote(envs[k]))
+		}
+	case envFormatExport:
+		for _, k := range keys {
+			_, _ = fmt.Fprintf(w, "export %s=%s\n", k, ShellQuote(envs[k]))
+		}
+	case envFormatJSON:
+		data, err := json.MarshalIndent(envs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal envs: %w", err)
+		}
+		_, _ = fmt.Fprintln(w, string(data))
+	default:
+		return fmt.Errorf("unknown format %q, valid options: %s", format,
+			strings.Join([]string{EnvFormatDotenv, envFormatJSON, envFormatExport}, ", "))
+	}
+	return nil
+}
+
+// WriteContainerEnv replaces the container env file with the
+// override_on_enter variables expanded from the host environment.
+func WriteContainerEnv(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, containerName string) error {
+	var buf bytes.Buffer
+	if err := WriteEnvs(&buf, cfg.ResolvedEnvs(os.Getenv, true), envFormatExport); err != nil {
+		return err
+	}
+	if err := rt.WriteFile(ctx, runtimeEnv, containerName, ContainerEnvFile, buf.String()); err != nil {
+		return fmt.Errorf("failed to refresh the container env file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package sandbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteEnvs(t *testing.T) {
+	envs := map[string]string{
+		"B_VAR": "it's here",
+		"A_VAR": "plain",
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: EnvFormatDotenv,
+			want:   "A_VAR=\"plain\"\nB_VAR=\"it's here\"\n",
+		},
+		{
+			format: envFormatExport,
+			want:   "export A_VAR='plain'\nexport B_VAR='it'\\''s here'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteEnvs(&buf, envs, tt.format); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteEnvs_JSON(t *testing.T) {
+	envs := map[string]string{"FOO": "bar", "EMPTY_LOOKING": " "}
+
+	var buf bytes.Buffer
+	if err := WriteEnvs(&buf, envs, envFormatJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(got) != 2 || got["FOO"] != "bar" || got["EMPTY_LOOKING"] != " " {
+		t.Errorf("unexpected JSON round-trip: %v", got)
+	}
+}
+
+func TestWriteEnvs_UnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteEnvs(&buf, map[string]string{}, "yaml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
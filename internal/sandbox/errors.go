@@ -0,0 +1,19 @@
+package sandbox
+
+import "errors"
+
+// Sentinel errors for the sandbox package.
+var (
+	// ErrSkipFirewall signals that firewall setup should be skipped without reporting an error.
+	ErrSkipFirewall = errors.New("skip firewall")
+	// errSyncConflicts is returned when unresolved sync conflicts block an operation.
+	errSyncConflicts = errors.New("sync conflicts")
+	// ErrProjectPathMismatch is returned when the project directory has moved since the container was created.
+	ErrProjectPathMismatch = errors.New("project path mismatch")
+	// ErrConfirmationRequired is returned when a prompt is needed but interaction is disabled.
+	ErrConfirmationRequired = errors.New("confirmation required")
+	// ErrContainerNameTaken is returned when the configured container name belongs to another container.
+	ErrContainerNameTaken = errors.New("container name taken")
+	// errVersionSkew is returned when a destructive command runs on a project managed by a much newer alca.
+	errVersionSkew = errors.New("alca version skew")
+)
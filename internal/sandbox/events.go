@@ -0,0 +1,206 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// exitCodeSIGKILL is the exit code of a process ended by SIGKILL, which is
+// how the out-of-memory killer ends processes.
+const exitCodeSIGKILL = 137
+
+// exitCodeSIGTERM is the exit code of a process ended by SIGTERM.
+const exitCodeSIGTERM = 143
+
+// eventNow is the clock for recorded events. Tests replace it.
+var eventNow = time.Now
+
+// surfaceExecKill explains a command in the container that died from
+// SIGKILL (exit 137): an out-of-memory kill when the container's oom_kill
+// counter went up, otherwise an unexplained kill. The event is recorded in
+// st and saved best-effort. Returns whether an event was recorded.
+func surfaceExecKill(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cwd string, st *state.State, cfg *config.Config, containerName, source string, execErr error, w io.Writer) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(execErr, &exitErr) || exitErr.ExitCode() != exitCodeSIGKILL {
+		return false
+	}
+
+	cfg = appliedConfig(st, cfg)
+	e := state.Event{Time: eventNow(), Source: source, ExitCode: exitCodeSIGKILL}
+	count, err := rt.OOMKillCount(ctx, runtimeEnv, containerName)
+	if err == nil && count > baselineOf(count, st.EventCounters.OOMKills) {
+		st.EventCounters.OOMKills = count
+		e.Kind = state.EventOOM
+		e.Message = fmt.Sprintf("%s was killed by the out-of-memory killer (%s)", source, describeMemoryLimit(cfg))
+	} else {
+		e.Kind = state.EventKilled
+		e.Message = fmt.Sprintf("%s was killed (SIGKILL, exit 137); if it ran out of memory, the limit is %s", source, describeMemoryLimit(cfg))
+	}
+	recordEvent(fs, cwd, st, e, w)
+	return true
+}
+
+// checkContainerExit records restarts and crashes of the container's main
+// process since they were last seen, and prints them to w. The restart
+// policy brings the container back after a crash, so a restart is often the
+// only trace of one.
+func checkContainerExit(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cwd string, st *state.State, cfg *config.Config, status runtime.ContainerStatus, w io.Writer) {
+	if status.State == runtime.StateNotFound {
+		return
+	}
+	exit, err := rt.ContainerExit(ctx, runtimeEnv, status.Name)
+	if err != nil {
+		return
+	}
+	cfg = appliedConfig(st, cfg)
+
+	describe := func() (string, string) {
+		switch {
+		case exit.OOMKilled:
+			return state.EventOOM, fmt.Sprintf("killed by the out-of-memory killer (%s)", describeMemoryLimit(cfg))
+		case exit.ExitCode == exitCodeSIGKILL:
+			return state.EventKilled, "killed (SIGKILL, exit 137)"
+		default:
+			return state.EventCrash, fmt.Sprintf("exited with code %d", exit.ExitCode)
+		}
+	}
+
+	switch {
+	case exit.RestartCount > baselineOf(exit.RestartCount, st.EventCounters.Restarts):
+		restarts := exit.RestartCount - baselineOf(exit.RestartCount, st.EventCounters.Restarts)
+		st.EventCounters.Restarts = exit.RestartCount
+		_, detail := describe()
+		recordEvent(fs, cwd, st, state.Event{
+			Time:     eventTime(exit),
+			Kind:     state.EventRestart,
+			Source:   "container",
+			ExitCode: exit.ExitCode,
+			Message:  fmt.Sprintf("container restarted %d time(s); last exit: %s", restarts, detail),
+		}, w)
+	case status.State == runtime.StateStopped && !exit.FinishedAt.IsZero() && (exit.OOMKilled || !stopExitCode(exit.ExitCode)):
+		kind, detail := describe()
+		e := state.Event{Time: eventTime(exit), Kind: kind, Source: "container", ExitCode: exit.ExitCode, Message: "container " + detail}
+		if n := len(st.Events); n > 0 && st.Events[n-1] == e {
+			return // already reported
+		}
+		recordEvent(fs, cwd, st, e, w)
+	}
+}
+
+// stopExitCode reports exit codes of a container stopped on purpose: a clean
+// exit, or SIGTERM and the SIGKILL that follows when the keep-alive process
+// ignores it.
+func stopExitCode(code int) bool {
+	return code == 0 || code == exitCodeSIGTERM || code == exitCodeSIGKILL
+}
+
+// appliedConfig returns the config the container was created with, falling
+// back to cfg for state written before configs were stored.
+func appliedConfig(st *state.State, cfg *config.Config) *config.Config {
+	if st.Config != nil {
+		return st.Config
+	}
+	return cfg
+}
+
+// baselineOf returns the recorded counter baseline, or 0 when the counter
+// is below it (the container was recreated and its counters restarted).
+func baselineOf(current, recorded int) int {
+	if current < recorded {
+		return 0
+	}
+	return recorded
+}
+
+// eventTime dates an event by when the container stopped, if known.
+func eventTime(exit runtime.ContainerExit) time.Time {
+	if !exit.FinishedAt.IsZero() {
+		return exit.FinishedAt.UTC()
+	}
+	return eventNow()
+}
+
+// describeMemoryLimit names the memory limit an OOM kill hit, with a hint
+// for raising it.
+func describeMemoryLimit(cfg *config.Config) string {
+	if cfg == nil || cfg.Resources.Memory == "" {
+		return "no resources.memory limit set: the host or the runtime's VM ran out of memory"
+	}
+	return fmt.Sprintf("memory limit %s: raise resources.memory in %s", cfg.Resources.Memory, ConfigFilename)
+}
+
+// recordEvent adds e to st, saves the state best-effort and prints e to w.
+// Commands such as run and status load state read-only, so the state is
+// written through fs, the real filesystem.
+func recordEvent(fs afero.Fs, cwd string, st *state.State, e state.Event, w io.Writer) {
+	st.AddEvent(e)
+	EmitEvent(fs, cwd, st, lifecycle.Event{
+		Time:      e.Time,
+		Type:      lifecycle.ContainerExited,
+		Container: st.ContainerName,
+		Message:   e.Message,
+		Details:   map[string]string{"kind": e.Kind, "source": e.Source, "exit_code": strconv.Itoa(e.ExitCode)},
+	})
+	util.ProgressStep(w, "Warning: %s\n", e.Message)
+	if err := state.Save(&util.Env{Fs: fs}, cwd, st); err != nil {
+		util.ProgressStep(w, "Warning: failed to record container event: %v\n", err)
+	}
+}
+
+// EmitEvent appends e, dated now unless set, to the lifecycle events of the
+// project in cwd. Best-effort, and like recordEvent written through fs, the
+// real filesystem.
+func EmitEvent(fs afero.Fs, cwd string, st *state.State, e lifecycle.Event) {
+	if cwd == "" {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = eventNow().UTC()
+	}
+	if st != nil {
+		e.ProjectID = st.ProjectID
+	}
+	_ = lifecycle.Append(fs, cwd, e)
+}
+
+// emitContainerUp records what the up flow did to a container that was in
+// state before: created or started it, which also created its Mutagen
+// sync sessions. A running container was left alone.
+func emitContainerUp(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, st *state.State, cwd string, platform runtime.RuntimePlatform, before runtime.ContainerState) {
+	if before == runtime.StateRunning {
+		return
+	}
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil || status.State != runtime.StateRunning {
+		return
+	}
+	details := map[string]string{"image": status.Image}
+	if before == runtime.StateNotFound {
+		EmitEvent(fs, cwd, st, lifecycle.Event{Type: lifecycle.ContainerCreated, Container: status.Name, Details: details})
+	}
+	EmitEvent(fs, cwd, st, lifecycle.Event{Type: lifecycle.ContainerStarted, Container: status.Name, Details: details})
+	for _, m := range cfg.Mounts {
+		if !runtime.ShouldUseMutagen(platform, m.HasExcludes()) {
+			continue
+		}
+		EmitEvent(fs, cwd, st, lifecycle.Event{
+			Type:      lifecycle.SyncCreated,
+			Container: status.Name,
+			Message:   fmt.Sprintf("%s -> %s", m.Source, m.Target),
+			Details:   map[string]string{"source": m.Source, "target": m.Target},
+		})
+	}
+}
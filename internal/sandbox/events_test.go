@@ -0,0 +1,113 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+type eventsRuntime struct {
+	runtime.StubRuntime
+	oomKills int
+	exit     runtime.ContainerExit
+}
+
+func (r *eventsRuntime) OOMKillCount(context.Context, *runtime.RuntimeEnv, string) (int, error) {
+	return r.oomKills, nil
+}
+
+func (r *eventsRuntime) ContainerExit(context.Context, *runtime.RuntimeEnv, string) (runtime.ContainerExit, error) {
+	return r.exit, nil
+}
+
+func exitErrorWithCode(t *testing.T, code string) error {
+	t.Helper()
+	_, err := util.NewCommandRunner().RunQuiet(context.Background(), "sh", "-c", "exit "+code)
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected an exit error, got %v", err)
+	}
+	return err
+}
+
+func TestSurfaceExecKill(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cwd := "/p1"
+	st := &state.State{
+		ProjectID:     "p1",
+		ContainerName: "alca-p1",
+		Config:        &config.Config{Resources: config.Resources{Memory: "2g"}},
+		EventCounters: state.EventCounters{OOMKills: 1},
+	}
+	rt := &eventsRuntime{oomKills: 2}
+	var out bytes.Buffer
+
+	if surfaceExecKill(context.Background(), fs, nil, rt, cwd, st, nil, "alca-p1", "command", exitErrorWithCode(t, "1"), &out) {
+		t.Error("exit 1 should not be surfaced")
+	}
+
+	if !surfaceExecKill(context.Background(), fs, nil, rt, cwd, st, nil, "alca-p1", "command", exitErrorWithCode(t, "137"), &out) {
+		t.Fatal("exit 137 should be surfaced")
+	}
+	if !strings.Contains(out.String(), "command was killed by the out-of-memory killer (memory limit 2g: raise resources.memory in .alca.toml)") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	saved, err := state.Load(&util.Env{Fs: fs}, cwd)
+	if err != nil || saved == nil {
+		t.Fatalf("state.Load() = %v, %v", saved, err)
+	}
+	if len(saved.Events) != 1 || saved.Events[0].Kind != state.EventOOM || saved.EventCounters.OOMKills != 2 {
+		t.Errorf("saved events = %+v, counters = %+v", saved.Events, saved.EventCounters)
+	}
+
+	// The counter did not move this time: a plain kill
+	out.Reset()
+	surfaceExecKill(context.Background(), fs, nil, rt, cwd, st, nil, "alca-p1", "command", exitErrorWithCode(t, "137"), &out)
+	if st.Events[len(st.Events)-1].Kind != state.EventKilled {
+		t.Errorf("expected a killed event, got %+v", st.Events[len(st.Events)-1])
+	}
+}
+
+func TestCheckContainerExit_Restart(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cwd := "/p1"
+	st := &state.State{ProjectID: "p1", ContainerName: "alca-p1", Config: &config.Config{}}
+	rt := &eventsRuntime{exit: runtime.ContainerExit{OOMKilled: true, ExitCode: 137, RestartCount: 1, FinishedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}}
+	status := runtime.ContainerStatus{State: runtime.StateRunning, Name: "alca-p1"}
+	var out bytes.Buffer
+
+	checkContainerExit(context.Background(), fs, nil, rt, cwd, st, nil, status, &out)
+	if len(st.Events) != 1 || st.Events[0].Kind != state.EventRestart {
+		t.Fatalf("events = %+v, want one restart", st.Events)
+	}
+	if !strings.Contains(out.String(), "container restarted 1 time(s); last exit: killed by the out-of-memory killer (no resources.memory limit set") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	out.Reset()
+	checkContainerExit(context.Background(), fs, nil, rt, cwd, st, nil, status, &out)
+	if len(st.Events) != 1 || out.Len() != 0 {
+		t.Errorf("a seen restart should not be reported again: events %+v, output %q", st.Events, out.String())
+	}
+}
+
+func TestCheckContainerExit_StoppedOnPurpose(t *testing.T) {
+	st := &state.State{ProjectID: "p1", ContainerName: "alca-p1"}
+	rt := &eventsRuntime{exit: runtime.ContainerExit{ExitCode: 137, FinishedAt: time.Now()}}
+	checkContainerExit(context.Background(), afero.NewMemMapFs(), nil, rt, t.TempDir(), st, nil, runtime.ContainerStatus{State: runtime.StateStopped, Name: "alca-p1"}, nil)
+	if len(st.Events) != 0 {
+		t.Errorf("a container stopped with docker stop should not be reported, got %+v", st.Events)
+	}
+}
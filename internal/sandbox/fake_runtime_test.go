@@ -0,0 +1,15 @@
+package sandbox
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func writeFakeConfig(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := afero.WriteFile(afero.NewOsFs(), filepath.Join(dir, ConfigFilename), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// forwardPidFile in .alca holds the PID of the project's forwarder.
+	forwardPidFile = "forward.pid"
+	// ForwardLog is the forwarder's log in .alca/logs.
+	ForwardLog = "forward.log"
+)
+
+// ForwardRoot is a bind-mounted host directory and its container path.
+type ForwardRoot struct {
+	Source string
+	Target string
+}
+
+// ForwardRoots returns the mounts the runtime bind-mounts on platform.
+// Mutagen-synced mounts are left out.
+func ForwardRoots(cfg *config.Config, projectDir string, platform runtime.RuntimePlatform) []ForwardRoot {
+	var roots []ForwardRoot
+	for _, m := range cfg.Mounts {
+		if runtime.ShouldUseMutagen(platform, m.HasExcludes()) {
+			continue
+		}
+		source := m.Source
+		if source == "." {
+			source = projectDir
+		} else if !filepath.IsAbs(source) {
+			source = filepath.Join(projectDir, source)
+		}
+		roots = append(roots, ForwardRoot{Source: source, Target: m.Target})
+	}
+	return roots
+}
+
+// ForwardPidPath is the pid file of the project's forwarder.
+func ForwardPidPath(projectDir string) string {
+	return filepath.Join(state.StateDirPath(projectDir), forwardPidFile)
+}
+
+// spawnFileEventForwarder starts 'alca forward-events' for projectDir in
+// the background.
+func spawnFileEventForwarder(env *util.Env, projectDir string) error {
+	return spawnBackground(env, projectDir, filepath.Join(state.StateDirPath(projectDir), "logs", ForwardLog), "forward-events")
+}
+
+// ensureFileEventForwarder starts the project's forwarder when
+// forward_file_events is set, the container runs in a macOS VM, there are
+// bind mounts to watch and none is running. Failures are warnings: the
+// container is usable.
+func ensureFileEventForwarder(env *util.Env, cfg *config.Config, projectDir string, platform runtime.RuntimePlatform, out io.Writer) {
+	if !cfg.ForwardFileEvents || !runtime.IsDarwin(platform) || len(ForwardRoots(cfg, projectDir, platform)) == 0 {
+		return
+	}
+	if _, ok := RunningPID(env.Fs, ForwardPidPath(projectDir)); ok {
+		return
+	}
+	if err := spawnFileEventForwarder(env, projectDir); err != nil {
+		util.ProgressStep(out, "Warning: failed to start the file event forwarder: %v\n", err)
+		return
+	}
+	util.ProgressStep(out, "Started file event forwarder\n")
+}
+
+// stopFileEventForwarder stops the project's forwarder, if one is running.
+func stopFileEventForwarder(fs afero.Fs, projectDir string) error {
+	pid, ok := RunningPID(fs, ForwardPidPath(projectDir))
+	if !ok {
+		return nil
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to stop file event forwarder (pid %d): %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package sandbox
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestForwardRoots(t *testing.T) {
+	cfg := &config.Config{Mounts: []config.MountConfig{
+		{Source: ".", Target: "/workspace"},
+		{Source: "../lib", Target: "/lib"},
+		{Source: "/data", Target: "/data", Exclude: []string{"tmp/"}},
+	}}
+
+	want := []ForwardRoot{{Source: "/p", Target: "/workspace"}, {Source: "/lib", Target: "/lib"}}
+	if got := ForwardRoots(cfg, "/p", runtime.PlatformMacOrbStack); !slices.Equal(got, want) {
+		t.Errorf("OrbStack roots = %v, want %v", got, want)
+	}
+	// Docker Desktop syncs every mount with Mutagen
+	if got := ForwardRoots(cfg, "/p", runtime.PlatformMacDockerDesktop); len(got) != 0 {
+		t.Errorf("Docker Desktop roots = %v, want none", got)
+	}
+}
+
+func TestEnsureFileEventForwarder(t *testing.T) {
+	env := util.NewTestEnv()
+	mock := env.Cmd.(*util.MockCommandRunner)
+	mock.ExpectMatch("* forward-events", nil, nil)
+	cfg := &config.Config{ForwardFileEvents: true, Mounts: []config.MountConfig{{Source: ".", Target: "/workspace"}}}
+	var out bytes.Buffer
+	// Linux bind mounts deliver inotify events themselves
+	ensureFileEventForwarder(env, cfg, "/p", runtime.PlatformLinux, &out)
+	ensureFileEventForwarder(env, &config.Config{Mounts: cfg.Mounts}, "/p", runtime.PlatformMacOrbStack, &out)
+	if len(mock.Calls) != 0 {
+		t.Fatalf("started %v, want nothing", mock.Calls)
+	}
+	ensureFileEventForwarder(env, cfg, "/p", runtime.PlatformMacOrbStack, &out)
+	if len(mock.Calls) != 1 || mock.Calls[0].Dir != "/p" || mock.Calls[0].LogFile != "/p/.alca/logs/"+ForwardLog {
+		t.Errorf("started %+v, want the forwarder in /p", mock.Calls)
+	}
+}
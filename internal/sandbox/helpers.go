@@ -0,0 +1,474 @@
+package sandbox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/afero"
+	"golang.org/x/term"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/transact"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Common error messages.
+const (
+	ErrMsgConfigNotFound = "configuration not found: run 'alca init' first"
+	ErrMsgStateNotFound  = "no state file found: run 'alca up' first"
+	ErrMsgNotRunning     = "container is not running: run 'alca up' first"
+	ErrMsgPaused         = "container is paused: run 'alca resume' first"
+)
+
+// LoadConfigFromCwd loads configuration from the current working directory.
+// Returns the config and config path, or an error with user-friendly message.
+func LoadConfigFromCwd(ctx context.Context, env *util.Env, cwd string) (*config.Config, string, error) {
+	return loadConfigFromCwdWithOverrides(ctx, env, cwd, nil)
+}
+
+// loadConfigFromCwdWithOverrides is LoadConfigFromCwd with --set overrides applied.
+func loadConfigFromCwdWithOverrides(ctx context.Context, env *util.Env, cwd string, overrides []config.Override) (*config.Config, string, error) {
+	configPath := filepath.Join(cwd, ConfigFilename)
+	cfg, err := config.LoadConfigWithOverrides(env, configPath, config.StrictExpandEnv, overrides, ConfigLoadOptions(ctx, env)...)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, configPath, errors.New(ErrMsgConfigNotFound)
+		}
+		return nil, configPath, fmt.Errorf("failed to load config: %w", err)
+	}
+	return &cfg, configPath, nil
+}
+
+// loadConfigOptional loads configuration, returning zero config if not found.
+// Use this for commands that can work without a config file.
+func loadConfigOptional(ctx context.Context, env *util.Env, cwd string) (*config.Config, string) {
+	configPath := filepath.Join(cwd, ConfigFilename)
+	cfg, _ := config.LoadConfig(env, configPath, config.StrictExpandEnv, ConfigLoadOptions(ctx, env)...)
+	return &cfg, configPath
+}
+
+// LoadConfigAndRuntime loads config and selects the appropriate runtime.
+// This is the most common pattern for commands that need both.
+func LoadConfigAndRuntime(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, cwd string) (*config.Config, runtime.Runtime, error) {
+	cfg, _, err := LoadConfigFromCwd(ctx, env, cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to select runtime: %w", err)
+	}
+
+	return cfg, rt, nil
+}
+
+// LoadConfigAndRuntimeOptional loads config (optional) and selects runtime.
+// Use for commands like 'list' and 'cleanup' that work without config.
+func LoadConfigAndRuntimeOptional(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, cwd string) (*config.Config, runtime.Runtime, error) {
+	cfg, _ := loadConfigOptional(ctx, env, cwd)
+
+	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, cfg)
+	if err != nil {
+		return cfg, nil, fmt.Errorf("failed to select runtime: %w", err)
+	}
+
+	return cfg, rt, nil
+}
+
+// LoadRequiredState loads state file and returns error if not found.
+// Use for commands that require an existing container state.
+func LoadRequiredState(env *util.Env, cwd string) (*state.State, error) {
+	st, err := state.Load(env, cwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	if st == nil {
+		return nil, errors.New(ErrMsgStateNotFound)
+	}
+	return st, nil
+}
+
+// LoadStateOptional loads state file, returning nil without error if not found.
+// Use for commands where missing state is acceptable (e.g., down).
+func LoadStateOptional(env *util.Env, cwd string) (*state.State, error) {
+	st, err := state.Load(env, cwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	return st, nil
+}
+
+// hotApplyNote marks drift lines that 'alca up' applies without a rebuild.
+const hotApplyNote = "(applied by 'alca up', no rebuild)"
+
+// DisplayConfigDrift prints configuration drift information to the writer.
+// Returns true if there was any drift to display.
+func DisplayConfigDrift(w io.Writer, drift *state.DriftChanges, runtimeChanged bool, oldRuntime, newRuntime string) bool {
+	if drift == nil && !runtimeChanged {
+		return false
+	}
+
+	_, _ = fmt.Fprintln(w, "Configuration has changed since last container creation:")
+
+	if runtimeChanged {
+		_, _ = fmt.Fprintf(w, "  Runtime: %s → %s\n", oldRuntime, newRuntime)
+	}
+
+	if drift != nil {
+		if drift.Image != nil {
+			_, _ = fmt.Fprintf(w, "  Image: %s → %s\n", drift.Image[0], drift.Image[1])
+		}
+		if drift.Platform != nil {
+			_, _ = fmt.Fprintf(w, "  Platform: %s → %s\n", DisplayOrDefault(drift.Platform[0]), DisplayOrDefault(drift.Platform[1]))
+		}
+		if drift.RuntimeContext != nil {
+			_, _ = fmt.Fprintf(w, "  Runtime context: %s → %s\n", DisplayOrDefault(drift.RuntimeContext[0]), DisplayOrDefault(drift.RuntimeContext[1]))
+		}
+		if drift.NestedContainers != nil {
+			_, _ = fmt.Fprintf(w, "  Nested containers: %s → %s\n", drift.NestedContainers[0], drift.NestedContainers[1])
+		}
+		if drift.Userns != nil {
+			_, _ = fmt.Fprintf(w, "  Userns: %s → %s\n", DisplayOrDefault(drift.Userns[0]), DisplayOrDefault(drift.Userns[1]))
+		}
+		if drift.Mounts {
+			_, _ = fmt.Fprintf(w, "  Mounts: changed\n")
+		}
+		if drift.Workdir != nil {
+			_, _ = fmt.Fprintf(w, "  Workdir: %s → %s\n", drift.Workdir[0], drift.Workdir[1])
+		}
+		if drift.WorkdirSource != nil {
+			_, _ = fmt.Fprintf(w, "  Workdir source: %s → %s\n", DisplayOrDefault(drift.WorkdirSource[0]), DisplayOrDefault(drift.WorkdirSource[1]))
+		}
+		if drift.WorkdirExclude {
+			_, _ = fmt.Fprintf(w, "  Workdir exclude: changed\n")
+		}
+		if drift.CommandUp != nil {
+			_, _ = fmt.Fprintf(w, "  Commands.up: changed\n")
+		}
+		resourcesNote := ""
+		if drift.ResourcesHotApplicable() {
+			resourcesNote = " " + hotApplyNote
+		}
+		if drift.Memory != nil {
+			_, _ = fmt.Fprintf(w, "  Resources.memory: %s → %s%s\n", DisplayOrDefault(drift.Memory[0]), DisplayOrDefault(drift.Memory[1]), resourcesNote)
+		}
+		if drift.CPUs != nil {
+			_, _ = fmt.Fprintf(w, "  Resources.cpus: %d → %d%s\n", drift.CPUs[0], drift.CPUs[1], resourcesNote)
+		}
+		if drift.CPUWeight != nil {
+			_, _ = fmt.Fprintf(w, "  Resources.cgroup.cpu_weight: %d → %d%s\n", drift.CPUWeight[0], drift.CPUWeight[1], resourcesNote)
+		}
+		if drift.CpusetCPUs != nil {
+			_, _ = fmt.Fprintf(w, "  Resources.cgroup.cpuset_cpus: %s → %s%s\n", DisplayOrDefault(drift.CpusetCPUs[0]), DisplayOrDefault(drift.CpusetCPUs[1]), resourcesNote)
+		}
+		if drift.CpusetMems != nil {
+			_, _ = fmt.Fprintf(w, "  Resources.cgroup.cpuset_mems: %s → %s%s\n", DisplayOrDefault(drift.CpusetMems[0]), DisplayOrDefault(drift.CpusetMems[1]), resourcesNote)
+		}
+		if drift.CgroupParent != nil {
+			_, _ = fmt.Fprintf(w, "  Resources.cgroup.parent: %s → %s\n", DisplayOrDefault(drift.CgroupParent[0]), DisplayOrDefault(drift.CgroupParent[1]))
+		}
+		if drift.IOLatency != nil {
+			_, _ = fmt.Fprintf(w, "  Resources.cgroup.io_latency: %s → %s\n", DisplayOrDefault(drift.IOLatency[0]), DisplayOrDefault(drift.IOLatency[1]))
+		}
+		if drift.Envs {
+			_, _ = fmt.Fprintf(w, "  Envs: changed\n")
+		}
+		if drift.Ports {
+			_, _ = fmt.Fprintf(w, "  Ports: changed\n")
+		}
+		if drift.Caps {
+			_, _ = fmt.Fprintf(w, "  Caps: changed\n")
+		}
+		if drift.SSH {
+			_, _ = fmt.Fprintf(w, "  SSH: changed\n")
+		}
+		if drift.Isolation != nil {
+			_, _ = fmt.Fprintf(w, "  Network.isolation: %s → %s", drift.Isolation[0], drift.Isolation[1])
+			if drift.Isolation[0] != config.IsolationNone && drift.Isolation[1] != config.IsolationNone {
+				_, _ = fmt.Fprintf(w, " %s", hotApplyNote)
+			}
+			_, _ = fmt.Fprintln(w)
+		}
+		if drift.LANAccess {
+			_, _ = fmt.Fprintf(w, "  Network.lan-access: changed %s\n", hotApplyNote)
+		}
+		if drift.Proxy != nil {
+			_, _ = fmt.Fprintf(w, "  Network.proxy: %s → %s %s\n", DisplayOrDefault(drift.Proxy[0]), DisplayOrDefault(drift.Proxy[1]), hotApplyNote)
+		}
+		if drift.Shaping {
+			_, _ = fmt.Fprintf(w, "  Network.shaping: changed %s\n", hotApplyNote)
+		}
+		if drift.LogConnections != nil {
+			_, _ = fmt.Fprintf(w, "  Network.log_connections: %t → %t %s\n", drift.LogConnections[0], drift.LogConnections[1], hotApplyNote)
+		}
+		if drift.HooksPostUp != nil {
+			_, _ = fmt.Fprintf(w, "  Hooks.post_up: changed\n")
+		}
+		if drift.HooksPreDown != nil {
+			_, _ = fmt.Fprintf(w, "  Hooks.pre_down: changed\n")
+		}
+	}
+
+	return true
+}
+
+// driftSummary names the drifted settings as DisplayConfigDrift labels
+// them, comma-separated (e.g. "envs, network.lan-access"). Empty without
+// drift.
+func driftSummary(drift *state.DriftChanges, runtimeChanged bool, oldRuntime, newRuntime string) string {
+	var buf strings.Builder
+	if !DisplayConfigDrift(&buf, drift, runtimeChanged, oldRuntime, newRuntime) {
+		return ""
+	}
+	var names []string
+	// The first line is the heading
+	for _, line := range strings.Split(buf.String(), "\n")[1:] {
+		if name, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// displayConfigDiff prints a unified diff of the config the container was
+// created with against the current config, colored when w is a terminal.
+// Best-effort: nothing is printed when either side can't be serialized.
+func displayConfigDiff(w io.Writer, stored, current *config.Config) {
+	if stored == nil || current == nil {
+		return
+	}
+	oldText, err := stored.EncodeTOML()
+	if err != nil {
+		return
+	}
+	newText, err := current.EncodeTOML()
+	if err != nil {
+		return
+	}
+	diff := util.Redact(util.UnifiedDiff("container config", "current .alca.toml", oldText, newText, 3))
+	if diff == "" {
+		return
+	}
+
+	renderer := lipgloss.NewRenderer(w)
+	removed := renderer.NewStyle().Foreground(lipgloss.Color("1"))
+	added := renderer.NewStyle().Foreground(lipgloss.Color("2"))
+	hunk := renderer.NewStyle().Foreground(lipgloss.Color("6"))
+	header := renderer.NewStyle().Bold(true)
+
+	_, _ = fmt.Fprintln(w)
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			line = header.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = hunk.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = removed.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = added.Render(line)
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+// DisplayOrDefault shows an empty setting as "(default)" in drift output.
+func DisplayOrDefault(s string) string {
+	if s == "" {
+		return "(default)"
+	}
+	return s
+}
+
+// FindProjectDirFrom walks up from startDir looking for ConfigFilename.
+// Returns startDir if not found.
+func FindProjectDirFrom(fs afero.Fs, startDir string) string {
+	dir := startDir
+	for {
+		info, err := fs.Stat(filepath.Join(dir, ConfigFilename))
+		if err == nil && !info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// Reached filesystem root — return original startDir
+			return startDir
+		}
+		dir = parent
+	}
+}
+
+// promptConfirm prompts the user for confirmation.
+// Returns false immediately when stdin is not a terminal (CI, scripts, piped input)
+// so that non-interactive invocations never block waiting for input.
+func promptConfirm(prompt string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	fmt.Printf("%s [y/N] ", prompt)
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes"
+}
+
+// CommitWithSudo commits TransactFs changes, intelligently grouping operations
+// by sudo requirement and executing each group with the appropriate method.
+// If any operation requires sudo, the msg is printed first to explain why.
+func CommitWithSudo(ctx context.Context, env *util.Env, tfs *transact.TransactFs, out io.Writer, msg string) error {
+	_, err := tfs.Commit(func(commitCtx transact.CommitContext) (*transact.CommitOpsResult, error) {
+		if len(commitCtx.Ops) == 0 {
+			return nil, nil
+		}
+
+		// Check if any op needs sudo and output explanation
+		if out != nil {
+			for _, op := range commitCtx.Ops {
+				if op.NeedSudo {
+					if msg != "" {
+						util.ProgressStep(out, "%s (sudo required)...\n", msg)
+						break
+					}
+
+					// Not expected to happen, so we need to print the path
+					util.ProgressStep(out, "Writing to %s (sudo required)...\n", op.Path)
+				}
+			}
+		}
+
+		// Group operations by NeedSudo while preserving order
+		groups := transact.GroupOpsBySudo(commitCtx.Ops)
+
+		// Execute each group with the appropriate method
+		if err := transact.ExecuteGroupedOps(commitCtx.BaseFs, groups, func(script string) error {
+			return env.Cmd.SudoRunScriptQuiet(ctx, script)
+		}); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	})
+	return err
+}
+
+// CommitIfNeeded checks if there are pending changes and commits them with sudo support.
+// The msg is only printed if sudo is actually required.
+func CommitIfNeeded(ctx context.Context, env *util.Env, tfs *transact.TransactFs, out io.Writer, msg string) error {
+	if !tfs.NeedsCommit() {
+		return nil
+	}
+	return CommitWithSudo(ctx, env, tfs, out, msg)
+}
+
+// Deps holds shared dependencies for flows that perform writes.
+type Deps struct {
+	Tfs        *transact.TransactFs
+	CmdRunner  util.CommandRunner
+	Env        *util.Env
+	RuntimeEnv *runtime.RuntimeEnv
+}
+
+// NewDeps creates the shared transactional dependencies over host, the
+// real filesystem and command runner: writes are staged and committed to
+// host.Fs.
+func NewDeps(host *util.Env) Deps {
+	tfs := transact.New(transact.WithActualFs(host.Fs))
+	return Deps{
+		Tfs:        tfs,
+		CmdRunner:  host.Cmd,
+		Env:        &util.Env{Fs: tfs, Cmd: host.Cmd},
+		RuntimeEnv: &runtime.RuntimeEnv{Cmd: host.Cmd, Fs: host.Fs},
+	}
+}
+
+// ReadDeps holds shared dependencies for read-only flows.
+type ReadDeps struct {
+	CmdRunner  util.CommandRunner
+	Env        *util.Env
+	RuntimeEnv *runtime.RuntimeEnv
+}
+
+// NewReadDeps creates shared dependencies for reading through host.
+func NewReadDeps(host *util.Env) ReadDeps {
+	return ReadDeps{
+		CmdRunner:  host.Cmd,
+		Env:        &util.Env{Fs: afero.NewReadOnlyFs(host.Fs), Cmd: host.Cmd},
+		RuntimeEnv: &runtime.RuntimeEnv{Cmd: host.Cmd, Fs: host.Fs},
+	}
+}
+
+// CheckProjectPathConsistency detects when a project directory has been moved
+// since the container was created. Returns an error with instructions if the
+// container's recorded path differs from the current working directory.
+func CheckProjectPathConsistency(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, cwd string, cfg *config.Config) error {
+	oldDir, err := movedProjectDir(ctx, runtimeEnv, rt, st, cwd)
+	if err != nil || oldDir == "" {
+		return err
+	}
+
+	msg := fmt.Sprintf("project directory has moved from %s to %s\n\n"+
+		"The container was created in a different directory. Run 'alca up' to recreate it for the new directory\n"+
+		"(volumes are kept), or 'alca down' to remove it.",
+		oldDir, cwd)
+
+	// Check if mutagen/sync might be active
+	if cfg != nil && cfg.HasMutagenSync() {
+		msg += "\n\nNote: the old directory may have been recreated by file sync (mutagen). Check and clean up if needed."
+	}
+
+	return fmt.Errorf("%s: %w", msg, ErrProjectPathMismatch)
+}
+
+// movedProjectDir returns the directory the project's container was created
+// in when it is not cwd, i.e. the project directory was moved or renamed
+// since; "" when they match or there is no container.
+func movedProjectDir(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, cwd string) (string, error) {
+	if st == nil {
+		return "", nil
+	}
+
+	containers, err := rt.ListContainers(ctx, runtimeEnv)
+	if err != nil {
+		return "", fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	// Find the container matching this project. An unlabeled container
+	// (e.g. adopted) records no path to compare.
+	for _, c := range containers {
+		if c.ProjectID == st.ProjectID && c.ProjectPath != "" && c.ProjectPath != cwd {
+			return c.ProjectPath, nil
+		}
+	}
+	return "", nil
+}
+
+// runHook executes a host-side lifecycle hook command via "sh -c".
+// The command runs in the project directory, writing its output to out.
+// Returns nil if hook is empty (no-op).
+func runHook(ctx context.Context, cmdRunner util.CommandRunner, hook string, cwd string, out io.Writer) error {
+	if hook == "" {
+		return nil
+	}
+	return cmdRunner.RunInDir(ctx, out, cwd, "sh", "-c", hook)
+}
+
+// ProgressFunc returns a progress callback that writes to the given writer.
+func ProgressFunc(w io.Writer) func(format string, args ...any) {
+	return func(format string, args ...any) {
+		util.ProgressStep(w, format, args...)
+	}
+}
@@ -0,0 +1,34 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/history"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestRecordHistory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Now()
+
+	RecordHistory(fs, "/project", now, []string{"true"}, nil)
+	_, exitErr := util.NewCommandRunner().RunQuiet(context.Background(), "sh", "-c", "exit 3")
+	RecordHistory(fs, "/project", now, []string{"false"}, exitErr)
+	RecordHistory(fs, "/project", now, []string{"missing"}, errors.New("failed to start"))
+
+	entries, err := history.Load(fs, "/project")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries (start failure not recorded), got %d", len(entries))
+	}
+	if entries[0].ExitCode != 0 || entries[1].ExitCode != 3 {
+		t.Errorf("exit codes = %d, %d; want 0, 3", entries[0].ExitCode, entries[1].ExitCode)
+	}
+}
@@ -0,0 +1,59 @@
+package sandbox
+
+import (
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+const (
+	// useHeartbeat is how often an open enter or run session records use,
+	// well below config.MinIdleTimeout.
+	useHeartbeat = 5 * time.Minute
+	// EnvNoIdleCheck disables the background idle check, e.g. when a timer
+	// runs 'alca stop-idle' instead.
+	EnvNoIdleCheck = "ALCA_NO_IDLE_CHECK"
+)
+
+// WasIdleStopped reports whether 'alca stop-idle' stopped the project's
+// container since it was last used.
+func WasIdleStopped(env *util.Env, cwd string) bool {
+	usage, err := state.LoadUsage(env, cwd)
+	return err == nil && usage != nil && !usage.IdleStoppedAt.IsZero()
+}
+
+// TrackUse records use of the project's container now and every
+// useHeartbeat until the returned function is called, which records the
+// end of use. Failures are ignored: a missed record at worst stops the
+// container early, and enter restarts it.
+func TrackUse(env *util.Env, cwd string) func() {
+	recordUse(env, cwd, time.Now())
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(useHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				recordUse(env, cwd, now)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		recordUse(env, cwd, time.Now())
+	}
+}
+
+// recordUse marks the project's container used at now, best-effort.
+func recordUse(env *util.Env, cwd string, now time.Time) {
+	_ = state.RecordUse(env, cwd, now)
+}
@@ -0,0 +1,65 @@
+package sandbox
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/network"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// applyLANAccessGrants resolves temporary lan-access rules against the expiry
+// times recorded in state:
+//   - ttl rules from the config get their expiry anchored on first use, so
+//     later ups do not extend them; once expired they are skipped
+//   - grants from 'alca network allow' are appended until they expire, then
+//     pruned from state
+//
+// expand resolves alca tokens in grant rules. The returned rules carry
+// Expires so the firewall can enforce the deadline itself.
+func applyLANAccessGrants(st *state.State, rules []network.LANAccessRule, now time.Time, expand func(string) (string, error), out io.Writer) ([]network.LANAccessRule, error) {
+	configTTL := make(map[string]bool)
+	var result []network.LANAccessRule
+	for _, r := range rules {
+		if r.TTL == 0 {
+			result = append(result, r)
+			continue
+		}
+		key := r.Key()
+		configTTL[key] = true
+		g, ok := st.Grant(key)
+		if !ok {
+			g = state.LANAccessGrant{Rule: key, Expires: now.Add(r.TTL)}
+			st.SetGrant(key, g.Expires)
+		}
+		if g.Expired(now) {
+			util.ProgressStep(out, "lan-access %s expired at %s, not applied\n", key, g.Expires.Format(time.DateTime))
+			continue
+		}
+		r.Expires = g.Expires
+		result = append(result, r)
+	}
+
+	for _, g := range st.PruneGrants(now, configTTL) {
+		util.ProgressStep(out, "Removed expired lan-access grant %s\n", g.Rule)
+	}
+
+	for _, g := range st.LANAccessGrants {
+		if configTTL[g.Rule] {
+			continue
+		}
+		expanded, err := expand(g.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("expanding lan-access grant %q: %w", g.Rule, err)
+		}
+		r, err := network.ParseLANAccessRule(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lan-access grant in state: %w", err)
+		}
+		r.Expires = g.Expires
+		result = append(result, r)
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package sandbox
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/network"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+func TestFirewallFeatures(t *testing.T) {
+	tests := []struct {
+		isolation, proxy, logging bool
+		want                      string
+	}{
+		{true, false, false, "Network isolation"},
+		{true, true, false, "Network isolation and transparent proxy"},
+		{false, true, false, "Transparent proxy"},
+		{false, false, true, "Connection logging"},
+	}
+	for _, tt := range tests {
+		if got := firewallFeatures(tt.isolation, tt.proxy, tt.logging); got != tt.want {
+			t.Errorf("firewallFeatures(%v, %v, %v) = %q, want %q", tt.isolation, tt.proxy, tt.logging, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLANAccessGrants(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	noExpand := func(s string) (string, error) { return s, nil }
+
+	rules, err := network.ParseLANAccessRules([]string{"10.0.0.1", "192.168.1.50:5432 ttl=2h", "192.168.1.60 ttl=1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &state.State{LANAccessGrants: []state.LANAccessGrant{
+		{Rule: "192.168.1.60", Expires: now.Add(-time.Minute)},          // config ttl rule, already expired
+		{Rule: "udp://10.0.0.2:53", Expires: now.Add(30 * time.Minute)}, // from alca network allow
+		{Rule: "10.0.0.3", Expires: now.Add(-time.Hour)},                // expired grant
+	}}
+
+	var out bytes.Buffer
+	got, err := applyLANAccessGrants(st, rules, now, noExpand, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expires := make(map[string]time.Time)
+	var keys []string
+	for _, r := range got {
+		keys = append(keys, r.Key())
+		expires[r.Key()] = r.Expires
+	}
+	if want := []string{"10.0.0.1", "192.168.1.50:5432", "udp://10.0.0.2:53"}; !slices.Equal(keys, want) {
+		t.Errorf("rules = %v, want %v", keys, want)
+	}
+	if !expires["10.0.0.1"].IsZero() {
+		t.Error("permanent rules should not expire")
+	}
+	if !expires["192.168.1.50:5432"].Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("config ttl rule expires %v, want now+2h", expires["192.168.1.50:5432"])
+	}
+	if !expires["udp://10.0.0.2:53"].Equal(now.Add(30 * time.Minute)) {
+		t.Errorf("allow grant expires %v, want now+30m", expires["udp://10.0.0.2:53"])
+	}
+
+	// The new anchor is recorded; the expired config rule is kept so its ttl is not restarted
+	if g, ok := st.Grant("192.168.1.50:5432"); !ok || !g.Expires.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("config ttl rule should be anchored in state, got %+v", st.LANAccessGrants)
+	}
+	if _, ok := st.Grant("192.168.1.60"); !ok {
+		t.Error("expired config ttl grant should stay in state")
+	}
+	if _, ok := st.Grant("10.0.0.3"); ok {
+		t.Error("expired allow grant should be pruned")
+	}
+	if !strings.Contains(out.String(), "192.168.1.60 expired") || !strings.Contains(out.String(), "Removed expired lan-access grant 10.0.0.3") {
+		t.Errorf("unexpected progress output:\n%s", out.String())
+	}
+
+	// A later up keeps the original anchor instead of extending it
+	got, _ = applyLANAccessGrants(st, rules, now.Add(time.Hour), noExpand, &bytes.Buffer{})
+	for _, r := range got {
+		if r.Key() == "192.168.1.50:5432" && !r.Expires.Equal(now.Add(2*time.Hour)) {
+			t.Errorf("ttl anchor moved to %v", r.Expires)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ResumeProject thaws the paused container of the project. A running
+// container is reported, not rejected.
+func ResumeProject(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, cwd string, out io.Writer) error {
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+	switch status.State {
+	case runtime.StateRunning:
+		util.ProgressStep(out, "Container %s is not paused\n", status.Name)
+		return nil
+	case runtime.StatePaused:
+	default:
+		return errors.New(ErrMsgNotRunning)
+	}
+
+	if err := rt.Unpause(ctx, runtimeEnv, cwd, st); err != nil {
+		return fmt.Errorf("failed to resume container: %w", err)
+	}
+	EmitEvent(fs, cwd, st, lifecycle.Event{Type: lifecycle.ContainerResumed, Container: status.Name})
+	util.ProgressDone(out, "Resumed container %s\n", status.Name)
+	return nil
+}
@@ -0,0 +1,88 @@
+package sandbox
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bolasblack/alcatraz/internal/network"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// connectPeers joins the container to the network it shares with each
+// project in network.peers, and connects the peer's container too when it is
+// running, so the link holds whichever side lists the other. Each container
+// is reachable there by its project directory name. Returns allow rules for
+// the shared subnets. Failures are warnings: a missing peer must not keep
+// the project from starting.
+func connectPeers(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, projectDir string, st *state.State, peers []string, out io.Writer) []network.LANAccessRule {
+	var rules []network.LANAccessRule
+	for _, peer := range peers {
+		peerDir := peer
+		if !filepath.IsAbs(peerDir) {
+			peerDir = filepath.Join(projectDir, peerDir)
+		}
+		peerDir = filepath.Clean(peerDir)
+		netName := runtime.PeerNetworkName(projectDir, peerDir)
+
+		subnet, err := rt.ConnectPeerNetwork(ctx, runtimeEnv, st.ContainerName, netName, peerAlias(projectDir))
+		if err != nil {
+			util.ProgressStep(out, "Warning: peer %s: %v\n", peer, err)
+			continue
+		}
+		rule, err := network.ParseLANAccessRule(subnet)
+		if err != nil {
+			util.ProgressStep(out, "Warning: peer %s: unexpected subnet %q: %v\n", peer, subnet, err)
+			continue
+		}
+		rules = append(rules, rule)
+
+		if connectPeerContainer(ctx, env, runtimeEnv, rt, peerDir, netName, out) {
+			util.ProgressStep(out, "Connected to peer %s on %s\n", peerAlias(peerDir), netName)
+		} else {
+			util.ProgressStep(out, "Peer %s is not running; it joins %s on its next 'alca up' if it lists this project in network.peers\n", peerAlias(peerDir), netName)
+		}
+	}
+	return rules
+}
+
+// connectPeerContainer attaches the peer project's running container to the
+// shared network. Reports false when the peer has no running container.
+func connectPeerContainer(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, peerDir, netName string, out io.Writer) bool {
+	peerSt, err := state.Load(env, peerDir)
+	if err != nil || peerSt == nil {
+		return false
+	}
+	status, err := rt.Status(ctx, runtimeEnv, peerDir, peerSt)
+	if err != nil || status.State != runtime.StateRunning {
+		return false
+	}
+	if _, err := rt.ConnectPeerNetwork(ctx, runtimeEnv, status.Name, netName, peerAlias(peerDir)); err != nil {
+		util.ProgressStep(out, "Warning: failed to connect peer %s: %v\n", peerAlias(peerDir), err)
+		return false
+	}
+	return true
+}
+
+// peerAlias turns a project directory into the DNS name its container has on
+// peer networks: the lowercased base name, other characters replaced by "-".
+func peerAlias(projectDir string) string {
+	alias := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, filepath.Base(projectDir))
+	alias = strings.Trim(alias, "-")
+	if alias == "" {
+		return "peer"
+	}
+	return alias
+}
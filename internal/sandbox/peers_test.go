@@ -0,0 +1,19 @@
+package sandbox
+
+import (
+	"testing"
+)
+
+func TestPeerAlias(t *testing.T) {
+	tests := map[string]string{
+		"/src/backend":     "backend",
+		"/src/My_Frontend": "my-frontend",
+		"/src/api.v2":      "api-v2",
+		"/src/__":          "peer",
+	}
+	for dir, want := range tests {
+		if got := peerAlias(dir); got != want {
+			t.Errorf("peerAlias(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// PluginPrefix is the executable name prefix for plugins (git-style: alca-<name>).
+const PluginPrefix = "alca-"
+
+// Plugin lifecycle events, passed as `alca-<name> hook <event>`.
+const (
+	pluginEventPostUp  = "post_up"
+	pluginEventPreDown = "pre_down"
+)
+
+// Environment variables that carry project context to plugins.
+const (
+	EnvPluginProjectDir    = "ALCA_PROJECT_DIR"
+	EnvPluginProjectID     = "ALCA_PROJECT_ID"
+	EnvPluginContainerName = "ALCA_CONTAINER_NAME"
+	EnvPluginRuntime       = "ALCA_RUNTIME"
+	// EnvPluginConfig holds the merged config as JSON, using .alca.toml field names.
+	EnvPluginConfig = "ALCA_CONFIG"
+)
+
+// PluginContext is the project context passed to a plugin.
+// Fields are empty when invoked outside a project or before the first 'alca up'.
+type PluginContext struct {
+	ProjectDir string
+	Config     *config.Config
+	State      *state.State
+}
+
+// Environ returns the context as KEY=value pairs to add to the plugin's
+// environment.
+func (pc PluginContext) Environ() []string {
+	var env []string
+	if pc.ProjectDir != "" {
+		env = append(env, EnvPluginProjectDir+"="+pc.ProjectDir)
+	}
+	if pc.State != nil {
+		env = append(env,
+			EnvPluginProjectID+"="+pc.State.ProjectID,
+			EnvPluginContainerName+"="+pc.State.ContainerName,
+			EnvPluginRuntime+"="+pc.State.Runtime,
+		)
+	}
+	if pc.Config != nil {
+		if data, err := json.Marshal(pc.Config.ToRaw()); err == nil {
+			env = append(env, EnvPluginConfig+"="+string(data))
+		}
+	}
+	return env
+}
+
+// runPluginHooks notifies each configured plugin of a lifecycle event by
+// running `alca-<name> hook <event>` in the project directory.
+func runPluginHooks(ctx context.Context, cmdRunner util.CommandRunner, cfg *config.Config, st *state.State, cwd string, event string, out io.Writer) error {
+	if len(cfg.Plugins) == 0 {
+		return nil
+	}
+
+	environ := PluginContext{ProjectDir: cwd, Config: cfg, State: st}.Environ()
+	for _, name := range cfg.Plugins {
+		path, err := exec.LookPath(PluginPrefix + name)
+		if err != nil {
+			return fmt.Errorf("plugin %q: %s%s not found on PATH", name, PluginPrefix, name)
+		}
+		util.ProgressStep(out, "Running %s hook of plugin %s...\n", event, name)
+		if err := ExecPlugin(ctx, cmdRunner, path, []string{"hook", event}, cwd, environ); err != nil {
+			return fmt.Errorf("plugin %q %s hook failed: %w", name, event, err)
+		}
+	}
+	return nil
+}
+
+// ExecPlugin runs a plugin executable with cmdRunner, attached to this
+// process's stdio since plugins are interactive user programs, with environ
+// (see PluginContext.Environ) added to its environment.
+func ExecPlugin(ctx context.Context, cmdRunner util.CommandRunner, path string, args []string, dir string, environ []string) error {
+	return cmdRunner.RunAttached(ctx, dir, environ, path, args...)
+}
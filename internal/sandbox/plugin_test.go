@@ -0,0 +1,77 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestPluginContextEnviron(t *testing.T) {
+	pc := PluginContext{
+		ProjectDir: "/project",
+		Config:     &config.Config{Image: "ubuntu:latest", Plugins: []string{"notify"}},
+		State:      &state.State{ProjectID: "abc", ContainerName: "alca-abc", Runtime: "Docker"},
+	}
+
+	vars := make(map[string]string)
+	for _, kv := range pc.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		vars[k] = v
+	}
+
+	if vars[EnvPluginProjectDir] != "/project" || vars[EnvPluginProjectID] != "abc" ||
+		vars[EnvPluginContainerName] != "alca-abc" || vars[EnvPluginRuntime] != "Docker" {
+		t.Errorf("unexpected context vars: %v", vars)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(vars[EnvPluginConfig]), &raw); err != nil {
+		t.Fatalf("%s is not valid JSON: %v", EnvPluginConfig, err)
+	}
+	if raw["image"] != "ubuntu:latest" {
+		t.Errorf("config image = %v, want ubuntu:latest", raw["image"])
+	}
+}
+
+func TestPluginContextEnviron_OutsideProject(t *testing.T) {
+	for _, kv := range (PluginContext{}).Environ() {
+		if strings.HasPrefix(kv, EnvPluginConfig+"=") || strings.HasPrefix(kv, EnvPluginContainerName+"=") {
+			t.Errorf("unexpected context var outside project: %s", kv)
+		}
+	}
+}
+
+func TestRunPluginHooks(t *testing.T) {
+	bin := t.TempDir()
+	path := filepath.Join(bin, PluginPrefix+"notify")
+	if err := afero.WriteFile(afero.NewOsFs(), path, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(path+" hook post_up", nil)
+	cfg := &config.Config{Plugins: []string{"notify"}}
+	if err := runPluginHooks(context.Background(), cmd, cfg, nil, "/project", pluginEventPostUp, io.Discard); err != nil {
+		t.Fatalf("runPluginHooks() error = %v", err)
+	}
+	cmd.AssertAllExpectationsMet(t)
+	if call := cmd.Calls[0]; call.Dir != "/project" || !slices.Contains(call.Env, EnvPluginProjectDir+"=/project") {
+		t.Errorf("plugin ran in %q with env %v", call.Dir, call.Env)
+	}
+
+	cfg.Plugins = []string{"missing"}
+	if err := runPluginHooks(context.Background(), cmd, cfg, nil, "/project", pluginEventPostUp, io.Discard); err == nil {
+		t.Error("runPluginHooks() with a missing plugin should fail")
+	}
+}
@@ -0,0 +1,30 @@
+package sandbox
+
+import "fmt"
+
+// EnvAssumeYes is the environment variable that answers yes to all confirmation
+// prompts, equivalent to the global --yes flag.
+const EnvAssumeYes = "ALCA_ASSUME_YES"
+
+// PromptMode controls how confirmation prompts are answered.
+// The zero value prompts interactively on a terminal.
+type PromptMode struct {
+	// AssumeYes answers yes to every confirmation without prompting.
+	AssumeYes bool
+	// NonInteractive forbids prompting; confirmations fail with ErrConfirmationRequired.
+	NonInteractive bool
+}
+
+// Confirm asks the user to confirm an action according to the mode.
+// Returns true without prompting in assume-yes mode, and ErrConfirmationRequired
+// in non-interactive mode so that scripts fail loudly instead of silently
+// taking the "no" branch.
+func (m PromptMode) Confirm(prompt string) (bool, error) {
+	if m.AssumeYes {
+		return true, nil
+	}
+	if m.NonInteractive {
+		return false, fmt.Errorf("%q needs confirmation, pass --yes or set %s=1 to proceed: %w", prompt, EnvAssumeYes, ErrConfirmationRequired)
+	}
+	return promptConfirm(prompt), nil
+}
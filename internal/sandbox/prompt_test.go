@@ -0,0 +1,26 @@
+package sandbox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPromptModeConfirm_AssumeYes(t *testing.T) {
+	ok, err := PromptMode{AssumeYes: true, NonInteractive: true}.Confirm("Continue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Error("expected assume-yes mode to confirm")
+	}
+}
+
+func TestPromptModeConfirm_NonInteractive(t *testing.T) {
+	ok, err := PromptMode{NonInteractive: true}.Confirm("Continue?")
+	if !errors.Is(err, ErrConfirmationRequired) {
+		t.Fatalf("expected errConfirmationRequired, got %v", err)
+	}
+	if ok {
+		t.Error("expected non-interactive mode not to confirm")
+	}
+}
@@ -0,0 +1,178 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/transact"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// PullPolicy says when 'alca up' pulls the image of a container it creates.
+type PullPolicy string
+
+const (
+	// PullMissing pulls the image only when it is not present locally (the default).
+	PullMissing PullPolicy = "missing"
+	// PullAlways pulls the image before every container creation, picking up
+	// a tag that moved upstream.
+	PullAlways PullPolicy = "always"
+	// PullNever never pulls; creating a container fails if the image is not
+	// present locally.
+	PullNever PullPolicy = "never"
+)
+
+// parsePullPolicy validates a --pull-policy value; empty means PullMissing.
+func parsePullPolicy(s string) (PullPolicy, error) {
+	switch p := PullPolicy(s); p {
+	case "":
+		return PullMissing, nil
+	case PullMissing, PullAlways, PullNever:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid pull policy %q: must be always, missing or never", s)
+}
+
+// imageCheckInterval is the minimum time between upstream checks for image_check = "daily".
+const imageCheckInterval = 24 * time.Hour
+
+// PullImage pulls the configured image within timeouts.pull.
+func PullImage(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, out io.Writer) error {
+	timeout := cfg.Timeouts.PullTimeout()
+	pullCtx, cancel := util.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := rt.PullImage(pullCtx, runtimeEnv, cfg.Image, cfg.Platform, out); err != nil {
+		return util.TimeoutError(pullCtx, "pull (timeouts.pull)", timeout, err)
+	}
+	return nil
+}
+
+// applyPullPolicy prepares the image of a container about to be created.
+// PullMissing leaves the pull to the runtime, which fetches a missing image
+// while creating the container. A container created from an image baked by
+// 'alca bake' is left alone: the baked image is local by construction.
+func applyPullPolicy(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, st *state.State, policy PullPolicy, out io.Writer) error {
+	if st.Bake != nil && st.Bake.Base == cfg.Image {
+		return nil
+	}
+	switch policy {
+	case PullAlways:
+		util.ProgressStep(out, "Pulling %s (pull policy: always)...\n", cfg.Image)
+		return PullImage(ctx, runtimeEnv, rt, cfg, out)
+	case PullNever:
+		present, err := rt.ImagePresent(ctx, runtimeEnv, cfg.Image)
+		if err != nil {
+			return fmt.Errorf("failed to check for image %s: %w", cfg.Image, err)
+		}
+		if !present {
+			return fmt.Errorf("image %s is not present locally and the pull policy is never; run 'alca pull' first", cfg.Image)
+		}
+	}
+	return nil
+}
+
+// WarnArchMismatch warns when the image runs under a different CPU
+// architecture than the runtime host, since it will be emulated (Rosetta or
+// qemu) and run noticeably slower. Best-effort: detection failures are ignored.
+func WarnArchMismatch(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, out io.Writer) {
+	hostArch, imageArch, err := rt.ImageArch(ctx, runtimeEnv, cfg.Image, cfg.Platform)
+	if err != nil || hostArch == "" || imageArch == "" || hostArch == imageArch {
+		return
+	}
+	util.ProgressStep(out, "Warning: %s runs as %s on this %s host; it will be emulated (Rosetta/qemu) and noticeably slower.\n", cfg.Image, imageArch, hostArch)
+	if cfg.Platform == "" {
+		util.ProgressStep(out, "Set platform = \"linux/%s\" in %s to use a native image if one is published.\n", hostArch, ConfigFilename)
+	}
+}
+
+// imageCheckDue reports whether the upstream image check should run now.
+func imageCheckDue(mode config.ImageCheckMode, lastChecked, now time.Time) bool {
+	switch mode {
+	case config.ImageCheckAlways:
+		return true
+	case config.ImageCheckDaily:
+		return now.Sub(lastChecked) >= imageCheckInterval
+	default:
+		return false
+	}
+}
+
+// imageCheckWait bounds how long 'alca up' waits at its end for an image
+// update check still in flight.
+const imageCheckWait = 2 * time.Second
+
+// imageUpdateCheck is an upstream image check running in the background
+// while 'alca up' does its other work.
+type imageUpdateCheck struct {
+	image     string
+	startedAt time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+	newer     bool
+	err       error
+}
+
+// startImageUpdateCheck starts checking whether the configured image tag has
+// a newer digest upstream, if image_check says one is due. Returns nil when
+// no check is due. The registry round trip overlaps the rest of 'alca up';
+// finish reports the result.
+func startImageUpdateCheck(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, st *state.State, now time.Time) *imageUpdateCheck {
+	if !imageCheckDue(cfg.NormalizeImageCheck(), st.ImageCheckedAt, now) {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c := &imageUpdateCheck{image: cfg.Image, startedAt: now, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		c.newer, c.err = rt.ImageUpdateAvailable(ctx, runtimeEnv, c.image)
+	}()
+	return c
+}
+
+// stop abandons the check if it is still running. Safe on a nil check.
+func (c *imageUpdateCheck) stop() {
+	if c != nil {
+		c.cancel()
+	}
+}
+
+// finish waits up to wait for the check, warns when a newer image is
+// available, and persists the check time to state to throttle
+// image_check = "daily". A check that has not finished in time is
+// abandoned without recording it, so the next 'alca up' tries again.
+// Best-effort: registry or network failures never fail 'alca up'. Safe on
+// a nil check.
+func (c *imageUpdateCheck) finish(ctx context.Context, env *util.Env, tfs *transact.TransactFs, st *state.State, cwd string, wait time.Duration, out io.Writer) {
+	if c == nil {
+		return
+	}
+	defer c.cancel()
+	select {
+	case <-c.done:
+	case <-time.After(wait):
+		return
+	}
+
+	if c.err != nil {
+		util.ProgressStep(out, "Warning: image update check failed: %v\n", c.err)
+		return
+	}
+	if c.newer {
+		util.ProgressStep(out, "Warning: a newer version of %s is available upstream.\n", c.image)
+		util.ProgressStep(out, "Run 'alca pull' and then 'alca down && alca up' to use it.\n")
+	}
+
+	st.ImageCheckedAt = c.startedAt
+	if err := state.Save(env, cwd, st); err != nil {
+		util.ProgressStep(out, "Warning: failed to save state: %v\n", err)
+		return
+	}
+	if err := CommitWithSudo(ctx, env, tfs, out, ""); err != nil {
+		util.ProgressStep(out, "Warning: failed to save state: %v\n", err)
+	}
+}
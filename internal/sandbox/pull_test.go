@@ -0,0 +1,117 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/transact"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestImageCheckDue(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		mode        config.ImageCheckMode
+		lastChecked time.Time
+		want        bool
+	}{
+		{"never", config.ImageCheckNever, time.Time{}, false},
+		{"always recently checked", config.ImageCheckAlways, now.Add(-time.Minute), true},
+		{"daily never checked", config.ImageCheckDaily, time.Time{}, true},
+		{"daily checked within a day", config.ImageCheckDaily, now.Add(-23 * time.Hour), false},
+		{"daily checked a day ago", config.ImageCheckDaily, now.Add(-24 * time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageCheckDue(tt.mode, tt.lastChecked, now); got != tt.want {
+				t.Errorf("imageCheckDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// imageCheckRuntime answers ImageUpdateAvailable once release is closed, or
+// fails when the check is canceled first.
+type imageCheckRuntime struct {
+	runtime.StubRuntime
+	newer   bool
+	release chan struct{}
+}
+
+func (r *imageCheckRuntime) ImageUpdateAvailable(ctx context.Context, _ *runtime.RuntimeEnv, _ string) (bool, error) {
+	select {
+	case <-r.release:
+		return r.newer, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+func TestImageUpdateCheck(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	cfg := &config.Config{Image: "alpine:latest", ImageCheck: config.ImageCheckAlways}
+
+	tests := []struct {
+		name        string
+		answered    bool
+		wantWarning bool
+		wantChecked time.Time
+	}{
+		{"newer image", true, true, now},
+		// A check still in flight is abandoned, not recorded, so the next
+		// up retries it
+		{"still running", false, false, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cmd := util.NewMockCommandRunner()
+			tfs := transact.New(transact.WithActualFs(afero.NewMemMapFs()))
+			env := &util.Env{Fs: tfs, Cmd: cmd}
+			rt := &imageCheckRuntime{newer: true, release: make(chan struct{})}
+			if tt.answered {
+				close(rt.release)
+			}
+			st := &state.State{ProjectID: "test-id"}
+
+			check := startImageUpdateCheck(ctx, runtime.NewRuntimeEnv(cmd), rt, cfg, st, now)
+			if check == nil {
+				t.Fatal("startImageUpdateCheck() = nil, want a running check")
+			}
+			if tt.answered {
+				<-check.done
+			}
+			var out bytes.Buffer
+			check.finish(ctx, env, tfs, st, "/project", time.Millisecond, &out)
+
+			if got := strings.Contains(out.String(), "newer version of alpine:latest"); got != tt.wantWarning {
+				t.Errorf("warning printed = %v, want %v; output: %q", got, tt.wantWarning, out.String())
+			}
+			if !st.ImageCheckedAt.Equal(tt.wantChecked) {
+				t.Errorf("ImageCheckedAt = %v, want %v", st.ImageCheckedAt, tt.wantChecked)
+			}
+		})
+	}
+}
+
+func TestStartImageUpdateCheck_NotDue(t *testing.T) {
+	cfg := &config.Config{Image: "alpine:latest", ImageCheck: config.ImageCheckNever}
+	check := startImageUpdateCheck(context.Background(), nil, &imageCheckRuntime{}, cfg, &state.State{}, time.Now())
+	if check != nil {
+		t.Fatal("startImageUpdateCheck() started a check that is not due")
+	}
+	// A nil check is a no-op
+	check.stop()
+	check.finish(context.Background(), nil, nil, nil, "", 0, nil)
+}
@@ -0,0 +1,120 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Recovery decides whether run/enter bring a project whose
+// container is not running back up, e.g. after the runtime daemon restarted
+// and took the container with it, and whether enter first applies config
+// changes the running container predates.
+type Recovery struct {
+	// AutoUp runs up without asking.
+	AutoUp bool
+	// CheckDrift warns when the running container was created with an
+	// older config; AutoReconcile runs up to apply it instead.
+	CheckDrift    bool
+	AutoReconcile bool
+	// Prompt says whether to ask before running up.
+	Prompt PromptMode
+	// Up runs the up flow for the project in cwd.
+	Up func(ctx context.Context, cwd string) error
+	// Out receives progress output; nil discards it.
+	Out io.Writer
+}
+
+// shouldUp reports whether to run up: --auto-up or --yes do so outright,
+// otherwise an interactive terminal is asked.
+func (r *Recovery) shouldUp(st *state.State, status runtime.ContainerStatus) bool {
+	if r.AutoUp || r.Prompt.AssumeYes {
+		return true
+	}
+	if r.Prompt.NonInteractive {
+		return false
+	}
+	return promptConfirm(fmt.Sprintf("Container %s is %s. Run 'alca up' now?", st.ContainerName, describeContainerState(status.State)))
+}
+
+// describeContainerState words a non-running state for messages.
+func describeContainerState(s runtime.ContainerState) string {
+	if s == runtime.StateNotFound {
+		return "gone"
+	}
+	return string(s)
+}
+
+// reconcileContainer handles a container that state.json records but the
+// runtime no longer runs. Sync sessions of a vanished container point at a
+// dead container ID, so they are terminated; with recovery (nil disables it)
+// the up flow recreates or restarts the container. Returns the new status.
+func reconcileContainer(ctx context.Context, env *util.Env, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, cwd string, status runtime.ContainerStatus, recovery *Recovery) (runtime.ContainerStatus, error) {
+	var out io.Writer
+	if recovery != nil {
+		out = recovery.Out
+	}
+
+	if status.State == runtime.StateNotFound && st.Config != nil && st.Config.HasMutagenSync() {
+		sessions, _ := runtime.ListMutagenSyncs(ctx, runtimeEnv, util.MutagenSessionPrefix(st.ProjectID))
+		if len(sessions) > 0 {
+			util.ProgressStep(out, "Container %s is gone (did the runtime daemon restart?); removing %d stale sync session(s)\n", st.ContainerName, len(sessions))
+			if err := runtime.TerminateProjectSyncs(ctx, runtimeEnv, st.ProjectID); err != nil {
+				util.ProgressStep(out, "Warning: failed to remove stale sync sessions: %v\n", err)
+			}
+		}
+	}
+
+	// A container stopped by idle_timeout is started again without asking
+	idleStopped := status.State == runtime.StateStopped && WasIdleStopped(env, cwd)
+	if recovery == nil || !(idleStopped || recovery.shouldUp(st, status)) {
+		return status, errors.New(ErrMsgNotRunning)
+	}
+
+	if idleStopped {
+		util.ProgressStep(out, "Container %s was stopped after idle_timeout; running 'alca up'...\n", st.ContainerName)
+	} else {
+		util.ProgressStep(out, "Container %s is %s; running 'alca up'...\n", st.ContainerName, describeContainerState(status.State))
+	}
+	if err := recovery.Up(ctx, cwd); err != nil {
+		return status, fmt.Errorf("alca up failed: %w", err)
+	}
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return status, fmt.Errorf("failed to get container status: %w", err)
+	}
+	if status.State != runtime.StateRunning {
+		return status, errors.New(ErrMsgNotRunning)
+	}
+	return status, nil
+}
+
+// reconcileDrift checks a running container against the current config.
+// Drift is reported in one line, or with AutoReconcile applied by the up
+// flow, which changes what it can in place and rebuilds (asking first, as
+// 'alca up' does) only when it must. Returns whether up ran, in which case
+// state must be loaded again.
+func (r *Recovery) reconcileDrift(ctx context.Context, cwd string, cfg *config.Config, st *state.State, rtName string) (bool, error) {
+	if r == nil || !r.CheckDrift {
+		return false, nil
+	}
+	summary := driftSummary(st.DetectConfigDrift(cfg), st.Runtime != rtName, st.Runtime, rtName)
+	if summary == "" {
+		return false, nil
+	}
+	if !r.AutoReconcile {
+		_, _ = fmt.Fprintf(r.Out, "Warning: the container predates config changes (%s); run 'alca up' to apply them, or pass --auto-reconcile\n", summary)
+		return false, nil
+	}
+	util.ProgressStep(r.Out, "Config changed since the container was created (%s); running 'alca up'...\n", summary)
+	if err := r.Up(ctx, cwd); err != nil {
+		return false, fmt.Errorf("alca up failed: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,124 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestReconcileContainer_RemovesStaleSessions(t *testing.T) {
+	st := &state.State{
+		ProjectID:     "p1",
+		ContainerName: "alca-p1",
+		Config:        &config.Config{WorkdirExclude: []string{"node_modules"}},
+	}
+	cmd := util.NewMockCommandRunner()
+	cmd.ExpectSuccess(`mutagen sync list --template={{range .}}{{.Name}}{{"\n"}}{{end}}`, []byte("alca-p1-workspace\nalca-other-workspace\n"))
+	cmd.ExpectSuccess("mutagen sync terminate alca-p1-workspace", nil)
+	defer cmd.AssertAllExpectationsMet(t)
+
+	status := runtime.ContainerStatus{State: runtime.StateNotFound}
+	_, err := reconcileContainer(context.Background(), util.NewTestEnv(), runtime.NewRuntimeEnv(cmd), runtime.NewFake(), st, "/p1", status, nil)
+	if err == nil || err.Error() != ErrMsgNotRunning {
+		t.Errorf("err = %v, want %q", err, ErrMsgNotRunning)
+	}
+}
+
+func TestReconcileContainer_Recovery(t *testing.T) {
+	tests := []struct {
+		name     string
+		recovery Recovery
+		wantUp   bool
+	}{
+		{"auto-up", Recovery{AutoUp: true}, true},
+		{"assume yes", Recovery{Prompt: PromptMode{AssumeYes: true}}, true},
+		{"non-interactive", Recovery{Prompt: PromptMode{NonInteractive: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := runtime.NewFake()
+			st := &state.State{ProjectID: "p1", ContainerName: "alca-p1"}
+			ups := 0
+			recovery := tt.recovery
+			recovery.Out = &bytes.Buffer{}
+			recovery.Up = func(context.Context, string) error {
+				ups++
+				fake.AddContainer(runtime.FakeContainer{Name: st.ContainerName, State: runtime.StateRunning})
+				return nil
+			}
+
+			status := runtime.ContainerStatus{State: runtime.StateStopped}
+			got, err := reconcileContainer(context.Background(), util.NewTestEnv(), runtime.NewRuntimeEnv(util.NewMockCommandRunner()), fake, st, "/p1", status, &recovery)
+			if tt.wantUp {
+				if err != nil || ups != 1 || got.State != runtime.StateRunning {
+					t.Errorf("reconcile = %+v, %v (ups %d), want running after one up", got, err, ups)
+				}
+				return
+			}
+			if err == nil || err.Error() != ErrMsgNotRunning || ups != 0 {
+				t.Errorf("reconcile err = %v (ups %d), want %q without up", err, ups, ErrMsgNotRunning)
+			}
+		})
+	}
+}
+
+func TestReconcileDrift(t *testing.T) {
+	stored := &config.Config{Image: "alpine:3", Envs: map[string]config.EnvValue{"A": {Value: "1"}}}
+	current := &config.Config{Image: "alpine:3", Envs: map[string]config.EnvValue{"A": {Value: "2"}}}
+
+	tests := []struct {
+		name      string
+		recovery  Recovery
+		cfg       *config.Config
+		wantUp    bool
+		wantEmpty bool
+		wantOut   string
+	}{
+		{"run does not check", Recovery{}, current, false, true, ""},
+		{"no drift", Recovery{CheckDrift: true}, stored, false, true, ""},
+		{"drift warns", Recovery{CheckDrift: true}, current, false, false, "Warning: the container predates config changes (envs); run 'alca up'"},
+		{"auto-reconcile", Recovery{CheckDrift: true, AutoReconcile: true}, current, true, false, "Config changed since the container was created (envs); running 'alca up'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &state.State{Runtime: "Fake", Config: stored}
+			var out bytes.Buffer
+			ups := 0
+			recovery := tt.recovery
+			recovery.Out = &out
+			recovery.Up = func(context.Context, string) error {
+				ups++
+				return nil
+			}
+
+			got, err := recovery.reconcileDrift(context.Background(), "/p1", tt.cfg, st, "Fake")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.wantUp || (ups == 1) != tt.wantUp {
+				t.Errorf("reconcileDrift() = %v (ups %d), want %v", got, ups, tt.wantUp)
+			}
+			if tt.wantEmpty && out.Len() > 0 {
+				t.Errorf("unexpected output %q", out.String())
+			}
+			if !bytes.Contains(out.Bytes(), []byte(tt.wantOut)) {
+				t.Errorf("output %q does not contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestDriftSummary(t *testing.T) {
+	drift := &state.DriftChanges{Image: &[2]string{"a", "b"}, Envs: true, LANAccess: true}
+	if got, want := driftSummary(drift, true, "Docker", "Podman"), "runtime, image, envs, network.lan-access"; got != want {
+		t.Errorf("driftSummary() = %q, want %q", got, want)
+	}
+	if got := driftSummary(nil, false, "Docker", "Docker"); got != "" {
+		t.Errorf("driftSummary(nil) = %q, want empty", got)
+	}
+}
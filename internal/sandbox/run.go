@@ -0,0 +1,253 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/history"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sync"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// runSandboxSession runs args in the sandbox of the project in cwd and
+// records the command with its exit code in the project history;
+// interactive sessions (enter) also get the [ui] title and prompt. A
+// command that exits non-zero returns its *exec.ExitError.
+func runSandboxSession(ctx context.Context, host *util.Env, cwd string, args []string, opts ExecOptions, interactive bool) error {
+	user, recovery := opts.User, opts.Recovery
+
+	// Create shared dependencies once
+	deps := NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+
+	// Load configuration and runtime
+	cfg, rt, err := LoadConfigAndRuntime(ctx, env, runtimeEnv, cwd)
+	if err != nil {
+		return err
+	}
+
+	// Load state (required)
+	st, err := LoadRequiredState(env, cwd)
+	if err != nil {
+		return err
+	}
+
+	// Check if project directory has moved since container was created
+	if err := CheckProjectPathConsistency(ctx, runtimeEnv, rt, st, cwd, cfg); err != nil {
+		return err
+	}
+
+	// --user is the most specific setting, so it takes the commands.enter slot
+	if user != "" {
+		cfg.Commands.Enter.User = user
+	}
+
+	// Check if container is running
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		return fmt.Errorf("failed to get container status: %w", err)
+	}
+
+	checkContainerExit(ctx, host.Fs, runtimeEnv, rt, cwd, st, cfg, status, os.Stderr)
+
+	// A frozen container would leave the command hanging until resumed
+	if status.State == runtime.StatePaused {
+		return errors.New(ErrMsgPaused)
+	}
+	if status.State != runtime.StateRunning {
+		if status, err = reconcileContainer(ctx, host, runtimeEnv, rt, st, cwd, status, recovery); err != nil {
+			return err
+		}
+	} else if reconciled, err := recovery.reconcileDrift(ctx, cwd, cfg, st, rt.Name()); err != nil {
+		return err
+	} else if reconciled {
+		if st, err = LoadRequiredState(env, cwd); err != nil {
+			return err
+		}
+		if status, err = rt.Status(ctx, runtimeEnv, cwd, st); err != nil {
+			return fmt.Errorf("failed to get container status: %w", err)
+		}
+		if status.State != runtime.StateRunning {
+			return errors.New(ErrMsgNotRunning)
+		}
+	}
+
+	correctClockDrift(ctx, runtimeEnv, rt, cfg, runtime.DetectPlatform(ctx, runtimeEnv), status.Name, os.Stderr)
+
+	// Opted in by 'alca env sync': hand the current host values to
+	// processes that were started by earlier sessions
+	if st.EnvSync {
+		if err := WriteContainerEnv(ctx, runtimeEnv, rt, cfg, status.Name); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// SWR: show stale cache banner immediately, refresh periodically in background.
+	syncEnv := sync.NewSyncEnv(host.Fs, host.Cmd, runtime.NewMutagenSyncClient(runtimeEnv))
+	if cache, err := sync.ReadCache(host.Fs, cwd); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to read sync conflict cache: %v\n", err)
+	} else if cache != nil && len(cache.Conflicts) > 0 {
+		sync.RenderBanner(cache.Conflicts, os.Stderr)
+	}
+	stopRefresh := sync.StartPeriodicRefresh(ctx, syncEnv, st.ProjectID, cwd)
+
+	execCmd := WrapWithEnter(cfg, args)
+
+	// Start in the container directory matching the user's, as with git
+	// run from a subdirectory
+	var prompt string
+	restoreTitle := func() {}
+	if interactive {
+		vars := uiVars(cwd, status.Name)
+		prompt = cfg.UI.Prompt(vars)
+		restoreTitle = setTerminalTitle(os.Stdout, cfg.UI.TerminalTitle(vars))
+	}
+	execCfg := SessionConfig(cfg, cwd, prompt)
+	if opts.Dir != "" {
+		execCfg.Workdir = ExecWorkdir(cfg, cwd, opts.Dir)
+	}
+
+	startedAt := time.Now()
+	endUse := TrackUse(host, cwd)
+	err = rt.Exec(ctx, runtimeEnv, execCfg, cwd, st, execCmd)
+	endUse()
+	restoreTitle()
+	RecordHistory(host.Fs, cwd, startedAt, args, err)
+
+	// Show exit banner if conflicts exist
+	if conflicts := stopRefresh(); len(conflicts) > 0 {
+		sync.RenderBanner(conflicts, os.Stderr)
+	}
+
+	if err != nil {
+		surfaceExecKill(ctx, host.Fs, runtimeEnv, rt, cwd, st, cfg, status.Name, "command", err, os.Stderr)
+
+		// Exit codes are the command's, not a failure to run it
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return err
+		}
+		return fmt.Errorf("failed to execute command: %w", err)
+	}
+
+	return nil
+}
+
+// ExecWorkdir returns where in the container hostDir is mounted, so
+// commands start in the directory the user is in. The mount with the
+// deepest source wins; directories excluded from a synced mount are not in
+// the container, so their nearest included parent is used. Returns the
+// configured workdir when no mount covers hostDir (e.g. --member run from
+// another project).
+func ExecWorkdir(cfg *config.Config, projectDir, hostDir string) string {
+	best, bestSource := "", ""
+	for _, m := range cfg.Mounts {
+		source := m.Source
+		if source == "." {
+			source = projectDir
+		} else if !filepath.IsAbs(source) {
+			source = filepath.Join(projectDir, source)
+		}
+		rel, err := filepath.Rel(source, hostDir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if best != "" && len(source) <= len(bestSource) {
+			continue
+		}
+		dir := m.Target
+		if rel != "." {
+			prefix := ""
+			for _, name := range strings.Split(filepath.ToSlash(rel), "/") {
+				prefix = path.Join(prefix, name)
+				if IsExcluded(m.Exclude, name, prefix) {
+					break
+				}
+				dir = path.Join(dir, name)
+			}
+		}
+		best, bestSource = dir, source
+	}
+	if best == "" {
+		return cfg.Workdir
+	}
+	return best
+}
+
+// WrapWithEnter builds the exec command with the optional enter prefix.
+// If commands.enter is set, it is used as command wrapper/prefix.
+func WrapWithEnter(cfg *config.Config, args []string) []string {
+	if cfg.Commands.Enter.Command == "" {
+		// Run command directly
+		return args
+	}
+
+	// Enter may contain shell syntax (&&, |, etc.), so wrap with sh -c
+	// Quote each arg to preserve spaces and special characters
+	quotedArgs := make([]string, len(args))
+	for i, arg := range args {
+		quotedArgs[i] = ShellQuote(arg)
+	}
+	fullCmd := cfg.Commands.Enter.Command + " " + strings.Join(quotedArgs, " ")
+	return []string{"sh", "-c", fullCmd}
+}
+
+// RecordHistory appends the executed command to .alca/history.jsonl.
+// Commands that failed to start (no exit status) are not recorded.
+// Best-effort: a history write failure never affects the command result.
+func RecordHistory(fs afero.Fs, cwd string, startedAt time.Time, args []string, execErr error) {
+	exitCode := 0
+	if execErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(execErr, &exitErr) {
+			return
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	entry := history.Entry{Time: startedAt, Command: args, ExitCode: exitCode}
+	if err := history.Append(fs, cwd, entry); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to record history: %v\n", err)
+	}
+}
+
+// ShellQuote quotes a string for safe use in shell commands.
+// It wraps the string in single quotes and escapes internal single quotes.
+func ShellQuote(s string) string {
+	// Replace ' with '\'' (end quote, escaped quote, start quote)
+	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+}
+
+// IsExcluded reports whether an existing pattern already covers the
+// directory named name at rel. Only the common spellings (name, name/,
+// **/name, /rel, rel/) are recognized; a pattern such as *_modules is not.
+func IsExcluded(existing []string, name, rel string) bool {
+	for _, p := range existing {
+		if strings.HasPrefix(p, "!") {
+			continue
+		}
+		p = strings.TrimSuffix(p, "/")
+		if strings.HasPrefix(p, "/") {
+			if strings.TrimPrefix(p, "/") == rel {
+				return true
+			}
+			continue
+		}
+		p = strings.TrimPrefix(p, "**/")
+		if p == name || p == rel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package sandbox
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "simple string",
+			input:    "hello",
+			expected: "'hello'",
+		},
+		{
+			name:     "string with spaces",
+			input:    "hello world",
+			expected: "'hello world'",
+		},
+		{
+			name:     "string with single quote",
+			input:    "it's",
+			expected: "'it'\\''s'",
+		},
+		{
+			name:     "string with multiple single quotes",
+			input:    "it's a 'test'",
+			expected: "'it'\\''s a '\\''test'\\'''",
+		},
+		{
+			name:     "empty string",
+			input:    "",
+			expected: "''",
+		},
+		{
+			name:     "string with special chars",
+			input:    "hello$world",
+			expected: "'hello$world'",
+		},
+		{
+			name:     "string with double quotes",
+			input:    `say "hello"`,
+			expected: `'say "hello"'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ShellQuote(tt.input)
+			if result != tt.expected {
+				t.Errorf("shellQuote(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWrapWithEnter(t *testing.T) {
+	args := []string{"sh", "-c", "go test ./..."}
+
+	t.Run("no enter command", func(t *testing.T) {
+		got := WrapWithEnter(&config.Config{}, args)
+		if !reflect.DeepEqual(got, args) {
+			t.Errorf("wrapWithEnter() = %v, want %v", got, args)
+		}
+	})
+
+	t.Run("enter command prefixes quoted args", func(t *testing.T) {
+		cfg := &config.Config{Commands: config.Commands{Enter: config.CommandValue{Command: ". ~/.profile &&"}}}
+		got := WrapWithEnter(cfg, args)
+		want := []string{"sh", "-c", ". ~/.profile && 'sh' '-c' 'go test ./...'"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("wrapWithEnter() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestExecWorkdir(t *testing.T) {
+	cfg := &config.Config{
+		Workdir: "/workspace",
+		Mounts: []config.MountConfig{
+			{Source: ".", Target: "/workspace", Exclude: []string{"node_modules", "/build/"}},
+			{Source: "vendor/sdk", Target: "/opt/sdk"},
+			{Source: "/home/me/.cache", Target: "/cache"},
+		},
+	}
+	tests := []struct {
+		name    string
+		hostDir string
+		want    string
+	}{
+		{"project root", "/p", "/workspace"},
+		{"subdirectory", "/p/src/pkg", "/workspace/src/pkg"},
+		{"deeper mount wins", "/p/vendor/sdk/lib", "/opt/sdk/lib"},
+		{"absolute source", "/home/me/.cache/go", "/cache/go"},
+		{"excluded directory", "/p/web/node_modules/react", "/workspace/web"},
+		{"anchored exclude", "/p/build/out", "/workspace"},
+		{"sibling with shared prefix", "/p2/src", "/workspace"},
+		{"outside every mount", "/tmp", "/workspace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExecWorkdir(cfg, "/p", tt.hostDir); got != tt.want {
+				t.Errorf("execWorkdir(%q) = %q, want %q", tt.hostDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	tests := []struct {
+		existing []string
+		name     string
+		rel      string
+		want     bool
+	}{
+		{[]string{"node_modules"}, "node_modules", "web/node_modules", true},
+		{[]string{"**/node_modules/"}, "node_modules", "node_modules", true},
+		{[]string{"/node_modules"}, "node_modules", "web/node_modules", false},
+		{[]string{"/rust/target/"}, "target", "rust/target", true},
+		{[]string{"rust/target"}, "target", "rust/target", true},
+		{[]string{"!node_modules"}, "node_modules", "node_modules", false},
+	}
+	for _, tt := range tests {
+		if got := IsExcluded(tt.existing, tt.name, tt.rel); got != tt.want {
+			t.Errorf("IsExcluded(%q, %q, %q) = %v, want %v", tt.existing, tt.name, tt.rel, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Status gathers the status of the project in cwd, reporting container
+// exits noticed since the last look to w (nil discards them). Only a
+// config that fails to load is an error; later failures stop the gathering
+// and are recorded in the result.
+func Status(ctx context.Context, host *util.Env, cwd string, w io.Writer) (*ProjectStatus, error) {
+	// Create shared dependencies once
+	deps := NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+
+	ps := &ProjectStatus{ConfigPath: filepath.Join(cwd, ConfigFilename)}
+
+	// Check if config exists
+	if _, err := env.Fs.Stat(ps.ConfigPath); os.IsNotExist(err) {
+		return ps, nil
+	}
+	ps.Initialized = true
+
+	// Load config
+	cfg, err := config.LoadConfig(env, ps.ConfigPath, config.StrictExpandEnv, ConfigLoadOptions(ctx, env)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	ps.Config = &cfg
+
+	// Select runtime
+	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, &cfg)
+	if err != nil {
+		ps.RuntimeErr = err
+		return ps, nil
+	}
+	ps.Runtime = rt.Name()
+
+	// Load state (optional for status)
+	st, err := state.Load(env, cwd)
+	if err != nil {
+		ps.StateErr = err
+		return ps, nil
+	}
+	if st == nil {
+		return ps, nil
+	}
+	ps.State = st
+
+	// Get container status
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil {
+		ps.ContainerErr = err
+		return ps, nil
+	}
+	ps.Container = status
+
+	checkContainerExit(ctx, host.Fs, runtimeEnv, rt, cwd, st, &cfg, status, w)
+	return ps, nil
+}
@@ -0,0 +1,24 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bolasblack/alcatraz/internal/sync"
+)
+
+// ShowSyncBanner detects conflicts synchronously and shows the banner on w.
+// Uses synchronous update because the process exits immediately after status —
+// an async goroutine would be killed before it finishes writing the cache.
+// Best-effort: errors are logged to w but do not block the command.
+func ShowSyncBanner(ctx context.Context, syncEnv *sync.SyncEnv, projectID string, projectRoot string, w io.Writer) {
+	cache, err := sync.SyncUpdateCache(ctx, syncEnv, projectID, projectRoot)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: failed to check sync conflicts: %v\n", err)
+		return
+	}
+	if cache != nil && len(cache.Conflicts) > 0 {
+		sync.RenderBanner(cache.Conflicts, w)
+	}
+}
@@ -0,0 +1,86 @@
+package sandbox
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/term"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandboxinfo"
+)
+
+// Environment variables set in every enter and run session, so programs
+// and shell configs can tell they run in the sandbox.
+const (
+	// EnvSandbox is "1" inside the sandbox.
+	EnvSandbox = "ALCA_SANDBOX"
+	// EnvProjectName is the base name of the project directory.
+	EnvProjectName = "ALCA_PROJECT_NAME"
+
+	// envPromptPrefix carries ui.prompt_prefix to promptCommand.
+	envPromptPrefix = "ALCA_PROMPT_PREFIX"
+)
+
+// promptCommand is the bash PROMPT_COMMAND that keeps ui.prompt_prefix in
+// front of PS1, even after .bashrc sets its own prompt.
+const promptCommand = `case "$PS1" in "$ALCA_PROMPT_PREFIX"*) ;; *) PS1="$ALCA_PROMPT_PREFIX$PS1" ;; esac`
+
+// SessionConfig returns a copy of cfg whose envs also carry the sandbox
+// markers, plus the ui.prompt_prefix setup when prompt is non-empty. The
+// markers are applied per exec like override_on_enter envs, so they reach
+// sessions of containers created before they existed.
+func SessionConfig(cfg *config.Config, cwd, prompt string) *config.Config {
+	sessionCfg := *cfg
+	sessionCfg.Envs = maps.Clone(cfg.Envs)
+	if sessionCfg.Envs == nil {
+		sessionCfg.Envs = make(map[string]config.EnvValue)
+	}
+	set := func(key, value string) {
+		sessionCfg.Envs[key] = config.EnvValue{Value: value, OverrideOnEnter: true}
+	}
+	set(EnvSandbox, "1")
+	set(EnvProjectName, filepath.Base(cwd))
+	set(EnvSandboxInfo, sandboxinfo.ContainerPath)
+	if prompt != "" {
+		set(envPromptPrefix, prompt)
+		// sh, dash and ash take PS1 from the environment; bash rewrites
+		// it from its rc files, so promptCommand prefixes it again
+		set("PS1", prompt+"$ ")
+		set("PROMPT_COMMAND", promptCommand)
+	}
+	return &sessionCfg
+}
+
+// uiVars returns the placeholder values of the [ui] settings.
+func uiVars(cwd, containerName string) config.UIVars {
+	return config.UIVars{ProjectName: filepath.Base(cwd), Container: containerName}
+}
+
+// setTerminalTitle sets the title of the terminal on out for the duration of
+// a session, and returns a function restoring the previous one. Terminals
+// that keep a title stack (xterm and most others) restore it exactly;
+// others keep the sandbox title until something sets another. Does nothing
+// when title is empty or out is not a terminal.
+func setTerminalTitle(out *os.File, title string) (restore func()) {
+	if title == "" || !term.IsTerminal(int(out.Fd())) {
+		return func() {}
+	}
+	writeTerminalTitle(out, title)
+	return func() { _, _ = io.WriteString(out, titlePop) }
+}
+
+const (
+	// titlePush saves the current window title on the terminal's stack.
+	titlePush = "\x1b[22;0t"
+	// titlePop restores the window title saved by titlePush.
+	titlePop = "\x1b[23;0t"
+)
+
+// writeTerminalTitle saves the current title and sets title (OSC 0).
+func writeTerminalTitle(w io.Writer, title string) {
+	_, _ = fmt.Fprintf(w, "%s\x1b]0;%s\x07", titlePush, title)
+}
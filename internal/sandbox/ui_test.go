@@ -0,0 +1,37 @@
+package sandbox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+)
+
+func TestSessionConfig(t *testing.T) {
+	cfg := &config.Config{Envs: map[string]config.EnvValue{"FOO": {Value: "bar"}}}
+
+	got := SessionConfig(cfg, "/home/me/myapp", "")
+	envs := got.ResolvedEnvs(func(string) string { return "" }, true)
+	if envs[EnvSandbox] != "1" || envs[EnvProjectName] != "myapp" {
+		t.Errorf("session envs = %v", envs)
+	}
+	if _, ok := envs["PS1"]; ok {
+		t.Error("PS1 set without a prompt prefix")
+	}
+	if _, ok := cfg.Envs[EnvSandbox]; ok {
+		t.Error("sessionConfig modified the original envs")
+	}
+
+	envs = SessionConfig(cfg, "/home/me/myapp", "(alca) ").ResolvedEnvs(func(string) string { return "" }, true)
+	if envs["PS1"] != "(alca) $ " || envs[envPromptPrefix] != "(alca) " || envs["PROMPT_COMMAND"] != promptCommand {
+		t.Errorf("prompt envs = %v", envs)
+	}
+}
+
+func TestWriteTerminalTitle(t *testing.T) {
+	var buf bytes.Buffer
+	writeTerminalTitle(&buf, "alca: myapp (alca-1234)")
+	if want := "\x1b[22;0t\x1b]0;alca: myapp (alca-1234)\x07"; buf.String() != want {
+		t.Errorf("title sequence = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,937 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
+	"github.com/bolasblack/alcatraz/internal/network"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/sync"
+	"github.com/bolasblack/alcatraz/internal/transact"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Up runs the up flow for the project in cwd: it creates or updates the
+// container, network, firewall and sync to match the config.
+func Up(ctx context.Context, host *util.Env, cwd string, opts UpOptions) error {
+	force, resume, verbose, setSave := opts.Force, opts.Resume, opts.Verbose, opts.SaveSet
+	prompt := opts.promptMode()
+
+	overrides, err := parseOverrides(opts.Set)
+	if err != nil {
+		return err
+	}
+	pullPolicy, err := parsePullPolicy(string(opts.PullPolicy))
+	if err != nil {
+		return err
+	}
+	if setSave && len(overrides) == 0 {
+		return errors.New("--set-save requires at least one --set")
+	}
+
+	if opts.Idempotent && !setSave && upToDate(ctx, host, cwd, overrides) {
+		return nil
+	}
+
+	out := opts.Out
+
+	// Create shared dependencies once
+	deps := NewDeps(host)
+	tfs, env, runtimeEnv := deps.Tfs, deps.Env, deps.RuntimeEnv
+
+	// Load configuration
+	util.ProgressStep(out, "Loading config from %s\n", ConfigFilename)
+	cfg, configPath, err := loadConfigFromCwdWithOverrides(ctx, env, cwd, overrides)
+	if err != nil {
+		return err
+	}
+	if setSave {
+		if err := saveOverrides(ctx, env, tfs, cwd, configPath, overrides, out); err != nil {
+			return err
+		}
+	}
+	if verbose {
+		for _, f := range LintConfig(env, configPath, cfg) {
+			util.ProgressStep(out, "Config lint: %s\n", config.FormatLintFinding(f))
+		}
+	}
+
+	// Select runtime based on config
+	util.ProgressStep(out, "Detecting runtime...\n")
+	rt, err := runtime.SelectRuntimeWithOutput(ctx, runtimeEnv, cfg, out)
+	if err != nil {
+		return fmt.Errorf("failed to select runtime: %w", err)
+	}
+	util.ProgressStep(out, "Detected runtime: %s\n", rt.Name())
+	WarnArchMismatch(ctx, runtimeEnv, rt, cfg, out)
+
+	// TODO: extract to validateMounts(runtimeEnv, rt, cfg) — mount-related validations
+	// Validate Mutagen is available if any mount requires it
+	if err := runtime.ValidateMutagenAvailable(ctx, runtimeEnv, cfg); err != nil {
+		return err
+	}
+
+	// Validate mount excludes compatibility with runtime
+	// See AGD-025 for rootless Podman + Mutagen limitations
+	if err := runtime.ValidateMountExcludes(ctx, runtimeEnv, rt, cfg); err != nil {
+		return fmt.Errorf("%w\n\nAlternatives:\n"+
+			"  1. Remove 'exclude' from mount configuration\n"+
+			"  2. Use rootful Podman (sudo podman)\n"+
+			"  3. Use Docker instead", err)
+	}
+
+	// Detect platform once for all network operations
+	platform := runtime.DetectPlatform(ctx, runtimeEnv)
+
+	// Load or create state early — ProjectID is needed by network env
+	st, isNew, err := state.LoadOrCreate(env, cwd, rt.Name())
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if isNew {
+		util.ProgressStep(out, "Created new state file: %s\n", state.StateFilePath(cwd))
+	}
+
+	// Check whether the image tag moved upstream (image_check) while the
+	// rest of up runs; the warning is printed at the end
+	imageCheck := startImageUpdateCheck(ctx, runtimeEnv, rt, cfg, st, time.Now())
+	defer imageCheck.stop()
+
+	// Progress is written straight to disk so an interrupted run leaves a
+	// record; any other failure clears it.
+	tracker, err := newUpTracker(host, cwd, out, resume, time.Now())
+	if err != nil {
+		return err
+	}
+	defer tracker.abandon(ctx)
+
+	// Create shared network env once for all network operations (AGD-029)
+	networkEnv := network.NewNetworkEnv(tfs, deps.CmdRunner, cwd, st.ProjectID, platform)
+
+	// Network helper (handles all platform-specific logic)
+	nh := network.NewNetworkHelperForProject(cfg.Network, platform)
+	if nh != nil && !tracker.skip(upStepNetwork) {
+		if err := tracker.step(ctx, upStepNetwork, nil, func() error {
+			return setupNetwork(ctx, nh, networkEnv, env, tfs, out, prompt)
+		}); err != nil {
+			return err
+		}
+	}
+
+	// A run killed while creating the container may have skipped commands.up;
+	// the container cannot be trusted, so recreate it.
+	if tracker.interruptedDuring(upStepContainer) && !containerMissing(ctx, rt, runtimeEnv, cwd, st) {
+		util.ProgressStep(out, "Removing container left half-created by an interrupted 'alca up'...\n")
+		if err := rt.Down(ctx, runtimeEnv, cwd, st); err != nil {
+			return fmt.Errorf("failed to remove half-created container: %w", err)
+		}
+	}
+
+	// A paused container is thawed: up means a running sandbox, and a
+	// frozen one cannot be rebuilt or set up
+	if s, err := rt.Status(ctx, runtimeEnv, cwd, st); err == nil && s.State == runtime.StatePaused {
+		if err := ResumeProject(ctx, host.Fs, runtimeEnv, rt, st, cwd, out); err != nil {
+			return err
+		}
+	}
+
+	// A moved project directory leaves the container mounting, labeled with
+	// and firewalled under the old path
+	if err := relocateProject(ctx, env, tfs, deps.CmdRunner, runtimeEnv, rt, st, platform, cwd, out, force, prompt); err != nil {
+		return err
+	}
+
+	// Check for configuration drift and handle rebuild.
+	// Only relevant when a container exists — after 'alca down' there's
+	// nothing to rebuild, so skip drift detection and create fresh.
+	needsRebuild, err := handleConfigDrift(ctx, host.Fs, cfg, st, rt, runtimeEnv, cwd, out, force, prompt)
+	if err != nil {
+		return err
+	}
+
+	// If rebuild needed, remove existing container first
+	if needsRebuild {
+		if err := rebuildContainerIfNeeded(ctx, host.Fs, runtimeEnv, cfg, st, rt, cwd, out); err != nil {
+			return err
+		}
+	}
+
+	// Resource limit changes are applied to the existing container
+	if !needsRebuild {
+		if err := updateResourcesIfNeeded(ctx, env, tfs, runtimeEnv, rt, cfg, st, cwd, out); err != nil {
+			return err
+		}
+	}
+
+	// TODO: extract to saveStateIfNeeded(env, tfs, cfg, st, cwd, out) — state persistence
+	// Update state with current config when creating fresh, rebuilding, or first time.
+	// "Creating fresh" = container was removed (e.g., alca down) but state.json persists.
+	if needsRebuild || isNew || containerMissing(ctx, rt, runtimeEnv, cwd, st) {
+		// container_name and name_template take effect on creation
+		if err := applyContainerName(ctx, deps.CmdRunner, runtimeEnv, rt, cfg, st, cwd, out); err != nil {
+			return err
+		}
+		st.UpdateConfig(cfg)
+		// A new container starts its OOM and restart counters from zero
+		st.EventCounters = state.EventCounters{}
+		if err := state.Save(env, cwd, st); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		// Commit state changes (project dir, normally no sudo needed)
+		if err := CommitWithSudo(ctx, env, tfs, out, ""); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+
+	// A stale baked image must not be used for the new container
+	discardStaleBake(ctx, env, tfs, runtimeEnv, rt, cfg, st, cwd, out)
+
+	// Report crashes and restarts since the last up before starting again
+	if status, err := rt.Status(ctx, runtimeEnv, cwd, st); err == nil {
+		checkContainerExit(ctx, host.Fs, runtimeEnv, rt, cwd, st, cfg, status, out)
+	}
+
+	// Start container. If interrupted while creating it, remove what was
+	// created; an already running container is left alone.
+	var undoContainer func(context.Context) error
+	before, _ := rt.Status(ctx, runtimeEnv, cwd, st)
+	if before.State == runtime.StateNotFound {
+		if err := applyPullPolicy(ctx, runtimeEnv, rt, cfg, st, pullPolicy, out); err != nil {
+			return err
+		}
+		undoContainer = func(ctx context.Context) error {
+			return rt.Down(ctx, runtimeEnv, cwd, st)
+		}
+	}
+	if err := tracker.step(ctx, upStepContainer, undoContainer, func() error {
+		return rt.Up(ctx, runtimeEnv, cfg, cwd, st, out)
+	}); err != nil {
+		surfaceExecKill(ctx, host.Fs, runtimeEnv, rt, cwd, st, cfg, st.ContainerName, "up command", err, out)
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+	emitContainerUp(ctx, host.Fs, runtimeEnv, rt, cfg, st, cwd, platform, before.State)
+	// Restarts the idle_timeout clock
+	recordUse(host, cwd, time.Now())
+
+	// Setup firewall rules for network isolation
+	// See AGD-027 for design decisions
+	// Files written via tfs, committed to real disk before nft loads them.
+	fw, fwType := network.New(ctx, networkEnv)
+
+	var expandedNet config.Network
+	fwErr := tracker.step(ctx, upStepFirewall, func(ctx context.Context) error {
+		return CleanupFirewall(ctx, fw, env, tfs, runtimeEnv, rt, st, out)
+	}, func() error {
+		var err error
+		expandedNet, err = SetupFirewall(ctx, host.Fs, fw, fwType, networkEnv, env, tfs, runtimeEnv, cfg.Network, rt, st, nh, out, prompt)
+		return err
+	})
+	if fwErr != nil && ctx.Err() != nil {
+		return fwErr
+	}
+	if fwErr != nil {
+		if errors.Is(fwErr, ErrSkipFirewall) {
+			// User declined helper install — already messaged, not an error
+		} else if errors.Is(fwErr, ErrConfirmationRequired) {
+			// Non-interactive mode must not silently start without isolation
+			return fwErr
+		} else {
+			// Firewall errors are warnings, not fatal - container is already running
+			util.ProgressStep(out, "Warning: %v\n", fwErr)
+		}
+	} else {
+		// Persist expanded network config (tokens resolved to IPs) to state.
+		// Always runs on success — even after rebuild, because UpdateConfig saves
+		// raw tokens and we need the resolved values in state.
+		if err := SaveNetworkState(ctx, env, tfs, cwd, expandedNet, st, out); err != nil {
+			return err
+		}
+	}
+
+	// Shaping failures are warnings, like firewall errors: the container is usable
+	ApplyShaping(ctx, networkEnv, runtimeEnv, rt, st, cfg.Network.Shaping, out)
+
+	// A VM clock left behind by host sleep breaks TLS and build caches
+	if s, err := rt.Status(ctx, runtimeEnv, cwd, st); err == nil && s.State == runtime.StateRunning {
+		correctClockDrift(ctx, runtimeEnv, rt, cfg, platform, s.Name, out)
+
+		// Tell the sandbox what it is allowed to do (alca whoami)
+		if fwErr == nil {
+			writeSandboxInfo(ctx, runtimeEnv, rt, cfg, st, cwd, s.Name, expandedNet, fwType, out)
+		} else {
+			writeSandboxInfo(ctx, runtimeEnv, rt, cfg, st, cwd, s.Name, cfg.Network, network.TypeNone, out)
+		}
+	}
+
+	// Show sync conflict banner if any (best-effort, errors ignored).
+	syncEnv := sync.NewSyncEnv(host.Fs, deps.CmdRunner, runtime.NewMutagenSyncClient(runtimeEnv))
+	ShowSyncBanner(ctx, syncEnv, st.ProjectID, cwd, os.Stderr)
+
+	// Execute post_up hook on host (runs after container and all setup is ready)
+	if !tracker.skip(upStepHooks) {
+		if err := tracker.step(ctx, upStepHooks, nil, func() error {
+			if cfg.Hooks.PostUp != "" {
+				util.ProgressStep(out, "Running post_up hook...\n")
+				if err := runHook(ctx, deps.CmdRunner, cfg.Hooks.PostUp, cwd, os.Stdout); err != nil {
+					return fmt.Errorf("post_up hook failed: %w", err)
+				}
+			}
+			return runPluginHooks(ctx, deps.CmdRunner, cfg, st, cwd, pluginEventPostUp, out)
+		}); err != nil {
+			return err
+		}
+	}
+
+	ensureCronScheduler(host, cfg, cwd, out)
+	ensureFileEventForwarder(host, cfg, cwd, platform, out)
+
+	imageCheck.finish(ctx, env, tfs, st, cwd, imageCheckWait, out)
+
+	tracker.finish()
+	util.ProgressDone(out, "Environment ready\n")
+	return nil
+}
+
+// upToDate reports whether the project's container is running with the
+// current config, so alca up --idempotent has nothing to do. Any doubt
+// (unreadable config or state, drift, an interrupted up) means a full up.
+func upToDate(ctx context.Context, host *util.Env, cwd string, overrides []config.Override) bool {
+	deps := NewReadDeps(host)
+	env, runtimeEnv := deps.Env, deps.RuntimeEnv
+	cfg, _, err := loadConfigFromCwdWithOverrides(ctx, env, cwd, overrides)
+	if err != nil {
+		return false
+	}
+	st, err := state.Load(env, cwd)
+	if err != nil || st == nil {
+		return false
+	}
+	if progress, err := state.LoadUpProgress(env, cwd); err != nil || progress != nil {
+		return false
+	}
+	rt, err := runtime.SelectRuntime(ctx, runtimeEnv, cfg)
+	if err != nil || st.Runtime != rt.Name() {
+		return false
+	}
+	if drift := st.DetectConfigDrift(cfg); drift != nil {
+		return false
+	}
+	if moved, err := movedProjectDir(ctx, runtimeEnv, rt, st, cwd); err != nil || moved != "" {
+		return false
+	}
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	return err == nil && status.State == runtime.StateRunning
+}
+
+func containerMissing(ctx context.Context, rt runtime.Runtime, runtimeEnv *runtime.RuntimeEnv, cwd string, st *state.State) bool {
+	s, _ := rt.Status(ctx, runtimeEnv, cwd, st)
+	return s.State == runtime.StateNotFound
+}
+
+// handleConfigDrift checks for configuration drift and prompts user if needed.
+// Returns true if rebuild is needed.
+// Skips drift detection when no container exists (e.g., after 'alca down') —
+// there's nothing to rebuild, just create fresh with current config.
+func handleConfigDrift(ctx context.Context, fs afero.Fs, cfg *config.Config, st *state.State, rt runtime.Runtime, runtimeEnv *runtime.RuntimeEnv, cwd string, out io.Writer, force bool, prompt PromptMode) (bool, error) {
+	// No container → no drift. Create fresh.
+	if containerMissing(ctx, rt, runtimeEnv, cwd, st) {
+		return false, nil
+	}
+
+	runtimeChanged := st.Runtime != rt.Name()
+	drift := st.DetectConfigDrift(cfg)
+	if summary := driftSummary(drift, runtimeChanged, st.Runtime, rt.Name()); summary != "" {
+		EmitEvent(fs, cwd, st, lifecycle.Event{
+			Type:      lifecycle.DriftDetected,
+			Container: st.ContainerName,
+			Message:   summary,
+			Details:   map[string]string{"recreate": strconv.FormatBool(runtimeChanged || drift.RequiresRecreate())},
+		})
+	}
+
+	// Network and resource limit changes are applied to the running
+	// container below
+	if !drift.RequiresRecreate() && !runtimeChanged {
+		if drift.HotApplicable() {
+			util.ProgressStep(out, "Configuration changed, applying without rebuild\n")
+		}
+		return false, nil
+	}
+
+	if force {
+		util.ProgressStep(out, "Configuration changed, rebuilding container (-f)\n")
+		return true, nil
+	}
+
+	// Show drift and ask for confirmation
+	DisplayConfigDrift(out, drift, runtimeChanged, st.Runtime, rt.Name())
+	displayConfigDiff(out, st.Config, cfg)
+
+	ok, err := prompt.Confirm("Rebuild container with new configuration?")
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		fmt.Println("Keeping existing container.")
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// updateResourcesIfNeeded applies changed memory and cpus limits, CPU
+// weight and cpuset pinning to the existing container with the runtime's
+// update command and records them in state. A failed update is a warning: the container keeps its old limits
+// and the drift stays, so 'alca rebuild' can still apply them.
+func updateResourcesIfNeeded(ctx context.Context, env *util.Env, tfs *transact.TransactFs, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, st *state.State, cwd string, out io.Writer) error {
+	if st.Runtime != rt.Name() || !st.DetectConfigDrift(cfg).ResourcesHotApplicable() {
+		return nil
+	}
+	status, err := rt.Status(ctx, runtimeEnv, cwd, st)
+	if err != nil || status.State == runtime.StateNotFound {
+		return nil
+	}
+
+	if err := rt.UpdateResources(ctx, runtimeEnv, status.Name, cfg.Resources); err != nil {
+		util.ProgressStep(out, "Warning: resource limits not changed in place: %v; 'alca rebuild' recreates the container with them\n", err)
+		return nil
+	}
+	util.ProgressStep(out, "Resource limits changed, applied without rebuild\n")
+
+	// The cgroup parent and io.latency keep drifting until a recreate
+	applied := *st.Config
+	applied.Resources.Memory, applied.Resources.CPUs = cfg.Resources.Memory, cfg.Resources.CPUs
+	cg := &applied.Resources.Cgroup
+	cg.CPUWeight, cg.CpusetCPUs, cg.CpusetMems = cfg.Resources.Cgroup.CPUWeight, cfg.Resources.Cgroup.CpusetCPUs, cfg.Resources.Cgroup.CpusetMems
+	st.Config = &applied
+	if err := state.Save(env, cwd, st); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	if err := CommitWithSudo(ctx, env, tfs, out, ""); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// relocateProject removes the container and firewall rules of a project
+// whose directory was moved or renamed since the container was created, so
+// the up that follows recreates them for cwd. The state (and with it the
+// project ID, container name and named volumes) moved with the directory.
+func relocateProject(ctx context.Context, env *util.Env, tfs *transact.TransactFs, cmdRunner util.CommandRunner, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, platform runtime.RuntimePlatform, cwd string, out io.Writer, force bool, prompt PromptMode) error {
+	oldDir, err := movedProjectDir(ctx, runtimeEnv, rt, st, cwd)
+	if err != nil || oldDir == "" {
+		return err
+	}
+
+	util.ProgressStep(out, "Project directory moved from %s to %s\n", oldDir, cwd)
+	if !force {
+		ok, err := prompt.Confirm("Recreate the container for the new directory?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("container still uses %s; run 'alca up' again to recreate it, or 'alca down' to remove it: %w", oldDir, ErrProjectPathMismatch)
+		}
+	}
+
+	// The rule file is named after the old directory, which a plain
+	// cleanup under cwd would not find
+	fw, _ := network.New(ctx, network.NewNetworkEnv(tfs, cmdRunner, oldDir, st.ProjectID, platform))
+	if err := CleanupFirewall(ctx, fw, env, tfs, runtimeEnv, rt, st, out); err != nil {
+		util.ProgressStep(out, "Warning: %v\n", err)
+	}
+
+	util.ProgressStep(out, "Removing container created in %s...\n", oldDir)
+	if err := rt.Down(ctx, runtimeEnv, oldDir, st); err != nil {
+		return fmt.Errorf("failed to remove container for relocation: %w", err)
+	}
+	return nil
+}
+
+// rebuildContainerIfNeeded removes the existing container for rebuild.
+func rebuildContainerIfNeeded(ctx context.Context, fs afero.Fs, runtimeEnv *runtime.RuntimeEnv, cfg *config.Config, st *state.State, rt runtime.Runtime, cwd string, out io.Writer) error {
+	// Determine which runtime to use for cleanup
+	cleanupRt := rt
+	runtimeChanged := st.Runtime != rt.Name()
+
+	if runtimeChanged {
+		// Runtime changed - use old runtime to remove old container
+		if oldRt := runtime.ByName(st.Runtime); oldRt != nil {
+			cleanupRt = oldRt
+			util.ProgressStep(out, "Runtime changed: %s → %s\n", st.Runtime, rt.Name())
+		}
+	}
+	status, _ := cleanupRt.Status(ctx, runtimeEnv, cwd, st)
+	if status.State != runtime.StateNotFound {
+		util.ProgressStep(out, "Removing existing container for rebuild...\n")
+		if err := cleanupRt.Down(ctx, runtimeEnv, cwd, st); err != nil {
+			return fmt.Errorf("failed to remove container for rebuild: %w", err)
+		}
+		EmitEvent(fs, cwd, st, lifecycle.Event{Type: lifecycle.ContainerRemoved, Container: status.Name, Message: "removed for rebuild"})
+	}
+
+	return nil
+}
+
+// setupNetwork configures network helper for LAN access.
+// See AGD-030 for design decisions.
+func setupNetwork(ctx context.Context, nh network.NetworkHelper, networkEnv *network.NetworkEnv, env *util.Env, tfs *transact.TransactFs, out io.Writer, prompt PromptMode) error {
+	progress := ProgressFunc(out)
+
+	// Check and install helper if needed
+	status := nh.HelperStatus(ctx, networkEnv)
+	if !status.Installed {
+		util.ProgressStep(out, "Network helper required for LAN access.\n")
+		ok, err := prompt.Confirm("Install now?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("LAN access requires network helper")
+		}
+	}
+
+	if !status.Installed || status.NeedsUpdate {
+		action, err := nh.InstallHelper(networkEnv, progress)
+		if err != nil {
+			return fmt.Errorf("failed to install network helper: %w", err)
+		}
+
+		if err := CommitIfNeeded(ctx, env, tfs, out, "Writing network helper to system directories"); err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+
+		if action.Run != nil {
+			if err := action.Run(ctx, progress); err != nil {
+				return fmt.Errorf("post-install failed: %w", err)
+			}
+		}
+	}
+
+	// Setup project rules
+	action, err := nh.Setup(networkEnv, networkEnv.ProjectDir, progress)
+	if err != nil {
+		return fmt.Errorf("failed to setup network: %w", err)
+	}
+
+	if err := CommitIfNeeded(ctx, env, tfs, out, "Writing firewall rules to system directories"); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	if action.Run != nil {
+		if err := action.Run(ctx, progress); err != nil {
+			return fmt.Errorf("post-setup failed: %w", err)
+		}
+	}
+
+	util.ProgressStep(out, "LAN access configured\n")
+	return nil
+}
+
+// ApplyShaping installs the configured bandwidth/latency limits on the
+// container's network interface. Shaping is a testing aid, not isolation,
+// so every failure — including unsupported platforms — is only a warning.
+func ApplyShaping(ctx context.Context, networkEnv *network.NetworkEnv, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, st *state.State, shaping config.Shaping, out io.Writer) {
+	if shaping.IsZero() {
+		return
+	}
+
+	pid, err := rt.GetContainerPID(ctx, runtimeEnv, st.ContainerName)
+	if err == nil {
+		err = network.ApplyShaping(ctx, networkEnv, pid, shaping)
+	}
+	switch {
+	case errors.Is(err, network.ErrShapingUnsupported):
+		util.ProgressStep(out, "Warning: network.shaping ignored: %v\n", err)
+	case err != nil:
+		util.ProgressStep(out, "Warning: failed to apply network shaping: %v\n", err)
+	default:
+		util.ProgressStep(out, "Network shaping applied (%s)\n", describeShaping(shaping))
+	}
+}
+
+// describeShaping renders shaping settings for progress output.
+func describeShaping(s config.Shaping) string {
+	var parts []string
+	if s.Rate != "" {
+		parts = append(parts, "rate "+s.Rate)
+	}
+	if s.Delay != "" {
+		parts = append(parts, "delay "+s.Delay)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SetupFirewall applies firewall rules for network isolation and transparent proxy.
+// Handles both lan-access isolation (AGD-027) and proxy DNAT (AGD-037) in one call.
+//
+// On success, returns a Network with expanded fields (alca tokens resolved to IPs).
+// The caller should persist this expanded config — not the raw cfg.Network — so that
+// state reflects what was actually applied.
+func SetupFirewall(ctx context.Context, fs afero.Fs, fw network.Firewall, fwType network.Type, networkEnv *network.NetworkEnv, env *util.Env, tfs *transact.TransactFs, runtimeEnv *runtime.RuntimeEnv, netCfg config.Network, rt runtime.Runtime, st *state.State, nh network.NetworkHelper, out io.Writer, prompt PromptMode) (config.Network, error) {
+	// Clean up stale rule files unconditionally — must run even when
+	// HasAllLAN or TypeNone would cause early returns below.
+	if fw != nil {
+		if staleCount, err := fw.CleanupStaleFiles(ctx); err != nil {
+			util.ProgressStep(out, "Warning: stale rule cleanup: %v\n", err)
+		} else if staleCount > 0 {
+			util.ProgressStep(out, "Cleaned up %d stale firewall rule file(s)\n", staleCount)
+		}
+	}
+
+	// Create token resolver once for both lan-access and proxy expansion.
+	// HOST_IP is resolved at most once via the resolver's internal cache,
+	// avoiding duplicate resolution when both lan-access and proxy are configured.
+	resolver := newAlcaTokenResolver(ctx, runtimeEnv, rt)
+
+	// Expand alca tokens in lan-access rules before parsing (AGD-036)
+	expandedLANAccess, err := config.ExpandAlcaTokensInStrings(netCfg.LANAccess, resolver)
+	if err != nil {
+		return config.Network{}, fmt.Errorf("expanding lan-access tokens: %w", err)
+	}
+
+	// Mirror type ensures all Network fields are carried forward (AGD-015).
+	// Missing a field here causes false drift detection on every `alca up`.
+	type networkFields struct {
+		LANAccess       []string
+		Ports           []config.PortConfig
+		Proxy           string
+		Shaping         config.Shaping
+		LogConnections  bool
+		AllowHostAccess bool
+		Isolation       string
+		Peers           []string
+		Preset          string
+		Presets         map[string]config.NetworkPreset
+	}
+
+	expandedNet := config.Network{
+		LANAccess:       expandedLANAccess,
+		Ports:           netCfg.Ports,
+		Proxy:           netCfg.Proxy,
+		Shaping:         netCfg.Shaping,
+		LogConnections:  netCfg.LogConnections,
+		AllowHostAccess: netCfg.AllowHostAccess,
+		Isolation:       netCfg.Isolation,
+		Peers:           netCfg.Peers,
+		Preset:          netCfg.Preset,
+		Presets:         netCfg.Presets,
+	}
+	_ = networkFields(expandedNet) // AGD-015: compile-time check on actual value
+
+	// Parse lan-access rules
+	rules, err := network.ParseLANAccessRules(expandedLANAccess)
+	if err != nil {
+		return config.Network{}, fmt.Errorf("invalid lan-access configuration: %w", err)
+	}
+
+	// Add temporary grants and drop expired ones; st is saved by the caller
+	rules, err = applyLANAccessGrants(st, rules, time.Now(), func(s string) (string, error) {
+		return config.ExpandAlcaTokens(s, resolver)
+	}, out)
+	if err != nil {
+		return config.Network{}, err
+	}
+
+	// Shared networks with peer projects; their subnets are allowed like lan-access
+	rules = append(rules, connectPeers(ctx, env, runtimeEnv, rt, networkEnv.ProjectDir, st, netCfg.Peers, out)...)
+
+	// network.allow_host_access allows the host behind host.alca.internal
+	if netCfg.AllowHostAccess {
+		rule, err := hostAccessRule(ctx, runtimeEnv, rt)
+		if err != nil {
+			return config.Network{}, err
+		}
+		rules = append(rules, rule)
+	}
+
+	// Expand and parse proxy config (AGD-037)
+	var proxy *network.ProxyConfig
+	if netCfg.Proxy != "" {
+		expandedProxy, err := config.ExpandAlcaTokens(netCfg.Proxy, resolver)
+		if err != nil {
+			return config.Network{}, fmt.Errorf("expanding proxy address tokens: %w", err)
+		}
+		expandedNet.Proxy = expandedProxy
+		proxyHost, proxyPort, err := config.ParseProxyAddress(expandedProxy)
+		if err != nil {
+			return config.Network{}, fmt.Errorf("invalid proxy address after expansion: %w", err)
+		}
+		// Mirror type ensures ProxyConfig field drift is caught at compile time (AGD-015).
+		type proxyFields struct {
+			Host string
+			Port int
+		}
+		_ = proxyFields(network.ProxyConfig{})
+		proxy = &network.ProxyConfig{Host: proxyHost, Port: proxyPort}
+	}
+
+	// Determine if any nftables work is needed. A container without a
+	// network has nothing to filter.
+	level := netCfg.IsolationLevel()
+	if level == config.IsolationNone {
+		util.ProgressStep(out, "Network disabled (isolation = none)\n")
+		return expandedNet, nil
+	}
+	strict := level == config.IsolationStrict
+	hasIsolation := strict || !network.HasAllLAN(rules)
+	hasProxy := proxy != nil
+	hasLogging := netCfg.LogConnections
+	if !hasIsolation && !hasProxy && !hasLogging {
+		return expandedNet, nil
+	}
+
+	// The network helper must be installed for nft reload.
+	if err := ensureNetworkHelper(ctx, nh, networkEnv, env, tfs, out, prompt); err != nil {
+		return config.Network{}, err
+	}
+
+	if fwType == network.TypeNone {
+		feature := firewallFeatures(hasIsolation, hasProxy, hasLogging)
+		proxyFallbackHint := ""
+		if hasProxy {
+			proxyFallbackHint = `
+Alternatively, set HTTP_PROXY/HTTPS_PROXY environment variables in your
+config to route traffic through your proxy without nftables.
+`
+		}
+		util.ProgressStep(out, `
+⚠️  %s not available
+
+Your system does not have a supported firewall backend.
+The container will start WITHOUT network rules.
+
+On Linux, install nftables:
+  1. Install nftables: sudo apt install nftables  # or yum/dnf/pacman
+  2. Ensure kernel version >= 3.13: uname -r
+  3. Restart Alcatraz
+%s
+`, feature, proxyFallbackHint)
+		return expandedNet, nil
+	}
+
+	if fw == nil {
+		return expandedNet, nil
+	}
+
+	// Get container status to find the container name
+	status, err := rt.Status(ctx, runtimeEnv, "", st)
+	if err != nil || status.State != runtime.StateRunning {
+		return config.Network{}, fmt.Errorf("container not running, cannot apply firewall rules")
+	}
+
+	// Get container IP
+	containerIP, err := rt.GetContainerIP(ctx, runtimeEnv, status.Name)
+	if err != nil {
+		return config.Network{}, fmt.Errorf("failed to get container IP: %w", err)
+	}
+
+	if hasIsolation {
+		util.ProgressStep(out, "Applying network isolation rules...\n")
+	}
+	if hasProxy {
+		util.ProgressStep(out, "Applying transparent proxy rules (→ %s:%d)...\n", proxy.Host, proxy.Port)
+	}
+
+	// Apply all firewall rules — isolation + proxy + logging (writes files via tfs)
+	action, err := fw.ApplyRules(network.RuleSpec{
+		ContainerID:    status.ID,
+		ContainerIP:    containerIP,
+		Rules:          rules,
+		Proxy:          proxy,
+		LogConnections: hasLogging,
+		Strict:         strict,
+	})
+	if err != nil {
+		return config.Network{}, fmt.Errorf("failed to apply firewall rules: %w", err)
+	}
+
+	// Commit tfs to write files to real disk
+	if err := CommitIfNeeded(ctx, env, tfs, out, "Writing firewall rules"); err != nil {
+		return config.Network{}, fmt.Errorf("commit firewall files: %w", err)
+	}
+
+	// Run post-commit action (nft -f or reload)
+	if action != nil && action.Run != nil {
+		if err := action.Run(ctx, nil); err != nil {
+			return config.Network{}, fmt.Errorf("load firewall rules: %w", err)
+		}
+	}
+
+	if strict {
+		util.ProgressStep(out, "Strict network isolation enabled (egress limited to lan-access and proxy)\n")
+	} else if hasIsolation {
+		util.ProgressStep(out, "Network isolation enabled\n")
+	}
+	if hasProxy {
+		util.ProgressStep(out, "Transparent proxy enabled\n")
+	}
+	if hasLogging {
+		util.ProgressStep(out, "Connection logging enabled (view with: alca network log)\n")
+	}
+	EmitEvent(fs, networkEnv.ProjectDir, st, lifecycle.Event{
+		Type:      lifecycle.FirewallApplied,
+		Container: status.Name,
+		Message:   firewallFeatures(hasIsolation, hasProxy, hasLogging) + " rules applied",
+		Details:   map[string]string{"firewall": fwType.String()},
+	})
+	return expandedNet, nil
+}
+
+// firewallFeatures names the requested nftables features for messages,
+// e.g. "Network isolation and transparent proxy".
+func firewallFeatures(isolation, proxy, logging bool) string {
+	var features []string
+	if isolation {
+		features = append(features, "network isolation")
+	}
+	if proxy {
+		features = append(features, "transparent proxy")
+	}
+	if logging {
+		features = append(features, "connection logging")
+	}
+	feature := strings.Join(features, " and ")
+	return strings.ToUpper(feature[:1]) + feature[1:]
+}
+
+// ensureNetworkHelper checks if the network helper is installed and prompts to install if needed.
+// Returns nil if the helper is already installed or was successfully installed.
+// Returns a non-nil error sentinel to signal the caller to skip firewall setup (not a real error).
+func ensureNetworkHelper(ctx context.Context, nh network.NetworkHelper, networkEnv *network.NetworkEnv, env *util.Env, tfs *transact.TransactFs, out io.Writer, prompt PromptMode) error {
+	if nh == nil {
+		return fmt.Errorf("no network helper available for this platform")
+	}
+
+	status := nh.HelperStatus(ctx, networkEnv)
+	if status.Installed {
+		return nil
+	}
+
+	util.ProgressStep(out, "Network helper required for network isolation.\n")
+	ok, err := prompt.Confirm("Install now?")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		util.ProgressStep(out, "Skipping network isolation — helper not installed\n")
+		return ErrSkipFirewall
+	}
+
+	// Install the helper (same flow as setupNetwork)
+	progress := ProgressFunc(out)
+	action, err := nh.InstallHelper(networkEnv, progress)
+	if err != nil {
+		return fmt.Errorf("failed to install network helper: %w", err)
+	}
+
+	if err := CommitIfNeeded(ctx, env, tfs, out, "Writing network helper to system directories"); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	if action.Run != nil {
+		if err := action.Run(ctx, progress); err != nil {
+			return fmt.Errorf("post-install failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// hostAccessRule returns the lan-access rule allowing every port of the
+// address runtime.HostAlias resolves to.
+func hostAccessRule(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime) (network.LANAccessRule, error) {
+	ip, err := rt.HostGatewayIP(ctx, runtimeEnv)
+	if err != nil {
+		return network.LANAccessRule{}, fmt.Errorf("allow_host_access: %w", err)
+	}
+	rule, err := network.ParseLANAccessRule(ip)
+	if err != nil {
+		return network.LANAccessRule{}, fmt.Errorf("allow_host_access: %w", err)
+	}
+	return rule, nil
+}
+
+// newAlcaTokenResolver creates a resolver func for ${alca:...} tokens.
+// Caches HOST_IP resolution so it's resolved at most once per call site.
+func newAlcaTokenResolver(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime) func(string) (string, error) {
+	var hostIP string
+	var hostIPErr error
+	var hostIPResolved bool
+
+	return func(name string) (string, error) {
+		if name != "HOST_IP" {
+			return "", fmt.Errorf("unhandled alca token %q", name)
+		}
+		if !hostIPResolved {
+			hostIP, hostIPErr = rt.GetHostIP(ctx, runtimeEnv)
+			hostIPResolved = true
+		}
+		return hostIP, hostIPErr
+	}
+}
+
+// expandAlcaTokensInRules expands ${alca:...} tokens in lan-access rule strings.
+// Resolves HOST_IP once (cached) via rt.GetHostIP, then expands all rules.
+// Rules without alca tokens are passed through unchanged.
+func expandAlcaTokensInRules(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, rules []string) ([]string, error) {
+	return config.ExpandAlcaTokensInStrings(rules, newAlcaTokenResolver(ctx, runtimeEnv, rt))
+}
+
+// SaveNetworkState selectively persists the network config section to state.
+// Only updates Network — preserves drift signals for image, mounts, etc.
+// Network changes are applied without a rebuild, so without this they would
+// be reported as drift on every later up.
+func SaveNetworkState(ctx context.Context, env *util.Env, tfs *transact.TransactFs, cwd string, netCfg config.Network, st *state.State, out io.Writer) error {
+	st.Config.Network = netCfg
+	if err := state.Save(env, cwd, st); err != nil {
+		return fmt.Errorf("failed to save network state: %w", err)
+	}
+	if err := CommitWithSudo(ctx, env, tfs, out, ""); err != nil {
+		return fmt.Errorf("failed to save network state: %w", err)
+	}
+	return nil
+}
+
+// parseOverrides parses --set values.
+func parseOverrides(sets []string) ([]config.Override, error) {
+	overrides := make([]config.Override, 0, len(sets))
+	for _, s := range sets {
+		o, err := config.ParseOverride(s)
+		if err != nil {
+			return nil, fmt.Errorf("--set: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, nil
+}
+
+// saveOverrides writes --set values to .alca.local.toml, warning when
+// .alca.toml does not include that file and later runs would not see them.
+func saveOverrides(ctx context.Context, env *util.Env, tfs *transact.TransactFs, cwd, configPath string, overrides []config.Override, out io.Writer) error {
+	localPath := filepath.Join(cwd, LocalConfigFilename)
+	if err := config.SaveOverrides(env.Fs, localPath, overrides); err != nil {
+		return fmt.Errorf("failed to save overrides: %w", err)
+	}
+	if err := CommitWithSudo(ctx, env, tfs, out, ""); err != nil {
+		return fmt.Errorf("failed to save overrides: %w", err)
+	}
+	util.ProgressStep(out, "Saved %d override(s) to %s\n", len(overrides), LocalConfigFilename)
+	if !config.IncludesFile(env, configPath, localPath, config.StrictExpandEnv, ConfigLoadOptions(ctx, env)...) {
+		util.ProgressStep(out, "Warning: %s does not include %s; add includes = [\"./%s\"] to keep the overrides\n", ConfigFilename, LocalConfigFilename, LocalConfigFilename)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// alca up steps recorded in .alca/up-progress.json.
+const (
+	upStepNetwork   = "network"
+	upStepContainer = "container"
+	upStepFirewall  = "firewall"
+	upStepHooks     = "hooks"
+)
+
+// upTracker records the progress of alca up and acts as its cleanup
+// controller: when a signal interrupts a step, the rollback registered for
+// that step runs so no half-created container or rule file is left behind.
+// Completed steps are kept; 'alca up --resume' skips the ones that need not
+// run again.
+type upTracker struct {
+	env        *util.Env
+	projectDir string
+	out        io.Writer
+	resume     bool
+	previous   *state.UpProgress
+	progress   state.UpProgress
+	finished   bool
+}
+
+// newUpTracker loads the progress of an earlier interrupted run, if any.
+func newUpTracker(env *util.Env, projectDir string, out io.Writer, resume bool, now time.Time) (*upTracker, error) {
+	previous, err := state.LoadUpProgress(env, projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &upTracker{
+		env:        env,
+		projectDir: projectDir,
+		out:        out,
+		resume:     resume,
+		previous:   previous,
+		progress:   state.UpProgress{StartedAt: now},
+	}
+
+	switch {
+	case previous != nil && resume:
+		util.ProgressStep(out, "Resuming 'alca up' interrupted %s (completed: %s)\n",
+			describeInterruption(previous), describeSteps(previous.Completed))
+	case previous != nil:
+		util.ProgressStep(out, "Previous 'alca up' was interrupted %s; starting over (use --resume to skip completed steps)\n",
+			describeInterruption(previous))
+	case resume:
+		util.ProgressStep(out, "No interrupted 'alca up' to resume; starting a full bring-up\n")
+	}
+	return t, nil
+}
+
+// interruptedDuring reports whether an earlier run died in step without rolling it back.
+func (t *upTracker) interruptedDuring(step string) bool {
+	return t.previous != nil && t.previous.Current == step
+}
+
+// skip reports whether a resumed run can skip step because it already completed.
+func (t *upTracker) skip(step string) bool {
+	if !t.resume || t.previous == nil || !t.previous.Done(step) {
+		return false
+	}
+	util.ProgressStep(t.out, "Skipping %s (completed before interruption)\n", step)
+	t.progress.Completed = append(t.progress.Completed, step)
+	return true
+}
+
+// step runs fn as the named step and records its completion. If ctx was
+// cancelled by a signal when fn fails, undo (if non-nil) runs on a context
+// that is no longer cancelled before the error is returned.
+func (t *upTracker) step(ctx context.Context, name string, undo func(context.Context) error, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("interrupted before %s: %w", name, err)
+	}
+
+	t.progress.Current = name
+	t.save()
+
+	if err := fn(); err != nil {
+		if ctx.Err() != nil && undo != nil {
+			util.ProgressStep(t.out, "Interrupted during %s, rolling back...\n", name)
+			if undoErr := undo(context.WithoutCancel(ctx)); undoErr != nil {
+				util.ProgressStep(t.out, "Warning: rollback of %s failed: %v\n", name, undoErr)
+				return err
+			}
+			t.progress.Current = ""
+			t.save()
+		}
+		return err
+	}
+
+	t.progress.Completed = append(t.progress.Completed, name)
+	t.progress.Current = ""
+	t.save()
+	return nil
+}
+
+// finish removes the progress file after a successful bring-up.
+func (t *upTracker) finish() {
+	t.finished = true
+	if err := state.DeleteUpProgress(t.env, t.projectDir); err != nil {
+		util.ProgressStep(t.out, "Warning: %v\n", err)
+	}
+}
+
+// abandon removes the progress file of a run that failed without being
+// interrupted, so the next run neither reports it as interrupted nor lets
+// --resume skip its steps. Interrupted runs keep their record; so does a
+// finished one.
+func (t *upTracker) abandon(ctx context.Context) {
+	if t.finished || ctx.Err() != nil {
+		return
+	}
+	t.finish()
+}
+
+// save persists progress. Best-effort: a write failure only costs resumability.
+func (t *upTracker) save() {
+	if err := state.SaveUpProgress(t.env, t.projectDir, &t.progress); err != nil {
+		util.ProgressStep(t.out, "Warning: %v\n", err)
+	}
+}
+
+// describeInterruption renders where an interrupted run stopped.
+func describeInterruption(p *state.UpProgress) string {
+	if p.Current != "" {
+		return "during " + p.Current
+	}
+	return "at " + p.StartedAt.Local().Format("2006-01-02 15:04:05")
+}
+
+// describeSteps renders a step list for progress output.
+func describeSteps(steps []string) string {
+	if len(steps) == 0 {
+		return "none"
+	}
+	return strings.Join(steps, ", ")
+}
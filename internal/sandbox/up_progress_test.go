@@ -0,0 +1,167 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func newTestTracker(t *testing.T, env *util.Env, resume bool, out *bytes.Buffer) *upTracker {
+	t.Helper()
+	tracker, err := newUpTracker(env, "/p", out, resume, time.Now())
+	if err != nil {
+		t.Fatalf("newUpTracker() error = %v", err)
+	}
+	return tracker
+}
+
+func TestUpTracker_RecordsCompletedSteps(t *testing.T) {
+	env := &util.Env{Fs: afero.NewMemMapFs()}
+	tracker := newTestTracker(t, env, false, &bytes.Buffer{})
+
+	if err := tracker.step(context.Background(), upStepNetwork, nil, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	failure := errors.New("up command failed")
+	err := tracker.step(context.Background(), upStepContainer, nil, func() error { return failure })
+	if !errors.Is(err, failure) {
+		t.Fatalf("step() error = %v, want %v", err, failure)
+	}
+
+	p, err := state.LoadUpProgress(env, "/p")
+	if err != nil || p == nil {
+		t.Fatalf("LoadUpProgress() = %v, %v", p, err)
+	}
+	if !slices.Equal(p.Completed, []string{upStepNetwork}) || p.Current != upStepContainer {
+		t.Errorf("progress = %+v, want network completed and container in flight", p)
+	}
+
+	tracker.finish()
+	if p, _ := state.LoadUpProgress(env, "/p"); p != nil {
+		t.Errorf("finish() should remove the progress file, got %+v", p)
+	}
+}
+
+func TestUpTracker_AbandonClearsFailedRun(t *testing.T) {
+	failure := errors.New("pull failed")
+	for _, interrupted := range []bool{false, true} {
+		env := &util.Env{Fs: afero.NewMemMapFs()}
+		tracker := newTestTracker(t, env, false, &bytes.Buffer{})
+		ctx, cancel := context.WithCancel(context.Background())
+		if interrupted {
+			cancel()
+		}
+
+		_ = tracker.step(context.Background(), upStepContainer, nil, func() error { return failure })
+		tracker.abandon(ctx)
+		cancel()
+
+		p, err := state.LoadUpProgress(env, "/p")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if interrupted && p == nil {
+			t.Error("abandon() should keep the progress of an interrupted run")
+		}
+		if !interrupted && p != nil {
+			t.Errorf("abandon() should clear the progress of a failed run, got %+v", p)
+		}
+	}
+}
+
+func TestUpTracker_RollsBackInterruptedStep(t *testing.T) {
+	env := &util.Env{Fs: afero.NewMemMapFs()}
+	tracker := newTestTracker(t, env, false, &bytes.Buffer{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	undone := false
+	err := tracker.step(ctx, upStepContainer, func(undoCtx context.Context) error {
+		if undoCtx.Err() != nil {
+			t.Error("rollback should get a live context")
+		}
+		undone = true
+		return nil
+	}, func() error {
+		cancel()
+		return ctx.Err()
+	})
+	if err == nil {
+		t.Fatal("step() should return the interruption")
+	}
+	if !undone {
+		t.Error("rollback did not run")
+	}
+	if p, _ := state.LoadUpProgress(env, "/p"); p == nil || p.Current != "" {
+		t.Errorf("rolled-back step should not stay in flight: %+v", p)
+	}
+
+	// Further steps do not start once interrupted
+	ran := false
+	_ = tracker.step(ctx, upStepHooks, nil, func() error { ran = true; return nil })
+	if ran {
+		t.Error("step ran after interruption")
+	}
+}
+
+func TestUpTracker_FailedRollbackStaysInFlight(t *testing.T) {
+	env := &util.Env{Fs: afero.NewMemMapFs()}
+	tracker := newTestTracker(t, env, false, &bytes.Buffer{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_ = tracker.step(ctx, upStepFirewall, func(context.Context) error {
+		return errors.New("nft busy")
+	}, func() error {
+		cancel()
+		return ctx.Err()
+	})
+
+	next := newTestTracker(t, env, false, &bytes.Buffer{})
+	if !next.interruptedDuring(upStepFirewall) {
+		t.Error("next run should see the firewall step as interrupted")
+	}
+}
+
+func TestUpTracker_Resume(t *testing.T) {
+	env := &util.Env{Fs: afero.NewMemMapFs()}
+	prev := &state.UpProgress{Completed: []string{upStepNetwork}, Current: upStepContainer}
+	if err := state.SaveUpProgress(env, "/p", prev); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("without --resume", func(t *testing.T) {
+		var out bytes.Buffer
+		tracker := newTestTracker(t, env, false, &out)
+		if tracker.skip(upStepNetwork) {
+			t.Error("skip() should be false without --resume")
+		}
+		if !tracker.interruptedDuring(upStepContainer) {
+			t.Error("interruptedDuring(container) should be true")
+		}
+		if !strings.Contains(out.String(), "starting over") {
+			t.Errorf("unexpected output: %q", out.String())
+		}
+	})
+
+	t.Run("with --resume", func(t *testing.T) {
+		var out bytes.Buffer
+		tracker := newTestTracker(t, env, true, &out)
+		if !tracker.skip(upStepNetwork) {
+			t.Error("skip(network) should be true when resuming")
+		}
+		if tracker.skip(upStepHooks) {
+			t.Error("skip(hooks) should be false: the step never completed")
+		}
+		if !strings.Contains(out.String(), "completed: network") {
+			t.Errorf("unexpected output: %q", out.String())
+		}
+	})
+}
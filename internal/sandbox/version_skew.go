@@ -0,0 +1,31 @@
+package sandbox
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+// FlagAllowVersionSkew is the flag that runs a destructive command on a
+// project managed by a much newer alca anyway.
+const FlagAllowVersionSkew = "allow-version-skew"
+
+// VersionSkewCheck warns when st was last saved by an alca newer than
+// cliVersion, and for destructive commands refuses one beyond
+// state.SkewWindow unless allow is set.
+func VersionSkewCheck(w io.Writer, st *state.State, cliVersion string, destructive, allow bool) error {
+	switch st.VersionSkew(cliVersion) {
+	case state.SkewMinor:
+		_, _ = fmt.Fprintf(w, "Warning: this project was last used with alca %s, newer than this alca %s. "+
+			"Run 'alca self-update' to upgrade.\n", st.AlcaVersion, cliVersion)
+	case state.SkewIncompatible:
+		_, _ = fmt.Fprintf(w, "Warning: this project was last used with alca %s, which may store state alca %s does not understand. "+
+			"Run 'alca self-update' to upgrade.\n", st.AlcaVersion, cliVersion)
+		if destructive && !allow {
+			return fmt.Errorf("%w: refusing to run with alca %s on a project managed by alca %s; upgrade alca, or pass --%s to run anyway",
+				errVersionSkew, cliVersion, st.AlcaVersion, FlagAllowVersionSkew)
+		}
+	}
+	return nil
+}
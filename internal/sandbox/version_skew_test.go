@@ -0,0 +1,43 @@
+package sandbox
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+func TestVersionSkewCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		stateVer    string
+		destructive bool
+		allow       bool
+		wantWarn    bool
+		wantErr     bool
+	}{
+		{"same version", "0.3.0", true, false, false, false},
+		{"older state", "0.2.0", true, false, false, false},
+		{"newer minor warns", "0.4.0", true, false, true, false},
+		{"incompatible read-only warns", "0.6.0", false, false, true, false},
+		{"incompatible destructive refused", "0.6.0", true, false, true, true},
+		{"incompatible destructive allowed", "0.6.0", true, true, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := VersionSkewCheck(&out, &state.State{AlcaVersion: tt.stateVer}, "0.3.0", tt.destructive, tt.allow)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errVersionSkew) {
+				t.Errorf("expected errVersionSkew, got %v", err)
+			}
+			if got := strings.Contains(out.String(), "Warning"); got != tt.wantWarn {
+				t.Errorf("warning printed = %v, want %v:\n%s", got, tt.wantWarn, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package sandbox
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/network"
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/sandboxinfo"
+	"github.com/bolasblack/alcatraz/internal/state"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// EnvSandboxInfo points sessions at the sandbox description.
+const EnvSandboxInfo = "ALCA_SANDBOX_INFO"
+
+// writeSandboxInfo writes the sandbox description into the running
+// container. net is the network config in effect and fwType the firewall
+// that applied it (TypeNone when none did). Best-effort: a failure is a
+// warning, since the sandbox works without it.
+func writeSandboxInfo(ctx context.Context, runtimeEnv *runtime.RuntimeEnv, rt runtime.Runtime, cfg *config.Config, st *state.State, cwd, containerName string, net config.Network, fwType network.Type, out io.Writer) {
+	firewall := ""
+	if fwType != network.TypeNone {
+		firewall = fwType.String()
+	}
+	info := sandboxinfo.New(cfg, st, cwd, containerName, rt.Name(), firewall, net, time.Now())
+	content, err := info.Marshal()
+	if err == nil {
+		err = rt.WriteFile(ctx, runtimeEnv, containerName, sandboxinfo.ContainerPath, content)
+	}
+	if err != nil {
+		util.ProgressStep(out, "Warning: failed to write sandbox info: %v\n", err)
+	}
+}
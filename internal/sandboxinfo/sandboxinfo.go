@@ -0,0 +1,149 @@
+// Package sandboxinfo describes a sandbox to the processes running in it:
+// the project, the network policy, the mounts and the resource limits. alca
+// up writes the description into the container as JSON at ContainerPath, so
+// agents can read what they are allowed to do and limit themselves
+// accordingly; alca whoami prints the same description on the host.
+//
+// The description never includes host paths: mounts are listed by their
+// container target only.
+package sandboxinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+// ContainerPath is where the description is written inside the container.
+const ContainerPath = "/run/alca/sandbox.json"
+
+// SchemaVersion is bumped when a field changes meaning or is removed;
+// readers should ignore fields they do not know.
+const SchemaVersion = 1
+
+// Info describes a sandbox.
+type Info struct {
+	SchemaVersion int       `json:"schema_version"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	ProjectID     string    `json:"project_id"`
+	ProjectName   string    `json:"project_name"`
+	Container     string    `json:"container"`
+	Runtime       string    `json:"runtime,omitempty"`
+	Workdir       string    `json:"workdir"`
+	User          string    `json:"user,omitempty"`
+	Network       Network   `json:"network"`
+	Mounts        []Mount   `json:"mounts"`
+	Resources     Resources `json:"resources"`
+	Caps          Caps      `json:"caps"`
+	IdleTimeout   string    `json:"idle_timeout,omitempty"`
+}
+
+// Network is the network policy the firewall enforces.
+type Network struct {
+	// Isolation is none, default, strict or custom (see network.isolation).
+	Isolation string `json:"isolation"`
+	// Firewall names the firewall enforcing the policy, empty when none is
+	// active (the policy is then not enforced).
+	Firewall        string   `json:"firewall,omitempty"`
+	LANAccess       []string `json:"lan_access,omitempty"`
+	AllowHostAccess bool     `json:"allow_host_access"`
+	Proxy           string   `json:"proxy,omitempty"`
+	Ports           []string `json:"ports,omitempty"`
+	Peers           []string `json:"peers,omitempty"`
+	Rate            string   `json:"rate,omitempty"`
+	Delay           string   `json:"delay,omitempty"`
+}
+
+// Mount is a directory shared with the host.
+type Mount struct {
+	Target   string   `json:"target"`
+	Readonly bool     `json:"readonly"`
+	Exclude  []string `json:"exclude,omitempty"`
+}
+
+// Resources are the container's resource limits; empty means unlimited.
+type Resources struct {
+	Memory     string `json:"memory,omitempty"`
+	CPUs       int    `json:"cpus,omitempty"`
+	CPUWeight  int    `json:"cpu_weight,omitempty"`
+	CpusetCPUs string `json:"cpuset_cpus,omitempty"`
+}
+
+// Caps are the Linux capabilities dropped from and added to the container.
+type Caps struct {
+	Drop []string `json:"drop,omitempty"`
+	Add  []string `json:"add,omitempty"`
+}
+
+// New describes the sandbox of the project in projectDir. net is the
+// network config in effect (with tokens resolved, when the firewall
+// applied it); firewall names the active firewall, if any.
+func New(cfg *config.Config, st *state.State, projectDir, containerName, runtimeName, firewall string, net config.Network, now time.Time) Info {
+	info := Info{
+		SchemaVersion: SchemaVersion,
+		UpdatedAt:     now.UTC(),
+		ProjectID:     st.ProjectID,
+		ProjectName:   filepath.Base(projectDir),
+		Container:     containerName,
+		Runtime:       runtimeName,
+		Workdir:       cfg.Workdir,
+		User:          cfg.ExecUser(),
+		Network: Network{
+			Isolation:       net.IsolationLevel(),
+			Firewall:        firewall,
+			LANAccess:       net.LANAccess,
+			AllowHostAccess: net.AllowHostAccess,
+			Proxy:           net.Proxy,
+			Peers:           peerNames(net.Peers),
+			Rate:            net.Shaping.Rate,
+			Delay:           net.Shaping.Delay,
+		},
+		Mounts: make([]Mount, 0, len(cfg.Mounts)),
+		Resources: Resources{
+			Memory:     cfg.Resources.Memory,
+			CPUs:       cfg.Resources.CPUs,
+			CPUWeight:  cfg.Resources.Cgroup.CPUWeight,
+			CpusetCPUs: cfg.Resources.Cgroup.CpusetCPUs,
+		},
+		Caps:        Caps{Drop: cfg.Caps.Drop, Add: cfg.Caps.Add},
+		IdleTimeout: cfg.IdleTimeout,
+	}
+	for _, p := range net.Ports {
+		info.Network.Ports = append(info.Network.Ports, config.FormatPortArg(p))
+	}
+	for _, m := range cfg.Mounts {
+		info.Mounts = append(info.Mounts, Mount{Target: m.Target, Readonly: m.Readonly, Exclude: m.Exclude})
+	}
+	return info
+}
+
+// peerNames returns the names peers are reachable by: their directory names.
+func peerNames(peers []string) []string {
+	var names []string
+	for _, p := range peers {
+		names = append(names, filepath.Base(p))
+	}
+	return names
+}
+
+// Marshal returns the description as indented JSON with a trailing newline.
+func (i Info) Marshal() (string, error) {
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sandbox info: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// Parse reads a description written by Marshal.
+func Parse(data []byte) (Info, error) {
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return Info{}, fmt.Errorf("failed to parse sandbox info: %w", err)
+	}
+	return info, nil
+}
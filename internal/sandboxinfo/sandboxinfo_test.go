@@ -0,0 +1,47 @@
+package sandboxinfo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/state"
+)
+
+func TestNew(t *testing.T) {
+	cfg := &config.Config{
+		Workdir: "/workspace",
+		Mounts: []config.MountConfig{
+			{Source: "/home/me/secret-project", Target: "/workspace"},
+			{Source: "/home/me/.config/tool", Target: "/root/.config/tool", Readonly: true},
+		},
+		Resources: config.Resources{Memory: "4g", CPUs: 2},
+	}
+	net := config.Network{
+		LANAccess: []string{"192.168.1.10:443"},
+		Ports:     []config.PortConfig{{Port: 8080}},
+		Peers:     []string{"/home/me/api"},
+	}
+	st := &state.State{ProjectID: "1234"}
+
+	info := New(cfg, st, "/home/me/secret-project", "alca-1234", "Docker", "nftables", net, time.Unix(0, 0))
+	data, err := info.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(data, "/home/me") {
+		t.Errorf("description exposes host paths:\n%s", data)
+	}
+
+	got, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ProjectName != "secret-project" || got.Network.Isolation != config.IsolationCustom ||
+		len(got.Network.Peers) != 1 || got.Network.Peers[0] != "api" ||
+		len(got.Network.Ports) != 1 || len(got.Mounts) != 2 || !got.Mounts[1].Readonly ||
+		got.Resources.CPUs != 2 {
+		t.Errorf("Parse(Marshal()) = %+v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package sbom
+
+import "time"
+
+// CycloneDX 1.5 JSON, the subset alca writes.
+// See https://cyclonedx.org/docs/1.5/json/.
+
+type cdxBOM struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cycloneDX converts doc to a CycloneDX BOM whose metadata component is the
+// image. The layer and package manager are recorded as alca: properties.
+func cycloneDX(doc Document) cdxBOM {
+	components := make([]cdxComponent, 0, len(doc.Components))
+	for _, c := range doc.Components {
+		components = append(components, cdxComponent{
+			Type:    "library",
+			BOMRef:  c.PURL,
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.PURL,
+			Properties: []cdxProperty{
+				{Name: "alca:layer", Value: c.Layer},
+				{Name: "alca:package-manager", Value: c.Manager},
+			},
+		})
+	}
+
+	image := cdxComponent{Type: "container", BOMRef: "image", Name: doc.Image}
+	if doc.OSID != "" {
+		image.Properties = []cdxProperty{{Name: "alca:os", Value: doc.OSID + " " + doc.OSVersion}}
+	}
+	return cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + doc.Serial,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: doc.Created.UTC().Format(time.RFC3339),
+			Tools: cdxTools{Components: []cdxComponent{
+				{Type: "application", Name: "alca", Version: doc.ToolVersion},
+			}},
+			Component: image,
+		},
+		Components: components,
+	}
+}
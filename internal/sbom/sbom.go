@@ -0,0 +1,131 @@
+// Package sbom builds software bills of materials for the sandbox: the OS
+// packages of the configured image plus the ones the up command installed,
+// found by diffing the container's package databases against the image's.
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+// Supported document formats.
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+// Layers a component can come from.
+const (
+	// LayerImage marks packages shipped by the image.
+	LayerImage = "image"
+	// LayerUp marks packages installed or upgraded after the container was
+	// created, normally by commands.up.
+	LayerUp = "up-command"
+)
+
+// Component is a package in the container with the layer it came from.
+type Component struct {
+	runtime.Package
+	Layer string
+	PURL  string
+}
+
+// Document is an SBOM before encoding.
+type Document struct {
+	// Image is the configured image the container was created from.
+	Image string
+	// Serial uniquely identifies this document (a UUID).
+	Serial string
+	// Created is when the document was generated.
+	Created time.Time
+	// ToolVersion is the alca version that generated it.
+	ToolVersion string
+	// OSID and OSVersion come from the container's /etc/os-release.
+	OSID      string
+	OSVersion string
+	// Components are the container's packages, in PackageList order.
+	Components []Component
+}
+
+// Diff returns the container's packages, each marked LayerImage when the
+// image has the same version and LayerUp otherwise. Packages the image has
+// but the container no longer does are omitted: the SBOM describes the
+// container.
+func Diff(image, container runtime.PackageList) []Component {
+	inImage := make(map[runtime.Package]bool, len(image.Packages))
+	for _, p := range image.Packages {
+		inImage[p] = true
+	}
+
+	osID := container.OSID
+	if osID == "" {
+		osID = image.OSID
+	}
+	components := make([]Component, 0, len(container.Packages))
+	for _, p := range container.Packages {
+		layer := LayerUp
+		if inImage[p] {
+			layer = LayerImage
+		}
+		components = append(components, Component{Package: p, Layer: layer, PURL: PURL(p, osID)})
+	}
+	return components
+}
+
+// purlTypes maps package managers to package URL types.
+var purlTypes = map[string]string{
+	"apk":  "apk",
+	"dpkg": "deb",
+	"rpm":  "rpm",
+}
+
+// PURL returns the package URL of p, namespaced by the distribution ID
+// when known, e.g. pkg:deb/debian/bash@5.2.15-2?arch=amd64.
+func PURL(p runtime.Package, osID string) string {
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(purlTypes[p.Manager])
+	b.WriteString("/")
+	if osID != "" {
+		b.WriteString(purlEscape(strings.ToLower(osID)))
+		b.WriteString("/")
+	}
+	b.WriteString(purlEscape(p.Name))
+	if p.Version != "" {
+		b.WriteString("@")
+		b.WriteString(purlEscape(p.Version))
+	}
+	if p.Arch != "" {
+		b.WriteString("?arch=")
+		b.WriteString(purlEscape(p.Arch))
+	}
+	return b.String()
+}
+
+// purlEscape percent-encodes a purl segment; '+' is common in Debian
+// versions and must not read as a space.
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// Encode writes doc to w in format.
+func Encode(w io.Writer, doc Document, format string) error {
+	var v any
+	switch format {
+	case FormatCycloneDX:
+		v = cycloneDX(doc)
+	case FormatSPDX:
+		v = spdx(doc)
+	default:
+		return fmt.Errorf("unknown SBOM format %q, valid options: %s, %s", format, FormatCycloneDX, FormatSPDX)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,135 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+)
+
+var (
+	imageList = runtime.PackageList{
+		OSID: "debian", OSVersion: "12",
+		Packages: []runtime.Package{
+			{Manager: "dpkg", Name: "bash", Version: "5.2.15-2+b7", Arch: "amd64"},
+			{Manager: "dpkg", Name: "curl", Version: "7.88.1-10", Arch: "amd64"},
+			{Manager: "dpkg", Name: "wget", Version: "1.21.3-1", Arch: "amd64"},
+		},
+	}
+	containerList = runtime.PackageList{
+		OSID: "debian", OSVersion: "12",
+		Packages: []runtime.Package{
+			{Manager: "dpkg", Name: "bash", Version: "5.2.15-2+b7", Arch: "amd64"},
+			{Manager: "dpkg", Name: "curl", Version: "7.88.1-10+deb12u8", Arch: "amd64"},
+			{Manager: "dpkg", Name: "git", Version: "1:2.39.5-0+deb12u1", Arch: "amd64"},
+		},
+	}
+)
+
+func TestDiff(t *testing.T) {
+	got := Diff(imageList, containerList)
+
+	want := map[string]string{
+		"bash": LayerImage,
+		"curl": LayerUp, // upgraded
+		"git":  LayerUp,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Diff() = %+v, want %d components (wget was removed)", got, len(want))
+	}
+	for _, c := range got {
+		if c.Layer != want[c.Name] {
+			t.Errorf("%s layer = %q, want %q", c.Name, c.Layer, want[c.Name])
+		}
+	}
+}
+
+func TestPURL(t *testing.T) {
+	tests := []struct {
+		pkg  runtime.Package
+		osID string
+		want string
+	}{
+		{runtime.Package{Manager: "dpkg", Name: "git", Version: "1:2.39.5-0+deb12u1", Arch: "amd64"}, "debian",
+			"pkg:deb/debian/git@1%3A2.39.5-0%2Bdeb12u1?arch=amd64"},
+		{runtime.Package{Manager: "apk", Name: "musl", Version: "1.2.5-r8", Arch: "x86_64"}, "alpine",
+			"pkg:apk/alpine/musl@1.2.5-r8?arch=x86_64"},
+		{runtime.Package{Manager: "rpm", Name: "git", Version: "2.47.1-1.fc41"}, "",
+			"pkg:rpm/git@2.47.1-1.fc41"},
+	}
+	for _, tt := range tests {
+		if got := PURL(tt.pkg, tt.osID); got != tt.want {
+			t.Errorf("PURL(%+v, %q) = %q, want %q", tt.pkg, tt.osID, got, tt.want)
+		}
+	}
+}
+
+func testDocument() Document {
+	return Document{
+		Image:       "debian:12",
+		Serial:      "0b1e2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d",
+		Created:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		ToolVersion: "1.2.3",
+		OSID:        "debian",
+		OSVersion:   "12",
+		Components:  Diff(imageList, containerList),
+	}
+}
+
+func TestEncode_CycloneDX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, testDocument(), FormatCycloneDX); err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+
+	var bom cdxBOM
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" || bom.SerialNumber != "urn:uuid:0b1e2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d" {
+		t.Errorf("header = %+v", bom)
+	}
+	if bom.Metadata.Timestamp != "2026-01-02T03:04:05Z" || bom.Metadata.Component.Name != "debian:12" {
+		t.Errorf("metadata = %+v", bom.Metadata)
+	}
+	if len(bom.Components) != 3 {
+		t.Fatalf("components = %+v", bom.Components)
+	}
+	git := bom.Components[2]
+	if git.PURL != "pkg:deb/debian/git@1%3A2.39.5-0%2Bdeb12u1?arch=amd64" || git.Properties[0] != (cdxProperty{Name: "alca:layer", Value: LayerUp}) {
+		t.Errorf("git component = %+v", git)
+	}
+}
+
+func TestEncode_SPDX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, testDocument(), FormatSPDX); err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+
+	var doc spdxDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if doc.SPDXVersion != "SPDX-2.3" || doc.CreationInfo.Creators[0] != "Tool: alca-1.2.3" {
+		t.Errorf("header = %+v", doc)
+	}
+	// The image package plus one per component, each contained by the image
+	if len(doc.Packages) != 4 || len(doc.Relationships) != 4 {
+		t.Fatalf("packages = %d, relationships = %d", len(doc.Packages), len(doc.Relationships))
+	}
+	if doc.Packages[1].Comment != "" || doc.Packages[3].Comment == "" {
+		t.Errorf("up-command comments: bash %q, git %q", doc.Packages[1].Comment, doc.Packages[3].Comment)
+	}
+	if r := doc.Relationships[3]; r.SPDXElementID != spdxImageID || r.RelationshipType != "CONTAINS" || r.RelatedSPDXElement != "SPDXRef-Package-3" {
+		t.Errorf("relationship = %+v", r)
+	}
+}
+
+func TestEncode_UnknownFormat(t *testing.T) {
+	if err := Encode(&bytes.Buffer{}, Document{}, "syft"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
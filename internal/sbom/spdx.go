@@ -0,0 +1,104 @@
+package sbom
+
+import (
+	"fmt"
+	"time"
+)
+
+// SPDX 2.3 JSON, the subset alca writes.
+// See https://spdx.github.io/spdx-spec/v2.3/.
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	PrimaryPurpose   string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	Comment          string            `json:"comment,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxImageID is the SPDX ID of the image package the document describes.
+const spdxImageID = "SPDXRef-Image"
+
+// spdx converts doc to an SPDX document that describes the image, which
+// contains every package. Packages from the up command carry a comment.
+func spdx(doc Document) spdxDocument {
+	packages := []spdxPackage{{
+		Name:             doc.Image,
+		SPDXID:           spdxImageID,
+		DownloadLocation: "NOASSERTION",
+		PrimaryPurpose:   "CONTAINER",
+	}}
+	relationships := []spdxRelationship{{
+		SPDXElementID:      "SPDXRef-DOCUMENT",
+		RelationshipType:   "DESCRIBES",
+		RelatedSPDXElement: spdxImageID,
+	}}
+	for i, c := range doc.Components {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		p := spdxPackage{
+			Name:             c.Name,
+			SPDXID:           id,
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  c.PURL,
+			}},
+		}
+		if c.Layer == LayerUp {
+			p.Comment = "Installed after container creation (commands.up)"
+		}
+		packages = append(packages, p)
+		relationships = append(relationships, spdxRelationship{
+			SPDXElementID:      spdxImageID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	return spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              doc.Image,
+		DocumentNamespace: "https://spdx.org/spdxdocs/alca-" + doc.Serial,
+		CreationInfo: spdxCreationInfo{
+			Created:  doc.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: alca-" + doc.ToolVersion},
+		},
+		Packages:      packages,
+		Relationships: relationships,
+	}
+}
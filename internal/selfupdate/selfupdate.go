@@ -0,0 +1,221 @@
+// Package selfupdate replaces the running alca binary with a release from
+// GitHub or a downloaded archive: it finds the release for a channel,
+// checks the archive against the release's checksums.txt (and its minisign
+// signature), and swaps the binary in with a rename.
+package selfupdate
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/minisign"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// Release channels.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+const (
+	// DefaultAPIURL lists the project's releases.
+	DefaultAPIURL = "https://api.github.com/repos/bolasblack/alcatraz/releases"
+	// ChecksumsName is the release asset with the archives' SHA-256 sums.
+	ChecksumsName = "checksums.txt"
+	// SignatureName is the minisign signature of ChecksumsName.
+	SignatureName = ChecksumsName + ".minisig"
+	// BinaryName is the executable inside release archives.
+	BinaryName = "alca"
+	// maxBinarySize bounds what is extracted from an archive.
+	maxBinarySize = 512 << 20
+)
+
+var (
+	// ErrNoRelease is returned when no release matches the channel.
+	ErrNoRelease = errors.New("no release found")
+	// ErrChecksumMismatch is returned when an archive does not match checksums.txt.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrUnsigned is returned when a signature is required but missing.
+	ErrUnsigned = errors.New("release is not signed")
+)
+
+// Release is a GitHub release.
+type Release struct {
+	Tag        string  `json:"tag_name"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release tag without its leading v.
+func (r Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// Asset returns the named asset.
+func (r Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// ArchiveName returns the release archive for a platform, as named by
+// .goreleaser.yml.
+func ArchiveName(version, goos, goarch string) string {
+	return fmt.Sprintf("alcatraz_%s_%s_%s.tar.gz", strings.TrimPrefix(version, "v"), goos, goarch)
+}
+
+// Client fetches releases and their assets.
+type Client struct {
+	HTTP   *http.Client
+	APIURL string
+}
+
+// Latest returns the newest release on channel: stable skips prereleases,
+// beta includes them. Drafts are never returned.
+func (c *Client) Latest(ctx context.Context, channel string) (Release, error) {
+	data, err := c.get(ctx, c.APIURL+"?per_page=30")
+	if err != nil {
+		return Release{}, err
+	}
+	var releases []Release
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return Release{}, fmt.Errorf("failed to parse releases: %w", err)
+	}
+	// GitHub lists releases newest first
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+		return r, nil
+	}
+	return Release{}, fmt.Errorf("%w on the %s channel", ErrNoRelease, channel)
+}
+
+// Download returns the content of a release asset.
+func (c *Client) Download(ctx context.Context, a Asset) ([]byte, error) {
+	return c.get(ctx, a.URL)
+}
+
+func (c *Client) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// VerifyChecksum checks archive against its line in a checksums.txt
+// ("<sha256>  <name>" per line).
+func VerifyChecksum(checksums []byte, name string, archive []byte) error {
+	sum := sha256.Sum256(archive)
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[1] != name {
+			continue
+		}
+		if fields[0] != hex.EncodeToString(sum[:]) {
+			return fmt.Errorf("%s: %w", name, ErrChecksumMismatch)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s is not listed in %s: %w", name, ChecksumsName, ErrChecksumMismatch)
+}
+
+// VerifySignature checks the minisign signature of checksums against keys.
+// A nil sig is ErrUnsigned.
+func VerifySignature(keys []minisign.Key, checksums, sig []byte) error {
+	if sig == nil {
+		return fmt.Errorf("%s is missing: %w", SignatureName, ErrUnsigned)
+	}
+	if err := minisign.Verify(keys, checksums, sig); err != nil {
+		return fmt.Errorf("%s: %w", SignatureName, err)
+	}
+	return nil
+}
+
+// ExtractBinary returns BinaryName from a .tar.gz release archive.
+func ExtractBinary(archive io.Reader) ([]byte, error) {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("not a .tar.gz archive: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("archive has no %s binary", BinaryName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || filepath.Base(hdr.Name) != BinaryName {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxBinarySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", BinaryName, err)
+		}
+		if len(data) > maxBinarySize {
+			return nil, fmt.Errorf("%s in archive is larger than %d bytes", BinaryName, maxBinarySize)
+		}
+		return data, nil
+	}
+}
+
+// Install replaces the executable at path with binary. The new binary is
+// written next to it and renamed over it, so the swap is atomic and a
+// running alca keeps its open file. The old binary's mode is kept.
+func Install(fs afero.Fs, path string, binary []byte) error {
+	info, err := fs.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := util.WriteFileAtomic(fs, path, binary, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// ManagedBy returns the package manager that installed the executable at
+// path ("Homebrew" or "Nix"), which should update it instead, or "".
+func ManagedBy(path string) string {
+	switch {
+	case strings.Contains(path, "/Cellar/") || strings.Contains(path, "/homebrew/"):
+		return "Homebrew"
+	case strings.HasPrefix(path, "/nix/store/"):
+		return "Nix"
+	}
+	return ""
+}
@@ -0,0 +1,219 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/minisign"
+)
+
+// makeArchive returns a .tar.gz holding files.
+func makeArchive(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func checksumLine(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + "  " + name + "\n"
+}
+
+// signPure returns a key and a minisign signature file for data.
+func signPure(t *testing.T, data []byte) (minisign.Key, []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	sig := ed25519.Sign(priv, data)
+	trusted := "timestamp:1767225600\tfile:checksums.txt"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+	blob := append(append([]byte(minisign.AlgPure), id[:]...), sig...)
+	raw := append(append([]byte(minisign.AlgPure), id[:]...), pub...)
+	key, err := minisign.ParseKey(base64.StdEncoding.EncodeToString(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, []byte("untrusted comment: signature\n" +
+		base64.StdEncoding.EncodeToString(blob) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestClientLatest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"tag_name": "v0.5.0", "draft": true},
+			{"tag_name": "v0.4.0-rc.1", "prerelease": true},
+			{"tag_name": "v0.3.0", "assets": [{"name": "checksums.txt", "browser_download_url": "http://x/checksums.txt"}]}
+		]`))
+	}))
+	defer srv.Close()
+	c := &Client{HTTP: srv.Client(), APIURL: srv.URL}
+
+	tests := []struct {
+		channel string
+		want    string
+	}{
+		{ChannelStable, "v0.3.0"},
+		{ChannelBeta, "v0.4.0-rc.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			r, err := c.Latest(context.Background(), tt.channel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Tag != tt.want {
+				t.Errorf("Latest(%s) = %s, want %s", tt.channel, r.Tag, tt.want)
+			}
+		})
+	}
+
+	r, _ := c.Latest(context.Background(), ChannelStable)
+	if a, ok := r.Asset(ChecksumsName); !ok || a.URL != "http://x/checksums.txt" {
+		t.Errorf("Asset(%s) = %+v, %v", ChecksumsName, a, ok)
+	}
+}
+
+func TestClientLatest_NoRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"tag_name": "v0.4.0-rc.1", "prerelease": true}]`))
+	}))
+	defer srv.Close()
+	c := &Client{HTTP: srv.Client(), APIURL: srv.URL}
+	if _, err := c.Latest(context.Background(), ChannelStable); !errors.Is(err, ErrNoRelease) {
+		t.Errorf("expected ErrNoRelease, got %v", err)
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	if got := ArchiveName("v0.3.0", "linux", "arm64"); got != "alcatraz_0.3.0_linux_arm64.tar.gz" {
+		t.Errorf("ArchiveName = %s", got)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	archive := []byte("archive")
+	checksums := []byte(checksumLine("other.tar.gz", []byte("x")) + checksumLine("a.tar.gz", archive))
+
+	if err := VerifyChecksum(checksums, "a.tar.gz", archive); err != nil {
+		t.Errorf("valid checksum: %v", err)
+	}
+	if err := VerifyChecksum(checksums, "a.tar.gz", []byte("tampered")); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("tampered archive: expected ErrChecksumMismatch, got %v", err)
+	}
+	if err := VerifyChecksum(checksums, "b.tar.gz", archive); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("unlisted archive: expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	checksums := []byte(checksumLine("a.tar.gz", []byte("archive")))
+	key, sig := signPure(t, checksums)
+	keys := []minisign.Key{key}
+
+	if err := VerifySignature(keys, checksums, sig); err != nil {
+		t.Errorf("valid signature: %v", err)
+	}
+	var sigErr *minisign.Error
+	if err := VerifySignature(keys, append(checksums, 'x'), sig); !errors.As(err, &sigErr) {
+		t.Errorf("tampered checksums: expected *minisign.Error, got %v", err)
+	}
+	if err := VerifySignature(keys, checksums, nil); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("missing signature: expected ErrUnsigned, got %v", err)
+	}
+}
+
+func TestExtractBinary(t *testing.T) {
+	archive := makeArchive(t, map[string][]byte{
+		"README.md": []byte("readme"),
+		"alca":      []byte("new binary"),
+	})
+	got, err := ExtractBinary(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new binary" {
+		t.Errorf("ExtractBinary = %q", got)
+	}
+
+	if _, err := ExtractBinary(bytes.NewReader(makeArchive(t, map[string][]byte{"README.md": nil}))); err == nil {
+		t.Error("expected error for archive without alca")
+	}
+	if _, err := ExtractBinary(bytes.NewReader([]byte("not gzip"))); err == nil {
+		t.Error("expected error for non-gzip input")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/usr/local/bin/alca"
+	if err := afero.WriteFile(fs, path, []byte("old"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := Install(fs, path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("binary = %q, want new", data)
+	}
+	info, _ := fs.Stat(path)
+	if info.Mode().Perm() != 0o750 {
+		t.Errorf("mode = %v, want 0750", info.Mode().Perm())
+	}
+	entries, _ := afero.ReadDir(fs, filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
+
+func TestManagedBy(t *testing.T) {
+	tests := map[string]string{
+		"/opt/homebrew/Cellar/alca/0.3.0/bin/alca": "Homebrew",
+		"/usr/local/Cellar/alca/0.3.0/bin/alca":    "Homebrew",
+		"/nix/store/abc-alca-0.3.0/bin/alca":       "Nix",
+		"/usr/local/bin/alca":                      "",
+	}
+	for path, want := range tests {
+		if got := ManagedBy(path); got != want {
+			t.Errorf("ManagedBy(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package state
+
+import "time"
+
+// MaxEvents is how many container events State keeps.
+const MaxEvents = 20
+
+// Event kinds.
+const (
+	// EventOOM is a process ended by the out-of-memory killer.
+	EventOOM = "oom"
+	// EventKilled is a process ended by SIGKILL (exit 137) for another reason.
+	EventKilled = "killed"
+	// EventRestart is the container restarted by its restart policy.
+	EventRestart = "restart"
+	// EventCrash is the container's main process exiting with an error.
+	EventCrash = "crash"
+)
+
+// Event is a notable container exit, shown by alca status.
+type Event struct {
+	// Time is when the event was observed, or when the container stopped.
+	Time time.Time `json:"time"`
+	// Kind is one of the Event* constants.
+	Kind string `json:"kind"`
+	// Source is what ended: "container", "up command" or "exec".
+	Source string `json:"source"`
+	// ExitCode is the exit code of what ended, if known.
+	ExitCode int `json:"exit_code,omitempty"`
+	// Message describes the event for humans.
+	Message string `json:"message"`
+}
+
+// EventCounters are the runtime counters events were last derived from, so
+// each OOM kill or restart is recorded once.
+type EventCounters struct {
+	// OOMKills is the container cgroup's oom_kill count.
+	OOMKills int `json:"oom_kills,omitempty"`
+	// Restarts is the container's restart count.
+	Restarts int `json:"restarts,omitempty"`
+}
+
+// AddEvent records e, keeping the latest MaxEvents events. An event equal to
+// the latest one is not recorded twice.
+func (s *State) AddEvent(e Event) {
+	if n := len(s.Events); n > 0 && s.Events[n-1] == e {
+		return
+	}
+	s.Events = append(s.Events, e)
+	if len(s.Events) > MaxEvents {
+		s.Events = s.Events[len(s.Events)-MaxEvents:]
+	}
+}
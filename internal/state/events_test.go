@@ -0,0 +1,26 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAddEvent(t *testing.T) {
+	st := &State{}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range MaxEvents + 5 {
+		st.AddEvent(Event{Time: base.Add(time.Duration(i) * time.Minute), Kind: EventOOM, Message: fmt.Sprint(i)})
+	}
+	if len(st.Events) != MaxEvents {
+		t.Fatalf("len(Events) = %d, want %d", len(st.Events), MaxEvents)
+	}
+	if st.Events[0].Message != "5" || st.Events[MaxEvents-1].Message != fmt.Sprint(MaxEvents+4) {
+		t.Errorf("expected the latest events kept, got first %q last %q", st.Events[0].Message, st.Events[MaxEvents-1].Message)
+	}
+
+	st.AddEvent(st.Events[MaxEvents-1])
+	if len(st.Events) != MaxEvents || st.Events[MaxEvents-2].Message == st.Events[MaxEvents-1].Message {
+		t.Error("an event equal to the latest should not be recorded twice")
+	}
+}
@@ -0,0 +1,53 @@
+package state
+
+import "time"
+
+// LANAccessGrant is a temporary lan-access rule. Expires is absolute so the
+// grant survives restarts of alca without being extended.
+type LANAccessGrant struct {
+	// Rule is the lan-access rule without its ttl option (e.g. "192.168.1.50:5432").
+	Rule    string    `json:"rule"`
+	Expires time.Time `json:"expires"`
+}
+
+// Expired reports whether the grant no longer applies at now.
+func (g LANAccessGrant) Expired(now time.Time) bool {
+	return !now.Before(g.Expires)
+}
+
+// Grant returns the recorded grant for rule, if any.
+func (s *State) Grant(rule string) (LANAccessGrant, bool) {
+	for _, g := range s.LANAccessGrants {
+		if g.Rule == rule {
+			return g, true
+		}
+	}
+	return LANAccessGrant{}, false
+}
+
+// SetGrant records or replaces the grant for a rule.
+func (s *State) SetGrant(rule string, expires time.Time) {
+	for i, g := range s.LANAccessGrants {
+		if g.Rule == rule {
+			s.LANAccessGrants[i].Expires = expires
+			return
+		}
+	}
+	s.LANAccessGrants = append(s.LANAccessGrants, LANAccessGrant{Rule: rule, Expires: expires})
+}
+
+// PruneGrants drops expired grants and returns them. Grants for rules in keep
+// are retained even when expired: they come from ttl rules in the config, and
+// forgetting them would restart their ttl on the next up.
+func (s *State) PruneGrants(now time.Time, keep map[string]bool) []LANAccessGrant {
+	var kept, pruned []LANAccessGrant
+	for _, g := range s.LANAccessGrants {
+		if g.Expired(now) && !keep[g.Rule] {
+			pruned = append(pruned, g)
+			continue
+		}
+		kept = append(kept, g)
+	}
+	s.LANAccessGrants = kept
+	return pruned
+}
@@ -0,0 +1,45 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetGrant(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	st := &State{}
+
+	st.SetGrant("192.168.1.50:5432", now.Add(time.Hour))
+	st.SetGrant("10.0.0.2", now.Add(2*time.Hour))
+	st.SetGrant("192.168.1.50:5432", now.Add(3*time.Hour))
+
+	if len(st.LANAccessGrants) != 2 {
+		t.Fatalf("SetGrant should replace an existing grant, got %+v", st.LANAccessGrants)
+	}
+	g, ok := st.Grant("192.168.1.50:5432")
+	if !ok || !g.Expires.Equal(now.Add(3*time.Hour)) {
+		t.Errorf("Grant() = %+v, %v; want expiry extended to +3h", g, ok)
+	}
+	if _, ok := st.Grant("10.0.0.3"); ok {
+		t.Error("Grant() should not find an unknown rule")
+	}
+}
+
+func TestPruneGrants(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	st := &State{LANAccessGrants: []LANAccessGrant{
+		{Rule: "expired", Expires: now.Add(-time.Minute)},
+		{Rule: "expired-config", Expires: now.Add(-time.Minute)},
+		{Rule: "active", Expires: now.Add(time.Minute)},
+		{Rule: "boundary", Expires: now},
+	}}
+
+	pruned := st.PruneGrants(now, map[string]bool{"expired-config": true})
+
+	if len(pruned) != 2 || pruned[0].Rule != "expired" || pruned[1].Rule != "boundary" {
+		t.Errorf("PruneGrants() returned %+v, want expired and boundary", pruned)
+	}
+	if len(st.LANAccessGrants) != 2 || st.LANAccessGrants[0].Rule != "expired-config" || st.LANAccessGrants[1].Rule != "active" {
+		t.Errorf("remaining grants = %+v, want expired-config and active", st.LANAccessGrants)
+	}
+}
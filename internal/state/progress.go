@@ -0,0 +1,82 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// UpProgressFilename is the name of the alca up progress file.
+const UpProgressFilename = "up-progress.json"
+
+// UpProgress records the steps of an alca up run. The file exists only while
+// up is running or after a run was interrupted before finishing.
+type UpProgress struct {
+	// StartedAt is when the recorded run started.
+	StartedAt time.Time `json:"started_at"`
+	// Completed lists the steps that finished, in order.
+	Completed []string `json:"completed,omitempty"`
+	// Current is the step in flight. It stays set when alca died (or its
+	// rollback failed) before the step was undone.
+	Current string `json:"current,omitempty"`
+}
+
+// Done reports whether step completed.
+func (p *UpProgress) Done(step string) bool {
+	return slices.Contains(p.Completed, step)
+}
+
+// UpProgressFilePath returns the path to the up progress file for the given project directory.
+func UpProgressFilePath(projectDir string) string {
+	return filepath.Join(projectDir, StateDir, UpProgressFilename)
+}
+
+// LoadUpProgress reads the up progress file.
+// Returns nil and no error if no interrupted run is recorded.
+func LoadUpProgress(env *util.Env, projectDir string) (*UpProgress, error) {
+	data, err := afero.ReadFile(env.Fs, UpProgressFilePath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read up progress: %w", err)
+	}
+
+	var p UpProgress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse up progress: %w", err)
+	}
+	return &p, nil
+}
+
+// SaveUpProgress writes the up progress file.
+func SaveUpProgress(env *util.Env, projectDir string, p *UpProgress) error {
+	if err := env.Fs.MkdirAll(StateDirPath(projectDir), stateDirPerm); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal up progress: %w", err)
+	}
+	if err := util.WriteFileAtomic(env.Fs, UpProgressFilePath(projectDir), data, stateFilePerm); err != nil {
+		return fmt.Errorf("failed to write up progress: %w", err)
+	}
+	return nil
+}
+
+// DeleteUpProgress removes the up progress file once a run has finished.
+func DeleteUpProgress(env *util.Env, projectDir string) error {
+	err := env.Fs.Remove(UpProgressFilePath(projectDir))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete up progress: %w", err)
+	}
+	return nil
+}
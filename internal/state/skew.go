@@ -0,0 +1,96 @@
+package state
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CLIVersion is the release of the running alca, stamped into saved state
+// files as AlcaVersion. The CLI sets it from its build version; versions
+// that are not releases (such as "dev") are not recorded.
+var CLIVersion = ""
+
+// SkewWindow is how many minor releases newer than the running alca a
+// project's state may be before destructive commands require an explicit
+// override. Newer patch releases never count as skew.
+const SkewWindow = 1
+
+// VersionSkew classifies how much newer the alca that wrote a project's
+// state is than the running one.
+type VersionSkew int
+
+const (
+	// SkewNone means the state was written by this release or an older one,
+	// or a version is unknown.
+	SkewNone VersionSkew = iota
+	// SkewMinor means the state was written by a newer release within
+	// SkewWindow: commands work, with a warning.
+	SkewMinor
+	// SkewIncompatible means the state was written by a newer major release
+	// or one beyond SkewWindow, which may store things this release does not
+	// understand.
+	SkewIncompatible
+)
+
+// VersionSkew compares the newest alca that wrote the state with cli.
+func (s *State) VersionSkew(cli string) VersionSkew {
+	stateV, ok1 := parseRelease(s.AlcaVersion)
+	cliV, ok2 := parseRelease(cli)
+	if !ok1 || !ok2 || compareRelease(stateV, cliV) <= 0 {
+		return SkewNone
+	}
+	switch {
+	case stateV[0] != cliV[0] || stateV[1]-cliV[1] > SkewWindow:
+		return SkewIncompatible
+	case stateV[1] > cliV[1]:
+		return SkewMinor
+	}
+	return SkewNone
+}
+
+// stampVersion records CLIVersion in the state unless the state was
+// already written by a newer release, so a downgraded alca keeps seeing
+// the skew after it saves.
+func (s *State) stampVersion() {
+	cliV, ok := parseRelease(CLIVersion)
+	if !ok {
+		return
+	}
+	if stateV, ok := parseRelease(s.AlcaVersion); ok && compareRelease(stateV, cliV) >= 0 {
+		return
+	}
+	s.AlcaVersion = strings.TrimPrefix(CLIVersion, "v")
+}
+
+// parseRelease parses "[v]major.minor.patch[-pre]", ignoring any
+// prerelease or build suffix.
+func parseRelease(version string) ([3]int, bool) {
+	var v [3]int
+	core, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	core, _, _ = strings.Cut(core, "+")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// compareRelease returns -1, 0 or 1 as a is older than, equal to or newer than b.
+func compareRelease(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestVersionSkew(t *testing.T) {
+	tests := []struct {
+		state, cli string
+		want       VersionSkew
+	}{
+		{"", "0.3.0", SkewNone},
+		{"0.3.0", "dev", SkewNone},
+		{"0.3.0", "0.3.0", SkewNone},
+		{"0.3.5", "0.3.0", SkewNone},
+		{"0.2.0", "0.3.0", SkewNone},
+		{"0.4.0", "0.3.2", SkewMinor},
+		{"0.4.0-rc.1", "v0.3.0", SkewMinor},
+		{"0.5.0", "0.3.0", SkewIncompatible},
+		{"1.0.0", "0.9.0", SkewIncompatible},
+	}
+	for _, tt := range tests {
+		st := &State{AlcaVersion: tt.state}
+		if got := st.VersionSkew(tt.cli); got != tt.want {
+			t.Errorf("state %q, cli %q: VersionSkew = %d, want %d", tt.state, tt.cli, got, tt.want)
+		}
+	}
+}
+
+func TestSave_StampsNewestVersion(t *testing.T) {
+	orig := CLIVersion
+	t.Cleanup(func() { CLIVersion = orig })
+	env := util.NewTestEnv()
+	st := newState("Docker")
+
+	save := func(cli string) string {
+		t.Helper()
+		CLIVersion = cli
+		if err := Save(env, "/project", st); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(env, "/project")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loaded.AlcaVersion
+	}
+
+	if got := save("dev"); got != "" {
+		t.Errorf("dev build stamped %q", got)
+	}
+	if got := save("v0.4.0"); got != "0.4.0" {
+		t.Errorf("after 0.4.0: AlcaVersion = %q", got)
+	}
+	if got := save("0.3.0"); got != "0.4.0" {
+		t.Errorf("older alca overwrote the stamp: %q", got)
+	}
+	if got := save("0.4.1"); got != "0.4.1" {
+		t.Errorf("after 0.4.1: AlcaVersion = %q", got)
+	}
+}
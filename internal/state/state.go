@@ -4,7 +4,11 @@
 package state
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -30,6 +34,8 @@ const (
 	LabelVersion = "alca.version"
 	// CurrentVersion is the current alca state version.
 	CurrentVersion = "1"
+	// CurrentSchemaVersion is the state.json schema version this binary writes.
+	CurrentSchemaVersion = 1
 
 	// containerNameUUIDPrefixLen is the number of UUID characters used in container names.
 	// 12 characters matches Docker's short container ID length convention,
@@ -39,10 +45,21 @@ const (
 	stateDirPerm = 0755
 	// stateFilePerm is the permission for the state file (rw-r--r--).
 	stateFilePerm = 0644
+	// BackupCount is the number of previous state files kept by Save
+	// (state.json.bak, state.json.bak.1, ...).
+	BackupCount = 3
+	// checksumPrefix tags the algorithm used for State.Checksum.
+	checksumPrefix = "sha256:"
 )
 
+// ErrStateCorrupted is returned when the state file cannot be parsed or its
+// checksum does not match its content.
+var ErrStateCorrupted = errors.New("state file is corrupted (run 'alca state repair')")
+
 // State represents the persistent state of an Alcatraz project.
 type State struct {
+	// SchemaVersion is the state.json schema version; older files are migrated on load.
+	SchemaVersion int `json:"schema_version"`
 	// ProjectID is a unique UUID for this project, survives directory moves.
 	ProjectID string `json:"project_id"`
 	// ContainerName is the name of the container for this project.
@@ -54,8 +71,49 @@ type State struct {
 	// Config stores the configuration at container creation time.
 	// Used for detecting configuration drift.
 	Config *config.Config `json:"config,omitempty"`
+	// ImageCheckedAt is when the upstream image digest was last checked.
+	// Used to throttle image_check = "daily".
+	ImageCheckedAt time.Time `json:"image_checked_at,omitempty"`
+	// LANAccessGrants are temporary lan-access rules with absolute expiry times,
+	// from `alca network allow --ttl` or ttl-suffixed rules in .alca.toml.
+	LANAccessGrants []LANAccessGrant `json:"lan_access_grants,omitempty"`
+	// Bake is the project image made by `alca bake`, if any. New containers
+	// are created from it while Bake.Base matches the configured image.
+	Bake *BakeInfo `json:"bake,omitempty"`
+	// Events are the latest OOM kills, restarts and crashes, oldest first.
+	Events []Event `json:"events,omitempty"`
+	// EventCounters are the runtime counters Events were last derived from.
+	// Reset when the container is recreated.
+	EventCounters EventCounters `json:"event_counters,omitempty"`
+	// AlcaVersion is the newest alca release that has saved this file.
+	// Older releases warn about it, and refuse destructive commands when
+	// it is beyond SkewWindow (see VersionSkew).
+	AlcaVersion string `json:"alca_version,omitempty"`
+	// EnvSync is set by `alca env sync`: every alca enter and run then
+	// rewrites the container's env file with the current host values.
+	EnvSync bool `json:"env_sync,omitempty"`
+	// Checksum is a digest of all other fields, written by Save and verified
+	// by Load to detect corruption. Files written before it existed have none.
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// BakeInfo records a project image baked by `alca bake`.
+type BakeInfo struct {
+	// Image is the baked image reference.
+	Image string `json:"image"`
+	// Base is the configured image the container was created from.
+	Base string `json:"base"`
+	// Hash digests the inputs that determine the baked contents; the bake
+	// is discarded when it no longer matches.
+	Hash string `json:"hash"`
+	// BakedAt is when the image was baked.
+	BakedAt time.Time `json:"baked_at"`
 }
 
+// stateMigrations upgrades state.json documents. Append an entry whenever
+// CurrentSchemaVersion is bumped; entries must be ordered by From.
+var stateMigrations []config.Migration
+
 // StateFilePath returns the path to the state file for the given project directory.
 func StateFilePath(projectDir string) string {
 	return filepath.Join(projectDir, StateDir, StateFilename)
@@ -84,29 +142,198 @@ func Load(env *util.Env, projectDir string) (*State, error) {
 		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
 
+	return parseState(data)
+}
+
+// parseState decodes state file content, migrating older schema versions and
+// verifying the checksum of current ones.
+func parseState(data []byte) (*State, error) {
+	data, applied, err := migrateStateData(data)
+	if errors.Is(err, config.ErrSchemaTooNew) {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w: %w", ErrStateCorrupted, err)
+	}
+
 	var state State
 	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, fmt.Errorf("failed to parse state file: %w", err)
+		return nil, fmt.Errorf("failed to parse state file: %w: %w", ErrStateCorrupted, err)
+	}
+
+	// Migrations rewrite the document, so only unmigrated files can be verified.
+	if len(applied) == 0 && state.Checksum != "" {
+		sum, err := documentChecksum(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse state file: %w: %w", ErrStateCorrupted, err)
+		}
+		if sum != state.Checksum {
+			return nil, fmt.Errorf("checksum mismatch: %w", ErrStateCorrupted)
+		}
 	}
 
 	return &state, nil
 }
 
+// computeChecksum digests the state with the Checksum field cleared.
+func (s *State) computeChecksum() (string, error) {
+	unsummed := *s
+	unsummed.Checksum = ""
+	data, err := json.Marshal(&unsummed)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal state: %w", err)
+	}
+	return documentChecksum(data)
+}
+
+// documentChecksum digests a state.json document without its checksum key.
+// It works on the document rather than the decoded State so fields added by
+// a newer alca are covered too, instead of being dropped and reported as
+// corruption by an older one. Keys are sorted and numbers kept verbatim.
+func documentChecksum(data []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return "", err
+	}
+	delete(doc, "checksum")
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal state: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return checksumPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// BackupFilePath returns the path of the n-th most recent state backup (0 is newest).
+func BackupFilePath(projectDir string, n int) string {
+	path := StateFilePath(projectDir)
+	if n == 0 {
+		return path + ".bak"
+	}
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// LoadBackup reads the n-th most recent state backup.
+// Returns nil and no error if that backup does not exist.
+func LoadBackup(env *util.Env, projectDir string, n int) (*State, error) {
+	data, err := afero.ReadFile(env.Fs, BackupFilePath(projectDir, n))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read state backup: %w", err)
+	}
+	return parseState(data)
+}
+
+// rotateBackups keeps the current state file as the newest backup, shifting
+// older backups down and dropping the oldest. Nothing is rotated when the
+// content is unchanged, so repeated saves do not flush useful backups.
+func rotateBackups(env *util.Env, projectDir string, next []byte) error {
+	current, err := afero.ReadFile(env.Fs, StateFilePath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+	if bytes.Equal(current, next) {
+		return nil
+	}
+
+	for i := BackupCount - 1; i > 0; i-- {
+		newer := BackupFilePath(projectDir, i-1)
+		if _, err := env.Fs.Stat(newer); err != nil {
+			continue
+		}
+		if err := env.Fs.Rename(newer, BackupFilePath(projectDir, i)); err != nil {
+			return fmt.Errorf("failed to rotate state backup: %w", err)
+		}
+	}
+
+	if err := afero.WriteFile(env.Fs, BackupFilePath(projectDir, 0), current, stateFilePerm); err != nil {
+		return fmt.Errorf("failed to write state backup: %w", err)
+	}
+	return nil
+}
+
+// migrateStateData upgrades raw state.json content to CurrentSchemaVersion.
+func migrateStateData(data []byte) ([]byte, []config.Migration, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	applied, err := config.ApplyMigrations(doc, CurrentSchemaVersion, stateMigrations)
+	if err != nil || len(applied) == 0 {
+		return data, nil, err
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal migrated state: %w", err)
+	}
+	return migrated, applied, nil
+}
+
+// Migrate upgrades the state file in projectDir and, when write is true and
+// migrations applied, persists the result. Returns nil if there is no state file.
+func Migrate(env *util.Env, projectDir string, write bool) ([]config.Migration, error) {
+	data, err := afero.ReadFile(env.Fs, StateFilePath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	_, applied, err := migrateStateData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	if !write || len(applied) == 0 {
+		return applied, nil
+	}
+
+	st, err := Load(env, projectDir)
+	if err != nil {
+		return applied, err
+	}
+	return applied, Save(env, projectDir, st)
+}
+
 // Save writes the state file to the given project directory.
 // Creates the .alca directory if it does not exist.
+// The file is always stamped with CurrentSchemaVersion, CLIVersion (see
+// AlcaVersion) and a fresh checksum, and the previous file is kept as a
+// backup (see BackupCount).
 func Save(env *util.Env, projectDir string, state *State) error {
 	dir := StateDirPath(projectDir)
 	if err := env.Fs.MkdirAll(dir, stateDirPerm); err != nil {
 		return fmt.Errorf("failed to create state directory: %w", err)
 	}
 
+	state.SchemaVersion = CurrentSchemaVersion
+	state.stampVersion()
+	sum, err := state.computeChecksum()
+	if err != nil {
+		return err
+	}
+	state.Checksum = sum
+
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
+	if err := rotateBackups(env, projectDir, data); err != nil {
+		return err
+	}
+
 	path := StateFilePath(projectDir)
-	if err := afero.WriteFile(env.Fs, path, data, stateFilePerm); err != nil {
+	if err := util.WriteFileAtomic(env.Fs, path, data, stateFilePerm); err != nil {
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
@@ -138,13 +365,38 @@ func LoadOrCreate(env *util.Env, projectDir string, runtimeName string) (*State,
 
 // newState creates a fresh State with a new project UUID and container name.
 func newState(runtimeName string) *State {
-	projectID := uuid.New().String()
-	return &State{
-		ProjectID:     projectID,
-		ContainerName: "alca-" + projectID[:containerNameUUIDPrefixLen],
+	st := &State{
+		SchemaVersion: CurrentSchemaVersion,
+		ProjectID:     uuid.New().String(),
 		CreatedAt:     time.Now(),
 		Runtime:       runtimeName,
 	}
+	st.ContainerName = st.GeneratedContainerName()
+	return st
+}
+
+// NewEphemeral returns an unsaved State for a throwaway container such as the
+// one used by alca test. Its fresh project ID keeps label lookups, Mutagen
+// sessions and firewall rules apart from the project's long-lived container.
+func NewEphemeral(runtimeName, purpose string) *State {
+	st := newState(runtimeName)
+	st.ContainerName = "alca-" + purpose + "-" + st.ShortProjectID()
+	return st
+}
+
+// ShortProjectID returns the prefix of the project ID used in generated
+// container names.
+func (s *State) ShortProjectID() string {
+	if len(s.ProjectID) < containerNameUUIDPrefixLen {
+		return s.ProjectID
+	}
+	return s.ProjectID[:containerNameUUIDPrefixLen]
+}
+
+// GeneratedContainerName returns the alca-<id> name a container gets unless
+// container_name or name_template is set.
+func (s *State) GeneratedContainerName() string {
+	return "alca-" + s.ShortProjectID()
 }
 
 // syncRuntime persists the runtime name if it has changed.
@@ -177,25 +429,84 @@ func (s *State) ContainerLabels(projectDir string) map[string]string {
 	}
 }
 
-// DriftChanges describes specific configuration changes that require rebuild.
+// DriftChanges describes specific configuration changes since the container
+// was created. Most require recreating the container; the network fields
+// marked "hot" are reapplied by the next alca up without a rebuild (see
+// RequiresRecreate and HotApplicable).
 //
 // Design: Pointer fields (*[2]T) provide old/new values for user-facing diff display.
 // Boolean fields are used for complex types (slices, maps) where showing the full
 // diff would be verbose - the CLI just reports "changed" for these.
 type DriftChanges struct {
-	Image          *[2]string // [old, new] if changed
-	Workdir        *[2]string
-	Runtime        *[2]string
-	CommandUp      *[2]string
-	Memory         *[2]string
-	CPUs           *[2]int
-	HooksPostUp    *[2]string // [old, new] if changed
-	HooksPreDown   *[2]string // [old, new] if changed
-	WorkdirExclude bool       // true if changed (slice comparison, no diff detail)
-	Mounts         bool       // true if changed (slice comparison, no diff detail)
-	Envs           bool       // true if changed (map comparison, no diff detail)
-	Caps           bool       // true if changed (struct comparison, no diff detail)
-	Ports          bool       // true if changed (slice comparison, no diff detail)
+	Image            *[2]string // [old, new] if changed
+	Platform         *[2]string
+	Workdir          *[2]string
+	WorkdirSource    *[2]string
+	Runtime          *[2]string
+	RuntimeContext   *[2]string
+	NestedContainers *[2]string
+	Userns           *[2]string
+	CommandUp        *[2]string
+	Memory           *[2]string // hot unless the limit is removed
+	CPUs             *[2]int    // hot unless the limit is removed
+	CPUWeight        *[2]int    // hot unless the weight is removed
+	CpusetCPUs       *[2]string // hot unless the pinning is removed
+	CpusetMems       *[2]string // hot unless the pinning is removed
+	CgroupParent     *[2]string
+	IOLatency        *[2]string // [old, new] io.latency values
+	HooksPostUp      *[2]string // [old, new] if changed
+	HooksPreDown     *[2]string // [old, new] if changed
+	WorkdirExclude   bool       // true if changed (slice comparison, no diff detail)
+	Mounts           bool       // true if changed (slice comparison, no diff detail)
+	Envs             bool       // true if changed (map comparison, no diff detail)
+	Caps             bool       // true if changed (struct comparison, no diff detail)
+	Ports            bool       // true if changed (slice comparison, no diff detail)
+	SSH              bool       // true if changed (struct comparison, no diff detail)
+	LANAccess        bool       // hot: true if changed (slice comparison, no diff detail)
+	Proxy            *[2]string // hot: [old, new] if changed
+	Shaping          bool       // hot: true if changed (struct comparison, no diff detail)
+	LogConnections   *[2]bool   // hot: [old, new] if changed
+	Isolation        *[2]string // [old, new] effective level; hot unless "none" is involved
+}
+
+// RequiresRecreate reports whether any change only takes effect when the
+// container is recreated. Safe to call on nil.
+func (c *DriftChanges) RequiresRecreate() bool {
+	if c == nil {
+		return false
+	}
+	cold := *c
+	cold.LANAccess, cold.Proxy, cold.Shaping, cold.LogConnections = false, nil, false, nil
+	if cold.ResourcesHotApplicable() {
+		cold.Memory, cold.CPUs = nil, nil
+		cold.CPUWeight, cold.CpusetCPUs, cold.CpusetMems = nil, nil, nil
+	}
+	if !isolationNeedsRecreate(cold.Isolation) {
+		cold.Isolation = nil
+	}
+	return cold != (DriftChanges{})
+}
+
+// HotApplicable reports whether any change is applied to the running
+// container by the next alca up (firewall rules, tc qdisc, resource limits).
+// Safe to call on nil.
+func (c *DriftChanges) HotApplicable() bool {
+	return c != nil && (c.LANAccess || c.Proxy != nil || c.Shaping || c.LogConnections != nil ||
+		(c.Isolation != nil && !isolationNeedsRecreate(c.Isolation)) || c.ResourcesHotApplicable())
+}
+
+// ResourcesHotApplicable reports whether memory, cpus, the cgroup CPU
+// weight or the cpuset pinning changed in a way the runtime can update in
+// place (docker update): limits are changed or added, not removed. The
+// cgroup parent and io.latency only change with a recreate. Safe to call
+// on nil.
+func (c *DriftChanges) ResourcesHotApplicable() bool {
+	if c == nil || (c.Memory == nil && c.CPUs == nil && c.CPUWeight == nil && c.CpusetCPUs == nil && c.CpusetMems == nil) {
+		return false
+	}
+	return (c.Memory == nil || c.Memory[1] != "") && (c.CPUs == nil || c.CPUs[1] > 0) &&
+		(c.CPUWeight == nil || c.CPUWeight[1] > 0) &&
+		(c.CpusetCPUs == nil || c.CpusetCPUs[1] != "") && (c.CpusetMems == nil || c.CpusetMems[1] != "")
 }
 
 // DetectConfigDrift compares the state's config with the given config.
@@ -216,17 +527,39 @@ func (s *State) DetectConfigDrift(current *config.Config) *DriftChanges {
 // See AGD-015 for pattern details.
 func enforceConfigFieldCompleteness(cfg *config.Config) {
 	type fields struct {
-		Image          string
-		Workdir        string
-		WorkdirExclude []string
-		Runtime        config.RuntimeType
-		Commands       config.Commands
-		Mounts         []config.MountConfig
-		Resources      config.Resources
-		Envs           map[string]config.EnvValue
-		Network        config.Network
-		Caps           config.Caps
-		Hooks          config.Hooks
+		Image              string
+		ImageCheck         config.ImageCheckMode
+		Platform           string
+		RegistryMirror     string
+		Workdir            string
+		WorkdirSource      string
+		WorkdirExclude     []string
+		WorkdirExcludeFrom []string
+		WorkdirSync        config.MountSync
+		Runtime            config.RuntimeType
+		RuntimeContext     string
+		User               string
+		EnterTmux          bool
+		NestedContainers   config.NestedContainersMode
+		Userns             config.UsernsMode
+		Commands           config.Commands
+		Mounts             []config.MountConfig
+		Resources          config.Resources
+		Envs               map[string]config.EnvValue
+		Network            config.Network
+		SSH                config.SSH
+		Caps               config.Caps
+		Hooks              config.Hooks
+		Rebuild            config.Rebuild
+		Clock              config.Clock
+		Timeouts           config.Timeouts
+		IdleTimeout        string
+		ContainerName      string
+		NameTemplate       string
+		Plugins            []string
+		Cron               map[string]string
+		ForwardFileEvents  bool
+		UI                 config.UI
 	}
 	_ = fields(*cfg)
 
@@ -237,9 +570,16 @@ func enforceConfigFieldCompleteness(cfg *config.Config) {
 	_ = fieldsHooks(cfg.Hooks)
 
 	type fieldsNetwork struct {
-		LANAccess []string
-		Ports     []config.PortConfig
-		Proxy     string
+		LANAccess       []string
+		Ports           []config.PortConfig
+		Proxy           string
+		Shaping         config.Shaping
+		LogConnections  bool
+		AllowHostAccess bool
+		Isolation       string
+		Peers           []string
+		Preset          string
+		Presets         map[string]config.NetworkPreset
 	}
 	_ = fieldsNetwork(cfg.Network)
 
@@ -257,25 +597,45 @@ func enforceConfigFieldCompleteness(cfg *config.Config) {
 	type fieldsCommands struct {
 		Up    config.CommandValue
 		Enter config.CommandValue
+		Test  config.CommandValue
 	}
 	_ = fieldsCommands(cfg.Commands)
 
 	type fieldsCommandValue struct {
 		Command string
 		Append  bool
+		User    string
 	}
 	_ = fieldsCommandValue(cfg.Commands.Up)
 	_ = fieldsCommandValue(cfg.Commands.Enter)
+	_ = fieldsCommandValue(cfg.Commands.Test)
 
 	type fieldsResources struct {
 		Memory string
 		CPUs   int
+		Cgroup config.Cgroup
 	}
 	_ = fieldsResources(cfg.Resources)
 
+	type fieldsCgroup struct {
+		Parent     string
+		CPUWeight  int
+		CpusetCPUs string
+		CpusetMems string
+		IOLatency  config.IOLatency
+	}
+	_ = fieldsCgroup(cfg.Resources.Cgroup)
+
+	type fieldsIOLatency struct {
+		Device string
+		Target string
+	}
+	_ = fieldsIOLatency(cfg.Resources.Cgroup.IOLatency)
+
 	type fieldsEnvValue struct {
 		Value           string
 		OverrideOnEnter bool
+		Sensitive       bool
 	}
 	for _, v := range cfg.Envs {
 		_ = fieldsEnvValue(v)
@@ -283,10 +643,14 @@ func enforceConfigFieldCompleteness(cfg *config.Config) {
 	}
 
 	type fieldsMountConfig struct {
-		Source   string
-		Target   string
-		Readonly bool
-		Exclude  []string
+		Source      string
+		Target      string
+		Readonly    bool
+		Exclude     []string
+		ExcludeFrom []string
+		Propagation string
+		Consistency string
+		Sync        config.MountSync
 	}
 	for _, m := range cfg.Mounts {
 		_ = fieldsMountConfig(m)
@@ -298,10 +662,33 @@ func enforceConfigFieldCompleteness(cfg *config.Config) {
 // Returns nil if configs are equivalent.
 //
 // Intentionally excluded fields (don't require rebuild):
+//   - ImageCheck: only affects the upstream digest check during up
+//   - RegistryMirror: only changes where the image is pulled from
+//   - Rebuild: only used by alca rebuild, not applied to the container
+//   - Clock: only affects the host-side drift check
+//   - Timeouts: only bound host-side operations
+//   - IdleTimeout: only read by the host-side idle check
+//   - ContainerName/NameTemplate: applied when the container is next created
+//   - Plugins: host-side lifecycle handlers, not applied to the container
+//   - Cron: run by the host-side scheduler, which rereads the config
+//   - ForwardFileEvents: run by the host-side forwarder alca up starts
+//   - UI: only affects enter sessions
 //   - Commands.Enter: only affects enter behavior
+//   - User: only affects enter/run sessions, applied per exec
+//   - EnterTmux: only affects enter sessions
+//   - Network.Preset/Presets: the preset is resolved into the compared network fields on load
+//   - Network.Peers: shared networks are joined on every up (leaving one takes a recreate)
+//   - Commands.Test: only used by alca test, in its own container
 //   - EnvValue.OverrideOnEnter: only affects enter behavior
-//   - Network.LANAccess: nftables rules are external, no container rebuild needed
-//   - Network.Proxy: nftables DNAT rules are external, no container rebuild needed
+//   - EnvValue.Sensitive: only affects alca's own output
+//   - WorkdirSync: carried by the workdir mount (Mounts[0]) and compared there
+//
+// Network.LANAccess (with AllowHostAccess, which adds a lan-access rule),
+// Proxy, Shaping and LogConnections are compared but are hot-applicable:
+// nftables rules and the tc qdisc live outside the container and are
+// reapplied on every up. Network.Isolation is compared by effective
+// level, so state from before the key existed does not drift; it is hot too,
+// except to or from "none", which is a container network mode.
 func compareConfigs(old, new *config.Config) *DriftChanges {
 	// Each field is compared explicitly. This is intentional: the AGD-015
 	// exhaustiveness check in enforceConfigFieldCompleteness ensures new
@@ -314,15 +701,31 @@ func compareConfigs(old, new *config.Config) *DriftChanges {
 	if old.Image != new.Image {
 		c.Image = &[2]string{old.Image, new.Image}
 	}
+	if old.Platform != new.Platform {
+		c.Platform = &[2]string{old.Platform, new.Platform}
+	}
 	if old.Workdir != new.Workdir {
 		c.Workdir = &[2]string{old.Workdir, new.Workdir}
 	}
-	if !config.StringSlicesEqual(old.WorkdirExclude, new.WorkdirExclude) {
+	if old.WorkdirSource != new.WorkdirSource {
+		c.WorkdirSource = &[2]string{old.WorkdirSource, new.WorkdirSource}
+	}
+	if !config.StringSlicesEqual(old.WorkdirExclude, new.WorkdirExclude) ||
+		!config.StringSlicesEqual(old.WorkdirExcludeFrom, new.WorkdirExcludeFrom) {
 		c.WorkdirExclude = true
 	}
 	if old.Runtime != new.Runtime {
 		c.Runtime = &[2]string{string(old.Runtime), string(new.Runtime)}
 	}
+	if old.RuntimeContext != new.RuntimeContext {
+		c.RuntimeContext = &[2]string{old.RuntimeContext, new.RuntimeContext}
+	}
+	if old.NestedContainers.OrNone() != new.NestedContainers.OrNone() {
+		c.NestedContainers = &[2]string{string(old.NestedContainers.OrNone()), string(new.NestedContainers.OrNone())}
+	}
+	if old.Userns != new.Userns {
+		c.Userns = &[2]string{string(old.Userns), string(new.Userns)}
+	}
 	if old.Commands.Up.Command != new.Commands.Up.Command {
 		c.CommandUp = &[2]string{old.Commands.Up.Command, new.Commands.Up.Command}
 	}
@@ -332,6 +735,22 @@ func compareConfigs(old, new *config.Config) *DriftChanges {
 	if old.Resources.CPUs != new.Resources.CPUs {
 		c.CPUs = &[2]int{old.Resources.CPUs, new.Resources.CPUs}
 	}
+	oldCg, newCg := old.Resources.Cgroup, new.Resources.Cgroup
+	if oldCg.CPUWeight != newCg.CPUWeight {
+		c.CPUWeight = &[2]int{oldCg.CPUWeight, newCg.CPUWeight}
+	}
+	if oldCg.CpusetCPUs != newCg.CpusetCPUs {
+		c.CpusetCPUs = &[2]string{oldCg.CpusetCPUs, newCg.CpusetCPUs}
+	}
+	if oldCg.CpusetMems != newCg.CpusetMems {
+		c.CpusetMems = &[2]string{oldCg.CpusetMems, newCg.CpusetMems}
+	}
+	if oldCg.Parent != newCg.Parent {
+		c.CgroupParent = &[2]string{oldCg.Parent, newCg.Parent}
+	}
+	if oldCg.IOLatencyValue() != newCg.IOLatencyValue() {
+		c.IOLatency = &[2]string{oldCg.IOLatencyValue(), newCg.IOLatencyValue()}
+	}
 	if !config.MountsEqual(old.Mounts, new.Mounts) {
 		c.Mounts = true
 	}
@@ -344,6 +763,25 @@ func compareConfigs(old, new *config.Config) *DriftChanges {
 	if !config.PortsEqual(old.Network.Ports, new.Network.Ports) {
 		c.Ports = true
 	}
+	if old.SSH != new.SSH {
+		c.SSH = true
+	}
+	if !tokenAwareSlicesEqual(old.Network.LANAccess, new.Network.LANAccess) ||
+		old.Network.AllowHostAccess != new.Network.AllowHostAccess {
+		c.LANAccess = true
+	}
+	if !tokenAwareEqual(old.Network.Proxy, new.Network.Proxy) {
+		c.Proxy = &[2]string{old.Network.Proxy, new.Network.Proxy}
+	}
+	if old.Network.Shaping != new.Network.Shaping {
+		c.Shaping = true
+	}
+	if old.Network.LogConnections != new.Network.LogConnections {
+		c.LogConnections = &[2]bool{old.Network.LogConnections, new.Network.LogConnections}
+	}
+	if isolationChanged(old.Network.IsolationLevel(), new.Network.IsolationLevel()) {
+		c.Isolation = &[2]string{old.Network.IsolationLevel(), new.Network.IsolationLevel()}
+	}
 	if old.Hooks.PostUp != new.Hooks.PostUp {
 		c.HooksPostUp = &[2]string{old.Hooks.PostUp, new.Hooks.PostUp}
 	}
@@ -357,6 +795,44 @@ func compareConfigs(old, new *config.Config) *DriftChanges {
 	return &c
 }
 
+// isolationChanged reports an isolation level change worth its own drift
+// entry. default and custom differ only in lan-access and proxy, which are
+// reported themselves.
+func isolationChanged(old, new string) bool {
+	lanOnly := func(level string) bool {
+		return level == config.IsolationDefault || level == config.IsolationCustom
+	}
+	return old != new && !(lanOnly(old) && lanOnly(new))
+}
+
+// isolationNeedsRecreate reports whether an isolation change involves
+// "none", a container network mode; other levels are firewall rules.
+func isolationNeedsRecreate(change *[2]string) bool {
+	return change != nil && (change[0] == config.IsolationNone || change[1] == config.IsolationNone)
+}
+
+// tokenAwareEqual compares a stored network value with its config value.
+// State stores network values with alca tokens already expanded (see
+// saveNetworkState), so a config value containing tokens matches any
+// stored value; token results change with the host and are reapplied on
+// every up anyway.
+func tokenAwareEqual(stored, current string) bool {
+	return stored == current || config.ContainsAlcaTokens(current)
+}
+
+// tokenAwareSlicesEqual applies tokenAwareEqual element-wise.
+func tokenAwareSlicesEqual(stored, current []string) bool {
+	if len(stored) != len(current) {
+		return false
+	}
+	for i := range stored {
+		if !tokenAwareEqual(stored[i], current[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // UpdateConfig updates the config in the state.
 func (s *State) UpdateConfig(cfg *config.Config) {
 	s.Config = cfg
@@ -370,7 +846,8 @@ func (s *State) UpdateConfig(cfg *config.Config) {
 // Interpolated values (containing ${...}) are not compared because they depend on
 // host environment at runtime. However, adding/removing interpolated keys IS detected
 // as structural drift since it changes the container's environment shape.
-// EnvValue.OverrideOnEnter is ignored (only affects enter behavior).
+// EnvValue.OverrideOnEnter and Sensitive are ignored (they only affect enter
+// behavior and alca's output).
 func hasEnvLiteralDrift(a, b map[string]config.EnvValue) bool {
 	// First check: structural drift (key set changes)
 	// This catches adding/removing ANY key, including interpolated ones
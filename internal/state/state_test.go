@@ -2,6 +2,9 @@ package state
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -207,6 +210,218 @@ func TestLoadInvalidJSON(t *testing.T) {
 	}
 }
 
+func TestLoadSchemaVersion(t *testing.T) {
+	writeState := func(t *testing.T, env *util.Env, content string) {
+		t.Helper()
+		if err := afero.WriteFile(env.Fs, StateFilePath("/project"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("missing version is treated as 1", func(t *testing.T) {
+		env := newTestEnv(t)
+		writeState(t, env, `{"project_id": "abc", "container_name": "alca-abc"}`)
+
+		st, err := Load(env, "/project")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if st.ProjectID != "abc" {
+			t.Errorf("ProjectID = %q, want abc", st.ProjectID)
+		}
+	})
+
+	t.Run("newer version is rejected", func(t *testing.T) {
+		env := newTestEnv(t)
+		writeState(t, env, `{"schema_version": 99, "project_id": "abc"}`)
+
+		_, err := Load(env, "/project")
+		if !errors.Is(err, config.ErrSchemaTooNew) {
+			t.Errorf("Load() error = %v, want ErrSchemaTooNew", err)
+		}
+	})
+
+	t.Run("save stamps current version", func(t *testing.T) {
+		env := newTestEnv(t)
+		if err := Save(env, "/project", &State{ProjectID: "abc"}); err != nil {
+			t.Fatal(err)
+		}
+		st, err := Load(env, "/project")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.SchemaVersion != CurrentSchemaVersion {
+			t.Errorf("SchemaVersion = %d, want %d", st.SchemaVersion, CurrentSchemaVersion)
+		}
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	env := newTestEnv(t)
+
+	steps, err := Migrate(env, "/project", true)
+	if err != nil || steps != nil {
+		t.Fatalf("Migrate() without state = %v, %v; want nil, nil", steps, err)
+	}
+
+	legacy := `{"project_id": "abc", "container_name": "alca-abc"}`
+	if err := afero.WriteFile(env.Fs, StateFilePath("/project"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	steps, err = Migrate(env, "/project", true)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("Migrate() applied %d steps, want 0", len(steps))
+	}
+	data, _ := afero.ReadFile(env.Fs, StateFilePath("/project"))
+	if string(data) != legacy {
+		t.Errorf("Migrate() rewrote an up-to-date file: %s", data)
+	}
+}
+
+func TestSaveChecksum(t *testing.T) {
+	env := newTestEnv(t)
+	st := &State{
+		ProjectID:     "abc",
+		ContainerName: "alca-abc",
+		CreatedAt:     time.Now(),
+		Config: &config.Config{
+			Image:    "alpine",
+			Mounts:   []config.MountConfig{{Source: ".", Target: "/workspace", Exclude: []string{"**/.env"}}},
+			Envs:     map[string]config.EnvValue{"A": {Value: "1", OverrideOnEnter: true}},
+			Commands: config.Commands{Up: config.CommandValue{Command: "make", Append: true}},
+			Network:  config.Network{Ports: []config.PortConfig{{Port: 80, HostPort: 8080, Protocol: "tcp"}}},
+		},
+	}
+	if err := Save(env, "/project", st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Checksum == "" {
+		t.Fatal("Save() should set Checksum")
+	}
+
+	if _, err := Load(env, "/project"); err != nil {
+		t.Fatalf("Load() of an untouched file error = %v", err)
+	}
+
+	data, _ := afero.ReadFile(env.Fs, StateFilePath("/project"))
+	tampered := strings.Replace(string(data), "alca-abc", "alca-xyz", 1)
+	if err := afero.WriteFile(env.Fs, StateFilePath("/project"), []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(env, "/project"); !errors.Is(err, ErrStateCorrupted) {
+		t.Errorf("Load() of a tampered file error = %v, want ErrStateCorrupted", err)
+	}
+}
+
+func TestLoadChecksumCoversUnknownFields(t *testing.T) {
+	env := newTestEnv(t)
+	if err := Save(env, "/project", &State{ProjectID: "abc", ContainerName: "alca-abc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A newer alca saves a field this one does not know, without bumping
+	// the schema version
+	data, _ := afero.ReadFile(env.Fs, StateFilePath("/project"))
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	doc["future_field"] = map[string]any{"enabled": true, "ratio": 0.1}
+	delete(doc, "checksum")
+	unsummed, _ := json.Marshal(doc)
+	sum, err := documentChecksum(unsummed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc["checksum"] = sum
+	newer, _ := json.MarshalIndent(doc, "", "  ")
+	if err := afero.WriteFile(env.Fs, StateFilePath("/project"), newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := Load(env, "/project")
+	if err != nil {
+		t.Fatalf("Load() of a newer file error = %v", err)
+	}
+	if st.ContainerName != "alca-abc" {
+		t.Errorf("ContainerName = %q, want alca-abc", st.ContainerName)
+	}
+
+	// Tampering with the unknown field is still detected
+	tampered := strings.Replace(string(newer), "0.1", "0.2", 1)
+	if err := afero.WriteFile(env.Fs, StateFilePath("/project"), []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(env, "/project"); !errors.Is(err, ErrStateCorrupted) {
+		t.Errorf("Load() of a tampered newer file error = %v, want ErrStateCorrupted", err)
+	}
+}
+
+func TestSaveRotatesBackups(t *testing.T) {
+	env := newTestEnv(t)
+	for i := range BackupCount + 2 {
+		if err := Save(env, "/project", &State{ProjectID: fmt.Sprintf("v%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Saving identical content must not push a duplicate backup
+	if err := Save(env, "/project", &State{ProjectID: fmt.Sprintf("v%d", BackupCount+1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for n := range BackupCount {
+		backup, err := LoadBackup(env, "/project", n)
+		if err != nil {
+			t.Fatalf("LoadBackup(%d) error = %v", n, err)
+		}
+		want := fmt.Sprintf("v%d", BackupCount-n)
+		if backup == nil || backup.ProjectID != want {
+			t.Errorf("LoadBackup(%d) = %+v, want project %s", n, backup, want)
+		}
+	}
+	if exists, _ := afero.Exists(env.Fs, BackupFilePath("/project", BackupCount)); exists {
+		t.Errorf("only %d backups should be kept", BackupCount)
+	}
+}
+
+func TestSaveLeavesNoTemporaryFiles(t *testing.T) {
+	env := newTestEnv(t)
+	for i := range 2 {
+		if err := Save(env, "/project", &State{ProjectID: fmt.Sprintf("v%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := afero.ReadDir(env.Fs, StateDirPath("/project"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temporary file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestNewEphemeral(t *testing.T) {
+	st := NewEphemeral("Docker", "test")
+	other := NewEphemeral("Docker", "test")
+
+	if st.ProjectID == other.ProjectID {
+		t.Error("NewEphemeral() should generate a fresh project ID each time")
+	}
+	want := "alca-test-" + st.ProjectID[:containerNameUUIDPrefixLen]
+	if st.ContainerName != want {
+		t.Errorf("ContainerName = %q, want %q", st.ContainerName, want)
+	}
+	if st.Runtime != "Docker" {
+		t.Errorf("Runtime = %q, want Docker", st.Runtime)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	env := newTestEnv(t)
 	projectDir := "/project"
@@ -472,6 +687,41 @@ func TestDetectConfigDrift_WorkdirChange(t *testing.T) {
 	}
 }
 
+func TestDetectConfigDrift_WorkdirSourceChange(t *testing.T) {
+	state := &State{
+		Config: &config.Config{},
+	}
+	current := &config.Config{
+		WorkdirSource: "../monorepo",
+	}
+
+	changes := state.DetectConfigDrift(current)
+	if changes == nil || changes.WorkdirSource == nil {
+		t.Fatal("expected WorkdirSource change")
+	}
+	if *changes.WorkdirSource != [2]string{"", "../monorepo"} {
+		t.Errorf("WorkdirSource change = %v", *changes.WorkdirSource)
+	}
+	if !changes.RequiresRecreate() {
+		t.Error("workdir_source change should require recreate")
+	}
+}
+
+func TestDetectConfigDrift_PlatformChange(t *testing.T) {
+	state := &State{
+		Config: &config.Config{Platform: "linux/amd64"},
+	}
+	current := &config.Config{Platform: "linux/arm64"}
+
+	changes := state.DetectConfigDrift(current)
+	if changes == nil || changes.Platform == nil {
+		t.Fatal("expected Platform change")
+	}
+	if !changes.RequiresRecreate() {
+		t.Error("platform change should require recreate")
+	}
+}
+
 func TestDetectConfigDrift_RuntimeChange(t *testing.T) {
 	state := &State{
 		Config: &config.Config{
@@ -488,6 +738,23 @@ func TestDetectConfigDrift_RuntimeChange(t *testing.T) {
 	}
 }
 
+func TestDetectConfigDrift_RuntimeContextChange(t *testing.T) {
+	state := &State{
+		Config: &config.Config{},
+	}
+	current := &config.Config{
+		RuntimeContext: "remote-builder",
+	}
+
+	changes := state.DetectConfigDrift(current)
+	if changes == nil || changes.RuntimeContext == nil {
+		t.Fatal("expected RuntimeContext change")
+	}
+	if changes.RuntimeContext[1] != "remote-builder" {
+		t.Errorf("expected new context remote-builder, got %q", changes.RuntimeContext[1])
+	}
+}
+
 func TestDetectConfigDrift_CommandUpChange(t *testing.T) {
 	state := &State{
 		Config: &config.Config{
@@ -520,6 +787,154 @@ func TestDetectConfigDrift_CommandEnterIgnored(t *testing.T) {
 	}
 }
 
+func TestDetectConfigDrift_CapsChangeRequiresRecreate(t *testing.T) {
+	state := &State{
+		Config: &config.Config{Caps: config.Caps{Drop: []string{"ALL"}, Add: []string{"CHOWN"}}},
+	}
+	current := &config.Config{Caps: config.Caps{Drop: []string{"ALL"}, Add: []string{"CHOWN", "NET_ADMIN"}}}
+
+	changes := state.DetectConfigDrift(current)
+	if changes == nil || !changes.Caps {
+		t.Fatal("expected Caps=true for caps changes")
+	}
+	if !changes.RequiresRecreate() {
+		t.Error("caps change should require recreate")
+	}
+	if changes.HotApplicable() {
+		t.Error("caps change should not be hot-applicable")
+	}
+}
+
+func TestDetectConfigDrift_AllowHostAccessIsLANAccess(t *testing.T) {
+	state := &State{Config: &config.Config{}}
+	current := &config.Config{Network: config.Network{AllowHostAccess: true}}
+
+	changes := state.DetectConfigDrift(current)
+	if changes == nil || !changes.LANAccess {
+		t.Fatalf("expected lan-access drift, got %+v", changes)
+	}
+	if changes.RequiresRecreate() {
+		t.Error("allow_host_access change should not require recreate")
+	}
+}
+
+func TestDetectConfigDrift_NetworkChangesAreHotApplicable(t *testing.T) {
+	state := &State{
+		Config: &config.Config{
+			Network: config.Network{
+				LANAccess: []string{"192.168.1.1:80"},
+				Proxy:     "10.0.0.1:8080",
+			},
+		},
+	}
+	current := &config.Config{
+		Network: config.Network{
+			LANAccess:      []string{"192.168.1.1:80", "192.168.1.2:5432"},
+			Proxy:          "10.0.0.2:8080",
+			Shaping:        config.Shaping{Rate: "10mbit"},
+			LogConnections: true,
+		},
+	}
+
+	changes := state.DetectConfigDrift(current)
+	if changes == nil {
+		t.Fatal("expected drift changes")
+	}
+	if !changes.LANAccess || changes.Proxy == nil || !changes.Shaping || changes.LogConnections == nil {
+		t.Errorf("expected all network fields flagged, got %+v", changes)
+	}
+	if changes.RequiresRecreate() {
+		t.Error("network changes should not require recreate")
+	}
+	if !changes.HotApplicable() {
+		t.Error("network changes should be hot-applicable")
+	}
+}
+
+func TestDetectConfigDrift_Isolation(t *testing.T) {
+	tests := []struct {
+		name         string
+		stored       config.Network
+		current      config.Network
+		wantDrift    bool
+		wantRecreate bool
+	}{
+		{name: "unset in old state", stored: config.Network{}, current: config.Network{Isolation: config.IsolationDefault}},
+		{name: "default to custom", stored: config.Network{}, current: config.Network{Isolation: config.IsolationCustom}},
+		{name: "default to strict", stored: config.Network{}, current: config.Network{Isolation: config.IsolationStrict}, wantDrift: true},
+		{name: "strict to none", stored: config.Network{Isolation: config.IsolationStrict}, current: config.Network{Isolation: config.IsolationNone}, wantDrift: true, wantRecreate: true},
+		{name: "none to default", stored: config.Network{Isolation: config.IsolationNone}, current: config.Network{}, wantDrift: true, wantRecreate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &State{Config: &config.Config{Network: tt.stored}}
+			changes := state.DetectConfigDrift(&config.Config{Network: tt.current})
+			if got := changes != nil && changes.Isolation != nil; got != tt.wantDrift {
+				t.Fatalf("isolation drift = %v, want %v (%+v)", got, tt.wantDrift, changes)
+			}
+			if got := changes.RequiresRecreate(); got != tt.wantRecreate {
+				t.Errorf("RequiresRecreate() = %v, want %v", got, tt.wantRecreate)
+			}
+		})
+	}
+}
+
+func TestDetectConfigDrift_NestedContainers(t *testing.T) {
+	state := &State{Config: &config.Config{}}
+	if changes := state.DetectConfigDrift(&config.Config{NestedContainers: config.NestedContainersNone}); changes != nil {
+		t.Errorf("unset and none should be equivalent, got %+v", changes)
+	}
+
+	changes := state.DetectConfigDrift(&config.Config{NestedContainers: config.NestedContainersDind})
+	if changes == nil || changes.NestedContainers == nil || *changes.NestedContainers != [2]string{"none", "dind"} {
+		t.Fatalf("expected none → dind drift, got %+v", changes)
+	}
+	if !changes.RequiresRecreate() {
+		t.Error("a nested_containers change should require a recreate")
+	}
+}
+
+func TestDetectConfigDrift_Userns(t *testing.T) {
+	state := &State{Config: &config.Config{}}
+	changes := state.DetectConfigDrift(&config.Config{Userns: config.UsernsKeepID})
+	if changes == nil || changes.Userns == nil || *changes.Userns != [2]string{"", "keep-id"} {
+		t.Fatalf("expected userns drift, got %+v", changes)
+	}
+	if !changes.RequiresRecreate() {
+		t.Error("a userns change should require a recreate")
+	}
+}
+
+func TestDetectConfigDrift_NetworkTokensMatchExpandedState(t *testing.T) {
+	// State stores lan-access and proxy with alca tokens expanded
+	state := &State{
+		Config: &config.Config{
+			Network: config.Network{
+				LANAccess: []string{"192.168.65.254:5432"},
+				Proxy:     "192.168.65.254:1080",
+			},
+		},
+	}
+	current := &config.Config{
+		Network: config.Network{
+			LANAccess: []string{"${alca:HOST_IP}:5432"},
+			Proxy:     "${alca:HOST_IP}:1080",
+		},
+	}
+
+	if changes := state.DetectConfigDrift(current); changes != nil {
+		t.Errorf("token rules should match their expanded state, got %+v", changes)
+	}
+}
+
+func TestDriftChanges_NilIsNeitherRecreateNorHot(t *testing.T) {
+	var changes *DriftChanges
+	if changes.RequiresRecreate() || changes.HotApplicable() {
+		t.Error("nil drift should report no changes")
+	}
+}
+
 func TestDetectConfigDrift_ResourcesChange(t *testing.T) {
 	state := &State{
 		Config: &config.Config{
@@ -542,6 +957,37 @@ func TestDetectConfigDrift_ResourcesChange(t *testing.T) {
 	}
 }
 
+func TestDriftChanges_ResourcesHotApplicable(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new config.Resources
+		wantHot  bool
+	}{
+		{"raised", config.Resources{Memory: "2g", CPUs: 1}, config.Resources{Memory: "4g", CPUs: 2}, true},
+		{"added", config.Resources{}, config.Resources{Memory: "4g"}, true},
+		{"memory limit removed", config.Resources{Memory: "2g"}, config.Resources{}, false},
+		{"cpu limit removed", config.Resources{CPUs: 2}, config.Resources{}, false},
+		{"cpu weight and cpuset changed", config.Resources{Cgroup: config.Cgroup{CPUWeight: 100}}, config.Resources{Cgroup: config.Cgroup{CPUWeight: 50, CpusetCPUs: "0-3"}}, true},
+		{"cpuset pinning removed", config.Resources{Cgroup: config.Cgroup{CpusetCPUs: "0-3"}}, config.Resources{}, false},
+		{"cgroup parent changed", config.Resources{}, config.Resources{Cgroup: config.Cgroup{Parent: "alca.slice"}}, false},
+		{"io latency changed", config.Resources{}, config.Resources{Cgroup: config.Cgroup{IOLatency: config.IOLatency{Device: "259:0", Target: "10ms"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := (&State{Config: &config.Config{Resources: tt.old}}).DetectConfigDrift(&config.Config{Resources: tt.new})
+			if got := changes.ResourcesHotApplicable(); got != tt.wantHot {
+				t.Errorf("ResourcesHotApplicable() = %v, want %v", got, tt.wantHot)
+			}
+			if changes.RequiresRecreate() == tt.wantHot {
+				t.Errorf("RequiresRecreate() = %v, want %v", !tt.wantHot, !tt.wantHot)
+			}
+			if changes.HotApplicable() != tt.wantHot {
+				t.Errorf("HotApplicable() = %v, want %v", !tt.wantHot, tt.wantHot)
+			}
+		})
+	}
+}
+
 func TestDetectConfigDrift_MountsChange(t *testing.T) {
 	state := &State{
 		Config: &config.Config{
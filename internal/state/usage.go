@@ -0,0 +1,80 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// UsageFilename is the name of the container usage file.
+const UsageFilename = "usage.json"
+
+// Usage records when the container was last used, for idle_timeout. It is
+// kept out of state.json because open sessions rewrite it every few
+// minutes, which would churn the state backups.
+type Usage struct {
+	// LastUsedAt is when alca up, enter or run last used the container.
+	LastUsedAt time.Time `json:"last_used_at"`
+	// IdleStoppedAt is set when 'alca stop-idle' stopped the container, so
+	// the next enter or run starts it again without asking.
+	IdleStoppedAt time.Time `json:"idle_stopped_at,omitempty"`
+}
+
+// UsageFilePath returns the path to the usage file for the given project directory.
+func UsageFilePath(projectDir string) string {
+	return filepath.Join(projectDir, StateDir, UsageFilename)
+}
+
+// LoadUsage reads the usage file.
+// Returns nil and no error if the container was never used since it existed.
+func LoadUsage(env *util.Env, projectDir string) (*Usage, error) {
+	data, err := afero.ReadFile(env.Fs, UsageFilePath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	var u Usage
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, fmt.Errorf("failed to parse usage: %w", err)
+	}
+	return &u, nil
+}
+
+// SaveUsage writes the usage file.
+func SaveUsage(env *util.Env, projectDir string, u *Usage) error {
+	if err := env.Fs.MkdirAll(StateDirPath(projectDir), stateDirPerm); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+	if err := util.WriteFileAtomic(env.Fs, UsageFilePath(projectDir), data, stateFilePerm); err != nil {
+		return fmt.Errorf("failed to write usage: %w", err)
+	}
+	return nil
+}
+
+// RecordUse marks the container used at now, clearing IdleStoppedAt.
+func RecordUse(env *util.Env, projectDir string, now time.Time) error {
+	return SaveUsage(env, projectDir, &Usage{LastUsedAt: now})
+}
+
+// IdleFor returns how long the container has gone unused at now, or 0 if
+// no use is recorded.
+func (u *Usage) IdleFor(now time.Time) time.Duration {
+	if u == nil || u.LastUsedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(u.LastUsedAt)
+}
@@ -0,0 +1,44 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+func TestUsage_RecordUseClearsIdleStop(t *testing.T) {
+	env := &util.Env{Fs: afero.NewMemMapFs()}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if u, err := LoadUsage(env, "/p"); err != nil || u != nil {
+		t.Fatalf("LoadUsage() without a file = %v, %v; want nil, nil", u, err)
+	}
+	if err := SaveUsage(env, "/p", &Usage{LastUsedAt: now.Add(-3 * time.Hour), IdleStoppedAt: now.Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	u, err := LoadUsage(env, "/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.IdleFor(now); got != 3*time.Hour {
+		t.Errorf("IdleFor() = %v, want 3h", got)
+	}
+
+	if err := RecordUse(env, "/p", now); err != nil {
+		t.Fatal(err)
+	}
+	u, _ = LoadUsage(env, "/p")
+	if !u.IdleStoppedAt.IsZero() || u.IdleFor(now) != 0 {
+		t.Errorf("usage after RecordUse = %+v, want used now and not idle-stopped", u)
+	}
+}
+
+func TestUsage_IdleForWithoutRecord(t *testing.T) {
+	var u *Usage
+	if got := u.IdleFor(time.Now()); got != 0 {
+		t.Errorf("IdleFor() on nil usage = %v, want 0", got)
+	}
+}
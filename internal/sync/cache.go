@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/afero"
 
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
 	"github.com/bolasblack/alcatraz/internal/util"
 )
 
@@ -81,8 +83,37 @@ func SyncUpdateCache(ctx context.Context, env *SyncEnv, projectID string, projec
 		Conflicts: allConflicts,
 	}
 
+	previous, _ := ReadCache(env.Fs, projectRoot)
 	if err := WriteCache(env.Fs, projectRoot, cacheData); err != nil {
 		return nil, err
 	}
+	recordNewConflicts(env.Fs, projectID, projectRoot, previous, allConflicts)
 	return cacheData, nil
 }
+
+// recordNewConflicts appends a lifecycle event for the conflicts not in
+// the previous cache. Best-effort.
+func recordNewConflicts(fs afero.Fs, projectID, projectRoot string, previous *CacheData, conflicts []ConflictInfo) {
+	known := map[string]bool{}
+	if previous != nil {
+		for _, c := range previous.Conflicts {
+			known[c.Path] = true
+		}
+	}
+	var paths []string
+	for _, c := range conflicts {
+		if !known[c.Path] {
+			paths = append(paths, c.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	_ = lifecycle.Append(fs, projectRoot, lifecycle.Event{
+		Time:      time.Now().UTC(),
+		Type:      lifecycle.SyncConflicted,
+		ProjectID: projectID,
+		Message:   fmt.Sprintf("%d new sync conflict(s): %s", len(paths), strings.Join(paths, ", ")),
+		Details:   map[string]string{"paths": strings.Join(paths, "\n")},
+	})
+}
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/lifecycle"
 )
 
 func TestReadCache(t *testing.T) {
@@ -307,6 +309,18 @@ func TestSyncUpdateCache(t *testing.T) {
 		if len(cached.Conflicts) != 1 {
 			t.Errorf("expected 1 cached conflict, got %d", len(cached.Conflicts))
 		}
+
+		// The new conflict is a lifecycle event, recorded once
+		if _, err := SyncUpdateCache(context.Background(), env, "proj1", "/project"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		events, err := lifecycle.Load(fs, "/project")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].Type != lifecycle.SyncConflicted || events[0].ProjectID != "proj1" || events[0].Details["paths"] != "src/main.go" {
+			t.Errorf("events = %+v", events)
+		}
 	})
 }
 
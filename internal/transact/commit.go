@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/util"
 )
 
 // OpGroup represents a group of operations with the same sudo requirement.
@@ -24,10 +26,7 @@ func ExecuteOp(fs afero.Fs, op FileOp) error {
 		if err := fs.MkdirAll(dir, 0755); err != nil {
 			return err
 		}
-		if err := afero.WriteFile(fs, op.Path, op.Content, op.Mode); err != nil {
-			return err
-		}
-		return nil
+		return util.WriteFileAtomic(fs, op.Path, op.Content, op.Mode)
 
 	case OpChmod:
 		return fs.Chmod(op.Path, op.Mode)
@@ -40,6 +39,10 @@ func ExecuteOp(fs afero.Fs, op FileOp) error {
 	}
 }
 
+// batchTempSuffix names the temporary file a batch script writes before
+// renaming it over the target.
+const batchTempSuffix = ".alca-tmp"
+
 // GenerateBatchScript creates a shell script for batched sudo operations.
 // Uses base64 encoding for file content to avoid shell escaping issues.
 // Useful for implementing commit callbacks that need sudo.
@@ -54,10 +57,13 @@ func GenerateBatchScript(ops []FileOp) string {
 			dir := parentDir(op.Path)
 			script.WriteString(fmt.Sprintf("mkdir -p %q\n", dir))
 
-			// Use base64 for safe content transfer
+			// Use base64 for safe content transfer. Write next to the target
+			// and rename over it, so the file is never seen half-written.
+			tmp := op.Path + batchTempSuffix
 			encoded := base64.StdEncoding.EncodeToString(op.Content)
-			script.WriteString(fmt.Sprintf("echo %q | base64 -d > %q\n", encoded, op.Path))
-			script.WriteString(fmt.Sprintf("chmod %o %q\n", op.Mode.Perm(), op.Path))
+			script.WriteString(fmt.Sprintf("echo %q | base64 -d > %q\n", encoded, tmp))
+			script.WriteString(fmt.Sprintf("chmod %o %q\n", op.Mode.Perm(), tmp))
+			script.WriteString(fmt.Sprintf("mv -f %q %q\n", tmp, op.Path))
 
 		case OpChmod:
 			script.WriteString(fmt.Sprintf("chmod %o %q\n", op.Mode.Perm(), op.Path))
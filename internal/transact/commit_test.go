@@ -26,6 +26,11 @@ func TestGenerateBatchScript(t *testing.T) {
 		t.Error("script should use base64 for content")
 	}
 
+	// Content goes to a temporary file renamed over the target
+	if !strings.Contains(script, `> "/etc/test.alca-tmp"`) || !strings.Contains(script, `mv -f "/etc/test.alca-tmp" "/etc/test"`) {
+		t.Errorf("script should write a temporary file and rename it:\n%s", script)
+	}
+
 	// Check for chmod
 	if !strings.Contains(script, "chmod 644") {
 		t.Error("script should have chmod 644 for create")
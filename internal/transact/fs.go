@@ -333,6 +333,13 @@ func (t *TransactFs) Rename(oldname, newname string) error {
 	return nil
 }
 
+// WriteFileAtomic stages data for path, like afero.WriteFile. Staged files
+// only reach disk through ExecuteOp or GenerateBatchScript, which replace
+// them atomically, so util.WriteFileAtomic needs no temporary file here.
+func (t *TransactFs) WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return afero.WriteFile(t, path, data, perm)
+}
+
 // Stat returns file info from the CopyOnWrite overlay (staged first, then actual).
 func (t *TransactFs) Stat(name string) (os.FileInfo, error) {
 	t.mu.RLock()
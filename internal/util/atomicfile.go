@@ -0,0 +1,61 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// atomicFileWriter is implemented by filesystems that make writes atomic on
+// their own, e.g. a staging filesystem whose commit step calls
+// WriteFileAtomic. WriteFileAtomic defers to them instead of staging a
+// temporary file that would only show up as noise in the commit.
+type atomicFileWriter interface {
+	WriteFileAtomic(path string, data []byte, perm os.FileMode) error
+}
+
+// WriteFileAtomic replaces path with data so that readers, and the file
+// after a crash, see either the old or the new content but never a partial
+// write. The data is written to a temporary file in the same directory,
+// fsynced, then renamed over path; the directory is synced afterwards on a
+// best-effort basis so the rename itself survives a power loss.
+func WriteFileAtomic(fs afero.Fs, path string, data []byte, perm os.FileMode) error {
+	if w, ok := fs.(atomicFileWriter); ok {
+		return w.WriteFileAtomic(path, data, perm)
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := afero.TempFile(fs, dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// Removing after a successful rename fails harmlessly
+	defer func() { _ = fs.Remove(tmpPath) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmpPath, err)
+	}
+	if err := fs.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to chmod %s: %w", tmpPath, err)
+	}
+	if err := fs.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", tmpPath, path, err)
+	}
+
+	if d, err := fs.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
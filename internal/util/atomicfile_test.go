@@ -0,0 +1,83 @@
+package util
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestWriteFileAtomic_ReplacesContent(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = fs.MkdirAll("/dir", 0755)
+	_ = afero.WriteFile(fs, "/dir/file", []byte("old"), 0600)
+
+	if err := WriteFileAtomic(fs, "/dir/file", []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+
+	got, _ := afero.ReadFile(fs, "/dir/file")
+	if string(got) != "new" {
+		t.Errorf("content = %q, want %q", got, "new")
+	}
+	info, _ := fs.Stat("/dir/file")
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %o, want 644", info.Mode().Perm())
+	}
+
+	entries, _ := afero.ReadDir(fs, "/dir")
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
+
+// renameFailFs fails every rename, simulating a crash before the swap.
+type renameFailFs struct {
+	afero.Fs
+}
+
+func (renameFailFs) Rename(string, string) error {
+	return errors.New("rename failed")
+}
+
+func TestWriteFileAtomic_KeepsOldContentOnFailure(t *testing.T) {
+	base := afero.NewMemMapFs()
+	_ = base.MkdirAll("/dir", 0755)
+	_ = afero.WriteFile(base, "/dir/file", []byte("old"), 0644)
+
+	if err := WriteFileAtomic(renameFailFs{base}, "/dir/file", []byte("new"), 0644); err == nil {
+		t.Fatal("expected error")
+	}
+
+	got, _ := afero.ReadFile(base, "/dir/file")
+	if string(got) != "old" {
+		t.Errorf("content = %q, want old content untouched", got)
+	}
+	entries, _ := afero.ReadDir(base, "/dir")
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
+
+// stagingFs records WriteFileAtomic calls instead of writing.
+type stagingFs struct {
+	afero.Fs
+	written map[string]string
+}
+
+func (s *stagingFs) WriteFileAtomic(path string, data []byte, _ os.FileMode) error {
+	s.written[path] = string(data)
+	return nil
+}
+
+func TestWriteFileAtomic_DefersToAtomicFs(t *testing.T) {
+	fs := &stagingFs{Fs: afero.NewMemMapFs(), written: map[string]string{}}
+
+	if err := WriteFileAtomic(fs, "/dir/file", []byte("data"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+	if fs.written["/dir/file"] != "data" {
+		t.Errorf("expected write to be delegated, got %v", fs.written)
+	}
+}
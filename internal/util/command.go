@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strings"
 )
 
 // CommandRunner executes external commands.
@@ -16,8 +17,28 @@ type CommandRunner interface {
 	// RunQuiet executes a command without streaming, returning combined stdout/stderr.
 	RunQuiet(ctx context.Context, name string, args ...string) (output []byte, err error)
 
-	// RunInDir executes a command in the specified directory with inherited stdout/stderr.
-	RunInDir(ctx context.Context, dir string, name string, args ...string) error
+	// RunStream executes a command, forwarding its stdout/stderr line by line
+	// to out while it runs (nil discards), each line passed through transform
+	// if non-nil. Returns the untransformed combined output.
+	RunStream(ctx context.Context, out io.Writer, transform LineFunc, name string, args ...string) (output []byte, err error)
+
+	// RunTo executes a command, writing its stdout/stderr straight to out
+	// without keeping a copy. For long-running streams such as followed logs.
+	RunTo(ctx context.Context, out io.Writer, name string, args ...string) error
+
+	// RunWithInput executes a command with input on its stdin, without
+	// streaming, returning combined stdout/stderr. For secrets that must not
+	// appear in the process list.
+	RunWithInput(ctx context.Context, input string, name string, args ...string) (output []byte, err error)
+
+	// RunInDir executes a command in the specified directory, writing its
+	// stdout/stderr to out (nil discards).
+	RunInDir(ctx context.Context, out io.Writer, dir string, name string, args ...string) error
+
+	// RunAttached executes a command attached to this process's stdin,
+	// stdout and stderr, in dir (empty for the caller's) with env added to
+	// the inherited environment. For interactive programs such as plugins.
+	RunAttached(ctx context.Context, dir string, env []string, name string, args ...string) error
 
 	// SudoRun runs a command with sudo, connecting stdin/stdout/stderr.
 	SudoRun(ctx context.Context, name string, args ...string) error
@@ -27,6 +48,23 @@ type CommandRunner interface {
 
 	// SudoRunScriptQuiet writes script to a temp file and executes it with sudo.
 	SudoRunScriptQuiet(ctx context.Context, script string) error
+
+	// Start starts a command in the background as opts describes, and
+	// returns without waiting for it. The command is not tied to a context
+	// and outlives the caller.
+	Start(opts StartOptions, name string, args ...string) error
+}
+
+// StartOptions configures CommandRunner.Start.
+type StartOptions struct {
+	// Env is added to the inherited environment.
+	Env []string
+	// Dir is the working directory; empty for the caller's.
+	Dir string
+	// LogFile is a host file the command's stdout and stderr are appended
+	// to, created if missing; empty discards them. The command writes to
+	// it directly, so it keeps logging after the caller exits.
+	LogFile string
 }
 
 var _ CommandRunner = (*DefaultCommandRunner)(nil)
@@ -59,14 +97,73 @@ func (r *DefaultCommandRunner) RunQuiet(ctx context.Context, name string, args .
 	return cmd.CombinedOutput()
 }
 
-func (r *DefaultCommandRunner) RunInDir(ctx context.Context, dir string, name string, args ...string) error {
+func (r *DefaultCommandRunner) RunStream(ctx context.Context, out io.Writer, transform LineFunc, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...) //nolint:fslint // CommandRunner is the abstraction layer
+	var buf bytes.Buffer
+	lines := newLineWriter(out, transform)
+	// A single writer for both streams, so exec serializes the writes
+	w := io.MultiWriter(&buf, lines)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err := cmd.Run()
+	_ = lines.Flush()
+	return buf.Bytes(), err
+}
+
+func (r *DefaultCommandRunner) RunTo(ctx context.Context, out io.Writer, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...) //nolint:fslint // CommandRunner is the abstraction layer
+	// A single writer for both streams, so exec serializes the writes
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+func (r *DefaultCommandRunner) RunWithInput(ctx context.Context, input string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...) //nolint:fslint // CommandRunner is the abstraction layer
+	cmd.Stdin = strings.NewReader(input)
+	return cmd.CombinedOutput()
+}
+
+func (r *DefaultCommandRunner) RunInDir(ctx context.Context, out io.Writer, dir string, name string, args ...string) error {
 	cmd := exec.CommandContext(ctx, name, args...) //nolint:fslint // CommandRunner is the abstraction layer
 	cmd.Dir = dir
+	// A single writer for both streams, so exec serializes the writes
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+func (r *DefaultCommandRunner) RunAttached(ctx context.Context, dir string, env []string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...) //nolint:fslint // CommandRunner is the abstraction layer
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = os.Stdin
 	cmd.Stdout = r.stdout
 	cmd.Stderr = r.stderr
 	return cmd.Run()
 }
 
+func (r *DefaultCommandRunner) Start(opts StartOptions, name string, args ...string) error {
+	cmd := exec.Command(name, args...) //nolint:fslint // CommandRunner is the abstraction layer
+	cmd.Env = append(os.Environ(), opts.Env...)
+	cmd.Dir = opts.Dir
+	if opts.LogFile != "" {
+		// An *os.File hands the child the descriptor itself instead of a
+		// pipe copied by this process
+		logFile, err := os.OpenFile(opts.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644) //nolint:fslint // the child needs a real file descriptor
+		if err != nil {
+			return err
+		}
+		defer func() { _ = logFile.Close() }()
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
 func (r *DefaultCommandRunner) SudoRun(ctx context.Context, name string, args ...string) error {
 	return sudoRunContext(ctx, name, args...)
 }
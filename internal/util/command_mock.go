@@ -3,6 +3,9 @@ package util
 import (
 	"context"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
 	"strings"
 	"testing"
 )
@@ -19,6 +22,13 @@ type MockCommandRunner struct {
 	// Each call consumes the next result. When exhausted, falls back to commands map.
 	commandSequences map[string][]MockResult
 
+	// groups hold ordered expectations registered via InOrder.
+	groups []*MockGroup
+
+	// patterns hold glob/regexp expectations, checked in registration order
+	// after exact expectations and groups.
+	patterns []*mockPattern
+
 	// defaultError is returned for unexpected commands.
 	defaultError error
 
@@ -37,7 +47,29 @@ type CommandCall struct {
 	Name string
 	Args []string
 	Key  string // "name arg1 arg2 ..."
-	Dir  string // working directory (set by RunInDir, empty otherwise)
+	Dir  string // working directory (set by RunInDir, RunAttached and Start, empty otherwise)
+	// Input is what RunWithInput fed to stdin (empty otherwise); it is not
+	// part of Key.
+	Input string
+	// Env is what Start and RunAttached added to the environment (nil
+	// otherwise); it is not part of Key.
+	Env []string
+	// LogFile is Start's log file (empty otherwise).
+	LogFile string
+
+	// Expectation describes the expectation that matched ("" if unexpected).
+	Expectation string
+	// Output and Err are the result returned to the caller.
+	Output []byte
+	Err    error
+}
+
+// mockPattern is an expectation matching call keys by regular expression.
+type mockPattern struct {
+	re     *regexp.Regexp
+	desc   string
+	result MockResult
+	calls  int
 }
 
 // NewMockCommandRunner creates a mock that fails on unexpected commands.
@@ -75,6 +107,101 @@ func (m *MockCommandRunner) ExpectSequence(cmd string, output []byte, err error)
 	return m
 }
 
+// ExpectMatch registers a result for every command whose key matches glob,
+// where * matches any run of characters (including spaces and slashes) and
+// ? matches one. Use it for arguments that vary between runs, such as
+// generated names or temp paths.
+func (m *MockCommandRunner) ExpectMatch(glob string, output []byte, err error) *MockCommandRunner {
+	m.patterns = append(m.patterns, &mockPattern{
+		re:     globRegexp(glob),
+		desc:   "glob " + glob,
+		result: MockResult{Output: output, Err: err},
+	})
+	return m
+}
+
+// ExpectRegexp registers a result for every command whose key matches the
+// regular expression expr (unanchored). Panics if expr is invalid.
+func (m *MockCommandRunner) ExpectRegexp(expr string, output []byte, err error) *MockCommandRunner {
+	m.patterns = append(m.patterns, &mockPattern{
+		re:     regexp.MustCompile(expr),
+		desc:   "regexp " + expr,
+		result: MockResult{Output: output, Err: err},
+	})
+	return m
+}
+
+// globRegexp compiles a glob (see ExpectMatch) into an anchored regexp.
+func globRegexp(glob string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(glob)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// InOrder starts a group of expectations that must be called in the order
+// they are added. Each group entry answers one call. AssertAllExpectationsMet
+// reports entries called out of order or never called.
+func (m *MockCommandRunner) InOrder() *MockGroup {
+	g := &MockGroup{}
+	m.groups = append(m.groups, g)
+	return g
+}
+
+// MockGroup is an ordered expectation group created by InOrder.
+type MockGroup struct {
+	entries    []*mockGroupEntry
+	next       int
+	violations []string
+}
+
+type mockGroupEntry struct {
+	re       *regexp.Regexp
+	desc     string
+	result   MockResult
+	consumed bool
+}
+
+// Expect adds an exact expectation to the group.
+func (g *MockGroup) Expect(cmd string, output []byte, err error) *MockGroup {
+	return g.add(regexp.MustCompile("^"+regexp.QuoteMeta(cmd)+"$"), cmd, output, err)
+}
+
+// ExpectSuccess is shorthand for Expect(cmd, output, nil).
+func (g *MockGroup) ExpectSuccess(cmd string, output []byte) *MockGroup {
+	return g.Expect(cmd, output, nil)
+}
+
+// ExpectMatch adds a glob expectation (see MockCommandRunner.ExpectMatch).
+func (g *MockGroup) ExpectMatch(glob string, output []byte, err error) *MockGroup {
+	return g.add(globRegexp(glob), "glob "+glob, output, err)
+}
+
+func (g *MockGroup) add(re *regexp.Regexp, desc string, output []byte, err error) *MockGroup {
+	g.entries = append(g.entries, &mockGroupEntry{re: re, desc: desc, result: MockResult{Output: output, Err: err}})
+	return g
+}
+
+// consume answers key with the first unconsumed matching entry, recording
+// a violation if entries before it have not been called yet.
+func (g *MockGroup) consume(key string) (MockResult, string, bool) {
+	for i := g.next; i < len(g.entries); i++ {
+		e := g.entries[i]
+		if e.consumed || !e.re.MatchString(key) {
+			continue
+		}
+		if i != g.next {
+			g.violations = append(g.violations, fmt.Sprintf("%q called before %q", key, g.entries[g.next].desc))
+		}
+		e.consumed = true
+		for g.next < len(g.entries) && g.entries[g.next].consumed {
+			g.next++
+		}
+		return e.result, e.desc, true
+	}
+	return MockResult{}, "", false
+}
+
 // AllowUnexpected makes unexpected commands return empty output and nil error.
 func (m *MockCommandRunner) AllowUnexpected() *MockCommandRunner {
 	m.defaultError = nil
@@ -83,33 +210,58 @@ func (m *MockCommandRunner) AllowUnexpected() *MockCommandRunner {
 
 // Run implements CommandRunner.
 func (m *MockCommandRunner) Run(_ context.Context, name string, args ...string) ([]byte, error) {
+	return m.call(name, args, "")
+}
+
+// call records an invocation and returns its expected result, or the
+// default behavior for unexpected commands. name includes any "sudo " prefix.
+func (m *MockCommandRunner) call(name string, args []string, dir string) ([]byte, error) {
 	key := name
 	if len(args) > 0 {
 		key = name + " " + strings.Join(args, " ")
 	}
 
+	result, expectation, ok := m.lookup(key)
+	if !ok && m.defaultError != nil {
+		result.Err = fmt.Errorf("%w: %s", m.defaultError, key)
+	}
+
 	m.Calls = append(m.Calls, CommandCall{
-		Name: name,
-		Args: args,
-		Key:  key,
+		Name:        name,
+		Args:        args,
+		Key:         key,
+		Dir:         dir,
+		Expectation: expectation,
+		Output:      result.Output,
+		Err:         result.Err,
 	})
+	return result.Output, result.Err
+}
 
-	// Check sequence queue first (ordered, consumed on each call)
-	if seq, ok := m.commandSequences[key]; ok && len(seq) > 0 {
-		result := seq[0]
+// lookup returns the expected result for key and a description of the
+// expectation that matched. Exact expectations (the next queued sequence
+// result, else the single response) win over ordered groups, which win over
+// patterns in registration order.
+func (m *MockCommandRunner) lookup(key string) (MockResult, string, bool) {
+	if seq := m.commandSequences[key]; len(seq) > 0 {
 		m.commandSequences[key] = seq[1:]
-		return result.Output, result.Err
+		return seq[0], key, true
 	}
-
-	// Fall back to single-response map
 	if result, ok := m.commands[key]; ok {
-		return result.Output, result.Err
+		return result, key, true
 	}
-
-	if m.defaultError != nil {
-		return nil, fmt.Errorf("%w: %s", m.defaultError, key)
+	for _, g := range m.groups {
+		if result, desc, ok := g.consume(key); ok {
+			return result, desc, true
+		}
 	}
-	return nil, nil
+	for _, p := range m.patterns {
+		if p.re.MatchString(key) {
+			p.calls++
+			return p.result, p.desc, true
+		}
+	}
+	return MockResult{}, "", false
 }
 
 // RunQuiet implements CommandRunner.
@@ -117,106 +269,94 @@ func (m *MockCommandRunner) RunQuiet(ctx context.Context, name string, args ...s
 	return m.Run(ctx, name, args...)
 }
 
+// RunStream implements CommandRunner.
+// Records like Run, and feeds the expected output through transform to out.
+func (m *MockCommandRunner) RunStream(ctx context.Context, out io.Writer, transform LineFunc, name string, args ...string) ([]byte, error) {
+	output, err := m.Run(ctx, name, args...)
+	lines := newLineWriter(out, transform)
+	_, _ = lines.Write(output)
+	_ = lines.Flush()
+	return output, err
+}
+
+// RunTo implements CommandRunner. Records the call like Run and writes the
+// scripted output to out.
+func (m *MockCommandRunner) RunTo(ctx context.Context, out io.Writer, name string, args ...string) error {
+	output, err := m.Run(ctx, name, args...)
+	_, _ = out.Write(output)
+	return err
+}
+
+// RunWithInput implements CommandRunner. Records like Run, keeping input
+// in the call's Input field.
+func (m *MockCommandRunner) RunWithInput(_ context.Context, input string, name string, args ...string) ([]byte, error) {
+	output, err := m.call(name, args, "")
+	m.Calls[len(m.Calls)-1].Input = input
+	return output, err
+}
+
 // RunInDir implements CommandRunner.
-// Records the dir in the call's Args[0] position for test assertions.
-func (m *MockCommandRunner) RunInDir(ctx context.Context, dir string, name string, args ...string) error {
-	// Record dir as part of the call for assertions.
-	// The call key is still based on name+args (same as Run) so that
-	// ExpectSuccess/ExpectFailure work without needing dir in the key.
-	key := name
-	if len(args) > 0 {
-		key = name + " " + strings.Join(args, " ")
+// Records the dir in the call's Dir field for test assertions and writes the
+// scripted output to out. The call key is still based on name+args (same as
+// Run) so that ExpectSuccess/ExpectFailure work without needing dir in the key.
+func (m *MockCommandRunner) RunInDir(_ context.Context, out io.Writer, dir string, name string, args ...string) error {
+	output, err := m.call(name, args, dir)
+	if out != nil {
+		_, _ = out.Write(output)
 	}
-	m.Calls = append(m.Calls, CommandCall{
-		Name: name,
-		Args: args,
-		Key:  key,
-		Dir:  dir,
-	})
+	return err
+}
 
-	if seq, ok := m.commandSequences[key]; ok && len(seq) > 0 {
-		result := seq[0]
-		m.commandSequences[key] = seq[1:]
-		return result.Err
-	}
-	if result, ok := m.commands[key]; ok {
-		return result.Err
-	}
-	if m.defaultError != nil {
-		return fmt.Errorf("%w: %s", m.defaultError, key)
-	}
-	return nil
+// RunAttached implements CommandRunner. Records like RunInDir, keeping env
+// in the call's Env field.
+func (m *MockCommandRunner) RunAttached(_ context.Context, dir string, env []string, name string, args ...string) error {
+	_, err := m.call(name, args, dir)
+	m.Calls[len(m.Calls)-1].Env = env
+	return err
+}
+
+// Start implements CommandRunner. Records like RunInDir, keeping opts.Env
+// and opts.LogFile in the call's Env and LogFile fields.
+func (m *MockCommandRunner) Start(opts StartOptions, name string, args ...string) error {
+	_, err := m.call(name, args, opts.Dir)
+	m.Calls[len(m.Calls)-1].Env = opts.Env
+	m.Calls[len(m.Calls)-1].LogFile = opts.LogFile
+	return err
 }
 
 // SudoRun implements CommandRunner.
 // Records with key "sudo name arg1 arg2 ...".
 func (m *MockCommandRunner) SudoRun(_ context.Context, name string, args ...string) error {
-	key := "sudo " + name
-	if len(args) > 0 {
-		key = "sudo " + name + " " + strings.Join(args, " ")
-	}
-
-	m.Calls = append(m.Calls, CommandCall{
-		Name: "sudo " + name,
-		Args: args,
-		Key:  key,
-	})
-
-	if result, ok := m.commands[key]; ok {
-		return result.Err
-	}
-
-	if m.defaultError != nil {
-		return fmt.Errorf("%w: %s", m.defaultError, key)
-	}
-	return nil
+	_, err := m.call("sudo "+name, args, "")
+	return err
 }
 
 // SudoRunQuiet implements CommandRunner.
 // Records with key "sudo name arg1 arg2 ...".
 func (m *MockCommandRunner) SudoRunQuiet(_ context.Context, name string, args ...string) ([]byte, error) {
-	key := "sudo " + name
-	if len(args) > 0 {
-		key = "sudo " + name + " " + strings.Join(args, " ")
-	}
-
-	m.Calls = append(m.Calls, CommandCall{
-		Name: "sudo " + name,
-		Args: args,
-		Key:  key,
-	})
-
-	if result, ok := m.commands[key]; ok {
-		return result.Output, result.Err
-	}
-
-	if m.defaultError != nil {
-		return nil, fmt.Errorf("%w: %s", m.defaultError, key)
-	}
-	return nil, nil
+	return m.call("sudo "+name, args, "")
 }
 
 // SudoRunScriptQuiet implements CommandRunner.
-// Records with key "sudo sh -c <script>" (truncated to first line for readability).
+// Records with key "sudo sh script"; the script itself is in Args[0].
 func (m *MockCommandRunner) SudoRunScriptQuiet(_ context.Context, script string) error {
-	key := "sudo sh script"
-
+	result, expectation, ok := m.lookup(mockScriptKey)
+	if !ok && m.defaultError != nil {
+		result.Err = fmt.Errorf("%w: %s", m.defaultError, mockScriptKey)
+	}
 	m.Calls = append(m.Calls, CommandCall{
-		Name: "sudo sh",
-		Args: []string{script},
-		Key:  key,
+		Name:        "sudo sh",
+		Args:        []string{script},
+		Key:         mockScriptKey,
+		Expectation: expectation,
+		Err:         result.Err,
 	})
-
-	if result, ok := m.commands[key]; ok {
-		return result.Err
-	}
-
-	if m.defaultError != nil {
-		return fmt.Errorf("%w: %s", m.defaultError, key)
-	}
-	return nil
+	return result.Err
 }
 
+// mockScriptKey is the call key of SudoRunScriptQuiet.
+const mockScriptKey = "sudo sh script"
+
 // CallKeys returns all called command keys for debugging.
 func (m *MockCommandRunner) CallKeys() []string {
 	keys := make([]string, len(m.Calls))
@@ -251,8 +391,7 @@ func (m *MockCommandRunner) CallCount(cmd string) int {
 func (m *MockCommandRunner) AssertCalled(t *testing.T, cmd string) {
 	t.Helper()
 	if !m.Called(cmd) {
-		t.Errorf("expected command to be called: %s", cmd)
-		t.Errorf("actual calls: %v", m.CallKeys())
+		t.Errorf("expected command to be called: %s\n%s", cmd, m.Transcript())
 	}
 }
 
@@ -265,27 +404,96 @@ func (m *MockCommandRunner) AssertNotCalled(t *testing.T, cmd string) {
 }
 
 // AssertAllExpectationsMet fails the test if any expected commands were set up
-// but never called. Call this at the end of each test to ensure no git operations
-// were silently skipped.
+// but never called, or if an InOrder group was called out of order. Call this
+// at the end of each test to ensure no git operations were silently skipped.
+// Failures include the call transcript.
 func (m *MockCommandRunner) AssertAllExpectationsMet(t *testing.T) {
 	t.Helper()
+	if problems := m.unmetExpectations(); len(problems) > 0 {
+		for _, p := range problems {
+			t.Error(p)
+		}
+		t.Log(m.Transcript())
+	}
+}
 
+// unmetExpectations lists expectations that were never called or were
+// called out of order, sorted for stable output.
+func (m *MockCommandRunner) unmetExpectations() []string {
 	called := make(map[string]bool)
 	for _, c := range m.Calls {
 		called[c.Key] = true
 	}
 
+	var problems []string
+
 	// Check commands map: any entry not consumed?
 	for key := range m.commands {
 		if !called[key] {
-			t.Errorf("expected command was set up but never called: %s", key)
+			problems = append(problems, "expected command was set up but never called: "+key)
 		}
 	}
 
 	// Check commandSequences: any remaining entries in queues?
 	for key, seq := range m.commandSequences {
 		if len(seq) > 0 {
-			t.Errorf("expected command sequence has %d unconsumed entries: %s", len(seq), key)
+			problems = append(problems, fmt.Sprintf("expected command sequence has %d unconsumed entries: %s", len(seq), key))
 		}
 	}
+
+	for _, p := range m.patterns {
+		if p.calls == 0 {
+			problems = append(problems, "expected pattern matched no command: "+p.desc)
+		}
+	}
+
+	for _, g := range m.groups {
+		for _, v := range g.violations {
+			problems = append(problems, "ordered expectation violated: "+v)
+		}
+		for _, e := range g.entries {
+			if !e.consumed {
+				problems = append(problems, "ordered expectation never called: "+e.desc)
+			}
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// Transcript renders every recorded call with the expectation it matched
+// and its result, for failure messages.
+func (m *MockCommandRunner) Transcript() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "command transcript (%d calls):", len(m.Calls))
+	for i, c := range m.Calls {
+		fmt.Fprintf(&b, "\n  %d. %s", i+1, c.Key)
+		if c.Dir != "" {
+			fmt.Fprintf(&b, " (in %s)", c.Dir)
+		}
+		switch {
+		case c.Expectation == "":
+			b.WriteString("\n     unexpected")
+		case c.Expectation != c.Key:
+			fmt.Fprintf(&b, "\n     matched %s", c.Expectation)
+		}
+		if c.Err != nil {
+			fmt.Fprintf(&b, "\n     -> error: %v", c.Err)
+		} else if out := strings.TrimSpace(string(c.Output)); out != "" {
+			fmt.Fprintf(&b, "\n     -> %s", strings.ReplaceAll(out, "\n", "\n        "))
+		}
+	}
+	return b.String()
+}
+
+// LogTranscriptOnFailure logs the call transcript when t fails, so a failing
+// test shows every command it ran without asserting on each one.
+func (m *MockCommandRunner) LogTranscriptOnFailure(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Log(m.Transcript())
+		}
+	})
 }
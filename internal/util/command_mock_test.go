@@ -0,0 +1,94 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMockCommandRunner_ExpectMatch(t *testing.T) {
+	m := NewMockCommandRunner()
+	m.ExpectMatch("mutagen sync create * --name alca-*", []byte("created"), nil)
+	m.ExpectRegexp(`^docker rm -f alca-[0-9a-f]{4}$`, nil, nil)
+	ctx := context.Background()
+
+	out, err := m.Run(ctx, "mutagen", "sync", "create", "/src/a b", "docker://x/workspace", "--name", "alca-1234-0")
+	if err != nil || string(out) != "created" {
+		t.Errorf("glob: got %q, %v", out, err)
+	}
+	if _, err := m.Run(ctx, "docker", "rm", "-f", "alca-beef"); err != nil {
+		t.Errorf("regexp: %v", err)
+	}
+	if _, err := m.Run(ctx, "docker", "rm", "-f", "alca-xyz"); err == nil {
+		t.Error("non-matching command should be unexpected")
+	}
+	if problems := m.unmetExpectations(); len(problems) != 0 {
+		t.Errorf("unmet = %v", problems)
+	}
+}
+
+func TestMockCommandRunner_ExactWinsOverPattern(t *testing.T) {
+	m := NewMockCommandRunner()
+	m.ExpectMatch("docker *", nil, errors.New("generic"))
+	m.ExpectSuccess("docker ps", []byte("exact"))
+
+	if out, err := m.Run(context.Background(), "docker", "ps"); err != nil || string(out) != "exact" {
+		t.Errorf("got %q, %v", out, err)
+	}
+}
+
+func TestMockCommandRunner_InOrder(t *testing.T) {
+	ctx := context.Background()
+
+	m := NewMockCommandRunner()
+	m.InOrder().
+		ExpectSuccess("docker stop alca-test", nil).
+		ExpectMatch("docker rm *", nil, nil)
+	_, _ = m.Run(ctx, "docker", "stop", "alca-test")
+	_, _ = m.Run(ctx, "docker", "rm", "-f", "alca-test")
+	if problems := m.unmetExpectations(); len(problems) != 0 {
+		t.Errorf("in order: unmet = %v", problems)
+	}
+
+	m = NewMockCommandRunner()
+	m.InOrder().
+		ExpectSuccess("docker stop alca-test", nil).
+		ExpectMatch("docker rm *", nil, nil)
+	_, _ = m.Run(ctx, "docker", "rm", "-f", "alca-test")
+	_, _ = m.Run(ctx, "docker", "stop", "alca-test")
+	problems := m.unmetExpectations()
+	want := `ordered expectation violated: "docker rm -f alca-test" called before "docker stop alca-test"`
+	if !slices.Equal(problems, []string{want}) {
+		t.Errorf("out of order: unmet = %v", problems)
+	}
+
+	m = NewMockCommandRunner()
+	m.InOrder().ExpectSuccess("docker stop alca-test", nil)
+	if problems := m.unmetExpectations(); !slices.Equal(problems, []string{"ordered expectation never called: docker stop alca-test"}) {
+		t.Errorf("not called: unmet = %v", problems)
+	}
+}
+
+func TestMockCommandRunner_Transcript(t *testing.T) {
+	m := NewMockCommandRunner()
+	m.ExpectSuccess("docker ps", []byte("abc\n"))
+	m.ExpectMatch("docker rm *", nil, errors.New("no such container"))
+	ctx := context.Background()
+	_, _ = m.Run(ctx, "docker", "ps")
+	_, _ = m.Run(ctx, "docker", "rm", "x")
+	_ = m.RunInDir(ctx, nil, "/p", "git", "status")
+
+	got := m.Transcript()
+	for _, want := range []string{
+		"command transcript (3 calls):",
+		"1. docker ps\n     -> abc",
+		"2. docker rm x\n     matched glob docker rm *\n     -> error: no such container",
+		"3. git status (in /p)\n     unexpected\n     -> error: unexpected command: git status",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("transcript missing %q:\n%s", want, got)
+		}
+	}
+}
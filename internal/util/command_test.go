@@ -3,7 +3,11 @@ package util
 import (
 	"bytes"
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/spf13/afero"
 )
 
 func TestRun_StreamsToStdoutAndCaptures(t *testing.T) {
@@ -36,6 +40,48 @@ func TestRun_ErrorPropagation(t *testing.T) {
 	}
 }
 
+func TestStart_DoesNotWait(t *testing.T) {
+	runner := NewCommandRunner()
+	if err := runner.Start(StartOptions{Env: []string{"X=1"}}, "true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := runner.Start(StartOptions{}, "/nonexistent/command"); err == nil {
+		t.Fatal("expected error for a missing command, got nil")
+	}
+}
+
+func TestStart_LogFile(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "out.log")
+	runner := NewCommandRunner()
+	err := runner.Start(StartOptions{Env: []string{"X=1"}, Dir: dir, LogFile: logPath}, "sh", "-c", "sleep 0.1; echo $X; echo err >&2; ls")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The command writes after Start has returned and closed its copy of
+	// the file; ls shows it ran in dir
+	want := "1\nerr\nout.log\n"
+	var got []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if got, _ = afero.ReadFile(afero.NewOsFs(), logPath); string(got) == want {
+			return
+		}
+	}
+	t.Errorf("log = %q, want %q", got, want)
+}
+
+func TestRunAttached_DirAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := afero.WriteFile(afero.NewOsFs(), filepath.Join(dir, "marker"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runner := NewCommandRunner()
+	if err := runner.RunAttached(context.Background(), dir, []string{"X=1"}, "sh", "-c", `test "$X" = 1 && test -f marker`); err != nil {
+		t.Errorf("RunAttached() error = %v, want the command to see dir and X", err)
+	}
+}
+
 func TestRunQuiet_ReturnsFullOutputOnSuccess(t *testing.T) {
 	runner := NewCommandRunner()
 	output, err := runner.RunQuiet(context.Background(), "echo", "hello")
@@ -57,3 +103,17 @@ func TestRunQuiet_ReturnsOutputOnError(t *testing.T) {
 		t.Errorf("expected output to contain 'failure-output', got %q", output)
 	}
 }
+
+func TestRunStream_ForwardsTransformedLines(t *testing.T) {
+	var out bytes.Buffer
+	output, err := NewCommandRunner().RunStream(context.Background(), &out, PrefixLines("  "), "sh", "-c", "echo one; echo two >&2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "  one\n  two\n" {
+		t.Errorf("streamed output = %q", out.String())
+	}
+	if string(output) != "one\ntwo\n" {
+		t.Errorf("captured output = %q, want untransformed", output)
+	}
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnifiedDiff returns a unified diff of two texts compared line by line,
+// with contextLines unchanged lines around each change. Returns "" when the
+// texts are equal. Intended for small inputs such as config files: the
+// line matching is quadratic in the number of lines.
+func UnifiedDiff(oldName, newName, oldText, newText string, contextLines int) string {
+	if oldText == newText {
+		return ""
+	}
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range groupHunks(ops, contextLines) {
+		writeHunk(&b, ops[h[0]:h[1]])
+	}
+	return b.String()
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	kind         byte
+	text         string
+	oldNo, newNo int // 1-based line numbers before the op is applied
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes an edit script from the longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i + 1, j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i], i + 1, j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j], i + 1, j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// groupHunks returns [start, end) op ranges covering every change plus up to
+// contextLines kept lines on each side, merging ranges that touch.
+func groupHunks(ops []diffOp, contextLines int) [][2]int {
+	var hunks [][2]int
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start := max(i-contextLines, 0)
+		end := min(i+contextLines+1, len(ops))
+		if n := len(hunks); n > 0 && start <= hunks[n-1][1] {
+			hunks[n-1][1] = end
+			continue
+		}
+		hunks = append(hunks, [2]int{start, end})
+	}
+	return hunks
+}
+
+func writeHunk(b *strings.Builder, ops []diffOp) {
+	var oldCount, newCount int
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(ops[0].oldNo, oldCount), hunkRange(ops[0].newNo, newCount))
+	for _, op := range ops {
+		b.WriteByte(op.kind)
+		b.WriteString(op.text)
+		b.WriteByte('\n')
+	}
+}
+
+// hunkRange formats a hunk range; an empty range refers to the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,55 @@
+package util
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		context  int
+		want     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name:    "changed line with context",
+			old:     "a\nb\nc\nd\ne\n",
+			new:     "a\nb\nC\nd\ne\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n",
+		},
+		{
+			name:    "separate hunks",
+			old:     "1\n2\n3\n4\n5\n6\n7\n",
+			new:     "x\n2\n3\n4\n5\n6\ny\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n-1\n+x\n 2\n@@ -6,2 +6,2 @@\n 6\n-7\n+y\n",
+		},
+		{
+			name:    "added to empty",
+			old:     "",
+			new:     "a\n",
+			context: 3,
+			want:    "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name:    "removed line",
+			old:     "a\nb\nc\n",
+			new:     "a\nc\n",
+			context: 0,
+			want:    "--- old\n+++ new\n@@ -2,1 +1,0 @@\n-b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UnifiedDiff("old", "new", tt.old, tt.new, tt.context)
+			if got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
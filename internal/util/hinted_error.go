@@ -0,0 +1,103 @@
+package util
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorCode identifies a class of failure the user can fix. Codes are
+// stable: scripts match on them and docs/errors.md has a section for each.
+type ErrorCode string
+
+const (
+	// CodeRuntimeUnavailable: no container runtime answered (not installed,
+	// or its daemon or VM is not running).
+	CodeRuntimeUnavailable ErrorCode = "runtime-unavailable"
+	// CodeImagePullAuth: the registry refused to serve the image.
+	CodeImagePullAuth ErrorCode = "image-pull-auth"
+	// CodeImagePullRateLimit: the registry refused more pulls for now.
+	CodeImagePullRateLimit ErrorCode = "image-pull-rate-limit"
+	// CodeImagePullInterrupted: the network failed mid-pull, even after retries.
+	CodeImagePullInterrupted ErrorCode = "image-pull-interrupted"
+	// CodeMutagenMissing: Mutagen is needed but not installed.
+	CodeMutagenMissing ErrorCode = "mutagen-missing"
+	// CodeMutagenOutdated: the installed Mutagen has a known sync bug.
+	CodeMutagenOutdated ErrorCode = "mutagen-outdated"
+	// CodeSudoRequired: a step that needs root could not get it.
+	CodeSudoRequired ErrorCode = "sudo-required"
+)
+
+// ErrorDocsURL is the page documenting every ErrorCode.
+const ErrorDocsURL = "https://github.com/bolasblack/alcatraz/blob/master/docs/errors.md"
+
+// HintedError is a failure with a stable code and a hint on how to fix it.
+// Wrapping it with fmt.Errorf("...: %w") keeps it reachable by errors.As,
+// which is how the CLI finds it to print the hint.
+type HintedError struct {
+	Code        ErrorCode
+	Message     string
+	Remediation string
+	Err         error // underlying cause, may be nil
+}
+
+// NewHintedError returns a HintedError wrapping err.
+func NewHintedError(code ErrorCode, message, remediation string, err error) *HintedError {
+	return &HintedError{Code: code, Message: message, Remediation: remediation, Err: err}
+}
+
+func (e *HintedError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *HintedError) Unwrap() error { return e.Err }
+
+// Is matches any HintedError with the same code, so callers can test
+// errors.Is(err, &HintedError{Code: CodeSudoRequired}).
+func (e *HintedError) Is(target error) bool {
+	t, ok := target.(*HintedError)
+	return ok && t.Code == e.Code
+}
+
+// DocsURL returns the section of ErrorDocsURL for the error's code.
+func (e *HintedError) DocsURL() string {
+	return ErrorDocsURL + "#" + string(e.Code)
+}
+
+// AsHintedError returns the first HintedError in err's chain.
+func AsHintedError(err error) (*HintedError, bool) {
+	var h *HintedError
+	ok := errors.As(err, &h)
+	return h, ok
+}
+
+// sudoAuthFailures are what sudo prints when it cannot ask for or accept
+// a password.
+var sudoAuthFailures = []string{
+	"a password is required",
+	"a terminal is required",
+	"incorrect password attempt",
+	"is not in the sudoers file",
+	"not allowed to execute",
+}
+
+// SudoError wraps err in a CodeSudoRequired HintedError when output shows
+// sudo itself failed rather than the command it ran. Returns err unchanged
+// otherwise.
+func SudoError(what string, output []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, s := range sudoAuthFailures {
+		if strings.Contains(string(output), s) {
+			return NewHintedError(CodeSudoRequired, what+" needs sudo", SudoRemediation, err)
+		}
+	}
+	return err
+}
+
+// SudoRemediation is the hint for CodeSudoRequired.
+const SudoRemediation = "Run the command again from a terminal where sudo can ask for your password, " +
+	"or run 'sudo -v' first to cache your credentials."
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHintedError_Chain(t *testing.T) {
+	cause := errors.New("exit status 1")
+	err := fmt.Errorf("failed to start container: %w",
+		NewHintedError(CodeRuntimeUnavailable, "Docker not available", "Start Docker.", cause))
+
+	if got, want := err.Error(), "failed to start container: Docker not available: exit status 1"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("cause not reachable with errors.Is")
+	}
+	if !errors.Is(err, &HintedError{Code: CodeRuntimeUnavailable}) {
+		t.Error("code not matched with errors.Is")
+	}
+	if errors.Is(err, &HintedError{Code: CodeSudoRequired}) {
+		t.Error("other code matched")
+	}
+	h, ok := AsHintedError(err)
+	if !ok || h.DocsURL() != ErrorDocsURL+"#runtime-unavailable" {
+		t.Errorf("AsHintedError() = %v, %v", h, ok)
+	}
+}
+
+func TestSudoError(t *testing.T) {
+	cause := errors.New("exit status 1")
+	tests := []struct {
+		name     string
+		output   string
+		wantHint bool
+	}{
+		{"no tty", "sudo: a terminal is required to read the password; either use the -S option to read from standard input or configure an askpass helper\n", true},
+		{"no password", "sudo: a password is required\n", true},
+		{"command failed", "Error: Could not process rule: No such file or directory\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SudoError("loading firewall rules", []byte(tt.output), cause)
+			_, ok := AsHintedError(err)
+			if ok != tt.wantHint {
+				t.Errorf("SudoError() = %v, want hint %v", err, tt.wantHint)
+			}
+			if !errors.Is(err, cause) {
+				t.Error("cause lost")
+			}
+		})
+	}
+	if SudoError("x", nil, nil) != nil {
+		t.Error("SudoError(nil) != nil")
+	}
+}
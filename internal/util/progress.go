@@ -6,10 +6,11 @@ import (
 	"io"
 )
 
-// Progress writes a progress message if not in quiet mode.
+// Progress writes a progress message if not in quiet mode, with registered
+// secrets masked (see RegisterSecret).
 func Progress(w io.Writer, format string, args ...any) {
 	if w != nil {
-		_, _ = fmt.Fprintf(w, format, args...)
+		_, _ = io.WriteString(w, Redact(fmt.Sprintf(format, args...)))
 	}
 }
 
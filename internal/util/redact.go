@@ -0,0 +1,90 @@
+package util
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces secret values in output.
+const RedactedValue = "********"
+
+// minSecretLen keeps trivial values such as "1" or "on" from being masked
+// everywhere they appear.
+const minSecretLen = 4
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// RegisterSecret marks values as sensitive for the rest of the process:
+// Redact and writers from NewRedactingWriter mask them from then on. Values
+// shorter than four characters are ignored.
+func RegisterSecret(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, v := range values {
+		if len(v) < minSecretLen || slices.Contains(secrets, v) {
+			continue
+		}
+		secrets = append(secrets, v)
+	}
+	// Longest first, so a secret containing another is masked whole
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+}
+
+// ResetSecrets forgets all registered secrets. For tests.
+func ResetSecrets() {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = nil
+}
+
+// Redact masks every registered secret in s.
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, v := range secrets {
+		s = strings.ReplaceAll(s, v, RedactedValue)
+	}
+	return s
+}
+
+// RedactAll returns a copy of values with Redact applied to each.
+func RedactAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = Redact(v)
+	}
+	return out
+}
+
+// redactingWriter applies Redact to each write.
+type redactingWriter struct {
+	w io.Writer
+}
+
+// NewRedactingWriter returns a writer that masks registered secrets before
+// writing to w. Redaction is per write call, so a secret split across two
+// writes is not caught; alca's output is written a line or more at a time.
+// A nil w returns nil, keeping quiet-mode writers quiet.
+func NewRedactingWriter(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	if _, ok := w.(redactingWriter); ok {
+		return w
+	}
+	return redactingWriter{w: w}
+}
+
+// Write reports len(p) on success, since callers count input bytes, not the
+// masked output.
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
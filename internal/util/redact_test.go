@@ -0,0 +1,54 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	ResetSecrets()
+	t.Cleanup(ResetSecrets)
+	RegisterSecret("ghp_abcdef123456", "ghp_abcdef123456-extra", "on")
+
+	tests := map[string]string{
+		"token=ghp_abcdef123456":                      "token=" + RedactedValue,
+		"ghp_abcdef123456-extra and ghp_abcdef123456": RedactedValue + " and " + RedactedValue,
+		"turn it on": "turn it on",
+	}
+	for in, want := range tests {
+		if got := Redact(in); got != want {
+			t.Errorf("Redact(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRedactingWriter(t *testing.T) {
+	ResetSecrets()
+	t.Cleanup(ResetSecrets)
+	RegisterSecret("s3cr3t-value")
+
+	var buf bytes.Buffer
+	w := NewRedactingWriter(&buf)
+	n, err := w.Write([]byte("-e API_KEY=s3cr3t-value\n"))
+	if err != nil || n != len("-e API_KEY=s3cr3t-value\n") {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if got := buf.String(); got != "-e API_KEY="+RedactedValue+"\n" {
+		t.Errorf("output = %q", got)
+	}
+	if NewRedactingWriter(nil) != nil {
+		t.Error("a nil writer should stay nil")
+	}
+}
+
+func TestProgressRedacts(t *testing.T) {
+	ResetSecrets()
+	t.Cleanup(ResetSecrets)
+	RegisterSecret("s3cr3t-value")
+
+	var buf bytes.Buffer
+	ProgressStep(&buf, "Running %s\n", "login s3cr3t-value")
+	if got := buf.String(); got != "→ Running login "+RedactedValue+"\n" {
+		t.Errorf("output = %q", got)
+	}
+}
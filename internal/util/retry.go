@@ -0,0 +1,109 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff configures Retry. The zero value retries immediately until the
+// context is done, so callers should set MaxAttempts or MaxElapsed.
+type Backoff struct {
+	// Initial is the delay before the second attempt.
+	Initial time.Duration
+	// Max caps each delay (0 = no cap).
+	Max time.Duration
+	// Multiplier grows the delay after each attempt (values below 1 mean 2).
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in either
+	// direction (0.2 = ±20%), so concurrent callers do not retry in lockstep.
+	Jitter float64
+	// MaxAttempts stops after this many attempts (0 = unlimited).
+	MaxAttempts int
+	// MaxElapsed stops once this much time has passed since the first
+	// attempt; no attempt starts after it (0 = unlimited).
+	MaxElapsed time.Duration
+}
+
+// permanentError stops Retry without further attempts.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that Retry returns it immediately instead of
+// retrying. Retry returns err itself, not the wrapper.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// Retry calls op until it succeeds, returns a Permanent error, the attempts
+// or elapsed time are exhausted, or ctx is done. attempt counts from 0.
+// Returns the last error from op, or ctx.Err() if cancelled while waiting.
+func Retry(ctx context.Context, b Backoff, op func(attempt int) error) error {
+	start := time.Now()
+	delay := b.Initial
+	for attempt := 0; ; attempt++ {
+		err := op(attempt)
+		if err == nil {
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if b.MaxAttempts > 0 && attempt+1 >= b.MaxAttempts {
+			return err
+		}
+
+		wait := b.jittered(delay)
+		if b.MaxElapsed > 0 && time.Since(start)+wait > b.MaxElapsed {
+			return err
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+		delay = b.next(delay)
+	}
+}
+
+// next returns the delay following d.
+func (b Backoff) next(d time.Duration) time.Duration {
+	m := b.Multiplier
+	if m < 1 {
+		m = 2
+	}
+	d = time.Duration(float64(d) * m)
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// jittered randomizes d by up to ±Jitter.
+func (b Backoff) jittered(d time.Duration) time.Duration {
+	if b.Jitter <= 0 || d <= 0 {
+		return d
+	}
+	spread := float64(d) * b.Jitter
+	return time.Duration(float64(d) - spread + rand.Float64()*2*spread)
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+func TestRetry_SucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), Backoff{MaxAttempts: 5}, func(attempt int) error {
+		if attempt != calls {
+			t.Errorf("attempt = %d, want %d", attempt, calls)
+		}
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetry_MaxAttempts(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), Backoff{MaxAttempts: 3}, func(int) error {
+		calls++
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) {
+		t.Errorf("Retry() error = %v, want errTransient", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetry_Permanent(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), Backoff{MaxAttempts: 5}, func(int) error {
+		calls++
+		return Permanent(errTransient)
+	})
+	if err != errTransient {
+		t.Errorf("Retry() error = %v, want the unwrapped error", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetry_MaxElapsed(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), Backoff{Initial: time.Hour, MaxElapsed: time.Minute}, func(int) error {
+		calls++
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) {
+		t.Errorf("Retry() error = %v, want errTransient", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (next delay exceeds MaxElapsed)", calls)
+	}
+}
+
+func TestRetry_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Retry(ctx, Backoff{Initial: time.Hour}, func(int) error {
+		calls++
+		cancel()
+		return errTransient
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Retry() error = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestBackoff_Delays(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second}
+	var got []time.Duration
+	for d := b.Initial; len(got) < 5; d = b.next(d) {
+		got = append(got, d)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("delays = %v, want %v", got, want)
+		}
+	}
+
+	b.Jitter = 0.2
+	for range 100 {
+		if d := b.jittered(10 * time.Second); d < 8*time.Second || d > 12*time.Second {
+			t.Fatalf("jittered(10s) = %v, want within ±20%%", d)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// RotatingWriter appends to a file and rotates it once it reaches a size
+// limit: path becomes path.1, path.1 becomes path.2 and so on, keeping at
+// most Keep rotated files.
+type RotatingWriter struct {
+	fs      afero.Fs
+	path    string
+	maxSize int64
+	keep    int
+	file    afero.File
+	size    int64
+}
+
+// NewRotatingWriter opens path for appending, creating it and its directory
+// if needed. maxSize 0 disables rotation.
+func NewRotatingWriter(fs afero.Fs, path string, maxSize int64, keep int) (*RotatingWriter, error) {
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	w := &RotatingWriter{fs: fs, path: path, maxSize: maxSize, keep: keep}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open opens the current file for appending and records its size.
+func (w *RotatingWriter) open() error {
+	f, err := w.fs.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", w.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat %s: %w", w.path, err)
+	}
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would take the file past the limit.
+// A write larger than the limit goes whole into a fresh file.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the rotated files up by one and starts a new file.
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", w.path, err)
+	}
+	if w.keep > 0 {
+		_ = w.fs.Remove(fmt.Sprintf("%s.%d", w.path, w.keep))
+		for i := w.keep - 1; i >= 1; i-- {
+			_ = w.fs.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+		}
+		if err := w.fs.Rename(w.path, w.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate %s: %w", w.path, err)
+		}
+	} else if err := w.fs.Remove(w.path); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", w.path, err)
+	}
+	return w.open()
+}
+
+// Close closes the current file.
+func (w *RotatingWriter) Close() error {
+	return w.file.Close()
+}
+
+// sizePattern matches sizes such as 512, 10MB, 1.5G or 64KiB.
+var sizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?) ?([kmgt]?)(?:i?b)?$`)
+
+// ParseSize parses a byte size such as 512, 10MB, 1.5G or 64KiB. Units are
+// powers of 1024, as in docker's --memory.
+func ParseSize(s string) (int64, error) {
+	m := sizePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q: expected a number with an optional unit, e.g. 10MB", s)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	shift := strings.Index("kmgt", m[2]) + 1
+	if m[2] == "" {
+		shift = 0
+	}
+	return int64(n * float64(int64(1)<<(10*shift))), nil
+}
+
+// FormatSize formats a byte count the way ParseSize reads it, e.g. 1.5GB.
+func FormatSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	f := float64(n)
+	unit := -1
+	for f >= 1024 && unit < 3 {
+		f /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f%cB", f, "KMGT"[unit])
+}
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/p/.alca/logs/container.log"
+	w, err := NewRotatingWriter(fs, path, 10, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingWriter() error: %v", err)
+	}
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n", "ffffffffffff\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) error: %v", line, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	// Three rotations: the oldest file (aaaa, bbbb) was dropped
+	for name, want := range map[string]string{
+		path:        "ffffffffffff\n",
+		path + ".1": "eeee\n",
+		path + ".2": "cccc\ndddd\n",
+	} {
+		got, err := afero.ReadFile(fs, name)
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if ok, _ := afero.Exists(fs, path+".3"); ok {
+		t.Error("only 2 rotated files should be kept")
+	}
+
+	// Reopening appends and counts the existing size
+	w, err = NewRotatingWriter(fs, path, 20, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingWriter() error: %v", err)
+	}
+	_, _ = w.Write([]byte("gggggggg\n"))
+	_ = w.Close()
+	if got, _ := afero.ReadFile(fs, path+".1"); string(got) != "ffffffffffff\n" {
+		t.Errorf("after reopen %s.1 = %q", path, got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"512":    512,
+		"10MB":   10 << 20,
+		"10m":    10 << 20,
+		"1.5G":   3 << 29,
+		"64KiB":  64 << 10,
+		"2 tb":   2 << 40,
+		"100b":   100,
+		" 1 KB ": 1 << 10,
+	} {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "-1MB", "10XB", "ten"} {
+		if _, err := ParseSize(in); err == nil || !strings.Contains(err.Error(), "invalid size") {
+			t.Errorf("ParseSize(%q) error = %v, want invalid size", in, err)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:         "0B",
+		1023:      "1023B",
+		1 << 10:   "1.0KB",
+		3 << 29:   "1.5GB",
+		5 << 40:   "5.0TB",
+		300 << 20: "300.0MB",
+	} {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// LineFunc transforms one line of streamed command output (without its
+// trailing newline). Returning false drops the line.
+type LineFunc func(line string) (string, bool)
+
+// PrefixLines returns a LineFunc that prepends prefix to every line.
+func PrefixLines(prefix string) LineFunc {
+	return func(line string) (string, bool) {
+		return prefix + line, true
+	}
+}
+
+// lineWriter forwards complete lines to out, passing each through
+// transform. Partial lines are held until their newline arrives or Flush.
+// Carriage-return progress updates (used by pull progress bars) end a line
+// too, so each update is forwarded instead of piling up. Blank lines are
+// dropped, and registered secrets are masked (see RegisterSecret).
+type lineWriter struct {
+	out       io.Writer
+	transform LineFunc
+	pending   []byte
+}
+
+// newLineWriter returns a lineWriter writing to out (nil discards).
+// A nil transform forwards lines unchanged.
+func newLineWriter(out io.Writer, transform LineFunc) *lineWriter {
+	if out == nil {
+		out = io.Discard
+	}
+	return &lineWriter{out: out, transform: transform}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexAny(w.pending, "\r\n")
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(w.pending[:i])
+		w.pending = w.pending[i+1:]
+		if err := w.emit(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush forwards a trailing line that had no newline.
+func (w *lineWriter) Flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	line := string(w.pending)
+	w.pending = nil
+	return w.emit(line)
+}
+
+func (w *lineWriter) emit(line string) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	line = Redact(line)
+	if w.transform != nil {
+		var keep bool
+		if line, keep = w.transform(line); !keep {
+			return nil
+		}
+	}
+	_, err := io.WriteString(w.out, line+"\n")
+	return err
+}
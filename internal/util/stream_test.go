@@ -0,0 +1,41 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	var out bytes.Buffer
+	dropDebug := func(line string) (string, bool) {
+		if strings.HasPrefix(line, "debug") {
+			return "", false
+		}
+		return "> " + line, true
+	}
+	w := newLineWriter(&out, dropDebug)
+
+	// Lines split across writes, CRLF, progress updates and a trailing partial line
+	for _, chunk := range []string{"pul", "ling\r\n", "debug: x\n\n", "10%\r50%\r", "done"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if got := out.String(); got != "> pulling\n> 10%\n> 50%\n" {
+		t.Errorf("before Flush: got %q", got)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if got := out.String(); got != "> pulling\n> 10%\n> 50%\n> done\n" {
+		t.Errorf("after Flush: got %q", got)
+	}
+}
+
+func TestLineWriter_NilOutDiscards(t *testing.T) {
+	w := newLineWriter(nil, PrefixLines("  "))
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Errorf("Write() error: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithTimeout returns ctx bounded by d, or ctx unchanged (with a no-op
+// cancel) when d is 0, which means no limit.
+func WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// TimeoutError replaces err with a "timed out" error when ctx hit its
+// deadline, since a command killed by the deadline otherwise only reports
+// "signal: killed". Returns err unchanged in every other case.
+func TimeoutError(ctx context.Context, what string, d time.Duration, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%s timed out after %s: %w", what, d, context.DeadlineExceeded)
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout_ZeroMeansNoLimit(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("WithTimeout(0) set a deadline")
+	}
+
+	ctx, cancel = WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("WithTimeout(1m) did not set a deadline")
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	killed := errors.New("signal: killed")
+
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+
+	err := TimeoutError(expired, "pull", time.Minute, killed)
+	if !errors.Is(err, context.DeadlineExceeded) || err.Error() != "pull timed out after 1m0s: context deadline exceeded" {
+		t.Errorf("TimeoutError() = %v", err)
+	}
+
+	if err := TimeoutError(context.Background(), "pull", time.Minute, killed); err != killed {
+		t.Errorf("without deadline: got %v, want the original error", err)
+	}
+	if err := TimeoutError(expired, "pull", time.Minute, nil); err != nil {
+		t.Errorf("nil error: got %v", err)
+	}
+}
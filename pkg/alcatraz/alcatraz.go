@@ -0,0 +1,344 @@
+// Package alcatraz manages Alcatraz sandboxes from Go programs (editor
+// integrations, task runners, CI tools) without shelling out to alca.
+//
+// A Project is a directory with an .alca.toml. Its methods do what the
+// matching alca commands do, through the same code: Up and Down create and
+// remove the container with its network, firewall and sync, Run and Enter
+// execute in it, and Status reports on it. They never prompt: a change that
+// needs confirmation fails unless UpOptions.AssumeYes is set.
+//
+//	p, err := alcatraz.Open(".")
+//	if err != nil {
+//		return err
+//	}
+//	if err := p.Up(ctx, alcatraz.UpOptions{Out: os.Stderr}); err != nil {
+//		return err
+//	}
+//	return p.Run(ctx, []string{"make", "test"}, alcatraz.RunOptions{})
+//
+// The runtime (Docker or Podman) and, where configured, Mutagen are run
+// through the project's CommandRunner, by default on the host, so they must
+// be installed there. WithFs and WithCommandRunner replace the host
+// filesystem and command runner, for tests and for callers that sandbox
+// alcatraz itself.
+package alcatraz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/config"
+	"github.com/bolasblack/alcatraz/internal/sandbox"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// ConfigFilename is the name of the project config file.
+const ConfigFilename = sandbox.ConfigFilename
+
+// ErrNotInitialized is returned by Open when no directory at or above the
+// given one has a ConfigFilename.
+var ErrNotInitialized = errors.New("not an alcatraz project (no " + ConfigFilename + " found)")
+
+// Config is the part of a project's loaded configuration that callers
+// usually need. The .alca.toml reference documents each setting.
+type Config struct {
+	// Image is the container image.
+	Image string
+	// Platform is the image platform, e.g. linux/amd64; empty for the host's.
+	Platform string
+	// Runtime is the configured runtime: auto, docker or podman.
+	Runtime string
+	// Workdir is the project directory inside the container.
+	Workdir string
+	// User is the user commands run as; empty for the image's.
+	User string
+	// ContainerName is the configured container name; empty for the
+	// generated one.
+	ContainerName string
+	// Mounts are the extra host directories mounted into the container.
+	Mounts []Mount
+}
+
+// Mount is a host directory mounted into the container.
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// ContainerState is the state of a project's container.
+type ContainerState string
+
+// Container states.
+const (
+	StateUnknown  ContainerState = "unknown"
+	StateRunning  ContainerState = "running"
+	StateStopped  ContainerState = "stopped"
+	StatePaused   ContainerState = "paused"
+	StateNotFound ContainerState = "not_found"
+)
+
+// ContainerStatus describes a project's container.
+type ContainerStatus struct {
+	State     ContainerState
+	ID        string
+	Name      string
+	Image     string
+	StartedAt string
+}
+
+// PullPolicy says when Project.Up pulls the image of a container it creates.
+type PullPolicy string
+
+// Pull policies.
+const (
+	// PullMissing pulls the image only when it is not present locally.
+	PullMissing PullPolicy = "missing"
+	// PullAlways pulls the image before every container creation.
+	PullAlways PullPolicy = "always"
+	// PullNever fails to create a container whose image is not present.
+	PullNever PullPolicy = "never"
+)
+
+// UpOptions configures Project.Up. The zero value is a plain 'alca up'
+// that prints nothing.
+type UpOptions struct {
+	// Force rebuilds the container on config changes without confirmation.
+	Force bool
+	// Resume skips the steps an interrupted Up completed.
+	Resume bool
+	// Set overlays config values for this run (key=value or key+=value).
+	Set []string
+	// SaveSet also writes the Set values to the local config file.
+	SaveSet bool
+	// Idempotent does nothing when the container already runs with the
+	// current config.
+	Idempotent bool
+	// PullPolicy says when to pull the image; empty means PullMissing.
+	PullPolicy PullPolicy
+	// AssumeYes answers yes to every confirmation.
+	AssumeYes bool
+	// Out receives progress output; nil discards it.
+	Out io.Writer
+}
+
+// DownOptions configures Project.Down.
+type DownOptions struct {
+	// Force proceeds despite unresolved sync conflicts.
+	Force bool
+	// Out receives progress output; nil discards it.
+	Out io.Writer
+}
+
+// RunOptions configures Project.Run and Project.Enter.
+type RunOptions struct {
+	// User runs the command as this user (name or uid[:gid]) instead of
+	// the configured one.
+	User string
+	// Dir is a host directory in the project; the command starts in the
+	// matching container directory. Empty starts it in the workdir.
+	Dir string
+	// AutoUp runs Up first when the container is stopped or gone, instead
+	// of failing.
+	AutoUp bool
+	// Out receives the output of that Up; nil discards it.
+	Out io.Writer
+}
+
+// EnterOptions configures Project.Enter.
+type EnterOptions struct {
+	RunOptions
+	// Shell is the shell to start; "sh" when empty.
+	Shell string
+}
+
+// Status is the status of a project.
+type Status struct {
+	// Initialized reports whether the project's config file exists.
+	Initialized bool
+	// Runtime is the name of the runtime the project uses.
+	Runtime string
+	// ProjectID identifies the project; empty before the first Up.
+	ProjectID string
+	// Container describes the project's container; its State is
+	// StateNotFound before the first Up and after Down.
+	Container ContainerStatus
+}
+
+// Option configures Open.
+type Option func(*util.Env)
+
+// WithFs makes the project read and write its config and state on fs
+// instead of the host filesystem.
+func WithFs(fs afero.Fs) Option {
+	return func(env *util.Env) { env.Fs = fs }
+}
+
+// WithCommandRunner makes the project run the runtime, Mutagen and other
+// host commands through r.
+func WithCommandRunner(r CommandRunner) Option {
+	return func(env *util.Env) { env.Cmd = commandRunnerAdapter{r} }
+}
+
+// Project is an Alcatraz project.
+type Project struct {
+	dir  string
+	host *util.Env
+}
+
+// Open returns the project dir belongs to: like alca, it uses the nearest
+// directory at or above dir holding a ConfigFilename.
+func Open(dir string, opts ...Option) (*Project, error) {
+	host := &util.Env{Fs: afero.NewOsFs(), Cmd: util.NewCommandRunner()}
+	for _, opt := range opts {
+		opt(host)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	projectDir, ok := sandbox.FindProjectDir(host.Fs, abs)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", abs, ErrNotInitialized)
+	}
+	return &Project{dir: projectDir, host: host}, nil
+}
+
+// Dir returns the project directory.
+func (p *Project) Dir() string {
+	return p.dir
+}
+
+// Config loads the project's configuration, with extends, includes, the
+// local config and a monorepo root applied. ctx bounds the credential store
+// lookup that decrypts an encrypted config.
+func (p *Project) Config(ctx context.Context) (*Config, error) {
+	cfg, err := sandbox.LoadConfig(ctx, p.host, p.dir)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(cfg), nil
+}
+
+// Up creates or updates the project's container to match its config, like
+// 'alca up'. Cancelling ctx rolls back the step in flight.
+func (p *Project) Up(ctx context.Context, opts UpOptions) error {
+	if err := sandbox.CheckVersionSkew(p.host.Fs, p.dir, writerOrDiscard(opts.Out)); err != nil {
+		return err
+	}
+	return sandbox.Up(ctx, p.host, p.dir, sandbox.UpOptions{
+		Force:      opts.Force,
+		Resume:     opts.Resume,
+		Set:        opts.Set,
+		SaveSet:    opts.SaveSet,
+		Idempotent: opts.Idempotent,
+		PullPolicy: sandbox.PullPolicy(opts.PullPolicy),
+		AssumeYes:  opts.AssumeYes,
+		Out:        opts.Out,
+	})
+}
+
+// Down stops and removes the project's container, like 'alca down'.
+func (p *Project) Down(ctx context.Context, opts DownOptions) error {
+	if err := sandbox.CheckVersionSkew(p.host.Fs, p.dir, writerOrDiscard(opts.Out)); err != nil {
+		return err
+	}
+	return sandbox.Down(ctx, p.host, p.dir, sandbox.DownOptions{Force: opts.Force, Out: opts.Out})
+}
+
+// Run runs args in the project's container, like 'alca run', with the
+// calling process's standard streams. A command that exits non-zero
+// returns an *exec.ExitError carrying its exit code.
+func (p *Project) Run(ctx context.Context, args []string, opts RunOptions) error {
+	if len(args) == 0 {
+		return errors.New("no command to run")
+	}
+	return sandbox.Exec(ctx, p.host, p.dir, args, p.execOptions(opts))
+}
+
+// Enter opens an interactive shell in the project's container, like 'alca
+// enter', on the calling process's terminal.
+func (p *Project) Enter(ctx context.Context, opts EnterOptions) error {
+	shell := opts.Shell
+	if shell == "" {
+		shell = "sh"
+	}
+	return sandbox.Enter(ctx, p.host, p.dir, shell, p.execOptions(opts.RunOptions))
+}
+
+// execOptions translates opts for the sandbox, bringing the container up
+// with default UpOptions when AutoUp is set.
+func (p *Project) execOptions(opts RunOptions) sandbox.ExecOptions {
+	return sandbox.ExecOptions{
+		User: opts.User,
+		Dir:  opts.Dir,
+		Recovery: &sandbox.Recovery{
+			AutoUp: opts.AutoUp,
+			Prompt: sandbox.PromptMode{NonInteractive: true},
+			Up: func(ctx context.Context, cwd string) error {
+				return sandbox.Up(ctx, p.host, cwd, sandbox.UpOptions{Out: opts.Out})
+			},
+			Out: opts.Out,
+		},
+	}
+}
+
+// Status reports on the project, like 'alca status'. Failing to select a
+// runtime or to read the state or the container is an error.
+func (p *Project) Status(ctx context.Context) (*Status, error) {
+	ps, err := sandbox.Status(ctx, p.host, p.dir, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	s := &Status{Initialized: ps.Initialized, Runtime: ps.Runtime}
+	if !ps.Initialized {
+		return s, nil
+	}
+	for _, err := range []error{ps.RuntimeErr, ps.StateErr, ps.ContainerErr} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ps.State == nil {
+		s.Container.State = StateNotFound
+		return s, nil
+	}
+	s.ProjectID = ps.State.ProjectID
+	s.Container = ContainerStatus{
+		State:     ContainerState(ps.Container.State),
+		ID:        ps.Container.ID,
+		Name:      ps.Container.Name,
+		Image:     ps.Container.Image,
+		StartedAt: ps.Container.StartedAt,
+	}
+	return s, nil
+}
+
+// newConfig copies the public part of cfg.
+func newConfig(cfg *config.Config) *Config {
+	c := &Config{
+		Image:         cfg.Image,
+		Platform:      cfg.Platform,
+		Runtime:       string(cfg.Runtime),
+		Workdir:       cfg.Workdir,
+		User:          cfg.User,
+		ContainerName: cfg.ContainerName,
+	}
+	for _, m := range cfg.Mounts {
+		c.Mounts = append(c.Mounts, Mount{Source: m.Source, Target: m.Target, ReadOnly: m.Readonly})
+	}
+	return c
+}
+
+// writerOrDiscard returns w, or io.Discard when w is nil.
+func writerOrDiscard(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
+	}
+	return w
+}
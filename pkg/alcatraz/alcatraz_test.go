@@ -0,0 +1,167 @@
+package alcatraz
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/bolasblack/alcatraz/internal/runtime"
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// fakeProjectConfig allows all LAN traffic so no firewall work is needed.
+const fakeProjectConfig = `image = "alpine:3"
+
+[commands]
+up = "apk add git"
+
+[network]
+lan-access = ["*"]
+`
+
+// openFakeProject opens a project in memory on the fake runtime, with a
+// mock command runner that fails every host command.
+func openFakeProject(t *testing.T) (*Project, *runtime.Fake) {
+	t.Helper()
+	t.Setenv(runtime.EnvFakeRuntime, "1")
+	fake := runtime.ResetSharedFake()
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, filepath.Join("/project", ConfigFilename), []byte(fakeProjectConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mock := util.NewMockCommandRunner()
+	mock.LogTranscriptOnFailure(t)
+
+	p, err := Open("/project", WithFs(fs), WithCommandRunner(hostCommandRunner{mock}))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return p, fake
+}
+
+func TestOpen_FindsProjectFromSubdirectory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, filepath.Join("/root", ConfigFilename), []byte("image = \"alpine:3\"\nworkdir = \"/workspace\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join("/root", "src", "pkg")
+	if err := fs.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Open(sub, WithFs(fs))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if p.Dir() != "/root" {
+		t.Errorf("Dir() = %q, want /root", p.Dir())
+	}
+
+	cfg, err := p.Config(context.Background())
+	if err != nil {
+		t.Fatalf("Config: %v", err)
+	}
+	if cfg.Image != "alpine:3" || cfg.Workdir != "/workspace" {
+		t.Errorf("Config() = image %q workdir %q", cfg.Image, cfg.Workdir)
+	}
+}
+
+func TestOpen_NotInitialized(t *testing.T) {
+	_, err := Open("/", WithFs(afero.NewMemMapFs()))
+	if !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("Open error = %v, want ErrNotInitialized", err)
+	}
+}
+
+func TestProject_UpRunStatusDown(t *testing.T) {
+	p, fake := openFakeProject(t)
+	ctx := context.Background()
+
+	s, err := p.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status before up: %v", err)
+	}
+	if !s.Initialized || s.ProjectID != "" || s.Container.State != StateNotFound {
+		t.Errorf("Status before up = %+v", s)
+	}
+
+	if err := p.Up(ctx, UpOptions{}); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	s, err = p.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if s.Runtime != runtime.FakeRuntimeName || s.ProjectID == "" || s.Container.State != StateRunning || s.Container.Image != "alpine:3" {
+		t.Fatalf("Status after up = %+v", s)
+	}
+
+	if err := p.Run(ctx, []string{"go", "test"}, RunOptions{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := [][]string{{"sh", "-c", "apk add git"}, {"go", "test"}}
+	if c := fake.Container(s.Container.Name); !slices.EqualFunc(c.Execs, want, slices.Equal) {
+		t.Errorf("execs = %q, want %q", c.Execs, want)
+	}
+
+	if err := p.Down(ctx, DownOptions{}); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if fake.Container(s.Container.Name) != nil {
+		t.Error("container still exists after Down")
+	}
+	s, err = p.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status after down: %v", err)
+	}
+	if s.Container.State != StateNotFound {
+		t.Errorf("Status after down = %+v", s)
+	}
+}
+
+func TestProject_RunAutoUp(t *testing.T) {
+	p, fake := openFakeProject(t)
+	ctx := context.Background()
+
+	if err := p.Up(ctx, UpOptions{}); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := p.Down(ctx, DownOptions{}); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if err := p.Run(ctx, []string{"true"}, RunOptions{}); err == nil {
+		t.Fatal("Run after Down should fail")
+	}
+	if err := p.Run(ctx, []string{"true"}, RunOptions{AutoUp: true}); err != nil {
+		t.Fatalf("Run with AutoUp: %v", err)
+	}
+	s, err := p.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := fake.Container(s.Container.Name); c == nil || c.State != runtime.StateRunning {
+		t.Errorf("container after Run with AutoUp = %+v", c)
+	}
+}
+
+func TestContainerStatesMatchRuntime(t *testing.T) {
+	for _, s := range []struct {
+		public   ContainerState
+		internal runtime.ContainerState
+	}{
+		{StateUnknown, runtime.StateUnknown},
+		{StateRunning, runtime.StateRunning},
+		{StateStopped, runtime.StateStopped},
+		{StatePaused, runtime.StatePaused},
+		{StateNotFound, runtime.StateNotFound},
+	} {
+		if string(s.public) != string(s.internal) {
+			t.Errorf("state %q does not match runtime state %q", s.public, s.internal)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package alcatraz
+
+import (
+	"context"
+	"io"
+
+	"github.com/bolasblack/alcatraz/internal/util"
+)
+
+// LineFunc transforms one line of streamed command output (without its
+// trailing newline). Returning false drops the line.
+type LineFunc func(line string) (string, bool)
+
+// CommandRunner runs the host commands a project needs: the container
+// runtime, Mutagen, and under sudo the firewall tools. NewCommandRunner
+// returns the one used by default, which callers may wrap.
+type CommandRunner interface {
+	// Run executes a command and returns combined stdout/stderr.
+	Run(ctx context.Context, name string, args ...string) (output []byte, err error)
+
+	// RunQuiet executes a command without streaming, returning combined stdout/stderr.
+	RunQuiet(ctx context.Context, name string, args ...string) (output []byte, err error)
+
+	// RunStream executes a command, forwarding its stdout/stderr line by line
+	// to out while it runs (nil discards), each line passed through transform
+	// if non-nil. Returns the untransformed combined output.
+	RunStream(ctx context.Context, out io.Writer, transform LineFunc, name string, args ...string) (output []byte, err error)
+
+	// RunTo executes a command, writing its stdout/stderr straight to out
+	// without keeping a copy.
+	RunTo(ctx context.Context, out io.Writer, name string, args ...string) error
+
+	// RunWithInput executes a command with input on its stdin, without
+	// streaming, returning combined stdout/stderr.
+	RunWithInput(ctx context.Context, input string, name string, args ...string) (output []byte, err error)
+
+	// RunInDir executes a command in the specified directory, writing its
+	// stdout/stderr to out (nil discards).
+	RunInDir(ctx context.Context, out io.Writer, dir string, name string, args ...string) error
+
+	// RunAttached executes a command attached to this process's stdin,
+	// stdout and stderr, in dir (empty for the caller's) with env added to
+	// the inherited environment. Plugins run this way.
+	RunAttached(ctx context.Context, dir string, env []string, name string, args ...string) error
+
+	// SudoRun runs a command with sudo, connecting stdin/stdout/stderr.
+	SudoRun(ctx context.Context, name string, args ...string) error
+
+	// SudoRunQuiet runs a command with sudo without streaming, returning combined stdout/stderr.
+	SudoRunQuiet(ctx context.Context, name string, args ...string) (output []byte, err error)
+
+	// SudoRunScriptQuiet writes script to a temp file and executes it with sudo.
+	SudoRunScriptQuiet(ctx context.Context, script string) error
+
+	// Start starts a command in the background as opts describes, and
+	// returns without waiting for it.
+	Start(opts StartOptions, name string, args ...string) error
+}
+
+// StartOptions configures CommandRunner.Start.
+type StartOptions struct {
+	// Env is added to the inherited environment.
+	Env []string
+	// Dir is the working directory; empty for the caller's.
+	Dir string
+	// LogFile is a host file the command's stdout and stderr are appended
+	// to, created if missing; empty discards them.
+	LogFile string
+}
+
+// NewCommandRunner returns the CommandRunner projects use by default, which
+// runs commands on the host with the process's standard streams.
+func NewCommandRunner() CommandRunner {
+	return hostCommandRunner{util.NewCommandRunner()}
+}
+
+var (
+	_ CommandRunner      = hostCommandRunner{}
+	_ util.CommandRunner = commandRunnerAdapter{}
+)
+
+// hostCommandRunner presents a util.CommandRunner as a CommandRunner.
+type hostCommandRunner struct {
+	util.CommandRunner
+}
+
+func (r hostCommandRunner) RunStream(ctx context.Context, out io.Writer, transform LineFunc, name string, args ...string) ([]byte, error) {
+	return r.CommandRunner.RunStream(ctx, out, util.LineFunc(transform), name, args...)
+}
+
+func (r hostCommandRunner) Start(opts StartOptions, name string, args ...string) error {
+	return r.CommandRunner.Start(util.StartOptions(opts), name, args...)
+}
+
+// commandRunnerAdapter presents a CommandRunner as a util.CommandRunner.
+type commandRunnerAdapter struct {
+	CommandRunner
+}
+
+func (r commandRunnerAdapter) RunStream(ctx context.Context, out io.Writer, transform util.LineFunc, name string, args ...string) ([]byte, error) {
+	return r.CommandRunner.RunStream(ctx, out, LineFunc(transform), name, args...)
+}
+
+func (r commandRunnerAdapter) Start(opts util.StartOptions, name string, args ...string) error {
+	return r.CommandRunner.Start(StartOptions(opts), name, args...)
+}